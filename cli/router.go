@@ -0,0 +1,345 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vsc-eco/vsc-dex-mapping/services/router"
+)
+
+// Connection and signing options shared by the router commands
+var routerOpts struct {
+	vscNode   string
+	indexer   string
+	routerURL string
+	dexRouter string
+	username  string
+	key       string
+	keyFile   string
+	dryRun    bool
+}
+
+var routerCmd = &cobra.Command{
+	Use:   "router",
+	Short: "Quote and trade through the DEX router",
+	Long: `Quote, swap, deposit and withdraw through the DEX router, and look up orders.
+
+Trades are composed locally from indexed pool data and signed with the active
+key given by --key, --key-file or VSC_ACTIVE_KEY. With --dry-run nothing is
+signed or broadcast; the result's Simulation shows the contract calls that
+would have been sent.`,
+}
+
+var routerQuoteCmd = &cobra.Command{
+	Use:   "quote <from-asset> <to-asset> <amount>",
+	Short: "Quote a swap without submitting it",
+	Args:  cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		amount, err := parseAmount(args[2])
+		if err != nil {
+			return err
+		}
+		slippage, _ := cmd.Flags().GetUint64("slippage-bps")
+
+		// Quotes never sign, so no key is needed
+		svc := newRouterService(&dexPrinter{w: io.Discard})
+		quote, err := svc.Quote(cmd.Context(), router.SwapParams{
+			AssetIn:  args[0],
+			AssetOut: args[1],
+			AmountIn: amount,
+		})
+		if err != nil {
+			return err
+		}
+		return printJSON(map[string]interface{}{
+			"quote":           quote,
+			"minimumReceived": quote.MinimumReceived(slippage),
+		})
+	},
+}
+
+var routerSwapCmd = &cobra.Command{
+	Use:   "swap <from-asset> <to-asset> <amount>",
+	Short: "Swap one asset for another",
+	Args:  cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		amount, err := parseAmount(args[2])
+		if err != nil {
+			return err
+		}
+		minOut, _ := cmd.Flags().GetInt64("min-out")
+		slippage, _ := cmd.Flags().GetUint64("slippage-bps")
+		deadline, _ := cmd.Flags().GetDuration("deadline")
+		commitReveal, _ := cmd.Flags().GetBool("commit-reveal")
+
+		svc, sender, err := newSigningRouterService()
+		if err != nil {
+			return err
+		}
+		params := router.SwapParams{
+			Sender:       sender,
+			AssetIn:      args[0],
+			AssetOut:     args[1],
+			AmountIn:     amount,
+			MinAmountOut: minOut,
+			MaxSlippage:  slippage,
+			CommitReveal: commitReveal,
+		}
+		if deadline > 0 {
+			params.Deadline = time.Now().Add(deadline)
+		}
+
+		result, err := svc.ExecuteSwap(tradeContext(cmd), params)
+		return printResult(result, err)
+	},
+}
+
+var routerDepositCmd = &cobra.Command{
+	Use:   "deposit <from-asset> <to-asset> <amount>",
+	Short: "Add liquidity to a pool",
+	Args:  cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		amount, err := parseAmount(args[2])
+		if err != nil {
+			return err
+		}
+		pairAmount, _ := cmd.Flags().GetInt64("pair-amount")
+
+		svc, sender, err := newSigningRouterService()
+		if err != nil {
+			return err
+		}
+		result, err := svc.ExecuteDeposit(tradeContext(cmd), router.DepositParams{
+			Sender:    sender,
+			AssetIn:   args[0],
+			AssetOut:  args[1],
+			AmountIn:  amount,
+			AmountOut: pairAmount,
+		})
+		return printResult(result, err)
+	},
+}
+
+var routerWithdrawCmd = &cobra.Command{
+	Use:   "withdraw <asset> <pair-asset>",
+	Short: "Remove liquidity from a pool",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		lpAmount, _ := cmd.Flags().GetInt64("lp-amount")
+
+		svc, sender, err := newSigningRouterService()
+		if err != nil {
+			return err
+		}
+		result, err := svc.ExecuteWithdrawal(tradeContext(cmd), router.WithdrawalParams{
+			Sender:   sender,
+			AssetIn:  args[0],
+			AssetOut: args[1],
+			LpAmount: lpAmount,
+		})
+		return printResult(result, err)
+	},
+}
+
+var routerOrdersCmd = &cobra.Command{
+	Use:   "orders [order-id]",
+	Short: "Look up swap, TWAP and DCA orders on a running router",
+	Long: `Look up an order by ID, or list recurring (DCA) orders with --owner.
+
+Orders are tracked by the router service, so this queries the one at --router-url.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		owner, _ := cmd.Flags().GetString("owner")
+
+		var path string
+		switch {
+		case len(args) == 1:
+			path = "/api/v1/orders/" + url.PathEscape(args[0])
+		case owner != "":
+			path = "/api/v1/dca?owner=" + url.QueryEscape(owner)
+		default:
+			return fmt.Errorf("an order ID or --owner is required")
+		}
+		return getRouterJSON(cmd.Context(), path)
+	},
+}
+
+// newRouterService connects a router service to the indexer for pool data
+func newRouterService(executor router.DEXExecutor) *router.Service {
+	svc := router.NewService(router.VSCConfig{
+		Endpoint:          routerOpts.vscNode,
+		Key:               routerOpts.key,
+		Username:          routerOpts.username,
+		DexRouterContract: routerOpts.dexRouter,
+	}, executor)
+
+	if routerOpts.indexer != "" {
+		querier := router.NewIndexerPoolQuerier(routerOpts.indexer)
+		svc.SetPoolQuerier(querier)
+		svc.SetPositionQuerier(querier)
+	}
+	return svc
+}
+
+// newSigningRouterService loads the signing key, unless this is a dry run,
+// and returns a router service along with the account trades are sent from
+func newSigningRouterService() (*router.Service, string, error) {
+	if routerOpts.username == "" {
+		return nil, "", fmt.Errorf("--username or VSC_USERNAME is required")
+	}
+	if !routerOpts.dryRun {
+		if err := loadKey(); err != nil {
+			return nil, "", err
+		}
+	}
+
+	// Broadcasting goes through the SDK client once its dependencies build:
+	// vscdex.NewClient(vscdex.Config{Endpoint: routerOpts.vscNode, Username:
+	// routerOpts.username, ActiveKey: routerOpts.key, ...}). Until then the
+	// operations are only printed, as the router service does.
+	return newRouterService(&dexPrinter{w: os.Stderr}), routerOpts.username, nil
+}
+
+// tradeContext returns the context trades run under, simulated for --dry-run
+func tradeContext(cmd *cobra.Command) context.Context {
+	if routerOpts.dryRun {
+		return router.WithSimulation(cmd.Context())
+	}
+	return cmd.Context()
+}
+
+// loadKey resolves the active key from --key, --key-file or VSC_ACTIVE_KEY,
+// in that order
+func loadKey() error {
+	if routerOpts.key != "" {
+		return nil
+	}
+	if routerOpts.keyFile != "" {
+		data, err := os.ReadFile(routerOpts.keyFile)
+		if err != nil {
+			return fmt.Errorf("failed to read key file: %w", err)
+		}
+		routerOpts.key = strings.TrimSpace(string(data))
+	} else {
+		routerOpts.key = os.Getenv("VSC_ACTIVE_KEY")
+	}
+	if routerOpts.key == "" {
+		return fmt.Errorf("an active key is required: pass --key, --key-file or set VSC_ACTIVE_KEY, or use --dry-run")
+	}
+	return nil
+}
+
+// dexPrinter implements router.DEXExecutor by printing each contract call
+type dexPrinter struct {
+	w io.Writer
+}
+
+func (p *dexPrinter) ExecuteDexOperation(ctx context.Context, operationType string, payload string) error {
+	return p.ExecuteDexOperationWithIntents(ctx, operationType, payload, nil)
+}
+
+func (p *dexPrinter) ExecuteDexOperationWithIntents(ctx context.Context, operationType string, payload string, intents []router.Intent) error {
+	fmt.Fprintf(p.w, "Executing %s on %s with payload %s\n", operationType, routerOpts.dexRouter, payload)
+	for _, intent := range intents {
+		fmt.Fprintf(p.w, "  Intent %s with args %v\n", intent.Type, intent.Args)
+	}
+	return nil
+}
+
+func (p *dexPrinter) ExecuteDexSwap(ctx context.Context, amountOut int64, route []string, fee int64) error {
+	return nil
+}
+
+// parseAmount parses an amount in the asset's smallest unit
+func parseAmount(s string) (int64, error) {
+	amount, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || amount <= 0 {
+		return 0, fmt.Errorf("invalid amount %q: must be a positive integer", s)
+	}
+	return amount, nil
+}
+
+// printResult prints a trade's result, failing the command if it did not go
+// through
+func printResult(result *router.SwapResult, err error) error {
+	if err != nil {
+		return err
+	}
+	if err := printJSON(result); err != nil {
+		return err
+	}
+	if !result.Success {
+		return fmt.Errorf("%s", result.ErrorMessage)
+	}
+	return nil
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// getRouterJSON prints the JSON response of a GET against the router service
+func getRouterJSON(ctx context.Context, path string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(routerOpts.routerURL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach router: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("router returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return printJSON(v)
+}
+
+func init() {
+	flags := routerCmd.PersistentFlags()
+	flags.StringVar(&routerOpts.vscNode, "vsc-node", "http://localhost:4000", "VSC node GraphQL endpoint")
+	flags.StringVar(&routerOpts.indexer, "indexer-endpoint", "http://localhost:8081", "Indexer service HTTP endpoint")
+	flags.StringVar(&routerOpts.routerURL, "router-url", "http://localhost:8080", "Router service HTTP endpoint, for order lookups")
+	flags.StringVar(&routerOpts.dexRouter, "dex-router-contract", "", "DEX router contract ID")
+	flags.StringVar(&routerOpts.username, "username", os.Getenv("VSC_USERNAME"), "Account to trade from (default $VSC_USERNAME)")
+	flags.StringVar(&routerOpts.key, "key", "", "Active key to sign with; prefer --key-file or VSC_ACTIVE_KEY")
+	flags.StringVar(&routerOpts.keyFile, "key-file", "", "File containing the active key")
+	flags.BoolVar(&routerOpts.dryRun, "dry-run", false, "Show the contract calls that would be broadcast without signing or sending them")
+
+	routerQuoteCmd.Flags().Uint64("slippage-bps", 50, "Slippage tolerance for the minimum received, in basis points")
+
+	routerSwapCmd.Flags().Int64("min-out", 0, "Minimum amount to receive; 0 derives it from --slippage-bps")
+	routerSwapCmd.Flags().Uint64("slippage-bps", 50, "Slippage tolerance, in basis points")
+	routerSwapCmd.Flags().Duration("deadline", 0, "Give up if the swap is not submitted within this long (0 means no deadline)")
+	routerSwapCmd.Flags().Bool("commit-reveal", false, "Commit to a hash of the swap first and reveal it once the commitment is included, hiding it from front-runners")
+
+	routerDepositCmd.Flags().Int64("pair-amount", 0, "Amount of the pair asset to deposit alongside")
+
+	routerWithdrawCmd.Flags().Int64("lp-amount", 0, "LP tokens to burn; 0 withdraws the whole position")
+
+	routerOrdersCmd.Flags().String("owner", "", "List recurring (DCA) orders owned by this account")
+
+	routerCmd.AddCommand(routerQuoteCmd, routerSwapCmd, routerDepositCmd, routerWithdrawCmd, routerOrdersCmd)
+	rootCmd.AddCommand(routerCmd)
+}
@@ -0,0 +1,753 @@
+package main
+
+import (
+	sdk "dex-router/sdk"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Concentrated pools hold liquidity in positions over tick ranges instead of
+// across the whole price curve. A pool's price is asset1 per asset0 and tick
+// t is the price 1.0001^t; the pool tracks sqrt(price) in Q64.64. A
+// position's liquidity only trades while the pool's tick is in its range
+// [tick_lower, tick_upper), and the fees swaps pay accrue to the positions
+// in range as fee growth per unit of liquidity, in Q128.
+
+const (
+	minTick        = -443636 // Keeps sqrt prices within 2^-32..2^32
+	maxTick        = 443636
+	maxTickSpacing = 16384
+)
+
+var (
+	q64  = new(big.Int).Lsh(big.NewInt(1), 64)
+	q128 = new(big.Int).Lsh(big.NewInt(1), 128)
+	q256 = new(big.Int).Lsh(big.NewInt(1), 256)
+)
+
+// tickRatios[i] is 1.0001^(-2^i/2) in Q128, the sqrt price factor for bit i
+// of a tick
+var tickRatios = hexInts(
+	"fffcb933bd6fad37aa2d162d1a594001",
+	"fff97272373d413259a46990580e2139",
+	"fff2e50f5f656932ef12357cf3c7fdcb",
+	"ffe5caca7e10e4e61c3624eaa0941ccf",
+	"ffcb9843d60f6159c9db58835c926643",
+	"ff973b41fa98c081472e6896dfb254bf",
+	"ff2ea16466c96a3843ec78b326b52860",
+	"fe5dee046a99a2a811c461f1969c3052",
+	"fcbe86c7900a88aedcffc83b479aa3a3",
+	"f987a7253ac413176f2b074cf7815e53",
+	"f3392b0822b70005940c7a398e4b70f2",
+	"e7159475a2c29b7443b29c7fa6e889d8",
+	"d097f3bdfd2022b8845ad8f792aa5825",
+	"a9f746462d870fdf8a65dc1f90e061e4",
+	"70d869a156d2a1b890bb3df62baf32f6",
+	"31be135f97d08fd981231505542fcfa5",
+	"9aa508b5b7a84e1c677de54f3e99bc8",
+	"5d6af8dedb81196699c329225ee604",
+	"2216e584f5fa1ea926041bedfe97",
+)
+
+func hexInts(values ...string) []*big.Int {
+	ints := make([]*big.Int, len(values))
+	for i, value := range values {
+		ints[i], _ = new(big.Int).SetString(value, 16)
+	}
+	return ints
+}
+
+// concentratedSwap is a swap priced against a concentrated pool, written
+// once the whole route it is part of is priced
+type concentratedSwap struct {
+	sqrtPrice *big.Int
+	tick      int64
+	liquidity *big.Int
+	feeGrowth *big.Int // Global fee growth of the input asset after the swap
+	fee       uint64   // Paid to the positions in range
+	crossed   []tickCrossing
+}
+
+// tickCrossing is an initialized tick a swap crossed, with the global fee
+// growth when it did
+type tickCrossing struct {
+	tick       int64
+	feeGrowth0 *big.Int
+	feeGrowth1 *big.Int
+}
+
+// isConcentrated reports whether a pool holds range-bound positions
+func isConcentrated(poolId string) bool {
+	return getUint(poolKey(poolId, keyPoolTickSpacing)) > 0
+}
+
+// initConcentratedPool sets up a new pool's price and empty liquidity
+func initConcentratedPool(poolId string, tickSpacing uint64, initialTick int64) {
+	setUint(poolKey(poolId, keyPoolTickSpacing), tickSpacing)
+	setBig(poolKey(poolId, keyPoolSqrtPrice), sqrtPriceAtTick(initialTick))
+	setInt(poolKey(poolId, keyPoolTick), initialTick)
+	setUint(poolKey(poolId, keyPoolLiquidity), 0)
+	setUint(poolKey(poolId, keyPoolLiquidityTotal), 0)
+	setBig(poolKey(poolId, keyPoolFeeGrowth0), new(big.Int))
+	setBig(poolKey(poolId, keyPoolFeeGrowth1), new(big.Int))
+}
+
+// Mint a position over [tick_lower, tick_upper) in a concentrated pool with
+// as much liquidity as metadata amount0 and amount1 allow. Only the amounts
+// that liquidity needs are drawn. The position belongs to the sender.
+// Returns the position ID.
+func mintPosition(instruction DexInstruction) *string {
+	poolId := instruction.Metadata["pool_id"]
+	if !isConcentrated(poolId) {
+		return &[]string{"error", "concentrated pool not found"}[1]
+	}
+	if !poolHasPair(poolId, instruction.AssetIn, instruction.AssetOut) {
+		return &[]string{"error", "pool is for a different asset pair"}[1]
+	}
+	lower, upper, err := positionRange(poolId, instruction.Metadata)
+	if err != nil {
+		return err
+	}
+	max0, err := metadataAmount(instruction.Metadata, "amount0")
+	if err != nil {
+		return err
+	}
+	max1, err := metadataAmount(instruction.Metadata, "amount1")
+	if err != nil {
+		return err
+	}
+
+	sqrtPrice := getBig(poolKey(poolId, keyPoolSqrtPrice))
+	tick := getInt(poolKey(poolId, keyPoolTick))
+	liquidity := liquidityForAmounts(sqrtPrice, tick, lower, upper, max0, max1)
+	if liquidity.Sign() == 0 {
+		return &[]string{"error", "amounts give no liquidity in range"}[1]
+	}
+	total := new(big.Int).Add(liquidity, new(big.Int).SetUint64(getUint(poolKey(poolId, keyPoolLiquidityTotal))))
+	if total.Cmp(big.NewInt(math.MaxInt64)) > 0 {
+		return &[]string{"error", "pool liquidity too large"}[1]
+	}
+	amount0, amount1 := amountsForLiquidity(sqrtPrice, tick, lower, upper, liquidity, true)
+	contractAssert(amount0 <= max0 && amount1 <= max1)
+
+	positionId := strconv.FormatUint(getUint(keyNextPositionId)+1, 10)
+	setUint(keyNextPositionId, getUint(keyNextPositionId)+1)
+	owner := sdk.GetEnv().Sender.Address.String()
+	delta := liquidity.Int64()
+
+	updateTick(poolId, lower, delta, false, tick)
+	updateTick(poolId, upper, delta, true, tick)
+	if lower <= tick && tick < upper {
+		setUint(poolKey(poolId, keyPoolLiquidity), getUint(poolKey(poolId, keyPoolLiquidity))+uint64(delta))
+	}
+	setUint(poolKey(poolId, keyPoolLiquidityTotal), total.Uint64())
+
+	growth0, growth1 := feeGrowthInside(poolId, lower, upper, tick)
+	setStr(positionKey(positionId, keyPositionOwner), owner)
+	setStr(positionKey(positionId, keyPositionPool), poolId)
+	setInt(positionKey(positionId, keyPositionLower), lower)
+	setInt(positionKey(positionId, keyPositionUpper), upper)
+	setUint(positionKey(positionId, keyPositionLiquidity), uint64(delta))
+	setBig(positionKey(positionId, keyPositionGrowth0), growth0)
+	setBig(positionKey(positionId, keyPositionGrowth1), growth1)
+
+	if amount0 > 0 {
+		drawAsset(int64(amount0), getPoolAsset0(poolId))
+		setPoolReserve0(poolId, getPoolReserve0(poolId)+amount0)
+	}
+	if amount1 > 0 {
+		drawAsset(int64(amount1), getPoolAsset1(poolId))
+		setPoolReserve1(poolId, getPoolReserve1(poolId)+amount1)
+	}
+
+	emitEvent("position_minted",
+		"position_id", jsonStr(positionId),
+		"pool_id", jsonStr(poolId),
+		"owner", jsonStr(owner),
+		"tick_lower", jsonInt(lower),
+		"tick_upper", jsonInt(upper),
+		"liquidity", jsonUint(uint64(delta)),
+		"amount0", jsonUint(amount0),
+		"amount1", jsonUint(amount1),
+		"tick", jsonInt(tick),
+		"sqrt_price", jsonStr(sqrtPrice.String()),
+		"pool_liquidity", jsonUint(getUint(poolKey(poolId, keyPoolLiquidity))),
+	)
+	return &positionId
+}
+
+// Burn metadata liquidity from a position, or all of it if none is given.
+// The assets it held are credited to the position with its fees, to be
+// taken with collect_position. Only the owner may burn.
+func burnPosition(instruction DexInstruction) *string {
+	positionId, poolId, err := instructionPosition(instruction)
+	if err != nil {
+		return err
+	}
+	liquidity := getUint(positionKey(positionId, keyPositionLiquidity))
+	burn := liquidity
+	if instruction.Metadata["liquidity"] != "" {
+		burn, err = metadataAmount(instruction.Metadata, "liquidity")
+		if err != nil {
+			return err
+		}
+		if burn > liquidity {
+			return &[]string{"error", "liquidity exceeds position"}[1]
+		}
+	}
+	if burn == 0 {
+		return &[]string{"error", "no liquidity to burn"}[1]
+	}
+
+	lower := getInt(positionKey(positionId, keyPositionLower))
+	upper := getInt(positionKey(positionId, keyPositionUpper))
+	sqrtPrice := getBig(poolKey(poolId, keyPoolSqrtPrice))
+	tick := getInt(poolKey(poolId, keyPoolTick))
+
+	// Fees are accrued on the liquidity held until now, before the range's
+	// ticks may be cleared
+	accruePositionFees(positionId, poolId, lower, upper, tick)
+	amount0, amount1 := amountsForLiquidity(sqrtPrice, tick, lower, upper, new(big.Int).SetUint64(burn), false)
+
+	updateTick(poolId, lower, -int64(burn), false, tick)
+	updateTick(poolId, upper, -int64(burn), true, tick)
+	if lower <= tick && tick < upper {
+		setUint(poolKey(poolId, keyPoolLiquidity), getUint(poolKey(poolId, keyPoolLiquidity))-burn)
+	}
+	setUint(poolKey(poolId, keyPoolLiquidityTotal), getUint(poolKey(poolId, keyPoolLiquidityTotal))-burn)
+
+	setUint(positionKey(positionId, keyPositionLiquidity), liquidity-burn)
+	setUint(positionKey(positionId, keyPositionOwed0), getUint(positionKey(positionId, keyPositionOwed0))+amount0)
+	setUint(positionKey(positionId, keyPositionOwed1), getUint(positionKey(positionId, keyPositionOwed1))+amount1)
+
+	emitEvent("position_burned",
+		"position_id", jsonStr(positionId),
+		"pool_id", jsonStr(poolId),
+		"owner", jsonStr(getStr(positionKey(positionId, keyPositionOwner))),
+		"tick_lower", jsonInt(lower),
+		"tick_upper", jsonInt(upper),
+		"liquidity", jsonUint(burn),
+		"amount0", jsonUint(amount0),
+		"amount1", jsonUint(amount1),
+		"tick", jsonInt(tick),
+		"sqrt_price", jsonStr(sqrtPrice.String()),
+		"pool_liquidity", jsonUint(getUint(poolKey(poolId, keyPoolLiquidity))),
+	)
+	return nil
+}
+
+// Pay a position's fees and burned liquidity to the instruction's
+// recipient. A position with no liquidity left is removed once collected.
+// Only the owner may collect.
+func collectPosition(instruction DexInstruction) *string {
+	positionId, poolId, err := instructionPosition(instruction)
+	if err != nil {
+		return err
+	}
+	owner := getStr(positionKey(positionId, keyPositionOwner))
+	liquidity := getUint(positionKey(positionId, keyPositionLiquidity))
+	if liquidity > 0 {
+		lower := getInt(positionKey(positionId, keyPositionLower))
+		upper := getInt(positionKey(positionId, keyPositionUpper))
+		accruePositionFees(positionId, poolId, lower, upper, getInt(poolKey(poolId, keyPoolTick)))
+	}
+
+	owed0 := getUint(positionKey(positionId, keyPositionOwed0))
+	owed1 := getUint(positionKey(positionId, keyPositionOwed1))
+	if liquidity == 0 {
+		deletePosition(positionId)
+	} else {
+		setUint(positionKey(positionId, keyPositionOwed0), 0)
+		setUint(positionKey(positionId, keyPositionOwed1), 0)
+	}
+
+	if owed0 > 0 {
+		setPoolReserve0(poolId, getPoolReserve0(poolId)-owed0)
+		transferAsset(instruction.Recipient, int64(owed0), getPoolAsset0(poolId))
+	}
+	if owed1 > 0 {
+		setPoolReserve1(poolId, getPoolReserve1(poolId)-owed1)
+		transferAsset(instruction.Recipient, int64(owed1), getPoolAsset1(poolId))
+	}
+
+	emitEvent("position_collected",
+		"position_id", jsonStr(positionId),
+		"pool_id", jsonStr(poolId),
+		"owner", jsonStr(owner),
+		"recipient", jsonStr(instruction.Recipient),
+		"amount0", jsonUint(owed0),
+		"amount1", jsonUint(owed1),
+	)
+	return nil
+}
+
+// instructionPosition returns the position named by metadata.position_id
+// and its pool, checking the sender owns it and the instruction names the
+// pool's asset pair
+func instructionPosition(instruction DexInstruction) (string, string, *string) {
+	positionId := instruction.Metadata["position_id"]
+	if positionId == "" {
+		return "", "", &[]string{"error", "position_id required in metadata"}[1]
+	}
+	owner := getStr(positionKey(positionId, keyPositionOwner))
+	if owner == "" {
+		return "", "", &[]string{"error", "position not found"}[1]
+	}
+	if sdk.GetEnv().Sender.Address.String() != owner {
+		return "", "", &[]string{"error", "only the owner can manage a position"}[1]
+	}
+	poolId := getStr(positionKey(positionId, keyPositionPool))
+	if !poolHasPair(poolId, instruction.AssetIn, instruction.AssetOut) {
+		return "", "", &[]string{"error", "pool is for a different asset pair"}[1]
+	}
+	return positionId, poolId, nil
+}
+
+// deletePosition removes a position's state
+func deletePosition(positionId string) {
+	for _, suffix := range []string{
+		keyPositionOwner, keyPositionPool, keyPositionLower, keyPositionUpper,
+		keyPositionLiquidity, keyPositionGrowth0, keyPositionGrowth1,
+		keyPositionOwed0, keyPositionOwed1,
+	} {
+		sdk.StateDeleteObject(positionKey(positionId, suffix))
+	}
+}
+
+// poolHasPair reports whether a pool trades assetA against assetB, in
+// either order
+func poolHasPair(poolId, assetA, assetB string) bool {
+	asset0, asset1 := getPoolAsset0(poolId), getPoolAsset1(poolId)
+	return asset0 != "" && ((asset0 == assetA && asset1 == assetB) || (asset0 == assetB && asset1 == assetA))
+}
+
+// positionRange reads a position's tick range from metadata; both ends must
+// be on the pool's tick spacing
+func positionRange(poolId string, metadata map[string]string) (int64, int64, *string) {
+	lower, err := strconv.ParseInt(metadata["tick_lower"], 10, 64)
+	if err != nil {
+		return 0, 0, &[]string{"error", "tick_lower required in metadata"}[1]
+	}
+	upper, err := strconv.ParseInt(metadata["tick_upper"], 10, 64)
+	if err != nil {
+		return 0, 0, &[]string{"error", "tick_upper required in metadata"}[1]
+	}
+	if lower >= upper {
+		return 0, 0, &[]string{"error", "tick_lower must be below tick_upper"}[1]
+	}
+	if lower < minTick || upper > maxTick {
+		return 0, 0, &[]string{"error", "tick range out of bounds"}[1]
+	}
+	spacing := int64(getUint(poolKey(poolId, keyPoolTickSpacing)))
+	if lower%spacing != 0 || upper%spacing != 0 {
+		return 0, 0, &[]string{"error", "ticks must be multiples of the pool's tick spacing"}[1]
+	}
+	return lower, upper, nil
+}
+
+// metadataAmount reads a whole, non-negative amount from metadata
+func metadataAmount(metadata map[string]string, key string) (uint64, *string) {
+	value, ok := metadata[key]
+	if !ok {
+		return 0, &[]string{"error", key + " required in metadata"}[1]
+	}
+	amount, err := strconv.ParseUint(value, 10, 63)
+	if err != nil {
+		return 0, &[]string{"error", key + " must be a whole number"}[1]
+	}
+	return amount, nil
+}
+
+// updateTick adds liquidityDelta of a position's liquidity at one end of its
+// range, initializing the tick or clearing it when no position uses it
+func updateTick(poolId string, tick, liquidityDelta int64, upper bool, currentTick int64) {
+	gross := getUint(tickKey(poolId, tick, keyTickGross))
+	if gross == 0 {
+		// All fee growth so far is taken to be below the current tick
+		if tick <= currentTick {
+			setBig(tickKey(poolId, tick, keyTickOutside0), getBig(poolKey(poolId, keyPoolFeeGrowth0)))
+			setBig(tickKey(poolId, tick, keyTickOutside1), getBig(poolKey(poolId, keyPoolFeeGrowth1)))
+		}
+		setTicks(poolId, insertTick(getTicks(poolId), tick))
+	}
+
+	gross = uint64(int64(gross) + liquidityDelta)
+	if gross == 0 {
+		for _, suffix := range []string{keyTickGross, keyTickNet, keyTickOutside0, keyTickOutside1} {
+			sdk.StateDeleteObject(tickKey(poolId, tick, suffix))
+		}
+		setTicks(poolId, removeTick(getTicks(poolId), tick))
+		return
+	}
+
+	// Crossing a range's lower tick upwards brings its liquidity into range,
+	// and crossing its upper tick takes it out
+	net := getInt(tickKey(poolId, tick, keyTickNet))
+	if upper {
+		net -= liquidityDelta
+	} else {
+		net += liquidityDelta
+	}
+	setUint(tickKey(poolId, tick, keyTickGross), gross)
+	setInt(tickKey(poolId, tick, keyTickNet), net)
+}
+
+// feeGrowthInside returns the fee growth of each asset inside a tick range
+func feeGrowthInside(poolId string, lower, upper, currentTick int64) (*big.Int, *big.Int) {
+	inside := func(globalKey, outsideKey string) *big.Int {
+		global := getBig(poolKey(poolId, globalKey))
+		below := getBig(tickKey(poolId, lower, outsideKey))
+		if currentTick < lower {
+			below.Sub(global, below)
+		}
+		above := getBig(tickKey(poolId, upper, outsideKey))
+		if currentTick >= upper {
+			above.Sub(global, above)
+		}
+		return global.Sub(global, below).Sub(global, above)
+	}
+	return inside(keyPoolFeeGrowth0, keyTickOutside0), inside(keyPoolFeeGrowth1, keyTickOutside1)
+}
+
+// accruePositionFees credits a position with the fees its liquidity earned
+// since they were last accrued
+func accruePositionFees(positionId, poolId string, lower, upper, currentTick int64) {
+	liquidity := new(big.Int).SetUint64(getUint(positionKey(positionId, keyPositionLiquidity)))
+	growth0, growth1 := feeGrowthInside(poolId, lower, upper, currentTick)
+	for _, accrual := range []struct {
+		growth             *big.Int
+		growthKey, owedKey string
+	}{
+		{growth0, keyPositionGrowth0, keyPositionOwed0},
+		{growth1, keyPositionGrowth1, keyPositionOwed1},
+	} {
+		earned := new(big.Int).Sub(accrual.growth, getBig(positionKey(positionId, accrual.growthKey)))
+		earned.Mul(earned, liquidity).Rsh(earned, 128)
+		owed := getUint(positionKey(positionId, accrual.owedKey))
+		setUint(positionKey(positionId, accrual.owedKey), owed+earned.Uint64())
+		setBig(positionKey(positionId, accrual.growthKey), accrual.growth)
+	}
+}
+
+// priceConcentrated prices a swap of amountIn into a concentrated pool,
+// stepping the price from one initialized tick to the next. The whole input
+// must trade.
+func priceConcentrated(poolId string, input0 bool, amountIn uint64) (uint64, *concentratedSwap, *string) {
+	feeBps := getPoolFee(poolId)
+	growth0 := getBig(poolKey(poolId, keyPoolFeeGrowth0))
+	growth1 := getBig(poolKey(poolId, keyPoolFeeGrowth1))
+	swap := &concentratedSwap{
+		sqrtPrice: getBig(poolKey(poolId, keyPoolSqrtPrice)),
+		tick:      getInt(poolKey(poolId, keyPoolTick)),
+		liquidity: new(big.Int).SetUint64(getUint(poolKey(poolId, keyPoolLiquidity))),
+		feeGrowth: growth1,
+	}
+	if input0 {
+		swap.feeGrowth = growth0
+	}
+	ticks := getTicks(poolId)
+
+	remaining := new(big.Int).SetUint64(amountIn)
+	amountOut := new(big.Int)
+	fee := new(big.Int)
+	for remaining.Sign() > 0 {
+		next, initialized := nextInitializedTick(ticks, swap.tick, input0)
+		target := sqrtPriceAtTick(next)
+
+		// With no liquidity in range the price moves to the next tick for free
+		sqrtNext, stepIn, stepOut, stepFee := target, new(big.Int), new(big.Int), new(big.Int)
+		if swap.liquidity.Sign() > 0 {
+			sqrtNext, stepIn, stepOut, stepFee = swapStep(swap.sqrtPrice, target, swap.liquidity, remaining, feeBps, input0)
+			growth := new(big.Int).Lsh(stepFee, 128)
+			swap.feeGrowth.Add(swap.feeGrowth, growth.Quo(growth, swap.liquidity))
+		}
+		remaining.Sub(remaining, stepIn).Sub(remaining, stepFee)
+		contractAssert(remaining.Sign() >= 0)
+		amountOut.Add(amountOut, stepOut)
+		fee.Add(fee, stepFee)
+		swap.sqrtPrice = sqrtNext
+
+		if sqrtNext.Cmp(target) != 0 {
+			swap.tick = tickAtSqrtPrice(sqrtNext)
+			continue
+		}
+		if !initialized {
+			if remaining.Sign() > 0 {
+				return 0, nil, &[]string{"error", "insufficient liquidity in concentrated pool"}[1]
+			}
+			swap.tick = tickAtSqrtPrice(sqrtNext)
+			break
+		}
+
+		swap.crossed = append(swap.crossed, tickCrossing{
+			tick:       next,
+			feeGrowth0: new(big.Int).Set(growth0),
+			feeGrowth1: new(big.Int).Set(growth1),
+		})
+		net := big.NewInt(getInt(tickKey(poolId, next, keyTickNet)))
+		if input0 {
+			swap.liquidity.Sub(swap.liquidity, net)
+			swap.tick = next - 1
+		} else {
+			swap.liquidity.Add(swap.liquidity, net)
+			swap.tick = next
+		}
+	}
+
+	if !amountOut.IsUint64() {
+		return 0, nil, &[]string{"error", "insufficient liquidity in concentrated pool"}[1]
+	}
+	swap.fee = fee.Uint64()
+	return amountOut.Uint64(), swap, nil
+}
+
+// swapStep trades as much of remaining as takes the price from sqrtPrice
+// towards target, returning where the price stops, the input and output
+// and the fee on the input
+func swapStep(sqrtPrice, target, liquidity, remaining *big.Int, feeBps uint64, input0 bool) (*big.Int, *big.Int, *big.Int, *big.Int) {
+	lessFee := new(big.Int).Mul(remaining, new(big.Int).SetUint64(10000-feeBps))
+	lessFee.Quo(lessFee, big.NewInt(10000))
+
+	var sqrtNext, amountIn, amountOut, fee *big.Int
+	if input0 {
+		amountIn = amount0Delta(target, sqrtPrice, liquidity, true)
+	} else {
+		amountIn = amount1Delta(sqrtPrice, target, liquidity, true)
+	}
+	if lessFee.Cmp(amountIn) >= 0 {
+		sqrtNext = target
+		fee = divUp(new(big.Int).Mul(amountIn, new(big.Int).SetUint64(feeBps)), new(big.Int).SetUint64(10000-feeBps))
+		if left := new(big.Int).Sub(remaining, amountIn); fee.Cmp(left) > 0 {
+			fee = left
+		}
+	} else {
+		sqrtNext = nextSqrtPriceFromInput(sqrtPrice, liquidity, lessFee, input0)
+		if input0 {
+			amountIn = amount0Delta(sqrtNext, sqrtPrice, liquidity, true)
+		} else {
+			amountIn = amount1Delta(sqrtPrice, sqrtNext, liquidity, true)
+		}
+		// What the price cannot move for is kept as fee
+		fee = new(big.Int).Sub(remaining, amountIn)
+	}
+
+	if input0 {
+		amountOut = amount1Delta(sqrtNext, sqrtPrice, liquidity, false)
+	} else {
+		amountOut = amount0Delta(sqrtPrice, sqrtNext, liquidity, false)
+	}
+	return sqrtNext, amountIn, amountOut, fee
+}
+
+// applyConcentrated writes a priced swap's price, liquidity, fee growth and
+// crossed ticks to its pool
+func applyConcentrated(poolId string, input0 bool, swap *concentratedSwap) {
+	setBig(poolKey(poolId, keyPoolSqrtPrice), swap.sqrtPrice)
+	setInt(poolKey(poolId, keyPoolTick), swap.tick)
+	setUint(poolKey(poolId, keyPoolLiquidity), swap.liquidity.Uint64())
+	if input0 {
+		setBig(poolKey(poolId, keyPoolFeeGrowth0), swap.feeGrowth)
+	} else {
+		setBig(poolKey(poolId, keyPoolFeeGrowth1), swap.feeGrowth)
+	}
+
+	// Fee growth outside a crossed tick flips to the other side of it
+	for _, crossing := range swap.crossed {
+		outside0 := tickKey(poolId, crossing.tick, keyTickOutside0)
+		outside1 := tickKey(poolId, crossing.tick, keyTickOutside1)
+		setBig(outside0, new(big.Int).Sub(crossing.feeGrowth0, getBig(outside0)))
+		setBig(outside1, new(big.Int).Sub(crossing.feeGrowth1, getBig(outside1)))
+	}
+}
+
+// nextInitializedTick returns the next initialized tick a swap reaches from
+// tick: at or below it when the price falls, above it when the price rises.
+// Without one it returns the bound of the tick range, uninitialized.
+func nextInitializedTick(ticks []int64, tick int64, falling bool) (int64, bool) {
+	if falling {
+		for i := len(ticks) - 1; i >= 0; i-- {
+			if ticks[i] <= tick {
+				return ticks[i], true
+			}
+		}
+		return minTick, false
+	}
+	for _, t := range ticks {
+		if t > tick {
+			return t, true
+		}
+	}
+	return maxTick, false
+}
+
+func getTicks(poolId string) []int64 {
+	list := getStr(poolKey(poolId, keyPoolTicks))
+	if list == "" {
+		return nil
+	}
+	fields := strings.Split(list, ",")
+	ticks := make([]int64, len(fields))
+	for i, field := range fields {
+		ticks[i], _ = strconv.ParseInt(field, 10, 64)
+	}
+	return ticks
+}
+
+func setTicks(poolId string, ticks []int64) {
+	fields := make([]string, len(ticks))
+	for i, tick := range ticks {
+		fields[i] = strconv.FormatInt(tick, 10)
+	}
+	setStr(poolKey(poolId, keyPoolTicks), strings.Join(fields, ","))
+}
+
+// insertTick adds a tick to an ascending list
+func insertTick(ticks []int64, tick int64) []int64 {
+	i := 0
+	for i < len(ticks) && ticks[i] < tick {
+		i++
+	}
+	ticks = append(ticks, 0)
+	copy(ticks[i+1:], ticks[i:])
+	ticks[i] = tick
+	return ticks
+}
+
+// removeTick drops a tick from an ascending list
+func removeTick(ticks []int64, tick int64) []int64 {
+	for i, t := range ticks {
+		if t == tick {
+			return append(ticks[:i], ticks[i+1:]...)
+		}
+	}
+	return ticks
+}
+
+// sqrtPriceAtTick returns sqrt(1.0001^tick) in Q64.64, rounded up
+func sqrtPriceAtTick(tick int64) *big.Int {
+	abs := tick
+	if abs < 0 {
+		abs = -abs
+	}
+	ratio := new(big.Int).Set(q128)
+	for i, factor := range tickRatios {
+		if abs&(1<<uint(i)) != 0 {
+			ratio.Mul(ratio, factor).Rsh(ratio, 128)
+		}
+	}
+	if tick > 0 {
+		ratio.Quo(q256, ratio)
+	}
+	return divUp(ratio, q64)
+}
+
+// tickAtSqrtPrice returns the highest tick whose sqrt price is at most
+// sqrtPrice
+func tickAtSqrtPrice(sqrtPrice *big.Int) int64 {
+	lo, hi := int64(minTick), int64(maxTick)
+	for lo < hi {
+		mid := lo + (hi-lo+1)/2
+		if sqrtPriceAtTick(mid).Cmp(sqrtPrice) <= 0 {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return lo
+}
+
+// amount0Delta is the asset0 that liquidity holds between sqrt prices a < b
+func amount0Delta(a, b, liquidity *big.Int, roundUp bool) *big.Int {
+	numerator := new(big.Int).Mul(liquidity, q64)
+	numerator.Mul(numerator, new(big.Int).Sub(b, a))
+	if roundUp {
+		return divUp(divUp(numerator, b), a)
+	}
+	numerator.Quo(numerator, b)
+	return numerator.Quo(numerator, a)
+}
+
+// amount1Delta is the asset1 that liquidity holds between sqrt prices a < b
+func amount1Delta(a, b, liquidity *big.Int, roundUp bool) *big.Int {
+	numerator := new(big.Int).Mul(liquidity, new(big.Int).Sub(b, a))
+	if roundUp {
+		return divUp(numerator, q64)
+	}
+	return numerator.Quo(numerator, q64)
+}
+
+// nextSqrtPriceFromInput is where amountIn moves the price, rounded so the
+// pool never gives more than the input pays for
+func nextSqrtPriceFromInput(sqrtPrice, liquidity, amountIn *big.Int, input0 bool) *big.Int {
+	if input0 {
+		// Asset0 in lowers the price: L*P / (L + amountIn*P)
+		scaled := new(big.Int).Mul(liquidity, q64)
+		denominator := new(big.Int).Mul(amountIn, sqrtPrice)
+		denominator.Add(denominator, scaled)
+		return divUp(scaled.Mul(scaled, sqrtPrice), denominator)
+	}
+	// Asset1 in raises the price: P + amountIn/L
+	step := new(big.Int).Mul(amountIn, q64)
+	step.Quo(step, liquidity)
+	return step.Add(step, sqrtPrice)
+}
+
+// liquidityForAmounts is the most liquidity amount0 and amount1 can fund
+// over [lower, upper) at the pool's current price
+func liquidityForAmounts(sqrtPrice *big.Int, tick, lower, upper int64, amount0, amount1 uint64) *big.Int {
+	a, b := sqrtPriceAtTick(lower), sqrtPriceAtTick(upper)
+	from0 := func(a, b *big.Int) *big.Int {
+		l := new(big.Int).Mul(new(big.Int).SetUint64(amount0), a)
+		l.Mul(l, b).Quo(l, q64)
+		return l.Quo(l, new(big.Int).Sub(b, a))
+	}
+	from1 := func(a, b *big.Int) *big.Int {
+		l := new(big.Int).Mul(new(big.Int).SetUint64(amount1), q64)
+		return l.Quo(l, new(big.Int).Sub(b, a))
+	}
+
+	switch {
+	case tick < lower:
+		return from0(a, b)
+	case tick >= upper:
+		return from1(a, b)
+	case sqrtPrice.Cmp(a) == 0:
+		// At the range's lower edge only asset0 is needed
+		return from0(sqrtPrice, b)
+	default:
+		l0, l1 := from0(sqrtPrice, b), from1(a, sqrtPrice)
+		if l0.Cmp(l1) < 0 {
+			return l0
+		}
+		return l1
+	}
+}
+
+// amountsForLiquidity is what liquidity over [lower, upper) holds of each
+// asset at the pool's current price
+func amountsForLiquidity(sqrtPrice *big.Int, tick, lower, upper int64, liquidity *big.Int, roundUp bool) (uint64, uint64) {
+	a, b := sqrtPriceAtTick(lower), sqrtPriceAtTick(upper)
+	amount0, amount1 := new(big.Int), new(big.Int)
+	switch {
+	case tick < lower:
+		amount0 = amount0Delta(a, b, liquidity, roundUp)
+	case tick >= upper:
+		amount1 = amount1Delta(a, b, liquidity, roundUp)
+	default:
+		amount0 = amount0Delta(sqrtPrice, b, liquidity, roundUp)
+		amount1 = amount1Delta(a, sqrtPrice, liquidity, roundUp)
+	}
+	contractAssert(amount0.IsUint64() && amount1.IsUint64())
+	return amount0.Uint64(), amount1.Uint64()
+}
+
+// divUp returns ceil(a / b) for positive a and b
+func divUp(a, b *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(a, b, new(big.Int))
+	if r.Sign() > 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	return q
+}
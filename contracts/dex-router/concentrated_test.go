@@ -0,0 +1,126 @@
+package main
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestTickMath(t *testing.T) {
+	t.Run("sqrt price at tick", func(t *testing.T) {
+		if got := sqrtPriceAtTick(0); got.Cmp(q64) != 0 {
+			t.Errorf("sqrtPriceAtTick(0) = %v, want 2^64", got)
+		}
+
+		prev := sqrtPriceAtTick(minTick)
+		for _, tick := range []int64{-200000, -60, -1, 1, 60, 13860, 200000, maxTick} {
+			got := sqrtPriceAtTick(tick)
+			if got.Cmp(prev) <= 0 {
+				t.Errorf("sqrtPriceAtTick(%d) is not above the tick before it", tick)
+			}
+			prev = got
+
+			price, _ := new(big.Float).Quo(new(big.Float).SetInt(got), new(big.Float).SetInt(q64)).Float64()
+			want := math.Pow(1.0001, float64(tick)/2)
+			if math.Abs(price/want-1) > 1e-9 {
+				t.Errorf("sqrtPriceAtTick(%d) = %v, want %v", tick, price, want)
+			}
+		}
+	})
+
+	t.Run("tick at sqrt price", func(t *testing.T) {
+		for _, tick := range []int64{minTick, -13860, -1, 0, 1, 13860, maxTick} {
+			price := sqrtPriceAtTick(tick)
+			if got := tickAtSqrtPrice(price); got != tick {
+				t.Errorf("tickAtSqrtPrice(sqrtPriceAtTick(%d)) = %d", tick, got)
+			}
+			// Prices between ticks round down to the tick below
+			if tick < maxTick {
+				between := new(big.Int).Add(price, big.NewInt(1))
+				if got := tickAtSqrtPrice(between); got != tick {
+					t.Errorf("tickAtSqrtPrice just above tick %d = %d", tick, got)
+				}
+			}
+		}
+	})
+}
+
+func TestConcentratedLiquidityMath(t *testing.T) {
+	t.Run("amounts round in the pool's favour", func(t *testing.T) {
+		a, b := sqrtPriceAtTick(-600), sqrtPriceAtTick(600)
+		liquidity := big.NewInt(1000000)
+
+		up, down := amount0Delta(a, b, liquidity, true), amount0Delta(a, b, liquidity, false)
+		if new(big.Int).Sub(up, down).Cmp(big.NewInt(1)) > 0 || up.Cmp(down) < 0 {
+			t.Errorf("amount0Delta rounded %v up and %v down", up, down)
+		}
+		up, down = amount1Delta(a, b, liquidity, true), amount1Delta(a, b, liquidity, false)
+		if new(big.Int).Sub(up, down).Cmp(big.NewInt(1)) > 0 || up.Cmp(down) < 0 {
+			t.Errorf("amount1Delta rounded %v up and %v down", up, down)
+		}
+	})
+
+	t.Run("liquidity for amounts", func(t *testing.T) {
+		// At price 1 a symmetric range takes equal amounts of each asset
+		liquidity := liquidityForAmounts(q64, 0, -600, 600, 1000000, 1000000)
+		amount0, amount1 := amountsForLiquidity(q64, 0, -600, 600, liquidity, true)
+		if amount0 != amount1 || amount0 > 1000000 || amount0 < 999990 {
+			t.Errorf("amountsForLiquidity = %v, %v, want about 1000000 each", amount0, amount1)
+		}
+
+		// Out of range positions hold only one asset
+		if amount0, amount1 := amountsForLiquidity(q64, 0, 60, 600, liquidity, true); amount0 == 0 || amount1 != 0 {
+			t.Errorf("range above the price holds %v, %v, want asset0 only", amount0, amount1)
+		}
+		if amount0, amount1 := amountsForLiquidity(q64, 0, -600, -60, liquidity, true); amount0 != 0 || amount1 == 0 {
+			t.Errorf("range below the price holds %v, %v, want asset1 only", amount0, amount1)
+		}
+	})
+
+	t.Run("next sqrt price from input", func(t *testing.T) {
+		liquidity := big.NewInt(1000000)
+		if next := nextSqrtPriceFromInput(q64, liquidity, big.NewInt(1000), true); next.Cmp(q64) >= 0 {
+			t.Errorf("asset0 in should lower the price, got %v", next)
+		}
+		if next := nextSqrtPriceFromInput(q64, liquidity, big.NewInt(1000), false); next.Cmp(q64) <= 0 {
+			t.Errorf("asset1 in should raise the price, got %v", next)
+		}
+	})
+}
+
+func TestTickList(t *testing.T) {
+	var ticks []int64
+	for _, tick := range []int64{60, -120, 0, 600} {
+		ticks = insertTick(ticks, tick)
+	}
+	if want := []int64{-120, 0, 60, 600}; !equalTicks(ticks, want) {
+		t.Errorf("insertTick = %v, want %v", ticks, want)
+	}
+
+	if tick, ok := nextInitializedTick(ticks, 30, true); tick != 0 || !ok {
+		t.Errorf("next tick down from 30 = %v, %v", tick, ok)
+	}
+	if tick, ok := nextInitializedTick(ticks, 60, false); tick != 600 || !ok {
+		t.Errorf("next tick up from 60 = %v, %v", tick, ok)
+	}
+	if tick, ok := nextInitializedTick(ticks, -121, true); tick != minTick || ok {
+		t.Errorf("next tick down from -121 = %v, %v", tick, ok)
+	}
+
+	ticks = removeTick(ticks, 0)
+	if want := []int64{-120, 60, 600}; !equalTicks(ticks, want) {
+		t.Errorf("removeTick = %v, want %v", ticks, want)
+	}
+}
+
+func equalTicks(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
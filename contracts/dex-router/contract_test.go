@@ -4,6 +4,7 @@ import (
 	_ "embed"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"strconv"
 	"testing"
 
@@ -273,8 +274,8 @@ func TestDirectSwap(t *testing.T) {
 			"asset_in": "HBD",
 			"asset_out": "HIVE",
 			"recipient": "hive:bob",
-			"min_amount_out": 47500,
-			"slippage_bps": 50
+			"amount_in": 100000,
+			"min_amount_out": 47500
 		}`)),
 		RcLimit: 10000,
 		Intents: intents,
@@ -301,14 +302,409 @@ func TestDirectSwap(t *testing.T) {
 	reserve0 := ct.StateGet(contractId, "pool/1/reserve0") // HBD
 	reserve1 := ct.StateGet(contractId, "pool/1/reserve1") // HIVE
 
-	// Input: 100000 HBD, expected output: ~47619 HIVE (after 0.08% fee)
-	// New reserves: HBD: 2000000 + 99920 = 2099920, HIVE: 1000000 - 47619 = 952381
+	// Input: 100000 HBD, 99920 after the 0.08% fee, for 47582 HIVE
+	// New reserves: HBD: 2000000 + 99920 = 2099920, HIVE: 1000000 - 47582 = 952418
 	assert.Equal(t, `"2099920"`, reserve0)
-	assert.Equal(t, `"952381"`, reserve1)
+	assert.Equal(t, `"952418"`, reserve1)
 
 	fmt.Println("Return value:", result.Ret)
 }
 
+func TestSwapFees(t *testing.T) {
+	ct := test_utils.NewContractTest()
+	contractId := "dex_router"
+	ct.RegisterContract(contractId, "hive:alice", ContractWasm)
+
+	setupDexTest(&ct, contractId)
+	addLiquidityToPool(&ct, contractId, "1", 2000000, 1000000)
+
+	// A fee on HIVE input stays in the reserves: 50000 HIVE, 49960 after the
+	// 0.08% fee, for 95165 HBD, and the whole 50000 joins the pool
+	result := callExecute(&ct, contractId, "hive_swap", "hive:bob", "execute", `{
+		"type": "swap", "version": "1.0.0", "asset_in": "HIVE", "asset_out": "HBD",
+		"recipient": "hive:bob", "amount_in": 50000, "min_amount_out": 95000
+	}`, allow("HIVE", 50000))
+	assertApplied(t, result)
+	assert.Equal(t, `"1904835"`, ct.StateGet(contractId, "pool/1/reserve0"))
+	assert.Equal(t, `"1050000"`, ct.StateGet(contractId, "pool/1/reserve1"))
+	assert.Equal(t, `"0"`, ct.StateGet(contractId, "pool/1/fee1"))
+
+	// A fee on HBD input is kept for claiming: 100000 HBD, of which 80 is fee
+	result = callExecute(&ct, contractId, "hbd_swap", "hive:bob", "execute", `{
+		"type": "swap", "version": "1.0.0", "asset_in": "HBD", "asset_out": "HIVE",
+		"recipient": "hive:bob", "amount_in": 100000, "min_amount_out": 1
+	}`, allow("HBD", 100000))
+	assertApplied(t, result)
+	assert.Equal(t, `"2004755"`, ct.StateGet(contractId, "pool/1/reserve0"))
+	assert.Equal(t, `"80"`, ct.StateGet(contractId, "pool/1/fee0"))
+
+	// Dust that the fee rounds to nothing is refused
+	result = callExecute(&ct, contractId, "dust_swap", "hive:bob", "execute", `{
+		"type": "swap", "version": "1.0.0", "asset_in": "HBD", "asset_out": "HIVE",
+		"recipient": "hive:bob", "amount_in": 1, "min_amount_out": 0
+	}`, allow("HBD", 1))
+	assertRefused(t, result, "swap amount too small")
+	assert.Equal(t, `"2004755"`, ct.StateGet(contractId, "pool/1/reserve0"))
+}
+
+func TestSwapDeadline(t *testing.T) {
+	ct := test_utils.NewContractTest()
+	contractId := "dex_router"
+	ct.RegisterContract(contractId, "hive:alice", ContractWasm)
+
+	setupDexTest(&ct, contractId)
+	addLiquidityToPool(&ct, contractId, "1", 2000000, 1000000)
+
+	swap := func(txId string, deadline int64) stateEngine.TxResult {
+		result, _, _ := ct.Call(stateEngine.TxVscCallContract{
+			Self: stateEngine.TxSelf{
+				TxId:                 txId,
+				BlockId:              "block:" + txId,
+				Index:                3,
+				OpIndex:              0,
+				Timestamp:            "2025-01-01T00:00:03Z", // 1735689603
+				RequiredAuths:        []string{"hive:bob"},
+				RequiredPostingAuths: []string{},
+			},
+			ContractId: contractId,
+			Action:     "execute",
+			Payload: json.RawMessage(fmt.Sprintf(`{
+				"type": "swap",
+				"version": "1.0.0",
+				"asset_in": "HBD",
+				"asset_out": "HIVE",
+				"recipient": "hive:bob",
+				"amount_in": 100000,
+				"min_amount_out": 47500,
+				"deadline": %d
+			}`, deadline)),
+			RcLimit: 10000,
+			Intents: []contracts.Intent{
+				{
+					Type: "transfer.allow",
+					Args: map[string]string{
+						"limit": "100000",
+						"token": "HBD",
+					},
+				},
+			},
+			Caller: "hive:bob",
+		})
+		return result
+	}
+
+	// A block after the deadline refuses the swap and leaves the pool alone
+	assertRefused(t, swap("late_swap_tx", 1735689602), "deadline has passed")
+	assert.Equal(t, `"2000000"`, ct.StateGet(contractId, "pool/1/reserve0"))
+
+	// The deadline's own second is still in time
+	assertApplied(t, swap("swap_tx", 1735689603))
+	assert.Equal(t, `"2099920"`, ct.StateGet(contractId, "pool/1/reserve0"))
+}
+
+func TestLimitOrders(t *testing.T) {
+	ct := test_utils.NewContractTest()
+	contractId := "dex_router"
+	ct.RegisterContract(contractId, "hive:alice", ContractWasm)
+
+	setupDexTest(&ct, contractId)
+	addLiquidityToPool(&ct, contractId, "1", 2000000, 1000000)
+
+	order := func(txId, caller, kind, extra string) stateEngine.TxResult {
+		return callExecute(&ct, contractId, txId, caller, "execute", `{
+			"type": "`+kind+`", "version": "1.0.0", "asset_in": "HIVE", "asset_out": "HBD",
+			"recipient": "`+caller+`"`+extra+`
+		}`, allow("HIVE", 10000))
+	}
+
+	// 10000 HIVE swaps for 19786 HBD, of which the maker keeps 19767 after
+	// the 0.1% maker fee, short of this order's limit
+	result := order("place_1", "hive:carol", "limit_order", `, "amount_in": 10000, "min_amount_out": 19780`)
+	assert.True(t, result.Success)
+	assert.Equal(t, "1", result.Ret)
+	assert.Equal(t, `"10000"`, ct.StateGet(contractId, "order/1/amount_in"))
+
+	result = order("fill_1", "hive:keeper", "fill_order", `, "metadata": {"order_id": "1"}`)
+	assertRefused(t, result, "order limit price not reached")
+	assert.Equal(t, `"1000000"`, ct.StateGet(contractId, "pool/1/reserve1"))
+
+	// Only the maker may cancel, which removes the order
+	result = order("cancel_1_bob", "hive:bob", "cancel_order", `, "metadata": {"order_id": "1"}`)
+	assertRefused(t, result, "only the maker can cancel an order")
+	result = order("cancel_1", "hive:carol", "cancel_order", `, "metadata": {"order_id": "1"}`)
+	assertApplied(t, result)
+	assert.Empty(t, ct.StateGet(contractId, "order/1/owner"))
+	result = order("fill_cancelled", "hive:keeper", "fill_order", `, "metadata": {"order_id": "1"}`)
+	assertRefused(t, result, "order not found")
+
+	// An order whose limit the pool meets fills against it, and the whole
+	// escrowed input joins the pool
+	result = order("place_2", "hive:carol", "limit_order", `, "amount_in": 10000, "min_amount_out": 19700`)
+	assert.True(t, result.Success)
+	assert.Equal(t, "2", result.Ret)
+	result = order("fill_2", "hive:keeper", "fill_order", `, "metadata": {"order_id": "2"}`)
+	assertApplied(t, result)
+	assert.Equal(t, `"1980214"`, ct.StateGet(contractId, "pool/1/reserve0"))
+	assert.Equal(t, `"1010000"`, ct.StateGet(contractId, "pool/1/reserve1"))
+	assert.Empty(t, ct.StateGet(contractId, "order/2/owner"))
+}
+
+// TestConcentratedPositions mints a position in a concentrated pool, swaps
+// through it in both directions and burns and collects it
+func TestConcentratedPositions(t *testing.T) {
+	ct := test_utils.NewContractTest()
+	contractId := "dex_router"
+	ct.RegisterContract(contractId, "hive:alice", ContractWasm)
+
+	setupDexTest(&ct, contractId)
+	addLiquidityToPool(&ct, contractId, "1", 2000000, 1000000)
+	result := callExecute(&ct, contractId, "create_concentrated", "hive:alice", "create_pool", `{
+		"asset0": "HBD", "asset1": "HIVE", "fee_bps": 30, "tick_spacing": 60, "initial_tick": 13860
+	}`)
+	assertApplied(t, result)
+
+	position := func(txId, caller, kind, metadata string) stateEngine.TxResult {
+		return callExecute(&ct, contractId, txId, caller, "execute", `{
+			"type": "`+kind+`", "version": "1.0.0", "asset_in": "HBD", "asset_out": "HIVE",
+			"recipient": "hive:lp", "metadata": `+metadata+`
+		}`, allow("HBD", 1000000), allow("HIVE", 4000000))
+	}
+	swap := func(txId, assetIn, assetOut string, amountIn uint64, route string) stateEngine.TxResult {
+		return callExecute(&ct, contractId, txId, "hive:trader", "execute", fmt.Sprintf(`{
+			"type": "swap", "version": "1.0.0", "asset_in": "%s", "asset_out": "%s",
+			"recipient": "hive:trader", "amount_in": %d, "min_amount_out": 1%s
+		}`, assetIn, assetOut, amountIn, route), allow(assetIn, amountIn))
+	}
+
+	// Ticks off the pool's spacing are refused
+	result = position("mint_off_spacing", "hive:lp", "mint_position",
+		`{"pool_id": "2", "tick_lower": "12000", "tick_upper": "15001", "amount0": "1000000", "amount1": "4000000"}`)
+	assertRefused(t, result, "ticks must be multiples of the pool's tick spacing")
+
+	result = position("mint", "hive:lp", "mint_position",
+		`{"pool_id": "2", "tick_lower": "12000", "tick_upper": "15000", "amount0": "1000000", "amount1": "4000000"}`)
+	assert.True(t, result.Success)
+	assert.Equal(t, "1", result.Ret)
+	assert.Equal(t, `"hive:lp"`, ct.StateGet(contractId, "position/1/owner"))
+	assert.Equal(t, `"22526044"`, ct.StateGet(contractId, "position/1/liquidity"))
+	assert.Equal(t, `"22526044"`, ct.StateGet(contractId, "pool/2/liquidity"))
+	assert.Equal(t, `"624122"`, ct.StateGet(contractId, "pool/2/reserve0"))
+	assert.Equal(t, `"4000000"`, ct.StateGet(contractId, "pool/2/reserve1"))
+
+	// A swap reaches a concentrated pool only through its route, and moves
+	// the pool's price
+	assertApplied(t, swap("swap_hbd", "HBD", "HIVE", 10000, `, "route": ["2"]`))
+	assert.Equal(t, `"634122"`, ct.StateGet(contractId, "pool/2/reserve0"))
+	assert.Equal(t, `"3960170"`, ct.StateGet(contractId, "pool/2/reserve1"))
+	assert.Equal(t, `"13842"`, ct.StateGet(contractId, "pool/2/tick"))
+	assertApplied(t, swap("swap_hive", "HIVE", "HBD", 40000, `, "route": ["2"]`))
+	assert.Equal(t, `"624140"`, ct.StateGet(contractId, "pool/2/reserve0"))
+	assert.Equal(t, `"4000170"`, ct.StateGet(contractId, "pool/2/reserve1"))
+	assert.Equal(t, `"13860"`, ct.StateGet(contractId, "pool/2/tick"))
+
+	// Without a route the swap goes through the constant product pool, which
+	// keeps its HBD fee aside
+	assertApplied(t, swap("swap_default", "HBD", "HIVE", 10000, ""))
+	assert.Equal(t, `"2009992"`, ct.StateGet(contractId, "pool/1/reserve0"))
+	assert.Equal(t, `"624140"`, ct.StateGet(contractId, "pool/2/reserve0"))
+
+	// Only the owner may burn or collect
+	result = position("burn_other", "hive:other", "burn_position", `{"position_id": "1"}`)
+	assertRefused(t, result, "only the owner can manage a position")
+	result = position("collect_other", "hive:other", "collect_position", `{"position_id": "1"}`)
+	assertRefused(t, result, "only the owner can manage a position")
+
+	// Burning credits the position its assets and fees, and collecting pays
+	// them out and removes the emptied position. Rounding leaves the pool a
+	// few units of dust.
+	result = position("burn", "hive:lp", "burn_position", `{"position_id": "1"}`)
+	assertApplied(t, result)
+	assert.Equal(t, `"0"`, ct.StateGet(contractId, "position/1/liquidity"))
+	assert.Equal(t, `"0"`, ct.StateGet(contractId, "pool/2/liquidity"))
+	assert.Equal(t, `"624137"`, ct.StateGet(contractId, "position/1/owed0"))
+	assert.Equal(t, `"4000168"`, ct.StateGet(contractId, "position/1/owed1"))
+
+	result = position("collect", "hive:lp", "collect_position", `{"position_id": "1"}`)
+	assertApplied(t, result)
+	assert.Empty(t, ct.StateGet(contractId, "position/1/owner"))
+	assert.Equal(t, `"3"`, ct.StateGet(contractId, "pool/2/reserve0"))
+	assert.Equal(t, `"2"`, ct.StateGet(contractId, "pool/2/reserve1"))
+	result = position("collect_again", "hive:lp", "collect_position", `{"position_id": "1"}`)
+	assertRefused(t, result, "position not found")
+}
+
+// TestCommitmentExpiry reveals commitments in and after their window and
+// checks expired ones are deleted, whether revealed late or never
+func TestCommitmentExpiry(t *testing.T) {
+	ct := test_utils.NewContractTest()
+	contractId := "dex_router"
+	ct.RegisterContract(contractId, "hive:alice", ContractWasm)
+
+	setupDexTest(&ct, contractId)
+	addLiquidityToPool(&ct, contractId, "1", 2000000, 1000000)
+
+	swap := func(salt string) (string, string) {
+		payload := `{"type": "swap", "version": "1.0.0", "asset_in": "HBD", "asset_out": "HIVE",
+			"recipient": "hive:bob", "amount_in": 1000, "min_amount_out": 1, "metadata": {"commit_salt": "` + salt + `"}}`
+		hash := sha256.Sum256([]byte(payload))
+		return payload, hex.EncodeToString(hash[:])
+	}
+	commit := func(caller, hash string) {
+		assertApplied(t, callExecute(&ct, contractId, "commit_"+hash, caller, "commit", hash))
+	}
+	reveal := func(payload, hash string) stateEngine.TxResult {
+		return callExecute(&ct, contractId, "reveal_"+hash, "hive:bob", "execute", payload, allow("HBD", 1000))
+	}
+	stored := func(caller, hash string) string {
+		return ct.StateGet(contractId, "commit/"+caller+"/"+hash)
+	}
+
+	// A commitment revealed in its window executes and is consumed
+	payload, hash := swap("in-time")
+	commit("hive:bob", hash)
+	ct.IncrementBlocks(1)
+	assertApplied(t, reveal(payload, hash))
+	assert.Empty(t, stored("hive:bob", hash))
+
+	// A commitment revealed after its window is refused and deleted
+	late, lateHash := swap("late")
+	commit("hive:bob", lateHash)
+	ct.IncrementBlocks(201)
+	assertRefused(t, reveal(late, lateHash), "commitment expired")
+	assert.Empty(t, stored("hive:bob", lateHash))
+
+	// Commitments never revealed are deleted by commits after they expire
+	_, abandoned := swap("abandoned")
+	commit("hive:bob", abandoned)
+	ct.IncrementBlocks(100)
+	_, open := swap("open")
+	commit("hive:carol", open)
+	ct.IncrementBlocks(101)
+	_, next := swap("next")
+	commit("hive:dave", next)
+	assert.Empty(t, stored("hive:bob", abandoned))
+	assert.NotEmpty(t, stored("hive:carol", open))
+	assert.NotEmpty(t, stored("hive:dave", next))
+	assert.Equal(t, `"3"`, ct.StateGet(contractId, "commit_queue_head"))
+	assert.Empty(t, ct.StateGet(contractId, "commit_queue/2"))
+	assert.Equal(t, `"commit/hive:carol/`+open+`"`, ct.StateGet(contractId, "commit_queue/3"))
+}
+
+// TestPriceCumulatives runs swaps and liquidity changes across block heights
+// and checks get_price_cumulatives against a model that adds, once per
+// block, the spot price a pool held before anything moved it
+func TestPriceCumulatives(t *testing.T) {
+	ct := test_utils.NewContractTest()
+	contractId := "dex_router"
+	ct.RegisterContract(contractId, "hive:alice", ContractWasm)
+
+	// Pool 1 is HBD/HIVE and pool 2 BTC/HBD, both opened at block 100
+	ct.IncrementBlocks(100)
+	setupDexTest(&ct, contractId)
+	callExecute(&ct, contractId, "create_btc_pool", "hive:alice", "create_pool",
+		`{"asset0": "BTC", "asset1": "HBD", "fee_bps": 8}`)
+	addLiquidityToPool(&ct, contractId, "1", 2000000, 1000000)
+	result := callExecute(&ct, contractId, "add_btc_liq", "hive:alice", "execute", `{
+		"type": "deposit", "version": "1.0.0", "asset_in": "BTC", "asset_out": "HBD",
+		"recipient": "hive:alice", "metadata": {"amount0": "10000", "amount1": "3000000"}
+	}`, allow("BTC", 10000), allow("HBD", 3000000))
+	assertApplied(t, result)
+
+	model := map[string]*cumulativeModel{"1": {block: 100}, "2": {block: 100}}
+	check := func(step string) {
+		for _, poolId := range []string{"1", "2"} {
+			model[poolId].accrue(&ct, contractId, poolId)
+			price0, price1 := readCumulatives(t, &ct, contractId, poolId)
+			assert.Equal(t, model[poolId].price0.String(), price0, "%s: pool %s price0_cumulative", step, poolId)
+			assert.Equal(t, model[poolId].price1.String(), price1, "%s: pool %s price1_cumulative", step, poolId)
+		}
+	}
+	swap := func(txId, payload, token string, amount uint64, pools ...string) {
+		for _, poolId := range pools {
+			model[poolId].accrue(&ct, contractId, poolId)
+		}
+		result := callExecute(&ct, contractId, txId, "hive:bob", "execute", payload, allow(token, amount))
+		assertApplied(t, result, txId)
+	}
+
+	// Nothing accrues within the block the pools were funded in
+	check("funded")
+
+	// A direct swap ten blocks later adds ten blocks of the funded price
+	ct.IncrementBlocks(10)
+	swap("direct_swap", `{"type": "swap", "version": "1.0.0", "asset_in": "HBD", "asset_out": "HIVE",
+		"recipient": "hive:bob", "amount_in": 100000, "min_amount_out": 1}`, "HBD", 100000, "1")
+	check("direct swap")
+	before0, before1 := readCumulatives(t, &ct, contractId, "1")
+
+	// A second swap in the same block adds nothing
+	swap("direct_swap_again", `{"type": "swap", "version": "1.0.0", "asset_in": "HIVE", "asset_out": "HBD",
+		"recipient": "hive:bob", "amount_in": 20000, "min_amount_out": 1}`, "HIVE", 20000, "1")
+	after0, after1 := readCumulatives(t, &ct, contractId, "1")
+	assert.Equal(t, before0, after0)
+	assert.Equal(t, before1, after1)
+	check("same block swap")
+
+	// A two-hop swap through HBD accrues both pools before moving them
+	ct.IncrementBlocks(5)
+	swap("two_hop_swap", `{"type": "swap", "version": "1.0.0", "asset_in": "BTC", "asset_out": "HIVE",
+		"recipient": "hive:bob", "amount_in": 500, "min_amount_out": 1}`, "BTC", 500, "2", "1")
+	check("two-hop swap")
+
+	// So does a swap along an explicit route
+	ct.IncrementBlocks(3)
+	swap("routed_swap", `{"type": "swap", "version": "1.0.0", "asset_in": "HIVE", "asset_out": "BTC",
+		"recipient": "hive:bob", "amount_in": 40000, "min_amount_out": 1, "route": ["1", "2"]}`, "HIVE", 40000, "1", "2")
+	check("routed swap")
+
+	// Adding and removing liquidity accrue before changing the reserves too
+	ct.IncrementBlocks(7)
+	model["1"].accrue(&ct, contractId, "1")
+	addLiquidityToPool(&ct, contractId, "1", 200000, 100000)
+	check("deposit")
+
+	ct.IncrementBlocks(2)
+	model["1"].accrue(&ct, contractId, "1")
+	result = callExecute(&ct, contractId, "withdraw", "hive:alice", "execute", `{
+		"type": "withdrawal", "version": "1.0.0", "asset_in": "HBD", "asset_out": "HIVE",
+		"recipient": "hive:alice", "metadata": {"lp_amount": "100000"}
+	}`)
+	assertApplied(t, result)
+	check("withdrawal")
+
+	// A query between calls extends the accumulators at the current price
+	ct.IncrementBlocks(4)
+	check("idle blocks")
+}
+
+// TestPriceCumulativesUnstartedPool reads the accumulators of a pool with no
+// starting block, as pools created before the accumulators existed have.
+// The first reading starts them at that block, and later readings accrue
+// from it even if nothing touches the pool.
+func TestPriceCumulativesUnstartedPool(t *testing.T) {
+	ct := test_utils.NewContractTest()
+	contractId := "dex_router"
+	ct.RegisterContract(contractId, "hive:alice", ContractWasm)
+
+	ct.IncrementBlocks(100)
+	setupDexTest(&ct, contractId)
+	addLiquidityToPool(&ct, contractId, "1", 2000000, 1000000)
+	ct.StateDelete(contractId, "pool/1/price_block")
+
+	ct.IncrementBlocks(5)
+	price0, price1 := readCumulatives(t, &ct, contractId, "1")
+	assert.Equal(t, "0", price0)
+	assert.Equal(t, "0", price1)
+	assert.Equal(t, strconv.Quote(strconv.FormatUint(ct.BlockHeight, 10)), ct.StateGet(contractId, "pool/1/price_block"))
+
+	model := cumulativeModel{block: ct.BlockHeight}
+	ct.IncrementBlocks(10)
+	model.accrue(&ct, contractId, "1")
+	price0, price1 = readCumulatives(t, &ct, contractId, "1")
+	assert.Equal(t, model.price0.String(), price0)
+	assert.Equal(t, model.price1.String(), price1)
+}
+
 // Helper functions
 
 func setupDexTest(ct *test_utils.ContractTest, contractId string) {
@@ -401,3 +797,88 @@ func addLiquidityToPool(ct *test_utils.ContractTest, contractId, poolId string,
 		Caller:  "hive:alice",
 	})
 }
+
+// callExecute calls action on the contract as caller in the current block
+func callExecute(ct *test_utils.ContractTest, contractId, txId, caller, action, payload string, intents ...contracts.Intent) stateEngine.TxResult {
+	result, _, _ := ct.Call(stateEngine.TxVscCallContract{
+		Self: stateEngine.TxSelf{
+			TxId:                 txId,
+			BlockId:              "block:" + txId,
+			Index:                0,
+			OpIndex:              0,
+			Timestamp:            "2025-01-01T00:02:00Z",
+			RequiredAuths:        []string{caller},
+			RequiredPostingAuths: []string{},
+		},
+		ContractId: contractId,
+		Action:     action,
+		Payload:    json.RawMessage(payload),
+		RcLimit:    10000,
+		Intents:    intents,
+		Caller:     caller,
+	})
+	return result
+}
+
+// assertApplied asserts that a call ran and returned no error. The contract
+// returns an instruction's error as the call's result, so a refused
+// instruction still succeeds as a call.
+func assertApplied(t *testing.T, result stateEngine.TxResult, msgAndArgs ...interface{}) bool {
+	return assert.True(t, result.Success, msgAndArgs...) && assert.Empty(t, result.Ret, msgAndArgs...)
+}
+
+// assertRefused asserts that a call returned the error message
+func assertRefused(t *testing.T, result stateEngine.TxResult, message string) bool {
+	return assert.True(t, result.Success) && assert.Equal(t, message, result.Ret)
+}
+
+// allow is an intent letting the contract draw up to limit of token
+func allow(token string, limit uint64) contracts.Intent {
+	return contracts.Intent{
+		Type: "transfer.allow",
+		Args: map[string]string{
+			"limit": strconv.FormatUint(limit, 10),
+			"token": token,
+		},
+	}
+}
+
+// readCumulatives returns a pool's price accumulators from get_price_cumulatives
+func readCumulatives(t *testing.T, ct *test_utils.ContractTest, contractId, poolId string) (string, string) {
+	result := callExecute(ct, contractId, "cumulatives_"+poolId, "hive:alice", "get_price_cumulatives", poolId)
+	if !assert.True(t, result.Success, result.Ret) {
+		return "", ""
+	}
+	var cumulatives PriceCumulatives
+	if !assert.NoError(t, json.Unmarshal([]byte(result.Ret), &cumulatives)) {
+		return "", ""
+	}
+	assert.Equal(t, ct.BlockHeight, cumulatives.BlockHeight)
+	return cumulatives.Price0Cumulative, cumulatives.Price1Cumulative
+}
+
+// cumulativeModel tracks what a constant product pool's price accumulators
+// should hold
+type cumulativeModel struct {
+	price0, price1 big.Int
+	block          uint64
+}
+
+// accrue adds the pool's spot price, read from its reserves, for each block
+// since the model last accrued. It must run before the pool's reserves move.
+func (m *cumulativeModel) accrue(ct *test_utils.ContractTest, contractId, poolId string) {
+	if ct.BlockHeight <= m.block {
+		return
+	}
+	reserve := func(key string) uint64 {
+		value, _ := strconv.Unquote(ct.StateGet(contractId, "pool/"+poolId+"/"+key))
+		n, _ := strconv.ParseUint(value, 10, 64)
+		return n
+	}
+	if spot0, spot1, ok := reservePrices(reserve("reserve0"), reserve("reserve1")); ok {
+		blocks := new(big.Int).SetUint64(ct.BlockHeight - m.block)
+		m.price0.Add(&m.price0, spot0.Mul(spot0, blocks))
+		m.price1.Add(&m.price1, spot1.Mul(spot1, blocks))
+	}
+	m.block = ct.BlockHeight
+}
@@ -1,9 +1,13 @@
 package main
 
 import (
+	"crypto/sha256"
 	sdk "dex-router/sdk"
+	"encoding/hex"
+	"math/big"
 	"math/bits"
 	"strconv"
+	"time"
 
 	tinyjson "github.com/CosmWasm/tinyjson"
 )
@@ -27,6 +31,8 @@ func Init(payload *string) *string {
 // Create a new liquidity pool
 // Payload: JSON with pool parameters
 // {"asset0": "HBD", "asset1": "HIVE", "fee_bps": 8}
+// A tick_spacing makes a concentrated pool priced at initial_tick:
+// {"asset0": "HBD", "asset1": "HIVE", "fee_bps": 30, "tick_spacing": 60, "initial_tick": 13860}
 //
 
 //go:wasmexport create_pool
@@ -50,6 +56,18 @@ func CreatePool(payload *string) *string {
 		params.FeeBps = defaultBaseFeeBps
 	}
 
+	if params.TickSpacing > maxTickSpacing {
+		return &[]string{"error", "tick_spacing too large"}[1]
+	}
+	if params.TickSpacing > 0 {
+		if params.FeeBps >= 10000 {
+			return &[]string{"error", "fee_bps must be below 10000"}[1]
+		}
+		if params.InitialTick < minTick || params.InitialTick > maxTick {
+			return &[]string{"error", "initial_tick out of range"}[1]
+		}
+	}
+
 	// Generate pool ID
 	poolId := strconv.FormatUint(getUint(keyNextPoolId), 10)
 	setUint(keyNextPoolId, getUint(keyNextPoolId)+1)
@@ -64,6 +82,10 @@ func CreatePool(payload *string) *string {
 	setUint(poolFee0Key(poolId), 0)
 	setUint(poolFee1Key(poolId), 0)
 	setStr(poolFeeLastClaimKey(poolId), sdk.GetEnv().Timestamp)
+	setUint(poolKey(poolId, keyPoolPriceBlock), sdk.GetEnv().BlockHeight)
+	if params.TickSpacing > 0 {
+		initConcentratedPool(poolId, params.TickSpacing, params.InitialTick)
+	}
 
 	return nil
 }
@@ -89,9 +111,36 @@ func Execute(payload *string) *string {
 		return &[]string{"error", "missing required fields"}[1]
 	}
 
+	if instruction.Deadline != nil {
+		if err := checkDeadline(*instruction.Deadline); err != nil {
+			return err
+		}
+	}
+
+	// A salted instruction reveals an earlier commitment, which must exist
+	if instruction.Metadata["commit_salt"] != "" {
+		if err := revealCommitment(*payload); err != nil {
+			return err
+		}
+	}
+
 	switch instruction.Type {
 	case "swap":
 		return executeSwap(instruction)
+	case "swap_exact_out":
+		return executeSwapExactOut(instruction)
+	case "limit_order":
+		return placeLimitOrder(instruction)
+	case "cancel_order":
+		return cancelLimitOrder(instruction)
+	case "fill_order":
+		return fillLimitOrder(instruction)
+	case "mint_position":
+		return mintPosition(instruction)
+	case "burn_position":
+		return burnPosition(instruction)
+	case "collect_position":
+		return collectPosition(instruction)
 	case "deposit":
 		return executeDeposit(instruction)
 	case "withdrawal":
@@ -101,31 +150,143 @@ func Execute(payload *string) *string {
 	}
 }
 
-// Execute swap operation
-func executeSwap(instruction DexInstruction) *string {
-	// Find direct pool first
-	directPoolId := findPool(instruction.AssetIn, instruction.AssetOut)
-	if directPoolId != "" {
-		return executeDirectSwap(directPoolId, instruction)
+// Commit to an instruction without revealing it, so it cannot be front-run.
+// Each commit also deletes a few of the oldest commitments whose reveal
+// window has closed.
+// Payload: hex SHA-256 of the exact execute payload that will reveal it,
+// which carries a random metadata.commit_salt
+//
+//go:wasmexport commit
+func Commit(payload *string) *string {
+	if payload == nil || len(*payload) != sha256.Size*2 {
+		return &[]string{"error", "payload must be a hex sha256 hash"}[1]
+	}
+	if _, err := hex.DecodeString(*payload); err != nil {
+		return &[]string{"error", "payload must be a hex sha256 hash"}[1]
 	}
 
-	// Try two-hop swap via HBD
-	if instruction.AssetIn != "HBD" && instruction.AssetOut != "HBD" {
-		return executeTwoHopSwap(instruction)
+	env := sdk.GetEnv()
+	key := commitKey(env.Sender.Address.String(), *payload)
+	if sdk.StateGetObject(key) != nil {
+		return &[]string{"error", "already committed"}[1]
 	}
+	setUint(key, env.BlockHeight)
+	queueCommitment(key)
+	pruneCommitments(env.BlockHeight)
+	return nil
+}
+
+// queueCommitment queues a commitment for pruning. Commitments are queued
+// in block order, so the oldest is always at the head.
+func queueCommitment(key string) {
+	tail := getUint(keyCommitQueueTail)
+	setStr(commitQueueKey(tail), key)
+	setUint(keyCommitQueueTail, tail+1)
+}
 
-	return &[]string{"error", "no suitable pool found"}[1]
+// pruneCommitments deletes up to maxCommitPrunes expired commitments from
+// the head of the queue. Revealed commitments are already gone and only
+// leave the queue. Pruning stops at the first commitment still open, which
+// may be a newer commitment to the same hash.
+func pruneCommitments(height uint64) {
+	head := getUint(keyCommitQueueHead)
+	tail := getUint(keyCommitQueueTail)
+	for pruned := 0; pruned < maxCommitPrunes && head < tail; pruned++ {
+		slot := commitQueueKey(head)
+		key := getStr(slot)
+		if sdk.StateGetObject(key) != nil {
+			if height <= getUint(key)+commitRevealWindowBlocks {
+				break
+			}
+			sdk.StateDeleteObject(key)
+		}
+		sdk.StateDeleteObject(slot)
+		head++
+	}
+	setUint(keyCommitQueueHead, head)
 }
 
-// Find pool by assets - iterates through all pools to find matching pair
+// revealCommitment checks a payload was committed to by its sender in an
+// earlier block, within the reveal window, and consumes the commitment. An
+// expired commitment is deleted as it is refused.
+func revealCommitment(payload string) *string {
+	hash := sha256.Sum256([]byte(payload))
+	env := sdk.GetEnv()
+	key := commitKey(env.Sender.Address.String(), hex.EncodeToString(hash[:]))
+
+	if sdk.StateGetObject(key) == nil {
+		return &[]string{"error", "no matching commitment"}[1]
+	}
+	committedAt := getUint(key)
+	if env.BlockHeight <= committedAt {
+		return &[]string{"error", "reveal must follow the commitment's block"}[1]
+	}
+	if env.BlockHeight > committedAt+commitRevealWindowBlocks {
+		sdk.StateDeleteObject(key)
+		return &[]string{"error", "commitment expired"}[1]
+	}
+
+	sdk.StateDeleteObject(key)
+	return nil
+}
+
+// Refuse an instruction included in a block after its deadline, so a swap
+// held back by the network cannot execute at a price its sender no longer
+// expects
+func checkDeadline(deadline int64) *string {
+	blockTime, err := time.Parse(time.RFC3339, sdk.GetEnv().Timestamp)
+	if err != nil {
+		return &[]string{"error", "block timestamp unavailable"}[1]
+	}
+	if blockTime.Unix() > deadline {
+		return &[]string{"error", "deadline has passed"}[1]
+	}
+	return nil
+}
+
+// Execute swap operation. An explicit route of pools is followed exactly;
+// without one the swap takes its pair's pool, or two hops through HBD.
+// Either way amount_in is drawn and min_amount_out is the least the final
+// pool may return.
+func executeSwap(instruction DexInstruction) *string {
+	pools := instruction.Route
+	if len(pools) == 0 {
+		pools = defaultRoute(instruction.AssetIn, instruction.AssetOut)
+	}
+	if len(pools) == 0 {
+		return &[]string{"error", "no suitable pool found"}[1]
+	}
+	return executeRouteSwap(instruction, pools)
+}
+
+// defaultRoute returns the pools a swap without a route takes: the constant
+// product pool for its pair, or else two hops through HBD. It returns nil if
+// neither exists.
+func defaultRoute(assetIn, assetOut string) []string {
+	if poolId := findPool(assetIn, assetOut); poolId != "" {
+		return []string{poolId}
+	}
+	if isHbd(assetIn) || isHbd(assetOut) {
+		return nil
+	}
+	first := findPool(assetIn, "HBD")
+	second := findPool("HBD", assetOut)
+	if first == "" || second == "" {
+		return nil
+	}
+	return []string{first, second}
+}
+
+// Find pool by assets - iterates through all pools to find matching pair.
+// Concentrated pools are only swapped through by naming them in a route.
 func findPool(assetA, assetB string) string {
 	nextPoolId := getUint(keyNextPoolId)
 	for i := uint64(1); i < nextPoolId; i++ {
 		poolId := strconv.FormatUint(i, 10)
 		asset0 := getPoolAsset0(poolId)
 		asset1 := getPoolAsset1(poolId)
-		if asset0 == "" {
-			continue // Pool doesn't exist
+		if asset0 == "" || isConcentrated(poolId) {
+			continue // Pool doesn't exist or isn't constant product
 		}
 		// Check both asset orders
 		if (asset0 == assetA && asset1 == assetB) || (asset0 == assetB && asset1 == assetA) {
@@ -135,232 +296,422 @@ func findPool(assetA, assetB string) string {
 	return ""
 }
 
-// Execute direct swap within a pool
-func executeDirectSwap(poolId string, instruction DexInstruction) *string {
-	asset0 := getPoolAsset0(poolId)
-	asset1 := getPoolAsset1(poolId)
+// routeHop is one leg of a routed swap
+type routeHop struct {
+	poolId     string
+	input0     bool   // Whether the hop sells the pool's asset0
+	hbdIn      bool   // Whether the hop sells HBD, whose fee is kept for claiming
+	feeBps     uint64 // Pool fee
+	reserveIn  uint64 // Reserves before the hop
+	reserveOut uint64
+
+	// Set once the hop is priced
+	amountIn  uint64 // Input after the pool fee, which prices the hop
+	amountOut uint64
+	fee       uint64 // Pool fee taken from the input
+
+	concentrated bool              // Whether the pool holds range-bound positions
+	swap         *concentratedSwap // Priced swap, for concentrated pools
+}
 
-	if asset0 == "" {
-		return &[]string{"error", "pool not found"}[1]
+// Execute a swap along pools, in one call. Every hop is priced before any
+// state changes, so a route that breaks or misses min_amount_out on its
+// final output changes nothing.
+func executeRouteSwap(instruction DexInstruction, pools []string) *string {
+	if instruction.AmountIn == nil || *instruction.AmountIn <= 0 {
+		return &[]string{"error", "amount_in required for swap"}[1]
 	}
+	amountIn := uint64(*instruction.AmountIn)
 
-	r0 := getPoolReserve0(poolId)
-	r1 := getPoolReserve1(poolId)
-	feeBps := getPoolFee(poolId)
+	hops, err := planRoute(instruction.AssetIn, instruction.AssetOut, pools)
+	if err != nil {
+		return err
+	}
+	amountOut, err := priceRoute(hops, amountIn)
+	if err != nil {
+		return err
+	}
 
-	if r0 == 0 || r1 == 0 {
-		return &[]string{"error", "pool has zero reserves"}[1]
+	// Slippage is enforced once, on the final output
+	if instruction.MinAmountOut != nil && amountOut < uint64(*instruction.MinAmountOut) {
+		return &[]string{"error", "slippage tolerance exceeded"}[1]
 	}
 
-	// For now, use MinAmountOut as the input amount (this is a simplification)
-	var amountInU uint64
-	if instruction.MinAmountOut != nil {
-		amountInU = uint64(*instruction.MinAmountOut)
-	} else {
-		return &[]string{"error", "amount_in required for swap"}[1]
+	applyRoute(hops)
+	drawAsset(int64(amountIn), instruction.AssetIn)
+	amountOut = payReferral(instruction, amountOut, instruction.AssetOut)
+	transferAsset(instruction.Recipient, int64(amountOut), instruction.AssetOut)
+
+	return nil
+}
+
+// Execute a swap for an exact output. The input needed is computed from the
+// pools, refused if above max_amount_in, and only that much is drawn; the
+// rest of the sender's allowance is never taken. Any referral fee is added
+// on top, so the recipient still receives amount_out.
+func executeSwapExactOut(instruction DexInstruction) *string {
+	if instruction.AmountOut == nil || *instruction.AmountOut <= 0 {
+		return &[]string{"error", "amount_out required for exact output swap"}[1]
+	}
+	if instruction.MaxAmountIn == nil || *instruction.MaxAmountIn <= 0 {
+		return &[]string{"error", "max_amount_in required for exact output swap"}[1]
 	}
 
-	var amountOut uint64
-	var inputAsset, outputAsset string
-	var feeReserveKey string
+	pools := instruction.Route
+	if len(pools) == 0 {
+		pools = defaultRoute(instruction.AssetIn, instruction.AssetOut)
+	}
+	if len(pools) == 0 {
+		return &[]string{"error", "no suitable pool found"}[1]
+	}
 
-	// Determine swap direction and calculate output
-	if asset0 == instruction.AssetIn && asset1 == instruction.AssetOut {
-		// asset0 -> asset1
-		inputAsset = asset0
-		outputAsset = asset1
-		feeReserveKey = poolFee0Key(poolId)
+	hops, err := planRoute(instruction.AssetIn, instruction.AssetOut, pools)
+	if err != nil {
+		return err
+	}
 
-		// Calculate output: dy = r1 - (r0 * r1) / (r0 + dx)
-		dx := amountInU * (10000 - feeBps) / 10000 // Apply fee
-		if dx == 0 {
-			dx = 1
+	target := uint64(*instruction.AmountOut)
+	if instruction.Beneficiary != nil && instruction.RefBps != nil && *instruction.RefBps > 0 {
+		if *instruction.RefBps >= 10000 {
+			return &[]string{"error", "ref_bps must be below 10000"}[1]
 		}
-		k := r0 * r1
-		newR0 := r0 + dx
-		amountOut = r1 - (k / newR0)
-
-		// Update reserves
-		setPoolReserve0(poolId, newR0)
-		setPoolReserve1(poolId, r1-amountOut)
-
-	} else if asset1 == instruction.AssetIn && asset0 == instruction.AssetOut {
-		// asset1 -> asset0
-		inputAsset = asset1
-		outputAsset = asset0
-		feeReserveKey = poolFee1Key(poolId)
-
-		// Calculate output: dx = r0 - (r0 * r1) / (r1 + dy)
-		dy := amountInU // No fee for non-HBD input
-		k := r0 * r1
-		newR1 := r1 + dy
-		amountOut = r0 - (k / newR1)
-
-		// Update reserves
-		setPoolReserve1(poolId, newR1)
-		setPoolReserve0(poolId, r0-amountOut)
+		target = ceilDiv(target*10000, 10000-uint64(*instruction.RefBps))
+	}
+	amountIn, err := routeInputFor(hops, target)
+	if err != nil {
+		return err
+	}
+	if amountIn > uint64(*instruction.MaxAmountIn) {
+		return &[]string{"error", "max_amount_in exceeded"}[1]
+	}
+	amountOut, err := priceRoute(hops, amountIn)
+	if err != nil {
+		return err
+	}
 
-	} else {
-		return &[]string{"error", "invalid asset pair for pool"}[1]
+	applyRoute(hops)
+	drawAsset(int64(amountIn), instruction.AssetIn)
+	amountOut = payReferral(instruction, amountOut, instruction.AssetOut)
+	transferAsset(instruction.Recipient, int64(amountOut), instruction.AssetOut)
+
+	return nil
+}
+
+// planRoute checks pools lead from assetIn to assetOut, each once, and reads
+// their reserves
+func planRoute(assetIn, assetOut string, pools []string) ([]routeHop, *string) {
+	if len(pools) > maxRouteHops {
+		return nil, &[]string{"error", "route has too many hops"}[1]
 	}
 
-	// Apply slippage protection if specified
-	if instruction.SlippageBps != nil {
-		minOut := amountOut * (10000 - uint64(*instruction.SlippageBps)) / 10000
-		if amountOut < minOut {
-			return &[]string{"error", "slippage tolerance exceeded"}[1]
+	hops := make([]routeHop, 0, len(pools))
+	asset := assetIn
+	for i, poolId := range pools {
+		for _, prev := range pools[:i] {
+			if prev == poolId {
+				return nil, &[]string{"error", "route passes through a pool twice"}[1]
+			}
 		}
-	}
 
-	// Draw input asset and transfer output asset
-	drawAsset(int64(amountInU), inputAsset)
+		asset0 := getPoolAsset0(poolId)
+		asset1 := getPoolAsset1(poolId)
+		if asset0 == "" {
+			return nil, &[]string{"error", "route pool not found"}[1]
+		}
 
-	// Handle referral fees
-	if instruction.Beneficiary != nil && instruction.RefBps != nil {
-		refOut := amountOut * uint64(*instruction.RefBps) / 10000
-		if refOut > 0 {
-			if refOut >= amountOut {
-				refOut = amountOut - 1
+		hop := routeHop{poolId: poolId, hbdIn: isHbd(asset), feeBps: getPoolFee(poolId), concentrated: isConcentrated(poolId)}
+		switch asset {
+		case asset0:
+			hop.input0 = true
+			hop.reserveIn, hop.reserveOut = getPoolReserve0(poolId), getPoolReserve1(poolId)
+			asset = asset1
+		case asset1:
+			hop.reserveIn, hop.reserveOut = getPoolReserve1(poolId), getPoolReserve0(poolId)
+			asset = asset0
+		default:
+			return nil, &[]string{"error", "route pools are not connected"}[1]
+		}
+		if !hop.concentrated && (hop.reserveIn == 0 || hop.reserveOut == 0) {
+			return nil, &[]string{"error", "pool has zero reserves"}[1]
+		}
+		if hop.feeBps >= 10000 {
+			return nil, &[]string{"error", "pool fee must be below 10000 bps"}[1]
+		}
+		hops = append(hops, hop)
+	}
+	if asset != assetOut {
+		return nil, &[]string{"error", "route does not end in asset_out"}[1]
+	}
+	return hops, nil
+}
+
+// priceRoute prices each hop for amountIn entering the first, and returns
+// the final output
+func priceRoute(hops []routeHop, amountIn uint64) (uint64, *string) {
+	amount := amountIn
+	for i := range hops {
+		hop := &hops[i]
+		if hop.concentrated {
+			// The whole input joins the pool; its fee goes to the positions in range
+			amountOut, swap, err := priceConcentrated(hop.poolId, hop.input0, amount)
+			if err != nil {
+				return 0, err
+			}
+			if amountOut == 0 {
+				return 0, &[]string{"error", "route output is zero"}[1]
 			}
-			amountOut -= refOut
-			transferAsset(*instruction.Beneficiary, int64(refOut), outputAsset)
+			hop.amountIn, hop.amountOut, hop.swap = amount, amountOut, swap
+			amount = amountOut
+			continue
+		}
+		hop.amountIn = amount * (10000 - hop.feeBps) / 10000
+		if hop.amountIn == 0 {
+			return 0, &[]string{"error", "swap amount too small"}[1]
 		}
+		hop.fee = amount - hop.amountIn
+		hop.amountOut = mulDiv(hop.reserveOut, hop.amountIn, hop.reserveIn+hop.amountIn)
+		if hop.amountOut == 0 {
+			return 0, &[]string{"error", "route output is zero"}[1]
+		}
+		amount = hop.amountOut
 	}
+	return amount, nil
+}
 
-	transferAsset(instruction.Recipient, int64(amountOut), outputAsset)
+// routeInputFor returns the least input for which the route returns at least
+// amountOut
+func routeInputFor(hops []routeHop, amountOut uint64) (uint64, *string) {
+	amount := amountOut
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := hops[i]
+		if hop.concentrated {
+			return 0, &[]string{"error", "exact output swaps cannot route through concentrated pools"}[1]
+		}
+		if amount >= hop.reserveOut {
+			return 0, &[]string{"error", "insufficient liquidity for amount_out"}[1]
+		}
+		// Input after fee for the output, then grossed up by the fee
+		net := mulDivUp(hop.reserveIn, amount, hop.reserveOut-amount)
+		amount = ceilDiv(net*10000, 10000-hop.feeBps)
+	}
+	return amount, nil
+}
 
-	// Accumulate fees (simplified - only for HBD input)
-	if inputAsset == "HBD" {
-		fee := amountInU - (amountInU * (10000 - feeBps) / 10000)
-		if fee > 0 {
-			currentFee := getUint(feeReserveKey)
-			setUint(feeReserveKey, currentFee+fee)
+// applyRoute writes priced hops' reserves and fees. A fee on HBD input is
+// kept for claim_fees; any other fee stays in the reserves for the pool's
+// liquidity providers.
+func applyRoute(hops []routeHop) {
+	for _, hop := range hops {
+		accumulatePrice(hop.poolId)
+		credit := hop.amountIn
+		if !hop.hbdIn {
+			credit += hop.fee
+		}
+		feeKey := poolFee1Key(hop.poolId)
+		if hop.input0 {
+			feeKey = poolFee0Key(hop.poolId)
+			setPoolReserve0(hop.poolId, hop.reserveIn+credit)
+			setPoolReserve1(hop.poolId, hop.reserveOut-hop.amountOut)
+		} else {
+			setPoolReserve1(hop.poolId, hop.reserveIn+credit)
+			setPoolReserve0(hop.poolId, hop.reserveOut-hop.amountOut)
+		}
+		if hop.hbdIn && hop.fee > 0 {
+			setUint(feeKey, getUint(feeKey)+hop.fee)
+		}
+		if hop.concentrated {
+			applyConcentrated(hop.poolId, hop.input0, hop.swap)
+			emitConcentratedSwap(hop)
 		}
 	}
+}
 
-	return nil
+// emitConcentratedSwap logs a hop through a concentrated pool with the
+// pool's price and liquidity after it
+func emitConcentratedSwap(hop routeHop) {
+	assetIn, assetOut := getPoolAsset1(hop.poolId), getPoolAsset0(hop.poolId)
+	if hop.input0 {
+		assetIn, assetOut = assetOut, assetIn
+	}
+	emitEvent("swap_executed",
+		"pool_id", jsonStr(hop.poolId),
+		"user", jsonStr(sdk.GetEnv().Sender.Address.String()),
+		"asset_in", jsonStr(assetIn),
+		"asset_out", jsonStr(assetOut),
+		"amount_in", jsonUint(hop.amountIn),
+		"amount_out", jsonUint(hop.amountOut),
+		"fee", jsonUint(hop.swap.fee),
+		"tick", jsonInt(hop.swap.tick),
+		"sqrt_price", jsonStr(hop.swap.sqrtPrice.String()),
+		"liquidity", jsonUint(hop.swap.liquidity.Uint64()),
+	)
 }
 
-// Execute two-hop swap via HBD
-func executeTwoHopSwap(instruction DexInstruction) *string {
-	// Find first pool: AssetIn -> HBD
-	pool1Id := findPool(instruction.AssetIn, "HBD")
-	if pool1Id == "" {
-		return &[]string{"error", "no pool found for first hop"}[1]
+// payReferral sends the instruction's referral cut of a swap's output to its
+// beneficiary and returns what is left for the recipient
+func payReferral(instruction DexInstruction, amountOut uint64, outputAsset string) uint64 {
+	if instruction.Beneficiary == nil || instruction.RefBps == nil {
+		return amountOut
 	}
+	refOut := amountOut * uint64(*instruction.RefBps) / 10000
+	if refOut > 0 {
+		if refOut >= amountOut {
+			refOut = amountOut - 1
+		}
+		amountOut -= refOut
+		transferAsset(*instruction.Beneficiary, int64(refOut), outputAsset)
+	}
+	return amountOut
+}
 
-	// Find second pool: HBD -> AssetOut
-	pool2Id := findPool("HBD", instruction.AssetOut)
-	if pool2Id == "" {
-		return &[]string{"error", "no pool found for second hop"}[1]
+// Place a resting limit order: amount_in of asset_in is escrowed until the
+// pool returns at least min_amount_out of asset_out for it, when anyone may
+// fill the order. Returns the order ID.
+func placeLimitOrder(instruction DexInstruction) *string {
+	if instruction.AmountIn == nil || *instruction.AmountIn <= 0 {
+		return &[]string{"error", "amount_in required for limit order"}[1]
+	}
+	if instruction.MinAmountOut == nil || *instruction.MinAmountOut <= 0 {
+		return &[]string{"error", "min_amount_out required for limit order"}[1]
+	}
+	poolId := findPool(instruction.AssetIn, instruction.AssetOut)
+	if poolId == "" {
+		return &[]string{"error", "pool not found"}[1]
 	}
 
-	// Get pool information
-	asset1_0 := getPoolAsset0(pool1Id)
-	r1_0 := getPoolReserve0(pool1Id)
-	r1_1 := getPoolReserve1(pool1Id)
-	fee1 := getPoolFee(pool1Id)
+	orderId := strconv.FormatUint(getUint(keyNextOrderId)+1, 10)
+	setUint(keyNextOrderId, getUint(keyNextOrderId)+1)
 
-	r2_0 := getPoolReserve0(pool2Id)
-	r2_1 := getPoolReserve1(pool2Id)
-	fee2 := getPoolFee(pool2Id)
+	owner := sdk.GetEnv().Sender.Address.String()
+	amountIn := uint64(*instruction.AmountIn)
+	minOut := uint64(*instruction.MinAmountOut)
+	drawAsset(int64(amountIn), instruction.AssetIn)
 
-	// Determine input amount (simplified - using MinAmountOut as input for now)
-	// In a real implementation, this would be passed explicitly or calculated
-	var amountIn uint64
-	if instruction.MinAmountOut != nil {
-		amountIn = uint64(*instruction.MinAmountOut)
-	} else {
-		return &[]string{"error", "amount_in required for two-hop swap"}[1]
-	}
+	setStr(orderKey(orderId, keyOrderOwner), owner)
+	setStr(orderKey(orderId, keyOrderRecipient), instruction.Recipient)
+	setStr(orderKey(orderId, keyOrderPool), poolId)
+	setStr(orderKey(orderId, keyOrderAssetIn), instruction.AssetIn)
+	setStr(orderKey(orderId, keyOrderAssetOut), instruction.AssetOut)
+	setUint(orderKey(orderId, keyOrderAmountIn), amountIn)
+	setUint(orderKey(orderId, keyOrderMinOut), minOut)
+
+	emitEvent("order_placed",
+		"order_id", jsonStr(orderId),
+		"pool_id", jsonStr(poolId),
+		"maker", jsonStr(owner),
+		"asset_in", jsonStr(instruction.AssetIn),
+		"asset_out", jsonStr(instruction.AssetOut),
+		"amount_in", jsonUint(amountIn),
+		"min_amount_out", jsonUint(minOut),
+	)
+	return &orderId
+}
 
-	// Calculate first hop: AssetIn -> HBD
-	var amountIntermediate uint64
-	if asset1_0 == instruction.AssetIn {
-		// AssetIn is asset0, HBD is asset1
-		k1 := r1_0 * r1_1
-		dxEff := amountIn * (10000 - fee1) / 10000
-		if dxEff == 0 {
-			dxEff = 1
-		}
-		newR0 := r1_0 + dxEff
-		amountIntermediate = r1_1 - (k1 / newR0)
+// Cancel a resting limit order and return its escrow to the maker. Only the
+// maker may cancel.
+func cancelLimitOrder(instruction DexInstruction) *string {
+	orderId, err := instructionOrder(instruction)
+	if err != nil {
+		return err
+	}
+	owner := getStr(orderKey(orderId, keyOrderOwner))
+	if sdk.GetEnv().Sender.Address.String() != owner {
+		return &[]string{"error", "only the maker can cancel an order"}[1]
+	}
 
-		// Update first pool reserves
-		setPoolReserve0(pool1Id, newR0)
-		setPoolReserve1(pool1Id, r1_1-amountIntermediate)
-	} else {
-		// AssetIn is asset1, HBD is asset0
-		k1 := r1_0 * r1_1
-		dyEff := amountIn * (10000 - fee1) / 10000
-		if dyEff == 0 {
-			dyEff = 1
-		}
-		newR1 := r1_1 + dyEff
-		amountIntermediate = r1_0 - (k1 / newR1)
-
-		// Update first pool reserves
-		setPoolReserve1(pool1Id, newR1)
-		setPoolReserve0(pool1Id, r1_0-amountIntermediate)
-	}
-
-	// Calculate second hop: HBD -> AssetOut
-	var amountOut uint64
-	if getPoolAsset0(pool2Id) == "HBD" {
-		// HBD is asset0, AssetOut is asset1
-		k2 := r2_0 * r2_1
-		dxEff := amountIntermediate * (10000 - fee2) / 10000
-		if dxEff == 0 {
-			dxEff = 1
-		}
-		newR0 := r2_0 + dxEff
-		amountOut = r2_1 - (k2 / newR0)
+	amountIn := getUint(orderKey(orderId, keyOrderAmountIn))
+	deleteOrder(orderId)
+	transferAsset(owner, int64(amountIn), instruction.AssetIn)
 
-		// Update second pool reserves
-		setPoolReserve0(pool2Id, newR0)
-		setPoolReserve1(pool2Id, r2_1-amountOut)
-	} else {
-		// HBD is asset1, AssetOut is asset0
-		k2 := r2_0 * r2_1
-		dyEff := amountIntermediate * (10000 - fee2) / 10000
-		if dyEff == 0 {
-			dyEff = 1
-		}
-		newR1 := r2_1 + dyEff
-		amountOut = r2_0 - (k2 / newR1)
+	emitEvent("order_cancelled",
+		"order_id", jsonStr(orderId),
+		"maker", jsonStr(owner),
+		"amount_in", jsonUint(amountIn),
+	)
+	return nil
+}
 
-		// Update second pool reserves
-		setPoolReserve1(pool2Id, newR1)
-		setPoolReserve0(pool2Id, r2_0-amountOut)
+// Fill a resting limit order against its pool once the pool's price has
+// crossed the order's limit. The maker pays orderMakerFeeBps of the output
+// to the taker who filled it, on top of the pool's own fee on the swap, and
+// must still receive the order's min_amount_out after it.
+func fillLimitOrder(instruction DexInstruction) *string {
+	orderId, err := instructionOrder(instruction)
+	if err != nil {
+		return err
 	}
 
-	// Apply slippage protection
-	if instruction.SlippageBps != nil {
-		minOut := amountOut * (10000 - uint64(*instruction.SlippageBps)) / 10000
-		if amountOut < minOut {
-			return &[]string{"error", "slippage tolerance exceeded"}[1]
-		}
+	poolId := getStr(orderKey(orderId, keyOrderPool))
+	amountIn := getUint(orderKey(orderId, keyOrderAmountIn))
+	minOut := getUint(orderKey(orderId, keyOrderMinOut))
+	hops, err := planRoute(instruction.AssetIn, instruction.AssetOut, []string{poolId})
+	if err != nil {
+		return err
+	}
+	amountOut, err := priceRoute(hops, amountIn)
+	if err != nil {
+		return err
+	}
+	makerFee := amountOut * orderMakerFeeBps / 10000
+	if amountOut-makerFee < minOut {
+		return &[]string{"error", "order limit price not reached"}[1]
 	}
 
-	// Execute the transfers
-	drawAsset(int64(amountIn), instruction.AssetIn)
-	transferAsset(instruction.Recipient, int64(amountOut), instruction.AssetOut)
+	maker := getStr(orderKey(orderId, keyOrderOwner))
+	recipient := getStr(orderKey(orderId, keyOrderRecipient))
+	taker := instruction.Recipient
+	deleteOrder(orderId)
 
-	// Accumulate fees (simplified - only for HBD in first hop)
-	if instruction.AssetIn == "HBD" {
-		fee := amountIn - (amountIn * (10000 - fee1) / 10000)
-		if fee > 0 {
-			if asset1_0 == "HBD" {
-				setUint(poolFee0Key(pool1Id), getUint(poolFee0Key(pool1Id))+fee)
-			} else {
-				setUint(poolFee1Key(pool1Id), getUint(poolFee1Key(pool1Id))+fee)
-			}
-		}
+	// The escrowed input is already held, so nothing is drawn
+	applyRoute(hops)
+	transferAsset(recipient, int64(amountOut-makerFee), instruction.AssetOut)
+	if makerFee > 0 {
+		transferAsset(taker, int64(makerFee), instruction.AssetOut)
 	}
 
+	emitEvent("order_filled",
+		"order_id", jsonStr(orderId),
+		"pool_id", jsonStr(poolId),
+		"maker", jsonStr(maker),
+		"taker", jsonStr(taker),
+		"asset_in", jsonStr(instruction.AssetIn),
+		"asset_out", jsonStr(instruction.AssetOut),
+		"amount_in", jsonUint(amountIn),
+		"amount_out", jsonUint(hops[0].amountOut),
+		"pool_fee", jsonUint(amountIn-hops[0].amountIn),
+		"maker_fee", jsonUint(makerFee),
+		"taker_reward", jsonUint(makerFee),
+	)
 	return nil
 }
 
+// instructionOrder returns the resting order named by metadata.order_id,
+// which must be for the instruction's asset pair
+func instructionOrder(instruction DexInstruction) (string, *string) {
+	orderId := instruction.Metadata["order_id"]
+	if orderId == "" {
+		return "", &[]string{"error", "order_id required in metadata"}[1]
+	}
+	if getStr(orderKey(orderId, keyOrderOwner)) == "" {
+		return "", &[]string{"error", "order not found"}[1]
+	}
+	if getStr(orderKey(orderId, keyOrderAssetIn)) != instruction.AssetIn ||
+		getStr(orderKey(orderId, keyOrderAssetOut)) != instruction.AssetOut {
+		return "", &[]string{"error", "order is for a different asset pair"}[1]
+	}
+	return orderId, nil
+}
+
+// deleteOrder removes a resting order's state
+func deleteOrder(orderId string) {
+	for _, suffix := range []string{
+		keyOrderOwner, keyOrderRecipient, keyOrderPool, keyOrderAssetIn,
+		keyOrderAssetOut, keyOrderAmountIn, keyOrderMinOut,
+	} {
+		sdk.StateDeleteObject(orderKey(orderId, suffix))
+	}
+}
+
 // Execute deposit (add liquidity)
 func executeDeposit(instruction DexInstruction) *string {
 	// Find the pool
@@ -459,6 +810,7 @@ func executeAddLiquidity(poolId string, amt0U, amt1U uint64, provider string) *s
 	contractAssert(minted > 0)
 
 	// Update state
+	accumulatePrice(poolId)
 	setPoolReserve0(poolId, r0+amt0U)
 	setPoolReserve1(poolId, r1+amt1U)
 	setPoolTotalLp(poolId, totalLP+minted)
@@ -486,6 +838,7 @@ func executeRemoveLiquidity(poolId string, lpAmountU uint64, provider string) *s
 	amt1 := int64(r1 * lpAmountU / totalLP)
 
 	// Update state first
+	accumulatePrice(poolId)
 	setPoolLp(poolId, providerAddr.String(), userLP-lpAmountU)
 	setPoolTotalLp(poolId, totalLP-lpAmountU)
 	setPoolReserve0(poolId, r0-uint64(amt0))
@@ -549,6 +902,13 @@ func GetPool(payload *string) *string {
 		Fee:      getPoolFee(poolId),
 		TotalLp:  getPoolTotalLp(poolId),
 	}
+	if isConcentrated(poolId) {
+		tick := getInt(poolKey(poolId, keyPoolTick))
+		poolInfo.TickSpacing = getUint(poolKey(poolId, keyPoolTickSpacing))
+		poolInfo.Tick = &tick
+		poolInfo.SqrtPrice = getBig(poolKey(poolId, keyPoolSqrtPrice)).String()
+		poolInfo.Liquidity = getUint(poolKey(poolId, keyPoolLiquidity))
+	}
 
 	resultBytes, err := tinyjson.Marshal(&poolInfo)
 	if err != nil {
@@ -559,6 +919,113 @@ func GetPool(payload *string) *string {
 	return &result
 }
 
+// Get a pool's price accumulators as of the current block, for contracts
+// that need a TWAP rather than a spot price they could move in one swap.
+// A caller saves two readings and divides the change in a cumulative by
+// the blocks between them to get the average Q64.64 price. A pool created
+// before the accumulators existed has no starting block, so the first
+// reading starts its accumulators from zero at the current block.
+// Payload: pool_id
+//
+//go:wasmexport get_price_cumulatives
+func GetPriceCumulatives(payload *string) *string {
+	if payload == nil {
+		return &[]string{"error", "pool_id required"}[1]
+	}
+
+	poolId := *payload
+	if getPoolAsset0(poolId) == "" {
+		return &[]string{"error", "pool not found"}[1]
+	}
+
+	height := sdk.GetEnv().BlockHeight
+	if getUint(poolKey(poolId, keyPoolPriceBlock)) == 0 {
+		setUint(poolKey(poolId, keyPoolPriceBlock), height)
+	}
+	price0, price1 := priceCumulatives(poolId, height)
+	cumulatives := PriceCumulatives{
+		PoolId:           poolId,
+		Price0Cumulative: price0.String(),
+		Price1Cumulative: price1.String(),
+		BlockHeight:      height,
+	}
+
+	resultBytes, err := tinyjson.Marshal(&cumulatives)
+	if err != nil {
+		return &[]string{"error", "serialization failed"}[1]
+	}
+
+	result := string(resultBytes)
+	return &result
+}
+
+// accumulatePrice brings a pool's price accumulators up to the current
+// block. It must run before anything that moves the pool's price, so each
+// block adds the price the pool held at its start. Later swaps in the same
+// block add nothing, which keeps a price pushed within one block out of
+// the accumulators until the next.
+func accumulatePrice(poolId string) {
+	height := sdk.GetEnv().BlockHeight
+	price0, price1 := priceCumulatives(poolId, height)
+	setBig(poolKey(poolId, keyPoolPrice0Cumulative), price0)
+	setBig(poolKey(poolId, keyPoolPrice1Cumulative), price1)
+	setUint(poolKey(poolId, keyPoolPriceBlock), height)
+}
+
+// priceCumulatives returns a pool's price accumulators extended to a block
+// height at its current price. Pools without a price, or without a recorded
+// starting block, accumulate nothing; get_price_cumulatives and
+// accumulatePrice record one.
+func priceCumulatives(poolId string, height uint64) (*big.Int, *big.Int) {
+	price0 := getBig(poolKey(poolId, keyPoolPrice0Cumulative))
+	price1 := getBig(poolKey(poolId, keyPoolPrice1Cumulative))
+
+	last := getUint(poolKey(poolId, keyPoolPriceBlock))
+	if last == 0 || height <= last {
+		return price0, price1
+	}
+
+	var spot0, spot1 *big.Int
+	var ok bool
+	if isConcentrated(poolId) {
+		spot0, spot1, ok = sqrtPricePrices(getBig(poolKey(poolId, keyPoolSqrtPrice)))
+	} else {
+		spot0, spot1, ok = reservePrices(getPoolReserve0(poolId), getPoolReserve1(poolId))
+	}
+	if !ok {
+		return price0, price1
+	}
+
+	blocks := new(big.Int).SetUint64(height - last)
+	price0.Add(price0, spot0.Mul(spot0, blocks))
+	price1.Add(price1, spot1.Mul(spot1, blocks))
+	return price0, price1
+}
+
+// reservePrices returns a constant product pool's Q64.64 prices, asset1 per
+// asset0 and asset0 per asset1, from its reserves
+func reservePrices(reserve0, reserve1 uint64) (*big.Int, *big.Int, bool) {
+	if reserve0 == 0 || reserve1 == 0 {
+		return nil, nil, false
+	}
+	r0, r1 := new(big.Int).SetUint64(reserve0), new(big.Int).SetUint64(reserve1)
+	price0 := new(big.Int).Lsh(r1, 64)
+	price1 := new(big.Int).Lsh(r0, 64)
+	return price0.Quo(price0, r0), price1.Quo(price1, r1), true
+}
+
+// sqrtPricePrices returns a concentrated pool's Q64.64 prices, asset1 per
+// asset0 and asset0 per asset1, from its Q64.64 square root price
+func sqrtPricePrices(sqrtPrice *big.Int) (*big.Int, *big.Int, bool) {
+	if sqrtPrice.Sign() <= 0 {
+		return nil, nil, false
+	}
+	squared := new(big.Int).Mul(sqrtPrice, sqrtPrice) // Q128.128
+	price0 := new(big.Int).Rsh(squared, 64)
+	price1 := new(big.Int).Lsh(q128, 64)
+	return price0, price1.Quo(price1, squared), true
+}
+
 // Claim fees (system only)
 // Payload: pool_id
 //
@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/json"
 	"math"
+	"math/big"
 	"testing"
 )
 
@@ -292,3 +293,59 @@ func TestMathFunctions(t *testing.T) {
 		}
 	})
 }
+
+func TestPriceAccumulatorPrices(t *testing.T) {
+	toFloat := func(x *big.Int) float64 {
+		f, _ := new(big.Float).Quo(new(big.Float).SetInt(x), new(big.Float).SetInt(q64)).Float64()
+		return f
+	}
+
+	t.Run("Constant product prices", func(t *testing.T) {
+		price0, price1, ok := reservePrices(1000000, 4000000)
+		if !ok {
+			t.Fatal("reservePrices() reported no price for a funded pool")
+		}
+		if got := toFloat(price0); got != 4 {
+			t.Errorf("price0 = %v, want 4", got)
+		}
+		if got := toFloat(price1); got != 0.25 {
+			t.Errorf("price1 = %v, want 0.25", got)
+		}
+
+		if _, _, ok := reservePrices(0, 4000000); ok {
+			t.Error("reservePrices() reported a price for an empty pool")
+		}
+	})
+
+	t.Run("Concentrated prices match the tick", func(t *testing.T) {
+		price0, price1, ok := sqrtPricePrices(sqrtPriceAtTick(13860))
+		if !ok {
+			t.Fatal("sqrtPricePrices() reported no price")
+		}
+		want := math.Pow(1.0001, 13860)
+		if got := toFloat(price0); math.Abs(got/want-1) > 1e-9 {
+			t.Errorf("price0 = %v, want %v", got, want)
+		}
+		if got := toFloat(price1); math.Abs(got*want-1) > 1e-9 {
+			t.Errorf("price1 = %v, want %v", got, 1/want)
+		}
+	})
+}
+
+func TestEventStrings(t *testing.T) {
+	// Sender-chosen strings must still leave a valid event
+	for _, s := range []string{"hive:alice", `quote " and \ backslash`, "tab\tnewline\n", "bell\a nul\x00 del\x7f", "héllo ✓"} {
+		encoded := jsonStr(s)
+		var decoded string
+		if err := json.Unmarshal([]byte(encoded), &decoded); err != nil {
+			t.Errorf("jsonStr(%q) = %s, not valid JSON: %v", s, encoded, err)
+		} else if decoded != s {
+			t.Errorf("jsonStr(%q) decodes to %q", s, decoded)
+		}
+	}
+
+	// Invalid UTF-8 is replaced rather than escaped
+	if encoded := jsonStr("bad utf8 \xff"); !json.Valid([]byte(encoded)) {
+		t.Errorf("jsonStr() = %s, not valid JSON", encoded)
+	}
+}
@@ -10,10 +10,15 @@ type DexInstruction struct {
 	AssetOut      string            `json:"asset_out"`
 	Recipient     string            `json:"recipient"`
 	SlippageBps   *int              `json:"slippage_bps,omitempty"`
+	AmountIn      *int64            `json:"amount_in,omitempty"`
 	MinAmountOut  *int64            `json:"min_amount_out,omitempty"`
+	AmountOut     *int64            `json:"amount_out,omitempty"`
+	MaxAmountIn   *int64            `json:"max_amount_in,omitempty"`
+	Route         []string          `json:"route,omitempty"`
 	Beneficiary   *string           `json:"beneficiary,omitempty"`
 	RefBps        *int              `json:"ref_bps,omitempty"`
 	ReturnAddress *ReturnAddress    `json:"return_address,omitempty"`
+	Deadline      *int64            `json:"deadline,omitempty"` // Unix seconds after which the instruction is refused
 	Metadata      map[string]string `json:"metadata,omitempty"`
 }
 
@@ -22,6 +27,10 @@ type CreatePoolParams struct {
 	Asset0 string `json:"asset0"`
 	Asset1 string `json:"asset1"`
 	FeeBps uint64 `json:"fee_bps"`
+
+	// A tick spacing makes the pool concentrated, starting at initial_tick
+	TickSpacing uint64 `json:"tick_spacing,omitempty"`
+	InitialTick int64  `json:"initial_tick,omitempty"`
 }
 
 //tinyjson:json
@@ -32,6 +41,25 @@ type PoolInfo struct {
 	Reserve1 uint64 `json:"reserve1"`
 	Fee      uint64 `json:"fee"`
 	TotalLp  uint64 `json:"total_lp"`
+
+	// Concentrated pools only
+	TickSpacing uint64 `json:"tick_spacing,omitempty"`
+	Tick        *int64 `json:"tick,omitempty"`
+	SqrtPrice   string `json:"sqrt_price,omitempty"` // Q64.64
+	Liquidity   uint64 `json:"liquidity,omitempty"`  // In range at the current tick
+}
+
+// A pool's price accumulators as of block_height. Each is the sum over
+// blocks of the pool's Q64.64 price at the start of that block, so a
+// TWAP between two readings is the difference of their cumulatives over
+// the blocks between them.
+//
+//tinyjson:json
+type PriceCumulatives struct {
+	PoolId           string `json:"pool_id"`
+	Price0Cumulative string `json:"price0_cumulative"` // asset1 per asset0
+	Price1Cumulative string `json:"price1_cumulative"` // asset0 per asset1
+	BlockHeight      uint64 `json:"block_height"`
 }
 
 //tinyjson:json
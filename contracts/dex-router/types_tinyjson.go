@@ -105,6 +105,22 @@ func tinyjsonA17a9c65DecodeExampleComBuildingTinyjsonDex1(in *jlexer.Lexer, out
 			out.Fee = uint64(in.Uint64())
 		case "total_lp":
 			out.TotalLp = uint64(in.Uint64())
+		case "tick_spacing":
+			out.TickSpacing = uint64(in.Uint64())
+		case "tick":
+			if in.IsNull() {
+				in.Skip()
+				out.Tick = nil
+			} else {
+				if out.Tick == nil {
+					out.Tick = new(int64)
+				}
+				*out.Tick = int64(in.Int64())
+			}
+		case "sqrt_price":
+			out.SqrtPrice = string(in.String())
+		case "liquidity":
+			out.Liquidity = uint64(in.Uint64())
 		default:
 			in.SkipRecursive()
 		}
@@ -149,6 +165,26 @@ func tinyjsonA17a9c65EncodeExampleComBuildingTinyjsonDex1(out *jwriter.Writer, i
 		out.RawString(prefix)
 		out.Uint64(uint64(in.TotalLp))
 	}
+	if in.TickSpacing != 0 {
+		const prefix string = ",\"tick_spacing\":"
+		out.RawString(prefix)
+		out.Uint64(uint64(in.TickSpacing))
+	}
+	if in.Tick != nil {
+		const prefix string = ",\"tick\":"
+		out.RawString(prefix)
+		out.Int64(int64(*in.Tick))
+	}
+	if in.SqrtPrice != "" {
+		const prefix string = ",\"sqrt_price\":"
+		out.RawString(prefix)
+		out.String(string(in.SqrtPrice))
+	}
+	if in.Liquidity != 0 {
+		const prefix string = ",\"liquidity\":"
+		out.RawString(prefix)
+		out.Uint64(uint64(in.Liquidity))
+	}
 	out.RawByte('}')
 }
 
@@ -200,6 +236,16 @@ func tinyjsonA17a9c65DecodeExampleComBuildingTinyjsonDex2(in *jlexer.Lexer, out
 				}
 				*out.SlippageBps = int(in.Int())
 			}
+		case "amount_in":
+			if in.IsNull() {
+				in.Skip()
+				out.AmountIn = nil
+			} else {
+				if out.AmountIn == nil {
+					out.AmountIn = new(int64)
+				}
+				*out.AmountIn = int64(in.Int64())
+			}
 		case "min_amount_out":
 			if in.IsNull() {
 				in.Skip()
@@ -210,6 +256,49 @@ func tinyjsonA17a9c65DecodeExampleComBuildingTinyjsonDex2(in *jlexer.Lexer, out
 				}
 				*out.MinAmountOut = int64(in.Int64())
 			}
+		case "amount_out":
+			if in.IsNull() {
+				in.Skip()
+				out.AmountOut = nil
+			} else {
+				if out.AmountOut == nil {
+					out.AmountOut = new(int64)
+				}
+				*out.AmountOut = int64(in.Int64())
+			}
+		case "max_amount_in":
+			if in.IsNull() {
+				in.Skip()
+				out.MaxAmountIn = nil
+			} else {
+				if out.MaxAmountIn == nil {
+					out.MaxAmountIn = new(int64)
+				}
+				*out.MaxAmountIn = int64(in.Int64())
+			}
+		case "route":
+			if in.IsNull() {
+				in.Skip()
+				out.Route = nil
+			} else {
+				in.Delim('[')
+				if out.Route == nil {
+					if !in.IsDelim(']') {
+						out.Route = make([]string, 0, 4)
+					} else {
+						out.Route = []string{}
+					}
+				} else {
+					out.Route = (out.Route)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v1 string
+					v1 = string(in.String())
+					out.Route = append(out.Route, v1)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
 		case "beneficiary":
 			if in.IsNull() {
 				in.Skip()
@@ -240,6 +329,16 @@ func tinyjsonA17a9c65DecodeExampleComBuildingTinyjsonDex2(in *jlexer.Lexer, out
 				}
 				(*out.ReturnAddress).UnmarshalTinyJSON(in)
 			}
+		case "deadline":
+			if in.IsNull() {
+				in.Skip()
+				out.Deadline = nil
+			} else {
+				if out.Deadline == nil {
+					out.Deadline = new(int64)
+				}
+				*out.Deadline = int64(in.Int64())
+			}
 		case "metadata":
 			if in.IsNull() {
 				in.Skip()
@@ -253,9 +352,9 @@ func tinyjsonA17a9c65DecodeExampleComBuildingTinyjsonDex2(in *jlexer.Lexer, out
 				for !in.IsDelim('}') {
 					key := string(in.String())
 					in.WantColon()
-					var v1 string
-					v1 = string(in.String())
-					(out.Metadata)[key] = v1
+					var v2 string
+					v2 = string(in.String())
+					(out.Metadata)[key] = v2
 					in.WantComma()
 				}
 				in.Delim('}')
@@ -304,11 +403,40 @@ func tinyjsonA17a9c65EncodeExampleComBuildingTinyjsonDex2(out *jwriter.Writer, i
 		out.RawString(prefix)
 		out.Int(int(*in.SlippageBps))
 	}
+	if in.AmountIn != nil {
+		const prefix string = ",\"amount_in\":"
+		out.RawString(prefix)
+		out.Int64(int64(*in.AmountIn))
+	}
 	if in.MinAmountOut != nil {
 		const prefix string = ",\"min_amount_out\":"
 		out.RawString(prefix)
 		out.Int64(int64(*in.MinAmountOut))
 	}
+	if in.AmountOut != nil {
+		const prefix string = ",\"amount_out\":"
+		out.RawString(prefix)
+		out.Int64(int64(*in.AmountOut))
+	}
+	if in.MaxAmountIn != nil {
+		const prefix string = ",\"max_amount_in\":"
+		out.RawString(prefix)
+		out.Int64(int64(*in.MaxAmountIn))
+	}
+	if len(in.Route) != 0 {
+		const prefix string = ",\"route\":"
+		out.RawString(prefix)
+		{
+			out.RawByte('[')
+			for v3, v4 := range in.Route {
+				if v3 > 0 {
+					out.RawByte(',')
+				}
+				out.String(string(v4))
+			}
+			out.RawByte(']')
+		}
+	}
 	if in.Beneficiary != nil {
 		const prefix string = ",\"beneficiary\":"
 		out.RawString(prefix)
@@ -324,21 +452,26 @@ func tinyjsonA17a9c65EncodeExampleComBuildingTinyjsonDex2(out *jwriter.Writer, i
 		out.RawString(prefix)
 		(*in.ReturnAddress).MarshalTinyJSON(out)
 	}
+	if in.Deadline != nil {
+		const prefix string = ",\"deadline\":"
+		out.RawString(prefix)
+		out.Int64(int64(*in.Deadline))
+	}
 	if len(in.Metadata) != 0 {
 		const prefix string = ",\"metadata\":"
 		out.RawString(prefix)
 		{
 			out.RawByte('{')
-			v2First := true
-			for v2Name, v2Value := range in.Metadata {
-				if v2First {
-					v2First = false
+			v5First := true
+			for v5Name, v5Value := range in.Metadata {
+				if v5First {
+					v5First = false
 				} else {
 					out.RawByte(',')
 				}
-				out.String(string(v2Name))
+				out.String(string(v5Name))
 				out.RawByte(':')
-				out.String(string(v2Value))
+				out.String(string(v5Value))
 			}
 			out.RawByte('}')
 		}
@@ -380,6 +513,10 @@ func tinyjsonA17a9c65DecodeExampleComBuildingTinyjsonDex3(in *jlexer.Lexer, out
 			out.Asset1 = string(in.String())
 		case "fee_bps":
 			out.FeeBps = uint64(in.Uint64())
+		case "tick_spacing":
+			out.TickSpacing = uint64(in.Uint64())
+		case "initial_tick":
+			out.InitialTick = int64(in.Int64())
 		default:
 			in.SkipRecursive()
 		}
@@ -409,6 +546,16 @@ func tinyjsonA17a9c65EncodeExampleComBuildingTinyjsonDex3(out *jwriter.Writer, i
 		out.RawString(prefix)
 		out.Uint64(uint64(in.FeeBps))
 	}
+	if in.TickSpacing != 0 {
+		const prefix string = ",\"tick_spacing\":"
+		out.RawString(prefix)
+		out.Uint64(uint64(in.TickSpacing))
+	}
+	if in.InitialTick != 0 {
+		const prefix string = ",\"initial_tick\":"
+		out.RawString(prefix)
+		out.Int64(int64(in.InitialTick))
+	}
 	out.RawByte('}')
 }
 
@@ -421,3 +568,76 @@ func (v CreatePoolParams) MarshalTinyJSON(w *jwriter.Writer) {
 func (v *CreatePoolParams) UnmarshalTinyJSON(l *jlexer.Lexer) {
 	tinyjsonA17a9c65DecodeExampleComBuildingTinyjsonDex3(l, v)
 }
+func tinyjsonA17a9c65DecodeExampleComBuildingTinyjsonDex4(in *jlexer.Lexer, out *PriceCumulatives) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "pool_id":
+			out.PoolId = string(in.String())
+		case "price0_cumulative":
+			out.Price0Cumulative = string(in.String())
+		case "price1_cumulative":
+			out.Price1Cumulative = string(in.String())
+		case "block_height":
+			out.BlockHeight = uint64(in.Uint64())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func tinyjsonA17a9c65EncodeExampleComBuildingTinyjsonDex4(out *jwriter.Writer, in PriceCumulatives) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"pool_id\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.PoolId))
+	}
+	{
+		const prefix string = ",\"price0_cumulative\":"
+		out.RawString(prefix)
+		out.String(string(in.Price0Cumulative))
+	}
+	{
+		const prefix string = ",\"price1_cumulative\":"
+		out.RawString(prefix)
+		out.String(string(in.Price1Cumulative))
+	}
+	{
+		const prefix string = ",\"block_height\":"
+		out.RawString(prefix)
+		out.Uint64(uint64(in.BlockHeight))
+	}
+	out.RawByte('}')
+}
+
+// MarshalTinyJSON supports tinyjson.Marshaler interface
+func (v PriceCumulatives) MarshalTinyJSON(w *jwriter.Writer) {
+	tinyjsonA17a9c65EncodeExampleComBuildingTinyjsonDex4(w, v)
+}
+
+// UnmarshalTinyJSON supports tinyjson.Unmarshaler interface
+func (v *PriceCumulatives) UnmarshalTinyJSON(l *jlexer.Lexer) {
+	tinyjsonA17a9c65DecodeExampleComBuildingTinyjsonDex4(l, v)
+}
@@ -2,8 +2,11 @@ package main
 
 import (
 	sdk "dex-router/sdk"
+	"math/big"
 	"math/bits"
 	"strconv"
+
+	jwriter "github.com/CosmWasm/tinyjson/jwriter"
 )
 
 // Keys for state storage
@@ -21,6 +24,50 @@ const (
 	keyPoolFee0         = "fee0"
 	keyPoolFee1         = "fee1"
 	keyPoolFeeLastClaim = "fee_last_claim"
+	keyCommitPrefix     = "commit/"           // commit/{sender}/{hash}
+	keyCommitQueue      = "commit_queue/"     // commit_queue/{n}, the commitment key committed nth
+	keyCommitQueueHead  = "commit_queue_head" // Oldest queued commitment not yet pruned
+	keyCommitQueueTail  = "commit_queue_tail" // Where the next commitment is queued
+	keyNextOrderId      = "next_order_id"
+	keyOrderPrefix      = "order/" // order/{orderId}/...
+	keyOrderOwner       = "owner"
+	keyOrderRecipient   = "recipient"
+	keyOrderPool        = "pool"
+	keyOrderAssetIn     = "asset_in"
+	keyOrderAssetOut    = "asset_out"
+	keyOrderAmountIn    = "amount_in"
+	keyOrderMinOut      = "min_amount_out"
+
+	// Concentrated pools
+	keyPoolTickSpacing    = "tick_spacing"
+	keyPoolSqrtPrice      = "sqrt_price" // Q64.64
+	keyPoolTick           = "tick"
+	keyPoolLiquidity      = "liquidity"       // In range at the current tick
+	keyPoolLiquidityTotal = "liquidity_total" // Of every position
+	keyPoolFeeGrowth0     = "fee_growth0"     // Q128 per unit of liquidity
+	keyPoolFeeGrowth1     = "fee_growth1"
+	keyPoolTicks          = "ticks" // Initialized ticks, ascending and comma separated
+	keyPoolTickPrefix     = "tick/" // tick/{tick}/...
+	keyTickGross          = "gross"
+	keyTickNet            = "net"
+	keyTickOutside0       = "outside0"
+	keyTickOutside1       = "outside1"
+	keyNextPositionId     = "next_position_id"
+	keyPositionPrefix     = "position/" // position/{positionId}/...
+	keyPositionOwner      = "owner"
+	keyPositionPool       = "pool"
+	keyPositionLower      = "tick_lower"
+	keyPositionUpper      = "tick_upper"
+	keyPositionLiquidity  = "liquidity"
+	keyPositionGrowth0    = "fee_growth0" // Fee growth inside the range when fees were last accrued
+	keyPositionGrowth1    = "fee_growth1"
+	keyPositionOwed0      = "owed0" // Fees and burned liquidity not yet collected
+	keyPositionOwed1      = "owed1"
+
+	// Price accumulators
+	keyPoolPrice0Cumulative = "price0_cumulative" // Sum of Q64.64 prices times blocks
+	keyPoolPrice1Cumulative = "price1_cumulative"
+	keyPoolPriceBlock       = "price_block" // Block height the cumulatives were last updated at
 )
 
 const (
@@ -28,8 +75,38 @@ const (
 	defaultFeeClaimIntervalS = 86400 // 1 day
 	defaultSlipBaselineBps   = 0     // off by default
 	defaultSlipShareBps      = 0     // off by default
+	commitRevealWindowBlocks = 200   // Blocks a commitment can be revealed in
+	maxCommitPrunes          = 8     // Most expired commitments one commit deletes
+	maxRouteHops             = 4     // Most pools a routed swap may pass through
+	orderMakerFeeBps         = 10    // Share of a limit order's output paid to whoever fills it
 )
 
+// commitKey is where a sender's commitment to a payload hash is stored
+func commitKey(sender, hash string) string {
+	return keyCommitPrefix + sender + "/" + hash
+}
+
+// commitQueueKey is where the nth commitment's key is queued for pruning
+func commitQueueKey(n uint64) string {
+	return keyCommitQueue + strconv.FormatUint(n, 10)
+}
+
+// orderKey is where a field of a resting limit order is stored
+func orderKey(orderId, suffix string) string {
+	return keyOrderPrefix + orderId + "/" + suffix
+}
+
+// tickKey is where a field of an initialized tick of a concentrated pool is
+// stored
+func tickKey(poolId string, tick int64, suffix string) string {
+	return poolKey(poolId, keyPoolTickPrefix+strconv.FormatInt(tick, 10)+"/"+suffix)
+}
+
+// positionKey is where a field of a concentrated liquidity position is stored
+func positionKey(positionId, suffix string) string {
+	return keyPositionPrefix + positionId + "/" + suffix
+}
+
 // Pool key helpers
 func poolKey(poolId string, suffix string) string {
 	return keyPoolPrefix + poolId + "/" + suffix
@@ -114,6 +191,20 @@ func setInt(key string, val int64) {
 	sdk.StateSetObject(key, strconv.FormatInt(val, 10))
 }
 
+// getBig reads a number too large for 64 bits, such as a sqrt price or fee
+// growth
+func getBig(key string) *big.Int {
+	n := new(big.Int)
+	if v := sdk.StateGetObject(key); v != nil {
+		n.SetString(*v, 10)
+	}
+	return n
+}
+
+func setBig(key string, val *big.Int) {
+	sdk.StateSetObject(key, val.String())
+}
+
 // Pool state helpers
 func getPoolAsset0(poolId string) string {
 	return getStr(poolAsset0Key(poolId))
@@ -212,11 +303,68 @@ func transferAsset(to string, amount int64, asset string) {
 	sdk.HiveTransfer(sdk.Address(to), amount, sdk.Asset(asset))
 }
 
+// emitEvent logs an event for indexers as {"method": name, "args": {...}},
+// the shape the indexer reads contract output in. fields alternate keys and
+// JSON encoded values.
+func emitEvent(name string, fields ...string) {
+	event := `{"method":` + jsonStr(name) + `,"args":{`
+	for i := 0; i+1 < len(fields); i += 2 {
+		if i > 0 {
+			event += ","
+		}
+		event += jsonStr(fields[i]) + ":" + fields[i+1]
+	}
+	sdk.Log(event + "}}")
+}
+
+// jsonStr encodes an account, asset or ID as a JSON string
+func jsonStr(s string) string {
+	var w jwriter.Writer
+	w.String(s)
+	return string(w.Buffer.BuildBytes())
+}
+
+// jsonUint encodes an amount as a JSON number
+func jsonUint(n uint64) string {
+	return strconv.FormatUint(n, 10)
+}
+
+// jsonInt encodes a signed value, such as a tick, as a JSON number
+func jsonInt(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
+
 // Check if asset is HBD
 func isHbd(asset string) bool {
 	return asset == "HBD"
 }
 
+// mulDiv returns floor(a * b / c) without overflowing the product; the
+// result must fit in 64 bits
+func mulDiv(a, b, c uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	contractAssert(hi < c)
+	q, _ := bits.Div64(hi, lo, c)
+	return q
+}
+
+// mulDivUp returns ceil(a * b / c) without overflowing the product; the
+// result must fit in 64 bits
+func mulDivUp(a, b, c uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	contractAssert(hi < c)
+	q, r := bits.Div64(hi, lo, c)
+	if r > 0 {
+		q++
+	}
+	return q
+}
+
+// ceilDiv returns ceil(a / b)
+func ceilDiv(a, b uint64) uint64 {
+	return (a + b - 1) / b
+}
+
 // sqrt128 returns floor(sqrt(hi:lo)) where hi:lo is a 128-bit unsigned integer
 func sqrt128(hi, lo uint64) uint64 {
 	var low, high uint64 = 0, ^uint64(0) >> 1
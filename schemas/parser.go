@@ -51,6 +51,28 @@ func ParseFromQueryParams(query string) (*SwapInstruction, error) {
 		}
 	}
 
+	if amountInStr := values.Get("amount_in"); amountInStr != "" {
+		if amountIn, err := strconv.ParseInt(amountInStr, 10, 64); err == nil {
+			instruction.AmountIn = &amountIn
+		}
+	}
+
+	if amountOutStr := values.Get("amount_out"); amountOutStr != "" {
+		if amountOut, err := strconv.ParseInt(amountOutStr, 10, 64); err == nil {
+			instruction.AmountOut = &amountOut
+		}
+	}
+
+	if maxAmountInStr := values.Get("max_amount_in"); maxAmountInStr != "" {
+		if maxAmountIn, err := strconv.ParseInt(maxAmountInStr, 10, 64); err == nil {
+			instruction.MaxAmountIn = &maxAmountIn
+		}
+	}
+
+	if route := values.Get("route"); route != "" {
+		instruction.Route = strings.Split(route, ",")
+	}
+
 	if beneficiary := values.Get("beneficiary"); beneficiary != "" {
 		instruction.Beneficiary = &beneficiary
 	}
@@ -61,6 +83,12 @@ func ParseFromQueryParams(query string) (*SwapInstruction, error) {
 		}
 	}
 
+	if deadlineStr := values.Get("deadline"); deadlineStr != "" {
+		if deadline, err := strconv.ParseInt(deadlineStr, 10, 64); err == nil {
+			instruction.Deadline = &deadline
+		}
+	}
+
 	if chain := values.Get("return_address.chain"); chain != "" {
 		if address := values.Get("return_address.address"); address != "" {
 			instruction.ReturnAddr = &ReturnAddress{
@@ -72,6 +72,18 @@ func TestParseFromJSON(t *testing.T) {
 			}`,
 			expectError: true,
 		},
+		{
+			name: "fill order without order_id",
+			jsonData: `{
+				"type": "fill_order",
+				"version": "1.0.0",
+				"asset_in": "HBD",
+				"asset_out": "HIVE",
+				"recipient": "bob",
+				"metadata": {"notes": "test"}
+			}`,
+			expectError: true,
+		},
 		{
 			name:     "invalid JSON",
 			jsonData: `{invalid json}`,
@@ -126,7 +138,7 @@ func TestParseFromQueryParams(t *testing.T) {
 		},
 		{
 			name:  "query with optional fields",
-			query: "type=swap&version=1.0.0&asset_in=BTC&asset_out=HBD&recipient=alice&slippage_bps=200&min_amount_out=50000&beneficiary=referrer&ref_bps=500&return_address.chain=ETH&return_address.address=0x123",
+			query: "type=swap&version=1.0.0&asset_in=BTC&asset_out=HBD&recipient=alice&slippage_bps=200&amount_in=100000&min_amount_out=50000&route=3,1&beneficiary=referrer&ref_bps=500&deadline=1735689600&return_address.chain=ETH&return_address.address=0x123",
 			expectError: false,
 			expected: &SwapInstruction{
 				InstructionType: "swap",
@@ -135,9 +147,12 @@ func TestParseFromQueryParams(t *testing.T) {
 				AssetOut:        "HBD",
 				Recipient:       "alice",
 				SlippageBps:     intPtr(200),
+				AmountIn:        int64Ptr(100000),
 				MinAmountOut:    int64Ptr(50000),
+				Route:           []string{"3", "1"},
 				Beneficiary:     stringPtr("referrer"),
 				RefBps:          intPtr(500),
+				Deadline:        int64Ptr(1735689600),
 				ReturnAddr:      &ReturnAddress{Chain: "ETH", Address: "0x123"},
 			},
 		},
@@ -169,6 +184,9 @@ func TestParseFromQueryParams(t *testing.T) {
 			assert.Equal(t, tt.expected.AssetIn, result.AssetIn)
 			assert.Equal(t, tt.expected.AssetOut, result.AssetOut)
 			assert.Equal(t, tt.expected.Recipient, result.Recipient)
+			assert.Equal(t, tt.expected.Deadline, result.Deadline)
+			assert.Equal(t, tt.expected.AmountIn, result.AmountIn)
+			assert.Equal(t, tt.expected.Route, result.Route)
 		})
 	}
 }
@@ -290,6 +308,153 @@ func TestValidateInstruction(t *testing.T) {
 			}`,
 			expectError: true,
 		},
+		{
+			name: "negative deadline",
+			jsonData: `{
+				"type": "swap",
+				"version": "1.0.0",
+				"asset_in": "BTC",
+				"asset_out": "HBD",
+				"recipient": "alice",
+				"deadline": -1
+			}`,
+			expectError: true,
+		},
+		{
+			name: "route with amount_in",
+			jsonData: `{
+				"type": "swap",
+				"version": "1.0.0",
+				"asset_in": "BTC",
+				"asset_out": "HIVE",
+				"recipient": "alice",
+				"amount_in": 100000,
+				"route": ["3", "1"]
+			}`,
+			expectError: false,
+		},
+		{
+			name: "route without amount_in",
+			jsonData: `{
+				"type": "swap",
+				"version": "1.0.0",
+				"asset_in": "BTC",
+				"asset_out": "HIVE",
+				"recipient": "alice",
+				"route": ["3", "1"]
+			}`,
+			expectError: true,
+		},
+		{
+			name: "exact output swap",
+			jsonData: `{
+				"type": "swap_exact_out",
+				"version": "1.0.0",
+				"asset_in": "HBD",
+				"asset_out": "HIVE",
+				"recipient": "alice",
+				"amount_out": 40000,
+				"max_amount_in": 10500,
+				"route": ["1"]
+			}`,
+			expectError: false,
+		},
+		{
+			name: "exact output swap without max_amount_in",
+			jsonData: `{
+				"type": "swap_exact_out",
+				"version": "1.0.0",
+				"asset_in": "HBD",
+				"asset_out": "HIVE",
+				"recipient": "alice",
+				"amount_out": 40000
+			}`,
+			expectError: true,
+		},
+		{
+			name: "limit order",
+			jsonData: `{
+				"type": "limit_order",
+				"version": "1.0.0",
+				"asset_in": "HBD",
+				"asset_out": "HIVE",
+				"recipient": "alice",
+				"amount_in": 10000,
+				"min_amount_out": 41000
+			}`,
+			expectError: false,
+		},
+		{
+			name: "limit order without limit price",
+			jsonData: `{
+				"type": "limit_order",
+				"version": "1.0.0",
+				"asset_in": "HBD",
+				"asset_out": "HIVE",
+				"recipient": "alice",
+				"amount_in": 10000,
+				"min_amount_out": 0
+			}`,
+			expectError: true,
+		},
+		{
+			name: "fill order",
+			jsonData: `{
+				"type": "fill_order",
+				"version": "1.0.0",
+				"asset_in": "HBD",
+				"asset_out": "HIVE",
+				"recipient": "bob",
+				"metadata": {"order_id": "1"}
+			}`,
+			expectError: false,
+		},
+		{
+			name: "mint position",
+			jsonData: `{
+				"type": "mint_position",
+				"version": "1.0.0",
+				"asset_in": "HBD",
+				"asset_out": "HIVE",
+				"recipient": "alice",
+				"metadata": {"pool_id": "3", "tick_lower": "12000", "tick_upper": "15000", "amount0": "1000000", "amount1": "4000000"}
+			}`,
+			expectError: false,
+		},
+		{
+			name: "mint position without range",
+			jsonData: `{
+				"type": "mint_position",
+				"version": "1.0.0",
+				"asset_in": "HBD",
+				"asset_out": "HIVE",
+				"recipient": "alice",
+				"metadata": {"pool_id": "3", "amount0": "1000000", "amount1": "4000000"}
+			}`,
+			expectError: true,
+		},
+		{
+			name: "collect position without position_id",
+			jsonData: `{
+				"type": "collect_position",
+				"version": "1.0.0",
+				"asset_in": "HBD",
+				"asset_out": "HIVE",
+				"recipient": "alice"
+			}`,
+			expectError: true,
+		},
+		{
+			name: "cancel order without order_id",
+			jsonData: `{
+				"type": "cancel_order",
+				"version": "1.0.0",
+				"asset_in": "HBD",
+				"asset_out": "HIVE",
+				"recipient": "alice"
+			}`,
+			expectError: true,
+		},
 		{
 			name: "invalid type",
 			jsonData: `{
@@ -23,9 +23,14 @@ type SwapInstruction struct {
 	AssetOut        string                 `json:"asset_out"`
 	Recipient       string                 `json:"recipient"`
 	SlippageBps     *int                   `json:"slippage_bps,omitempty"`
+	AmountIn        *int64                 `json:"amount_in,omitempty"` // Input amount; required with Route
 	MinAmountOut    *int64                 `json:"min_amount_out,omitempty"`
+	AmountOut       *int64                 `json:"amount_out,omitempty"`    // Exact output; swap_exact_out only
+	MaxAmountIn     *int64                 `json:"max_amount_in,omitempty"` // Most input spent; swap_exact_out only
+	Route           []string               `json:"route,omitempty"` // Pool IDs the swap passes through, in order
 	Beneficiary     *string                `json:"beneficiary,omitempty"`
 	RefBps          *int                   `json:"ref_bps,omitempty"`
+	Deadline        *int64                 `json:"deadline,omitempty"` // Unix seconds after which the swap is neither broadcast nor executed
 	ReturnAddr      *ReturnAddress         `json:"return_address,omitempty"`
 	Metadata        map[string]interface{} `json:"metadata,omitempty"`
 }
@@ -57,6 +62,42 @@ func (s SwapInstruction) Validate() error {
 	if s.Recipient == "" {
 		return &ValidationError{Field: "recipient", Message: "recipient is required"}
 	}
+	if s.InstructionType == "swap_exact_out" {
+		if s.AmountOut == nil {
+			return &ValidationError{Field: "amount_out", Message: "amount_out is required for swap_exact_out"}
+		}
+		if s.MaxAmountIn == nil {
+			return &ValidationError{Field: "max_amount_in", Message: "max_amount_in is required for swap_exact_out"}
+		}
+	}
+	if s.InstructionType == "limit_order" {
+		if s.AmountIn == nil {
+			return &ValidationError{Field: "amount_in", Message: "amount_in is required for limit_order"}
+		}
+		if s.MinAmountOut == nil || *s.MinAmountOut <= 0 {
+			return &ValidationError{Field: "min_amount_out", Message: "min_amount_out is required for limit_order"}
+		}
+	}
+	if s.InstructionType == "cancel_order" || s.InstructionType == "fill_order" {
+		if orderID, _ := s.Metadata["order_id"].(string); orderID == "" {
+			return &ValidationError{Field: "metadata.order_id", Message: "order_id is required to cancel or fill an order"}
+		}
+	}
+	if s.InstructionType == "mint_position" {
+		for _, key := range []string{"pool_id", "tick_lower", "tick_upper", "amount0", "amount1"} {
+			if value, _ := s.Metadata[key].(string); value == "" {
+				return &ValidationError{Field: "metadata." + key, Message: key + " is required for mint_position"}
+			}
+		}
+	}
+	if s.InstructionType == "burn_position" || s.InstructionType == "collect_position" {
+		if positionID, _ := s.Metadata["position_id"].(string); positionID == "" {
+			return &ValidationError{Field: "metadata.position_id", Message: "position_id is required to burn or collect a position"}
+		}
+	}
+	if len(s.Route) > 0 && s.AmountIn == nil && s.InstructionType != "swap_exact_out" {
+		return &ValidationError{Field: "amount_in", Message: "amount_in is required with route"}
+	}
 	return nil
 }
 
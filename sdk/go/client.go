@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 
 	contracts "vsc-node/modules/db/vsc/contracts"
 	transactionpool "vsc-node/modules/transaction-pool"
@@ -21,6 +23,13 @@ type Intent struct {
 	Args map[string]string `json:"args"`
 }
 
+// DexOperation is one contract call within a batched transaction
+type DexOperation struct {
+	OperationType string
+	Payload       string
+	Intents       []Intent
+}
+
 // Client provides SDK methods for VSC DEX mapping operations
 type Client struct {
 	config             Config
@@ -36,6 +45,7 @@ type Config struct {
 
 type ContractAddresses struct {
 	DexRouter string
+	Mappings  map[string]string // Mapping contract IDs by chain, e.g. BTC
 }
 
 // NewClient creates a new VSC DEX client
@@ -135,6 +145,91 @@ func (c *Client) ExecuteDexOperationWithIntents(ctx context.Context, operationTy
 	return c.broadcastTxWithIntents(ctx, payloadJSON, intents)
 }
 
+// SubmitDexOperation executes an operation on the unified DEX router contract
+// and returns the broadcast transaction ID, so its outcome can be looked up
+func (c *Client) SubmitDexOperation(ctx context.Context, operationType string, payload string, intents []Intent) (string, error) {
+	payloadJSON := fmt.Sprintf(`{
+		"contract": "%s",
+		"method": "%s",
+		"args": %s
+	}`, c.config.DexRouter, operationType, payload)
+
+	return c.submitTxWithIntents(ctx, payloadJSON, intents)
+}
+
+// ExecuteDexBatch executes several operations on the unified DEX router
+// contract in a single transaction and returns its ID
+func (c *Client) ExecuteDexBatch(ctx context.Context, operations []DexOperation) (string, error) {
+	ops := make([]transactionpool.VSCTransactionOp, len(operations))
+	for i, operation := range operations {
+		payloadJSON := fmt.Sprintf(`{
+		"contract": "%s",
+		"method": "%s",
+		"args": %s
+	}`, c.config.DexRouter, operation.OperationType, operation.Payload)
+
+		op, err := c.contractCallOp(payloadJSON, operation.Intents)
+		if err != nil {
+			return "", fmt.Errorf("operation %d: %w", i, err)
+		}
+		ops[i] = op
+	}
+	return c.submitOps(ctx, ops)
+}
+
+// MintDeposit submits a deposit proof to a chain's mapping contract, which
+// mints the mapped asset to the deposit's recipient, and returns the
+// transaction ID
+func (c *Client) MintDeposit(ctx context.Context, chain string, proof []byte) (string, error) {
+	contractID, ok := c.config.Contracts.Mappings[strings.ToUpper(chain)]
+	if !ok {
+		return "", fmt.Errorf("no mapping contract configured for %s", chain)
+	}
+
+	args, err := json.Marshal(map[string]string{"proof": hex.EncodeToString(proof)})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal deposit proof: %w", err)
+	}
+	payloadJSON := fmt.Sprintf(`{
+		"contract": "%s",
+		"method": "proveDeposit",
+		"args": %s
+	}`, contractID, args)
+
+	return c.submitTxWithIntents(ctx, payloadJSON, nil)
+}
+
+// WithdrawToChain asks a chain's mapping contract to burn amount of the
+// mapped asset and release it to an address on that chain, and returns the
+// transaction ID
+func (c *Client) WithdrawToChain(ctx context.Context, account string, asset string, amount int64, chain string, address string) (string, error) {
+	contractID, ok := c.config.Contracts.Mappings[strings.ToUpper(chain)]
+	if !ok {
+		return "", fmt.Errorf("no mapping contract configured for %s", chain)
+	}
+
+	args, err := json.Marshal(map[string]string{
+		"from":    account,
+		"asset":   asset,
+		"amount":  fmt.Sprintf("%d", amount),
+		"address": address,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal withdrawal: %w", err)
+	}
+	payloadJSON := fmt.Sprintf(`{
+		"contract": "%s",
+		"method": "withdraw",
+		"args": %s
+	}`, contractID, args)
+
+	intents := []Intent{{
+		Type: "transfer.allow",
+		Args: map[string]string{"limit": fmt.Sprintf("%d", amount), "token": asset},
+	}}
+	return c.submitTxWithIntents(ctx, payloadJSON, intents)
+}
+
 // ExecuteDexSwapRouter implements the router.DEXExecutor interface
 // This allows the SDK client to be injected into the router service
 func (c *Client) ExecuteDexSwapRouter(ctx context.Context, amountOut int64, route []string, fee int64) error {
@@ -156,10 +251,27 @@ func (c *Client) broadcastTx(ctx context.Context, payload string) error {
 
 // broadcastTxWithIntents broadcasts a transaction to VSC with intents
 func (c *Client) broadcastTxWithIntents(ctx context.Context, payload string, intents []Intent) error {
+	_, err := c.submitTxWithIntents(ctx, payload, intents)
+	return err
+}
+
+// submitTxWithIntents broadcasts a transaction to VSC with intents and
+// returns its ID
+func (c *Client) submitTxWithIntents(ctx context.Context, payload string, intents []Intent) (string, error) {
+	op, err := c.contractCallOp(payload, intents)
+	if err != nil {
+		return "", err
+	}
+	return c.submitOps(ctx, []transactionpool.VSCTransactionOp{op})
+}
+
+// contractCallOp serializes a contract call payload and its intents into a
+// transaction operation
+func (c *Client) contractCallOp(payload string, intents []Intent) (transactionpool.VSCTransactionOp, error) {
 	// Parse the payload to extract contract call parameters
 	var contractCall map[string]interface{}
 	if err := json.Unmarshal([]byte(payload), &contractCall); err != nil {
-		return fmt.Errorf("failed to parse contract call payload: %w", err)
+		return transactionpool.VSCTransactionOp{}, fmt.Errorf("failed to parse contract call payload: %w", err)
 	}
 
 	contractID, _ := contractCall["contract"].(string)
@@ -169,7 +281,7 @@ func (c *Client) broadcastTxWithIntents(ctx context.Context, payload string, int
 	// Serialize args to JSON string (VscContractCall.Payload is string, not map)
 	argsJSON, err := json.Marshal(args)
 	if err != nil {
-		return fmt.Errorf("failed to marshal contract call args: %w", err)
+		return transactionpool.VSCTransactionOp{}, fmt.Errorf("failed to marshal contract call args: %w", err)
 	}
 
 	// Convert SDK intents to contracts intents
@@ -198,12 +310,17 @@ func (c *Client) broadcastTxWithIntents(ctx context.Context, payload string, int
 	// Serialize the contract call
 	op, err := vscCall.SerializeVSC()
 	if err != nil {
-		return fmt.Errorf("failed to serialize contract call: %w", err)
+		return transactionpool.VSCTransactionOp{}, fmt.Errorf("failed to serialize contract call: %w", err)
 	}
+	return op, nil
+}
 
+// submitOps broadcasts operations as a single VSC transaction and returns
+// its ID
+func (c *Client) submitOps(ctx context.Context, ops []transactionpool.VSCTransactionOp) (string, error) {
 	// Create VSC transaction
 	tx := transactionpool.VSCTransaction{
-		Ops:   []transactionpool.VSCTransactionOp{op},
+		Ops:   ops,
 		Nonce: 0, // TODO: Implement proper nonce management
 	}
 
@@ -225,18 +342,18 @@ func (c *Client) broadcastTxWithIntents(ctx context.Context, payload string, int
 		} `graphql:"submitTransactionV1(tx: $tx, sig: $sig)"`
 	}
 
-	err = gqlClient.Query(ctx, &mutation, map[string]interface{}{
+	err := gqlClient.Query(ctx, &mutation, map[string]interface{}{
 		"tx":  graphql.String(txStr),
 		"sig": graphql.String(sigStr),
 	})
 
 	if err != nil {
 		log.Printf("Failed to broadcast transaction: %v", err)
-		return fmt.Errorf("failed to broadcast transaction: %w", err)
+		return "", fmt.Errorf("failed to broadcast transaction: %w", err)
 	}
 
 	log.Printf("Transaction broadcasted successfully, ID: %s", mutation.SubmitTransactionV1.Id)
-	return nil
+	return string(mutation.SubmitTransactionV1.Id), nil
 }
 
 // GetPools queries available liquidity pools from indexer
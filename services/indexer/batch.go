@@ -0,0 +1,136 @@
+package indexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// maxBatchQueries caps the number of sub-requests in a single batch call
+const maxBatchQueries = 100
+
+// BatchQuery describes a single sub-request within a batch lookup
+type BatchQuery struct {
+	ID     string `json:"id,omitempty"` // Optional client-supplied correlation ID
+	Op     string `json:"op"`           // "pool", "transaction", "transactions", "positions"
+	PoolID string `json:"pool_id,omitempty"`
+	TxID   string `json:"tx_id,omitempty"`
+	Type   string `json:"type,omitempty"`
+	Limit  int    `json:"limit,omitempty"`
+}
+
+// BatchResult holds the outcome of a single batch sub-request
+type BatchResult struct {
+	ID     string      `json:"id,omitempty"`
+	Status int         `json:"status"`
+	Data   interface{} `json:"data,omitempty"`
+	Error  string      `json:"error,omitempty"`
+	Code   ErrorCode   `json:"code,omitempty"`
+}
+
+// ExecuteBatch runs all queries against a single consistent view of the read
+// model, taking the read lock once for the whole batch
+func (dm *DexReadModel) ExecuteBatch(queries []BatchQuery) []BatchResult {
+	dm.mu.RLock()
+	defer dm.mu.RUnlock()
+
+	results := make([]BatchResult, len(queries))
+	for i, q := range queries {
+		results[i] = dm.executeBatchQueryLocked(q)
+	}
+	return results
+}
+
+// executeBatchQueryLocked executes one batch sub-request; caller must hold dm.mu
+func (dm *DexReadModel) executeBatchQueryLocked(q BatchQuery) BatchResult {
+	result := BatchResult{ID: q.ID, Status: http.StatusOK}
+
+	switch q.Op {
+	case "pool":
+		if q.PoolID == "" {
+			return batchError(q, http.StatusBadRequest, ErrCodeInvalidParameter, "pool_id is required")
+		}
+		pool, exists := dm.pools[q.PoolID]
+		if !exists {
+			return batchError(q, http.StatusNotFound, ErrCodePoolNotFound, "Pool not found")
+		}
+		result.Data = pool
+
+	case "transaction":
+		if q.TxID == "" {
+			return batchError(q, http.StatusBadRequest, ErrCodeInvalidParameter, "tx_id is required")
+		}
+		tx, found := dm.getTransactionLocked(q.TxID)
+		if !found {
+			return batchError(q, http.StatusNotFound, ErrCodeTransactionNotFound, "Transaction not found")
+		}
+		result.Data = tx
+
+	case "transactions":
+		limit := 100 // Default limit
+		if q.Limit > 0 && q.Limit <= 1000 {
+			limit = q.Limit
+		}
+		transactions := dm.queryTransactionsLocked(newTransactionFilter(q.PoolID, q.Type), limit)
+		if transactions == nil {
+			transactions = []TransactionInfo{}
+		}
+		result.Data = transactions
+
+	case "positions":
+		if q.PoolID == "" {
+			return batchError(q, http.StatusBadRequest, ErrCodeInvalidParameter, "pool_id is required")
+		}
+		result.Data = dm.queryLiquidityPositionsLocked(q.PoolID)
+
+	default:
+		return batchError(q, http.StatusBadRequest, ErrCodeInvalidParameter, fmt.Sprintf("unknown op: %q", q.Op))
+	}
+
+	return result
+}
+
+// batchError builds a failed BatchResult for a query
+func batchError(q BatchQuery, status int, code ErrorCode, message string) BatchResult {
+	return BatchResult{ID: q.ID, Status: status, Error: message, Code: code}
+}
+
+// handleBatch executes a list of read sub-requests in one round trip
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Requests []BatchQuery `json:"requests"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if isBodyTooLarge(err) {
+			writeProblem(w, r, http.StatusRequestEntityTooLarge, ErrCodeRequestTooLarge, "Request body too large")
+			return
+		}
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid request body")
+		return
+	}
+
+	if len(req.Requests) == 0 {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidRequest, "requests must not be empty")
+		return
+	}
+
+	if len(req.Requests) > maxBatchQueries {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidRequest, fmt.Sprintf("batch exceeds maximum of %d requests", maxBatchQueries))
+		return
+	}
+
+	dexReader, ok := s.dexReader()
+	if !ok {
+		writeProblem(w, r, http.StatusInternalServerError, ErrCodeNoDataAvailable, "No data available")
+		return
+	}
+
+	results := dexReader.ExecuteBatch(req.Requests)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"results": results,
+		"count":   len(results),
+	})
+}
@@ -0,0 +1,109 @@
+package indexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDexReadModel_ExecuteBatch(t *testing.T) {
+	rm := NewDexReadModel()
+	rm.pools["pool-1"] = PoolInfo{ID: "pool-1", Asset0: "HBD", Asset1: "HIVE"}
+	rm.transactions = []TransactionInfo{
+		{ID: "tx-1", Type: "swap", PoolID: "pool-1"},
+	}
+	rm.positions["pool-1"] = []LiquidityPosition{{User: "alice", PoolID: "pool-1", Amount: 10}}
+
+	results := rm.ExecuteBatch([]BatchQuery{
+		{ID: "a", Op: "pool", PoolID: "pool-1"},
+		{ID: "b", Op: "pool", PoolID: "missing"},
+		{ID: "c", Op: "transaction", TxID: "tx-1"},
+		{ID: "d", Op: "transactions", PoolID: "pool-1"},
+		{ID: "e", Op: "positions", PoolID: "pool-1"},
+		{ID: "f", Op: "bogus"},
+		{ID: "g", Op: "pool"},
+	})
+
+	require.Len(t, results, 7)
+
+	assert.Equal(t, http.StatusOK, results[0].Status)
+	assert.Equal(t, "pool-1", results[0].Data.(PoolInfo).ID)
+
+	assert.Equal(t, http.StatusNotFound, results[1].Status)
+	assert.Equal(t, "Pool not found", results[1].Error)
+
+	assert.Equal(t, http.StatusOK, results[2].Status)
+	assert.Equal(t, "tx-1", results[2].Data.(TransactionInfo).ID)
+
+	assert.Len(t, results[3].Data.([]TransactionInfo), 1)
+	assert.Len(t, results[4].Data.([]LiquidityPosition), 1)
+
+	assert.Equal(t, http.StatusBadRequest, results[5].Status)
+	assert.Equal(t, http.StatusBadRequest, results[6].Status)
+
+	// Results preserve client correlation IDs in order
+	for i, id := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		assert.Equal(t, id, results[i].ID)
+	}
+}
+
+func TestServer_handleBatch(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+	dexReader := svc.readers[0].(*DexReadModel)
+	for i := 0; i < 50; i++ {
+		id := fmt.Sprintf("pool-%d", i)
+		dexReader.pools[id] = PoolInfo{ID: id, Asset0: "HBD", Asset1: "HIVE"}
+	}
+	server := NewServer(svc, "8081")
+
+	queries := make([]BatchQuery, 50)
+	for i := range queries {
+		queries[i] = BatchQuery{Op: "pool", PoolID: fmt.Sprintf("pool-%d", i)}
+	}
+	body, err := json.Marshal(map[string]interface{}{"requests": queries})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("POST", "/api/v1/batch", strings.NewReader(string(body)))
+	w := httptest.NewRecorder()
+	server.handleBatch(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Results []struct {
+			Status int      `json:"status"`
+			Data   PoolInfo `json:"data"`
+		} `json:"results"`
+		Count int `json:"count"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, 50, resp.Count)
+	for i, result := range resp.Results {
+		assert.Equal(t, http.StatusOK, result.Status)
+		assert.Equal(t, fmt.Sprintf("pool-%d", i), result.Data.ID)
+	}
+}
+
+func TestServer_handleBatch_Invalid(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+	server := NewServer(svc, "8081")
+
+	tooMany := make([]BatchQuery, maxBatchQueries+1)
+	for i := range tooMany {
+		tooMany[i] = BatchQuery{Op: "pool", PoolID: "x"}
+	}
+	tooManyBody, _ := json.Marshal(map[string]interface{}{"requests": tooMany})
+
+	for _, body := range []string{`not json`, `{"requests":[]}`, string(tooManyBody)} {
+		req := httptest.NewRequest("POST", "/api/v1/batch", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		server.handleBatch(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,170 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ResponseCache stores serialized API responses shared across indexer replicas
+type ResponseCache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// CacheTTLs configures per-endpoint cache lifetimes. A zero TTL disables
+// caching for that endpoint.
+type CacheTTLs struct {
+	Pools        time.Duration
+	RichList     time.Duration
+	Leaderboards time.Duration
+}
+
+// DefaultCacheTTLs returns TTLs suited to the indexer's 5s poll interval
+func DefaultCacheTTLs() CacheTTLs {
+	return CacheTTLs{
+		Pools:        2 * time.Second,
+		RichList:     10 * time.Second,
+		Leaderboards: 30 * time.Second,
+	}
+}
+
+// RedisResponseCache implements ResponseCache on top of Redis
+type RedisResponseCache struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisResponseCache creates a Redis-backed response cache. Keys are
+// namespaced with prefix so several services can share one Redis.
+func NewRedisResponseCache(client *redis.Client, prefix string) *RedisResponseCache {
+	return &RedisResponseCache{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// Get returns the cached value for key, if present
+func (c *RedisResponseCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	val, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return val, true, nil
+}
+
+// Set stores value under key with the given TTL
+func (c *RedisResponseCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, c.prefix+key, value, ttl).Err()
+}
+
+// CacheStats counts response cache lookups since the server started
+type CacheStats struct {
+	Enabled bool    `json:"enabled"`
+	Hits    uint64  `json:"hits"`
+	Misses  uint64  `json:"misses"`
+	Errors  uint64  `json:"errors"`   // Failed cache reads and writes
+	HitRate float64 `json:"hit_rate"` // Hits over lookups, 0 before any
+}
+
+// cacheCounters tracks lookups for CacheStats
+type cacheCounters struct {
+	hits   atomic.Uint64
+	misses atomic.Uint64
+	errors atomic.Uint64
+}
+
+// cacheStats returns the response cache's counters
+func (s *Server) cacheStats() CacheStats {
+	stats := CacheStats{
+		Enabled: s.cache != nil,
+		Hits:    s.cacheCounters.hits.Load(),
+		Misses:  s.cacheCounters.misses.Load(),
+		Errors:  s.cacheCounters.errors.Load(),
+	}
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(lookups)
+	}
+	return stats
+}
+
+// handleGetCacheStats reports response cache hits and misses
+func (s *Server) handleGetCacheStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.cacheStats())
+}
+
+// cacheKey identifies a response by its path and query parameters. The
+// parameters are sorted so their order does not split the cache, and the
+// host is left out so every replica and hostname shares one entry.
+func cacheKey(r *http.Request) string {
+	key := r.URL.Path
+	if query := r.URL.Query(); len(query) > 0 {
+		key += "?" + query.Encode()
+	}
+	return key
+}
+
+// cacheRecorder captures a handler's response so it can be cached
+type cacheRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *cacheRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *cacheRecorder) Write(b []byte) (int, error) {
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// cached wraps a JSON handler with the response cache. Cache failures are
+// logged and fall through to the handler so Redis outages never break reads.
+func (s *Server) cached(ttl time.Duration, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.cache == nil || ttl <= 0 {
+			next(w, r)
+			return
+		}
+
+		key := cacheKey(r)
+		body, hit, err := s.cache.Get(r.Context(), key)
+		if err != nil {
+			s.cacheCounters.errors.Add(1)
+			log.Printf("Response cache get failed for %s: %v", key, err)
+		}
+		if hit {
+			s.cacheCounters.hits.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("X-Cache", "HIT")
+			w.Write(body)
+			return
+		}
+
+		s.cacheCounters.misses.Add(1)
+		w.Header().Set("X-Cache", "MISS")
+		rec := &cacheRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+
+		if rec.status == http.StatusOK {
+			if err := s.cache.Set(r.Context(), key, rec.body.Bytes(), ttl); err != nil {
+				s.cacheCounters.errors.Add(1)
+				log.Printf("Response cache set failed for %s: %v", key, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package indexer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockResponseCache is an in-memory ResponseCache for testing
+type mockResponseCache struct {
+	mu      sync.Mutex
+	entries map[string][]byte
+	ttls    map[string]time.Duration
+	failGet bool
+}
+
+func newMockResponseCache() *mockResponseCache {
+	return &mockResponseCache{
+		entries: make(map[string][]byte),
+		ttls:    make(map[string]time.Duration),
+	}
+}
+
+func (m *mockResponseCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failGet {
+		return nil, false, errors.New("connection refused")
+	}
+	val, ok := m.entries[key]
+	return val, ok, nil
+}
+
+func (m *mockResponseCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = append([]byte(nil), value...)
+	m.ttls[key] = ttl
+	return nil
+}
+
+func TestServer_Cache_PoolList(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+	dexReader := svc.readers[0].(*DexReadModel)
+	dexReader.pools["pool-1"] = PoolInfo{ID: "pool-1", Asset0: "HBD", Asset1: "HIVE"}
+
+	cache := newMockResponseCache()
+	server := NewServer(svc, "8081", WithResponseCache(cache, DefaultCacheTTLs()))
+
+	// First request misses and populates the cache
+	req := httptest.NewRequest("GET", "/api/v1/pools", nil)
+	w := httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "MISS", w.Header().Get("X-Cache"))
+	assert.Equal(t, 2*time.Second, cache.ttls["/api/v1/pools"])
+
+	// Mutate the read model; cached response must still be served
+	dexReader.pools["pool-2"] = PoolInfo{ID: "pool-2", Asset0: "BTC", Asset1: "HBD"}
+
+	req = httptest.NewRequest("GET", "/api/v1/pools", nil)
+	w = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "HIT", w.Header().Get("X-Cache"))
+	assert.NotContains(t, w.Body.String(), "pool-2")
+}
+
+func TestServer_Cache_KeyIncludesQuery(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+	cache := newMockResponseCache()
+	server := NewServer(svc, "8081", WithResponseCache(cache, DefaultCacheTTLs()))
+
+	for _, url := range []string{"/api/v1/pools/p1/richlist?limit=10", "/api/v1/pools/p1/richlist?limit=20"} {
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		server.http.Handler.ServeHTTP(w, req)
+		assert.Equal(t, "MISS", w.Header().Get("X-Cache"))
+	}
+
+	assert.Len(t, cache.entries, 2)
+	assert.Equal(t, 10*time.Second, cache.ttls["/api/v1/pools/p1/richlist?limit=10"])
+}
+
+func TestServer_Cache_KeyIgnoresHostAndQueryOrder(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+	cache := newMockResponseCache()
+	server := NewServer(svc, "8081", WithResponseCache(cache, DefaultCacheTTLs()))
+
+	for i, url := range []string{
+		"http://replica-a.example/api/v1/pools/p1/richlist?limit=10&offset=0",
+		"https://replica-b.example/api/v1/pools/p1/richlist?offset=0&limit=10",
+	} {
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		server.http.Handler.ServeHTTP(w, req)
+		assert.Equal(t, []string{"MISS", "HIT"}[i], w.Header().Get("X-Cache"), url)
+	}
+
+	assert.Len(t, cache.entries, 1)
+	assert.Contains(t, cache.entries, "/api/v1/pools/p1/richlist?limit=10&offset=0")
+}
+
+func TestServer_CacheStats(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+	cache := newMockResponseCache()
+	server := NewServer(svc, "8081", WithResponseCache(cache, DefaultCacheTTLs()))
+
+	stats := func() CacheStats {
+		req := httptest.NewRequest("GET", "/api/v1/cache/stats", nil)
+		w := httptest.NewRecorder()
+		server.http.Handler.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		var stats CacheStats
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+		return stats
+	}
+	assert.Equal(t, CacheStats{Enabled: true}, stats())
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest("GET", "/api/v1/pools", nil)
+		server.http.Handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	cache.failGet = true
+	server.http.Handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/pools", nil))
+
+	assert.Equal(t, CacheStats{Enabled: true, Hits: 2, Misses: 2, Errors: 1, HitRate: 0.5}, stats())
+
+	// Without a cache nothing is counted
+	server = NewServer(svc, "8081")
+	assert.Equal(t, CacheStats{}, stats())
+}
+
+func TestServer_Cache_SkipsErrors(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+	cache := newMockResponseCache()
+	server := NewServer(svc, "8081", WithResponseCache(cache, DefaultCacheTTLs()))
+
+	handler := server.cached(time.Minute, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest("GET", "/api/v1/pools", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, cache.entries)
+}
+
+func TestServer_Cache_FallsThroughOnFailure(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+	cache := newMockResponseCache()
+	cache.failGet = true
+	server := NewServer(svc, "8081", WithResponseCache(cache, DefaultCacheTTLs()))
+
+	req := httptest.NewRequest("GET", "/api/v1/pools", nil)
+	w := httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestServer_Cache_DisabledWithoutOption(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+	server := NewServer(svc, "8081")
+
+	req := httptest.NewRequest("GET", "/api/v1/pools/p1", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "p1"})
+	w := httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+
+	require.Empty(t, w.Header().Get("X-Cache"))
+}
@@ -10,6 +10,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/redis/go-redis/v9"
 	"github.com/vsc-eco/vsc-dex-mapping/services/indexer"
 )
 
@@ -19,10 +20,34 @@ func main() {
 		wsEndpoint   = flag.String("ws-endpoint", "", "VSC GraphQL WebSocket endpoint (optional, will try WebSocket first if provided)")
 		httpPort     = flag.String("http-port", "8081", "HTTP server port")
 		contracts    = flag.String("contracts", "", "Comma-separated list of contract IDs to monitor")
+		redisAddr    = flag.String("redis-addr", "", "Redis address for the shared response cache (optional)")
 	)
 	flag.Parse()
 
-	svc := indexer.NewService(*httpEndpoint, *httpPort)
+	// Server settings come from INDEXER_* environment variables; an explicit
+	// --http-port flag takes precedence over INDEXER_PORT
+	serverConfig, err := indexer.ServerConfigFromEnv()
+	if err != nil {
+		log.Fatal("Invalid server configuration:", err)
+	}
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "http-port" {
+			serverConfig.Port = *httpPort
+		}
+	})
+	if serverConfig.Port == "" {
+		serverConfig.Port = *httpPort
+	}
+
+	serverOpts := []indexer.ServerOption{indexer.WithConfig(serverConfig)}
+	if *redisAddr != "" {
+		client := redis.NewClient(&redis.Options{Addr: *redisAddr})
+		cache := indexer.NewRedisResponseCache(client, "dex-indexer:")
+		serverOpts = append(serverOpts, indexer.WithResponseCache(cache, indexer.DefaultCacheTTLs()))
+		log.Printf("Response cache enabled via Redis at %s", *redisAddr)
+	}
+
+	svc := indexer.NewService(*httpEndpoint, serverConfig.Port, serverOpts...)
 
 	// Set WebSocket URL if provided (will attempt WebSocket first, fallback to polling)
 	if *wsEndpoint != "" {
@@ -51,9 +76,9 @@ func main() {
 
 	go func() {
 		if *wsEndpoint != "" {
-			log.Printf("Starting indexer service on HTTP port %s, attempting WebSocket: %s (fallback: polling from %s)...", *httpPort, *wsEndpoint, *httpEndpoint)
+			log.Printf("Starting indexer service on %s, attempting WebSocket: %s (fallback: polling from %s)...", serverConfig.ListenAddress(), *wsEndpoint, *httpEndpoint)
 		} else {
-			log.Printf("Starting indexer service on HTTP port %s, polling from %s...", *httpPort, *httpEndpoint)
+			log.Printf("Starting indexer service on %s, polling from %s...", serverConfig.ListenAddress(), *httpEndpoint)
 		}
 		if err := svc.Start(ctx); err != nil {
 			log.Fatal("Indexer failed to start:", err)
@@ -0,0 +1,199 @@
+package indexer
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envPrefix prefixes every environment variable read by ServerConfigFromEnv
+const envPrefix = "INDEXER_"
+
+// ServerConfig holds the tunable settings of the HTTP server
+type ServerConfig struct {
+	Network     string      // "tcp" (default), "tcp4", "tcp6" or "unix"
+	BindAddress string      // Host or IP to bind, IPv6 with or without brackets; empty binds all interfaces
+	Port        string      // Empty keeps the port passed to NewServer
+	SocketPath  string      // Unix socket path, used when Network is "unix"
+	SocketMode  os.FileMode // Unix socket permissions; zero means 0660
+
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	HandlerTimeout    time.Duration // Zero disables handler deadlines
+	MaxBodyBytes      int64         // Non-positive disables the limit
+
+	// TLS is enabled when both files are set
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// CORS is enabled when at least one origin is allowed; "*" allows any
+	CORSAllowedOrigins []string
+	CORSAllowedMethods []string
+	CORSAllowedHeaders []string
+
+	// Per-client token bucket; a zero RateLimit disables rate limiting
+	RateLimit float64 // Requests per second
+	RateBurst int
+
+	BasePath string // Prefix for every route, e.g. "/indexer"
+}
+
+// DefaultServerConfig returns the configuration used when none is given
+func DefaultServerConfig() ServerConfig {
+	return ServerConfig{
+		ReadTimeout:        defaultReadTimeout,
+		ReadHeaderTimeout:  defaultReadHeaderTimeout,
+		WriteTimeout:       defaultWriteTimeout,
+		IdleTimeout:        defaultIdleTimeout,
+		HandlerTimeout:     defaultHandlerTimeout,
+		MaxBodyBytes:       defaultMaxBodyBytes,
+		CORSAllowedMethods: []string{"GET", "POST", "OPTIONS"},
+		CORSAllowedHeaders: []string{"Content-Type"},
+		RateBurst:          20,
+	}
+}
+
+// ServerConfigFromEnv returns the default configuration overridden by any
+// INDEXER_* environment variables that are set
+func ServerConfigFromEnv() (ServerConfig, error) {
+	return loadServerConfig(DefaultServerConfig(), os.LookupEnv)
+}
+
+// loadServerConfig overrides cfg with values found through lookup
+func loadServerConfig(cfg ServerConfig, lookup func(string) (string, bool)) (ServerConfig, error) {
+	var err error
+	get := func(name string) (string, bool) {
+		v, ok := lookup(envPrefix + name)
+		return strings.TrimSpace(v), ok && strings.TrimSpace(v) != ""
+	}
+	setString := func(name string, dst *string) {
+		if v, ok := get(name); ok {
+			*dst = v
+		}
+	}
+	setList := func(name string, dst *[]string) {
+		if v, ok := get(name); ok {
+			*dst = splitList(v)
+		}
+	}
+	setDuration := func(name string, dst *time.Duration) {
+		if v, ok := get(name); ok && err == nil {
+			d, parseErr := time.ParseDuration(v)
+			if parseErr != nil {
+				err = fmt.Errorf("invalid %s%s: %v", envPrefix, name, parseErr)
+				return
+			}
+			*dst = d
+		}
+	}
+
+	setString("NETWORK", &cfg.Network)
+	setString("BIND_ADDRESS", &cfg.BindAddress)
+	setString("PORT", &cfg.Port)
+	setString("SOCKET_PATH", &cfg.SocketPath)
+	setDuration("READ_TIMEOUT", &cfg.ReadTimeout)
+	setDuration("READ_HEADER_TIMEOUT", &cfg.ReadHeaderTimeout)
+	setDuration("WRITE_TIMEOUT", &cfg.WriteTimeout)
+	setDuration("IDLE_TIMEOUT", &cfg.IdleTimeout)
+	setDuration("HANDLER_TIMEOUT", &cfg.HandlerTimeout)
+	setString("TLS_CERT_FILE", &cfg.TLSCertFile)
+	setString("TLS_KEY_FILE", &cfg.TLSKeyFile)
+	setList("CORS_ALLOWED_ORIGINS", &cfg.CORSAllowedOrigins)
+	setList("CORS_ALLOWED_METHODS", &cfg.CORSAllowedMethods)
+	setList("CORS_ALLOWED_HEADERS", &cfg.CORSAllowedHeaders)
+	setString("BASE_PATH", &cfg.BasePath)
+	if err != nil {
+		return cfg, err
+	}
+
+	if v, ok := get("MAX_BODY_BYTES"); ok {
+		n, parseErr := strconv.ParseInt(v, 10, 64)
+		if parseErr != nil {
+			return cfg, fmt.Errorf("invalid %sMAX_BODY_BYTES: %s", envPrefix, v)
+		}
+		cfg.MaxBodyBytes = n
+	}
+	if v, ok := get("SOCKET_MODE"); ok {
+		mode, parseErr := strconv.ParseUint(v, 8, 32)
+		if parseErr != nil {
+			return cfg, fmt.Errorf("invalid %sSOCKET_MODE: %s (expected octal, e.g. 0660)", envPrefix, v)
+		}
+		cfg.SocketMode = os.FileMode(mode)
+	}
+	if v, ok := get("RATE_LIMIT"); ok {
+		rate, parseErr := strconv.ParseFloat(v, 64)
+		if parseErr != nil {
+			return cfg, fmt.Errorf("invalid %sRATE_LIMIT: %s", envPrefix, v)
+		}
+		cfg.RateLimit = rate
+	}
+	if v, ok := get("RATE_BURST"); ok {
+		burst, parseErr := strconv.Atoi(v)
+		if parseErr != nil {
+			return cfg, fmt.Errorf("invalid %sRATE_BURST: %s", envPrefix, v)
+		}
+		cfg.RateBurst = burst
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// Validate reports configuration values that cannot be served
+func (c ServerConfig) Validate() error {
+	if err := c.validateListener(); err != nil {
+		return err
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return fmt.Errorf("TLS requires both a certificate and a key file")
+	}
+	if c.RateLimit < 0 {
+		return fmt.Errorf("rate limit must not be negative")
+	}
+	if c.RateLimit > 0 && c.RateBurst < 1 {
+		return fmt.Errorf("rate burst must be at least 1 when rate limiting is enabled")
+	}
+	if c.BasePath != "" && !strings.HasPrefix(c.BasePath, "/") {
+		return fmt.Errorf("base path must start with /: %s", c.BasePath)
+	}
+	for _, d := range []time.Duration{c.ReadTimeout, c.ReadHeaderTimeout, c.WriteTimeout, c.IdleTimeout, c.HandlerTimeout} {
+		if d < 0 {
+			return fmt.Errorf("timeouts must not be negative")
+		}
+	}
+	return nil
+}
+
+// TLSEnabled reports whether the server should serve HTTPS
+func (c ServerConfig) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+// addr returns the listen address for the configured host and port
+func (c ServerConfig) addr() string {
+	return net.JoinHostPort(strings.Trim(c.BindAddress, "[]"), strings.TrimPrefix(c.Port, ":"))
+}
+
+// WithConfig applies a full server configuration. Options given after it,
+// such as WithTimeouts, override the matching settings.
+func WithConfig(cfg ServerConfig) ServerOption {
+	return func(s *Server) {
+		port := s.config.Port
+		s.config = cfg
+		s.config.BasePath = strings.TrimRight(cfg.BasePath, "/")
+		if s.config.Port == "" {
+			s.config.Port = port
+		}
+
+		s.limits.readTimeout = cfg.ReadTimeout
+		s.limits.readHeaderTimeout = cfg.ReadHeaderTimeout
+		s.limits.writeTimeout = cfg.WriteTimeout
+		s.limits.idleTimeout = cfg.IdleTimeout
+		s.limits.handlerTimeout = cfg.HandlerTimeout
+		s.limits.maxBodyBytes = cfg.MaxBodyBytes
+	}
+}
@@ -0,0 +1,119 @@
+package indexer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func envLookup(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+}
+
+func TestLoadServerConfig(t *testing.T) {
+	cfg, err := loadServerConfig(DefaultServerConfig(), envLookup(map[string]string{
+		"INDEXER_BIND_ADDRESS":         "127.0.0.1",
+		"INDEXER_PORT":                 "9090",
+		"INDEXER_READ_TIMEOUT":         "3s",
+		"INDEXER_HANDLER_TIMEOUT":      "500ms",
+		"INDEXER_MAX_BODY_BYTES":       "2048",
+		"INDEXER_CORS_ALLOWED_ORIGINS": "https://a.example, https://b.example",
+		"INDEXER_RATE_LIMIT":           "5",
+		"INDEXER_RATE_BURST":           "10",
+		"INDEXER_BASE_PATH":            "/indexer",
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, "127.0.0.1", cfg.BindAddress)
+	assert.Equal(t, "9090", cfg.Port)
+	assert.Equal(t, 3*time.Second, cfg.ReadTimeout)
+	assert.Equal(t, 500*time.Millisecond, cfg.HandlerTimeout)
+	assert.Equal(t, defaultWriteTimeout, cfg.WriteTimeout)
+	assert.Equal(t, int64(2048), cfg.MaxBodyBytes)
+	assert.Equal(t, []string{"https://a.example", "https://b.example"}, cfg.CORSAllowedOrigins)
+	assert.Equal(t, 5.0, cfg.RateLimit)
+	assert.Equal(t, 10, cfg.RateBurst)
+	assert.Equal(t, "/indexer", cfg.BasePath)
+	assert.Equal(t, "127.0.0.1:9090", cfg.addr())
+}
+
+func TestLoadServerConfig_Invalid(t *testing.T) {
+	tests := map[string]map[string]string{
+		"bad duration":     {"INDEXER_READ_TIMEOUT": "soon"},
+		"bad body limit":   {"INDEXER_MAX_BODY_BYTES": "lots"},
+		"bad rate":         {"INDEXER_RATE_LIMIT": "fast"},
+		"cert without key": {"INDEXER_TLS_CERT_FILE": "cert.pem"},
+		"relative base":    {"INDEXER_BASE_PATH": "indexer"},
+		"zero burst":       {"INDEXER_RATE_LIMIT": "5", "INDEXER_RATE_BURST": "0"},
+	}
+
+	for name, env := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := loadServerConfig(DefaultServerConfig(), envLookup(env))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNewServer_WithConfig(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+
+	cfg := DefaultServerConfig()
+	cfg.BindAddress = "127.0.0.1"
+	cfg.ReadTimeout = 2 * time.Second
+	cfg.BasePath = "/indexer/"
+	server := NewServer(svc, "8081", WithConfig(cfg))
+
+	// The port passed to NewServer is kept when the config leaves it empty
+	assert.Equal(t, "127.0.0.1:8081", server.http.Addr)
+	assert.Equal(t, 2*time.Second, server.http.ReadTimeout)
+
+	req := httptest.NewRequest("GET", "/indexer/health", nil)
+	w := httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req = httptest.NewRequest("GET", "/health", nil)
+	w = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), string(ErrCodeRouteNotFound))
+}
+
+func TestServer_CORS(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+
+	cfg := DefaultServerConfig()
+	cfg.CORSAllowedOrigins = []string{"https://app.example"}
+	server := NewServer(svc, "8081", WithConfig(cfg))
+
+	req := httptest.NewRequest("OPTIONS", "/api/v1/batch", nil)
+	req.Header.Set("Origin", "https://app.example")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	w := httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://app.example", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, POST, OPTIONS", w.Header().Get("Access-Control-Allow-Methods"))
+
+	req = httptest.NewRequest("GET", "/health", nil)
+	req.Header.Set("Origin", "https://app.example")
+	w = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "https://app.example", w.Header().Get("Access-Control-Allow-Origin"))
+
+	// Other origins get no CORS headers
+	req = httptest.NewRequest("GET", "/health", nil)
+	req.Header.Set("Origin", "https://evil.example")
+	w = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
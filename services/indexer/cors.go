@@ -0,0 +1,52 @@
+package indexer
+
+import (
+	"net/http"
+	"strings"
+)
+
+// cors adds CORS headers for allowed origins and answers preflight requests.
+// It wraps the whole router so preflights reach it before method matching.
+func (s *Server) cors(next http.Handler) http.Handler {
+	if len(s.config.CORSAllowedOrigins) == 0 {
+		return next
+	}
+
+	methods := strings.Join(s.config.CORSAllowedMethods, ", ")
+	headers := strings.Join(s.config.CORSAllowedHeaders, ", ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || !s.originAllowed(origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		if containsString(s.config.CORSAllowedOrigins, "*") {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", methods)
+			w.Header().Set("Access-Control-Allow-Headers", headers)
+			w.Header().Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// originAllowed reports whether origin may make cross-origin requests
+func (s *Server) originAllowed(origin string) bool {
+	for _, allowed := range s.config.CORSAllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package indexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// Depth query defaults and bounds
+const (
+	defaultDepthLevels = 10
+	maxDepthLevels     = 50
+	defaultDepthMaxPct = 10.0 // Largest simulated trade, as % of the input reserve
+)
+
+// DepthLevel is a simulated trade of a given input size against a pool
+type DepthLevel struct {
+	AmountIn    uint64  `json:"amount_in"`
+	AmountOut   uint64  `json:"amount_out"`
+	Price       float64 `json:"price"`        // Average execution price, asset1 per asset0
+	PriceImpact float64 `json:"price_impact"` // % move of execution price from spot
+}
+
+// PoolDepth is an order-book style view of a constant product pool. Bids
+// sell asset0 for asset1; asks buy asset0 with asset1.
+type PoolDepth struct {
+	PoolID    string       `json:"pool_id"`
+	Asset0    string       `json:"asset0"`
+	Asset1    string       `json:"asset1"`
+	SpotPrice float64      `json:"spot_price"` // asset1 per asset0
+	Bids      []DepthLevel `json:"bids"`
+	Asks      []DepthLevel `json:"asks"`
+}
+
+// GetPoolDepth simulates swaps of increasing size in both directions, up to
+// maxPct percent of the input reserve in the given number of levels. The
+// second return value is false if the pool does not exist.
+func (dm *DexReadModel) GetPoolDepth(poolID string, levels int, maxPct float64) (PoolDepth, bool) {
+	dm.mu.RLock()
+	defer dm.mu.RUnlock()
+
+	pool, exists := dm.pools[poolID]
+	if !exists {
+		return PoolDepth{}, false
+	}
+
+	depth := PoolDepth{
+		PoolID: pool.ID,
+		Asset0: pool.Asset0,
+		Asset1: pool.Asset1,
+		Bids:   []DepthLevel{},
+		Asks:   []DepthLevel{},
+	}
+	if pool.Reserve0 == 0 || pool.Reserve1 == 0 {
+		return depth, true
+	}
+
+	spot := float64(pool.Reserve1) / float64(pool.Reserve0)
+	depth.SpotPrice = spot
+
+	// Fee is a percentage (0.08 = 0.08%)
+	feeFactor := 1 - pool.Fee/100
+
+	for i := 1; i <= levels; i++ {
+		fraction := maxPct / 100 * float64(i) / float64(levels)
+
+		// Bid: sell asset0, receive asset1
+		in0 := uint64(float64(pool.Reserve0) * fraction)
+		if out1 := constantProductOut(in0, pool.Reserve0, pool.Reserve1, feeFactor); in0 > 0 && out1 > 0 {
+			price := float64(out1) / float64(in0)
+			depth.Bids = append(depth.Bids, DepthLevel{
+				AmountIn:    in0,
+				AmountOut:   out1,
+				Price:       price,
+				PriceImpact: (spot - price) / spot * 100,
+			})
+		}
+
+		// Ask: pay asset1, receive asset0
+		in1 := uint64(float64(pool.Reserve1) * fraction)
+		if out0 := constantProductOut(in1, pool.Reserve1, pool.Reserve0, feeFactor); in1 > 0 && out0 > 0 {
+			price := float64(in1) / float64(out0)
+			depth.Asks = append(depth.Asks, DepthLevel{
+				AmountIn:    in1,
+				AmountOut:   out0,
+				Price:       price,
+				PriceImpact: (price - spot) / spot * 100,
+			})
+		}
+	}
+
+	return depth, true
+}
+
+// constantProductOut returns the output of an x*y=k swap after fees
+func constantProductOut(amountIn, reserveIn, reserveOut uint64, feeFactor float64) uint64 {
+	inWithFee := float64(amountIn) * feeFactor
+	if inWithFee <= 0 {
+		return 0
+	}
+	return uint64(float64(reserveOut) * inWithFee / (float64(reserveIn) + inWithFee))
+}
+
+// parseDepthParams reads the levels and max_pct query parameters
+func parseDepthParams(r *http.Request) (int, float64, error) {
+	levels := defaultDepthLevels
+	maxPct := defaultDepthMaxPct
+
+	if v := r.URL.Query().Get("levels"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l <= 0 || l > maxDepthLevels {
+			return 0, 0, fmt.Errorf("invalid levels: %s (expected 1-%d)", v, maxDepthLevels)
+		}
+		levels = l
+	}
+	if v := r.URL.Query().Get("max_pct"); v != "" {
+		p, err := strconv.ParseFloat(v, 64)
+		if err != nil || p <= 0 || p > 100 {
+			return 0, 0, fmt.Errorf("invalid max_pct: %s (expected > 0 and <= 100)", v)
+		}
+		maxPct = p
+	}
+
+	return levels, maxPct, nil
+}
+
+// handleGetPoolDepth returns simulated amounts-out at increasing input sizes
+func (s *Server) handleGetPoolDepth(w http.ResponseWriter, r *http.Request) {
+	poolID := mux.Vars(r)["id"]
+
+	levels, maxPct, err := parseDepthParams(r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+		return
+	}
+
+	dexReader, ok := s.dexReader()
+	if !ok {
+		writeProblem(w, r, http.StatusNotFound, ErrCodePoolNotFound, "Pool not found")
+		return
+	}
+
+	depth, exists := dexReader.GetPoolDepth(poolID, levels, maxPct)
+	if !exists {
+		writeProblem(w, r, http.StatusNotFound, ErrCodePoolNotFound, "Pool not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(depth)
+}
@@ -0,0 +1,82 @@
+package indexer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDexReadModel_GetPoolDepth(t *testing.T) {
+	rm := NewDexReadModel()
+	rm.pools["pool-1"] = PoolInfo{ID: "pool-1", Asset0: "HBD", Asset1: "HIVE", Fee: 0.3, Reserve0: 1000000, Reserve1: 4000000}
+
+	depth, exists := rm.GetPoolDepth("pool-1", 4, 10)
+	require.True(t, exists)
+	assert.InDelta(t, 4.0, depth.SpotPrice, 1e-9)
+	require.Len(t, depth.Bids, 4)
+	require.Len(t, depth.Asks, 4)
+
+	// Largest level trades 10% of the input reserve
+	assert.Equal(t, uint64(100000), depth.Bids[3].AmountIn)
+	assert.Equal(t, uint64(400000), depth.Asks[3].AmountIn)
+
+	// 25000 HBD in: 4000000 * 24925 / 1024925
+	assert.Equal(t, uint64(25000), depth.Bids[0].AmountIn)
+	assert.Equal(t, uint64(97275), depth.Bids[0].AmountOut)
+
+	// Bids get worse and asks get more expensive as size grows
+	for i := 1; i < 4; i++ {
+		assert.Less(t, depth.Bids[i].Price, depth.Bids[i-1].Price)
+		assert.Greater(t, depth.Asks[i].Price, depth.Asks[i-1].Price)
+		assert.Greater(t, depth.Bids[i].PriceImpact, depth.Bids[i-1].PriceImpact)
+	}
+	assert.Less(t, depth.Bids[0].Price, depth.SpotPrice)
+	assert.Greater(t, depth.Asks[0].Price, depth.SpotPrice)
+
+	_, exists = rm.GetPoolDepth("missing", 4, 10)
+	assert.False(t, exists)
+}
+
+func TestDexReadModel_GetPoolDepth_EmptyPool(t *testing.T) {
+	rm := NewDexReadModel()
+	rm.pools["pool-1"] = PoolInfo{ID: "pool-1", Asset0: "HBD", Asset1: "HIVE"}
+
+	depth, exists := rm.GetPoolDepth("pool-1", 10, 10)
+	require.True(t, exists)
+	assert.Empty(t, depth.Bids)
+	assert.Empty(t, depth.Asks)
+}
+
+func TestServer_HandleGetPoolDepth(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+	server := NewServer(svc, "8081")
+
+	dexReader := svc.readers[0].(*DexReadModel)
+	dexReader.pools["pool-1"] = PoolInfo{ID: "pool-1", Asset0: "HBD", Asset1: "HIVE", Fee: 0.3, Reserve0: 1000000, Reserve1: 4000000}
+
+	req := httptest.NewRequest("GET", "/api/v1/pools/pool-1/depth?levels=5&max_pct=20", nil)
+	w := httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var depth PoolDepth
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &depth))
+	require.Len(t, depth.Bids, 5)
+	assert.Equal(t, uint64(200000), depth.Bids[4].AmountIn)
+
+	for _, query := range []string{"levels=0", "levels=51", "max_pct=0", "max_pct=150", "max_pct=abc"} {
+		req = httptest.NewRequest("GET", "/api/v1/pools/pool-1/depth?"+query, nil)
+		w = httptest.NewRecorder()
+		server.http.Handler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/pools/missing/depth", nil)
+	w = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
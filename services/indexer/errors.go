@@ -0,0 +1,78 @@
+package indexer
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ErrorCode is a stable, machine-readable error identifier returned in
+// problem responses. Codes are part of the public API and must not change.
+type ErrorCode string
+
+const (
+	ErrCodeInvalidRequest      ErrorCode = "INVALID_REQUEST"
+	ErrCodeInvalidParameter    ErrorCode = "INVALID_PARAMETER"
+	ErrCodeInvalidCursor       ErrorCode = "INVALID_CURSOR"
+	ErrCodePoolNotFound        ErrorCode = "POOL_NOT_FOUND"
+	ErrCodeTransactionNotFound ErrorCode = "TRANSACTION_NOT_FOUND"
+	ErrCodeNoDataAvailable     ErrorCode = "NO_DATA_AVAILABLE"
+	ErrCodeRouteNotFound       ErrorCode = "ROUTE_NOT_FOUND"
+	ErrCodeMethodNotAllowed    ErrorCode = "METHOD_NOT_ALLOWED"
+	ErrCodeRequestTooLarge     ErrorCode = "REQUEST_TOO_LARGE"
+	ErrCodeRateLimited         ErrorCode = "RATE_LIMITED"
+	ErrCodeTimeout             ErrorCode = "TIMEOUT"
+	ErrCodeInternal            ErrorCode = "INTERNAL_ERROR"
+)
+
+// problemContentType is the RFC 7807 media type for error responses
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details body extended with a stable code
+type Problem struct {
+	Type     string    `json:"type"`
+	Title    string    `json:"title"`
+	Status   int       `json:"status"`
+	Detail   string    `json:"detail,omitempty"`
+	Instance string    `json:"instance,omitempty"`
+	Code     ErrorCode `json:"code"`
+}
+
+// newProblem builds a Problem for the given status and code
+func newProblem(status int, code ErrorCode, detail string) Problem {
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// writeProblem writes an application/problem+json error response
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, code ErrorCode, detail string) {
+	problem := newProblem(status, code, detail)
+	if r != nil {
+		problem.Instance = r.URL.Path
+	}
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// problemBody renders a Problem as a JSON string, for fixed error bodies
+func problemBody(status int, code ErrorCode, detail string) string {
+	data, _ := json.Marshal(newProblem(status, code, detail))
+	return string(data)
+}
+
+// handleRouteNotFound answers requests that match no registered route
+func handleRouteNotFound(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, http.StatusNotFound, ErrCodeRouteNotFound, "No route matches "+r.URL.Path)
+}
+
+// handleMethodNotAllowed answers requests using an unsupported HTTP method
+func handleMethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, r.Method+" is not allowed on "+r.URL.Path)
+}
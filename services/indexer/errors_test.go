@@ -0,0 +1,90 @@
+package indexer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) Problem {
+	t.Helper()
+	assert.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+
+	var problem Problem
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&problem))
+	assert.Equal(t, w.Code, problem.Status)
+	return problem
+}
+
+func TestWriteProblem(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/v1/pools/missing", nil)
+	w := httptest.NewRecorder()
+
+	writeProblem(w, req, http.StatusNotFound, ErrCodePoolNotFound, "Pool not found")
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	problem := decodeProblem(t, w)
+	assert.Equal(t, "about:blank", problem.Type)
+	assert.Equal(t, "Not Found", problem.Title)
+	assert.Equal(t, "Pool not found", problem.Detail)
+	assert.Equal(t, "/api/v1/pools/missing", problem.Instance)
+	assert.Equal(t, ErrCodePoolNotFound, problem.Code)
+}
+
+func TestServer_Problems(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+	server := NewServer(svc, "8081")
+
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		body   string
+		status int
+		code   ErrorCode
+	}{
+		{"missing pool", "GET", "/api/v1/pools/missing", "", http.StatusNotFound, ErrCodePoolNotFound},
+		{"missing transaction", "GET", "/api/v1/transactions/missing", "", http.StatusNotFound, ErrCodeTransactionNotFound},
+		{"unknown field", "GET", "/api/v1/pools?fields=nope", "", http.StatusBadRequest, ErrCodeInvalidParameter},
+		{"bad batch body", "POST", "/api/v1/batch", "nope", http.StatusBadRequest, ErrCodeInvalidRequest},
+		{"unknown route", "GET", "/api/v2/nothing", "", http.StatusNotFound, ErrCodeRouteNotFound},
+		{"wrong method", "DELETE", "/api/v1/pools", "", http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			server.http.Handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			problem := decodeProblem(t, w)
+			assert.Equal(t, tt.code, problem.Code)
+		})
+	}
+}
+
+func TestServer_TimeoutProblem(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+	server := NewServer(svc, "8081", WithEndpointTimeout("/slow", 1))
+
+	r := mux.NewRouter()
+	r.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	r.Use(server.handlerDeadline)
+
+	req := httptest.NewRequest("GET", "/slow", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	problem := decodeProblem(t, w)
+	assert.Equal(t, ErrCodeTimeout, problem.Code)
+}
@@ -0,0 +1,68 @@
+package indexer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// etagRecorder buffers a response so its ETag can be computed before any of
+// it is written
+type etagRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *etagRecorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *etagRecorder) WriteHeader(status int) {
+	rec.status = status
+}
+
+func (rec *etagRecorder) Write(b []byte) (int, error) {
+	return rec.body.Write(b)
+}
+
+// withETag tags successful responses with an ETag derived from their body and
+// answers a matching If-None-Match with 304 Not Modified, so clients polling
+// unchanged data skip downloading it again
+func withETag(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rec := &etagRecorder{header: make(http.Header), status: http.StatusOK}
+		next(rec, r)
+
+		for key, values := range rec.header {
+			w.Header()[key] = values
+		}
+		if rec.status != http.StatusOK {
+			w.WriteHeader(rec.status)
+			w.Write(rec.body.Bytes())
+			return
+		}
+
+		sum := sha256.Sum256(rec.body.Bytes())
+		etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+		w.Header().Set("ETag", etag)
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write(rec.body.Bytes())
+	}
+}
+
+// etagMatches reports whether an If-None-Match header lists etag
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package indexer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_PoolsETag(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+	dexReader := svc.readers[0].(*DexReadModel)
+	dexReader.pools["pool-1"] = PoolInfo{ID: "pool-1", Asset0: "HBD", Asset1: "HIVE", Reserve0: 1000}
+	server := NewServer(svc, "8081")
+
+	w := httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/pools", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	// Unchanged pools are not sent again
+	req := httptest.NewRequest("GET", "/api/v1/pools", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	// A reserve change produces a new tag and a full response
+	dexReader.pools["pool-1"] = PoolInfo{ID: "pool-1", Asset0: "HBD", Asset1: "HIVE", Reserve0: 2000}
+	req = httptest.NewRequest("GET", "/api/v1/pools", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	assert.Contains(t, w.Body.String(), "2000")
+
+	// Errors are passed through untagged
+	w = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/pools/missing", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, w.Header().Get("ETag"))
+}
+
+func TestETagMatches(t *testing.T) {
+	assert.True(t, etagMatches(`"abc"`, `"abc"`))
+	assert.True(t, etagMatches(`"xyz", W/"abc"`, `"abc"`))
+	assert.True(t, etagMatches(`*`, `"abc"`))
+	assert.False(t, etagMatches(``, `"abc"`))
+	assert.False(t, etagMatches(`"abd"`, `"abc"`))
+}
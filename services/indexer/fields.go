@@ -0,0 +1,101 @@
+package indexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// parseFields parses the comma-separated fields= query parameter, validating
+// each name against the JSON attributes of model. An empty result means the
+// client did not ask for a sparse fieldset.
+func parseFields(r *http.Request, model interface{}) ([]string, error) {
+	raw := r.URL.Query().Get("fields")
+	if raw == "" {
+		return nil, nil
+	}
+
+	allowed, err := jsonAttributes(model)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []string
+	for _, f := range strings.Split(raw, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if _, ok := allowed[f]; !ok {
+			return nil, fmt.Errorf("unknown field: %s", f)
+		}
+		fields = append(fields, f)
+	}
+
+	return fields, nil
+}
+
+// jsonAttributes returns the set of top-level JSON attribute names of model
+func jsonAttributes(model interface{}) (map[string]struct{}, error) {
+	data, err := json.Marshal(model)
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+
+	attrs := make(map[string]struct{}, len(m))
+	for k := range m {
+		attrs[k] = struct{}{}
+	}
+	return attrs, nil
+}
+
+// selectFields projects v, a struct or a slice of structs, onto the requested
+// JSON attributes. The "id" attribute is always kept so entities stay
+// addressable. When fields is empty v is returned unchanged.
+func selectFields(v interface{}, fields []string) (interface{}, error) {
+	if len(fields) == 0 {
+		return v, nil
+	}
+
+	keep := map[string]struct{}{"id": {}}
+	for _, f := range fields {
+		keep[f] = struct{}{}
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	project := func(m map[string]json.RawMessage) map[string]json.RawMessage {
+		for k := range m {
+			if _, ok := keep[k]; !ok {
+				delete(m, k)
+			}
+		}
+		return m
+	}
+
+	if len(data) > 0 && data[0] == '[' {
+		var items []map[string]json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, err
+		}
+		for i := range items {
+			items[i] = project(items[i])
+		}
+		return items, nil
+	}
+
+	var item map[string]json.RawMessage
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, err
+	}
+	return project(item), nil
+}
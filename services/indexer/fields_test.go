@@ -0,0 +1,80 @@
+package indexer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFields(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/v1/pools?fields=reserve0,%20reserve1", nil)
+	fields, err := parseFields(req, PoolInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"reserve0", "reserve1"}, fields)
+
+	req = httptest.NewRequest("GET", "/api/v1/pools", nil)
+	fields, err = parseFields(req, PoolInfo{})
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+
+	req = httptest.NewRequest("GET", "/api/v1/pools?fields=reserve0,secret", nil)
+	_, err = parseFields(req, PoolInfo{})
+	assert.EqualError(t, err, "unknown field: secret")
+}
+
+func TestSelectFields(t *testing.T) {
+	pool := PoolInfo{ID: "pool-1", Asset0: "HBD", Asset1: "HIVE", Reserve0: 10, Reserve1: 20}
+
+	selected, err := selectFields(pool, []string{"reserve0"})
+	require.NoError(t, err)
+
+	data, err := json.Marshal(selected)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"pool-1","reserve0":10}`, string(data))
+
+	// No fields requested returns the value unchanged
+	unchanged, err := selectFields(pool, nil)
+	require.NoError(t, err)
+	assert.Equal(t, pool, unchanged)
+}
+
+func TestServer_handleGetPools_Fields(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+	dexReader := svc.readers[0].(*DexReadModel)
+	dexReader.pools["pool-1"] = PoolInfo{ID: "pool-1", Asset0: "HBD", Asset1: "HIVE", Reserve0: 10, Reserve1: 20}
+	server := NewServer(svc, "8081")
+
+	req := httptest.NewRequest("GET", "/api/v1/pools?fields=reserve0,reserve1", nil)
+	w := httptest.NewRecorder()
+	server.handleGetPools(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"id":"pool-1","reserve0":10,"reserve1":20}]`, w.Body.String())
+
+	req = httptest.NewRequest("GET", "/api/v1/pools?fields=bogus", nil)
+	w = httptest.NewRecorder()
+	server.handleGetPools(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestServer_handleGetTransaction_Fields(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+	dexReader := svc.readers[0].(*DexReadModel)
+	dexReader.transactions = []TransactionInfo{
+		{ID: "tx-1", Type: "swap", PoolID: "pool-1", User: "alice", BlockHeight: 7},
+	}
+	server := NewServer(svc, "8081")
+
+	req := httptest.NewRequest("GET", "/api/v1/transactions/tx-1?fields=type,user", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "tx-1"})
+	w := httptest.NewRecorder()
+	server.handleGetTransaction(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"id":"tx-1","type":"swap","user":"alice"}`, w.Body.String())
+}
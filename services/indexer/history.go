@@ -0,0 +1,239 @@
+package indexer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// maxSnapshotsPerPool bounds the point-in-time history kept for each pool
+const maxSnapshotsPerPool = 10000
+
+// poolSnapshot is a pool's state and positions at the end of a block
+type poolSnapshot struct {
+	blockHeight uint64
+	at          time.Time
+	pool        PoolInfo
+	positions   []LiquidityPosition
+}
+
+// poolHistory holds a pool's snapshots in block order
+type poolHistory struct {
+	snapshots []poolSnapshot
+	trimmed   bool // Older snapshots were dropped to respect maxSnapshotsPerPool
+}
+
+// PointInTime selects a past state by block height or by time. At most
+// one of Block and Time is set; the zero value means the current state.
+type PointInTime struct {
+	Block *uint64
+	Time  *time.Time
+}
+
+// IsZero reports whether p selects the current state
+func (p PointInTime) IsZero() bool {
+	return p.Block == nil && p.Time == nil
+}
+
+// errHistoryNotRetained is returned when a point in time predates the
+// oldest snapshot still held for a pool
+var errHistoryNotRetained = errors.New("history not retained for requested point in time")
+
+// eventTime returns the event's block timestamp, falling back to the clock
+func (dm *DexReadModel) eventTime(event VSCEvent) time.Time {
+	if event.Timestamp != "" {
+		if ts, err := time.Parse(time.RFC3339, event.Timestamp); err == nil {
+			return ts
+		}
+	}
+	return dm.clock()
+}
+
+// recordSnapshot stores a pool's state after an event; caller must hold dm.mu.
+// Events in the same block overwrite each other so each block keeps its final state.
+func (dm *DexReadModel) recordSnapshot(poolID string, event VSCEvent) {
+	pool, exists := dm.pools[poolID]
+	if !exists {
+		return
+	}
+
+	snapshot := poolSnapshot{
+		blockHeight: event.BlockHeight,
+		at:          dm.eventTime(event),
+		pool:        pool,
+		positions:   dm.queryLiquidityPositionsLocked(poolID),
+	}
+
+	history := dm.history[poolID]
+	if history == nil {
+		history = &poolHistory{}
+		dm.history[poolID] = history
+	}
+
+	if n := len(history.snapshots); n > 0 && history.snapshots[n-1].blockHeight == event.BlockHeight {
+		history.snapshots[n-1] = snapshot
+		return
+	}
+
+	history.snapshots = append(history.snapshots, snapshot)
+	if len(history.snapshots) > maxSnapshotsPerPool {
+		history.snapshots = history.snapshots[len(history.snapshots)-maxSnapshotsPerPool:]
+		history.trimmed = true
+	}
+}
+
+// snapshotAtLocked returns the latest snapshot at or before p; caller must
+// hold dm.mu. The second return value is false if the pool did not exist yet.
+func (dm *DexReadModel) snapshotAtLocked(poolID string, p PointInTime) (poolSnapshot, bool, error) {
+	history := dm.history[poolID]
+	if history == nil {
+		return poolSnapshot{}, false, nil
+	}
+	snapshots := history.snapshots
+
+	// Index of the first snapshot after the requested point
+	i := sort.Search(len(snapshots), func(i int) bool {
+		if p.Block != nil {
+			return snapshots[i].blockHeight > *p.Block
+		}
+		return snapshots[i].at.After(*p.Time)
+	})
+
+	if i == 0 {
+		if history.trimmed {
+			return poolSnapshot{}, false, errHistoryNotRetained
+		}
+		return poolSnapshot{}, false, nil
+	}
+	return snapshots[i-1], true, nil
+}
+
+// GetPoolAt returns a pool as it was at a past block or time, along with the
+// height of the snapshot used. The bool is false if the pool did not exist.
+func (dm *DexReadModel) GetPoolAt(poolID string, p PointInTime) (PoolInfo, uint64, bool, error) {
+	dm.mu.RLock()
+	defer dm.mu.RUnlock()
+
+	snapshot, exists, err := dm.snapshotAtLocked(poolID, p)
+	if err != nil || !exists {
+		return PoolInfo{}, 0, false, err
+	}
+	return snapshot.pool, snapshot.blockHeight, true, nil
+}
+
+// QueryLiquidityPositionsAt returns a pool's positions as they were at a past
+// block or time, along with the height of the snapshot used. The bool is
+// false if the pool did not exist.
+func (dm *DexReadModel) QueryLiquidityPositionsAt(poolID string, p PointInTime) ([]LiquidityPosition, uint64, bool, error) {
+	dm.mu.RLock()
+	defer dm.mu.RUnlock()
+
+	snapshot, exists, err := dm.snapshotAtLocked(poolID, p)
+	if err != nil || !exists {
+		return nil, 0, false, err
+	}
+
+	positions := make([]LiquidityPosition, len(snapshot.positions))
+	copy(positions, snapshot.positions)
+	return positions, snapshot.blockHeight, true, nil
+}
+
+// parsePointInTime reads the at_block and at_time query parameters. at_time
+// accepts RFC 3339 or Unix seconds.
+func parsePointInTime(r *http.Request) (PointInTime, error) {
+	var p PointInTime
+
+	atBlock := r.URL.Query().Get("at_block")
+	atTime := r.URL.Query().Get("at_time")
+	if atBlock != "" && atTime != "" {
+		return p, fmt.Errorf("at_block and at_time are mutually exclusive")
+	}
+
+	if atBlock != "" {
+		height, err := strconv.ParseUint(atBlock, 10, 64)
+		if err != nil {
+			return p, fmt.Errorf("invalid at_block: %s", atBlock)
+		}
+		p.Block = &height
+	}
+
+	if atTime != "" {
+		var t time.Time
+		if secs, err := strconv.ParseInt(atTime, 10, 64); err == nil {
+			t = time.Unix(secs, 0).UTC()
+		} else if parsed, err := time.Parse(time.RFC3339, atTime); err == nil {
+			t = parsed
+		} else {
+			return p, fmt.Errorf("invalid at_time: %s (expected RFC 3339 or Unix seconds)", atTime)
+		}
+		p.Time = &t
+	}
+
+	return p, nil
+}
+
+// writeSnapshotHeader reports which block a point-in-time response reflects
+func writeSnapshotHeader(w http.ResponseWriter, blockHeight uint64) {
+	w.Header().Set("X-Snapshot-Block", strconv.FormatUint(blockHeight, 10))
+}
+
+// handleGetPoolAt serves a pool as it was at a past block or time
+func (s *Server) handleGetPoolAt(w http.ResponseWriter, r *http.Request, poolID string, at PointInTime, fields []string) {
+	dexReader, ok := s.dexReader()
+	if !ok {
+		writeProblem(w, r, http.StatusNotFound, ErrCodePoolNotFound, "Pool not found")
+		return
+	}
+
+	pool, height, exists, err := dexReader.GetPoolAt(poolID, at)
+	if err != nil {
+		writeProblem(w, r, http.StatusNotFound, ErrCodeNoDataAvailable, err.Error())
+		return
+	}
+	if !exists {
+		writeProblem(w, r, http.StatusNotFound, ErrCodePoolNotFound, "Pool not found at requested point in time")
+		return
+	}
+
+	resp, err := selectFields(pool, fields)
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+
+	writeSnapshotHeader(w, height)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleGetPoolAccountsAt serves a pool's positions as they were at a past
+// block or time
+func (s *Server) handleGetPoolAccountsAt(w http.ResponseWriter, r *http.Request, poolID string, at PointInTime) {
+	dexReader, ok := s.dexReader()
+	if !ok {
+		writeProblem(w, r, http.StatusNotFound, ErrCodePoolNotFound, "Pool not found")
+		return
+	}
+
+	accounts, height, exists, err := dexReader.QueryLiquidityPositionsAt(poolID, at)
+	if err != nil {
+		writeProblem(w, r, http.StatusNotFound, ErrCodeNoDataAvailable, err.Error())
+		return
+	}
+	if !exists {
+		writeProblem(w, r, http.StatusNotFound, ErrCodePoolNotFound, "Pool not found at requested point in time")
+		return
+	}
+
+	writeSnapshotHeader(w, height)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"pool_id":      poolID,
+		"block_height": height,
+		"accounts":     accounts,
+	})
+}
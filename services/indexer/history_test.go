@@ -0,0 +1,135 @@
+package indexer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newHistoryModel returns a read model with a pool created at block 100,
+// funded by alice at block 110 and swapped against at block 120
+func newHistoryModel(t *testing.T) *DexReadModel {
+	rm := NewDexReadModel()
+
+	events := []VSCEvent{
+		{BlockHeight: 100, Timestamp: "2025-06-01T10:00:00Z", Method: "pool_created",
+			Args: json.RawMessage(`{"pool_id":"pool-1","asset0":"HBD","asset1":"HIVE","fee":0.3}`)},
+		{BlockHeight: 110, Timestamp: "2025-06-01T10:00:30Z", Method: "liquidity_added",
+			Args: json.RawMessage(`{"pool_id":"pool-1","user":"alice","amount0":1000,"amount1":2000,"lp_tokens":100}`)},
+		{BlockHeight: 120, Timestamp: "2025-06-01T10:01:00Z", Method: "swap_executed",
+			Args: json.RawMessage(`{"pool_id":"pool-1","amount_in":100,"amount_out":180,"asset_in":"HBD","asset_out":"HIVE"}`)},
+		{BlockHeight: 120, Timestamp: "2025-06-01T10:01:00Z", Method: "swap_executed",
+			Args: json.RawMessage(`{"pool_id":"pool-1","amount_in":100,"amount_out":150,"asset_in":"HBD","asset_out":"HIVE"}`)},
+	}
+	for _, event := range events {
+		event.Contract = "dex-router"
+		require.NoError(t, rm.HandleEvent(event))
+	}
+	return rm
+}
+
+func TestDexReadModel_GetPoolAt(t *testing.T) {
+	rm := newHistoryModel(t)
+
+	block := func(h uint64) PointInTime { return PointInTime{Block: &h} }
+
+	pool, height, exists, err := rm.GetPoolAt("pool-1", block(115))
+	require.NoError(t, err)
+	require.True(t, exists)
+	assert.Equal(t, uint64(110), height)
+	assert.Equal(t, uint64(1000), pool.Reserve0)
+	assert.Equal(t, uint64(2000), pool.Reserve1)
+
+	// Events in the same block collapse to the block's final state
+	pool, height, _, err = rm.GetPoolAt("pool-1", block(1000))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(120), height)
+	assert.Equal(t, uint64(1200), pool.Reserve0)
+	assert.Equal(t, uint64(1670), pool.Reserve1)
+
+	// Before the pool was created
+	_, _, exists, err = rm.GetPoolAt("pool-1", block(99))
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	at := time.Date(2025, 6, 1, 10, 0, 45, 0, time.UTC)
+	pool, height, exists, err = rm.GetPoolAt("pool-1", PointInTime{Time: &at})
+	require.NoError(t, err)
+	require.True(t, exists)
+	assert.Equal(t, uint64(110), height)
+	assert.Equal(t, uint64(1000), pool.Reserve0)
+}
+
+func TestDexReadModel_QueryLiquidityPositionsAt(t *testing.T) {
+	rm := newHistoryModel(t)
+
+	h := uint64(105)
+	positions, _, exists, err := rm.QueryLiquidityPositionsAt("pool-1", PointInTime{Block: &h})
+	require.NoError(t, err)
+	require.True(t, exists)
+	assert.Empty(t, positions)
+
+	h = 110
+	positions, _, _, err = rm.QueryLiquidityPositionsAt("pool-1", PointInTime{Block: &h})
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	assert.Equal(t, "alice", positions[0].User)
+	assert.Equal(t, uint64(100), positions[0].Amount)
+}
+
+func TestDexReadModel_HistoryTrimmed(t *testing.T) {
+	rm := NewDexReadModel()
+	rm.pools["pool-1"] = PoolInfo{ID: "pool-1", Asset0: "HBD", Asset1: "HIVE"}
+
+	for h := uint64(1); h <= maxSnapshotsPerPool+5; h++ {
+		rm.recordSnapshot("pool-1", VSCEvent{BlockHeight: h})
+	}
+
+	h := uint64(3)
+	_, _, _, err := rm.GetPoolAt("pool-1", PointInTime{Block: &h})
+	assert.ErrorIs(t, err, errHistoryNotRetained)
+
+	h = 10
+	_, height, exists, err := rm.GetPoolAt("pool-1", PointInTime{Block: &h})
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, uint64(10), height)
+}
+
+func TestServer_PointInTimeQueries(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+	server := NewServer(svc, "8081")
+	svc.readers[0] = newHistoryModel(t)
+
+	req := httptest.NewRequest("GET", "/api/v1/pools/pool-1?at_block=115&fields=reserve0", nil)
+	w := httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "110", w.Header().Get("X-Snapshot-Block"))
+	assert.JSONEq(t, `{"id":"pool-1","reserve0":1000}`, w.Body.String())
+
+	req = httptest.NewRequest("GET", "/api/v1/pools/pool-1/accounts?at_time=2025-06-01T10:00:10Z", nil)
+	w = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "100", w.Header().Get("X-Snapshot-Block"))
+	assert.Contains(t, w.Body.String(), `"accounts":[]`)
+
+	req = httptest.NewRequest("GET", "/api/v1/pools/pool-1?at_block=50", nil)
+	w = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), string(ErrCodePoolNotFound))
+
+	for _, query := range []string{"at_block=abc", "at_time=yesterday", "at_block=1&at_time=1"} {
+		req = httptest.NewRequest("GET", "/api/v1/pools/pool-1?"+query, nil)
+		w = httptest.NewRecorder()
+		server.http.Handler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
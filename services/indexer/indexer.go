@@ -32,9 +32,16 @@ type ReadModel interface {
 	QueryPools() ([]PoolInfo, error)
 }
 
+// Pool types. Only constant product pools are priced by their reserves.
+const (
+	PoolTypeConstantProduct = "constant_product"
+	PoolTypeConcentrated    = "concentrated"
+)
+
 // PoolInfo represents indexed pool data
 type PoolInfo struct {
 	ID          string  `json:"id"`
+	Type        string  `json:"type"` // PoolTypeConstantProduct or PoolTypeConcentrated
 	Asset0      string  `json:"asset0"`
 	Asset1      string  `json:"asset1"`
 	Reserve0    uint64  `json:"reserve0"`
@@ -53,10 +60,11 @@ type VSCEvent struct {
 	Args        json.RawMessage `json:"args"`
 	BlockHeight uint64          `json:"block_height"`
 	TxID        string          `json:"tx_id"`
+	Timestamp   string          `json:"timestamp,omitempty"` // Block timestamp (RFC 3339), if known
 }
 
 // NewService creates a new indexer service
-func NewService(httpURL string, port string) *Service {
+func NewService(httpURL string, port string, opts ...ServerOption) *Service {
 	svc := &Service{
 		httpURL:      httpURL,
 		wsURL:        "", // Will be set if WebSocket endpoint provided
@@ -71,7 +79,7 @@ func NewService(httpURL string, port string) *Service {
 	svc.AddReader(dexReader)
 
 	// Create HTTP server
-	svc.server = NewServer(svc, port)
+	svc.server = NewServer(svc, port, opts...)
 
 	return svc
 }
@@ -270,6 +278,7 @@ func (s *Service) pollContractOutputs(ctx context.Context, contractID string, fr
 				Method:      "", // Will be extracted from result if available
 				BlockHeight: uint64(output.BlockHeight),
 				TxID:        output.ID,
+				Timestamp:   output.Timestamp,
 				Args:        json.RawMessage("{}"), // Contract output data would go here
 			}
 
@@ -473,6 +482,9 @@ func parseVSCEvent(eventMap map[string]interface{}) *VSCEvent {
 	if v, ok := eventMap["blockHeight"].(float64); ok {
 		event.BlockHeight = uint64(v)
 	}
+	if v, ok := eventMap["timestamp"].(string); ok {
+		event.Timestamp = v
+	}
 	if args, ok := eventMap["args"].(map[string]interface{}); ok {
 		argsJSON, err := json.Marshal(args)
 		if err == nil {
@@ -0,0 +1,375 @@
+package indexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// maxActivityEntries bounds the per-user activity kept for leaderboards
+const maxActivityEntries = 100000
+
+// Activity kinds tracked for leaderboards
+const (
+	activitySwap       = "swap"
+	activityDeposit    = "deposit"
+	activityWithdrawal = "withdrawal"
+)
+
+// leaderboardPeriods maps period names to their lookback window. A zero
+// window covers all retained activity.
+var leaderboardPeriods = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+	"all": 0,
+}
+
+// activityEntry records a single user action for leaderboard aggregation
+type activityEntry struct {
+	at      time.Time
+	user    string
+	poolID  string
+	kind    string
+	amounts map[string]uint64 // asset -> amount moved
+}
+
+// TraderStats summarizes a user's swap activity over a period
+type TraderStats struct {
+	User   string            `json:"user"`
+	Trades int               `json:"trades"`
+	Volume map[string]uint64 `json:"volume"` // asset -> amount swapped in or out
+}
+
+// LPStats summarizes a user's liquidity activity over a period
+type LPStats struct {
+	User        string            `json:"user"`
+	Deposits    int               `json:"deposits"`
+	Withdrawals int               `json:"withdrawals"`
+	Provided    map[string]uint64 `json:"provided"`  // asset -> amount deposited
+	Withdrawn   map[string]uint64 `json:"withdrawn"` // asset -> amount withdrawn
+}
+
+// LeaderboardQuery selects and pages leaderboard entries
+type LeaderboardQuery struct {
+	Period string // One of leaderboardPeriods; empty means "all"
+	Asset  string // Rank by volume in this asset instead of by count
+	PoolID string // Restrict to a single pool
+	Offset int
+	Limit  int
+}
+
+// recordActivity appends a leaderboard entry; caller must hold dm.mu
+func (dm *DexReadModel) recordActivity(event VSCEvent, user string, pool PoolInfo, kind string, amounts map[string]uint64) {
+	if user == "" {
+		return
+	}
+
+	dm.activity = append(dm.activity, activityEntry{
+		at:      dm.eventTime(event),
+		user:    user,
+		poolID:  pool.ID,
+		kind:    kind,
+		amounts: amounts,
+	})
+
+	if len(dm.activity) > maxActivityEntries {
+		dm.activity = dm.activity[len(dm.activity)-maxActivityEntries:]
+	}
+}
+
+// clock returns the current time, defaulting to time.Now
+func (dm *DexReadModel) clock() time.Time {
+	if dm.now == nil {
+		return time.Now()
+	}
+	return dm.now()
+}
+
+// swapVolumes returns the amount of each asset moved by a swap, accepting
+// both the legacy reserve-delta format and the amount_in/amount_out format
+func swapVolumes(pool PoolInfo, delta0, delta1 int64, amountIn, amountOut uint64, assetIn, assetOut string) map[string]uint64 {
+	if delta0 != 0 || delta1 != 0 {
+		return map[string]uint64{
+			pool.Asset0: uint64(absFloat(float64(delta0))),
+			pool.Asset1: uint64(absFloat(float64(delta1))),
+		}
+	}
+
+	if assetOut == "" {
+		assetOut = pool.Asset1
+		if assetIn == pool.Asset1 {
+			assetOut = pool.Asset0
+		}
+	}
+	return map[string]uint64{
+		assetIn:  amountIn,
+		assetOut: amountOut,
+	}
+}
+
+// activitySinceLocked returns the entries inside the query's period and pool;
+// caller must hold dm.mu
+func (dm *DexReadModel) activitySinceLocked(q LeaderboardQuery) ([]activityEntry, error) {
+	period := q.Period
+	if period == "" {
+		period = "all"
+	}
+	window, ok := leaderboardPeriods[period]
+	if !ok {
+		return nil, fmt.Errorf("invalid period: %s (expected 24h, 7d, 30d or all)", q.Period)
+	}
+
+	var cutoff time.Time
+	if window > 0 {
+		cutoff = dm.clock().Add(-window)
+	}
+
+	var entries []activityEntry
+	for _, entry := range dm.activity {
+		if window > 0 && entry.at.Before(cutoff) {
+			continue
+		}
+		if q.PoolID != "" && entry.poolID != q.PoolID {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// QueryTraderLeaderboard ranks users by swap count, or by volume in
+// q.Asset if set. It returns one page of entries and the total number of
+// ranked users.
+func (dm *DexReadModel) QueryTraderLeaderboard(q LeaderboardQuery) ([]TraderStats, int, error) {
+	dm.mu.RLock()
+	defer dm.mu.RUnlock()
+
+	entries, err := dm.activitySinceLocked(q)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	byUser := make(map[string]*TraderStats)
+	for _, entry := range entries {
+		if entry.kind != activitySwap {
+			continue
+		}
+		stats, ok := byUser[entry.user]
+		if !ok {
+			stats = &TraderStats{User: entry.user, Volume: make(map[string]uint64)}
+			byUser[entry.user] = stats
+		}
+		stats.Trades++
+		for asset, amount := range entry.amounts {
+			stats.Volume[asset] += amount
+		}
+	}
+
+	traders := make([]TraderStats, 0, len(byUser))
+	for _, stats := range byUser {
+		if q.Asset != "" && stats.Volume[q.Asset] == 0 {
+			continue
+		}
+		traders = append(traders, *stats)
+	}
+
+	sort.Slice(traders, func(i, j int) bool {
+		a, b := traders[i], traders[j]
+		if q.Asset != "" && a.Volume[q.Asset] != b.Volume[q.Asset] {
+			return a.Volume[q.Asset] > b.Volume[q.Asset]
+		}
+		if a.Trades != b.Trades {
+			return a.Trades > b.Trades
+		}
+		return a.User < b.User
+	})
+
+	total := len(traders)
+	start, end := pageBounds(total, q.Offset, q.Limit)
+	return traders[start:end], total, nil
+}
+
+// QueryLPLeaderboard ranks users by deposit count, or by the amount of
+// q.Asset provided if set. It returns one page of entries and the total
+// number of ranked users.
+func (dm *DexReadModel) QueryLPLeaderboard(q LeaderboardQuery) ([]LPStats, int, error) {
+	dm.mu.RLock()
+	defer dm.mu.RUnlock()
+
+	entries, err := dm.activitySinceLocked(q)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	byUser := make(map[string]*LPStats)
+	for _, entry := range entries {
+		if entry.kind != activityDeposit && entry.kind != activityWithdrawal {
+			continue
+		}
+		stats, ok := byUser[entry.user]
+		if !ok {
+			stats = &LPStats{
+				User:      entry.user,
+				Provided:  make(map[string]uint64),
+				Withdrawn: make(map[string]uint64),
+			}
+			byUser[entry.user] = stats
+		}
+		if entry.kind == activityDeposit {
+			stats.Deposits++
+			for asset, amount := range entry.amounts {
+				stats.Provided[asset] += amount
+			}
+		} else {
+			stats.Withdrawals++
+			for asset, amount := range entry.amounts {
+				stats.Withdrawn[asset] += amount
+			}
+		}
+	}
+
+	lps := make([]LPStats, 0, len(byUser))
+	for _, stats := range byUser {
+		if q.Asset != "" && stats.Provided[q.Asset] == 0 {
+			continue
+		}
+		lps = append(lps, *stats)
+	}
+
+	sort.Slice(lps, func(i, j int) bool {
+		a, b := lps[i], lps[j]
+		if q.Asset != "" && a.Provided[q.Asset] != b.Provided[q.Asset] {
+			return a.Provided[q.Asset] > b.Provided[q.Asset]
+		}
+		if a.Deposits != b.Deposits {
+			return a.Deposits > b.Deposits
+		}
+		return a.User < b.User
+	})
+
+	total := len(lps)
+	start, end := pageBounds(total, q.Offset, q.Limit)
+	return lps[start:end], total, nil
+}
+
+// pageBounds clamps an offset/limit page to a slice of length n
+func pageBounds(n, offset, limit int) (int, int) {
+	if offset > n {
+		offset = n
+	}
+	end := offset + limit
+	if limit <= 0 || end > n {
+		end = n
+	}
+	return offset, end
+}
+
+// parseLeaderboardQuery reads period, asset, pool_id, offset and limit
+func parseLeaderboardQuery(r *http.Request) (LeaderboardQuery, error) {
+	params := r.URL.Query()
+
+	q := LeaderboardQuery{
+		Period: params.Get("period"),
+		Asset:  params.Get("asset"),
+		PoolID: params.Get("pool_id"),
+		Offset: 0,
+		Limit:  50, // Default limit
+	}
+	if q.Period == "" {
+		q.Period = "all"
+	}
+	if _, ok := leaderboardPeriods[q.Period]; !ok {
+		return q, fmt.Errorf("invalid period: %s (expected 24h, 7d, 30d or all)", q.Period)
+	}
+
+	if v := params.Get("offset"); v != "" {
+		o, err := strconv.Atoi(v)
+		if err != nil || o < 0 {
+			return q, fmt.Errorf("invalid offset: %s", v)
+		}
+		q.Offset = o
+	}
+	if v := params.Get("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l <= 0 || l > 100 {
+			return q, fmt.Errorf("invalid limit: %s (expected 1-100)", v)
+		}
+		q.Limit = l
+	}
+
+	return q, nil
+}
+
+// handleGetTraderLeaderboard returns users ranked by swap activity
+func (s *Server) handleGetTraderLeaderboard(w http.ResponseWriter, r *http.Request) {
+	q, err := parseLeaderboardQuery(r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+		return
+	}
+
+	dexReader, ok := s.dexReader()
+	if !ok {
+		writeProblem(w, r, http.StatusInternalServerError, ErrCodeNoDataAvailable, "No leaderboard data available")
+		return
+	}
+
+	traders, total, err := dexReader.QueryTraderLeaderboard(q)
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+
+	pagination := newPagination(r, q.Offset, q.Limit, total)
+	writePaginationLinks(w, pagination)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"period":     q.Period,
+		"asset":      q.Asset,
+		"pool_id":    q.PoolID,
+		"offset":     q.Offset,
+		"limit":      q.Limit,
+		"total":      total,
+		"traders":    traders,
+		"pagination": pagination,
+	})
+}
+
+// handleGetLPLeaderboard returns users ranked by liquidity provision
+func (s *Server) handleGetLPLeaderboard(w http.ResponseWriter, r *http.Request) {
+	q, err := parseLeaderboardQuery(r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+		return
+	}
+
+	dexReader, ok := s.dexReader()
+	if !ok {
+		writeProblem(w, r, http.StatusInternalServerError, ErrCodeNoDataAvailable, "No leaderboard data available")
+		return
+	}
+
+	lps, total, err := dexReader.QueryLPLeaderboard(q)
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+
+	pagination := newPagination(r, q.Offset, q.Limit, total)
+	writePaginationLinks(w, pagination)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"period":     q.Period,
+		"asset":      q.Asset,
+		"pool_id":    q.PoolID,
+		"offset":     q.Offset,
+		"limit":      q.Limit,
+		"total":      total,
+		"lps":        lps,
+		"pagination": pagination,
+	})
+}
@@ -0,0 +1,176 @@
+package indexer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLeaderboardModel returns a read model with one pool and a fixed clock
+func newLeaderboardModel(t *testing.T, now time.Time) *DexReadModel {
+	rm := NewDexReadModel()
+	rm.now = func() time.Time { return now }
+
+	require.NoError(t, rm.HandleEvent(VSCEvent{
+		Contract: "dex-router",
+		Method:   "pool_created",
+		Args:     json.RawMessage(`{"pool_id":"pool-1","asset0":"HBD","asset1":"HIVE","fee":0.3}`),
+	}))
+	return rm
+}
+
+func TestDexReadModel_QueryTraderLeaderboard(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	rm := newLeaderboardModel(t, now)
+
+	swap := func(user string, amountIn uint64, at time.Time) {
+		require.NoError(t, rm.HandleEvent(VSCEvent{
+			Contract:  "dex-router",
+			Method:    "swap_executed",
+			Timestamp: at.Format(time.RFC3339),
+			Args:      json.RawMessage(`{"pool_id":"pool-1","user":"` + user + `","amount_in":` + jsonUint(amountIn) + `,"amount_out":10,"asset_in":"HBD","asset_out":"HIVE"}`),
+		}))
+	}
+
+	swap("alice", 100, now.Add(-time.Hour))
+	swap("alice", 100, now.Add(-2*time.Hour))
+	swap("bob", 500, now.Add(-time.Hour))
+	swap("carol", 50, now.Add(-48*time.Hour))
+
+	// Ranked by trade count, ties broken by user
+	traders, total, err := rm.QueryTraderLeaderboard(LeaderboardQuery{Period: "all", Limit: 50})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, traders, 3)
+	assert.Equal(t, "alice", traders[0].User)
+	assert.Equal(t, 2, traders[0].Trades)
+	assert.Equal(t, uint64(200), traders[0].Volume["HBD"])
+	assert.Equal(t, uint64(20), traders[0].Volume["HIVE"])
+	assert.Equal(t, "bob", traders[1].User)
+	assert.Equal(t, "carol", traders[2].User)
+
+	// Ranked by volume in an asset, within the period
+	traders, total, err = rm.QueryTraderLeaderboard(LeaderboardQuery{Period: "24h", Asset: "HBD", Limit: 50})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, "bob", traders[0].User)
+	assert.Equal(t, "alice", traders[1].User)
+
+	// Pagination
+	traders, total, err = rm.QueryTraderLeaderboard(LeaderboardQuery{Period: "all", Offset: 1, Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, traders, 1)
+	assert.Equal(t, "bob", traders[0].User)
+
+	traders, _, err = rm.QueryTraderLeaderboard(LeaderboardQuery{Period: "all", Offset: 10, Limit: 1})
+	require.NoError(t, err)
+	assert.Empty(t, traders)
+
+	_, _, err = rm.QueryTraderLeaderboard(LeaderboardQuery{Period: "1y"})
+	assert.Error(t, err)
+}
+
+func TestDexReadModel_QueryLPLeaderboard(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	rm := newLeaderboardModel(t, now)
+
+	events := []VSCEvent{
+		{Method: "liquidity_added", Args: json.RawMessage(`{"pool_id":"pool-1","user":"alice","amount0":1000,"amount1":2000,"lp_tokens":100}`)},
+		{Method: "liquidity_added", Args: json.RawMessage(`{"pool_id":"pool-1","user":"bob","amount0":5000,"amount1":10000,"lp_tokens":500}`)},
+		{Method: "liquidity_added", Args: json.RawMessage(`{"pool_id":"pool-1","user":"alice","amount0":1000,"amount1":2000,"lp_tokens":100}`)},
+		{Method: "liquidity_removed", Args: json.RawMessage(`{"pool_id":"pool-1","user":"alice","amount0":500,"amount1":1000,"lp_tokens":50}`)},
+	}
+	for _, event := range events {
+		event.Contract = "dex-router"
+		require.NoError(t, rm.HandleEvent(event))
+	}
+
+	lps, total, err := rm.QueryLPLeaderboard(LeaderboardQuery{Limit: 50})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, "alice", lps[0].User)
+	assert.Equal(t, 2, lps[0].Deposits)
+	assert.Equal(t, 1, lps[0].Withdrawals)
+	assert.Equal(t, uint64(2000), lps[0].Provided["HBD"])
+	assert.Equal(t, uint64(500), lps[0].Withdrawn["HBD"])
+
+	lps, _, err = rm.QueryLPLeaderboard(LeaderboardQuery{Asset: "HIVE", Limit: 50})
+	require.NoError(t, err)
+	assert.Equal(t, "bob", lps[0].User)
+
+	lps, total, err = rm.QueryLPLeaderboard(LeaderboardQuery{PoolID: "other", Limit: 50})
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+	assert.Empty(t, lps)
+}
+
+func TestServer_Leaderboards(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+	server := NewServer(svc, "8081")
+
+	dexReader := svc.readers[0].(*DexReadModel)
+	dexReader.pools["pool-1"] = PoolInfo{ID: "pool-1", Asset0: "HBD", Asset1: "HIVE"}
+	require.NoError(t, dexReader.HandleEvent(VSCEvent{
+		Contract: "dex-router",
+		Method:   "swap_executed",
+		Args:     json.RawMessage(`{"pool_id":"pool-1","user":"alice","amount_in":100,"amount_out":10,"asset_in":"HBD","asset_out":"HIVE"}`),
+	}))
+
+	req := httptest.NewRequest("GET", "/api/v1/leaderboards/traders?period=7d&limit=10", nil)
+	w := httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Period  string        `json:"period"`
+		Limit   int           `json:"limit"`
+		Total   int           `json:"total"`
+		Traders []TraderStats `json:"traders"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "7d", resp.Period)
+	assert.Equal(t, 10, resp.Limit)
+	assert.Equal(t, 1, resp.Total)
+	require.Len(t, resp.Traders, 1)
+	assert.Equal(t, "alice", resp.Traders[0].User)
+
+	req = httptest.NewRequest("GET", "/api/v1/leaderboards/lps", nil)
+	w = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"lps":[]`)
+
+	for _, query := range []string{"period=1y", "limit=0", "limit=101", "offset=-1"} {
+		req = httptest.NewRequest("GET", "/api/v1/leaderboards/traders?"+query, nil)
+		w = httptest.NewRecorder()
+		server.http.Handler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		assert.Contains(t, w.Body.String(), string(ErrCodeInvalidParameter), query)
+	}
+}
+
+func TestServer_LeaderboardsCached(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+	cache := newMockResponseCache()
+	server := NewServer(svc, "8081", WithResponseCache(cache, DefaultCacheTTLs()))
+
+	for _, expected := range []string{"MISS", "HIT"} {
+		req := httptest.NewRequest("GET", "/api/v1/leaderboards/traders?period=24h", nil)
+		w := httptest.NewRecorder()
+		server.http.Handler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, expected, w.Header().Get("X-Cache"))
+	}
+}
+
+// jsonUint formats n for inline JSON fixtures
+func jsonUint(n uint64) string {
+	data, _ := json.Marshal(n)
+	return string(data)
+}
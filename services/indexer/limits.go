@@ -0,0 +1,132 @@
+package indexer
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Default server limits
+const (
+	defaultReadTimeout       = 10 * time.Second
+	defaultReadHeaderTimeout = 5 * time.Second
+	defaultWriteTimeout      = 30 * time.Second
+	defaultIdleTimeout       = 60 * time.Second
+	defaultHandlerTimeout    = 15 * time.Second
+	defaultMaxBodyBytes      = 1 << 20 // 1 MiB
+)
+
+// serverLimits holds connection timeouts, handler deadlines and body limits
+type serverLimits struct {
+	readTimeout       time.Duration
+	readHeaderTimeout time.Duration
+	writeTimeout      time.Duration
+	idleTimeout       time.Duration
+	handlerTimeout    time.Duration
+	endpointTimeouts  map[string]time.Duration // route path template -> deadline
+	maxBodyBytes      int64
+}
+
+// defaultServerLimits returns the limits used when no options are given
+func defaultServerLimits() serverLimits {
+	return serverLimits{
+		readTimeout:       defaultReadTimeout,
+		readHeaderTimeout: defaultReadHeaderTimeout,
+		writeTimeout:      defaultWriteTimeout,
+		idleTimeout:       defaultIdleTimeout,
+		handlerTimeout:    defaultHandlerTimeout,
+		endpointTimeouts:  map[string]time.Duration{poolStreamPath: 0}, // Streams run until the client disconnects
+		maxBodyBytes:      defaultMaxBodyBytes,
+	}
+}
+
+// WithTimeouts sets the connection-level read, write and idle timeouts
+func WithTimeouts(read, write, idle time.Duration) ServerOption {
+	return func(s *Server) {
+		s.limits.readTimeout = read
+		s.limits.writeTimeout = write
+		s.limits.idleTimeout = idle
+	}
+}
+
+// WithHandlerTimeout sets the default deadline applied to every handler.
+// A zero duration disables handler deadlines.
+func WithHandlerTimeout(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.limits.handlerTimeout = d
+	}
+}
+
+// WithEndpointTimeout overrides the handler deadline for a single route,
+// identified by its path template (e.g. "/api/v1/batch")
+func WithEndpointTimeout(pathTemplate string, d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.limits.endpointTimeouts[pathTemplate] = d
+	}
+}
+
+// WithMaxBodyBytes caps the size of request bodies. A non-positive value
+// disables the limit.
+func WithMaxBodyBytes(n int64) ServerOption {
+	return func(s *Server) {
+		s.limits.maxBodyBytes = n
+	}
+}
+
+// limitBody rejects request bodies larger than the configured maximum
+func (s *Server) limitBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.limits.maxBodyBytes > 0 && r.Body != nil {
+			if r.ContentLength > s.limits.maxBodyBytes {
+				writeProblem(w, r, http.StatusRequestEntityTooLarge, ErrCodeRequestTooLarge, "Request body too large")
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, s.limits.maxBodyBytes)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handlerDeadline applies the per-endpoint deadline to the matched route
+func (s *Server) handlerDeadline(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout := s.limits.handlerTimeout
+		if route := mux.CurrentRoute(r); route != nil {
+			if tpl, err := route.GetPathTemplate(); err == nil {
+				tpl = strings.TrimPrefix(tpl, s.config.BasePath)
+				if d, ok := s.limits.endpointTimeouts[tpl]; ok {
+					timeout = d
+				}
+			}
+		}
+
+		if timeout <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		body := problemBody(http.StatusServiceUnavailable, ErrCodeTimeout, "Request timed out")
+		http.TimeoutHandler(next, timeout, body).ServeHTTP(&timeoutProblemWriter{ResponseWriter: w}, r)
+	})
+}
+
+// timeoutProblemWriter labels http.TimeoutHandler's fixed 503 body as a
+// problem document, since TimeoutHandler writes it without a Content-Type
+type timeoutProblemWriter struct {
+	http.ResponseWriter
+}
+
+func (tw *timeoutProblemWriter) WriteHeader(status int) {
+	if status == http.StatusServiceUnavailable && tw.Header().Get("Content-Type") == "" {
+		tw.Header().Set("Content-Type", problemContentType)
+	}
+	tw.ResponseWriter.WriteHeader(status)
+}
+
+// isBodyTooLarge reports whether err was caused by exceeding the body limit
+func isBodyTooLarge(err error) bool {
+	var maxErr *http.MaxBytesError
+	return errors.As(err, &maxErr)
+}
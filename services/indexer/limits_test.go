@@ -0,0 +1,85 @@
+package indexer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewServer_DefaultLimits(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+	server := NewServer(svc, "8081")
+
+	assert.Equal(t, defaultReadTimeout, server.http.ReadTimeout)
+	assert.Equal(t, defaultReadHeaderTimeout, server.http.ReadHeaderTimeout)
+	assert.Equal(t, defaultWriteTimeout, server.http.WriteTimeout)
+	assert.Equal(t, defaultIdleTimeout, server.http.IdleTimeout)
+	assert.Equal(t, int64(defaultMaxBodyBytes), server.limits.maxBodyBytes)
+}
+
+func TestNewServer_WithTimeouts(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+	server := NewServer(svc, "8081", WithTimeouts(time.Second, 2*time.Second, 3*time.Second))
+
+	assert.Equal(t, time.Second, server.http.ReadTimeout)
+	assert.Equal(t, 2*time.Second, server.http.WriteTimeout)
+	assert.Equal(t, 3*time.Second, server.http.IdleTimeout)
+}
+
+func TestServer_MaxBodyBytes(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+	server := NewServer(svc, "8081", WithMaxBodyBytes(64))
+
+	body := `{"requests":[` + strings.Repeat(`{"op":"pool","pool_id":"x"},`, 10) + `{"op":"pool","pool_id":"x"}]}`
+
+	req := httptest.NewRequest("POST", "/api/v1/batch", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	// Bodies without a declared length are cut off while decoding
+	req = httptest.NewRequest("POST", "/rpc", strings.NewReader(body))
+	req.ContentLength = -1
+	w = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestServer_HandlerDeadline(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+	server := NewServer(svc, "8081",
+		WithHandlerTimeout(time.Second),
+		WithEndpointTimeout("/slow", 10*time.Millisecond),
+	)
+
+	wait := func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(50 * time.Millisecond):
+			w.Write([]byte("done"))
+		}
+	}
+
+	r := mux.NewRouter()
+	r.HandleFunc("/slow", wait)
+	r.HandleFunc("/fast", wait)
+	r.Use(server.handlerDeadline)
+
+	req := httptest.NewRequest("GET", "/slow", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "Request timed out")
+
+	// Routes without an override use the default deadline
+	req = httptest.NewRequest("GET", "/fast", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "done", w.Body.String())
+}
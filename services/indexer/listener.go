@@ -0,0 +1,94 @@
+package indexer
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// Listener networks accepted in ServerConfig.Network
+const (
+	networkTCP  = "tcp"
+	networkTCP4 = "tcp4"
+	networkTCP6 = "tcp6"
+	networkUnix = "unix"
+)
+
+// defaultSocketMode is the permission set on unix sockets, letting a
+// reverse proxy in the same group connect
+const defaultSocketMode os.FileMode = 0660
+
+// network returns the configured listener network, defaulting to tcp
+func (c ServerConfig) network() string {
+	if c.Network == "" {
+		return networkTCP
+	}
+	return c.Network
+}
+
+// validateListener checks the network, bind address and socket settings
+func (c ServerConfig) validateListener() error {
+	switch c.network() {
+	case networkTCP, networkTCP4, networkTCP6:
+		host := strings.Trim(c.BindAddress, "[]")
+		if host == "" {
+			return nil
+		}
+		ip := net.ParseIP(host)
+		if c.network() == networkTCP4 && ip != nil && ip.To4() == nil {
+			return fmt.Errorf("bind address %s is not an IPv4 address", c.BindAddress)
+		}
+		if c.network() == networkTCP6 && ip != nil && ip.To4() != nil {
+			return fmt.Errorf("bind address %s is not an IPv6 address", c.BindAddress)
+		}
+		return nil
+	case networkUnix:
+		if c.SocketPath == "" {
+			return fmt.Errorf("unix listener requires a socket path")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported network: %s (expected tcp, tcp4, tcp6 or unix)", c.Network)
+	}
+}
+
+// ListenAddress describes where the server listens, for logging
+func (c ServerConfig) ListenAddress() string {
+	if c.network() == networkUnix {
+		return "unix:" + c.SocketPath
+	}
+	return c.network() + ":" + c.addr()
+}
+
+// listen opens the configured listener. A stale unix socket left by an
+// earlier run is removed first; any other file at the path is an error.
+func (c ServerConfig) listen() (net.Listener, error) {
+	if c.network() != networkUnix {
+		return net.Listen(c.network(), c.addr())
+	}
+
+	if info, err := os.Lstat(c.SocketPath); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("socket path %s exists and is not a socket", c.SocketPath)
+		}
+		if err := os.Remove(c.SocketPath); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	ln, err := net.Listen(networkUnix, c.SocketPath)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := c.SocketMode
+	if mode == 0 {
+		mode = defaultSocketMode
+	}
+	if err := os.Chmod(c.SocketPath, mode); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+	return ln, nil
+}
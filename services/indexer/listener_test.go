@@ -0,0 +1,101 @@
+package indexer
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerConfig_ValidateListener(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     ServerConfig
+		wantErr bool
+	}{
+		{"default tcp", ServerConfig{}, false},
+		{"ipv6 bind", ServerConfig{Network: "tcp6", BindAddress: "::1"}, false},
+		{"bracketed ipv6 bind", ServerConfig{BindAddress: "[::1]"}, false},
+		{"ipv6 on tcp4", ServerConfig{Network: "tcp4", BindAddress: "::1"}, true},
+		{"ipv4 on tcp6", ServerConfig{Network: "tcp6", BindAddress: "127.0.0.1"}, true},
+		{"unix", ServerConfig{Network: "unix", SocketPath: "/run/indexer.sock"}, false},
+		{"unix without path", ServerConfig{Network: "unix"}, true},
+		{"unknown network", ServerConfig{Network: "udp"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.validateListener()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestServerConfig_ListenAddress(t *testing.T) {
+	assert.Equal(t, "tcp:[::1]:8081", ServerConfig{BindAddress: "[::1]", Port: "8081"}.ListenAddress())
+	assert.Equal(t, "tcp6:[::]:8081", ServerConfig{Network: "tcp6", BindAddress: "::", Port: ":8081"}.ListenAddress())
+	assert.Equal(t, "unix:/run/indexer.sock", ServerConfig{Network: "unix", SocketPath: "/run/indexer.sock"}.ListenAddress())
+}
+
+func TestServer_UnixSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "indexer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socketPath := filepath.Join(dir, "indexer.sock")
+
+	// A stale socket from an earlier run is replaced
+	stale, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	cfg := DefaultServerConfig()
+	cfg.Network = "unix"
+	cfg.SocketPath = socketPath
+	svc := NewService("http://localhost:4000", ":8081")
+	server := NewServer(svc, "8081", WithConfig(cfg))
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.Start() }()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+		},
+	}}
+
+	var resp *http.Response
+	require.Eventually(t, func() bool {
+		resp, err = client.Get("http://indexer/health")
+		return err == nil
+	}, 2*time.Second, 10*time.Millisecond)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	info, err := os.Stat(socketPath)
+	require.NoError(t, err)
+	assert.Equal(t, defaultSocketMode, info.Mode().Perm())
+
+	require.NoError(t, server.Stop(context.Background()))
+	assert.ErrorIs(t, <-errCh, http.ErrServerClosed)
+}
+
+func TestServer_UnixSocketPathInUse(t *testing.T) {
+	f, err := os.CreateTemp("", "indexer")
+	require.NoError(t, err)
+	f.Close()
+	defer os.Remove(f.Name())
+
+	_, err = ServerConfig{Network: "unix", SocketPath: f.Name()}.listen()
+	assert.Error(t, err)
+}
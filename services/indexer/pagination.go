@@ -0,0 +1,70 @@
+package indexer
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Pagination describes a page of a list response and links to its neighbours
+type Pagination struct {
+	Offset  int    `json:"offset"`
+	Limit   int    `json:"limit"`
+	Total   int    `json:"total"`
+	HasMore bool   `json:"has_more"`
+	Next    string `json:"next,omitempty"`
+	Prev    string `json:"prev,omitempty"`
+}
+
+// newPagination builds pagination metadata for a page of a list with total
+// entries, linking to the neighbouring pages of the request's URL
+func newPagination(r *http.Request, offset, limit, total int) Pagination {
+	p := Pagination{
+		Offset:  offset,
+		Limit:   limit,
+		Total:   total,
+		HasMore: offset+limit < total,
+	}
+
+	if p.HasMore {
+		p.Next = pageURL(r, offset+limit, limit)
+	}
+	if offset > 0 {
+		prev := offset - limit
+		if prev < 0 {
+			prev = 0
+		}
+		p.Prev = pageURL(r, prev, limit)
+	}
+	return p
+}
+
+// pageURL returns the request's path and query with offset and limit
+// replaced. Links leave out the host so cached pages suit any hostname.
+func pageURL(r *http.Request, offset, limit int) string {
+	query := r.URL.Query()
+	query.Set("offset", strconv.Itoa(offset))
+	query.Set("limit", strconv.Itoa(limit))
+
+	u := url.URL{
+		Path:     r.URL.Path,
+		RawQuery: query.Encode(),
+	}
+	return u.String()
+}
+
+// writePaginationLinks sets an RFC 8288 Link header for the page's neighbours
+func writePaginationLinks(w http.ResponseWriter, p Pagination) {
+	var links []string
+	if p.Next != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, p.Next))
+	}
+	if p.Prev != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, p.Prev))
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
@@ -0,0 +1,97 @@
+package indexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPagination(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/v1/transactions?type=swap&offset=10&limit=10", nil)
+
+	p := newPagination(req, 10, 10, 25)
+	assert.True(t, p.HasMore)
+	assert.Equal(t, 25, p.Total)
+	assert.Equal(t, "/api/v1/transactions?limit=10&offset=20&type=swap", p.Next)
+	assert.Equal(t, "/api/v1/transactions?limit=10&offset=0&type=swap", p.Prev)
+
+	// Last page
+	p = newPagination(req, 20, 10, 25)
+	assert.False(t, p.HasMore)
+	assert.Empty(t, p.Next)
+	assert.Equal(t, "/api/v1/transactions?limit=10&offset=10&type=swap", p.Prev)
+
+	// First page, and prev never goes below zero
+	p = newPagination(req, 0, 10, 5)
+	assert.False(t, p.HasMore)
+	assert.Empty(t, p.Prev)
+	p = newPagination(req, 5, 10, 5)
+	assert.Equal(t, "/api/v1/transactions?limit=10&offset=0&type=swap", p.Prev)
+
+	// Links are relative, so they hold for whichever host served them
+	req.Header.Set("X-Forwarded-Proto", "https")
+	p = newPagination(req, 0, 10, 25)
+	assert.Equal(t, "/api/v1/transactions?limit=10&offset=10&type=swap", p.Next)
+}
+
+func TestServer_TransactionsPagination(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+	server := NewServer(svc, "8081")
+
+	dexReader := svc.readers[0].(*DexReadModel)
+	for i := 0; i < 5; i++ {
+		dexReader.transactions = append(dexReader.transactions, TransactionInfo{ID: fmt.Sprintf("tx%d", i), Type: "swap"})
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/transactions?limit=2&offset=2", nil)
+	w := httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Transactions []TransactionInfo `json:"transactions"`
+		Count        int               `json:"count"`
+		Pagination   Pagination        `json:"pagination"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []string{"tx2", "tx1"}, transactionIDs(resp.Transactions))
+	assert.Equal(t, 2, resp.Count)
+	assert.Equal(t, 5, resp.Pagination.Total)
+	assert.True(t, resp.Pagination.HasMore)
+	assert.Equal(t, "/api/v1/transactions?limit=2&offset=4", resp.Pagination.Next)
+	assert.Equal(t, "/api/v1/transactions?limit=2&offset=0", resp.Pagination.Prev)
+	assert.Equal(t,
+		`</api/v1/transactions?limit=2&offset=4>; rel="next", </api/v1/transactions?limit=2&offset=0>; rel="prev"`,
+		w.Header().Get("Link"))
+}
+
+func TestServer_RichListPagination(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+	server := NewServer(svc, "8081")
+
+	dexReader := svc.readers[0].(*DexReadModel)
+	dexReader.positions["pool-1"] = []LiquidityPosition{
+		{User: "alice", PoolID: "pool-1", Amount: 300},
+		{User: "bob", PoolID: "pool-1", Amount: 200},
+		{User: "carol", PoolID: "pool-1", Amount: 100},
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/pools/pool-1/richlist?limit=2", nil)
+	w := httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Pagination Pagination `json:"pagination"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 3, resp.Pagination.Total)
+	assert.True(t, resp.Pagination.HasMore)
+	assert.Equal(t, "/api/v1/pools/pool-1/richlist?limit=2&offset=2", resp.Pagination.Next)
+	assert.Empty(t, resp.Pagination.Prev)
+}
@@ -0,0 +1,127 @@
+package indexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Pool stream settings
+const (
+	poolStreamPath      = "/api/v1/stream/pools"
+	poolStreamBuffer    = 256              // Updates queued per subscriber before it is dropped
+	poolStreamHeartbeat = 15 * time.Second // Interval between keep-alive comments
+)
+
+// SubscribePools returns the current pools and a channel receiving each pool
+// after it changes. The snapshot and subscription are taken atomically, so no
+// update is missed in between. Subscribers that fall behind have their channel
+// closed and should resubscribe; cancel releases the subscription.
+func (dm *DexReadModel) SubscribePools() ([]PoolInfo, <-chan PoolInfo, func()) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	pools := make([]PoolInfo, 0, len(dm.pools))
+	for _, pool := range dm.pools {
+		pools = append(pools, pool)
+	}
+
+	ch := make(chan PoolInfo, poolStreamBuffer)
+	dm.subscribers[ch] = struct{}{}
+
+	cancel := func() {
+		dm.mu.Lock()
+		defer dm.mu.Unlock()
+		if _, ok := dm.subscribers[ch]; ok {
+			delete(dm.subscribers, ch)
+			close(ch)
+		}
+	}
+	return pools, ch, cancel
+}
+
+// publishPoolLocked sends a pool's current state to subscribers; caller must
+// hold dm.mu
+func (dm *DexReadModel) publishPoolLocked(poolID string) {
+	pool, exists := dm.pools[poolID]
+	if !exists {
+		return
+	}
+
+	for ch := range dm.subscribers {
+		select {
+		case ch <- pool:
+		default:
+			delete(dm.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// writeSSE writes one server-sent event
+func writeSSE(w http.ResponseWriter, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	return err
+}
+
+// handlePoolStream streams pool changes as server-sent events. A "snapshot"
+// event carries every pool, followed by a "pool" event per change.
+func (s *Server) handlePoolStream(w http.ResponseWriter, r *http.Request) {
+	dexReader, ok := s.dexReader()
+	if !ok {
+		writeProblem(w, r, http.StatusServiceUnavailable, ErrCodeNoDataAvailable, "No DEX read model registered")
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// Streams outlive the server's write timeout
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && err != http.ErrNotSupported {
+		writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+
+	pools, updates, cancel := dexReader.SubscribePools()
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	if err := writeSSE(w, "snapshot", pools); err != nil {
+		return
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(poolStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case pool, ok := <-updates:
+			if !ok {
+				// Dropped for falling behind; the client resubscribes
+				return
+			}
+			if err := writeSSE(w, "pool", pool); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package indexer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDexReadModel_SubscribePools(t *testing.T) {
+	rm := NewDexReadModel()
+	rm.pools["pool-1"] = PoolInfo{ID: "pool-1", Asset0: "HBD", Asset1: "HIVE"}
+
+	pools, updates, cancel := rm.SubscribePools()
+	defer cancel()
+	require.Len(t, pools, 1)
+
+	require.NoError(t, rm.HandleEvent(VSCEvent{
+		Contract: "dex-router",
+		Method:   "liquidity_added",
+		Args:     json.RawMessage(`{"pool_id":"pool-1","amount0":1000,"amount1":4000}`),
+	}))
+
+	select {
+	case pool := <-updates:
+		assert.Equal(t, "pool-1", pool.ID)
+		assert.Equal(t, uint64(1000), pool.Reserve0)
+		assert.Equal(t, uint64(4000), pool.Reserve1)
+	default:
+		t.Fatal("expected a pool update")
+	}
+
+	cancel()
+	_, open := <-updates
+	assert.False(t, open)
+	assert.Empty(t, rm.subscribers)
+}
+
+func TestDexReadModel_SubscribePoolsDropsSlowSubscriber(t *testing.T) {
+	rm := NewDexReadModel()
+	rm.pools["pool-1"] = PoolInfo{ID: "pool-1", Asset0: "HBD", Asset1: "HIVE"}
+
+	_, updates, cancel := rm.SubscribePools()
+	defer cancel()
+
+	rm.mu.Lock()
+	for i := 0; i <= poolStreamBuffer; i++ {
+		rm.publishPoolLocked("pool-1")
+	}
+	rm.mu.Unlock()
+
+	received := 0
+	for range updates {
+		received++
+	}
+	assert.Equal(t, poolStreamBuffer, received)
+	assert.Empty(t, rm.subscribers)
+}
+
+func TestServer_PoolStream(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+	server := NewServer(svc, "8081")
+
+	dexReader := svc.readers[0].(*DexReadModel)
+	dexReader.pools["pool-1"] = PoolInfo{ID: "pool-1", Asset0: "HBD", Asset1: "HIVE", Reserve0: 1000, Reserve1: 4000}
+
+	ts := httptest.NewServer(server.http.Handler)
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", ts.URL+"/api/v1/stream/pools", nil)
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	readEvent := func() (string, string) {
+		var event, data string
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			line = strings.TrimRight(line, "\n")
+			switch {
+			case line == "":
+				return event, data
+			case strings.HasPrefix(line, "event: "):
+				event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}
+
+	event, data := readEvent()
+	assert.Equal(t, "snapshot", event)
+	var pools []PoolInfo
+	require.NoError(t, json.Unmarshal([]byte(data), &pools))
+	require.Len(t, pools, 1)
+	assert.Equal(t, uint64(1000), pools[0].Reserve0)
+
+	require.NoError(t, dexReader.HandleEvent(VSCEvent{
+		Contract: "dex-router",
+		Method:   "swap_executed",
+		Args:     json.RawMessage(`{"pool_id":"pool-1","amount_in":100,"amount_out":300,"asset_in":"HBD","asset_out":"HIVE"}`),
+	}))
+
+	event, data = readEvent()
+	assert.Equal(t, "pool", event)
+	var pool PoolInfo
+	require.NoError(t, json.Unmarshal([]byte(data), &pool))
+	assert.Equal(t, uint64(1100), pool.Reserve0)
+	assert.Equal(t, uint64(3700), pool.Reserve1)
+}
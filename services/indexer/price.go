@@ -0,0 +1,134 @@
+package indexer
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// lastTrade records the execution price of a pool's most recent swap
+type lastTrade struct {
+	price0      float64 // asset1 received/paid per unit of asset0
+	blockHeight uint64
+}
+
+// PoolPrice describes a pool's current prices in both directions
+type PoolPrice struct {
+	PoolID string `json:"pool_id"`
+	Asset0 string `json:"asset0"`
+	Asset1 string `json:"asset1"`
+
+	// Spot prices from reserves, before fees
+	Price0 float64 `json:"price0"` // asset1 per asset0
+	Price1 float64 `json:"price1"` // asset0 per asset1
+
+	// Fee-adjusted quotes for selling/buying asset0, and their midpoint
+	Bid             float64 `json:"bid"`               // asset1 received per asset0 sold
+	Ask             float64 `json:"ask"`               // asset1 paid per asset0 bought
+	MidPrice        float64 `json:"mid_price"`         // asset1 per asset0
+	MidPriceInverse float64 `json:"mid_price_inverse"` // asset0 per asset1
+
+	// Execution price of the most recent swap, if any
+	LastTradePrice        *float64 `json:"last_trade_price"`         // asset1 per asset0
+	LastTradePriceInverse *float64 `json:"last_trade_price_inverse"` // asset0 per asset1
+	LastTradeBlock        uint64   `json:"last_trade_block,omitempty"`
+}
+
+// recordLastTrade stores the execution price of a swap; caller must hold dm.mu
+func (dm *DexReadModel) recordLastTrade(pool PoolInfo, blockHeight uint64, delta0, delta1 int64, amountIn, amountOut uint64, assetIn string) {
+	var amount0, amount1 float64
+
+	if delta0 != 0 || delta1 != 0 {
+		// Legacy format: reserve deltas, one positive and one negative
+		amount0 = absFloat(float64(delta0))
+		amount1 = absFloat(float64(delta1))
+	} else if assetIn == pool.Asset0 {
+		amount0 = float64(amountIn)
+		amount1 = float64(amountOut)
+	} else {
+		amount0 = float64(amountOut)
+		amount1 = float64(amountIn)
+	}
+
+	if amount0 == 0 || amount1 == 0 {
+		return
+	}
+
+	dm.lastTrades[pool.ID] = lastTrade{
+		price0:      amount1 / amount0,
+		blockHeight: blockHeight,
+	}
+}
+
+// GetPoolPrice returns spot, mid and last trade prices for a pool. The
+// second return value is false if the pool does not exist.
+func (dm *DexReadModel) GetPoolPrice(poolID string) (PoolPrice, bool) {
+	dm.mu.RLock()
+	defer dm.mu.RUnlock()
+
+	pool, exists := dm.pools[poolID]
+	if !exists {
+		return PoolPrice{}, false
+	}
+
+	price := PoolPrice{
+		PoolID: pool.ID,
+		Asset0: pool.Asset0,
+		Asset1: pool.Asset1,
+	}
+
+	if pool.Reserve0 > 0 && pool.Reserve1 > 0 {
+		price.Price0 = float64(pool.Reserve1) / float64(pool.Reserve0)
+		price.Price1 = float64(pool.Reserve0) / float64(pool.Reserve1)
+
+		// Fee is a percentage (0.08 = 0.08%)
+		feeFactor := 1 - pool.Fee/100
+		price.Bid = price.Price0 * feeFactor
+		if feeFactor > 0 {
+			price.Ask = price.Price0 / feeFactor
+		}
+		price.MidPrice = (price.Bid + price.Ask) / 2
+		if price.MidPrice > 0 {
+			price.MidPriceInverse = 1 / price.MidPrice
+		}
+	}
+
+	if trade, ok := dm.lastTrades[poolID]; ok {
+		last := trade.price0
+		inverse := 1 / trade.price0
+		price.LastTradePrice = &last
+		price.LastTradePriceInverse = &inverse
+		price.LastTradeBlock = trade.blockHeight
+	}
+
+	return price, true
+}
+
+// handleGetPoolPrice returns spot prices in both directions for a pool
+func (s *Server) handleGetPoolPrice(w http.ResponseWriter, r *http.Request) {
+	poolID := mux.Vars(r)["id"]
+
+	dexReader, ok := s.dexReader()
+	if !ok {
+		writeProblem(w, r, http.StatusNotFound, ErrCodePoolNotFound, "Pool not found")
+		return
+	}
+
+	price, exists := dexReader.GetPoolPrice(poolID)
+	if !exists {
+		writeProblem(w, r, http.StatusNotFound, ErrCodePoolNotFound, "Pool not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(price)
+}
+
+// absFloat returns the absolute value of f
+func absFloat(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
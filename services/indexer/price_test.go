@@ -0,0 +1,93 @@
+package indexer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDexReadModel_GetPoolPrice(t *testing.T) {
+	rm := NewDexReadModel()
+
+	require.NoError(t, rm.HandleEvent(VSCEvent{
+		Contract: "dex-router",
+		Method:   "pool_created",
+		Args:     json.RawMessage(`{"pool_id":"pool-1","asset0":"HBD","asset1":"HIVE","fee":0.3}`),
+	}))
+	require.NoError(t, rm.HandleEvent(VSCEvent{
+		Contract: "dex-router",
+		Method:   "liquidity_added",
+		Args:     json.RawMessage(`{"pool_id":"pool-1","amount0":1000000,"amount1":4000000}`),
+	}))
+
+	price, exists := rm.GetPoolPrice("pool-1")
+	require.True(t, exists)
+	assert.InDelta(t, 4.0, price.Price0, 1e-9)
+	assert.InDelta(t, 0.25, price.Price1, 1e-9)
+	assert.InDelta(t, 4.0*0.997, price.Bid, 1e-9)
+	assert.InDelta(t, 4.0/0.997, price.Ask, 1e-9)
+	assert.InDelta(t, (price.Bid+price.Ask)/2, price.MidPrice, 1e-9)
+	assert.InDelta(t, 1/price.MidPrice, price.MidPriceInverse, 1e-9)
+	assert.Nil(t, price.LastTradePrice)
+
+	// Sell 1000 HIVE for 240 HBD
+	require.NoError(t, rm.HandleEvent(VSCEvent{
+		Contract:    "dex-router",
+		Method:      "swap_executed",
+		BlockHeight: 42,
+		Args:        json.RawMessage(`{"pool_id":"pool-1","amount_in":1000,"amount_out":240,"asset_in":"HIVE","asset_out":"HBD"}`),
+	}))
+
+	price, _ = rm.GetPoolPrice("pool-1")
+	require.NotNil(t, price.LastTradePrice)
+	assert.InDelta(t, 1000.0/240.0, *price.LastTradePrice, 1e-9)
+	assert.InDelta(t, 0.24, *price.LastTradePriceInverse, 1e-9)
+	assert.Equal(t, uint64(42), price.LastTradeBlock)
+
+	_, exists = rm.GetPoolPrice("missing")
+	assert.False(t, exists)
+}
+
+func TestDexReadModel_GetPoolPrice_LegacyDeltas(t *testing.T) {
+	rm := NewDexReadModel()
+	rm.pools["pool-1"] = PoolInfo{ID: "pool-1", Asset0: "HBD", Asset1: "HIVE", Reserve0: 1000, Reserve1: 1000}
+
+	require.NoError(t, rm.HandleEvent(VSCEvent{
+		Contract: "dex-router",
+		Method:   "swap_executed",
+		Args:     json.RawMessage(`{"pool_id":"pool-1","amount0":100,"amount1":-90}`),
+	}))
+
+	price, _ := rm.GetPoolPrice("pool-1")
+	require.NotNil(t, price.LastTradePrice)
+	assert.InDelta(t, 0.9, *price.LastTradePrice, 1e-9)
+}
+
+func TestServer_handleGetPoolPrice(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+	dexReader := svc.readers[0].(*DexReadModel)
+	dexReader.pools["pool-1"] = PoolInfo{ID: "pool-1", Asset0: "HBD", Asset1: "HIVE", Reserve0: 500, Reserve1: 1000}
+	server := NewServer(svc, "8081")
+
+	req := httptest.NewRequest("GET", "/api/v1/pools/pool-1/price", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "pool-1"})
+	w := httptest.NewRecorder()
+	server.handleGetPoolPrice(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var price PoolPrice
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&price))
+	assert.Equal(t, 2.0, price.Price0)
+	assert.Equal(t, 0.5, price.Price1)
+
+	req = httptest.NewRequest("GET", "/api/v1/pools/missing/price", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "missing"})
+	w = httptest.NewRecorder()
+	server.handleGetPoolPrice(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
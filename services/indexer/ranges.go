@@ -0,0 +1,162 @@
+package indexer
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+)
+
+// LiquidityRange is the liquidity a concentrated pool holds between two
+// adjacent initialized ticks
+type LiquidityRange struct {
+	TickLower  int64   `json:"tick_lower"`
+	TickUpper  int64   `json:"tick_upper"`
+	PriceLower float64 `json:"price_lower"` // asset1 per asset0, 1.0001^tick_lower
+	PriceUpper float64 `json:"price_upper"`
+	Liquidity  uint64  `json:"liquidity"`
+}
+
+// PoolLiquidity is a concentrated pool's liquidity by price range
+type PoolLiquidity struct {
+	PoolID    string           `json:"pool_id"`
+	Tick      int64            `json:"tick"`
+	SqrtPrice string           `json:"sqrt_price"` // Q64.64
+	Liquidity uint64           `json:"liquidity"`  // In range at the current tick
+	Positions int              `json:"positions"`  // Open positions
+	Ranges    []LiquidityRange `json:"ranges"`     // Ranges holding liquidity, by ascending tick
+}
+
+// concentratedPool follows a concentrated pool's positions from their events
+type concentratedPool struct {
+	tick      int64
+	sqrtPrice string
+	liquidity uint64
+	net       map[int64]int64  // Liquidity added crossing each tick upwards
+	positions map[string]int64 // position_id -> liquidity
+}
+
+// positionEvent is the payload of position_minted and position_burned
+type positionEvent struct {
+	PositionID    string `json:"position_id"`
+	PoolID        string `json:"pool_id"`
+	Owner         string `json:"owner"`
+	TickLower     int64  `json:"tick_lower"`
+	TickUpper     int64  `json:"tick_upper"`
+	Liquidity     int64  `json:"liquidity"`
+	Amount0       uint64 `json:"amount0"`
+	Amount1       uint64 `json:"amount1"`
+	Tick          int64  `json:"tick"`
+	SqrtPrice     string `json:"sqrt_price"`
+	PoolLiquidity uint64 `json:"pool_liquidity"`
+}
+
+// concentratedPoolLocked returns the tracked state of a concentrated pool,
+// creating it on first use. The listed pool is marked concentrated, since its
+// reserves do not price it. Caller must hold dm.mu.
+func (dm *DexReadModel) concentratedPoolLocked(poolID string) *concentratedPool {
+	pool := dm.concentrated[poolID]
+	if pool == nil {
+		pool = &concentratedPool{net: make(map[int64]int64), positions: make(map[string]int64)}
+		dm.concentrated[poolID] = pool
+	}
+	if info, exists := dm.pools[poolID]; exists && info.Type != PoolTypeConcentrated {
+		info.Type = PoolTypeConcentrated
+		dm.pools[poolID] = info
+	}
+	return pool
+}
+
+// applyPositionLocked adds a minted position's liquidity to its range, or
+// with a negative sign removes a burned one's; caller must hold dm.mu
+func (dm *DexReadModel) applyPositionLocked(args positionEvent, sign int64) {
+	pool := dm.concentratedPoolLocked(args.PoolID)
+	delta := sign * args.Liquidity
+
+	for tick, change := range map[int64]int64{args.TickLower: delta, args.TickUpper: -delta} {
+		pool.net[tick] += change
+		if pool.net[tick] == 0 {
+			delete(pool.net, tick)
+		}
+	}
+	pool.positions[args.PositionID] += delta
+	if pool.positions[args.PositionID] <= 0 {
+		delete(pool.positions, args.PositionID)
+	}
+
+	pool.tick = args.Tick
+	pool.sqrtPrice = args.SqrtPrice
+	pool.liquidity = args.PoolLiquidity
+}
+
+// GetPoolLiquidity returns a concentrated pool's liquidity by price range.
+// The second return value is false if no positions were seen for the pool.
+func (dm *DexReadModel) GetPoolLiquidity(poolID string) (PoolLiquidity, bool) {
+	dm.mu.RLock()
+	defer dm.mu.RUnlock()
+
+	pool, exists := dm.concentrated[poolID]
+	if !exists {
+		return PoolLiquidity{}, false
+	}
+
+	result := PoolLiquidity{
+		PoolID:    poolID,
+		Tick:      pool.tick,
+		SqrtPrice: pool.sqrtPrice,
+		Liquidity: pool.liquidity,
+		Positions: len(pool.positions),
+		Ranges:    []LiquidityRange{},
+	}
+
+	ticks := make([]int64, 0, len(pool.net))
+	for tick := range pool.net {
+		ticks = append(ticks, tick)
+	}
+	sort.Slice(ticks, func(i, j int) bool { return ticks[i] < ticks[j] })
+
+	// Liquidity between two ticks is the net liquidity of every tick below
+	var liquidity int64
+	for i := 0; i+1 < len(ticks); i++ {
+		liquidity += pool.net[ticks[i]]
+		if liquidity <= 0 {
+			continue
+		}
+		result.Ranges = append(result.Ranges, LiquidityRange{
+			TickLower:  ticks[i],
+			TickUpper:  ticks[i+1],
+			PriceLower: tickPrice(ticks[i]),
+			PriceUpper: tickPrice(ticks[i+1]),
+			Liquidity:  uint64(liquidity),
+		})
+	}
+
+	return result, true
+}
+
+// tickPrice is the price, asset1 per asset0, at a tick
+func tickPrice(tick int64) float64 {
+	return math.Pow(1.0001, float64(tick))
+}
+
+// handleGetPoolLiquidity returns a concentrated pool's liquidity by range
+func (s *Server) handleGetPoolLiquidity(w http.ResponseWriter, r *http.Request) {
+	poolID := mux.Vars(r)["id"]
+
+	dexReader, ok := s.dexReader()
+	if !ok {
+		writeProblem(w, r, http.StatusNotFound, ErrCodePoolNotFound, "Pool not found")
+		return
+	}
+
+	liquidity, exists := dexReader.GetPoolLiquidity(poolID)
+	if !exists {
+		writeProblem(w, r, http.StatusNotFound, ErrCodePoolNotFound, "Concentrated pool not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(liquidity)
+}
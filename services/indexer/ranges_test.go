@@ -0,0 +1,129 @@
+package indexer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func positionEventJSON(method, positionID string, lower, upper, liquidity int64) VSCEvent {
+	args, _ := json.Marshal(positionEvent{
+		PositionID:    positionID,
+		PoolID:        "3",
+		Owner:         "hive:lp",
+		TickLower:     lower,
+		TickUpper:     upper,
+		Liquidity:     liquidity,
+		Tick:          13860,
+		SqrtPrice:     "36886780478868786628",
+		PoolLiquidity: 22526044,
+	})
+	return VSCEvent{Type: "contract_output", Contract: "dex-router", Method: method, Args: args}
+}
+
+func TestDexReadModel_GetPoolLiquidity(t *testing.T) {
+	rm := NewDexReadModel()
+
+	_, exists := rm.GetPoolLiquidity("3")
+	assert.False(t, exists)
+
+	require.NoError(t, rm.HandleEvent(positionEventJSON("position_minted", "1", 12000, 15000, 22526044)))
+	require.NoError(t, rm.HandleEvent(positionEventJSON("position_minted", "2", 13200, 13800, 16972665)))
+
+	liquidity, exists := rm.GetPoolLiquidity("3")
+	require.True(t, exists)
+	assert.Equal(t, int64(13860), liquidity.Tick)
+	assert.Equal(t, uint64(22526044), liquidity.Liquidity)
+	assert.Equal(t, 2, liquidity.Positions)
+	assert.Equal(t, []LiquidityRange{
+		{TickLower: 12000, TickUpper: 13200, Liquidity: 22526044},
+		{TickLower: 13200, TickUpper: 13800, Liquidity: 39498709},
+		{TickLower: 13800, TickUpper: 15000, Liquidity: 22526044},
+	}, withoutPrices(liquidity.Ranges))
+	assert.InDelta(t, 3.3199, liquidity.Ranges[0].PriceLower, 1e-4)
+
+	// Swaps through the pool move its tick
+	require.NoError(t, rm.HandleEvent(VSCEvent{
+		Contract: "dex-router",
+		Method:   "swap_executed",
+		Args: json.RawMessage(`{"pool_id": "3", "asset_in": "HBD", "asset_out": "HIVE", "amount_in": 200000,
+			"amount_out": 786608, "tick": 13623, "sqrt_price": "36453338624368130413", "liquidity": 39498709}`),
+	}))
+	liquidity, _ = rm.GetPoolLiquidity("3")
+	assert.Equal(t, int64(13623), liquidity.Tick)
+	assert.Equal(t, uint64(39498709), liquidity.Liquidity)
+
+	// Burning part of a position shrinks its range; burning the rest closes it
+	require.NoError(t, rm.HandleEvent(positionEventJSON("position_burned", "2", 13200, 13800, 6972665)))
+	liquidity, _ = rm.GetPoolLiquidity("3")
+	assert.Equal(t, uint64(32526044), liquidity.Ranges[1].Liquidity)
+	require.NoError(t, rm.HandleEvent(positionEventJSON("position_burned", "2", 13200, 13800, 10000000)))
+	liquidity, _ = rm.GetPoolLiquidity("3")
+	assert.Equal(t, 1, liquidity.Positions)
+	assert.Equal(t, []LiquidityRange{
+		{TickLower: 12000, TickUpper: 15000, Liquidity: 22526044},
+	}, withoutPrices(liquidity.Ranges))
+
+	require.Len(t, rm.transactions, 5)
+	assert.Equal(t, "position_mint", rm.transactions[0].Type)
+	assert.Equal(t, "position_burn", rm.transactions[4].Type)
+	assert.Equal(t, "hive:lp", rm.transactions[4].User)
+}
+
+func TestDexReadModel_PoolType(t *testing.T) {
+	rm := NewDexReadModel()
+	created := func(poolID, extra string) VSCEvent {
+		return VSCEvent{Contract: "dex-router", Method: "pool_created",
+			Args: json.RawMessage(`{"pool_id": "` + poolID + `", "asset0": "HBD", "asset1": "HIVE", "fee": 0.3` + extra + `}`)}
+	}
+
+	require.NoError(t, rm.HandleEvent(created("1", "")))
+	require.NoError(t, rm.HandleEvent(created("2", `, "tick_spacing": 60`)))
+	require.NoError(t, rm.HandleEvent(created("3", "")))
+
+	pool, _ := rm.GetPool("1")
+	assert.Equal(t, PoolTypeConstantProduct, pool.Type)
+	pool, _ = rm.GetPool("2")
+	assert.Equal(t, PoolTypeConcentrated, pool.Type)
+
+	// A pool whose creation did not say is marked by its first position
+	require.NoError(t, rm.HandleEvent(positionEventJSON("position_minted", "1", 12000, 15000, 22526044)))
+	pool, _ = rm.GetPool("3")
+	assert.Equal(t, PoolTypeConcentrated, pool.Type)
+}
+
+func withoutPrices(ranges []LiquidityRange) []LiquidityRange {
+	stripped := make([]LiquidityRange, len(ranges))
+	for i, r := range ranges {
+		r.PriceLower, r.PriceUpper = 0, 0
+		stripped[i] = r
+	}
+	return stripped
+}
+
+func TestServer_HandleGetPoolLiquidity(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+	server := NewServer(svc, "8081")
+
+	dexReader := svc.readers[0].(*DexReadModel)
+	require.NoError(t, dexReader.HandleEvent(positionEventJSON("position_minted", "1", -600, 600, 1000000)))
+
+	req := httptest.NewRequest("GET", "/api/v1/pools/3/liquidity", nil)
+	w := httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var liquidity PoolLiquidity
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &liquidity))
+	require.Len(t, liquidity.Ranges, 1)
+	assert.Equal(t, uint64(1000000), liquidity.Ranges[0].Liquidity)
+
+	req = httptest.NewRequest("GET", "/api/v1/pools/1/liquidity", nil)
+	w = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,103 @@
+package indexer
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxRateLimitClients caps tracked clients before idle buckets are swept
+const maxRateLimitClients = 10000
+
+// tokenBucket tracks one client's remaining request allowance
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// rateLimiter applies a token bucket per client IP
+type rateLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // Tokens added per second
+	burst   float64
+	clients map[string]*tokenBucket
+	now     func() time.Time
+}
+
+// newRateLimiter creates a limiter allowing rate requests per second with
+// bursts of up to burst requests
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		clients: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// allow takes a token for client. When none is left it returns false and
+// how long until the next token is available.
+func (rl *rateLimiter) allow(client string) (bool, time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.now()
+	bucket, ok := rl.clients[client]
+	if !ok {
+		if len(rl.clients) >= maxRateLimitClients {
+			rl.sweep(now)
+		}
+		bucket = &tokenBucket{tokens: rl.burst, lastSeen: now}
+		rl.clients[client] = bucket
+	}
+
+	elapsed := now.Sub(bucket.lastSeen).Seconds()
+	bucket.tokens = math.Min(rl.burst, bucket.tokens+elapsed*rl.rate)
+	bucket.lastSeen = now
+
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / rl.rate * float64(time.Second))
+		return false, wait
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// sweep drops buckets that have refilled completely; caller must hold rl.mu
+func (rl *rateLimiter) sweep(now time.Time) {
+	for client, bucket := range rl.clients {
+		if bucket.tokens+now.Sub(bucket.lastSeen).Seconds()*rl.rate >= rl.burst {
+			delete(rl.clients, client)
+		}
+	}
+}
+
+// rateLimit rejects clients exceeding the configured request rate
+func (s *Server) rateLimit(next http.Handler) http.Handler {
+	if s.config.RateLimit <= 0 {
+		return next
+	}
+	limiter := newRateLimiter(s.config.RateLimit, s.config.RateBurst)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok, wait := limiter.allow(clientIP(r))
+		if !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			writeProblem(w, r, http.StatusTooManyRequests, ErrCodeRateLimited, "Rate limit exceeded")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientIP returns the remote IP of a request, without the port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
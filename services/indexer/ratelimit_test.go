@@ -0,0 +1,54 @@
+package indexer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter_Allow(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	rl := newRateLimiter(2, 3)
+	rl.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		ok, _ := rl.allow("1.2.3.4")
+		assert.True(t, ok, "request %d", i)
+	}
+	ok, wait := rl.allow("1.2.3.4")
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	// Other clients have their own bucket
+	ok, _ = rl.allow("5.6.7.8")
+	assert.True(t, ok)
+
+	now = now.Add(500 * time.Millisecond)
+	ok, _ = rl.allow("1.2.3.4")
+	assert.True(t, ok)
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+
+	cfg := DefaultServerConfig()
+	cfg.RateLimit = 1
+	cfg.RateBurst = 2
+	server := NewServer(svc, "8081", WithConfig(cfg))
+
+	codes := make([]int, 3)
+	var last *httptest.ResponseRecorder
+	for i := range codes {
+		req := httptest.NewRequest("GET", "/health", nil)
+		last = httptest.NewRecorder()
+		server.http.Handler.ServeHTTP(last, req)
+		codes[i] = last.Code
+	}
+
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, codes)
+	assert.Equal(t, "1", last.Header().Get("Retry-After"))
+	assert.Contains(t, last.Body.String(), string(ErrCodeRateLimited))
+}
@@ -3,12 +3,13 @@ package indexer
 import (
 	"encoding/json"
 	"sync"
+	"time"
 )
 
 // TransactionInfo represents a DEX transaction
 type TransactionInfo struct {
 	ID          string                 `json:"id"`
-	Type        string                 `json:"type"` // "swap", "deposit", "withdrawal"
+	Type        string                 `json:"type"` // "swap", "deposit", "withdrawal", "limit_order_fill", "position_mint", "position_burn", "position_collect"
 	PoolID      string                 `json:"pool_id"`
 	User        string                 `json:"user"`
 	BlockHeight uint64                 `json:"block_height"`
@@ -30,6 +31,12 @@ type DexReadModel struct {
 	pools        map[string]PoolInfo
 	transactions []TransactionInfo
 	positions    map[string][]LiquidityPosition // pool_id -> []positions
+	lastTrades   map[string]lastTrade           // pool_id -> most recent swap
+	activity     []activityEntry                // per-user activity for leaderboards
+	history      map[string]*poolHistory        // pool_id -> per-block snapshots
+	concentrated map[string]*concentratedPool   // pool_id -> range-bound positions
+	subscribers  map[chan PoolInfo]struct{}     // pool stream subscribers
+	now          func() time.Time
 }
 
 // NewDexReadModel creates a new DEX read model
@@ -38,6 +45,12 @@ func NewDexReadModel() *DexReadModel {
 		pools:        make(map[string]PoolInfo),
 		transactions: make([]TransactionInfo, 0),
 		positions:    make(map[string][]LiquidityPosition),
+		lastTrades:   make(map[string]lastTrade),
+		activity:     make([]activityEntry, 0),
+		history:      make(map[string]*poolHistory),
+		concentrated: make(map[string]*concentratedPool),
+		subscribers:  make(map[chan PoolInfo]struct{}),
+		now:          time.Now,
 	}
 }
 
@@ -60,24 +73,30 @@ func (dm *DexReadModel) handleDexRouterEvent(event VSCEvent) error {
 	txInfo := TransactionInfo{
 		ID:          event.TxID,
 		BlockHeight: event.BlockHeight,
-		Timestamp:   "", // Would need to be populated from block data
+		Timestamp:   event.Timestamp,
 	}
 
 	// Handle pool creation, liquidity changes, and swaps from unified contract
 	switch event.Method {
 	case "pool_created":
 		var args struct {
-			PoolID string  `json:"pool_id"`
-			Asset0 string  `json:"asset0"`
-			Asset1 string  `json:"asset1"`
-			Fee    float64 `json:"fee"`
+			PoolID      string  `json:"pool_id"`
+			Asset0      string  `json:"asset0"`
+			Asset1      string  `json:"asset1"`
+			Fee         float64 `json:"fee"`
+			TickSpacing uint64  `json:"tick_spacing,omitempty"` // Set for concentrated pools
 		}
 		if err := json.Unmarshal(event.Args, &args); err != nil {
 			return err
 		}
 
+		poolType := PoolTypeConstantProduct
+		if args.TickSpacing > 0 {
+			poolType = PoolTypeConcentrated
+		}
 		dm.pools[args.PoolID] = PoolInfo{
 			ID:       args.PoolID,
+			Type:     poolType,
 			Asset0:   args.Asset0,
 			Asset1:   args.Asset1,
 			Fee:      args.Fee,
@@ -119,6 +138,10 @@ func (dm *DexReadModel) handleDexRouterEvent(event VSCEvent) error {
 			// Update liquidity position only if user is specified
 			if args.User != "" {
 				dm.updateLiquidityPosition(args.PoolID, args.User, lpTokens, true)
+				dm.recordActivity(event, args.User, pool, activityDeposit, map[string]uint64{
+					pool.Asset0: args.Amount0,
+					pool.Asset1: args.Amount1,
+				})
 			}
 		}
 
@@ -151,6 +174,10 @@ func (dm *DexReadModel) handleDexRouterEvent(event VSCEvent) error {
 
 			// Update liquidity position
 			dm.updateLiquidityPosition(args.PoolID, args.User, args.LPTokens, false)
+			dm.recordActivity(event, args.User, pool, activityWithdrawal, map[string]uint64{
+				pool.Asset0: args.Amount0,
+				pool.Asset1: args.Amount1,
+			})
 		}
 
 		txInfo.Type = "withdrawal"
@@ -172,11 +199,23 @@ func (dm *DexReadModel) handleDexRouterEvent(event VSCEvent) error {
 			AmountOut uint64 `json:"amount_out,omitempty"`
 			AssetIn   string `json:"asset_in,omitempty"`
 			AssetOut  string `json:"asset_out,omitempty"`
+
+			// Concentrated pools report their price and liquidity after the swap
+			Tick      *int64 `json:"tick,omitempty"`
+			SqrtPrice string `json:"sqrt_price,omitempty"`
+			Liquidity uint64 `json:"liquidity,omitempty"`
 		}
 		if err := json.Unmarshal(event.Args, &args); err != nil {
 			return err
 		}
 
+		if args.Tick != nil {
+			pool := dm.concentratedPoolLocked(args.PoolID)
+			pool.tick = *args.Tick
+			pool.sqrtPrice = args.SqrtPrice
+			pool.liquidity = args.Liquidity
+		}
+
 		if pool, exists := dm.pools[args.PoolID]; exists {
 			// Handle backward compatibility: if amount0/amount1 are provided, treat as deltas
 			if args.Amount0 != 0 || args.Amount1 != 0 {
@@ -193,6 +232,8 @@ func (dm *DexReadModel) handleDexRouterEvent(event VSCEvent) error {
 				}
 			}
 			dm.pools[args.PoolID] = pool
+			dm.recordLastTrade(pool, event.BlockHeight, args.Amount0, args.Amount1, args.AmountIn, args.AmountOut, args.AssetIn)
+			dm.recordActivity(event, args.User, pool, activitySwap, swapVolumes(pool, args.Amount0, args.Amount1, args.AmountIn, args.AmountOut, args.AssetIn, args.AssetOut))
 		}
 
 		txInfo.Type = "swap"
@@ -206,6 +247,108 @@ func (dm *DexReadModel) handleDexRouterEvent(event VSCEvent) error {
 			"asset_in":   args.AssetIn,
 			"asset_out":  args.AssetOut,
 		}
+
+	case "order_filled":
+		// A resting limit order filled against its pool. amount_in is the
+		// escrowed input, of which pool_fee is kept out of the reserves.
+		var args struct {
+			OrderID     string `json:"order_id"`
+			PoolID      string `json:"pool_id"`
+			Maker       string `json:"maker"`
+			Taker       string `json:"taker"`
+			AssetIn     string `json:"asset_in"`
+			AssetOut    string `json:"asset_out"`
+			AmountIn    uint64 `json:"amount_in"`
+			AmountOut   uint64 `json:"amount_out"`
+			PoolFee     uint64 `json:"pool_fee"`
+			MakerFee    uint64 `json:"maker_fee"`
+			TakerReward uint64 `json:"taker_reward"`
+		}
+		if err := json.Unmarshal(event.Args, &args); err != nil {
+			return err
+		}
+
+		if pool, exists := dm.pools[args.PoolID]; exists {
+			added := args.AmountIn - args.PoolFee
+			if args.AssetIn == pool.Asset0 {
+				pool.Reserve0 += added
+				pool.Reserve1 -= args.AmountOut
+			} else {
+				pool.Reserve1 += added
+				pool.Reserve0 -= args.AmountOut
+			}
+			dm.pools[args.PoolID] = pool
+			dm.recordLastTrade(pool, event.BlockHeight, 0, 0, args.AmountIn, args.AmountOut, args.AssetIn)
+			dm.recordActivity(event, args.Maker, pool, activitySwap, swapVolumes(pool, 0, 0, args.AmountIn, args.AmountOut, args.AssetIn, args.AssetOut))
+		}
+
+		txInfo.Type = "limit_order_fill"
+		txInfo.PoolID = args.PoolID
+		txInfo.User = args.Maker
+		txInfo.Details = map[string]interface{}{
+			"order_id":     args.OrderID,
+			"taker":        args.Taker,
+			"amount_in":    args.AmountIn,
+			"amount_out":   args.AmountOut,
+			"asset_in":     args.AssetIn,
+			"asset_out":    args.AssetOut,
+			"pool_fee":     args.PoolFee,
+			"maker_fee":    args.MakerFee,
+			"taker_reward": args.TakerReward,
+		}
+
+	case "position_minted", "position_burned":
+		var args positionEvent
+		if err := json.Unmarshal(event.Args, &args); err != nil {
+			return err
+		}
+
+		txInfo.Type = "position_mint"
+		sign := int64(1)
+		if event.Method == "position_burned" {
+			txInfo.Type = "position_burn"
+			sign = -1
+		}
+		dm.applyPositionLocked(args, sign)
+
+		txInfo.PoolID = args.PoolID
+		txInfo.User = args.Owner
+		txInfo.Details = map[string]interface{}{
+			"position_id": args.PositionID,
+			"tick_lower":  args.TickLower,
+			"tick_upper":  args.TickUpper,
+			"liquidity":   args.Liquidity,
+			"amount0":     args.Amount0,
+			"amount1":     args.Amount1,
+		}
+
+	case "position_collected":
+		var args struct {
+			PositionID string `json:"position_id"`
+			PoolID     string `json:"pool_id"`
+			Owner      string `json:"owner"`
+			Recipient  string `json:"recipient"`
+			Amount0    uint64 `json:"amount0"`
+			Amount1    uint64 `json:"amount1"`
+		}
+		if err := json.Unmarshal(event.Args, &args); err != nil {
+			return err
+		}
+
+		txInfo.Type = "position_collect"
+		txInfo.PoolID = args.PoolID
+		txInfo.User = args.Owner
+		txInfo.Details = map[string]interface{}{
+			"position_id": args.PositionID,
+			"recipient":   args.Recipient,
+			"amount0":     args.Amount0,
+			"amount1":     args.Amount1,
+		}
+	}
+
+	if txInfo.PoolID != "" {
+		dm.recordSnapshot(txInfo.PoolID, event)
+		dm.publishPoolLocked(txInfo.PoolID)
 	}
 
 	// Add transaction to history (keep last 1000 transactions)
@@ -282,20 +425,23 @@ func (dm *DexReadModel) updateLiquidityPosition(poolID, user string, amount uint
 	dm.positions[poolID] = positions
 }
 
-// QueryTransactions returns recent transactions with optional filtering
+// QueryTransactions returns recent transactions with optional filtering.
+// txType may be a comma-separated list of types.
 func (dm *DexReadModel) QueryTransactions(poolID string, txType string, limit int) ([]TransactionInfo, error) {
 	dm.mu.RLock()
 	defer dm.mu.RUnlock()
 
+	return dm.queryTransactionsLocked(newTransactionFilter(poolID, txType), limit), nil
+}
+
+// queryTransactionsLocked filters transactions; caller must hold dm.mu
+func (dm *DexReadModel) queryTransactionsLocked(filter TransactionFilter, limit int) []TransactionInfo {
 	var filtered []TransactionInfo
 
 	for i := len(dm.transactions) - 1; i >= 0; i-- {
 		tx := dm.transactions[i]
 
-		if poolID != "" && tx.PoolID != poolID {
-			continue
-		}
-		if txType != "" && tx.Type != txType {
+		if !filter.matches(tx) {
 			continue
 		}
 
@@ -305,7 +451,7 @@ func (dm *DexReadModel) QueryTransactions(poolID string, txType string, limit in
 		}
 	}
 
-	return filtered, nil
+	return filtered
 }
 
 // GetTransaction returns a specific transaction by ID
@@ -313,6 +459,11 @@ func (dm *DexReadModel) GetTransaction(txID string) (TransactionInfo, bool) {
 	dm.mu.RLock()
 	defer dm.mu.RUnlock()
 
+	return dm.getTransactionLocked(txID)
+}
+
+// getTransactionLocked looks up a transaction; caller must hold dm.mu
+func (dm *DexReadModel) getTransactionLocked(txID string) (TransactionInfo, bool) {
 	for _, tx := range dm.transactions {
 		if tx.ID == txID {
 			return tx, true
@@ -326,15 +477,28 @@ func (dm *DexReadModel) QueryLiquidityPositions(poolID string) ([]LiquidityPosit
 	dm.mu.RLock()
 	defer dm.mu.RUnlock()
 
+	return dm.queryLiquidityPositionsLocked(poolID), nil
+}
+
+// queryLiquidityPositionsLocked copies a pool's positions; caller must hold dm.mu
+func (dm *DexReadModel) queryLiquidityPositionsLocked(poolID string) []LiquidityPosition {
 	positions, exists := dm.positions[poolID]
 	if !exists {
-		return []LiquidityPosition{}, nil
+		return []LiquidityPosition{}
 	}
 
 	// Return copy to avoid external modification
 	result := make([]LiquidityPosition, len(positions))
 	copy(result, positions)
-	return result, nil
+	return result
+}
+
+// CountLiquidityPositions returns the number of positions held in a pool
+func (dm *DexReadModel) CountLiquidityPositions(poolID string) int {
+	dm.mu.RLock()
+	defer dm.mu.RUnlock()
+
+	return len(dm.positions[poolID])
 }
 
 // QueryRichList returns top liquidity holders for a pool with pagination
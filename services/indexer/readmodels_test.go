@@ -192,3 +192,36 @@ func TestDexReadModel_GetPool(t *testing.T) {
 	assert.False(t, exists)
 	assert.Equal(t, PoolInfo{}, pool)
 }
+
+func TestDexReadModel_HandleEvent_OrderFilled(t *testing.T) {
+	rm := NewDexReadModel()
+	rm.pools["1"] = PoolInfo{
+		ID:       "1",
+		Asset0:   "HBD",
+		Asset1:   "HIVE",
+		Reserve0: 900000,
+		Reserve1: 4444444,
+	}
+
+	err := rm.HandleEvent(VSCEvent{
+		Type:     "contract_output",
+		Contract: "dex-router",
+		Method:   "order_filled",
+		Args: json.RawMessage(`{"order_id": "1", "pool_id": "1", "maker": "hive:maker", "taker": "hive:taker",
+			"asset_in": "HBD", "asset_out": "HIVE", "amount_in": 10000, "amount_out": 48695,
+			"pool_fee": 30, "maker_fee": 48, "taker_reward": 48}`),
+	})
+	require.NoError(t, err)
+
+	// The pool keeps its HBD fee outside the reserves
+	pool := rm.pools["1"]
+	assert.Equal(t, uint64(909970), pool.Reserve0)
+	assert.Equal(t, uint64(4395749), pool.Reserve1)
+
+	require.Len(t, rm.transactions, 1)
+	tx := rm.transactions[0]
+	assert.Equal(t, "limit_order_fill", tx.Type)
+	assert.Equal(t, "hive:maker", tx.User)
+	assert.Equal(t, uint64(48), tx.Details["maker_fee"])
+	assert.Equal(t, "hive:taker", tx.Details["taker"])
+}
@@ -0,0 +1,238 @@
+package indexer
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+)
+
+// JSON-RPC 2.0 error codes
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+	rpcNotFound       = -32004 // Implementation-defined: requested entity does not exist
+)
+
+// rpcRequest represents a single JSON-RPC 2.0 request
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+// rpcResponse represents a single JSON-RPC 2.0 response
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// rpcError represents a JSON-RPC 2.0 error object
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// rpcMethod handles a JSON-RPC method call with raw params
+type rpcMethod func(s *Server, params json.RawMessage) (interface{}, *rpcError)
+
+// rpcMethods maps JSON-RPC method names to their handlers
+var rpcMethods = map[string]rpcMethod{
+	"getPools":        rpcGetPools,
+	"getPool":         rpcGetPool,
+	"getTransactions": rpcGetTransactions,
+	"getPositions":    rpcGetPositions,
+}
+
+// handleRPC serves JSON-RPC 2.0 requests, including batches
+func (s *Server) handleRPC(w http.ResponseWriter, r *http.Request) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		if isBodyTooLarge(err) {
+			writeProblem(w, r, http.StatusRequestEntityTooLarge, ErrCodeRequestTooLarge, "Request body too large")
+			return
+		}
+		writeRPC(w, rpcResponse{
+			JSONRPC: "2.0",
+			Error:   &rpcError{Code: rpcParseError, Message: "Parse error"},
+			ID:      json.RawMessage("null"),
+		})
+		return
+	}
+
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(trimmed, &batch); err != nil || len(batch) == 0 {
+			writeRPC(w, rpcResponse{
+				JSONRPC: "2.0",
+				Error:   &rpcError{Code: rpcInvalidRequest, Message: "Invalid Request"},
+				ID:      json.RawMessage("null"),
+			})
+			return
+		}
+
+		responses := make([]rpcResponse, 0, len(batch))
+		for _, item := range batch {
+			if resp, ok := s.dispatchRPC(item); ok {
+				responses = append(responses, resp)
+			}
+		}
+
+		// A batch made only of notifications gets no response body
+		if len(responses) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeRPC(w, responses)
+		return
+	}
+
+	resp, ok := s.dispatchRPC(trimmed)
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeRPC(w, resp)
+}
+
+// dispatchRPC executes a single JSON-RPC request. The second return value is
+// false for notifications, which must not be answered.
+func (s *Server) dispatchRPC(raw json.RawMessage) (rpcResponse, bool) {
+	var req rpcRequest
+	if err := json.Unmarshal(raw, &req); err != nil || req.JSONRPC != "2.0" || req.Method == "" {
+		return rpcResponse{
+			JSONRPC: "2.0",
+			Error:   &rpcError{Code: rpcInvalidRequest, Message: "Invalid Request"},
+			ID:      json.RawMessage("null"),
+		}, true
+	}
+
+	isNotification := len(req.ID) == 0
+
+	method, exists := rpcMethods[req.Method]
+	if !exists {
+		return rpcResponse{
+			JSONRPC: "2.0",
+			Error:   &rpcError{Code: rpcMethodNotFound, Message: "Method not found"},
+			ID:      req.ID,
+		}, !isNotification
+	}
+
+	result, rpcErr := method(s, req.Params)
+	return rpcResponse{
+		JSONRPC: "2.0",
+		Result:  result,
+		Error:   rpcErr,
+		ID:      req.ID,
+	}, !isNotification
+}
+
+// writeRPC encodes a JSON-RPC response or batch of responses
+func writeRPC(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// decodeRPCParams decodes by-name params, treating absent params as empty
+func decodeRPCParams(params json.RawMessage, dst interface{}) *rpcError {
+	if len(params) == 0 || string(params) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(params, dst); err != nil {
+		return &rpcError{Code: rpcInvalidParams, Message: "Invalid params: " + err.Error()}
+	}
+	return nil
+}
+
+// rpcGetPools returns all indexed pools
+func rpcGetPools(s *Server, params json.RawMessage) (interface{}, *rpcError) {
+	pools, err := s.indexer.QueryPools()
+	if err != nil {
+		return nil, &rpcError{Code: rpcInternalError, Message: err.Error()}
+	}
+	if pools == nil {
+		pools = []PoolInfo{}
+	}
+	return pools, nil
+}
+
+// rpcGetPool returns a single pool by ID
+func rpcGetPool(s *Server, params json.RawMessage) (interface{}, *rpcError) {
+	var p struct {
+		PoolID string `json:"pool_id"`
+	}
+	if rpcErr := decodeRPCParams(params, &p); rpcErr != nil {
+		return nil, rpcErr
+	}
+	if p.PoolID == "" {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: "Invalid params: pool_id is required"}
+	}
+
+	if dexReader, ok := s.dexReader(); ok {
+		if pool, exists := dexReader.GetPool(p.PoolID); exists {
+			return pool, nil
+		}
+	}
+	return nil, &rpcError{Code: rpcNotFound, Message: "Pool not found"}
+}
+
+// rpcGetTransactions returns transaction history with optional filtering
+func rpcGetTransactions(s *Server, params json.RawMessage) (interface{}, *rpcError) {
+	var p struct {
+		PoolID string `json:"pool_id"`
+		Type   string `json:"type"`
+		Limit  int    `json:"limit"`
+	}
+	if rpcErr := decodeRPCParams(params, &p); rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	limit := 100 // Default limit
+	if p.Limit > 0 && p.Limit <= 1000 {
+		limit = p.Limit
+	}
+
+	dexReader, ok := s.dexReader()
+	if !ok {
+		return nil, &rpcError{Code: rpcInternalError, Message: "No transaction data available"}
+	}
+
+	transactions, err := dexReader.QueryTransactions(p.PoolID, p.Type, limit)
+	if err != nil {
+		return nil, &rpcError{Code: rpcInternalError, Message: err.Error()}
+	}
+	if transactions == nil {
+		transactions = []TransactionInfo{}
+	}
+	return transactions, nil
+}
+
+// rpcGetPositions returns liquidity positions for a pool
+func rpcGetPositions(s *Server, params json.RawMessage) (interface{}, *rpcError) {
+	var p struct {
+		PoolID string `json:"pool_id"`
+	}
+	if rpcErr := decodeRPCParams(params, &p); rpcErr != nil {
+		return nil, rpcErr
+	}
+	if p.PoolID == "" {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: "Invalid params: pool_id is required"}
+	}
+
+	dexReader, ok := s.dexReader()
+	if !ok {
+		return nil, &rpcError{Code: rpcNotFound, Message: "Pool not found"}
+	}
+
+	positions, err := dexReader.QueryLiquidityPositions(p.PoolID)
+	if err != nil {
+		return nil, &rpcError{Code: rpcInternalError, Message: err.Error()}
+	}
+	return positions, nil
+}
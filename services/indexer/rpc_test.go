@@ -0,0 +1,172 @@
+package indexer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRPCTestServer() *Server {
+	svc := NewService("http://localhost:4000", ":8081")
+
+	dexReader := svc.readers[0].(*DexReadModel)
+	dexReader.pools["pool-1"] = PoolInfo{
+		ID:          "pool-1",
+		Asset0:      "HBD",
+		Asset1:      "HIVE",
+		Reserve0:    1000000,
+		Reserve1:    500000,
+		Fee:         0.08,
+		TotalSupply: 1000,
+	}
+	dexReader.positions["pool-1"] = []LiquidityPosition{
+		{User: "alice", PoolID: "pool-1", Amount: 1000, Share: 100},
+	}
+	dexReader.transactions = []TransactionInfo{
+		{ID: "tx-1", Type: "deposit", PoolID: "pool-1", User: "alice"},
+		{ID: "tx-2", Type: "swap", PoolID: "pool-1", User: "bob"},
+	}
+
+	return NewServer(svc, "8081")
+}
+
+func doRPC(t *testing.T, server *Server, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/rpc", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	server.handleRPC(w, req)
+	return w
+}
+
+func TestRPC_GetPools(t *testing.T) {
+	server := newRPCTestServer()
+
+	w := doRPC(t, server, `{"jsonrpc":"2.0","method":"getPools","id":1}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		JSONRPC string     `json:"jsonrpc"`
+		Result  []PoolInfo `json:"result"`
+		ID      int        `json:"id"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "2.0", resp.JSONRPC)
+	assert.Equal(t, 1, resp.ID)
+	require.Len(t, resp.Result, 1)
+	assert.Equal(t, "pool-1", resp.Result[0].ID)
+}
+
+func TestRPC_GetPool(t *testing.T) {
+	server := newRPCTestServer()
+
+	w := doRPC(t, server, `{"jsonrpc":"2.0","method":"getPool","params":{"pool_id":"pool-1"},"id":"a"}`)
+
+	var resp struct {
+		Result PoolInfo `json:"result"`
+		ID     string   `json:"id"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "a", resp.ID)
+	assert.Equal(t, "HBD", resp.Result.Asset0)
+	assert.Equal(t, uint64(1000000), resp.Result.Reserve0)
+}
+
+func TestRPC_GetPool_NotFound(t *testing.T) {
+	server := newRPCTestServer()
+
+	w := doRPC(t, server, `{"jsonrpc":"2.0","method":"getPool","params":{"pool_id":"missing"},"id":2}`)
+
+	var resp rpcResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, rpcNotFound, resp.Error.Code)
+}
+
+func TestRPC_GetTransactions_Filtered(t *testing.T) {
+	server := newRPCTestServer()
+
+	w := doRPC(t, server, `{"jsonrpc":"2.0","method":"getTransactions","params":{"type":"swap"},"id":3}`)
+
+	var resp struct {
+		Result []TransactionInfo `json:"result"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Len(t, resp.Result, 1)
+	assert.Equal(t, "tx-2", resp.Result[0].ID)
+}
+
+func TestRPC_GetPositions(t *testing.T) {
+	server := newRPCTestServer()
+
+	w := doRPC(t, server, `{"jsonrpc":"2.0","method":"getPositions","params":{"pool_id":"pool-1"},"id":4}`)
+
+	var resp struct {
+		Result []LiquidityPosition `json:"result"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Len(t, resp.Result, 1)
+	assert.Equal(t, "alice", resp.Result[0].User)
+}
+
+func TestRPC_Errors(t *testing.T) {
+	server := newRPCTestServer()
+
+	tests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{"parse error", `{"jsonrpc":`, rpcParseError},
+		{"missing version", `{"method":"getPools","id":1}`, rpcInvalidRequest},
+		{"unknown method", `{"jsonrpc":"2.0","method":"dropPools","id":1}`, rpcMethodNotFound},
+		{"invalid params", `{"jsonrpc":"2.0","method":"getPool","params":{"pool_id":5},"id":1}`, rpcInvalidParams},
+		{"empty batch", `[]`, rpcInvalidRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRPC(t, server, tt.body)
+
+			var resp rpcResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+			require.NotNil(t, resp.Error)
+			assert.Equal(t, tt.code, resp.Error.Code)
+		})
+	}
+}
+
+func TestRPC_Batch(t *testing.T) {
+	server := newRPCTestServer()
+
+	body := `[
+		{"jsonrpc":"2.0","method":"getPool","params":{"pool_id":"pool-1"},"id":1},
+		{"jsonrpc":"2.0","method":"getPools"},
+		{"jsonrpc":"2.0","method":"nope","id":2}
+	]`
+	w := doRPC(t, server, body)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp []rpcResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+
+	// The notification (no id) must not be answered
+	require.Len(t, resp, 2)
+	assert.Equal(t, "1", string(resp[0].ID))
+	assert.Nil(t, resp[0].Error)
+	assert.Equal(t, "2", string(resp[1].ID))
+	require.NotNil(t, resp[1].Error)
+	assert.Equal(t, rpcMethodNotFound, resp[1].Error.Code)
+}
+
+func TestRPC_NotificationOnly(t *testing.T) {
+	server := newRPCTestServer()
+
+	w := doRPC(t, server, `{"jsonrpc":"2.0","method":"getPools"}`)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+}
@@ -11,34 +11,90 @@ import (
 
 // Server provides HTTP API for indexer read models
 type Server struct {
-	indexer *Service
-	http    *http.Server
+	indexer       *Service
+	http          *http.Server
+	cache         ResponseCache
+	cacheTTLs     CacheTTLs
+	cacheCounters cacheCounters
+	limits        serverLimits
+	config        ServerConfig
+}
+
+// ServerOption configures optional Server behaviour
+type ServerOption func(*Server)
+
+// WithResponseCache enables response caching for hot endpoints
+func WithResponseCache(cache ResponseCache, ttls CacheTTLs) ServerOption {
+	return func(s *Server) {
+		s.cache = cache
+		s.cacheTTLs = ttls
+	}
 }
 
 // NewServer creates a new HTTP server for the indexer
-func NewServer(svc *Service, port string) *Server {
+func NewServer(svc *Service, port string, opts ...ServerOption) *Server {
 	s := &Server{
 		indexer: svc,
+		limits:  defaultServerLimits(),
+		config:  DefaultServerConfig(),
+	}
+	s.config.Port = port
+
+	for _, opt := range opts {
+		opt(s)
 	}
 
-	r := mux.NewRouter()
+	root := mux.NewRouter()
+	r := root
+	if s.config.BasePath != "" {
+		r = root.PathPrefix(s.config.BasePath).Subrouter()
+	}
 
 	// Pool endpoints
-	r.HandleFunc("/api/v1/pools", s.handleGetPools).Methods("GET")
-	r.HandleFunc("/api/v1/pools/{id}", s.handleGetPool).Methods("GET")
+	r.HandleFunc("/api/v1/pools", withETag(s.cached(s.cacheTTLs.Pools, s.handleGetPools))).Methods("GET")
+	r.HandleFunc("/api/v1/pools/{id}", withETag(s.handleGetPool)).Methods("GET")
 	r.HandleFunc("/api/v1/pools/{id}/accounts", s.handleGetPoolAccounts).Methods("GET")
-	r.HandleFunc("/api/v1/pools/{id}/richlist", s.handleGetPoolRichList).Methods("GET")
+	r.HandleFunc("/api/v1/pools/{id}/richlist", s.cached(s.cacheTTLs.RichList, s.handleGetPoolRichList)).Methods("GET")
+	r.HandleFunc("/api/v1/pools/{id}/price", s.handleGetPoolPrice).Methods("GET")
+	r.HandleFunc("/api/v1/pools/{id}/depth", s.cached(s.cacheTTLs.Pools, s.handleGetPoolDepth)).Methods("GET")
+	r.HandleFunc("/api/v1/pools/{id}/liquidity", s.cached(s.cacheTTLs.Pools, s.handleGetPoolLiquidity)).Methods("GET")
+
+	// Leaderboard endpoints
+	r.HandleFunc("/api/v1/leaderboards/traders", s.cached(s.cacheTTLs.Leaderboards, s.handleGetTraderLeaderboard)).Methods("GET")
+	r.HandleFunc("/api/v1/leaderboards/lps", s.cached(s.cacheTTLs.Leaderboards, s.handleGetLPLeaderboard)).Methods("GET")
+
+	// Streaming endpoints
+	r.HandleFunc(poolStreamPath, s.handlePoolStream).Methods("GET")
 
 	// Transaction endpoints
 	r.HandleFunc("/api/v1/transactions", s.handleGetTransactions).Methods("GET")
 	r.HandleFunc("/api/v1/transactions/{id}", s.handleGetTransaction).Methods("GET")
 
+	// Batch query endpoint
+	r.HandleFunc("/api/v1/batch", s.handleBatch).Methods("POST")
+
+	// JSON-RPC 2.0 compatibility endpoint
+	r.HandleFunc("/rpc", s.handleRPC).Methods("POST")
+
+	// Response cache statistics
+	r.HandleFunc("/api/v1/cache/stats", s.handleGetCacheStats).Methods("GET")
+
 	// Health check
 	r.HandleFunc("/health", s.handleHealth).Methods("GET")
 
+	root.NotFoundHandler = http.HandlerFunc(handleRouteNotFound)
+	root.MethodNotAllowedHandler = http.HandlerFunc(handleMethodNotAllowed)
+	r.NotFoundHandler = root.NotFoundHandler
+	r.MethodNotAllowedHandler = root.MethodNotAllowedHandler
+	r.Use(s.limitBody, s.handlerDeadline)
+
 	s.http = &http.Server{
-		Addr:    ":" + port,
-		Handler: r,
+		Addr:              s.config.addr(),
+		Handler:           s.cors(s.rateLimit(root)),
+		ReadTimeout:       s.limits.readTimeout,
+		ReadHeaderTimeout: s.limits.readHeaderTimeout,
+		WriteTimeout:      s.limits.writeTimeout,
+		IdleTimeout:       s.limits.idleTimeout,
 	}
 
 	return s
@@ -46,7 +102,15 @@ func NewServer(svc *Service, port string) *Server {
 
 // Start starts the HTTP server
 func (s *Server) Start() error {
-	return s.http.ListenAndServe()
+	ln, err := s.config.listen()
+	if err != nil {
+		return err
+	}
+
+	if s.config.TLSEnabled() {
+		return s.http.ServeTLS(ln, s.config.TLSCertFile, s.config.TLSKeyFile)
+	}
+	return s.http.Serve(ln)
 }
 
 // Stop stops the HTTP server
@@ -54,16 +118,41 @@ func (s *Server) Stop(ctx context.Context) error {
 	return s.http.Shutdown(ctx)
 }
 
+// dexReader returns the first registered DEX read model
+func (s *Server) dexReader() (*DexReadModel, bool) {
+	s.indexer.mu.RLock()
+	defer s.indexer.mu.RUnlock()
+
+	for _, reader := range s.indexer.readers {
+		if dexReader, ok := reader.(*DexReadModel); ok {
+			return dexReader, true
+		}
+	}
+	return nil, false
+}
+
 // handleGetPools returns all pools
 func (s *Server) handleGetPools(w http.ResponseWriter, r *http.Request) {
+	fields, err := parseFields(r, PoolInfo{})
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+		return
+	}
+
 	pools, err := s.indexer.QueryPools()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+
+	resp, err := selectFields(pools, fields)
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(pools)
+	json.NewEncoder(w).Encode(resp)
 }
 
 // handleGetPool returns a specific pool
@@ -71,18 +160,40 @@ func (s *Server) handleGetPool(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	poolID := vars["id"]
 
+	fields, err := parseFields(r, PoolInfo{})
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+		return
+	}
+
+	at, err := parsePointInTime(r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+		return
+	}
+	if !at.IsZero() {
+		s.handleGetPoolAt(w, r, poolID, at, fields)
+		return
+	}
+
 	// Get the first read model that supports pool queries
 	for _, reader := range s.indexer.readers {
 		if dexReader, ok := reader.(*DexReadModel); ok {
 			if pool, exists := dexReader.GetPool(poolID); exists {
+				resp, err := selectFields(pool, fields)
+				if err != nil {
+					writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+					return
+				}
+
 				w.Header().Set("Content-Type", "application/json")
-				json.NewEncoder(w).Encode(pool)
+				json.NewEncoder(w).Encode(resp)
 				return
 			}
 		}
 	}
 
-	http.Error(w, "Pool not found", http.StatusNotFound)
+	writeProblem(w, r, http.StatusNotFound, ErrCodePoolNotFound, "Pool not found")
 }
 
 // handleGetPoolAccounts returns liquidity accounts for a specific pool
@@ -90,12 +201,22 @@ func (s *Server) handleGetPoolAccounts(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	poolID := vars["id"]
 
+	at, err := parsePointInTime(r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+		return
+	}
+	if !at.IsZero() {
+		s.handleGetPoolAccountsAt(w, r, poolID, at)
+		return
+	}
+
 	// Get the first read model that supports pool queries
 	for _, reader := range s.indexer.readers {
 		if dexReader, ok := reader.(*DexReadModel); ok {
 			accounts, err := dexReader.QueryLiquidityPositions(poolID)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 				return
 			}
 
@@ -108,7 +229,7 @@ func (s *Server) handleGetPoolAccounts(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	http.Error(w, "Pool not found", http.StatusNotFound)
+	writeProblem(w, r, http.StatusNotFound, ErrCodePoolNotFound, "Pool not found")
 }
 
 // handleGetPoolRichList returns paginated rich list for a specific pool
@@ -140,57 +261,85 @@ func (s *Server) handleGetPoolRichList(w http.ResponseWriter, r *http.Request) {
 		if dexReader, ok := reader.(*DexReadModel); ok {
 			richList, err := dexReader.QueryRichList(poolID, offset, limit)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 				return
 			}
 
+			pagination := newPagination(r, offset, limit, dexReader.CountLiquidityPositions(poolID))
+			writePaginationLinks(w, pagination)
 			w.Header().Set("Content-Type", "application/json")
 			json.NewEncoder(w).Encode(map[string]interface{}{
-				"pool_id": poolID,
-				"offset":  offset,
-				"limit":   limit,
-				"holders": richList,
+				"pool_id":    poolID,
+				"offset":     offset,
+				"limit":      limit,
+				"holders":    richList,
+				"pagination": pagination,
 			})
 			return
 		}
 	}
 
-	http.Error(w, "Pool not found", http.StatusNotFound)
+	writeProblem(w, r, http.StatusNotFound, ErrCodePoolNotFound, "Pool not found")
 }
 
 // handleGetTransactions returns transaction history with optional filtering
 func (s *Server) handleGetTransactions(w http.ResponseWriter, r *http.Request) {
 	// Parse query parameters
-	poolID := r.URL.Query().Get("pool_id")
-	txType := r.URL.Query().Get("type")
+	filter, err := parseTransactionFilter(r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+		return
+	}
+	offsetStr := r.URL.Query().Get("offset")
 	limitStr := r.URL.Query().Get("limit")
 
+	offset := 0
 	limit := 100 // Default limit
+	if offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
 	if limitStr != "" {
 		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 1000 {
 			limit = l
 		}
 	}
 
+	fields, err := parseFields(r, TransactionInfo{})
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+		return
+	}
+
 	// Get the first read model that supports transaction queries
 	for _, reader := range s.indexer.readers {
 		if dexReader, ok := reader.(*DexReadModel); ok {
-			transactions, err := dexReader.QueryTransactions(poolID, txType, limit)
+			transactions, total, err := dexReader.QueryTransactionsPage(filter, offset, limit)
+			if err != nil {
+				writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+				return
+			}
+
+			selected, err := selectFields(transactions, fields)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 				return
 			}
 
+			pagination := newPagination(r, offset, limit, total)
+			writePaginationLinks(w, pagination)
 			w.Header().Set("Content-Type", "application/json")
 			json.NewEncoder(w).Encode(map[string]interface{}{
-				"transactions": transactions,
+				"transactions": selected,
 				"count":        len(transactions),
+				"pagination":   pagination,
 			})
 			return
 		}
 	}
 
-	http.Error(w, "No transaction data available", http.StatusInternalServerError)
+	writeProblem(w, r, http.StatusInternalServerError, ErrCodeNoDataAvailable, "No transaction data available")
 }
 
 // handleGetTransaction returns a specific transaction by ID
@@ -198,22 +347,34 @@ func (s *Server) handleGetTransaction(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	txID := vars["id"]
 
+	fields, err := parseFields(r, TransactionInfo{})
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+		return
+	}
+
 	// Get the first read model that supports transaction queries
 	for _, reader := range s.indexer.readers {
 		if dexReader, ok := reader.(*DexReadModel); ok {
 			transaction, found := dexReader.GetTransaction(txID)
 			if !found {
-				http.Error(w, "Transaction not found", http.StatusNotFound)
+				writeProblem(w, r, http.StatusNotFound, ErrCodeTransactionNotFound, "Transaction not found")
+				return
+			}
+
+			resp, err := selectFields(transaction, fields)
+			if err != nil {
+				writeProblem(w, r, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 				return
 			}
 
 			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(transaction)
+			json.NewEncoder(w).Encode(resp)
 			return
 		}
 	}
 
-	http.Error(w, "Transaction not found", http.StatusNotFound)
+	writeProblem(w, r, http.StatusNotFound, ErrCodeTransactionNotFound, "Transaction not found")
 }
 
 // handleHealth provides health check endpoint
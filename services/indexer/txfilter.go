@@ -0,0 +1,146 @@
+package indexer
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// amountDetailKeys are the transaction detail fields considered by MinAmount
+var amountDetailKeys = []string{"amount_in", "amount_out", "amount0", "amount1"}
+
+// TransactionFilter selects transactions by pool, type and size
+type TransactionFilter struct {
+	TxID         string // All events of one VSC transaction, e.g. both hops of a routed swap
+	PoolID       string
+	Types        []string // Include only these types; empty means all
+	ExcludeTypes []string // Drop these types
+	MinAmount    uint64   // Drop transactions whose largest amount is below this
+}
+
+// newTransactionFilter builds a filter from a pool ID and a comma-separated
+// type list, as accepted by the transactions endpoint
+func newTransactionFilter(poolID, types string) TransactionFilter {
+	return TransactionFilter{
+		PoolID: poolID,
+		Types:  splitList(types),
+	}
+}
+
+// matches reports whether tx passes the filter
+func (f TransactionFilter) matches(tx TransactionInfo) bool {
+	if f.TxID != "" && tx.ID != f.TxID {
+		return false
+	}
+	if f.PoolID != "" && tx.PoolID != f.PoolID {
+		return false
+	}
+	if len(f.Types) > 0 && !containsString(f.Types, tx.Type) {
+		return false
+	}
+	if containsString(f.ExcludeTypes, tx.Type) {
+		return false
+	}
+	if f.MinAmount > 0 && transactionAmount(tx) < f.MinAmount {
+		return false
+	}
+	return true
+}
+
+// QueryTransactionsFiltered returns the most recent transactions matching filter
+func (dm *DexReadModel) QueryTransactionsFiltered(filter TransactionFilter, limit int) ([]TransactionInfo, error) {
+	dm.mu.RLock()
+	defer dm.mu.RUnlock()
+
+	return dm.queryTransactionsLocked(filter, limit), nil
+}
+
+// QueryTransactionsPage returns one page of the transactions matching
+// filter, most recent first, and the total number of matches
+func (dm *DexReadModel) QueryTransactionsPage(filter TransactionFilter, offset, limit int) ([]TransactionInfo, int, error) {
+	dm.mu.RLock()
+	defer dm.mu.RUnlock()
+
+	page := []TransactionInfo{}
+	total := 0
+	for i := len(dm.transactions) - 1; i >= 0; i-- {
+		tx := dm.transactions[i]
+		if !filter.matches(tx) {
+			continue
+		}
+		if total >= offset && len(page) < limit {
+			page = append(page, tx)
+		}
+		total++
+	}
+
+	return page, total, nil
+}
+
+// transactionAmount returns the largest amount recorded in a transaction's
+// details. Legacy swap deltas are signed, so their magnitude is used.
+func transactionAmount(tx TransactionInfo) uint64 {
+	var largest uint64
+	for _, key := range amountDetailKeys {
+		var amount uint64
+		switch v := tx.Details[key].(type) {
+		case uint64:
+			amount = v
+		case int64:
+			amount = uint64(absFloat(float64(v)))
+		case int:
+			amount = uint64(absFloat(float64(v)))
+		case float64:
+			amount = uint64(absFloat(v))
+		}
+		if amount > largest {
+			largest = amount
+		}
+	}
+	return largest
+}
+
+// parseTransactionFilter reads tx_id, pool_id, type, exclude_type and min_amount.
+// type and exclude_type take comma-separated lists.
+func parseTransactionFilter(r *http.Request) (TransactionFilter, error) {
+	params := r.URL.Query()
+
+	filter := TransactionFilter{
+		TxID:         params.Get("tx_id"),
+		PoolID:       params.Get("pool_id"),
+		Types:        splitList(params.Get("type")),
+		ExcludeTypes: splitList(params.Get("exclude_type")),
+	}
+
+	if v := params.Get("min_amount"); v != "" {
+		amount, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("invalid min_amount: %s", v)
+		}
+		filter.MinAmount = amount
+	}
+
+	return filter, nil
+}
+
+// splitList splits a comma-separated parameter, dropping empty entries
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package indexer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFilterTestModel() *DexReadModel {
+	rm := NewDexReadModel()
+	rm.transactions = []TransactionInfo{
+		{ID: "tx1", Type: "pool_created", PoolID: "pool-1"},
+		{ID: "tx2", Type: "deposit", PoolID: "pool-1", Details: map[string]interface{}{"amount0": uint64(5000), "amount1": uint64(10000)}},
+		{ID: "tx3", Type: "swap", PoolID: "pool-1", Details: map[string]interface{}{"amount_in": uint64(100), "amount_out": uint64(190)}},
+		{ID: "tx4", Type: "swap", PoolID: "pool-2", Details: map[string]interface{}{"amount0": int64(-2000), "amount1": int64(1900)}},
+		{ID: "tx5", Type: "withdrawal", PoolID: "pool-1", Details: map[string]interface{}{"amount0": uint64(50), "amount1": uint64(100)}},
+	}
+	return rm
+}
+
+func transactionIDs(txs []TransactionInfo) []string {
+	ids := make([]string, len(txs))
+	for i, tx := range txs {
+		ids[i] = tx.ID
+	}
+	return ids
+}
+
+func TestDexReadModel_QueryTransactionsFiltered(t *testing.T) {
+	rm := newFilterTestModel()
+
+	tests := []struct {
+		name     string
+		filter   TransactionFilter
+		expected []string
+	}{
+		{"all", TransactionFilter{}, []string{"tx5", "tx4", "tx3", "tx2", "tx1"}},
+		{"multiple types", TransactionFilter{Types: []string{"swap", "deposit"}}, []string{"tx4", "tx3", "tx2"}},
+		{"exclude type", TransactionFilter{ExcludeTypes: []string{"pool_created", "withdrawal"}}, []string{"tx4", "tx3", "tx2"}},
+		{"min amount", TransactionFilter{MinAmount: 1000}, []string{"tx4", "tx2"}},
+		{"tx id", TransactionFilter{TxID: "tx3"}, []string{"tx3"}},
+		{"combined", TransactionFilter{PoolID: "pool-1", Types: []string{"swap", "deposit"}, MinAmount: 150}, []string{"tx3", "tx2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txs, err := rm.QueryTransactionsFiltered(tt.filter, 100)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, transactionIDs(txs))
+		})
+	}
+}
+
+func TestDexReadModel_QueryTransactions_TypeList(t *testing.T) {
+	rm := newFilterTestModel()
+
+	txs, err := rm.QueryTransactions("", "swap, withdrawal", 100)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tx5", "tx4", "tx3"}, transactionIDs(txs))
+}
+
+func TestServer_HandleGetTransactions_Filters(t *testing.T) {
+	svc := NewService("http://localhost:4000", ":8081")
+	server := NewServer(svc, "8081")
+	svc.readers[0] = newFilterTestModel()
+
+	req := httptest.NewRequest("GET", "/api/v1/transactions?type=swap,deposit&exclude_type=deposit&min_amount=150", nil)
+	w := httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Transactions []TransactionInfo `json:"transactions"`
+		Count        int               `json:"count"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 2, resp.Count)
+	assert.Equal(t, []string{"tx4", "tx3"}, transactionIDs(resp.Transactions))
+
+	req = httptest.NewRequest("GET", "/api/v1/transactions?min_amount=-5", nil)
+	w = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), string(ErrCodeInvalidParameter))
+}
@@ -0,0 +1,453 @@
+package router
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultApprovalTTL is how long a held swap waits for its approvals
+const defaultApprovalTTL = 24 * time.Hour
+
+// defaultApprovalExecuteTimeout is how long an approved swap may take to
+// broadcast
+const defaultApprovalExecuteTimeout = 5 * time.Minute
+
+// Approval errors
+var (
+	ErrApprovalNotFound = errors.New("approval request not found")
+	ErrUnknownApprover  = errors.New("unknown approver")
+)
+
+// ApprovalStatus is the state of a swap held for approval
+type ApprovalStatus string
+
+// Approval request statuses
+const (
+	ApprovalPending   ApprovalStatus = "pending"
+	ApprovalExecuting ApprovalStatus = "executing" // Approved; the swap is being executed
+	ApprovalExecuted  ApprovalStatus = "executed"
+	ApprovalFailed    ApprovalStatus = "failed" // Approved, but the swap failed
+	ApprovalRejected  ApprovalStatus = "rejected"
+	ApprovalExpired   ApprovalStatus = "expired"
+)
+
+// ApprovalConfig sets which swaps need approval and who may give it
+type ApprovalConfig struct {
+	// Thresholds is the smallest AmountIn, per input asset, that needs
+	// approval. Swaps of assets not listed never do.
+	Thresholds map[string]int64
+
+	// Approvers maps each approver's name to the API token they
+	// authenticate with
+	Approvers map[string]string
+
+	// Required is how many approvers must approve a swap
+	Required int
+
+	// TTL is how long a swap waits for approval; zero uses 24 hours
+	TTL time.Duration
+
+	// ExecuteTimeout is how long an approved swap may take to broadcast;
+	// zero uses 5 minutes
+	ExecuteTimeout time.Duration
+}
+
+// Approval is one approver's sign-off
+type Approval struct {
+	Approver string    `json:"approver"`
+	At       time.Time `json:"at"`
+}
+
+// ApprovalRequest is a swap held until enough approvers sign off on it
+type ApprovalRequest struct {
+	ID           string         `json:"id"`
+	Status       ApprovalStatus `json:"status"`
+	Sender       string         `json:"sender"`
+	AssetIn      string         `json:"assetIn"`
+	AssetOut     string         `json:"assetOut"`
+	AmountIn     int64          `json:"amountIn"`
+	MinAmountOut int64          `json:"minAmountOut,omitempty"`
+	MaxSlippage  uint64         `json:"maxSlippage,omitempty"`
+	Required     int            `json:"required"`
+	Approvals    []Approval     `json:"approvals"`
+	RejectedBy   string         `json:"rejectedBy,omitempty"`
+	Reason       string         `json:"reason,omitempty"` // Given on rejection
+	Result       *SwapResult    `json:"result,omitempty"` // Once executed
+	CreatedAt    time.Time      `json:"createdAt"`
+	ExpiresAt    time.Time      `json:"expiresAt"`
+	UpdatedAt    time.Time      `json:"updatedAt"`
+}
+
+// copyApprovalRequest returns a copy that shares no slices with a
+func copyApprovalRequest(a *ApprovalRequest) ApprovalRequest {
+	copied := *a
+	copied.Approvals = append([]Approval{}, a.Approvals...)
+	return copied
+}
+
+// storedApproval is an approval request as persisted, with the swap it
+// executes once approved. The swap's unexported fields are stored beside
+// it, so a restored swap keeps its route and is not charged its fee tier
+// twice.
+type storedApproval struct {
+	ID         string          `json:"id"`
+	Request    ApprovalRequest `json:"request"`
+	Swap       SwapParams      `json:"swap"`
+	Tiered     bool            `json:"tiered,omitempty"`
+	Pools      []string        `json:"pools,omitempty"`
+	CommitSalt string          `json:"commitSalt,omitempty"`
+}
+
+// ApprovalQueue holds swaps above a size threshold until N of M approvers
+// approve them, then broadcasts them
+type ApprovalQueue struct {
+	router *Service
+	config ApprovalConfig
+	store  OrderStore // Nil keeps requests in memory only
+
+	mu       sync.Mutex
+	requests map[string]*ApprovalRequest
+	swaps    map[string]SwapParams
+	now      func() time.Time
+
+	// Approved swaps broadcast under ctx, which Stop cancels
+	ctx       context.Context
+	stop      context.CancelFunc
+	executing sync.WaitGroup
+}
+
+// NewApprovalQueue creates an approval queue, restoring held swaps from
+// store. Requests that were executing when the router stopped are marked
+// failed, since whether their swap went out is unknown.
+func NewApprovalQueue(svc *Service, config ApprovalConfig, store OrderStore) (*ApprovalQueue, error) {
+	if len(config.Approvers) == 0 {
+		return nil, fmt.Errorf("approvals need at least one approver")
+	}
+	if config.Required < 1 || config.Required > len(config.Approvers) {
+		return nil, fmt.Errorf("required approvals must be between 1 and %d", len(config.Approvers))
+	}
+	for name, token := range config.Approvers {
+		if token == "" {
+			return nil, fmt.Errorf("approver %s has no token", name)
+		}
+	}
+	for asset, threshold := range config.Thresholds {
+		if threshold <= 0 {
+			return nil, fmt.Errorf("approval threshold for %s must be greater than 0", asset)
+		}
+	}
+	if config.TTL <= 0 {
+		config.TTL = defaultApprovalTTL
+	}
+	if config.ExecuteTimeout <= 0 {
+		config.ExecuteTimeout = defaultApprovalExecuteTimeout
+	}
+
+	q := &ApprovalQueue{
+		router:   svc,
+		config:   config,
+		store:    store,
+		requests: make(map[string]*ApprovalRequest),
+		swaps:    make(map[string]SwapParams),
+		now:      time.Now,
+	}
+
+	if store != nil {
+		stored, err := store.LoadOrders(OrderApproval)
+		if err != nil {
+			return nil, err
+		}
+		for _, data := range stored {
+			var record storedApproval
+			if err := json.Unmarshal(data, &record); err != nil {
+				return nil, fmt.Errorf("failed to decode approval request: %w", err)
+			}
+			request := record.Request
+			swap := record.Swap
+			swap.tiered = record.Tiered
+			swap.pools = record.Pools
+			swap.commitSalt = record.CommitSalt
+			q.requests[request.ID] = &request
+			q.swaps[request.ID] = swap
+			if request.Status == ApprovalExecuting {
+				request.Status = ApprovalFailed
+				request.Result = &SwapResult{ErrorMessage: "router stopped while the swap was executing; check its receipt"}
+				if err := q.saveLocked(request.ID); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	q.ctx, q.stop = context.WithCancel(context.Background())
+	return q, nil
+}
+
+// Stop cancels the approved swaps still broadcasting and waits for them to
+// record their results
+func (q *ApprovalQueue) Stop() {
+	q.stop()
+	q.executing.Wait()
+}
+
+// SetApprovalQueue configures the queue large swaps are held in for
+// approval
+func (s *Service) SetApprovalQueue(queue *ApprovalQueue) {
+	s.approvals = queue
+}
+
+// required reports whether a swap must be approved before it is broadcast
+func (q *ApprovalQueue) required(params SwapParams) bool {
+	if q == nil || params.approved {
+		return false
+	}
+	threshold, ok := q.config.Thresholds[params.AssetIn]
+	return ok && params.AmountIn >= threshold
+}
+
+// hold queues a swap for approval
+func (q *ApprovalQueue) hold(params SwapParams) (*ApprovalRequest, error) {
+	id, err := newJobID()
+	if err != nil {
+		return nil, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// A retried swap waits on the request already holding it
+	if params.IdempotencyKey != "" {
+		for heldID, held := range q.swaps {
+			request := q.requests[heldID]
+			q.expireLocked(request)
+			if request.Status == ApprovalPending && held.Sender == params.Sender && held.IdempotencyKey == params.IdempotencyKey {
+				copied := copyApprovalRequest(request)
+				return &copied, nil
+			}
+		}
+	}
+
+	now := q.now()
+	request := &ApprovalRequest{
+		ID:           id,
+		Status:       ApprovalPending,
+		Sender:       params.Sender,
+		AssetIn:      params.AssetIn,
+		AssetOut:     params.AssetOut,
+		AmountIn:     params.AmountIn,
+		MinAmountOut: params.MinAmountOut,
+		MaxSlippage:  params.MaxSlippage,
+		Required:     q.config.Required,
+		Approvals:    []Approval{},
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(q.config.TTL),
+		UpdatedAt:    now,
+	}
+	params.claim = nil // Approved swaps claim their idempotency key afresh
+	q.requests[id] = request
+	q.swaps[id] = params
+
+	if err := q.saveLocked(id); err != nil {
+		delete(q.requests, id)
+		delete(q.swaps, id)
+		return nil, err
+	}
+	log.Printf("Holding swap of %d %s by %s for approval %s", params.AmountIn, params.AssetIn, params.Sender, id)
+
+	copied := copyApprovalRequest(request)
+	return &copied, nil
+}
+
+// Get returns an approval request
+func (q *ApprovalQueue) Get(id string) (*ApprovalRequest, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	request, ok := q.requests[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrApprovalNotFound, id)
+	}
+	q.expireLocked(request)
+	copied := copyApprovalRequest(request)
+	return &copied, nil
+}
+
+// List returns approval requests with the given status, or every request
+// if status is empty, oldest first
+func (q *ApprovalQueue) List(status ApprovalStatus) []ApprovalRequest {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	requests := []ApprovalRequest{}
+	for _, request := range q.requests {
+		q.expireLocked(request)
+		if status == "" || request.Status == status {
+			requests = append(requests, copyApprovalRequest(request))
+		}
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		if requests[i].CreatedAt.Equal(requests[j].CreatedAt) {
+			return requests[i].ID < requests[j].ID
+		}
+		return requests[i].CreatedAt.Before(requests[j].CreatedAt)
+	})
+	return requests
+}
+
+// Approve records the approval of the approver token belongs to. The swap
+// is broadcast in the background once enough approvers have approved it.
+func (q *ApprovalQueue) Approve(id, token string) (*ApprovalRequest, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	request, approver, err := q.decideLocked(id, token)
+	if err != nil {
+		return nil, err
+	}
+	for _, approval := range request.Approvals {
+		if approval.Approver == approver {
+			return nil, fmt.Errorf("%s has already approved %s", approver, id)
+		}
+	}
+
+	now := q.now()
+	request.Approvals = append(request.Approvals, Approval{Approver: approver, At: now})
+	request.UpdatedAt = now
+	execute := len(request.Approvals) >= request.Required
+	if execute {
+		request.Status = ApprovalExecuting
+	}
+	if err := q.saveLocked(id); err != nil {
+		request.Approvals = request.Approvals[:len(request.Approvals)-1]
+		request.Status = ApprovalPending
+		return nil, err
+	}
+
+	log.Printf("%s approved %s (%d of %d)", approver, id, len(request.Approvals), request.Required)
+	if execute {
+		q.executing.Add(1)
+		go q.execute(id)
+	}
+	copied := copyApprovalRequest(request)
+	return &copied, nil
+}
+
+// Reject refuses a held swap on behalf of the approver token belongs to.
+// One rejection is final.
+func (q *ApprovalQueue) Reject(id, token, reason string) (*ApprovalRequest, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	request, approver, err := q.decideLocked(id, token)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Status = ApprovalRejected
+	request.RejectedBy = approver
+	request.Reason = reason
+	request.UpdatedAt = q.now()
+	if err := q.saveLocked(id); err != nil {
+		request.Status = ApprovalPending
+		request.RejectedBy, request.Reason = "", ""
+		return nil, err
+	}
+
+	log.Printf("%s rejected %s", approver, id)
+	copied := copyApprovalRequest(request)
+	return &copied, nil
+}
+
+// decideLocked authenticates an approver and returns the pending request
+// they are deciding on; caller must hold q.mu
+func (q *ApprovalQueue) decideLocked(id, token string) (*ApprovalRequest, string, error) {
+	approver := q.approver(token)
+	if approver == "" {
+		return nil, "", ErrUnknownApprover
+	}
+	request, ok := q.requests[id]
+	if !ok {
+		return nil, "", fmt.Errorf("%w: %s", ErrApprovalNotFound, id)
+	}
+	q.expireLocked(request)
+	if request.Status != ApprovalPending {
+		return nil, "", fmt.Errorf("approval request %s is %s", id, request.Status)
+	}
+	return request, approver, nil
+}
+
+// approver returns the name of the approver a token belongs to, or "" if
+// it matches none. Every token is compared so timing reveals nothing.
+func (q *ApprovalQueue) approver(token string) string {
+	var found string
+	for name, expected := range q.config.Approvers {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1 {
+			found = name
+		}
+	}
+	return found
+}
+
+// expireLocked marks a pending request expired once its TTL has passed;
+// caller must hold q.mu
+func (q *ApprovalQueue) expireLocked(request *ApprovalRequest) {
+	if request.Status != ApprovalPending || q.now().Before(request.ExpiresAt) {
+		return
+	}
+	request.Status = ApprovalExpired
+	request.UpdatedAt = q.now()
+	if err := q.saveLocked(request.ID); err != nil {
+		log.Printf("Failed to persist approval request %s: %v", request.ID, err)
+	}
+}
+
+// execute broadcasts an approved swap and records its result. The swap
+// fails if it runs past the execute timeout or the queue is stopped.
+func (q *ApprovalQueue) execute(id string) {
+	defer q.executing.Done()
+
+	q.mu.Lock()
+	params := q.swaps[id]
+	q.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(q.ctx, q.config.ExecuteTimeout)
+	defer cancel()
+	params.approved = true
+	result := q.router.executeSwap(ctx, params, nil)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	request := q.requests[id]
+	request.Status = ApprovalExecuted
+	if !result.Success {
+		request.Status = ApprovalFailed
+	}
+	request.Result = result
+	request.UpdatedAt = q.now()
+	if err := q.saveLocked(id); err != nil {
+		log.Printf("Failed to persist approval request %s: %v", id, err)
+	}
+}
+
+// saveLocked persists a request; caller must hold q.mu
+func (q *ApprovalQueue) saveLocked(id string) error {
+	if q.store == nil {
+		return nil
+	}
+	swap := q.swaps[id]
+	return saveOrder(q.store, OrderApproval, id, storedApproval{
+		ID:         id,
+		Request:    *q.requests[id],
+		Swap:       swap,
+		Tiered:     swap.tiered,
+		Pools:      swap.pools,
+		CommitSalt: swap.commitSalt,
+	})
+}
@@ -0,0 +1,399 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// approvalTestConfig holds HBD swaps of 10000 or more for 2 of 3 approvers
+func approvalTestConfig() ApprovalConfig {
+	return ApprovalConfig{
+		Thresholds: map[string]int64{"HBD": 10000},
+		Approvers:  map[string]string{"alice": "alice-token", "bob": "bob-token", "carol": "carol-token"},
+		Required:   2,
+	}
+}
+
+func newApprovalTestQueue(t *testing.T, store OrderStore) (*ApprovalQueue, *mockDEXExecutor, *Service) {
+	executor := &mockDEXExecutor{}
+	svc := NewService(VSCConfig{DexRouterContract: "dex-router-contract"}, executor)
+	queue, err := NewApprovalQueue(svc, approvalTestConfig(), store)
+	require.NoError(t, err)
+	svc.SetApprovalQueue(queue)
+	return queue, executor, svc
+}
+
+func largeSwap() SwapParams {
+	return SwapParams{Sender: "treasury", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 50000, MaxSlippage: 50}
+}
+
+// waitForApproval waits until a request leaves the executing state
+func waitForApproval(t *testing.T, queue *ApprovalQueue, id string) *ApprovalRequest {
+	var request *ApprovalRequest
+	require.Eventually(t, func() bool {
+		var err error
+		request, err = queue.Get(id)
+		require.NoError(t, err)
+		return request.Status != ApprovalExecuting
+	}, time.Second, 5*time.Millisecond)
+	return request
+}
+
+func TestNewApprovalQueueValidation(t *testing.T) {
+	svc := NewService(VSCConfig{}, &mockDEXExecutor{})
+
+	tests := []struct {
+		name   string
+		modify func(c *ApprovalConfig)
+		errMsg string
+	}{
+		{"no approvers", func(c *ApprovalConfig) { c.Approvers = nil }, "at least one approver"},
+		{"zero required", func(c *ApprovalConfig) { c.Required = 0 }, "between 1 and 3"},
+		{"too many required", func(c *ApprovalConfig) { c.Required = 4 }, "between 1 and 3"},
+		{"empty token", func(c *ApprovalConfig) { c.Approvers["bob"] = "" }, "bob has no token"},
+		{"zero threshold", func(c *ApprovalConfig) { c.Thresholds["HIVE"] = 0 }, "threshold for HIVE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := approvalTestConfig()
+			tt.modify(&config)
+			_, err := NewApprovalQueue(svc, config, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestApprovalHoldsLargeSwaps(t *testing.T) {
+	queue, executor, svc := newApprovalTestQueue(t, nil)
+	ctx := context.Background()
+
+	// Below the threshold, and assets without one, go straight out
+	small := largeSwap()
+	small.AmountIn = 9999
+	result, err := svc.ExecuteSwap(ctx, small)
+	require.NoError(t, err)
+	assert.True(t, result.Success, result.ErrorMessage)
+
+	other := largeSwap()
+	other.AssetIn, other.AssetOut = "HIVE", "HBD"
+	result, err = svc.ExecuteSwap(ctx, other)
+	require.NoError(t, err)
+	assert.True(t, result.Success, result.ErrorMessage)
+	require.Len(t, executor.executedOperations, 2)
+
+	// At the threshold the swap is held, not broadcast
+	result, err = svc.ExecuteSwap(ctx, largeSwap())
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	require.NotEmpty(t, result.ApprovalID)
+	assert.Contains(t, result.ErrorMessage, "needs 2 approvals")
+	assert.Len(t, executor.executedOperations, 2)
+
+	request, err := queue.Get(result.ApprovalID)
+	require.NoError(t, err)
+	assert.Equal(t, ApprovalPending, request.Status)
+	assert.Equal(t, "treasury", request.Sender)
+	assert.Equal(t, int64(50000), request.AmountIn)
+	assert.Equal(t, 2, request.Required)
+	assert.Empty(t, request.Approvals)
+
+	order, err := svc.GetOrder(request.ID)
+	require.NoError(t, err)
+	assert.Equal(t, OrderApproval, order.Kind)
+
+	// Simulations show what would be broadcast without holding anything
+	simulated, err := svc.ExecuteSwap(WithSimulation(ctx), largeSwap())
+	require.NoError(t, err)
+	assert.True(t, simulated.Success, simulated.ErrorMessage)
+	assert.Empty(t, simulated.ApprovalID)
+	assert.Len(t, queue.List(""), 1)
+}
+
+func TestApprovalRetryWaitsOnHeldSwap(t *testing.T) {
+	queue, _, svc := newApprovalTestQueue(t, nil)
+
+	params := largeSwap()
+	params.IdempotencyKey = "payroll-june"
+	first, err := svc.ExecuteSwap(context.Background(), params)
+	require.NoError(t, err)
+	second, err := svc.ExecuteSwap(context.Background(), params)
+	require.NoError(t, err)
+
+	assert.Equal(t, first.ApprovalID, second.ApprovalID)
+	assert.Len(t, queue.List(""), 1)
+}
+
+func TestApprovalExecutesOnceEnoughApprove(t *testing.T) {
+	queue, executor, svc := newApprovalTestQueue(t, nil)
+
+	params := largeSwap()
+	params.IdempotencyKey = "payroll-june"
+	held, err := svc.ExecuteSwap(context.Background(), params)
+	require.NoError(t, err)
+	id := held.ApprovalID
+
+	_, err = queue.Approve(id, "nobody")
+	assert.ErrorIs(t, err, ErrUnknownApprover)
+	_, err = queue.Approve("missing", "alice-token")
+	assert.ErrorIs(t, err, ErrApprovalNotFound)
+
+	request, err := queue.Approve(id, "alice-token")
+	require.NoError(t, err)
+	assert.Equal(t, ApprovalPending, request.Status)
+	require.Len(t, request.Approvals, 1)
+	assert.Equal(t, "alice", request.Approvals[0].Approver)
+
+	_, err = queue.Approve(id, "alice-token")
+	assert.ErrorContains(t, err, "alice has already approved")
+
+	request, err = queue.Approve(id, "carol-token")
+	require.NoError(t, err)
+	assert.Equal(t, ApprovalExecuting, request.Status)
+
+	request = waitForApproval(t, queue, id)
+	assert.Equal(t, ApprovalExecuted, request.Status)
+	require.NotNil(t, request.Result)
+	assert.True(t, request.Result.Success, request.Result.ErrorMessage)
+	require.Len(t, executor.executedOperations, 1)
+	assert.Contains(t, executor.executedOperations[0], `"recipient":"treasury"`)
+
+	// A decided request takes no more approvals, and the swap is now
+	// returned to retries with its idempotency key
+	_, err = queue.Approve(id, "bob-token")
+	assert.ErrorContains(t, err, "is executed")
+	retried, err := svc.ExecuteSwap(context.Background(), params)
+	require.NoError(t, err)
+	assert.True(t, retried.Success, retried.ErrorMessage)
+	assert.Len(t, executor.executedOperations, 1)
+}
+
+func TestApprovalRejectAndExpiry(t *testing.T) {
+	queue, executor, svc := newApprovalTestQueue(t, nil)
+	clock := newTestClock()
+	queue.now = clock.now
+
+	rejected, err := svc.ExecuteSwap(context.Background(), largeSwap())
+	require.NoError(t, err)
+	_, err = queue.Approve(rejected.ApprovalID, "alice-token")
+	require.NoError(t, err)
+
+	request, err := queue.Reject(rejected.ApprovalID, "bob-token", "not budgeted")
+	require.NoError(t, err)
+	assert.Equal(t, ApprovalRejected, request.Status)
+	assert.Equal(t, "bob", request.RejectedBy)
+	assert.Equal(t, "not budgeted", request.Reason)
+
+	_, err = queue.Approve(rejected.ApprovalID, "carol-token")
+	assert.ErrorContains(t, err, "is rejected")
+
+	expiring, err := svc.ExecuteSwap(context.Background(), largeSwap())
+	require.NoError(t, err)
+	clock.advance(defaultApprovalTTL)
+
+	request, err = queue.Get(expiring.ApprovalID)
+	require.NoError(t, err)
+	assert.Equal(t, ApprovalExpired, request.Status)
+	_, err = queue.Approve(expiring.ApprovalID, "alice-token")
+	assert.ErrorContains(t, err, "is expired")
+
+	assert.Len(t, queue.List(ApprovalRejected), 1)
+	assert.Len(t, queue.List(ApprovalExpired), 1)
+	assert.Empty(t, queue.List(ApprovalPending))
+	assert.Empty(t, executor.executedOperations)
+}
+
+func TestApprovalsSurviveRestart(t *testing.T) {
+	store, err := NewFileOrderStore(t.TempDir())
+	require.NoError(t, err)
+	queue, _, svc := newApprovalTestQueue(t, store)
+
+	pending, err := svc.ExecuteSwap(context.Background(), largeSwap())
+	require.NoError(t, err)
+	_, err = queue.Approve(pending.ApprovalID, "alice-token")
+	require.NoError(t, err)
+
+	// A request that was executing when the router stopped is not retried
+	interrupted, err := queue.hold(largeSwap())
+	require.NoError(t, err)
+	queue.mu.Lock()
+	queue.requests[interrupted.ID].Status = ApprovalExecuting
+	require.NoError(t, queue.saveLocked(interrupted.ID))
+	queue.mu.Unlock()
+
+	restored, executor, _ := newApprovalTestQueue(t, store)
+	request, err := restored.Get(pending.ApprovalID)
+	require.NoError(t, err)
+	assert.Equal(t, ApprovalPending, request.Status)
+	require.Len(t, request.Approvals, 1)
+	assert.Equal(t, "alice", request.Approvals[0].Approver)
+
+	request, err = restored.Get(interrupted.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ApprovalFailed, request.Status)
+	assert.Contains(t, request.Result.ErrorMessage, "router stopped")
+
+	// The restored swap goes out once a second approver signs off
+	_, err = restored.Approve(pending.ApprovalID, "bob-token")
+	require.NoError(t, err)
+	request = waitForApproval(t, restored, pending.ApprovalID)
+	assert.Equal(t, ApprovalExecuted, request.Status)
+	assert.Len(t, executor.executedOperations, 1)
+}
+
+func TestApprovalsRestoreSwapRoute(t *testing.T) {
+	store, err := NewFileOrderStore(t.TempDir())
+	require.NoError(t, err)
+	queue, _, _ := newApprovalTestQueue(t, store)
+
+	swap := largeSwap()
+	swap.tiered = true
+	swap.pools = []string{"pool-1", "pool-2"}
+	swap.commitSalt = "salt"
+	held, err := queue.hold(swap)
+	require.NoError(t, err)
+
+	restored, _, _ := newApprovalTestQueue(t, store)
+	restored.mu.Lock()
+	defer restored.mu.Unlock()
+	assert.True(t, restored.swaps[held.ID].tiered)
+	assert.Equal(t, []string{"pool-1", "pool-2"}, restored.swaps[held.ID].pools)
+	assert.Equal(t, "salt", restored.swaps[held.ID].commitSalt)
+}
+
+// stalledExecutor holds every operation until its context ends
+type stalledExecutor struct {
+	mockDEXExecutor
+}
+
+func (m *stalledExecutor) ExecuteDexOperationWithIntents(ctx context.Context, operationType string, payload string, intents []Intent) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestApprovalExecutionTimesOutOrStops(t *testing.T) {
+	newQueue := func(timeout time.Duration) *ApprovalQueue {
+		svc := NewService(VSCConfig{DexRouterContract: "dex-router-contract"}, &stalledExecutor{})
+		config := approvalTestConfig()
+		config.ExecuteTimeout = timeout
+		queue, err := NewApprovalQueue(svc, config, nil)
+		require.NoError(t, err)
+		svc.SetApprovalQueue(queue)
+		return queue
+	}
+	approve := func(queue *ApprovalQueue) string {
+		held, err := queue.router.ExecuteSwap(context.Background(), largeSwap())
+		require.NoError(t, err)
+		for _, token := range []string{"alice-token", "bob-token"} {
+			_, err = queue.Approve(held.ApprovalID, token)
+			require.NoError(t, err)
+		}
+		return held.ApprovalID
+	}
+
+	// A swap that outlasts the execute timeout fails
+	queue := newQueue(10 * time.Millisecond)
+	request := waitForApproval(t, queue, approve(queue))
+	assert.Equal(t, ApprovalFailed, request.Status)
+	assert.Contains(t, request.Result.ErrorMessage, context.DeadlineExceeded.Error())
+
+	// Stopping the queue cancels a swap still broadcasting and waits for it
+	// to record its result
+	queue = newQueue(time.Hour)
+	id := approve(queue)
+	queue.Stop()
+	request, err := queue.Get(id)
+	require.NoError(t, err)
+	assert.Equal(t, ApprovalFailed, request.Status)
+	assert.Contains(t, request.Result.ErrorMessage, context.Canceled.Error())
+}
+
+func TestBatchRefusesSwapsNeedingApproval(t *testing.T) {
+	executor := &mockBatchExecutor{}
+	svc := NewService(VSCConfig{DexRouterContract: "dex-router-contract"}, executor)
+	queue, err := NewApprovalQueue(svc, approvalTestConfig(), nil)
+	require.NoError(t, err)
+	svc.SetApprovalQueue(queue)
+
+	swap := largeSwap()
+	result, err := svc.ExecuteBatch(context.Background(), BatchParams{
+		Sender:     "treasury",
+		Operations: []BatchOperation{{Swap: &swap}},
+	})
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.ErrorMessage, "operation 0: swaps that need approval cannot be batched")
+	assert.Empty(t, executor.batches)
+}
+
+func TestApprovalEndpoints(t *testing.T) {
+	queue, executor, svc := newApprovalTestQueue(t, nil)
+	server := NewServer(svc, "0")
+
+	approve := func(path, token, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		server.http.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// Held swaps are accepted, pointing at their approval request
+	w := serveTestRequest(server, http.MethodPost, "/api/v1/swap",
+		`{"fromAsset":"HBD","toAsset":"HIVE","amount":50000,"sender":"treasury"}`)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	var held SwapResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &held))
+	require.NotEmpty(t, held.ApprovalID)
+	assert.Equal(t, "/api/v1/approvals/"+held.ApprovalID, w.Header().Get("Location"))
+
+	w = serveTestRequest(server, http.MethodGet, "/api/v1/approvals?status=pending", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var listed struct {
+		Approvals []ApprovalRequest `json:"approvals"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	require.Len(t, listed.Approvals, 1)
+	assert.Equal(t, held.ApprovalID, listed.Approvals[0].ID)
+
+	path := "/api/v1/approvals/" + held.ApprovalID
+	assert.Equal(t, http.StatusOK, serveTestRequest(server, http.MethodGet, path, "").Code)
+	assert.Equal(t, http.StatusNotFound, serveTestRequest(server, http.MethodGet, "/api/v1/approvals/missing", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, approve(path+"/approve", "", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, approve(path+"/approve", "wrong", "").Code)
+
+	w = approve(path+"/approve", "alice-token", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusConflict, approve(path+"/approve", "alice-token", "").Code)
+	w = approve(path+"/approve", "bob-token", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, ApprovalExecuted, waitForApproval(t, queue, held.ApprovalID).Status)
+	assert.Len(t, executor.executedOperations, 1)
+
+	// A rejection carries its reason
+	w = serveTestRequest(server, http.MethodPost, "/api/v1/swap",
+		`{"fromAsset":"HBD","toAsset":"HIVE","amount":20000,"sender":"treasury"}`)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &held))
+	w = approve("/api/v1/approvals/"+held.ApprovalID+"/reject", "carol-token", `{"reason":"too large"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var rejected ApprovalRequest
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rejected))
+	assert.Equal(t, ApprovalRejected, rejected.Status)
+	assert.Equal(t, "too large", rejected.Reason)
+
+	// Without a queue the endpoints are unavailable
+	plain := NewServer(NewService(VSCConfig{}, &mockDEXExecutor{}), "0")
+	assert.Equal(t, http.StatusServiceUnavailable, serveTestRequest(plain, http.MethodGet, "/api/v1/approvals", "").Code)
+}
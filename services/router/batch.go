@@ -0,0 +1,305 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/vsc-eco/vsc-dex-mapping/services/router/intents"
+)
+
+// maxBatchOperations bounds how many operations one transaction may carry
+const maxBatchOperations = 16
+
+// DexOperation is one contract call within a batched transaction
+type DexOperation struct {
+	OperationType string   `json:"operationType"`
+	Payload       string   `json:"payload"`
+	Intents       []Intent `json:"intents"`
+}
+
+// BatchExecutor is implemented by executors that can submit several contract
+// calls as a single VSC transaction, returning its ID
+type BatchExecutor interface {
+	ExecuteDexBatch(ctx context.Context, operations []DexOperation) (string, error)
+}
+
+// BatchOperation is a swap, deposit or withdrawal within a batch. Exactly
+// one field is set.
+type BatchOperation struct {
+	Swap       *SwapParams
+	Deposit    *DepositParams
+	Withdrawal *WithdrawalParams
+}
+
+// kinds returns how many operation fields are set
+func (op BatchOperation) kinds() int {
+	n := 0
+	if op.Swap != nil {
+		n++
+	}
+	if op.Deposit != nil {
+		n++
+	}
+	if op.Withdrawal != nil {
+		n++
+	}
+	return n
+}
+
+// BatchParams represents a batch of operations submitted by one account
+type BatchParams struct {
+	Sender     string
+	Operations []BatchOperation // Executed in order
+}
+
+// BatchResult represents the outcome of a batched transaction
+type BatchResult struct {
+	Success      bool
+	TxID         string
+	Results      []*SwapResult // One per operation, in order
+	Intents      []Intent      // Total allowance the batch may draw, per token
+	ErrorMessage string
+	Simulation   *Simulation `json:",omitempty"` // Set instead of TxID when simulating
+	ReceiptID    string      `json:",omitempty"` // Set when a receipt store is configured
+}
+
+// ExecuteBatch composes swaps, deposits and withdrawals into a single transaction, so a
+// rebalance costs one broadcast instead of one per operation. The operations
+// succeed or fail together.
+func (s *Service) ExecuteBatch(ctx context.Context, params BatchParams) (*BatchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return &BatchResult{
+			Success:      false,
+			ErrorMessage: fmt.Sprintf("batch cancelled: %v", err),
+		}, nil
+	}
+
+	batcher, ok := s.dexExecutor.(BatchExecutor)
+	if !ok {
+		return &BatchResult{
+			Success:      false,
+			ErrorMessage: "executor does not support batched transactions",
+		}, nil
+	}
+
+	operations, results, deadline, risks, err := s.composeBatch(ctx, params)
+	if err != nil {
+		return &BatchResult{
+			Success:      false,
+			ErrorMessage: err.Error(),
+		}, nil
+	}
+
+	var operationIntents []Intent
+	for _, op := range operations {
+		operationIntents = append(operationIntents, op.Intents...)
+	}
+
+	// The whole batch is bound by its earliest swap deadline
+	submitCtx := ctx
+	if !deadline.IsZero() {
+		if !time.Now().Before(deadline) {
+			s.releaseRisk(risks)
+			return &BatchResult{
+				Success:      false,
+				ErrorMessage: fmt.Sprintf("batch deadline %s has passed", deadline.UTC().Format(time.RFC3339)),
+			}, nil
+		}
+		var cancel context.CancelFunc
+		submitCtx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+
+	if s.simulating(ctx) {
+		// The operations share one transaction, so each result carries it all
+		simulation := s.simulation(operations...)
+		for _, result := range results {
+			result.Success = true
+			result.Simulation = simulation
+		}
+		return &BatchResult{
+			Success:    true,
+			Results:    results,
+			Intents:    intents.Combine(operationIntents),
+			Simulation: simulation,
+		}, nil
+	}
+
+	submittedAt := time.Now()
+	var txID string
+	err = s.inTurn(submitCtx, params.Sender, func() (err error) {
+		txID, err = batcher.ExecuteDexBatch(submitCtx, operations)
+		return err
+	})
+	if err != nil {
+		s.releaseRisk(risks)
+		result := &BatchResult{
+			Success:      false,
+			ErrorMessage: fmt.Sprintf("batch execution failed: %v", err),
+		}
+		s.recordBatchExecution(params.Sender, operations, submittedAt, result)
+		return result, nil
+	}
+
+	for i, result := range results {
+		result.Success = true
+		result.TxID = txID
+		if len(result.HopFees) > 0 {
+			s.invalidateRoute(&Quote{Hops: result.HopFees})
+		}
+		if swap := params.Operations[i].Swap; swap != nil {
+			counted := *swap
+			counted.Sender = params.Sender
+			s.recordVolume(counted, result)
+		}
+	}
+	result := &BatchResult{
+		Success: true,
+		TxID:    txID,
+		Results: results,
+		Intents: intents.Combine(operationIntents),
+	}
+	s.recordBatchExecution(params.Sender, operations, submittedAt, result)
+	return result, nil
+}
+
+// composeBatch builds the contract call for each operation, with a preview
+// result for each, and returns the earliest swap deadline. The swaps' risk
+// assessments are returned to be released if the batch is not broadcast.
+func (s *Service) composeBatch(ctx context.Context, params BatchParams) (operations []DexOperation, results []*SwapResult, deadline time.Time, risks []*riskAssessment, err error) {
+	if len(params.Operations) == 0 {
+		return nil, nil, time.Time{}, nil, fmt.Errorf("batch has no operations")
+	}
+	if len(params.Operations) > maxBatchOperations {
+		return nil, nil, time.Time{}, nil, fmt.Errorf("batch has %d operations, at most %d are allowed", len(params.Operations), maxBatchOperations)
+	}
+	defer func() {
+		if err != nil {
+			s.releaseRisk(risks)
+		}
+	}()
+
+	operations = make([]DexOperation, 0, len(params.Operations))
+	results = make([]*SwapResult, 0, len(params.Operations))
+
+	for i, op := range params.Operations {
+		var (
+			payload   string
+			opIntents []Intent
+			result    *SwapResult
+		)
+
+		switch {
+		case op.kinds() != 1:
+			return nil, nil, time.Time{}, risks, fmt.Errorf("operation %d must be exactly one of a swap, deposit or withdrawal", i)
+
+		case op.Swap != nil:
+			swap := *op.Swap
+			swap.Sender = params.Sender
+			if swap.AssetIn == swap.AssetOut {
+				return nil, nil, time.Time{}, risks, fmt.Errorf("operation %d: cannot swap asset to itself", i)
+			}
+			if swap.AmountIn <= 0 {
+				return nil, nil, time.Time{}, risks, fmt.Errorf("operation %d: amount in must be greater than 0", i)
+			}
+			if err := s.acceptSwap(swap); err != nil {
+				return nil, nil, time.Time{}, risks, fmt.Errorf("operation %d: %w", i, err)
+			}
+			if swap.CommitReveal {
+				// A batch is one transaction, so it cannot be committed to first
+				return nil, nil, time.Time{}, risks, fmt.Errorf("operation %d: commit-reveal is not supported in batches", i)
+			}
+			if swap.ReturnAddress != nil {
+				// The withdrawal can only follow once the batch is included
+				return nil, nil, time.Time{}, risks, fmt.Errorf("operation %d: return addresses are not supported in batches", i)
+			}
+			if s.approvals.required(swap) && !s.simulating(ctx) {
+				return nil, nil, time.Time{}, risks, fmt.Errorf("operation %d: swaps that need approval cannot be batched", i)
+			}
+			if !swap.Deadline.IsZero() && (deadline.IsZero() || swap.Deadline.Before(deadline)) {
+				deadline = swap.Deadline
+			}
+
+			if swap.Quote != nil {
+				if qerr := s.checkQuote(ctx, &swap); qerr != nil {
+					return nil, nil, time.Time{}, risks, fmt.Errorf("operation %d: %w", i, qerr)
+				}
+			}
+
+			var quote *Quote
+			if s.poolQuerier != nil {
+				if q, qerr := s.quote(ctx, swap, 0); qerr == nil {
+					quote = q
+				} else {
+					log.Printf("Batch swap %d preview unavailable: %v", i, qerr)
+				}
+			}
+			if quote != nil && len(swap.pools) == 0 {
+				swap.pools = quote.pools()
+			}
+
+			swap = s.applyFeeTier(swap)
+			payload, opIntents, err = swapOperation(swap)
+			result = unsettledResult(swap, quote)
+			if s.risk != nil {
+				risk, rerr := s.risk.assess(swap, quote, !s.simulating(ctx))
+				if rerr != nil {
+					return nil, nil, time.Time{}, risks, fmt.Errorf("operation %d: %w", i, rerr)
+				}
+				risks = append(risks, risk)
+				result.Warnings = risk.warnings
+			}
+
+		case op.Deposit != nil:
+			deposit := *op.Deposit
+			deposit.Sender = params.Sender
+			if deposit.AmountIn <= 0 {
+				return nil, nil, time.Time{}, risks, fmt.Errorf("operation %d: amount in must be greater than 0", i)
+			}
+			if err := s.checkAssets(deposit.AssetIn, deposit.AssetOut); err != nil {
+				return nil, nil, time.Time{}, risks, fmt.Errorf("operation %d: %w", i, err)
+			}
+			if deposit.AmountOut < 0 {
+				return nil, nil, time.Time{}, risks, fmt.Errorf("operation %d: amount out must not be negative", i)
+			}
+
+			payload, opIntents, err = depositOperation(deposit, s.depositPool(deposit))
+			result = &SwapResult{Route: []string{"deposit"}}
+
+		case op.Withdrawal != nil:
+			withdrawal := *op.Withdrawal
+			withdrawal.Sender = params.Sender
+			plan, perr := s.planWithdrawal(withdrawal)
+			if perr != nil {
+				return nil, nil, time.Time{}, risks, fmt.Errorf("operation %d: failed to size withdrawal: %w", i, perr)
+			}
+
+			payload, opIntents, err = withdrawalOperation(withdrawal, plan)
+			result = &SwapResult{Route: []string{"withdrawal"}}
+		}
+
+		if err != nil {
+			return nil, nil, time.Time{}, risks, fmt.Errorf("operation %d: failed to build operation: %w", i, err)
+		}
+		operations = append(operations, DexOperation{
+			OperationType: "execute",
+			Payload:       payload,
+			Intents:       opIntents,
+		})
+		results = append(results, result)
+	}
+
+	return operations, results, deadline, risks, nil
+}
+
+// releaseRisk releases the risk assessments of swaps that were not broadcast
+func (s *Service) releaseRisk(risks []*riskAssessment) {
+	if s.risk == nil {
+		return
+	}
+	for _, risk := range risks {
+		s.risk.release(risk)
+	}
+}
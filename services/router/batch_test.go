@@ -0,0 +1,148 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockBatchExecutor records batched transactions
+type mockBatchExecutor struct {
+	mockDEXExecutor
+	batches [][]DexOperation
+	err     error
+}
+
+func (m *mockBatchExecutor) ExecuteDexBatch(ctx context.Context, operations []DexOperation) (string, error) {
+	if m.err != nil {
+		return "", m.err
+	}
+	m.batches = append(m.batches, operations)
+	return fmt.Sprintf("batch-tx-%d", len(m.batches)), nil
+}
+
+func TestExecuteBatch(t *testing.T) {
+	executor := &mockBatchExecutor{}
+	svc := NewService(VSCConfig{DexRouterContract: "dex-router-contract"}, executor)
+
+	result, err := svc.ExecuteBatch(context.Background(), BatchParams{
+		Sender: "alice",
+		Operations: []BatchOperation{
+			{Swap: &SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000, MinAmountOut: 900}},
+			{Swap: &SwapParams{AssetIn: "HBD", AssetOut: "BTC", AmountIn: 500}},
+			{Deposit: &DepositParams{AssetIn: "HIVE", AssetOut: "HBD", AmountIn: 900}},
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, result.Success, result.ErrorMessage)
+	assert.Equal(t, "batch-tx-1", result.TxID)
+
+	// All three operations go out in one transaction, in order
+	require.Len(t, executor.batches, 1)
+	require.Len(t, executor.batches[0], 3)
+	assert.Empty(t, executor.executedOperations, "nothing should be submitted individually")
+
+	for i, wantType := range []string{"swap", "swap", "deposit"} {
+		var instruction map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(executor.batches[0][i].Payload), &instruction))
+		assert.Equal(t, wantType, instruction["type"])
+		assert.Equal(t, "alice", instruction["recipient"])
+		assert.Equal(t, "execute", executor.batches[0][i].OperationType)
+	}
+
+	// Each operation keeps its own allowance; the result reports the totals
+	assert.Equal(t, "1000", executor.batches[0][0].Intents[0].Args["limit"])
+	assert.Equal(t, []Intent{
+		{Type: "transfer.allow", Args: map[string]string{"limit": "1500", "token": "HBD"}},
+		{Type: "transfer.allow", Args: map[string]string{"limit": "900", "token": "HIVE"}},
+	}, result.Intents)
+
+	require.Len(t, result.Results, 3)
+	for _, r := range result.Results {
+		assert.True(t, r.Success)
+		assert.Equal(t, "batch-tx-1", r.TxID)
+	}
+	assert.Equal(t, int64(900), result.Results[0].AmountOut)
+	assert.Equal(t, []string{"deposit"}, result.Results[2].Route)
+}
+
+func TestExecuteBatchValidation(t *testing.T) {
+	swap := &SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000}
+	tooMany := make([]BatchOperation, maxBatchOperations+1)
+	for i := range tooMany {
+		tooMany[i] = BatchOperation{Swap: swap}
+	}
+
+	tests := []struct {
+		name       string
+		operations []BatchOperation
+		errMsg     string
+	}{
+		{"empty", nil, "batch has no operations"},
+		{"too many", tooMany, "at most 16 are allowed"},
+		{"neither", []BatchOperation{{}}, "operation 0 must be exactly one of a swap, deposit or withdrawal"},
+		{"both", []BatchOperation{{Swap: swap, Deposit: &DepositParams{AmountIn: 1}}}, "exactly one of"},
+		{"same asset", []BatchOperation{{Swap: swap}, {Swap: &SwapParams{AssetIn: "HBD", AssetOut: "HBD", AmountIn: 1}}}, "operation 1: cannot swap asset to itself"},
+		{"zero deposit", []BatchOperation{{Deposit: &DepositParams{AssetIn: "HBD", AssetOut: "HIVE"}}}, "operation 0: amount in must be greater than 0"},
+		{"expired", []BatchOperation{{Swap: &SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1, Deadline: time.Now().Add(-time.Minute)}}}, "batch deadline"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &mockBatchExecutor{}
+			svc := NewService(VSCConfig{}, executor)
+
+			result, err := svc.ExecuteBatch(context.Background(), BatchParams{Sender: "alice", Operations: tt.operations})
+			require.NoError(t, err)
+			assert.False(t, result.Success)
+			assert.Contains(t, result.ErrorMessage, tt.errMsg)
+			assert.Empty(t, executor.batches)
+		})
+	}
+}
+
+func TestExecuteBatchRequiresBatchExecutor(t *testing.T) {
+	executor := &mockDEXExecutor{}
+	svc := NewService(VSCConfig{}, executor)
+
+	result, err := svc.ExecuteBatch(context.Background(), BatchParams{
+		Sender:     "alice",
+		Operations: []BatchOperation{{Swap: &SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000}}},
+	})
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.ErrorMessage, "does not support batched transactions")
+	assert.Empty(t, executor.executedOperations)
+}
+
+func TestHandleExecuteBatch(t *testing.T) {
+	executor := &mockBatchExecutor{}
+	server := NewServer(NewService(VSCConfig{}, executor), "0")
+
+	body := `{"sender":"alice","operations":[
+		{"type":"swap","fromAsset":"HBD","toAsset":"HIVE","amount":1000},
+		{"type":"deposit","fromAsset":"HIVE","toAsset":"HBD","amount":900}
+	]}`
+	w := httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/batch", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var result BatchResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.True(t, result.Success)
+	require.Len(t, executor.batches, 1)
+	assert.Contains(t, executor.batches[0][0].Payload, `"slippage_bps":50`)
+
+	body = `{"sender":"alice","operations":[{"type":"transfer","fromAsset":"HBD","toAsset":"HIVE","amount":1}]}`
+	w = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/batch", strings.NewReader(body)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -3,10 +3,14 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -22,7 +26,7 @@ func (m *mockDEXExecutor) ExecuteDexOperation(ctx context.Context, operationType
 	return nil
 }
 
-func (m *mockDEXExecutor) ExecuteDexOperationWithIntents(ctx context.Context, operationType string, payload string, intents []Intent) error {
+func (m *mockDEXExecutor) ExecuteDexOperationWithIntents(ctx context.Context, operationType string, payload string, intents []router.Intent) error {
 	log.Printf("Mock DEXExecutor: Executing %s with payload %s and %d intents", operationType, payload, len(intents))
 	for i, intent := range intents {
 		log.Printf("  Intent %d: %s with args %v", i, intent.Type, intent.Args)
@@ -35,14 +39,117 @@ func (m *mockDEXExecutor) ExecuteDexSwap(ctx context.Context, amountOut int64, r
 	return nil
 }
 
+func (m *mockDEXExecutor) ExecuteDexBatch(ctx context.Context, operations []router.DexOperation) (string, error) {
+	log.Printf("Mock DEXExecutor: Executing batch of %d operations", len(operations))
+	for i, op := range operations {
+		log.Printf("  Operation %d: %s with payload %s and %d intents", i, op.OperationType, op.Payload, len(op.Intents))
+	}
+	return "", nil
+}
+
+// splitList splits a comma-separated flag, dropping empty entries
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// poolStreamEndpoint returns the indexer the pool graph streams from, or
+// none when there are several: a stream from one indexer would bypass the
+// others' agreement
+func poolStreamEndpoint(endpoints []string) string {
+	if len(endpoints) != 1 {
+		if len(endpoints) > 1 {
+			log.Printf("Pool stream paused: pools are checked across %d indexers", len(endpoints))
+		}
+		return ""
+	}
+	return endpoints[0]
+}
+
+// crossCheckPools logs every pool on which the indexer disagrees with the
+// contract state on the VSC node, each interval until ctx is done
+func crossCheckPools(ctx context.Context, indexer, node router.PoolLister, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			discrepancies, err := router.CrossCheckPools(indexer, node)
+			if err != nil {
+				log.Printf("Pool cross-check failed: %v", err)
+				continue
+			}
+			for _, d := range discrepancies {
+				log.Printf("Pool cross-check: %s", d)
+			}
+		}
+	}
+}
+
 func main() {
 	var (
 		vscNode         = flag.String("vsc-node", "http://localhost:4000", "VSC node GraphQL endpoint")
 		vscKey          = flag.String("vsc-key", "", "VSC active key for transactions")
 		vscUsername     = flag.String("vsc-username", "", "VSC username")
 		port            = flag.String("port", "8080", "HTTP server port")
-		indexerEndpoint = flag.String("indexer-endpoint", "http://localhost:8081", "Indexer service HTTP endpoint")
+		grpcPort        = flag.String("grpc-port", "", "gRPC server port; empty disables gRPC")
+		indexerEndpoint = flag.String("indexer-endpoint", "http://localhost:8081", "Indexer service HTTP endpoint; several comma-separated endpoints are queried together and must agree on pools")
 		dexRouter       = flag.String("dex-router-contract", "", "DEX router contract ID")
+		outcomeTimeout  = flag.Duration("swap-outcome-timeout", 30*time.Second, "How long to wait for a submitted swap to be indexed")
+		commitDelay     = flag.Duration("commit-reveal-delay", 30*time.Second, "How long commit-reveal swaps wait between commitment and reveal when inclusion cannot be confirmed")
+		idempotencyTTL  = flag.Duration("idempotency-ttl", 24*time.Hour, "How long a swap's result is returned to retries with the same idempotency key")
+		accountParallel = flag.Int("account-parallelism", 0, "Most accounts that may broadcast at once; each account's transactions always go out one at a time, in order (0 is unlimited)")
+		simulate        = flag.Bool("simulate", false, "Build every operation but never broadcast; responses show what would have been sent")
+		orderStore      = flag.String("order-store", ".", "Where DCA, trigger, TWAP and approval orders are persisted: a directory, sqlite://<file> or a postgres:// URL; empty keeps them in memory")
+		triggerInterval = flag.Duration("trigger-check-interval", 5*time.Second, "How often trigger orders are checked against current prices")
+		referralStore   = flag.String("referral-store", "referrers.json", "File referrers and their default referral fees are persisted to; empty disables referrals")
+		volumeStore     = flag.String("volume-store", "volumes.json", "File each account's cumulative swap volume is persisted to; empty disables volume tracking and fee tiers")
+		feeTiers        = flag.String("fee-tiers", "", "Comma-separated name=minVolume:rebateBps fee tiers, such as silver=100000:2500, rebating that share of the referral fee once an account's HBD-valued volume reaches minVolume")
+		receiptStore    = flag.String("receipt-store", "receipts.jsonl", "File receipts of every broadcast operation are appended to; empty disables receipts")
+		adminToken      = flag.String("admin-token", "", "Bearer token for admin endpoints such as referrer management; empty disables them")
+		configFile      = flag.String("config", "", "JSON file of allowedAssets, maxSlippageBps and indexerEndpoints overriding their flags, reloaded with referrers on SIGHUP or POST /api/v1/admin/reload")
+		allowedAssets   = flag.String("allowed-assets", "", "Comma-separated assets swaps, quotes, orders and deposits may start or end in; empty allows every asset")
+		maxSlippage     = flag.Uint64("max-slippage-bps", 0, "Most slippage a swap or order may allow, in basis points (0 sets no cap)")
+		poolSource      = flag.String("pool-source", "indexer", "Where pools are read from: indexer, or vsc to read the DEX router contract's state from --vsc-node")
+		crossCheck      = flag.Duration("pool-cross-check-interval", 0, "How often to compare the indexer's pools with the contract state on --vsc-node (0 disables)")
+		poolStream      = flag.Bool("pool-stream", true, "Keep an in-memory pool graph updated from the indexer's pool stream")
+		poolCacheTTL    = flag.Duration("pool-cache-ttl", 2*time.Second, "How long pool data is cached for routing (0 disables)")
+		indexerCacheTTL = flag.Duration("indexer-cache-ttl", time.Second, "How long the indexer's pool list is reused before revalidating it (0 revalidates every lookup)")
+		indexerQuorum   = flag.Int("indexer-quorum", 0, "How many indexers must agree on a pool when several are configured (0 is a majority)")
+		indexerTolerBps = flag.Uint64("indexer-tolerance-bps", 50, "How far indexers' reserves may differ, in basis points, and still agree")
+		poolCacheBps    = flag.Uint64("pool-cache-threshold-bps", 50, "Reserve change in basis points that invalidates cached pools")
+		maxHops         = flag.Int("max-hops", 2, "Most pools a route may pass through")
+		maxCandidates   = flag.Int("max-route-candidates", 32, "Most routes compared per quote")
+		maxSplitLegs    = flag.Int("max-split-legs", 1, "Most routes a quote may split a swap across; swaps always execute one route (1 disables splitting)")
+		minPoolReserve  = flag.Uint64("min-pool-reserve", 0, "Pools holding less than this of either asset are never routed through (0 disables)")
+		maxReserveUsage = flag.Uint64("max-reserve-usage-bps", 5000, "Most of a pool's input reserve one hop may add, in basis points (0 disables)")
+		routeTxCost     = flag.Int64("route-tx-cost", 0, "Estimated resource credit cost of broadcasting a swap, valued in HBD; routes are compared net of it")
+		routeHopCost    = flag.Int64("route-hop-cost", 0, "Estimated extra resource credit cost of each pool a route passes through, valued in HBD")
+		riskDeviation   = flag.Uint64("risk-max-deviation-bps", 0, "Reject swaps quoted from a pool whose price strays this far from its TWAP, in basis points (0 disables)")
+		riskTWAPWindow  = flag.Duration("risk-twap-window", 30*time.Minute, "Period pool prices are averaged over for the risk check")
+		riskSampling    = flag.Duration("risk-price-sample-interval", 15*time.Second, "How often pool prices are sampled for TWAPs")
+		riskNotional    = flag.Int64("risk-max-notional", 0, "Most each account may swap per --risk-notional-window, valued in HBD (0 disables)")
+		riskAccounts    = flag.String("risk-account-notional", "", "Per-account notional caps overriding --risk-max-notional, as account=amount pairs separated by commas (0 exempts)")
+		riskWindow      = flag.Duration("risk-notional-window", 24*time.Hour, "Rolling period notional caps cover")
+		riskWarnOnly    = flag.Bool("risk-warn-only", false, "Return failed risk checks as warnings instead of rejecting swaps")
+		metrics         = flag.Bool("metrics", true, "Serve Prometheus metrics at /metrics")
+		quoteRate       = flag.Float64("rate-limit-quotes", 0, "Quotes each API key, or account or client address without one, may request per second (0 is unlimited)")
+		quoteBurst      = flag.Int("rate-limit-quote-burst", 0, "Quotes a caller may request at once before --rate-limit-quotes applies (0 is one second's worth)")
+		executionRate   = flag.Float64("rate-limit-executions", 0, "Swaps, deposits, withdrawals and orders each API key, or account or client address without one, may submit per second (0 is unlimited)")
+		executionBurst  = flag.Int("rate-limit-execution-burst", 0, "Executions a caller may submit at once before --rate-limit-executions applies (0 is one second's worth)")
+		approvalLimits  = flag.String("approval-thresholds", "", "Swaps of at least this much of an asset are held for approval, as asset=amount pairs separated by commas, e.g. HBD=100000; empty disables approvals")
+		approvers       = flag.String("approvers", "", "Who may approve held swaps, as name=token pairs separated by commas; approvers send their token as a bearer token")
+		approvalsNeeded = flag.Int("approvals-required", 1, "How many approvers must approve a held swap")
+		approvalTTL     = flag.Duration("approval-ttl", 24*time.Hour, "How long a held swap waits for approval before it expires")
+		approvalTimeout = flag.Duration("approval-execute-timeout", 5*time.Minute, "How long an approved swap may take to broadcast before it fails")
 	)
 	flag.Parse()
 
@@ -67,17 +174,270 @@ func main() {
 	mockExecutor := &mockDEXExecutor{}
 
 	svc := router.NewService(config, mockExecutor)
+	if err := svc.SetRoutingConfig(router.RoutingConfig{
+		MaxHops:            *maxHops,
+		MaxRouteCandidates: *maxCandidates,
+		MaxSplitLegs:       *maxSplitLegs,
+		MinPoolReserve:     *minPoolReserve,
+		MaxReserveUsageBps: *maxReserveUsage,
+		TxCost:             *routeTxCost,
+		HopCost:            *routeHopCost,
+	}); err != nil {
+		log.Fatalf("Invalid routing limits: %v", err)
+	}
+	svc.SetCommitRevealDelay(*commitDelay)
+	svc.SetIdempotencyTTL(*idempotencyTTL)
+	svc.SetAccountParallelism(*accountParallel)
+	if *simulate {
+		svc.SetSimulate(true)
+		log.Printf("Simulation mode: operations will not be broadcast")
+	}
+
+	streamCtx, stopStream := context.WithCancel(context.Background())
+	defer stopStream()
+
+	// The first indexer serves outcomes and positions; pools are read from
+	// every one of them. The endpoints can be changed by a config reload.
+	liveConfig := router.LiveConfig{
+		AllowedAssets:    splitList(*allowedAssets),
+		MaxSlippageBps:   *maxSlippage,
+		IndexerEndpoints: splitList(*indexerEndpoint),
+	}
+	var indexers *router.IndexerSet
+	if len(liveConfig.IndexerEndpoints) > 0 {
+		var err error
+		indexers, err = router.NewIndexerSet(liveConfig.IndexerEndpoints, *indexerCacheTTL, router.QuorumConfig{
+			Quorum:       *indexerQuorum,
+			ToleranceBps: *indexerTolerBps,
+		})
+		if err != nil {
+			log.Fatalf("Invalid indexer quorum: %v", err)
+		}
+		svc.SetOutcomeSource(indexers, *outcomeTimeout)
+	}
+
+	var (
+		poolQuerier router.PoolQuerier
+		graph       *router.PoolGraph
+	)
+	switch *poolSource {
+	case "vsc":
+		// Read pools straight from the contract, without an indexer
+		if *dexRouter == "" {
+			log.Fatalf("--pool-source=vsc requires --dex-router-contract")
+		}
+		nodeQuerier := router.NewVSCPoolQuerier(*vscNode, *dexRouter)
+		poolQuerier = nodeQuerier
+		if *poolCacheTTL > 0 {
+			poolQuerier = router.NewCachingPoolQuerier(poolQuerier, *poolCacheTTL, *poolCacheBps)
+		}
+		svc.SetPositionQuerier(nodeQuerier)
+		log.Printf("Router reading pools from contract %s on %s", *dexRouter, *vscNode)
+	case "indexer":
+		// Connect router to indexer for real-time pool data
+		if indexers == nil {
+			log.Printf("Warning: No indexer endpoint provided, router will use hardcoded fallback pools")
+			break
+		}
+		poolQuerier = indexers
+		if *poolCacheTTL > 0 {
+			poolQuerier = router.NewCachingPoolQuerier(poolQuerier, *poolCacheTTL, *poolCacheBps)
+		}
+		if *poolStream {
+			graph = router.NewPoolGraph(poolStreamEndpoint(liveConfig.IndexerEndpoints))
+			graph.SetFallback(poolQuerier)
+			go graph.Run(streamCtx)
+			poolQuerier = graph
+		}
+		svc.SetPositionQuerier(indexers)
+		log.Printf("Router connected to indexer at %s", strings.Join(liveConfig.IndexerEndpoints, ", "))
+	default:
+		log.Fatalf("Unknown pool source %q: use indexer or vsc", *poolSource)
+	}
 
-	// Connect router to indexer for real-time pool data
-	if *indexerEndpoint != "" {
-		poolQuerier := router.NewIndexerPoolQuerier(*indexerEndpoint)
+	if *riskDeviation > 0 || *riskNotional > 0 || *riskAccounts != "" {
+		accountCaps, err := parseAmounts(*riskAccounts)
+		if err != nil {
+			log.Fatalf("Invalid --risk-account-notional: %v", err)
+		}
+
+		// Track prices from the pools swaps are quoted from
+		var tracker *router.PriceTracker
+		if poolQuerier != nil {
+			tracker = router.NewPriceTracker(poolQuerier, *riskTWAPWindow)
+			go tracker.Run(streamCtx, *riskSampling)
+			poolQuerier = tracker
+		}
+		checker, err := router.NewRiskChecker(tracker, router.RiskConfig{
+			MaxDeviationBps: *riskDeviation,
+			MaxNotional:     *riskNotional,
+			AccountNotional: accountCaps,
+			NotionalWindow:  *riskWindow,
+			WarnOnly:        *riskWarnOnly,
+		})
+		if err != nil {
+			log.Fatalf("Invalid risk limits: %v", err)
+		}
+		svc.SetRiskChecker(checker)
+	}
+	if poolQuerier != nil {
 		svc.SetPoolQuerier(poolQuerier)
-		log.Printf("Router connected to indexer at %s", *indexerEndpoint)
+	}
+
+	if *crossCheck > 0 {
+		if indexers == nil || *dexRouter == "" {
+			log.Fatalf("--pool-cross-check-interval requires --indexer-endpoint and --dex-router-contract")
+		}
+		go crossCheckPools(streamCtx, indexers, router.NewVSCPoolQuerier(*vscNode, *dexRouter), *crossCheck)
+	}
+
+	var orders router.OrderStore
+	if *orderStore != "" {
+		store, err := router.OpenOrderStore(*orderStore)
+		if err != nil {
+			log.Fatalf("Failed to open order store: %v", err)
+		}
+		defer store.Close()
+		if err := svc.RestoreTWAPOrders(store); err != nil {
+			log.Fatalf("Failed to restore TWAP orders: %v", err)
+		}
+		orders = store
 	} else {
-		log.Printf("Warning: No indexer endpoint provided, router will use hardcoded fallback pools")
+		log.Printf("No order store: DCA, trigger and TWAP orders will be lost on restart")
+	}
+
+	scheduler, err := router.NewDCAScheduler(svc, orders)
+	if err != nil {
+		log.Fatalf("Failed to load DCA orders: %v", err)
 	}
+	svc.SetDCAScheduler(scheduler)
+	go scheduler.Run(streamCtx)
+
+	monitor, err := router.NewTriggerMonitor(svc, orders)
+	if err != nil {
+		log.Fatalf("Failed to load trigger orders: %v", err)
+	}
+	svc.SetTriggerMonitor(monitor)
+	go monitor.Run(streamCtx, *triggerInterval)
+
+	var approvals *router.ApprovalQueue
+	if *approvalLimits != "" {
+		thresholds, err := parseAmounts(*approvalLimits)
+		if err != nil {
+			log.Fatalf("Invalid --approval-thresholds: %v", err)
+		}
+		tokens, err := parseApprovers(*approvers)
+		if err != nil {
+			log.Fatalf("Invalid --approvers: %v", err)
+		}
+		queue, err := router.NewApprovalQueue(svc, router.ApprovalConfig{
+			Thresholds:     thresholds,
+			Approvers:      tokens,
+			Required:       *approvalsNeeded,
+			TTL:            *approvalTTL,
+			ExecuteTimeout: *approvalTimeout,
+		}, orders)
+		if err != nil {
+			log.Fatalf("Failed to set up approvals: %v", err)
+		}
+		svc.SetApprovalQueue(queue)
+		approvals = queue
+		log.Printf("Swaps above approval thresholds need %d of %d approvals", *approvalsNeeded, len(tokens))
+	}
+
+	if *referralStore != "" {
+		program, err := router.NewReferralProgram(*referralStore)
+		if err != nil {
+			log.Fatalf("Failed to load referrers: %v", err)
+		}
+		svc.SetReferralProgram(program)
+	}
+
+	if *volumeStore != "" {
+		tiers, err := parseFeeTiers(*feeTiers)
+		if err != nil {
+			log.Fatalf("Invalid --fee-tiers: %v", err)
+		}
+		volumes, err := router.NewVolumeTiers(*volumeStore, tiers)
+		if err != nil {
+			log.Fatalf("Failed to load volumes: %v", err)
+		}
+		svc.SetVolumeTiers(volumes)
+	}
+
+	if *receiptStore != "" {
+		receipts, err := router.NewReceiptStore(*receiptStore)
+		if err != nil {
+			log.Fatalf("Failed to load receipts: %v", err)
+		}
+		defer receipts.Close()
+		svc.SetReceiptStore(receipts)
+	}
+
+	if *metrics {
+		svc.SetMetrics(router.NewMetrics())
+	}
+
+	if *quoteRate > 0 || *executionRate > 0 {
+		limiter, err := router.NewRateLimiter(router.RateLimitConfig{
+			QuotesPerSecond:     *quoteRate,
+			QuoteBurst:          *quoteBurst,
+			ExecutionsPerSecond: *executionRate,
+			ExecutionBurst:      *executionBurst,
+		})
+		if err != nil {
+			log.Fatalf("Invalid rate limits: %v", err)
+		}
+		svc.SetRateLimiter(limiter)
+	}
+
+	// Reloads repoint the indexers, leaving lookups already under way on the
+	// old ones, and move the pool stream along with them
+	reloader := router.NewConfigReloader(svc, *configFile, liveConfig)
+	reloader.OnReload(func(config router.LiveConfig) error {
+		if indexers == nil || len(config.IndexerEndpoints) == 0 || slices.Equal(config.IndexerEndpoints, indexers.Endpoints()) {
+			return nil
+		}
+		if err := indexers.SetEndpoints(config.IndexerEndpoints); err != nil {
+			return err
+		}
+		if graph != nil {
+			graph.SetEndpoint(poolStreamEndpoint(config.IndexerEndpoints))
+		}
+		log.Printf("Router switched to indexer at %s", strings.Join(config.IndexerEndpoints, ", "))
+		return nil
+	})
+	if _, err := reloader.Reload(); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	svc.SetConfigReloader(reloader)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			config, err := reloader.Reload()
+			if err != nil {
+				log.Printf("Config reload failed, keeping the current config: %v", err)
+				continue
+			}
+			log.Printf("Config reloaded: %d allowed assets (0 allows all), max slippage %d bps", len(config.AllowedAssets), config.MaxSlippageBps)
+		}
+	}()
 
 	server := router.NewServer(svc, *port)
+	server.SetAdminToken(*adminToken)
+
+	var grpcServer *router.GRPCServer
+	if *grpcPort != "" {
+		grpcServer = router.NewGRPCServer(svc, *grpcPort)
+		go func() {
+			log.Printf("Starting router gRPC service on port %s...", *grpcPort)
+			if err := grpcServer.Start(); err != nil {
+				log.Fatal("gRPC server failed to start:", err)
+			}
+		}()
+	}
 
 	// Handle graceful shutdown
 	c := make(chan os.Signal, 1)
@@ -96,9 +456,86 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	if grpcServer != nil {
+		if err := grpcServer.Stop(ctx); err != nil {
+			log.Printf("gRPC server forced to shutdown: %v", err)
+		}
+	}
+
 	if err := server.Stop(ctx); err != nil {
 		log.Fatal("Server forced to shutdown:", err)
 	}
 
+	// Approved swaps still broadcasting are cancelled and recorded as failed
+	if approvals != nil {
+		approvals.Stop()
+	}
+
 	log.Println("Router service stopped")
 }
+
+// parseAmounts parses name=amount pairs separated by commas, such as
+// per-account caps or per-asset thresholds
+func parseAmounts(value string) (map[string]int64, error) {
+	amounts := make(map[string]int64)
+	if value == "" {
+		return amounts, nil
+	}
+	for _, pair := range strings.Split(value, ",") {
+		name, amount, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%q is not a name=amount pair", pair)
+		}
+		limit, err := strconv.ParseInt(amount, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid amount for %s: %w", name, err)
+		}
+		amounts[name] = limit
+	}
+	return amounts, nil
+}
+
+// parseFeeTiers parses name=minVolume:rebateBps fee tiers separated by
+// commas
+func parseFeeTiers(value string) ([]router.FeeTier, error) {
+	var tiers []router.FeeTier
+	if value == "" {
+		return tiers, nil
+	}
+	for _, pair := range strings.Split(value, ",") {
+		name, schedule, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		volume, rebate, hasRebate := strings.Cut(schedule, ":")
+		if !ok || !hasRebate || name == "" {
+			return nil, fmt.Errorf("%q is not a name=minVolume:rebateBps tier", pair)
+		}
+		minVolume, err := strconv.ParseInt(volume, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid minimum volume for %s: %w", name, err)
+		}
+		rebateBps, err := strconv.ParseUint(rebate, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rebate for %s: %w", name, err)
+		}
+		tiers = append(tiers, router.FeeTier{Name: name, MinVolume: minVolume, RebateBps: rebateBps})
+	}
+	return tiers, nil
+}
+
+// parseApprovers parses name=token pairs separated by commas
+func parseApprovers(value string) (map[string]string, error) {
+	tokens := make(map[string]string)
+	if value == "" {
+		return tokens, nil
+	}
+	for _, pair := range strings.Split(value, ",") {
+		name, token, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || name == "" || token == "" {
+			return nil, fmt.Errorf("%q is not a name=token pair", pair)
+		}
+		if _, dup := tokens[name]; dup {
+			return nil, fmt.Errorf("approver %s is listed twice", name)
+		}
+		tokens[name] = token
+	}
+	return tokens, nil
+}
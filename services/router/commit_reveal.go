@@ -0,0 +1,85 @@
+package router
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// defaultCommitRevealDelay is how long a reveal waits after its commitment
+// when the executor cannot report inclusion. It must cover at least one
+// block, as the contract refuses reveals in the commitment's own block.
+const defaultCommitRevealDelay = 30 * time.Second
+
+// InclusionWaiter is implemented by executors that can wait for a
+// transaction they broadcast to be included in a block
+type InclusionWaiter interface {
+	WaitForInclusion(ctx context.Context, txID string) error
+}
+
+// SetCommitRevealDelay sets how long a commit-reveal swap waits between its
+// commitment and its reveal when the executor cannot report inclusion
+func (s *Service) SetCommitRevealDelay(delay time.Duration) {
+	s.commitRevealDelay = delay
+}
+
+// newCommitSalt returns a random salt, so a commitment cannot be matched
+// against guessed trades
+func newCommitSalt() (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(salt), nil
+}
+
+// commitOperation builds the commitment to a salted swap payload: the hex
+// SHA-256 of the exact payload that will reveal it, and nothing else
+func commitOperation(payload string) DexOperation {
+	hash := sha256.Sum256([]byte(payload))
+	return DexOperation{OperationType: "commit", Payload: hex.EncodeToString(hash[:])}
+}
+
+// commitAndReveal broadcasts the commitment to a swap, waits for it to be
+// included and then broadcasts the swap itself, returning the reveal's
+// transaction ID
+func (s *Service) commitAndReveal(ctx context.Context, account string, payload string, intents []Intent) (string, error) {
+	commit := commitOperation(payload)
+	commitTxID, err := s.submitInTurn(ctx, account, commit.OperationType, commit.Payload, nil)
+	if err != nil {
+		return "", fmt.Errorf("commit failed: %w", err)
+	}
+
+	if err := s.awaitInclusion(ctx, commitTxID, s.commitRevealDelay); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("cancelled before reveal: %w", ctx.Err())
+		}
+		return "", fmt.Errorf("commit %s not included: %w", commitTxID, err)
+	}
+
+	txID, err := s.submit(ctx, account, payload, intents)
+	if err != nil {
+		return "", fmt.Errorf("reveal failed: %w", err)
+	}
+	return txID, nil
+}
+
+// awaitInclusion waits for a broadcast transaction to be included, or for
+// fallback if the executor cannot report inclusion
+func (s *Service) awaitInclusion(ctx context.Context, txID string, fallback time.Duration) error {
+	if waiter, ok := s.dexExecutor.(InclusionWaiter); ok && txID != "" {
+		return waiter.WaitForInclusion(ctx, txID)
+	}
+
+	timer := time.NewTimer(fallback)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
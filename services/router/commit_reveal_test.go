@@ -0,0 +1,147 @@
+package router
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockInclusionWaiter submits operations with sequential transaction IDs and
+// records which ones it was asked to wait for
+type mockInclusionWaiter struct {
+	mockTxSubmitter
+	waited []string
+}
+
+func (m *mockInclusionWaiter) SubmitDexOperation(ctx context.Context, operationType string, payload string, intents []Intent) (string, error) {
+	m.executedOperations = append(m.executedOperations, operationType+":"+payload)
+	return operationType + "-tx", nil
+}
+
+func (m *mockInclusionWaiter) WaitForInclusion(ctx context.Context, txID string) error {
+	m.waited = append(m.waited, txID)
+	return nil
+}
+
+// revealedPayload splits the executed commit and reveal, checking the
+// commitment is the reveal's hash
+func revealedPayload(t *testing.T, operations []string) map[string]interface{} {
+	t.Helper()
+	require.Len(t, operations, 2)
+	require.True(t, strings.HasPrefix(operations[0], "commit:"))
+	require.True(t, strings.HasPrefix(operations[1], "execute:"))
+
+	reveal := strings.TrimPrefix(operations[1], "execute:")
+	hash := sha256.Sum256([]byte(reveal))
+	assert.Equal(t, hex.EncodeToString(hash[:]), strings.TrimPrefix(operations[0], "commit:"))
+
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(reveal), &payload))
+	return payload
+}
+
+func TestCommitRevealSwap(t *testing.T) {
+	executor := &mockInclusionWaiter{}
+	svc := NewService(VSCConfig{DexRouterContract: "dex-router-contract"}, executor)
+
+	result, err := svc.ExecuteSwap(context.Background(), SwapParams{
+		Sender:       "alice",
+		AssetIn:      "HBD",
+		AssetOut:     "HIVE",
+		AmountIn:     1000,
+		MinAmountOut: 900,
+		CommitReveal: true,
+	})
+	require.NoError(t, err)
+	require.True(t, result.Success, result.ErrorMessage)
+	assert.Equal(t, "execute-tx", result.TxID)
+	assert.Equal(t, []string{"commit-tx"}, executor.waited, "the reveal should wait for the commitment")
+
+	payload := revealedPayload(t, executor.executedOperations)
+	metadata := payload["metadata"].(map[string]interface{})
+	assert.Len(t, metadata["commit_salt"], 32)
+}
+
+func TestCommitRevealSwap_SaltsDiffer(t *testing.T) {
+	executor := &mockInclusionWaiter{}
+	svc := NewService(VSCConfig{}, executor)
+	params := SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000, CommitReveal: true}
+
+	_, err := svc.ExecuteSwap(context.Background(), params)
+	require.NoError(t, err)
+	_, err = svc.ExecuteSwap(context.Background(), params)
+	require.NoError(t, err)
+
+	require.Len(t, executor.executedOperations, 4)
+	assert.NotEqual(t, executor.executedOperations[0], executor.executedOperations[2], "identical swaps should not share a commitment")
+}
+
+func TestCommitRevealSwap_WaitsWithoutInclusion(t *testing.T) {
+	executor := &mockDEXExecutor{}
+	svc := NewService(VSCConfig{}, executor)
+	svc.SetCommitRevealDelay(20 * time.Millisecond)
+
+	start := time.Now()
+	result, err := svc.ExecuteSwap(context.Background(), SwapParams{
+		Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000, CommitReveal: true,
+	})
+	require.NoError(t, err)
+	require.True(t, result.Success, result.ErrorMessage)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	revealedPayload(t, executor.executedOperations)
+}
+
+func TestCommitRevealSwap_DeadlineBeforeReveal(t *testing.T) {
+	executor := &mockDEXExecutor{}
+	svc := NewService(VSCConfig{}, executor)
+	svc.SetCommitRevealDelay(time.Hour)
+
+	result, err := svc.ExecuteSwap(context.Background(), SwapParams{
+		Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000, CommitReveal: true,
+		Deadline: time.Now().Add(20 * time.Millisecond),
+	})
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.ErrorMessage, "cancelled before reveal")
+	assert.Len(t, executor.executedOperations, 1, "only the commitment should have been sent")
+}
+
+func TestCommitRevealSwap_Simulated(t *testing.T) {
+	executor := &mockDEXExecutor{}
+	svc := NewService(VSCConfig{DexRouterContract: "dex-router-contract"}, executor)
+
+	result, err := svc.ExecuteSwap(WithSimulation(context.Background()), SwapParams{
+		Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000, CommitReveal: true,
+	})
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	assert.Empty(t, executor.executedOperations)
+
+	require.NotNil(t, result.Simulation)
+	ops := result.Simulation.Operations
+	require.Len(t, ops, 2)
+	revealedPayload(t, []string{ops[0].OperationType + ":" + ops[0].Payload, ops[1].OperationType + ":" + ops[1].Payload})
+	assert.Empty(t, ops[0].Intents, "the commitment should not reveal the input")
+	assert.NotEmpty(t, ops[1].Intents)
+}
+
+func TestCommitRevealSwap_RefusedInBatch(t *testing.T) {
+	svc := NewService(VSCConfig{}, &mockBatchExecutor{})
+
+	result, err := svc.ExecuteBatch(context.Background(), BatchParams{
+		Sender: "alice",
+		Operations: []BatchOperation{{Swap: &SwapParams{
+			AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000, CommitReveal: true,
+		}}},
+	})
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.ErrorMessage, "commit-reveal")
+}
@@ -0,0 +1,199 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// LiveConfig is the part of the router's configuration that can be changed
+// while it runs. A change applies to swaps and orders accepted after it;
+// those already accepted, including orders still waiting to fill, carry on
+// as they were.
+type LiveConfig struct {
+	// AllowedAssets lists the assets swaps, quotes, orders and deposits may
+	// start or end in; empty allows every asset
+	AllowedAssets []string `json:"allowedAssets,omitempty"`
+
+	// MaxSlippageBps is the most slippage a swap or order may allow, in
+	// basis points; zero sets no cap
+	MaxSlippageBps uint64 `json:"maxSlippageBps,omitempty"`
+
+	// IndexerEndpoints lists the indexers pools are read from, the first
+	// also serving positions and swap outcomes. The service does not read
+	// them itself; a ConfigReloader hook applies them.
+	IndexerEndpoints []string `json:"indexerEndpoints,omitempty"`
+}
+
+// validate checks the configuration is usable
+func (c LiveConfig) validate() error {
+	for _, asset := range c.AllowedAssets {
+		if asset == "" {
+			return fmt.Errorf("allowed assets must not be empty")
+		}
+	}
+	if c.MaxSlippageBps > 10000 {
+		return fmt.Errorf("max slippage must be at most 10000 bps")
+	}
+	for _, endpoint := range c.IndexerEndpoints {
+		if endpoint == "" {
+			return fmt.Errorf("indexer endpoints must not be empty")
+		}
+	}
+	return nil
+}
+
+// liveConfig is a LiveConfig prepared for lookups
+type liveConfig struct {
+	LiveConfig
+	allowed map[string]bool // Nil allows every asset
+}
+
+// SetLiveConfig replaces the allowlist and slippage cap. Swaps and orders
+// already accepted are not checked again.
+func (s *Service) SetLiveConfig(config LiveConfig) error {
+	if err := config.validate(); err != nil {
+		return err
+	}
+	live := &liveConfig{LiveConfig: config}
+	if len(config.AllowedAssets) > 0 {
+		live.allowed = make(map[string]bool, len(config.AllowedAssets))
+		for _, asset := range config.AllowedAssets {
+			live.allowed[asset] = true
+		}
+	}
+	s.live.Store(live)
+	return nil
+}
+
+// Config returns the live configuration in effect
+func (s *Service) Config() LiveConfig {
+	if live := s.live.Load(); live != nil {
+		return live.LiveConfig
+	}
+	return LiveConfig{}
+}
+
+// checkAssets refuses assets outside the allowlist
+func (s *Service) checkAssets(assets ...string) error {
+	live := s.live.Load()
+	if live == nil || live.allowed == nil {
+		return nil
+	}
+	for _, asset := range assets {
+		if !live.allowed[asset] {
+			return fmt.Errorf("asset %s is not allowed", asset)
+		}
+	}
+	return nil
+}
+
+// acceptSwap checks a new swap or order against the allowlist and the
+// slippage cap
+func (s *Service) acceptSwap(params SwapParams) error {
+	if err := s.checkAssets(params.AssetIn, params.AssetOut); err != nil {
+		return err
+	}
+	if live := s.live.Load(); live != nil && live.MaxSlippageBps > 0 && params.MaxSlippage > live.MaxSlippageBps {
+		return fmt.Errorf("slippage of %d bps exceeds the %d bps allowed", params.MaxSlippage, live.MaxSlippageBps)
+	}
+	if params.Quote != nil && len(params.Quote.Legs) > 0 {
+		// The contract swaps along one route per call, so the split could
+		// never be executed as quoted
+		return fmt.Errorf("quote splits the swap across %d routes and cannot be executed; swap along one route instead", len(params.Quote.Legs))
+	}
+	return nil
+}
+
+// ConfigReloader reloads the router's live configuration from a JSON file
+// and its referrers from the referral program's file. Values missing from
+// the file keep those the router was started with. Hooks registered with
+// OnReload apply what the service does not hold itself, such as indexer
+// endpoints.
+type ConfigReloader struct {
+	svc  *Service
+	path string     // Empty reloads only the referrers
+	base LiveConfig // Values from flags, overridden by the file
+
+	mu    sync.Mutex // Held for a whole reload, so reloads never interleave
+	hooks []func(LiveConfig) error
+}
+
+// NewConfigReloader creates a reloader reading path over base. Call Reload
+// to apply the file the first time.
+func NewConfigReloader(svc *Service, path string, base LiveConfig) *ConfigReloader {
+	return &ConfigReloader{svc: svc, path: path, base: base}
+}
+
+// OnReload registers a hook run with each newly loaded configuration,
+// before the service starts using it. A hook's error fails the reload.
+func (c *ConfigReloader) OnReload(hook func(LiveConfig) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks = append(c.hooks, hook)
+}
+
+// Reload reads the configuration file and the referrers again and applies
+// them. An invalid file changes nothing; a failing hook leaves the previous
+// allowlist and slippage cap in effect.
+func (c *ConfigReloader) Reload() (LiveConfig, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	config, err := c.load()
+	if err != nil {
+		return LiveConfig{}, err
+	}
+	if c.svc.referrals != nil {
+		if err := c.svc.referrals.Reload(); err != nil {
+			return LiveConfig{}, err
+		}
+	}
+	for _, hook := range c.hooks {
+		if err := hook(config); err != nil {
+			return LiveConfig{}, err
+		}
+	}
+	if err := c.svc.SetLiveConfig(config); err != nil {
+		return LiveConfig{}, err
+	}
+	return config, nil
+}
+
+// load reads the configuration file over the base values
+func (c *ConfigReloader) load() (LiveConfig, error) {
+	config := c.base
+	config.AllowedAssets = append([]string(nil), c.base.AllowedAssets...)
+	config.IndexerEndpoints = append([]string(nil), c.base.IndexerEndpoints...)
+	if c.path != "" {
+		data, err := os.ReadFile(c.path)
+		if err != nil {
+			return LiveConfig{}, fmt.Errorf("failed to read config: %w", err)
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&config); err != nil {
+			return LiveConfig{}, fmt.Errorf("failed to decode config: %w", err)
+		}
+	}
+	if err := config.validate(); err != nil {
+		return LiveConfig{}, fmt.Errorf("invalid config: %w", err)
+	}
+	return config, nil
+}
+
+// SetConfigReloader enables reloading the configuration through the admin
+// API
+func (s *Service) SetConfigReloader(reloader *ConfigReloader) {
+	s.reloader = reloader
+}
+
+// ReloadConfig reloads the configuration and referrers, as on SIGHUP
+func (s *Service) ReloadConfig() (LiveConfig, error) {
+	if s.reloader == nil {
+		return LiveConfig{}, fmt.Errorf("config reload is not enabled")
+	}
+	return s.reloader.Reload()
+}
@@ -0,0 +1,168 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLiveConfigAllowlist(t *testing.T) {
+	svc := newRoutingTestService(t, DefaultRoutingConfig())
+	ctx := context.Background()
+	require.NoError(t, svc.SetLiveConfig(LiveConfig{AllowedAssets: []string{"HBD", "HIVE"}}))
+
+	_, err := svc.Quote(ctx, SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000})
+	assert.NoError(t, err)
+	_, err = svc.Quote(ctx, SwapParams{AssetIn: "BTC", AssetOut: "HBD", AmountIn: 1000})
+	assert.EqualError(t, err, "asset BTC is not allowed")
+
+	result, err := svc.ExecuteSwap(ctx, SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "ETH", AmountIn: 1000, MaxSlippage: 50})
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Equal(t, "asset ETH is not allowed", result.ErrorMessage)
+
+	_, err = svc.SubmitSwap(SwapParams{Sender: "alice", AssetIn: "ETH", AssetOut: "HBD", AmountIn: 1000, MaxSlippage: 50})
+	assert.EqualError(t, err, "asset ETH is not allowed")
+
+	result, err = svc.ExecuteDeposit(ctx, DepositParams{Sender: "alice", AssetIn: "HBD", AssetOut: "SPK", AmountIn: 1000, AmountOut: 1000})
+	require.NoError(t, err)
+	assert.Equal(t, "asset SPK is not allowed", result.ErrorMessage)
+
+	// An empty allowlist allows every asset again
+	require.NoError(t, svc.SetLiveConfig(LiveConfig{}))
+	_, err = svc.Quote(ctx, SwapParams{AssetIn: "BTC", AssetOut: "HBD", AmountIn: 1000})
+	assert.NoError(t, err)
+}
+
+func TestLiveConfigSlippageCap(t *testing.T) {
+	svc := newRoutingTestService(t, DefaultRoutingConfig())
+	require.NoError(t, svc.SetLiveConfig(LiveConfig{MaxSlippageBps: 100}))
+
+	result, err := svc.ExecuteSwap(context.Background(), SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000, MaxSlippage: 500})
+	require.NoError(t, err)
+	assert.Equal(t, "slippage of 500 bps exceeds the 100 bps allowed", result.ErrorMessage)
+
+	result, err = svc.ExecuteSwap(context.Background(), SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000, MaxSlippage: 100})
+	require.NoError(t, err)
+	assert.True(t, result.Success, result.ErrorMessage)
+
+	assert.Error(t, svc.SetLiveConfig(LiveConfig{MaxSlippageBps: 10001}))
+	assert.Equal(t, uint64(100), svc.Config().MaxSlippageBps, "an invalid config is not applied")
+}
+
+func TestLiveConfigKeepsAcceptedOrders(t *testing.T) {
+	monitor, executor, querier, _ := newTriggerTestMonitor(t)
+	stop, err := monitor.Create(triggerTestParams(TriggerStopLoss, 3.5))
+	require.NoError(t, err)
+
+	// Tightening the config refuses new orders, but the accepted one fires
+	require.NoError(t, monitor.router.SetLiveConfig(LiveConfig{AllowedAssets: []string{"HBD"}, MaxSlippageBps: 10}))
+	_, err = monitor.Create(triggerTestParams(TriggerStopLoss, 3.5))
+	assert.EqualError(t, err, "asset HIVE is not allowed")
+
+	setReserves(querier, "hbd-hive", 10000000, 30000000)
+	monitor.checkPending(context.Background())
+	require.Len(t, executor.executedOperations, 1)
+	order, err := monitor.Get(stop.ID)
+	require.NoError(t, err)
+	assert.Equal(t, TriggerFilled, order.Status)
+}
+
+func TestConfigReloader(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "router.json")
+	referrersPath := filepath.Join(dir, "referrers.json")
+
+	svc := NewService(VSCConfig{}, &mockDEXExecutor{})
+	program, err := NewReferralProgram(referrersPath)
+	require.NoError(t, err)
+	svc.SetReferralProgram(program)
+
+	base := LiveConfig{MaxSlippageBps: 300, IndexerEndpoints: []string{"http://indexer-a"}}
+	reloader := NewConfigReloader(svc, configPath, base)
+	var applied []LiveConfig
+	reloader.OnReload(func(config LiveConfig) error {
+		applied = append(applied, config)
+		return nil
+	})
+
+	// The file overrides only what it sets
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"allowedAssets": ["HBD", "HIVE"]}`), 0o644))
+	config, err := reloader.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, LiveConfig{AllowedAssets: []string{"HBD", "HIVE"}, MaxSlippageBps: 300, IndexerEndpoints: []string{"http://indexer-a"}}, config)
+	assert.Equal(t, config, svc.Config())
+
+	// Referrers edited on disk are picked up
+	require.NoError(t, os.WriteFile(referrersPath, []byte(`[{"id": "wallet", "apiKey": "key-1", "beneficiary": "wallet-fees", "refBps": 25}]`), 0o644))
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"maxSlippageBps": 100, "indexerEndpoints": ["http://indexer-b"]}`), 0o644))
+	config, err = reloader.Reload()
+	require.NoError(t, err)
+	assert.Empty(t, config.AllowedAssets)
+	assert.Equal(t, uint64(100), config.MaxSlippageBps)
+	assert.Equal(t, []string{"http://indexer-b"}, applied[1].IndexerEndpoints)
+	ref, err := program.Get("wallet")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(25), ref.RefBps)
+
+	// Invalid files and failing hooks change nothing
+	for _, content := range []string{`{"maxSlippageBps": 20000}`, `{"maxSlipageBps": 20}`, `{`} {
+		require.NoError(t, os.WriteFile(configPath, []byte(content), 0o644))
+		_, err = reloader.Reload()
+		assert.Error(t, err, content)
+		assert.Equal(t, uint64(100), svc.Config().MaxSlippageBps, content)
+	}
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"maxSlippageBps": 50}`), 0o644))
+	reloader.OnReload(func(LiveConfig) error { return errors.New("indexer unreachable") })
+	_, err = reloader.Reload()
+	assert.EqualError(t, err, "indexer unreachable")
+	assert.Equal(t, uint64(100), svc.Config().MaxSlippageBps)
+}
+
+func TestConfigAdminEndpoints(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "router.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"allowedAssets": ["HBD"]}`), 0o644))
+
+	svc := NewService(VSCConfig{}, &mockDEXExecutor{})
+	server := NewServer(svc, "0")
+	adminRequest := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		server.http.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// Disabled until an admin token is set
+	w := adminRequest(http.MethodPost, "/api/v1/admin/reload")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	server.SetAdminToken("secret")
+	w = serveTestRequest(server, http.MethodPost, "/api/v1/admin/reload", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = adminRequest(http.MethodPost, "/api/v1/admin/reload")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	svc.SetConfigReloader(NewConfigReloader(svc, configPath, LiveConfig{}))
+	w = adminRequest(http.MethodPost, "/api/v1/admin/reload")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"allowedAssets": ["HBD"]}`, w.Body.String())
+
+	w = adminRequest(http.MethodGet, "/api/v1/admin/config")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"allowedAssets": ["HBD"]}`, w.Body.String())
+
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"allowedAssets": [""]}`), 0o644))
+	w = adminRequest(http.MethodPost, "/api/v1/admin/reload")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.True(t, strings.HasPrefix(w.Body.String(), "invalid config"), w.Body.String())
+}
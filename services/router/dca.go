@@ -0,0 +1,364 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DCA scheduler settings
+const (
+	dcaTickInterval = time.Second
+	dcaMinInterval  = time.Minute
+	maxDCAFills     = 1000 // Fill history kept per order; oldest fills are dropped
+)
+
+// ErrDCAOrderNotFound is returned for unknown order IDs
+var ErrDCAOrderNotFound = errors.New("DCA order not found")
+
+// DCAStatus is the state of a recurring order
+type DCAStatus string
+
+// DCA order statuses
+const (
+	DCAActive    DCAStatus = "active"
+	DCAPaused    DCAStatus = "paused"
+	DCACompleted DCAStatus = "completed" // MaxRuns reached
+	DCACancelled DCAStatus = "cancelled"
+)
+
+// DCAParams configures a recurring swap
+type DCAParams struct {
+	Swap     SwapParams    // Executed every Interval; Sender owns the order
+	Interval time.Duration // At least one minute
+	MaxRuns  int           // Stop after this many fills; zero runs until cancelled
+}
+
+// DCAFill records one execution of a recurring order
+type DCAFill struct {
+	At        time.Time `json:"at"`
+	AmountIn  int64     `json:"amountIn"`
+	AmountOut int64     `json:"amountOut"`
+	TxID      string    `json:"txId,omitempty"`
+	Success   bool      `json:"success"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// DCAOrder is a recurring swap and its history
+type DCAOrder struct {
+	ID           string        `json:"id"`
+	Owner        string        `json:"owner"`
+	AssetIn      string        `json:"assetIn"`
+	AssetOut     string        `json:"assetOut"`
+	AmountIn     int64         `json:"amountIn"`
+	MinAmountOut int64         `json:"minAmountOut,omitempty"`
+	MaxSlippage  uint64        `json:"maxSlippage,omitempty"`
+	Interval     time.Duration `json:"interval"`
+	MaxRuns      int           `json:"maxRuns,omitempty"`
+	Runs         int           `json:"runs"`
+	Status       DCAStatus     `json:"status"`
+	NextRunAt    time.Time     `json:"nextRunAt"`
+	Fills        []DCAFill     `json:"fills"`
+	CreatedAt    time.Time     `json:"createdAt"`
+	UpdatedAt    time.Time     `json:"updatedAt"`
+}
+
+// swapParams returns the swap a fill executes
+func (o *DCAOrder) swapParams() SwapParams {
+	return SwapParams{
+		Sender:       o.Owner,
+		AmountIn:     o.AmountIn,
+		AssetIn:      o.AssetIn,
+		AssetOut:     o.AssetOut,
+		MinAmountOut: o.MinAmountOut,
+		MaxSlippage:  o.MaxSlippage,
+	}
+}
+
+// copyDCAOrder returns a copy that shares no slices with o
+func copyDCAOrder(o *DCAOrder) DCAOrder {
+	copied := *o
+	copied.Fills = append([]DCAFill{}, o.Fills...)
+	return copied
+}
+
+// DCAScheduler executes recurring swaps on behalf of users
+type DCAScheduler struct {
+	router *Service
+	store  OrderStore // Nil keeps orders in memory only
+
+	mu     sync.Mutex
+	orders map[string]*DCAOrder
+	now    func() time.Time
+}
+
+// NewDCAScheduler creates a scheduler, restoring orders from store. Call Run
+// to start executing them.
+func NewDCAScheduler(svc *Service, store OrderStore) (*DCAScheduler, error) {
+	d := &DCAScheduler{
+		router: svc,
+		store:  store,
+		orders: make(map[string]*DCAOrder),
+		now:    time.Now,
+	}
+
+	if store != nil {
+		stored, err := store.LoadOrders(OrderDCA)
+		if err != nil {
+			return nil, err
+		}
+		for _, data := range stored {
+			order := &DCAOrder{}
+			if err := json.Unmarshal(data, order); err != nil {
+				return nil, fmt.Errorf("failed to decode DCA order: %w", err)
+			}
+			d.orders[order.ID] = order
+		}
+	}
+	return d, nil
+}
+
+// SetDCAScheduler configures the scheduler that serves recurring orders
+func (s *Service) SetDCAScheduler(scheduler *DCAScheduler) {
+	s.dca = scheduler
+}
+
+// Run executes due orders until ctx is cancelled
+func (d *DCAScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(dcaTickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.runDue(ctx)
+		}
+	}
+}
+
+// Create schedules a recurring order. The first fill runs one interval from now.
+func (d *DCAScheduler) Create(params DCAParams) (*DCAOrder, error) {
+	if params.Swap.AssetIn == params.Swap.AssetOut {
+		return nil, fmt.Errorf("cannot swap asset to itself")
+	}
+	if params.Swap.AmountIn <= 0 {
+		return nil, fmt.Errorf("amount in must be greater than 0")
+	}
+	if params.Swap.Sender == "" {
+		return nil, fmt.Errorf("sender is required")
+	}
+	if err := d.router.acceptSwap(params.Swap); err != nil {
+		return nil, err
+	}
+	if params.Interval < dcaMinInterval {
+		return nil, fmt.Errorf("interval must be at least %s", dcaMinInterval)
+	}
+	if params.MaxRuns < 0 {
+		return nil, fmt.Errorf("max runs must not be negative")
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	order := &DCAOrder{
+		ID:           id,
+		Owner:        params.Swap.Sender,
+		AssetIn:      params.Swap.AssetIn,
+		AssetOut:     params.Swap.AssetOut,
+		AmountIn:     params.Swap.AmountIn,
+		MinAmountOut: params.Swap.MinAmountOut,
+		MaxSlippage:  params.Swap.MaxSlippage,
+		Interval:     params.Interval,
+		MaxRuns:      params.MaxRuns,
+		Status:       DCAActive,
+		NextRunAt:    now.Add(params.Interval),
+		Fills:        []DCAFill{},
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	d.orders[id] = order
+
+	if err := d.saveLocked(order); err != nil {
+		delete(d.orders, id)
+		return nil, err
+	}
+
+	copied := copyDCAOrder(order)
+	return &copied, nil
+}
+
+// Get returns an order with its fill history
+func (d *DCAScheduler) Get(id string) (*DCAOrder, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	order, ok := d.orders[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrDCAOrderNotFound, id)
+	}
+	copied := copyDCAOrder(order)
+	return &copied, nil
+}
+
+// List returns the orders owned by owner, or every order if owner is empty,
+// oldest first
+func (d *DCAScheduler) List(owner string) []DCAOrder {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	orders := []DCAOrder{}
+	for _, order := range d.orders {
+		if owner == "" || order.Owner == owner {
+			orders = append(orders, copyDCAOrder(order))
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		if orders[i].CreatedAt.Equal(orders[j].CreatedAt) {
+			return orders[i].ID < orders[j].ID
+		}
+		return orders[i].CreatedAt.Before(orders[j].CreatedAt)
+	})
+	return orders
+}
+
+// Pause stops an active order from running until it is resumed
+func (d *DCAScheduler) Pause(id string) (*DCAOrder, error) {
+	return d.transition(id, func(order *DCAOrder) {
+		order.Status = DCAPaused
+	}, DCAActive)
+}
+
+// Resume restarts a paused order. Fills missed while paused are skipped; the
+// next fill runs one interval from now.
+func (d *DCAScheduler) Resume(id string) (*DCAOrder, error) {
+	return d.transition(id, func(order *DCAOrder) {
+		order.Status = DCAActive
+		order.NextRunAt = d.now().Add(order.Interval)
+	}, DCAPaused)
+}
+
+// Cancel stops an active or paused order permanently
+func (d *DCAScheduler) Cancel(id string) (*DCAOrder, error) {
+	return d.transition(id, func(order *DCAOrder) {
+		order.Status = DCACancelled
+	}, DCAActive, DCAPaused)
+}
+
+// transition applies fn to an order currently in one of the from statuses
+func (d *DCAScheduler) transition(id string, fn func(order *DCAOrder), from ...DCAStatus) (*DCAOrder, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	order, ok := d.orders[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrDCAOrderNotFound, id)
+	}
+
+	allowed := false
+	for _, status := range from {
+		allowed = allowed || order.Status == status
+	}
+	if !allowed {
+		return nil, fmt.Errorf("DCA order %s is %s", id, order.Status)
+	}
+
+	fn(order)
+	order.UpdatedAt = d.now()
+	if err := d.saveLocked(order); err != nil {
+		return nil, err
+	}
+
+	copied := copyDCAOrder(order)
+	return &copied, nil
+}
+
+// runDue executes every active order whose next fill is due
+func (d *DCAScheduler) runDue(ctx context.Context) {
+	d.mu.Lock()
+	now := d.now()
+	var due []DCAOrder
+	for _, order := range d.orders {
+		if order.Status == DCAActive && !order.NextRunAt.After(now) {
+			// Schedule the next fill before executing, so a slow swap is not
+			// picked up again by the next tick, nor run twice across a restart
+			order.NextRunAt = nextDCARun(order.NextRunAt, order.Interval, now)
+			if err := d.saveLocked(order); err != nil {
+				log.Printf("Skipping DCA fill of %s: %v", order.ID, err)
+				continue
+			}
+			due = append(due, copyDCAOrder(order))
+		}
+	}
+	d.mu.Unlock()
+
+	for _, order := range due {
+		result := d.router.executeSwap(ctx, order.swapParams(), nil)
+		d.recordFill(order.ID, DCAFill{
+			At:        d.now(),
+			AmountIn:  order.AmountIn,
+			AmountOut: result.AmountOut,
+			TxID:      result.TxID,
+			Success:   result.Success,
+			Error:     result.ErrorMessage,
+		})
+	}
+}
+
+// nextDCARun returns the first run time after now on the order's schedule,
+// skipping runs missed while the router was down
+func nextDCARun(last time.Time, interval time.Duration, now time.Time) time.Time {
+	next := last.Add(interval)
+	if !next.After(now) {
+		missed := now.Sub(last) / interval
+		next = last.Add((missed + 1) * interval)
+	}
+	return next
+}
+
+// recordFill appends a fill to an order's history and persists it
+func (d *DCAScheduler) recordFill(id string, fill DCAFill) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	order, ok := d.orders[id]
+	if !ok {
+		return
+	}
+
+	order.Fills = append(order.Fills, fill)
+	if len(order.Fills) > maxDCAFills {
+		order.Fills = order.Fills[len(order.Fills)-maxDCAFills:]
+	}
+	if fill.Success {
+		order.Runs++
+		if order.MaxRuns > 0 && order.Runs >= order.MaxRuns && order.Status == DCAActive {
+			order.Status = DCACompleted
+		}
+	}
+	order.UpdatedAt = d.now()
+
+	if err := d.saveLocked(order); err != nil {
+		log.Printf("Failed to persist DCA order %s: %v", id, err)
+	}
+}
+
+// saveLocked persists an order; caller must hold d.mu
+func (d *DCAScheduler) saveLocked(order *DCAOrder) error {
+	if d.store == nil {
+		return nil
+	}
+	return saveOrder(d.store, OrderDCA, order.ID, order)
+}
@@ -0,0 +1,237 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDCATestScheduler returns a scheduler backed by a file store in a temp
+// directory, with a clock the test controls
+func newDCATestScheduler(t *testing.T) (*DCAScheduler, *mockDEXExecutor, *time.Time, string) {
+	executor := &mockDEXExecutor{}
+	svc := NewService(VSCConfig{DexRouterContract: "dex-router-contract"}, executor)
+
+	dir := t.TempDir()
+	store, err := NewFileOrderStore(dir)
+	require.NoError(t, err)
+	scheduler, err := NewDCAScheduler(svc, store)
+	require.NoError(t, err)
+	svc.SetDCAScheduler(scheduler)
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	scheduler.now = func() time.Time { return now }
+	return scheduler, executor, &now, dir
+}
+
+func dcaTestParams() DCAParams {
+	return DCAParams{
+		Swap: SwapParams{
+			Sender:       "alice",
+			AssetIn:      "HBD",
+			AssetOut:     "HIVE",
+			AmountIn:     100000,
+			MinAmountOut: 90000,
+			MaxSlippage:  50,
+		},
+		Interval: time.Hour,
+		MaxRuns:  2,
+	}
+}
+
+func TestDCACreateValidation(t *testing.T) {
+	scheduler, _, _, _ := newDCATestScheduler(t)
+
+	tests := []struct {
+		name   string
+		modify func(p *DCAParams)
+		errMsg string
+	}{
+		{"same asset", func(p *DCAParams) { p.Swap.AssetOut = "HBD" }, "cannot swap asset to itself"},
+		{"zero amount", func(p *DCAParams) { p.Swap.AmountIn = 0 }, "amount in must be greater than 0"},
+		{"no sender", func(p *DCAParams) { p.Swap.Sender = "" }, "sender is required"},
+		{"short interval", func(p *DCAParams) { p.Interval = time.Second }, "interval must be at least"},
+		{"negative runs", func(p *DCAParams) { p.MaxRuns = -1 }, "max runs must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := dcaTestParams()
+			tt.modify(&params)
+			_, err := scheduler.Create(params)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+
+	assert.Empty(t, scheduler.List(""))
+}
+
+func TestDCAFillsUntilMaxRuns(t *testing.T) {
+	scheduler, executor, now, _ := newDCATestScheduler(t)
+
+	order, err := scheduler.Create(dcaTestParams())
+	require.NoError(t, err)
+	assert.Equal(t, DCAActive, order.Status)
+	assert.Equal(t, now.Add(time.Hour), order.NextRunAt)
+
+	// Nothing runs before the first interval elapses
+	scheduler.runDue(context.Background())
+	assert.Empty(t, executor.executedOperations)
+
+	*now = now.Add(time.Hour)
+	scheduler.runDue(context.Background())
+	require.Len(t, executor.executedOperations, 1)
+	assert.Contains(t, executor.executedOperations[0], `"asset_in":"HBD"`)
+	assert.Contains(t, executor.executedOperations[0], `"recipient":"alice"`)
+
+	order, err = scheduler.Get(order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, order.Runs)
+	assert.Equal(t, DCAActive, order.Status)
+	require.Len(t, order.Fills, 1)
+	assert.True(t, order.Fills[0].Success)
+	assert.Equal(t, int64(100000), order.Fills[0].AmountIn)
+	assert.Equal(t, *now, order.Fills[0].At)
+
+	*now = now.Add(time.Hour)
+	scheduler.runDue(context.Background())
+
+	order, err = scheduler.Get(order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, order.Runs)
+	assert.Equal(t, DCACompleted, order.Status)
+	assert.Len(t, order.Fills, 2)
+
+	// A completed order never runs again
+	*now = now.Add(time.Hour)
+	scheduler.runDue(context.Background())
+	assert.Len(t, executor.executedOperations, 2)
+}
+
+func TestDCAPauseResumeCancel(t *testing.T) {
+	scheduler, executor, now, _ := newDCATestScheduler(t)
+
+	order, err := scheduler.Create(dcaTestParams())
+	require.NoError(t, err)
+
+	paused, err := scheduler.Pause(order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, DCAPaused, paused.Status)
+
+	_, err = scheduler.Pause(order.ID)
+	assert.Error(t, err, "a paused order cannot be paused again")
+
+	// Paused orders are skipped
+	*now = now.Add(3 * time.Hour)
+	scheduler.runDue(context.Background())
+	assert.Empty(t, executor.executedOperations)
+
+	resumed, err := scheduler.Resume(order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, DCAActive, resumed.Status)
+	assert.Equal(t, now.Add(time.Hour), resumed.NextRunAt)
+
+	cancelled, err := scheduler.Cancel(order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, DCACancelled, cancelled.Status)
+
+	_, err = scheduler.Resume(order.ID)
+	assert.Error(t, err)
+
+	_, err = scheduler.Cancel("missing")
+	assert.ErrorIs(t, err, ErrDCAOrderNotFound)
+}
+
+func TestDCAOrdersSurviveRestart(t *testing.T) {
+	scheduler, _, now, dir := newDCATestScheduler(t)
+
+	order, err := scheduler.Create(dcaTestParams())
+	require.NoError(t, err)
+	*now = now.Add(time.Hour)
+	scheduler.runDue(context.Background())
+	_, err = scheduler.Pause(order.ID)
+	require.NoError(t, err)
+
+	store, err := NewFileOrderStore(dir)
+	require.NoError(t, err)
+	restored, err := NewDCAScheduler(scheduler.router, store)
+	require.NoError(t, err)
+
+	got, err := restored.Get(order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, DCAPaused, got.Status)
+	assert.Equal(t, 1, got.Runs)
+	assert.Len(t, got.Fills, 1)
+	assert.Equal(t, time.Hour, got.Interval)
+	assert.Equal(t, "alice", got.Owner)
+}
+
+func TestNextDCARun(t *testing.T) {
+	last := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, last.Add(time.Hour), nextDCARun(last, time.Hour, last))
+	assert.Equal(t, last.Add(time.Hour), nextDCARun(last, time.Hour, last.Add(30*time.Minute)))
+
+	// Runs missed while the router was down are skipped
+	assert.Equal(t, last.Add(4*time.Hour), nextDCARun(last, time.Hour, last.Add(3*time.Hour)))
+	assert.Equal(t, last.Add(4*time.Hour), nextDCARun(last, time.Hour, last.Add(3*time.Hour+time.Minute)))
+}
+
+func TestDCAHandlers(t *testing.T) {
+	scheduler, _, _, _ := newDCATestScheduler(t)
+	server := NewServer(scheduler.router, "0")
+
+	body := `{"fromAsset":"HBD","toAsset":"HIVE","amount":100000,"sender":"alice","intervalSeconds":3600,"maxRuns":5}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/dca", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	location := rec.Header().Get("Location")
+	require.True(t, strings.HasPrefix(location, "/api/v1/dca/"))
+	orders := scheduler.List("alice")
+	require.Len(t, orders, 1)
+	assert.Equal(t, uint64(50), orders[0].MaxSlippage)
+
+	rec = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, location+"/pause", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"status":"paused"`)
+
+	rec = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, location+"/pause", nil))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, location, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"status":"cancelled"`)
+
+	rec = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/dca?owner=bob", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"orders":[]}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/dca/missing", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// Short intervals are rejected
+	body = `{"fromAsset":"HBD","toAsset":"HIVE","amount":100000,"sender":"alice","intervalSeconds":5}`
+	rec = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/dca", strings.NewReader(body)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Without a scheduler the endpoints are unavailable
+	bare := NewServer(NewService(VSCConfig{}, &mockDEXExecutor{}), "0")
+	rec = httptest.NewRecorder()
+	bare.http.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/dca", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
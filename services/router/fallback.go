@@ -0,0 +1,68 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// FailedRoute is a route a swap tried before falling back to another
+type FailedRoute struct {
+	Route []string `json:"route"`
+	Pools []string `json:"pools"`
+	Error string   `json:"error"`
+}
+
+// fallbackSwap retries a swap whose route was refused before it was
+// broadcast. The retry avoids the pool the error names, or every pool of the
+// failed route if it names none, and is pinned to the best remaining route.
+// That route must still return at least the failed quote's output less the
+// swap's slippage. The deadline is checked again before the retry goes out.
+// The bool is false if no fallback was tried.
+func (r *Service) fallbackSwap(ctx context.Context, params SwapParams, quote *Quote, failed *SwapResult, progress func(status JobStatus, txID string)) (*SwapResult, bool) {
+	if params.FallbackRoutes <= 0 || quote == nil || ctx.Err() != nil {
+		return nil, false
+	}
+
+	attempt := FailedRoute{Route: quote.Route, Error: failed.ErrorMessage}
+	for _, hop := range quote.Hops {
+		attempt.Pools = append(attempt.Pools, hop.PoolID)
+	}
+
+	retry := params
+	retry.Route = nil
+	retry.pools = nil
+	retry.FallbackRoutes--
+	retry.ExcludePools = append(append([]string{}, params.ExcludePools...), failedPools(attempt)...)
+	retry.claim = nil
+	retry.Quote = nil // Already checked; its floor is kept in MinAmountOut
+
+	next, err := r.quote(ctx, retry, 0)
+	if err != nil {
+		failed.ErrorMessage += fmt.Sprintf("; no fallback route: %v", err)
+		return nil, false
+	}
+	floor := quote.MinimumReceived(params.MaxSlippage)
+	if next.AmountOut < floor {
+		failed.ErrorMessage += fmt.Sprintf("; fallback route %s returns %d, below the %d allowed by slippage", strings.Join(next.Route, " -> "), next.AmountOut, floor)
+		return nil, false
+	}
+	retry.Route = next.Route
+
+	log.Printf("Swap route %s failed (%s); falling back to %s", strings.Join(attempt.Route, " -> "), attempt.Error, strings.Join(retry.Route, " -> "))
+	result := r.runSwap(ctx, retry, progress)
+	result.FailedRoutes = append([]FailedRoute{attempt}, result.FailedRoutes...)
+	return result, true
+}
+
+// failedPools returns the pool a failed route's error names, or every pool
+// of the route if it names none
+func failedPools(attempt FailedRoute) []string {
+	for _, poolID := range attempt.Pools {
+		if strings.Contains(attempt.Error, poolID) {
+			return []string{poolID}
+		}
+	}
+	return attempt.Pools
+}
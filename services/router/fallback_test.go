@@ -0,0 +1,93 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// refusingExecutor refuses swaps through the hbd-hive pool, as the contract
+// would once that pool has moved
+type refusingExecutor struct {
+	mockDEXExecutor
+	refusals int
+}
+
+func (m *refusingExecutor) ExecuteDexOperationWithIntents(ctx context.Context, operationType string, payload string, intents []Intent) error {
+	if strings.Contains(payload, `"route":["hbd-hive"]`) {
+		m.refusals++
+		return fmt.Errorf("pool hbd-hive: reserves changed")
+	}
+	return m.mockDEXExecutor.ExecuteDexOperationWithIntents(ctx, operationType, payload, intents)
+}
+
+func newFallbackTestService(t *testing.T) (*Service, *refusingExecutor) {
+	executor := &refusingExecutor{}
+	svc := NewService(VSCConfig{}, executor)
+	svc.SetPoolQuerier(newRoutingTestService(t, DefaultRoutingConfig()).poolQuerier)
+	return svc, executor
+}
+
+func TestFallbackRoute(t *testing.T) {
+	svc, executor := newFallbackTestService(t)
+	params := SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000, MaxSlippage: 50, FallbackRoutes: 1}
+
+	result, err := svc.ExecuteSwap(context.Background(), params)
+	require.NoError(t, err)
+	require.True(t, result.Success, result.ErrorMessage)
+	assert.Equal(t, []string{"HBD", "SPK", "HIVE"}, result.Route)
+
+	// The failed route is reported, and the retry avoided its pool
+	require.Len(t, result.FailedRoutes, 1)
+	assert.Equal(t, []string{"HBD", "HIVE"}, result.FailedRoutes[0].Route)
+	assert.Equal(t, []string{"hbd-hive"}, result.FailedRoutes[0].Pools)
+	assert.Contains(t, result.FailedRoutes[0].Error, "reserves changed")
+	assert.Equal(t, 1, executor.refusals)
+	require.Len(t, executor.executedOperations, 1)
+	assert.Contains(t, executor.executedOperations[0], `"route":"HBD,SPK,HIVE"`)
+}
+
+func TestFallbackRouteLimits(t *testing.T) {
+	ctx := context.Background()
+	params := SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000, MaxSlippage: 50}
+
+	// Fallback is off unless requested
+	svc, executor := newFallbackTestService(t)
+	result, err := svc.ExecuteSwap(ctx, params)
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Empty(t, result.FailedRoutes)
+	assert.Equal(t, 1, executor.refusals)
+
+	// The route through SPK returns about 0.4% less, outside 10 bps of
+	// slippage
+	params.FallbackRoutes = 1
+	params.MaxSlippage = 10
+	result, err = svc.ExecuteSwap(ctx, params)
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.ErrorMessage, "below the")
+	assert.Contains(t, result.ErrorMessage, "allowed by slippage")
+	assert.Empty(t, executor.executedOperations)
+
+	// Excluded pools leave no route to fall back to
+	params.MaxSlippage = 50
+	params.ExcludePools = []string{"spk-hive"}
+	result, err = svc.ExecuteSwap(ctx, params)
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.ErrorMessage, "no fallback route")
+	assert.Empty(t, executor.executedOperations)
+}
+
+func TestFailedPools(t *testing.T) {
+	attempt := FailedRoute{Pools: []string{"btc-hbd", "hbd-hive"}, Error: "insufficient output"}
+	assert.Equal(t, []string{"btc-hbd", "hbd-hive"}, failedPools(attempt))
+
+	attempt.Error = "pool btc-hbd: reserves changed"
+	assert.Equal(t, []string{"btc-hbd"}, failedPools(attempt))
+}
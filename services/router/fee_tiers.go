@@ -0,0 +1,249 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FeeTier rebates part of the referral fee on swaps by accounts that have
+// traded at least MinVolume
+type FeeTier struct {
+	Name      string `json:"name"`
+	MinVolume int64  `json:"minVolume"` // Cumulative swap volume, valued in HBD
+	RebateBps uint64 `json:"rebateBps"` // Share of the referral fee waived, in basis points
+}
+
+// AccountVolume is an account's cumulative swap volume and the fee tier it
+// has reached
+type AccountVolume struct {
+	Account   string    `json:"account"`
+	Volume    int64     `json:"volume"` // Swap input valued in HBD when each swap was broadcast
+	Swaps     int       `json:"swaps"`
+	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+	Tier      *FeeTier  `json:"tier,omitempty"`     // Tier reached; nil below the first
+	NextTier  *FeeTier  `json:"nextTier,omitempty"` // Next tier up, if any
+}
+
+// VolumeTiers tracks each account's cumulative swap volume and rebates
+// referral fees on the swaps of accounts that reach a fee tier
+type VolumeTiers struct {
+	path  string    // File volumes are persisted to; empty keeps them in memory
+	tiers []FeeTier // By ascending MinVolume
+
+	mu      sync.Mutex
+	volumes map[string]*AccountVolume
+	now     func() time.Time
+}
+
+// NewVolumeTiers creates volume tracking with a tier schedule, persisted to
+// path and loading any volumes already saved there. An empty path keeps
+// volumes in memory.
+func NewVolumeTiers(path string, tiers []FeeTier) (*VolumeTiers, error) {
+	sorted := append([]FeeTier(nil), tiers...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].MinVolume < sorted[j].MinVolume })
+	for i, tier := range sorted {
+		if tier.Name == "" {
+			return nil, fmt.Errorf("fee tiers must be named")
+		}
+		if tier.MinVolume <= 0 {
+			return nil, fmt.Errorf("fee tier %s: minimum volume must be greater than 0", tier.Name)
+		}
+		if tier.RebateBps > maxRefBps {
+			return nil, fmt.Errorf("fee tier %s: rebate must be at most %d bps", tier.Name, maxRefBps)
+		}
+		if i > 0 && tier.MinVolume == sorted[i-1].MinVolume {
+			return nil, fmt.Errorf("fee tiers %s and %s have the same minimum volume", sorted[i-1].Name, tier.Name)
+		}
+	}
+
+	v := &VolumeTiers{
+		path:    path,
+		tiers:   sorted,
+		volumes: make(map[string]*AccountVolume),
+		now:     time.Now,
+	}
+	if path == "" {
+		return v, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return v, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read volumes: %w", err)
+	}
+	var volumes []AccountVolume
+	if err := json.Unmarshal(data, &volumes); err != nil {
+		return nil, fmt.Errorf("failed to decode volumes: %w", err)
+	}
+	for i := range volumes {
+		volume := volumes[i]
+		volume.Tier, volume.NextTier = nil, nil
+		v.volumes[volume.Account] = &volume
+	}
+	return v, nil
+}
+
+// SetVolumeTiers enables volume tracking and fee tiers
+func (s *Service) SetVolumeTiers(tiers *VolumeTiers) {
+	s.volumes = tiers
+}
+
+// Tiers returns the tier schedule, lowest first
+func (v *VolumeTiers) Tiers() []FeeTier {
+	return append([]FeeTier(nil), v.tiers...)
+}
+
+// Get returns an account's cumulative volume and fee tier. Accounts that
+// have not swapped have no volume.
+func (v *VolumeTiers) Get(account string) AccountVolume {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	volume := AccountVolume{Account: account}
+	if recorded, ok := v.volumes[account]; ok {
+		volume = *recorded
+	}
+	volume.Tier, volume.NextTier = v.tierFor(volume.Volume)
+	return volume
+}
+
+// tierFor returns the tier a volume reaches and the one above it
+func (v *VolumeTiers) tierFor(volume int64) (*FeeTier, *FeeTier) {
+	var reached, next *FeeTier
+	for i := range v.tiers {
+		tier := v.tiers[i]
+		if volume >= tier.MinVolume {
+			reached = &tier
+		} else {
+			next = &tier
+			break
+		}
+	}
+	return reached, next
+}
+
+// rebate reduces a swap's referral fee by its sender's tier rebate
+func (v *VolumeTiers) rebate(params SwapParams) SwapParams {
+	if params.tiered {
+		return params
+	}
+	params.tiered = true
+	if params.RefBps == 0 || params.Sender == "" {
+		return params
+	}
+	if tier := v.Get(params.Sender).Tier; tier != nil {
+		params.RefBps -= params.RefBps * tier.RebateBps / 10000
+	}
+	return params
+}
+
+// record adds a broadcast swap's volume to its sender's total
+func (v *VolumeTiers) record(account string, volume int64) {
+	if account == "" || volume <= 0 {
+		return
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	recorded, ok := v.volumes[account]
+	if !ok {
+		recorded = &AccountVolume{Account: account}
+		v.volumes[account] = recorded
+	}
+	recorded.Volume += volume
+	recorded.Swaps++
+	recorded.UpdatedAt = v.now()
+	if err := v.saveLocked(); err != nil {
+		// The total still counts in memory and is saved with the next swap
+		log.Printf("Failed to save volumes: %v", err)
+	}
+}
+
+// saveLocked writes the volumes to the tracker's file; caller must hold v.mu
+func (v *VolumeTiers) saveLocked() error {
+	if v.path == "" {
+		return nil
+	}
+
+	volumes := make([]AccountVolume, 0, len(v.volumes))
+	for _, volume := range v.volumes {
+		volumes = append(volumes, *volume)
+	}
+	sort.Slice(volumes, func(i, j int) bool { return volumes[i].Account < volumes[j].Account })
+
+	data, err := json.MarshalIndent(volumes, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode volumes: %w", err)
+	}
+	if err := writeFileAtomic(v.path, data); err != nil {
+		return fmt.Errorf("failed to write volumes: %w", err)
+	}
+	return nil
+}
+
+// applyFeeTier rebates a swap's referral fee by its sender's fee tier, once
+func (s *Service) applyFeeTier(params SwapParams) SwapParams {
+	if s.volumes == nil {
+		return params
+	}
+	return s.volumes.rebate(params)
+}
+
+// recordVolume adds a broadcast swap to its sender's volume, valued in HBD:
+// its HBD leg if it has one, or else its input at the spot price of the
+// input's deepest HBD pool
+func (s *Service) recordVolume(params SwapParams, result *SwapResult) {
+	if s.volumes == nil || !result.Success {
+		return
+	}
+	var volume int64
+	switch {
+	case params.AssetIn == hubAsset:
+		volume = params.AmountIn
+	case params.AssetOut == hubAsset:
+		volume = result.AmountOut
+	default:
+		value, ok := s.hubValue(params.AssetIn, params.AmountIn)
+		if !ok {
+			log.Printf("Volume of %s's swap from %s unpriced: no HBD pool", params.Sender, params.AssetIn)
+			return
+		}
+		volume = value
+	}
+	s.volumes.record(params.Sender, volume)
+}
+
+// hubValue values an amount of asset in HBD at the spot price of its
+// deepest HBD pool
+func (s *Service) hubValue(asset string, amount int64) (int64, bool) {
+	if s.poolQuerier == nil {
+		return 0, false
+	}
+	pools, err := s.poolQuerier.GetPoolsByAsset(hubAsset)
+	if err != nil {
+		return 0, false
+	}
+	var deepest, reserveAsset uint64
+	for _, pool := range pools {
+		if !poolHasAsset(pool, asset) || pool.concentrated() {
+			continue
+		}
+		reserveHub, reserveOther, _ := orientPool(pool, hubAsset)
+		if reserveHub > deepest && reserveOther > 0 {
+			deepest, reserveAsset = reserveHub, reserveOther
+		}
+	}
+	if deepest == 0 {
+		return 0, false
+	}
+	return int64(float64(amount) * float64(deepest) / float64(reserveAsset)), true
+}
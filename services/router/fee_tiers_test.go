@@ -0,0 +1,119 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testFeeTiers = []FeeTier{
+	{Name: "gold", MinVolume: 100000, RebateBps: 5000},
+	{Name: "silver", MinVolume: 10000, RebateBps: 2000},
+}
+
+func TestVolumeTiers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "volumes.json")
+	volumes, err := NewVolumeTiers(path, testFeeTiers)
+	require.NoError(t, err)
+	assert.Equal(t, "silver", volumes.Tiers()[0].Name, "tiers are sorted by volume")
+
+	volume := volumes.Get("alice")
+	assert.Zero(t, volume.Volume)
+	assert.Nil(t, volume.Tier)
+	assert.Equal(t, "silver", volume.NextTier.Name)
+
+	volumes.record("alice", 6000)
+	volumes.record("alice", 6000)
+	volume = volumes.Get("alice")
+	assert.Equal(t, int64(12000), volume.Volume)
+	assert.Equal(t, 2, volume.Swaps)
+	assert.Equal(t, "silver", volume.Tier.Name)
+	assert.Equal(t, "gold", volume.NextTier.Name)
+
+	// Volumes survive a restart
+	reloaded, err := NewVolumeTiers(path, testFeeTiers)
+	require.NoError(t, err)
+	assert.Equal(t, int64(12000), reloaded.Get("alice").Volume)
+
+	for _, tiers := range [][]FeeTier{
+		{{Name: "", MinVolume: 1}},
+		{{Name: "a", MinVolume: 0}},
+		{{Name: "a", MinVolume: 1, RebateBps: 10001}},
+		{{Name: "a", MinVolume: 1}, {Name: "b", MinVolume: 1}},
+	} {
+		_, err := NewVolumeTiers("", tiers)
+		assert.Error(t, err, "%+v", tiers)
+	}
+}
+
+func TestVolumeTiersRebate(t *testing.T) {
+	volumes, err := NewVolumeTiers("", testFeeTiers)
+	require.NoError(t, err)
+	volumes.record("alice", 100000)
+
+	params := SwapParams{Sender: "alice", Beneficiary: "wallet-fees", RefBps: 100}
+	rebated := volumes.rebate(params)
+	assert.Equal(t, uint64(50), rebated.RefBps)
+	assert.Equal(t, uint64(50), volumes.rebate(rebated).RefBps, "the rebate is applied once")
+
+	params.Sender = "bob"
+	assert.Equal(t, uint64(100), volumes.rebate(params).RefBps)
+}
+
+func TestFeeTiersAppliedToSwaps(t *testing.T) {
+	svc, executor := newQuoteTestService()
+	volumes, err := NewVolumeTiers("", testFeeTiers)
+	require.NoError(t, err)
+	svc.SetVolumeTiers(volumes)
+	ctx := context.Background()
+
+	swap := SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 10000, MaxSlippage: 100, Beneficiary: "wallet-fees", RefBps: 100}
+	result, err := svc.ExecuteSwap(ctx, swap)
+	require.NoError(t, err)
+	require.True(t, result.Success, result.ErrorMessage)
+	assert.Contains(t, executor.executedOperations[0], `"ref_bps":100`)
+
+	// The first swap reaches silver, so the next one's referral fee is rebated
+	result, err = svc.ExecuteSwap(ctx, swap)
+	require.NoError(t, err)
+	require.True(t, result.Success, result.ErrorMessage)
+	assert.Contains(t, executor.executedOperations[1], `"ref_bps":80`)
+
+	// Swaps without an HBD leg are valued at the spot price of the input's HBD pool
+	result, err = svc.ExecuteSwap(ctx, SwapParams{Sender: "bob", AssetIn: "BTC", AssetOut: "HIVE", AmountIn: 20000, MaxSlippage: 500})
+	require.NoError(t, err)
+	require.True(t, result.Success, result.ErrorMessage)
+	assert.Equal(t, int64(20000), volumes.Get("alice").Volume)
+	assert.Equal(t, int64(10000), volumes.Get("bob").Volume)
+}
+
+func TestAccountVolumeEndpoint(t *testing.T) {
+	svc, _ := newQuoteTestService()
+	server := NewServer(svc, "0")
+
+	w := serveTestRequest(server, http.MethodGet, "/api/v1/accounts/alice/volume", "")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	volumes, err := NewVolumeTiers("", testFeeTiers)
+	require.NoError(t, err)
+	volumes.record("alice", 15000)
+	svc.SetVolumeTiers(volumes)
+
+	w = serveTestRequest(server, http.MethodGet, "/api/v1/accounts/alice/volume", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var volume AccountVolume
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&volume))
+	assert.Equal(t, int64(15000), volume.Volume)
+	assert.Equal(t, "silver", volume.Tier.Name)
+
+	w = serveTestRequest(server, http.MethodGet, "/api/v1/fee-tiers", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var tiers []FeeTier
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&tiers))
+	assert.Equal(t, []string{"silver", "gold"}, []string{tiers[0].Name, tiers[1].Name})
+}
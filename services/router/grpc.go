@@ -0,0 +1,342 @@
+package router
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/vsc-eco/vsc-dex-mapping/services/router/routerpb"
+)
+
+// GRPCServer serves the router's quote and execution API over gRPC, defined
+// in proto/router.proto. Failed swaps are reported in the result, as over
+// HTTP; gRPC errors are reserved for invalid requests and unknown jobs.
+type GRPCServer struct {
+	routerpb.UnimplementedRouterServer
+
+	router *Service
+	grpc   *grpc.Server
+	port   string
+}
+
+// NewGRPCServer creates a new gRPC server for the router service
+func NewGRPCServer(svc *Service, port string) *GRPCServer {
+	s := &GRPCServer{
+		router: svc,
+		grpc:   grpc.NewServer(),
+		port:   port,
+	}
+	routerpb.RegisterRouterServer(s.grpc, s)
+	return s
+}
+
+// Start listens on the configured port and serves until stopped
+func (s *GRPCServer) Start() error {
+	lis, err := net.Listen("tcp", ":"+s.port)
+	if err != nil {
+		return err
+	}
+	return s.Serve(lis)
+}
+
+// Serve serves on an existing listener until stopped
+func (s *GRPCServer) Serve(lis net.Listener) error {
+	return s.grpc.Serve(lis)
+}
+
+// Stop waits for in-flight calls to finish, closing any still open when ctx
+// is done
+func (s *GRPCServer) Stop(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.grpc.Stop()
+		return ctx.Err()
+	}
+}
+
+// Quote computes the best route and expected output without submitting
+func (s *GRPCServer) Quote(ctx context.Context, req *routerpb.SwapRequest) (*routerpb.QuoteResponse, error) {
+	if err := s.allowCall(ctx, callQuote); err != nil {
+		return nil, err
+	}
+	params := swapParamsFromProto(req)
+	quote, err := s.router.Quote(ctx, params)
+	s.router.metrics.observeQuote(err)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return &routerpb.QuoteResponse{
+		AssetIn:         quote.AssetIn,
+		AssetOut:        quote.AssetOut,
+		AmountIn:        quote.AmountIn,
+		AmountOut:       quote.AmountOut,
+		Route:           quote.Route,
+		Hops:            hopsToProto(quote.Hops),
+		PriceImpact:     quote.PriceImpact,
+		MinimumReceived: quote.MinimumReceived(params.MaxSlippage),
+		Cost:            costToProto(quote.Cost),
+		NetAmountOut:    quote.NetAmountOut,
+	}, nil
+}
+
+// Swap executes a swap and waits for its result
+func (s *GRPCServer) Swap(ctx context.Context, req *routerpb.SwapRequest) (*routerpb.ExecutionResult, error) {
+	if err := s.allowCall(ctx, callExecution); err != nil {
+		return nil, err
+	}
+	result, err := s.router.ExecuteSwap(ctx, s.referredSwap(ctx, req))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return resultToProto(result), nil
+}
+
+// SubmitSwap queues a swap and streams its status until it stops progressing
+func (s *GRPCServer) SubmitSwap(req *routerpb.SwapRequest, stream routerpb.Router_SubmitSwapServer) error {
+	if err := s.allowCall(stream.Context(), callExecution); err != nil {
+		return err
+	}
+	jobID, err := s.router.SubmitSwap(s.referredSwap(stream.Context(), req))
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return s.streamSwap(jobID, stream)
+}
+
+// GetSwapStatus returns the current status of a submitted swap
+func (s *GRPCServer) GetSwapStatus(ctx context.Context, req *routerpb.GetSwapStatusRequest) (*routerpb.SwapStatus, error) {
+	job, err := s.router.GetSwapStatus(req.GetJobId())
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return jobToProto(job), nil
+}
+
+// WatchSwap streams the status of a previously submitted swap
+func (s *GRPCServer) WatchSwap(req *routerpb.GetSwapStatusRequest, stream routerpb.Router_WatchSwapServer) error {
+	return s.streamSwap(req.GetJobId(), stream)
+}
+
+// streamSwap sends a job's status whenever it changes until it is done or
+// the client goes away
+func (s *GRPCServer) streamSwap(jobID string, stream grpc.ServerStreamingServer[routerpb.SwapStatus]) error {
+	updates, err := s.router.WatchSwap(stream.Context(), jobID)
+	if err != nil {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	for job := range updates {
+		if err := stream.Send(jobToProto(&job)); err != nil {
+			return err
+		}
+	}
+	return stream.Context().Err()
+}
+
+// Deposit adds liquidity to a pool
+func (s *GRPCServer) Deposit(ctx context.Context, req *routerpb.DepositRequest) (*routerpb.ExecutionResult, error) {
+	if err := s.allowCall(ctx, callExecution); err != nil {
+		return nil, err
+	}
+	result, err := s.router.ExecuteDeposit(ctx, DepositParams{
+		Sender:    req.GetSender(),
+		AssetIn:   req.GetFromAsset(),
+		AssetOut:  req.GetToAsset(),
+		AmountIn:  req.GetAmount(),
+		AmountOut: req.GetPairAmount(),
+	})
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return resultToProto(result), nil
+}
+
+// Withdraw removes liquidity from a pool
+func (s *GRPCServer) Withdraw(ctx context.Context, req *routerpb.WithdrawRequest) (*routerpb.ExecutionResult, error) {
+	if err := s.allowCall(ctx, callExecution); err != nil {
+		return nil, err
+	}
+	result, err := s.router.ExecuteWithdrawal(ctx, WithdrawalParams{
+		Sender:   req.GetSender(),
+		AssetIn:  req.GetFromAsset(),
+		AssetOut: req.GetToAsset(),
+		LpAmount: req.GetLpAmount(),
+	})
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return resultToProto(result), nil
+}
+
+// swapParamsFromProto converts a swap request, applying the HTTP API's
+// default slippage
+func swapParamsFromProto(req *routerpb.SwapRequest) SwapParams {
+	slippage := req.GetSlippageBps()
+	if slippage == 0 {
+		slippage = 50 // 0.5% default slippage
+	}
+	return SwapParams{
+		AssetIn:        req.GetFromAsset(),
+		AssetOut:       req.GetToAsset(),
+		AmountIn:       req.GetAmount(),
+		MinAmountOut:   req.GetMinOut(),
+		MaxSlippage:    slippage,
+		Sender:         req.GetSender(),
+		Deadline:       unixDeadline(req.GetDeadline()),
+		CommitReveal:   req.GetCommitReveal(),
+		IdempotencyKey: req.GetIdempotencyKey(),
+		FallbackRoutes: int(req.GetFallbackRoutes()),
+		ExcludePools:   req.GetExcludePools(),
+		Quote:          quoteFromProto(req.GetQuote()),
+		Requote:        req.GetRequote(),
+	}
+}
+
+// quoteFromProto converts a quote passed back with a swap
+func quoteFromProto(quote *routerpb.QuoteResponse) *Quote {
+	if quote == nil {
+		return nil
+	}
+	out := &Quote{
+		AssetIn:      quote.GetAssetIn(),
+		AssetOut:     quote.GetAssetOut(),
+		AmountIn:     quote.GetAmountIn(),
+		AmountOut:    quote.GetAmountOut(),
+		Route:        quote.GetRoute(),
+		PriceImpact:  quote.GetPriceImpact(),
+		NetAmountOut: quote.GetNetAmountOut(),
+	}
+	for _, hop := range quote.GetHops() {
+		out.Hops = append(out.Hops, HopQuote{
+			PoolID:     hop.GetPoolId(),
+			AssetIn:    hop.GetAssetIn(),
+			AssetOut:   hop.GetAssetOut(),
+			AmountIn:   hop.GetAmountIn(),
+			AmountOut:  hop.GetAmountOut(),
+			FeeBps:     hop.GetFeeBps(),
+			Fee:        hop.GetFee(),
+			ReserveIn:  hop.GetReserveIn(),
+			ReserveOut: hop.GetReserveOut(),
+		})
+	}
+	return out
+}
+
+// referredSwap converts a swap request, applying the referral defaults of
+// the API key in the call's x-api-key metadata
+func (s *GRPCServer) referredSwap(ctx context.Context, req *routerpb.SwapRequest) SwapParams {
+	return s.router.ApplyReferral(swapParamsFromProto(req), apiKeyFrom(ctx))
+}
+
+// apiKeyFrom returns the API key in a call's x-api-key metadata
+func apiKeyFrom(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if keys := md.Get("x-api-key"); len(keys) > 0 {
+			return keys[0]
+		}
+	}
+	return ""
+}
+
+// allowCall counts a call against its caller's rate limit, failing with
+// ResourceExhausted if the caller is over it
+func (s *GRPCServer) allowCall(ctx context.Context, kind callKind) error {
+	if s.router.rateLimiter == nil {
+		return nil
+	}
+	var address string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		address = p.Addr.String()
+		if host, _, err := net.SplitHostPort(address); err == nil {
+			address = host
+		}
+	}
+	ok, wait := s.router.rateLimiter.allow(kind, s.router.rateLimitKey(apiKeyFrom(ctx), address))
+	if ok {
+		return nil
+	}
+	s.router.metrics.observeRateLimited(kind)
+	return status.Errorf(codes.ResourceExhausted, "rate limit exceeded for %s calls, retry in %s", kind, wait.Round(time.Millisecond))
+}
+
+func hopsToProto(hops []HopQuote) []*routerpb.Hop {
+	out := make([]*routerpb.Hop, 0, len(hops))
+	for _, hop := range hops {
+		out = append(out, &routerpb.Hop{
+			PoolId:     hop.PoolID,
+			AssetIn:    hop.AssetIn,
+			AssetOut:   hop.AssetOut,
+			AmountIn:   hop.AmountIn,
+			AmountOut:  hop.AmountOut,
+			FeeBps:     hop.FeeBps,
+			Fee:        hop.Fee,
+			ReserveIn:  hop.ReserveIn,
+			ReserveOut: hop.ReserveOut,
+		})
+	}
+	return out
+}
+
+func costToProto(cost *RouteCost) *routerpb.RouteCost {
+	if cost == nil {
+		return nil
+	}
+	return &routerpb.RouteCost{Hbd: cost.HBD, AmountOut: cost.AmountOut}
+}
+
+func resultToProto(result *SwapResult) *routerpb.ExecutionResult {
+	return &routerpb.ExecutionResult{
+		Success:            result.Success,
+		AmountOut:          result.AmountOut,
+		Fee:                result.Fee,
+		Route:              result.Route,
+		ErrorMessage:       result.ErrorMessage,
+		TxId:               result.TxID,
+		Settled:            result.Settled,
+		EstimatedAmountOut: result.EstimatedAmountOut,
+		PriceImpact:        result.PriceImpact,
+		EffectivePrice:     result.EffectivePrice,
+		MinimumReceived:    result.MinimumReceived,
+		HopFees:            hopsToProto(result.HopFees),
+		Warnings:           result.Warnings,
+		ApprovalId:         result.ApprovalID,
+	}
+}
+
+// jobStatuses maps swap job statuses to their protobuf enum
+var jobStatuses = map[JobStatus]routerpb.SwapStatus_Status{
+	JobQueued:    routerpb.SwapStatus_STATUS_QUEUED,
+	JobBroadcast: routerpb.SwapStatus_STATUS_BROADCAST,
+	JobIncluded:  routerpb.SwapStatus_STATUS_INCLUDED,
+	JobConfirmed: routerpb.SwapStatus_STATUS_CONFIRMED,
+	JobFailed:    routerpb.SwapStatus_STATUS_FAILED,
+}
+
+func jobToProto(job *SwapJob) *routerpb.SwapStatus {
+	msg := &routerpb.SwapStatus{
+		JobId:     job.ID,
+		Status:    jobStatuses[job.Status],
+		TxId:      job.TxID,
+		Error:     job.Error,
+		CreatedAt: job.CreatedAt.UnixMilli(),
+		UpdatedAt: job.UpdatedAt.UnixMilli(),
+	}
+	if job.Result != nil {
+		msg.Result = resultToProto(job.Result)
+	}
+	return msg
+}
@@ -0,0 +1,124 @@
+package router
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/vsc-eco/vsc-dex-mapping/services/router/routerpb"
+)
+
+// newGRPCTestClient serves svc over an in-memory connection
+func newGRPCTestClient(t *testing.T, svc *Service) routerpb.RouterClient {
+	lis := bufconn.Listen(1 << 20)
+	server := NewGRPCServer(svc, "0")
+	go server.Serve(lis)
+	t.Cleanup(func() { server.Stop(context.Background()) })
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return routerpb.NewRouterClient(conn)
+}
+
+func TestGRPCQuote(t *testing.T) {
+	svc, executor := newQuoteTestService()
+	client := newGRPCTestClient(t, svc)
+
+	quote, err := client.Quote(context.Background(), &routerpb.SwapRequest{FromAsset: "HBD", ToAsset: "HIVE", Amount: 10000})
+	require.NoError(t, err)
+	assert.Equal(t, int64(39486), quote.AmountOut)
+	assert.Equal(t, []string{"HBD", "HIVE"}, quote.Route)
+	require.Len(t, quote.Hops, 1)
+	assert.Equal(t, "1", quote.Hops[0].PoolId)
+	assert.Equal(t, int64(39288), quote.MinimumReceived) // Default 0.5% slippage
+	assert.Empty(t, executor.executedOperations, "quotes never submit")
+
+	_, err = client.Quote(context.Background(), &routerpb.SwapRequest{FromAsset: "HBD", ToAsset: "HBD", Amount: 10000})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPCSwap(t *testing.T) {
+	svc, executor := newQuoteTestService()
+	client := newGRPCTestClient(t, svc)
+
+	result, err := client.Swap(context.Background(), &routerpb.SwapRequest{FromAsset: "HBD", ToAsset: "HIVE", Amount: 10000, Sender: "alice"})
+	require.NoError(t, err)
+	assert.True(t, result.Success, result.ErrorMessage)
+	assert.Equal(t, int64(39486), result.EstimatedAmountOut)
+	require.Len(t, executor.executedOperations, 1)
+
+	// Failed swaps are reported in the result rather than as errors
+	result, err = client.Swap(context.Background(), &routerpb.SwapRequest{FromAsset: "HBD", ToAsset: "HBD", Amount: 10000})
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.NotEmpty(t, result.ErrorMessage)
+}
+
+func TestGRPCSubmitSwapStreamsStatus(t *testing.T) {
+	source := &blockingOutcomeSource{release: make(chan struct{}), outcome: &SwapOutcome{
+		TxID: "tx-1",
+		Hops: []HopQuote{{PoolID: "1", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000, AmountOut: 3980}},
+	}}
+	svc, _ := newOutcomeTestService(source)
+	client := newGRPCTestClient(t, svc)
+
+	stream, err := client.SubmitSwap(context.Background(), &routerpb.SwapRequest{FromAsset: "HBD", ToAsset: "HIVE", Amount: 1000, Sender: "alice"})
+	require.NoError(t, err)
+
+	first, err := stream.Recv()
+	require.NoError(t, err)
+	require.NotEmpty(t, first.JobId)
+
+	var statuses []routerpb.SwapStatus_Status
+	last := first
+	for msg := first; ; {
+		statuses = append(statuses, msg.Status)
+		last = msg
+		if msg.Status == routerpb.SwapStatus_STATUS_BROADCAST && msg.Result == nil {
+			close(source.release)
+		}
+		if msg, err = stream.Recv(); err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		assert.Equal(t, first.JobId, msg.JobId)
+	}
+
+	assert.Contains(t, statuses, routerpb.SwapStatus_STATUS_BROADCAST)
+	assert.Equal(t, routerpb.SwapStatus_STATUS_CONFIRMED, last.Status)
+	assert.Equal(t, "tx-1", last.TxId)
+	require.NotNil(t, last.Result)
+	assert.True(t, last.Result.Settled)
+	assert.Equal(t, int64(3980), last.Result.AmountOut)
+
+	status, err := client.GetSwapStatus(context.Background(), &routerpb.GetSwapStatusRequest{JobId: first.JobId})
+	require.NoError(t, err)
+	assert.Equal(t, routerpb.SwapStatus_STATUS_CONFIRMED, status.Status)
+}
+
+func TestGRPCUnknownJob(t *testing.T) {
+	client := newGRPCTestClient(t, NewService(VSCConfig{}, &mockDEXExecutor{}))
+
+	_, err := client.GetSwapStatus(context.Background(), &routerpb.GetSwapStatusRequest{JobId: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	stream, err := client.WatchSwap(context.Background(), &routerpb.GetSwapStatusRequest{JobId: "missing"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -1,16 +1,38 @@
 package router
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
+	"sync"
 	"time"
 )
 
+// defaultPoolListTTL is how long the indexer's pool list is reused before it
+// is revalidated
+const defaultPoolListTTL = time.Second
+
 // IndexerPoolQuerier implements PoolQuerier by querying the indexer HTTP API
 type IndexerPoolQuerier struct {
 	indexerEndpoint string
 	httpClient      *http.Client
+
+	mu       sync.Mutex // Serializes pool list fetches
+	poolList *poolListCache
+	cacheTTL time.Duration
+	now      func() time.Time
+}
+
+// poolListCache is the last pool list fetched, with the validators needed to
+// ask the indexer whether it has changed
+type poolListCache struct {
+	pools        []indexerPoolResponse
+	etag         string
+	lastModified string
+	fetchedAt    time.Time
 }
 
 // NewIndexerPoolQuerier creates a new indexer-based pool querier
@@ -18,14 +40,48 @@ func NewIndexerPoolQuerier(indexerEndpoint string) *IndexerPoolQuerier {
 	return &IndexerPoolQuerier{
 		indexerEndpoint: indexerEndpoint,
 		httpClient: &http.Client{
-			Timeout: 5 * time.Second,
+			Timeout:   5 * time.Second,
+			Transport: newIndexerTransport(),
 		},
+		cacheTTL: defaultPoolListTTL,
+		now:      time.Now,
+	}
+}
+
+// newIndexerTransport keeps connections to the indexer open between queries,
+// since routing makes many small requests to one host
+func newIndexerTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   2 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          64,
+		MaxIdleConnsPerHost:   32,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   2 * time.Second,
+		ExpectContinueTimeout: time.Second,
 	}
 }
 
+// SetCacheTTL sets how long the pool list is reused before it is
+// revalidated with a conditional request. Zero revalidates on every call.
+func (q *IndexerPoolQuerier) SetCacheTTL(ttl time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.cacheTTL = ttl
+}
+
+// poolTypeConcentrated is the type of pools priced by tick rather than by
+// their reserves
+const poolTypeConcentrated = "concentrated"
+
 // IndexerPoolInfo represents pool info from the indexer API
 type IndexerPoolInfo struct {
 	ID          string  `json:"id"`
+	Type        string  `json:"type,omitempty"` // "concentrated" for tick-based pools
 	Asset0      string  `json:"asset0"`
 	Asset1      string  `json:"asset1"`
 	Reserve0    uint64  `json:"reserve0"`
@@ -37,6 +93,7 @@ type IndexerPoolInfo struct {
 // indexerPoolResponse represents the raw response from indexer (Fee as float64)
 type indexerPoolResponse struct {
 	ID          string  `json:"id"`
+	Type        string  `json:"type"`
 	Asset0      string  `json:"asset0"`
 	Asset1      string  `json:"asset1"`
 	Reserve0    uint64  `json:"reserve0"`
@@ -45,8 +102,38 @@ type indexerPoolResponse struct {
 	TotalSupply uint64  `json:"total_supply"`
 }
 
+// toPoolInfo converts to router format (Fee as uint64 basis points)
+func (p indexerPoolResponse) toPoolInfo() IndexerPoolInfo {
+	return IndexerPoolInfo{
+		ID:          p.ID,
+		Type:        p.Type,
+		Asset0:      p.Asset0,
+		Asset1:      p.Asset1,
+		Reserve0:    p.Reserve0,
+		Reserve1:    p.Reserve1,
+		Fee:         uint64(p.Fee * 100), // Convert percentage to basis points
+		TotalSupply: p.TotalSupply,
+	}
+}
+
+// concentrated reports whether the pool concentrates liquidity in tick
+// ranges, so that its reserves cannot be quoted as x*y=k
+func (p IndexerPoolInfo) concentrated() bool {
+	return p.Type == poolTypeConcentrated
+}
+
 // GetPoolByID retrieves a pool by its contract ID
 func (q *IndexerPoolQuerier) GetPoolByID(poolID string) (*IndexerPoolInfo, error) {
+	// A fresh pool list already has it
+	if pools, ok := q.freshPools(); ok {
+		for _, pool := range pools {
+			if pool.ID == poolID {
+				info := pool.toPoolInfo()
+				return &info, nil
+			}
+		}
+	}
+
 	url := fmt.Sprintf("%s/api/v1/pools/%s", q.indexerEndpoint, poolID)
 	
 	resp, err := q.httpClient.Get(url)
@@ -69,28 +156,99 @@ func (q *IndexerPoolQuerier) GetPoolByID(poolID string) (*IndexerPoolInfo, error
 		return nil, fmt.Errorf("failed to decode pool response: %w", err)
 	}
 
-	// Convert to router format (Fee as uint64 basis points)
-	return &IndexerPoolInfo{
-		ID:          indexerPool.ID,
-		Asset0:      indexerPool.Asset0,
-		Asset1:      indexerPool.Asset1,
-		Reserve0:    indexerPool.Reserve0,
-		Reserve1:    indexerPool.Reserve1,
-		Fee:         uint64(indexerPool.Fee * 100), // Convert percentage to basis points
-		TotalSupply: indexerPool.TotalSupply,
-	}, nil
+	pool := indexerPool.toPoolInfo()
+	return &pool, nil
 }
 
 // GetPoolsByAsset retrieves all pools containing the specified asset
 func (q *IndexerPoolQuerier) GetPoolsByAsset(asset string) ([]IndexerPoolInfo, error) {
-	url := fmt.Sprintf("%s/api/v1/pools", q.indexerEndpoint)
-	
-	resp, err := q.httpClient.Get(url)
+	indexerPools, err := q.listPools()
+	if err != nil {
+		return nil, err
+	}
+
+	// Filter pools that contain the specified asset and convert to router format
+	var matchingPools []IndexerPoolInfo
+	for _, indexerPool := range indexerPools {
+		if indexerPool.Asset0 == asset || indexerPool.Asset1 == asset {
+			matchingPools = append(matchingPools, indexerPool.toPoolInfo())
+		}
+	}
+
+	return matchingPools, nil
+}
+
+// ListPools returns every pool the indexer knows
+func (q *IndexerPoolQuerier) ListPools() ([]IndexerPoolInfo, error) {
+	indexerPools, err := q.listPools()
+	if err != nil {
+		return nil, err
+	}
+
+	pools := make([]IndexerPoolInfo, 0, len(indexerPools))
+	for _, indexerPool := range indexerPools {
+		pools = append(pools, indexerPool.toPoolInfo())
+	}
+	return pools, nil
+}
+
+// freshPools returns the cached pool list if it is within the cache TTL
+func (q *IndexerPoolQuerier) freshPools() ([]indexerPoolResponse, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.poolList == nil || q.now().Sub(q.poolList.fetchedAt) >= q.cacheTTL {
+		return nil, false
+	}
+	return q.poolList.pools, true
+}
+
+// InvalidatePools marks the pool list stale so the next lookup revalidates
+// it with the indexer. The list holds every pool, so any pool invalidates it.
+func (q *IndexerPoolQuerier) InvalidatePools(poolIDs ...string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.poolList != nil && len(poolIDs) > 0 {
+		q.poolList.fetchedAt = time.Time{}
+	}
+}
+
+// listPools returns every pool the indexer knows. Within the cache TTL the
+// last list is reused; after it, the indexer is asked with a conditional
+// request and only sends the list again if it changed.
+func (q *IndexerPoolQuerier) listPools() ([]indexerPoolResponse, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	cached := q.poolList
+	if cached != nil && q.now().Sub(cached.fetchedAt) < q.cacheTTL {
+		return cached.pools, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/api/v1/pools", q.indexerEndpoint), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build indexer request: %w", err)
+	}
+	if cached != nil {
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	resp, err := q.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query indexer: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		cached.fetchedAt = q.now()
+		return cached.pools, nil
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("indexer returned status %d", resp.StatusCode)
 	}
@@ -101,22 +259,120 @@ func (q *IndexerPoolQuerier) GetPoolsByAsset(asset string) ([]IndexerPoolInfo, e
 		return nil, fmt.Errorf("failed to decode pools response: %w", err)
 	}
 
-	// Filter pools that contain the specified asset and convert to router format
-	var matchingPools []IndexerPoolInfo
-	for _, indexerPool := range indexerPools {
-		if indexerPool.Asset0 == asset || indexerPool.Asset1 == asset {
-			matchingPools = append(matchingPools, IndexerPoolInfo{
-				ID:          indexerPool.ID,
-				Asset0:      indexerPool.Asset0,
-				Asset1:      indexerPool.Asset1,
-				Reserve0:    indexerPool.Reserve0,
-				Reserve1:    indexerPool.Reserve1,
-				Fee:         uint64(indexerPool.Fee * 100), // Convert percentage to basis points
-				TotalSupply: indexerPool.TotalSupply,
-			})
+	q.poolList = &poolListCache{
+		pools:        indexerPools,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+		fetchedAt:    q.now(),
+	}
+	return indexerPools, nil
+}
+
+// GetLiquidityPosition returns the LP tokens account holds in a pool, or zero
+// if it has no position
+func (q *IndexerPoolQuerier) GetLiquidityPosition(poolID, account string) (uint64, error) {
+	url := fmt.Sprintf("%s/api/v1/pools/%s/accounts", q.indexerEndpoint, poolID)
+
+	resp, err := q.httpClient.Get(url)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query indexer: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("indexer returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Accounts []struct {
+			User   string `json:"user"`
+			Amount uint64 `json:"amount"`
+		} `json:"accounts"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("failed to decode accounts response: %w", err)
+	}
+
+	for _, position := range body.Accounts {
+		if position.User == account {
+			return position.Amount, nil
 		}
 	}
+	return 0, nil
+}
 
-	return matchingPools, nil
+// IndexerOutcomeSource implements SwapOutcomeSource by reading the swap
+// events the indexer recorded for a transaction
+type IndexerOutcomeSource struct {
+	indexerEndpoint string
+	httpClient      *http.Client
 }
 
+// NewIndexerOutcomeSource creates a new indexer-based swap outcome source
+func NewIndexerOutcomeSource(indexerEndpoint string) *IndexerOutcomeSource {
+	return &IndexerOutcomeSource{
+		indexerEndpoint: indexerEndpoint,
+		httpClient: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+	}
+}
+
+// indexerSwapEvent is a swap transaction as listed by the indexer
+type indexerSwapEvent struct {
+	ID      string `json:"id"`
+	PoolID  string `json:"pool_id"`
+	Details struct {
+		AmountIn  int64  `json:"amount_in"`
+		AmountOut int64  `json:"amount_out"`
+		AssetIn   string `json:"asset_in"`
+		AssetOut  string `json:"asset_out"`
+	} `json:"details"`
+}
+
+// GetSwapOutcome returns the hops a transaction executed, or
+// ErrOutcomePending if the indexer has not seen it yet
+func (o *IndexerOutcomeSource) GetSwapOutcome(ctx context.Context, txID string) (*SwapOutcome, error) {
+	query := url.Values{"tx_id": {txID}, "type": {"swap"}}
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/api/v1/transactions?%s", o.indexerEndpoint, query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query indexer: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("indexer returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Transactions []indexerSwapEvent `json:"transactions"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode transactions response: %w", err)
+	}
+	if len(body.Transactions) == 0 {
+		return nil, ErrOutcomePending
+	}
+
+	// Transactions are listed most recent first; hops execute in order
+	outcome := &SwapOutcome{TxID: txID}
+	for i := len(body.Transactions) - 1; i >= 0; i-- {
+		event := body.Transactions[i]
+		if event.Details.AssetIn == "" || event.Details.AssetOut == "" {
+			return nil, fmt.Errorf("swap %s in pool %s has no asset details", txID, event.PoolID)
+		}
+		outcome.Hops = append(outcome.Hops, HopQuote{
+			PoolID:    event.PoolID,
+			AssetIn:   event.Details.AssetIn,
+			AssetOut:  event.Details.AssetOut,
+			AmountIn:  event.Details.AmountIn,
+			AmountOut: event.Details.AmountOut,
+		})
+	}
+	return outcome, nil
+}
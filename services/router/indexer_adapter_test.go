@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -254,3 +256,114 @@ func TestGetPoolsByAsset_MalformedJSON(t *testing.T) {
 }
 
 
+
+func TestGetLiquidityPosition(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/pools/pool-1/accounts", r.URL.Path)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"pool_id": "pool-1",
+			"accounts": []map[string]interface{}{
+				{"user": "alice", "pool_id": "pool-1", "amount": 2500, "share": 25.0},
+				{"user": "bob", "pool_id": "pool-1", "amount": 7500, "share": 75.0},
+			},
+		})
+	}))
+	defer server.Close()
+
+	querier := NewIndexerPoolQuerier(server.URL)
+
+	amount, err := querier.GetLiquidityPosition("pool-1", "alice")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2500), amount)
+
+	amount, err = querier.GetLiquidityPosition("pool-1", "carol")
+	require.NoError(t, err)
+	assert.Zero(t, amount)
+}
+
+// poolListServer serves a fixed pool list with an ETag, counting requests and
+// how many were answered 304 Not Modified
+func poolListServer(t *testing.T) (*httptest.Server, *int32, *int32) {
+	var requests, notModified int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/pools", r.URL.Path)
+		atomic.AddInt32(&requests, 1)
+
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"id": "pool-1", "asset0": "BTC", "asset1": "HBD", "reserve0": 1000, "reserve1": 2000, "fee": 0.3},
+			{"id": "pool-2", "asset0": "HBD", "asset1": "HIVE", "reserve0": 3000, "reserve1": 4000, "fee": 0.3},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests, &notModified
+}
+
+func TestGetPoolsByAsset_ReusesListWithinTTL(t *testing.T) {
+	server, requests, _ := poolListServer(t)
+	querier := NewIndexerPoolQuerier(server.URL)
+
+	btc, err := querier.GetPoolsByAsset("BTC")
+	require.NoError(t, err)
+	hive, err := querier.GetPoolsByAsset("HIVE")
+	require.NoError(t, err)
+	pool, err := querier.GetPoolByID("pool-2")
+	require.NoError(t, err)
+
+	assert.Len(t, btc, 1)
+	assert.Len(t, hive, 1)
+	assert.Equal(t, uint64(3000), pool.Reserve0)
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests), "one download should serve every lookup within the TTL")
+}
+
+func TestGetPoolsByAsset_RevalidatesAfterTTL(t *testing.T) {
+	server, requests, notModified := poolListServer(t)
+	querier := NewIndexerPoolQuerier(server.URL)
+	now := time.Now()
+	querier.now = func() time.Time { return now }
+
+	_, err := querier.GetPoolsByAsset("BTC")
+	require.NoError(t, err)
+
+	now = now.Add(2 * defaultPoolListTTL)
+	pools, err := querier.GetPoolsByAsset("BTC")
+	require.NoError(t, err)
+
+	assert.Len(t, pools, 1, "a 304 should reuse the cached list")
+	assert.Equal(t, int32(2), atomic.LoadInt32(requests))
+	assert.Equal(t, int32(1), atomic.LoadInt32(notModified))
+}
+
+func TestIndexerPoolQuerier_InvalidatePools(t *testing.T) {
+	server, requests, notModified := poolListServer(t)
+	querier := NewIndexerPoolQuerier(server.URL)
+
+	_, err := querier.GetPoolsByAsset("BTC")
+	require.NoError(t, err)
+
+	querier.InvalidatePools("pool-1")
+	_, err = querier.GetPoolsByAsset("BTC")
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(requests), "invalidation should force revalidation")
+	assert.Equal(t, int32(1), atomic.LoadInt32(notModified))
+}
+
+func TestIndexerPoolQuerier_ZeroTTLAlwaysRevalidates(t *testing.T) {
+	server, requests, _ := poolListServer(t)
+	querier := NewIndexerPoolQuerier(server.URL)
+	querier.SetCacheTTL(0)
+
+	for i := 0; i < 3; i++ {
+		_, err := querier.GetPoolsByAsset("BTC")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(requests))
+}
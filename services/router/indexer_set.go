@@ -0,0 +1,126 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IndexerSet reads from one or more indexers and can be pointed at others
+// while the router runs. Pools are read from every indexer, through a
+// QuorumPoolQuerier when there are several; LP positions, swap outcomes and
+// the full pool list come from the first.
+type IndexerSet struct {
+	cacheTTL time.Duration
+	quorum   QuorumConfig
+
+	mu        sync.RWMutex
+	endpoints []string
+	primary   *IndexerPoolQuerier
+	pools     PoolQuerier
+	outcomes  *IndexerOutcomeSource
+}
+
+// NewIndexerSet creates an indexer set reading from endpoints. cacheTTL is
+// how long each indexer's pool list is reused before revalidating it, and
+// quorum how closely several indexers must agree.
+func NewIndexerSet(endpoints []string, cacheTTL time.Duration, quorum QuorumConfig) (*IndexerSet, error) {
+	x := &IndexerSet{cacheTTL: cacheTTL, quorum: quorum}
+	if err := x.SetEndpoints(endpoints); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// SetEndpoints points the set at other indexers. Lookups already under way
+// finish against the old ones. Invalid endpoints leave the set unchanged.
+func (x *IndexerSet) SetEndpoints(endpoints []string) error {
+	var cleaned []string
+	for _, endpoint := range endpoints {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			cleaned = append(cleaned, endpoint)
+		}
+	}
+	if len(cleaned) == 0 {
+		return fmt.Errorf("at least one indexer endpoint is required")
+	}
+
+	sources := make([]PoolSource, len(cleaned))
+	for i, endpoint := range cleaned {
+		querier := NewIndexerPoolQuerier(endpoint)
+		querier.SetCacheTTL(x.cacheTTL)
+		sources[i] = PoolSource{Name: endpoint, Querier: querier}
+	}
+	primary := sources[0].Querier.(*IndexerPoolQuerier)
+	var pools PoolQuerier = primary
+	if len(sources) > 1 {
+		quorum, err := NewQuorumPoolQuerier(sources, x.quorum)
+		if err != nil {
+			return err
+		}
+		pools = quorum
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.endpoints = cleaned
+	x.primary = primary
+	x.pools = pools
+	x.outcomes = NewIndexerOutcomeSource(cleaned[0])
+	return nil
+}
+
+// Endpoints returns the indexers currently read from, first the primary
+func (x *IndexerSet) Endpoints() []string {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return append([]string(nil), x.endpoints...)
+}
+
+func (x *IndexerSet) current() (*IndexerPoolQuerier, PoolQuerier, *IndexerOutcomeSource) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return x.primary, x.pools, x.outcomes
+}
+
+// GetPoolByID returns a pool the indexers agree on
+func (x *IndexerSet) GetPoolByID(poolID string) (*IndexerPoolInfo, error) {
+	_, pools, _ := x.current()
+	return pools.GetPoolByID(poolID)
+}
+
+// GetPoolsByAsset returns the pools containing asset that the indexers
+// agree on
+func (x *IndexerSet) GetPoolsByAsset(asset string) ([]IndexerPoolInfo, error) {
+	_, pools, _ := x.current()
+	return pools.GetPoolsByAsset(asset)
+}
+
+// InvalidatePools drops the indexers' cached pool lists
+func (x *IndexerSet) InvalidatePools(poolIDs ...string) {
+	_, pools, _ := x.current()
+	if invalidator, ok := pools.(poolInvalidator); ok {
+		invalidator.InvalidatePools(poolIDs...)
+	}
+}
+
+// ListPools returns every pool known to the primary indexer
+func (x *IndexerSet) ListPools() ([]IndexerPoolInfo, error) {
+	primary, _, _ := x.current()
+	return primary.ListPools()
+}
+
+// GetLiquidityPosition returns an account's LP balance from the primary
+// indexer
+func (x *IndexerSet) GetLiquidityPosition(poolID, account string) (uint64, error) {
+	primary, _, _ := x.current()
+	return primary.GetLiquidityPosition(poolID, account)
+}
+
+// GetSwapOutcome returns an executed swap's outcome from the primary indexer
+func (x *IndexerSet) GetSwapOutcome(ctx context.Context, txID string) (*SwapOutcome, error) {
+	_, _, outcomes := x.current()
+	return outcomes.GetSwapOutcome(ctx, txID)
+}
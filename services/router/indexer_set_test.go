@@ -0,0 +1,74 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestIndexer serves one HBD/HIVE pool with the given HIVE reserve
+func newTestIndexer(t *testing.T, reserve1 float64) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pool := map[string]interface{}{
+			"id":       "hbd-hive",
+			"asset0":   "HBD",
+			"asset1":   "HIVE",
+			"reserve0": float64(1000000),
+			"reserve1": reserve1,
+			"fee":      0.3,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/v1/pools" {
+			json.NewEncoder(w).Encode([]interface{}{pool})
+			return
+		}
+		json.NewEncoder(w).Encode(pool)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestIndexerSetSwitchesEndpoints(t *testing.T) {
+	first := newTestIndexer(t, 4000000)
+	second := newTestIndexer(t, 5000000)
+
+	indexers, err := NewIndexerSet([]string{first.URL}, 0, QuorumConfig{})
+	require.NoError(t, err)
+	pool, err := indexers.GetPoolByID("hbd-hive")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4000000), pool.Reserve1)
+
+	require.NoError(t, indexers.SetEndpoints([]string{second.URL}))
+	assert.Equal(t, []string{second.URL}, indexers.Endpoints())
+	pool, err = indexers.GetPoolByID("hbd-hive")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5000000), pool.Reserve1)
+	pools, err := indexers.ListPools()
+	require.NoError(t, err)
+	require.Len(t, pools, 1)
+	assert.Equal(t, uint64(5000000), pools[0].Reserve1)
+
+	// No endpoints is refused, keeping the current ones
+	assert.Error(t, indexers.SetEndpoints([]string{" "}))
+	assert.Equal(t, []string{second.URL}, indexers.Endpoints())
+}
+
+func TestIndexerSetQuorum(t *testing.T) {
+	first := newTestIndexer(t, 4000000)
+	second := newTestIndexer(t, 4000000)
+	stray := newTestIndexer(t, 8000000)
+
+	indexers, err := NewIndexerSet([]string{first.URL, stray.URL}, 0, QuorumConfig{ToleranceBps: 50})
+	require.NoError(t, err)
+	_, err = indexers.GetPoolByID("hbd-hive")
+	assert.Error(t, err, "two indexers that disagree cannot reach a quorum")
+
+	require.NoError(t, indexers.SetEndpoints([]string{first.URL, second.URL, stray.URL}))
+	pool, err := indexers.GetPoolByID("hbd-hive")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4000000), pool.Reserve1)
+}
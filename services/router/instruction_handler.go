@@ -2,6 +2,7 @@ package router
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/vsc-eco/vsc-dex-mapping/schemas"
 )
@@ -12,6 +13,14 @@ func InstructionToSwapParams(instruction *schemas.SwapInstruction, amountIn int6
 	if instruction == nil {
 		return nil, fmt.Errorf("instruction cannot be nil")
 	}
+	switch instruction.InstructionType {
+	case "swap_exact_out":
+		return nil, fmt.Errorf("exact output swaps are executed by the contract directly and cannot be routed")
+	case "limit_order", "cancel_order", "fill_order":
+		return nil, fmt.Errorf("limit orders are executed by the contract directly and cannot be routed")
+	case "mint_position", "burn_position", "collect_position":
+		return nil, fmt.Errorf("liquidity positions are managed by the contract directly and cannot be routed")
+	}
 
 	// Set default slippage to 50 basis points (0.5%) if not provided
 	maxSlippage := uint64(50)
@@ -36,6 +45,11 @@ func InstructionToSwapParams(instruction *schemas.SwapInstruction, amountIn int6
 		refBps = uint64(*instruction.RefBps)
 	}
 
+	var deadline time.Time
+	if instruction.Deadline != nil {
+		deadline = time.Unix(*instruction.Deadline, 0)
+	}
+
 	return &SwapParams{
 		Sender:         instruction.Recipient,
 		AmountIn:       amountIn,
@@ -46,6 +60,9 @@ func InstructionToSwapParams(instruction *schemas.SwapInstruction, amountIn int6
 		MiddleOutRatio: 0, // Default value, can be adjusted based on routing logic
 		Beneficiary:    beneficiary,
 		RefBps:         refBps,
+		Deadline:       deadline,
+		ReturnAddress:  instruction.ReturnAddr,
+		pools:          instruction.Route,
 	}, nil
 }
 
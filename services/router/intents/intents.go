@@ -0,0 +1,124 @@
+// Package intents builds the intents VSC transactions carry. An intent
+// authorises the contract a transaction calls to act for the sender, such as
+// drawing up to a limit of one token from their balance.
+//
+// Intents are built from typed values, validated, and serialised to the
+// wire form the VSC node accepts: a type and string arguments. Building the
+// same value always gives the same arguments, so payloads, receipts and
+// simulations agree byte for byte.
+package intents
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// TypeTransferAllow lets the called contract transfer up to a limit of one
+// token from the sender
+const TypeTransferAllow = "transfer.allow"
+
+// Intent is an intent in its wire form
+type Intent struct {
+	Type string            `json:"type"`
+	Args map[string]string `json:"args"`
+}
+
+// Builder is a typed intent
+type Builder interface {
+	// Build validates the intent and returns its wire form
+	Build() (Intent, error)
+}
+
+// Build validates and serialises intents, in order
+func Build(builders ...Builder) ([]Intent, error) {
+	built := make([]Intent, 0, len(builders))
+	for i, builder := range builders {
+		intent, err := builder.Build()
+		if err != nil {
+			return nil, fmt.Errorf("intent %d: %w", i, err)
+		}
+		built = append(built, intent)
+	}
+	return built, nil
+}
+
+// TransferAllow lets the called contract transfer up to Limit of Token from
+// the sender
+type TransferAllow struct {
+	Token string
+	Limit uint64
+}
+
+// Validate checks the token is named and the limit is positive
+func (t TransferAllow) Validate() error {
+	if err := validateToken(t.Token); err != nil {
+		return err
+	}
+	if t.Limit == 0 {
+		return fmt.Errorf("transfer limit for %s must be greater than 0", t.Token)
+	}
+	return nil
+}
+
+// Build returns the transfer.allow intent, with the limit in base 10
+func (t TransferAllow) Build() (Intent, error) {
+	if err := t.Validate(); err != nil {
+		return Intent{}, err
+	}
+	return Intent{
+		Type: TypeTransferAllow,
+		Args: map[string]string{
+			"limit": strconv.FormatUint(t.Limit, 10),
+			"token": t.Token,
+		},
+	}, nil
+}
+
+// Combine totals transfer.allow limits per token, in the order tokens first
+// appear, so one transaction can carry several operations' allowances.
+// Totals may exceed what a TransferAllow holds. Other intents, and
+// transfer.allow intents whose limit does not parse, pass through unchanged.
+func Combine(intents []Intent) []Intent {
+	var combined []Intent
+	totals := make(map[string]*big.Int)
+	positions := make(map[string]int) // token -> index in combined
+
+	for _, intent := range intents {
+		limit, ok := new(big.Int).SetString(intent.Args["limit"], 10)
+		if intent.Type != TypeTransferAllow || !ok {
+			combined = append(combined, intent)
+			continue
+		}
+
+		token := intent.Args["token"]
+		if total, seen := totals[token]; seen {
+			total.Add(total, limit)
+			continue
+		}
+		totals[token] = limit
+		positions[token] = len(combined)
+		combined = append(combined, Intent{Type: intent.Type})
+	}
+
+	for token, i := range positions {
+		combined[i].Args = map[string]string{
+			"limit": totals[token].String(),
+			"token": token,
+		}
+	}
+	return combined
+}
+
+// validateToken checks a token symbol is set and printable without spaces
+func validateToken(token string) error {
+	if token == "" {
+		return fmt.Errorf("token is required")
+	}
+	if strings.IndexFunc(token, func(r rune) bool { return unicode.IsSpace(r) || !unicode.IsPrint(r) }) >= 0 {
+		return fmt.Errorf("token %q contains spaces or control characters", token)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package intents
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuild(t *testing.T) {
+	built, err := Build(
+		TransferAllow{Token: "HBD", Limit: 1000},
+		TransferAllow{Token: "HIVE", Limit: math.MaxUint64},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []Intent{
+		{Type: "transfer.allow", Args: map[string]string{"limit": "1000", "token": "HBD"}},
+		{Type: "transfer.allow", Args: map[string]string{"limit": "18446744073709551615", "token": "HIVE"}},
+	}, built)
+
+	// The wire form is stable
+	data, err := json.Marshal(built[0])
+	require.NoError(t, err)
+	assert.Equal(t, `{"type":"transfer.allow","args":{"limit":"1000","token":"HBD"}}`, string(data))
+}
+
+func TestTransferAllowValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		intent TransferAllow
+		errMsg string
+	}{
+		{"no token", TransferAllow{Limit: 1}, "token is required"},
+		{"spaces", TransferAllow{Token: "HB D", Limit: 1}, "contains spaces"},
+		{"control character", TransferAllow{Token: "HBD\n", Limit: 1}, "contains spaces"},
+		{"zero limit", TransferAllow{Token: "HBD"}, "transfer limit for HBD must be greater than 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Build(TransferAllow{Token: "HIVE", Limit: 1}, tt.intent)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "intent 1: ")
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestCombine(t *testing.T) {
+	combined := Combine([]Intent{
+		{Type: "transfer.allow", Args: map[string]string{"limit": "100", "token": "HBD"}},
+		{Type: "custom", Args: map[string]string{"key": "value"}},
+		{Type: "transfer.allow", Args: map[string]string{"limit": "18446744073709551615", "token": "HBD"}},
+		{Type: "transfer.allow", Args: map[string]string{"limit": "7", "token": "HIVE"}},
+	})
+
+	assert.Equal(t, []Intent{
+		{Type: "transfer.allow", Args: map[string]string{"limit": "18446744073709551715", "token": "HBD"}},
+		{Type: "custom", Args: map[string]string{"key": "value"}},
+		{Type: "transfer.allow", Args: map[string]string{"limit": "7", "token": "HIVE"}},
+	}, combined)
+}
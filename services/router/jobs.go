@@ -0,0 +1,278 @@
+package router
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// maxRetainedJobs bounds the swap jobs kept for status lookups; the oldest
+// finished jobs are dropped first
+const maxRetainedJobs = 10000
+
+// JobStatus is the progress of an asynchronous swap
+type JobStatus string
+
+// Swap job statuses, in order. A job stays "broadcast" if its outcome cannot
+// be tracked, because no outcome source is configured or the executor does
+// not report transaction IDs.
+const (
+	JobQueued    JobStatus = "queued"
+	JobBroadcast JobStatus = "broadcast"
+	JobIncluded  JobStatus = "included"
+	JobConfirmed JobStatus = "confirmed"
+	JobFailed    JobStatus = "failed"
+)
+
+// SwapJob is the status of a swap submitted with SubmitSwap
+type SwapJob struct {
+	ID        string      `json:"id"`
+	Status    JobStatus   `json:"status"`
+	TxID      string      `json:"txId,omitempty"`
+	Result    *SwapResult `json:"result,omitempty"` // Set once the job stops progressing
+	Error     string      `json:"error,omitempty"`
+	CreatedAt time.Time   `json:"createdAt"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+}
+
+// done reports whether the job will not change again
+func (j *SwapJob) done() bool {
+	return j.Result != nil
+}
+
+// jobStore holds swap jobs by ID
+type jobStore struct {
+	mu      sync.RWMutex
+	jobs    map[string]*SwapJob
+	order   []string                 // Job IDs in creation order
+	changes map[string]chan struct{} // Closed on a job's next update
+}
+
+func newJobStore() *jobStore {
+	return &jobStore{
+		jobs:    make(map[string]*SwapJob),
+		changes: make(map[string]chan struct{}),
+	}
+}
+
+// create stores a new queued job
+func (js *jobStore) create() (*SwapJob, error) {
+	id, err := newJobID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	job := &SwapJob{ID: id, Status: JobQueued, CreatedAt: now, UpdatedAt: now}
+
+	js.mu.Lock()
+	defer js.mu.Unlock()
+
+	js.jobs[id] = job
+	js.order = append(js.order, id)
+	js.pruneLocked()
+
+	return job, nil
+}
+
+// update applies fn to a job under the store lock
+func (js *jobStore) update(id string, fn func(job *SwapJob)) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+
+	if job, ok := js.jobs[id]; ok {
+		fn(job)
+		job.UpdatedAt = time.Now()
+	}
+	if changed, ok := js.changes[id]; ok {
+		close(changed)
+		delete(js.changes, id)
+	}
+}
+
+// watch returns a copy of a job and a channel closed when it next changes
+func (js *jobStore) watch(id string) (SwapJob, <-chan struct{}, bool) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+
+	job, ok := js.jobs[id]
+	if !ok {
+		return SwapJob{}, nil, false
+	}
+	changed, ok := js.changes[id]
+	if !ok {
+		changed = make(chan struct{})
+		js.changes[id] = changed
+	}
+	return *job, changed, true
+}
+
+// get returns a copy of a job
+func (js *jobStore) get(id string) (SwapJob, bool) {
+	js.mu.RLock()
+	defer js.mu.RUnlock()
+
+	job, ok := js.jobs[id]
+	if !ok {
+		return SwapJob{}, false
+	}
+	return *job, true
+}
+
+// pruneLocked drops the oldest finished jobs beyond maxRetainedJobs; caller
+// must hold js.mu
+func (js *jobStore) pruneLocked() {
+	excess := len(js.order) - maxRetainedJobs
+	if excess <= 0 {
+		return
+	}
+
+	kept := js.order[:0]
+	for _, id := range js.order {
+		if excess > 0 && js.jobs[id].done() {
+			delete(js.jobs, id)
+			delete(js.changes, id)
+			excess--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	js.order = kept
+}
+
+// newJobID returns a random job identifier
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate job ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// SubmitSwap queues a swap and returns its job ID immediately. The swap runs
+// in the background; poll GetSwapStatus for progress.
+func (r *Service) SubmitSwap(params SwapParams) (string, error) {
+	if params.AssetIn == params.AssetOut {
+		return "", fmt.Errorf("cannot swap asset to itself")
+	}
+	if !params.Deadline.IsZero() && !time.Now().Before(params.Deadline) {
+		return "", fmt.Errorf("swap deadline has passed")
+	}
+	if err := r.acceptSwap(params); err != nil {
+		return "", err
+	}
+
+	// A retry of a submitted swap gets the original job back
+	if params.IdempotencyKey != "" && !r.simulate {
+		entry, first, err := r.idempotency.begin(params)
+		if err != nil {
+			return "", err
+		}
+		if jobID := r.idempotency.jobOf(entry); !first && jobID != "" {
+			return jobID, nil
+		}
+		if first {
+			params.claim = entry
+		}
+	}
+
+	job, err := r.jobs.create()
+	if err != nil {
+		if params.claim != nil {
+			r.idempotency.finish(params, params.claim, &SwapResult{Success: false, ErrorMessage: err.Error()})
+		}
+		return "", err
+	}
+	if params.claim != nil {
+		r.idempotency.setJob(params.claim, job.ID)
+	}
+
+	// Queued swaps keep their place in the account's broadcast order. A
+	// retry only waits for the original, so it must not hold a place ahead
+	// of it.
+	var turn *accountTurn
+	if params.IdempotencyKey == "" || params.claim != nil {
+		turn = r.accounts.reserve(params.Sender)
+	}
+	go r.runSwapJob(job.ID, params, turn)
+	return job.ID, nil
+}
+
+// GetSwapStatus returns the current state of a submitted swap
+func (r *Service) GetSwapStatus(jobID string) (*SwapJob, error) {
+	job, ok := r.jobs.get(jobID)
+	if !ok {
+		return nil, fmt.Errorf("job not found: %s", jobID)
+	}
+	return &job, nil
+}
+
+// WatchSwap streams a submitted swap's latest state whenever it changes,
+// starting with its current state. Changes made while the receiver is busy
+// are coalesced. The channel is closed once the job stops progressing or ctx
+// is cancelled.
+func (r *Service) WatchSwap(ctx context.Context, jobID string) (<-chan SwapJob, error) {
+	if _, _, ok := r.jobs.watch(jobID); !ok {
+		return nil, fmt.Errorf("job not found: %s", jobID)
+	}
+
+	updates := make(chan SwapJob)
+	go func() {
+		defer close(updates)
+		for {
+			job, changed, ok := r.jobs.watch(jobID)
+			if !ok {
+				return
+			}
+			select {
+			case updates <- job:
+			case <-ctx.Done():
+				return
+			}
+			if job.done() {
+				return
+			}
+			select {
+			case <-changed:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return updates, nil
+}
+
+// runSwapJob executes a queued swap in its reserved turn, if it has one,
+// recording its progress. The turn is given up if the swap never broadcasts.
+func (r *Service) runSwapJob(jobID string, params SwapParams, turn *accountTurn) {
+	ctx := context.Background()
+	if turn != nil {
+		defer r.accounts.leave(turn)
+		ctx = withReservedTurn(ctx, turn)
+	}
+
+	result := r.executeSwap(ctx, params, func(status JobStatus, txID string) {
+		r.jobs.update(jobID, func(job *SwapJob) {
+			job.Status = status
+			job.TxID = txID
+		})
+	})
+
+	r.jobs.update(jobID, func(job *SwapJob) {
+		job.Result = result
+		switch {
+		case !result.Success:
+			job.Status = JobFailed
+			job.Error = result.ErrorMessage
+		case result.Settled:
+			job.Status = JobConfirmed
+		case job.Status == JobQueued:
+			// A duplicate of another swap reports that swap's transaction
+			job.Status = JobBroadcast
+			job.TxID = result.TxID
+		}
+	})
+}
@@ -0,0 +1,148 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingOutcomeSource reports pending until released
+type blockingOutcomeSource struct {
+	release chan struct{}
+	outcome *SwapOutcome
+}
+
+func (b *blockingOutcomeSource) GetSwapOutcome(ctx context.Context, txID string) (*SwapOutcome, error) {
+	select {
+	case <-b.release:
+		return b.outcome, nil
+	default:
+		return nil, ErrOutcomePending
+	}
+}
+
+// waitForStatus polls a job until it reaches status
+func waitForStatus(t *testing.T, svc *Service, jobID string, status JobStatus) *SwapJob {
+	var job *SwapJob
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = svc.GetSwapStatus(jobID)
+		require.NoError(t, err)
+		return job.Status == status
+	}, 2*time.Second, time.Millisecond, "job never reached %s", status)
+	return job
+}
+
+func TestSubmitSwap_Progress(t *testing.T) {
+	source := &blockingOutcomeSource{release: make(chan struct{}), outcome: &SwapOutcome{
+		TxID: "tx-1",
+		Hops: []HopQuote{{PoolID: "1", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000, AmountOut: 3980}},
+	}}
+	svc, _ := newOutcomeTestService(source)
+
+	jobID, err := svc.SubmitSwap(SwapParams{Sender: "test-user", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000})
+	require.NoError(t, err)
+	assert.NotEmpty(t, jobID)
+
+	job := waitForStatus(t, svc, jobID, JobBroadcast)
+	assert.Equal(t, "tx-1", job.TxID)
+	assert.Nil(t, job.Result)
+
+	close(source.release)
+	job = waitForStatus(t, svc, jobID, JobConfirmed)
+	require.NotNil(t, job.Result)
+	assert.True(t, job.Result.Settled)
+	assert.Equal(t, int64(3980), job.Result.AmountOut)
+}
+
+func TestSubmitSwap_Untracked(t *testing.T) {
+	svc := NewService(VSCConfig{}, &mockDEXExecutor{})
+
+	jobID, err := svc.SubmitSwap(SwapParams{Sender: "test-user", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000, MinAmountOut: 3500})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		job, _ := svc.GetSwapStatus(jobID)
+		return job.Result != nil
+	}, 2*time.Second, time.Millisecond)
+
+	// Without transaction IDs the swap cannot be followed past broadcast
+	job, err := svc.GetSwapStatus(jobID)
+	require.NoError(t, err)
+	assert.Equal(t, JobBroadcast, job.Status)
+	assert.False(t, job.Result.Settled)
+}
+
+func TestSubmitSwap_Errors(t *testing.T) {
+	svc := NewService(VSCConfig{}, &mockDEXExecutor{})
+
+	_, err := svc.SubmitSwap(SwapParams{AssetIn: "HBD", AssetOut: "HBD", AmountIn: 1000})
+	assert.Error(t, err)
+
+	_, err = svc.GetSwapStatus("missing")
+	assert.ErrorContains(t, err, "job not found")
+}
+
+func TestJobStore_Prune(t *testing.T) {
+	js := newJobStore()
+	first, err := js.create()
+	require.NoError(t, err)
+	js.update(first.ID, func(job *SwapJob) { job.Result = &SwapResult{} })
+	second, err := js.create()
+	require.NoError(t, err)
+
+	for i := 0; i < maxRetainedJobs-1; i++ {
+		_, err := js.create()
+		require.NoError(t, err)
+	}
+
+	// The finished job is dropped; the unfinished one is kept
+	_, ok := js.get(first.ID)
+	assert.False(t, ok)
+	_, ok = js.get(second.ID)
+	assert.True(t, ok)
+	assert.Len(t, js.jobs, maxRetainedJobs)
+}
+
+func TestServer_SubmitSwap(t *testing.T) {
+	svc := NewService(VSCConfig{}, &mockDEXExecutor{})
+	server := NewServer(svc, "0")
+
+	req := httptest.NewRequest("POST", "/api/v1/swaps", strings.NewReader(`{"fromAsset":"HBD","toAsset":"HIVE","amount":1000,"sender":"test-user"}`))
+	w := httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusAccepted, w.Code)
+
+	var submitted struct {
+		JobID  string `json:"jobId"`
+		Status string `json:"status"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &submitted))
+	assert.Equal(t, "queued", submitted.Status)
+	assert.Equal(t, "/api/v1/swaps/"+submitted.JobID, w.Header().Get("Location"))
+
+	waitForStatus(t, svc, submitted.JobID, JobBroadcast)
+
+	req = httptest.NewRequest("GET", "/api/v1/swaps/"+submitted.JobID, nil)
+	w = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"broadcast"`)
+
+	req = httptest.NewRequest("GET", "/api/v1/swaps/missing", nil)
+	w = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	req = httptest.NewRequest("POST", "/api/v1/swaps", strings.NewReader(`{"fromAsset":"HBD","toAsset":"HBD","amount":1000}`))
+	w = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,137 @@
+package router
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// RetryReporter is implemented by executors that retry failed broadcasts.
+// The router registers an observer that is called before each retry.
+type RetryReporter interface {
+	SetRetryObserver(observe func(operationType string))
+}
+
+// Metrics holds the router's Prometheus metrics. A nil *Metrics records
+// nothing, so callers need not check whether metrics are enabled.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	quotes            *prometheus.CounterVec
+	routeDuration     prometheus.Histogram
+	executions        *prometheus.CounterVec
+	executionDuration *prometheus.HistogramVec
+	retries           *prometheus.CounterVec
+	rateLimited       *prometheus.CounterVec
+}
+
+// NewMetrics creates the router's metrics on their own registry, along with
+// the Go runtime and process collectors
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		quotes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "router_quotes_total",
+			Help: "Quotes served over HTTP and gRPC, by result.",
+		}, []string{"result"}),
+		routeDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "router_route_computation_seconds",
+			Help:    "Time taken to find and price the best route for a quote or swap.",
+			Buckets: prometheus.ExponentialBuckets(0.0005, 2, 14),
+		}),
+		executions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "router_executions_total",
+			Help: "Operations broadcast, by operation and result.",
+		}, []string{"operation", "result"}),
+		executionDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "router_execution_seconds",
+			Help:    "Time from broadcasting an operation until its result was known.",
+			Buckets: prometheus.ExponentialBuckets(0.05, 2, 12),
+		}, []string{"operation"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "router_executor_retries_total",
+			Help: "Broadcasts the executor retried, by contract method.",
+		}, []string{"operation_type"}),
+		rateLimited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "router_rate_limited_total",
+			Help: "Calls refused for exceeding a caller's rate limit, by kind of call.",
+		}, []string{"call"}),
+	}
+	m.registry.MustRegister(
+		m.quotes,
+		m.routeDuration,
+		m.executions,
+		m.executionDuration,
+		m.retries,
+		m.rateLimited,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// SetMetrics configures where the router records its metrics, and has a
+// retrying executor report its retries there
+func (s *Service) SetMetrics(metrics *Metrics) {
+	s.metrics = metrics
+	if reporter, ok := s.dexExecutor.(RetryReporter); ok {
+		reporter.SetRetryObserver(metrics.observeRetry)
+	}
+}
+
+// observeQuote counts a quote served to a client
+func (m *Metrics) observeQuote(err error) {
+	if m == nil {
+		return
+	}
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	m.quotes.WithLabelValues(result).Inc()
+}
+
+// observeRoute records how long a route computation took
+func (m *Metrics) observeRoute(started time.Time) {
+	if m == nil {
+		return
+	}
+	m.routeDuration.Observe(time.Since(started).Seconds())
+}
+
+// observeExecution counts a broadcast operation and how long it took
+func (m *Metrics) observeExecution(kind ReceiptKind, submittedAt time.Time, success bool) {
+	if m == nil {
+		return
+	}
+	result := "success"
+	if !success {
+		result = "failure"
+	}
+	m.executions.WithLabelValues(string(kind), result).Inc()
+	m.executionDuration.WithLabelValues(string(kind)).Observe(time.Since(submittedAt).Seconds())
+}
+
+// observeRetry counts an executor retry
+func (m *Metrics) observeRetry(operationType string) {
+	if m == nil {
+		return
+	}
+	m.retries.WithLabelValues(operationType).Inc()
+}
+
+// observeRateLimited counts a call refused by the rate limiter
+func (m *Metrics) observeRateLimited(kind callKind) {
+	if m == nil {
+		return
+	}
+	m.rateLimited.WithLabelValues(string(kind)).Inc()
+}
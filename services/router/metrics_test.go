@@ -0,0 +1,109 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// retryingExecutor is a mock executor that reports retries
+type retryingExecutor struct {
+	mockDEXExecutor
+	observe func(operationType string)
+}
+
+func (e *retryingExecutor) SetRetryObserver(observe func(operationType string)) {
+	e.observe = observe
+}
+
+// histogramCount returns how many observations a histogram has recorded
+func histogramCount(t *testing.T, histogram prometheus.Metric) uint64 {
+	var m dto.Metric
+	require.NoError(t, histogram.Write(&m))
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestMetricsQuotesAndExecutions(t *testing.T) {
+	svc := NewService(VSCConfig{}, &mockDEXExecutor{})
+	svc.SetPoolQuerier(&mockPoolQuerier{pools: []IndexerPoolInfo{
+		{ID: "hbd-hive", Asset0: "HBD", Asset1: "HIVE", Reserve0: 10000000, Reserve1: 40000000, Fee: 30},
+	}})
+	metrics := NewMetrics()
+	svc.SetMetrics(metrics)
+	server := NewServer(svc, "0")
+
+	w := serveTestRequest(server, "POST", "/api/v1/quote", `{"fromAsset":"HBD","toAsset":"HIVE","amount":1000}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = serveTestRequest(server, "POST", "/api/v1/quote", `{"fromAsset":"HBD","toAsset":"BTC","amount":1000}`)
+	require.NotEqual(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.quotes.WithLabelValues("success")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.quotes.WithLabelValues("error")))
+
+	result, err := svc.ExecuteSwap(context.Background(), SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000})
+	require.NoError(t, err)
+	require.True(t, result.Success, result.ErrorMessage)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.executions.WithLabelValues("swap", "success")))
+	assert.Equal(t, uint64(1), histogramCount(t, metrics.executionDuration.WithLabelValues("swap").(prometheus.Metric)))
+
+	// Both quotes and the swap computed a route
+	assert.Equal(t, uint64(3), histogramCount(t, metrics.routeDuration))
+
+	// Failed broadcasts are counted as failures
+	batch := NewService(VSCConfig{}, &mockBatchExecutor{err: errors.New("node unavailable")})
+	batch.SetPoolQuerier(svc.poolQuerier)
+	batch.SetMetrics(metrics)
+	batchResult, err := batch.ExecuteBatch(context.Background(), BatchParams{
+		Sender:     "alice",
+		Operations: []BatchOperation{{Swap: &SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000}}},
+	})
+	require.NoError(t, err)
+	require.False(t, batchResult.Success)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.executions.WithLabelValues("batch", "failure")))
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	svc := NewService(VSCConfig{}, &mockDEXExecutor{})
+
+	// Without metrics there is no endpoint
+	w := serveTestRequest(NewServer(svc, "0"), "GET", "/metrics", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	svc.SetMetrics(NewMetrics())
+	svc.metrics.observeQuote(nil)
+	w = serveTestRequest(NewServer(svc, "0"), "GET", "/metrics", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `router_quotes_total{result="success"} 1`)
+	assert.Contains(t, w.Body.String(), "go_goroutines")
+}
+
+func TestMetricsRetries(t *testing.T) {
+	executor := &retryingExecutor{}
+	svc := NewService(VSCConfig{}, executor)
+	metrics := NewMetrics()
+	svc.SetMetrics(metrics)
+
+	require.NotNil(t, executor.observe)
+	executor.observe("execute")
+	executor.observe("execute")
+	executor.observe("withdraw")
+	assert.Equal(t, 2.0, testutil.ToFloat64(metrics.retries.WithLabelValues("execute")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.retries.WithLabelValues("withdraw")))
+}
+
+func TestMetricsDisabled(t *testing.T) {
+	var metrics *Metrics
+	assert.NotPanics(t, func() {
+		metrics.observeQuote(nil)
+		metrics.observeRoute(time.Now())
+		metrics.observeExecution(ReceiptSwap, time.Now(), true)
+		metrics.observeRetry("execute")
+	})
+}
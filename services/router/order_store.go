@@ -0,0 +1,174 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// OrderStore persists orders across restarts. Orders are JSON documents
+// keyed by kind and ID; saving an order replaces any earlier version.
+type OrderStore interface {
+	LoadOrders(kind OrderKind) ([]json.RawMessage, error)
+	SaveOrder(kind OrderKind, id string, order json.RawMessage) error
+	Close() error
+}
+
+// OpenOrderStore opens the store described by spec: a sqlite:// path, a
+// postgres:// connection URL, or otherwise a directory for a FileOrderStore
+func OpenOrderStore(spec string) (OrderStore, error) {
+	switch {
+	case strings.HasPrefix(spec, "sqlite://"):
+		return OpenSQLOrderStore("sqlite", strings.TrimPrefix(spec, "sqlite://"))
+	case strings.HasPrefix(spec, "postgres://"), strings.HasPrefix(spec, "postgresql://"):
+		return OpenSQLOrderStore("postgres", spec)
+	case spec == "":
+		return nil, fmt.Errorf("order store is required")
+	}
+	return NewFileOrderStore(spec)
+}
+
+// FileOrderStore keeps each kind of order in its own JSON file in a
+// directory, named after the kind, e.g. dca-orders.json
+type FileOrderStore struct {
+	dir string
+
+	mu     sync.Mutex
+	orders map[OrderKind]map[string]json.RawMessage // Loaded kinds
+}
+
+// NewFileOrderStore creates a store in dir, creating the directory if needed
+func NewFileOrderStore(dir string) (*FileOrderStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create order store: %w", err)
+	}
+	return &FileOrderStore{dir: dir, orders: make(map[OrderKind]map[string]json.RawMessage)}, nil
+}
+
+// path returns the file holding one kind of order
+func (f *FileOrderStore) path(kind OrderKind) string {
+	return filepath.Join(f.dir, string(kind)+"-orders.json")
+}
+
+// LoadOrders reads every stored order of a kind. A missing file holds none.
+func (f *FileOrderStore) LoadOrders(kind OrderKind) ([]json.RawMessage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	orders, err := f.loadLocked(kind)
+	if err != nil {
+		return nil, err
+	}
+	return sortedOrders(orders), nil
+}
+
+// SaveOrder stores an order, rewriting its kind's file atomically
+func (f *FileOrderStore) SaveOrder(kind OrderKind, id string, order json.RawMessage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	orders, err := f.loadLocked(kind)
+	if err != nil {
+		return err
+	}
+
+	previous, existed := orders[id]
+	orders[id] = order
+	data, err := json.MarshalIndent(sortedOrders(orders), "", "  ")
+	if err == nil {
+		err = writeFileAtomic(f.path(kind), data)
+	}
+	if err != nil {
+		if existed {
+			orders[id] = previous
+		} else {
+			delete(orders, id)
+		}
+		return fmt.Errorf("failed to write %s orders: %w", kind, err)
+	}
+	return nil
+}
+
+// Close does nothing; every save is already on disk
+func (f *FileOrderStore) Close() error {
+	return nil
+}
+
+// loadLocked returns a kind's orders by ID, reading its file the first
+// time; caller must hold f.mu
+func (f *FileOrderStore) loadLocked(kind OrderKind) (map[string]json.RawMessage, error) {
+	if orders, ok := f.orders[kind]; ok {
+		return orders, nil
+	}
+
+	orders := make(map[string]json.RawMessage)
+	data, err := os.ReadFile(f.path(kind))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read %s orders: %w", kind, err)
+	}
+	if err == nil {
+		var stored []json.RawMessage
+		if err := json.Unmarshal(data, &stored); err != nil {
+			return nil, fmt.Errorf("failed to decode %s orders: %w", kind, err)
+		}
+		for _, order := range stored {
+			var keyed struct {
+				ID string `json:"id"`
+			}
+			if err := json.Unmarshal(order, &keyed); err != nil || keyed.ID == "" {
+				return nil, fmt.Errorf("failed to decode %s orders: order without an id", kind)
+			}
+			orders[keyed.ID] = order
+		}
+	}
+	f.orders[kind] = orders
+	return orders, nil
+}
+
+// sortedOrders returns orders ordered by ID, so files are stable
+func sortedOrders(orders map[string]json.RawMessage) []json.RawMessage {
+	ids := make([]string, 0, len(orders))
+	for id := range orders {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	sorted := make([]json.RawMessage, len(ids))
+	for i, id := range ids {
+		sorted[i] = orders[id]
+	}
+	return sorted
+}
+
+// saveOrder encodes and stores one order
+func saveOrder(store OrderStore, kind OrderKind, id string, order interface{}) error {
+	data, err := json.Marshal(order)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s order: %w", kind, err)
+	}
+	return store.SaveOrder(kind, id, data)
+}
+
+// writeFileAtomic replaces a file by writing a temporary file beside it and
+// renaming it into place
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
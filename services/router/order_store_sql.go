@@ -0,0 +1,88 @@
+package router
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	_ "github.com/lib/pq"  // Registers the postgres driver
+	_ "modernc.org/sqlite" // Registers the sqlite driver
+)
+
+// SQLOrderStore keeps orders in a SQL table, one row per order. The same
+// statements run on SQLite and Postgres.
+type SQLOrderStore struct {
+	db *sql.DB
+}
+
+// OpenSQLOrderStore connects to a database with the named driver, sqlite or
+// postgres, and creates the orders table if it does not exist
+func OpenSQLOrderStore(driver, dataSource string) (*SQLOrderStore, error) {
+	db, err := sql.Open(driver, dataSource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open order store: %w", err)
+	}
+	if driver == "sqlite" {
+		// SQLite serialises writers; one connection avoids busy errors
+		db.SetMaxOpenConns(1)
+	}
+
+	store, err := NewSQLOrderStore(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// NewSQLOrderStore stores orders in db, creating the orders table if it
+// does not exist
+func NewSQLOrderStore(db *sql.DB) (*SQLOrderStore, error) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS router_orders (
+		kind TEXT NOT NULL,
+		id TEXT NOT NULL,
+		data TEXT NOT NULL,
+		PRIMARY KEY (kind, id)
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create orders table: %w", err)
+	}
+	return &SQLOrderStore{db: db}, nil
+}
+
+// LoadOrders reads every stored order of a kind
+func (s *SQLOrderStore) LoadOrders(kind OrderKind) ([]json.RawMessage, error) {
+	rows, err := s.db.Query(`SELECT data FROM router_orders WHERE kind = $1 ORDER BY id`, string(kind))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s orders: %w", kind, err)
+	}
+	defer rows.Close()
+
+	orders := []json.RawMessage{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to read %s orders: %w", kind, err)
+		}
+		orders = append(orders, json.RawMessage(data))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s orders: %w", kind, err)
+	}
+	return orders, nil
+}
+
+// SaveOrder inserts or replaces an order
+func (s *SQLOrderStore) SaveOrder(kind OrderKind, id string, order json.RawMessage) error {
+	_, err := s.db.Exec(`INSERT INTO router_orders (kind, id, data) VALUES ($1, $2, $3)
+		ON CONFLICT (kind, id) DO UPDATE SET data = excluded.data`, string(kind), id, string(order))
+	if err != nil {
+		return fmt.Errorf("failed to write %s order %s: %w", kind, id, err)
+	}
+	return nil
+}
+
+// Close closes the database
+func (s *SQLOrderStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,127 @@
+package router
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testOrderStoreRoundTrip saves orders of two kinds through one store and
+// reads them back through another opened on the same storage
+func testOrderStoreRoundTrip(t *testing.T, store, reopen func() OrderStore) {
+	first := store()
+	orders, err := first.LoadOrders(OrderDCA)
+	require.NoError(t, err)
+	assert.Empty(t, orders)
+
+	require.NoError(t, first.SaveOrder(OrderDCA, "b", json.RawMessage(`{"id":"b","runs":1}`)))
+	require.NoError(t, first.SaveOrder(OrderDCA, "a", json.RawMessage(`{"id":"a","runs":1}`)))
+	require.NoError(t, first.SaveOrder(OrderTrigger, "a", json.RawMessage(`{"id":"a","status":"pending"}`)))
+
+	// Saving an order again replaces it
+	require.NoError(t, first.SaveOrder(OrderDCA, "b", json.RawMessage(`{"id":"b","runs":2}`)))
+	require.NoError(t, first.Close())
+
+	second := reopen()
+	defer second.Close()
+	orders, err = second.LoadOrders(OrderDCA)
+	require.NoError(t, err)
+	require.Len(t, orders, 2)
+	assert.JSONEq(t, `{"id":"a","runs":1}`, string(orders[0]))
+	assert.JSONEq(t, `{"id":"b","runs":2}`, string(orders[1]))
+
+	orders, err = second.LoadOrders(OrderTrigger)
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	assert.JSONEq(t, `{"id":"a","status":"pending"}`, string(orders[0]))
+}
+
+func TestFileOrderStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "orders")
+	open := func() OrderStore {
+		store, err := NewFileOrderStore(dir)
+		require.NoError(t, err)
+		return store
+	}
+	testOrderStoreRoundTrip(t, open, open)
+
+	// Each kind has its own file, in the format the DCA store always used
+	var dca []DCAOrder
+	data, err := os.ReadFile(filepath.Join(dir, "dca-orders.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &dca))
+	assert.Len(t, dca, 2)
+	assert.FileExists(t, filepath.Join(dir, "trigger-orders.json"))
+}
+
+func TestFileOrderStoreRejectsCorruptFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "dca-orders.json"), []byte(`[{"runs":1}]`), 0o644))
+
+	store, err := NewFileOrderStore(dir)
+	require.NoError(t, err)
+	_, err = store.LoadOrders(OrderDCA)
+	assert.ErrorContains(t, err, "order without an id")
+
+	_, err = NewDCAScheduler(NewService(VSCConfig{}, &mockDEXExecutor{}), store)
+	assert.Error(t, err)
+}
+
+func TestSQLOrderStore(t *testing.T) {
+	spec := "sqlite://" + filepath.Join(t.TempDir(), "orders.db")
+	open := func() OrderStore {
+		store, err := OpenOrderStore(spec)
+		require.NoError(t, err)
+		require.IsType(t, &SQLOrderStore{}, store)
+		return store
+	}
+	testOrderStoreRoundTrip(t, open, open)
+}
+
+func TestOpenOrderStore(t *testing.T) {
+	_, err := OpenOrderStore("")
+	assert.Error(t, err)
+
+	store, err := OpenOrderStore(t.TempDir())
+	require.NoError(t, err)
+	assert.IsType(t, &FileOrderStore{}, store)
+}
+
+func TestOrdersSurviveRestartInSQLStore(t *testing.T) {
+	spec := "sqlite://" + filepath.Join(t.TempDir(), "orders.db")
+	store, err := OpenOrderStore(spec)
+	require.NoError(t, err)
+
+	svc := NewService(VSCConfig{}, &mockDEXExecutor{})
+	scheduler, err := NewDCAScheduler(svc, store)
+	require.NoError(t, err)
+	dca, err := scheduler.Create(dcaTestParams())
+	require.NoError(t, err)
+	monitor, err := NewTriggerMonitor(svc, store)
+	require.NoError(t, err)
+	trigger, err := monitor.Create(triggerTestParams(TriggerStopLoss, 3.5))
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+
+	store, err = OpenOrderStore(spec)
+	require.NoError(t, err)
+	defer store.Close()
+
+	scheduler, err = NewDCAScheduler(svc, store)
+	require.NoError(t, err)
+	restoredDCA, err := scheduler.Get(dca.ID)
+	require.NoError(t, err)
+	assert.Equal(t, DCAActive, restoredDCA.Status)
+	assert.Equal(t, dca.NextRunAt.UTC(), restoredDCA.NextRunAt.UTC())
+
+	monitor, err = NewTriggerMonitor(svc, store)
+	require.NoError(t, err)
+	restoredTrigger, err := monitor.Get(trigger.ID)
+	require.NoError(t, err)
+	assert.Equal(t, TriggerPending, restoredTrigger.Status)
+	assert.Equal(t, 3.5, restoredTrigger.TriggerPrice)
+}
@@ -0,0 +1,60 @@
+package router
+
+import "fmt"
+
+// OrderKind identifies which subsystem tracks an order
+type OrderKind string
+
+const (
+	OrderSwap OrderKind = "swap" // Asynchronous swap job
+	OrderTWAP OrderKind = "twap"
+	OrderDCA  OrderKind = "dca"
+
+	OrderSwapIn OrderKind = "swapin" // Cross-chain deposit minted and swapped
+
+	OrderTrigger OrderKind = "trigger" // Stop-loss, take-profit or limit
+
+	OrderApproval OrderKind = "approval" // Large swap held for approval
+)
+
+// Order is any order the router tracks by ID. Exactly one of Swap, TWAP, DCA,
+// SwapIn, Trigger and Approval is set, matching Kind.
+type Order struct {
+	Kind     OrderKind        `json:"kind"`
+	Swap     *SwapJob         `json:"swap,omitempty"`
+	TWAP     *TWAPOrder       `json:"twap,omitempty"`
+	DCA      *DCAOrder        `json:"dca,omitempty"`
+	SwapIn   *SwapInOrder     `json:"swapIn,omitempty"`
+	Trigger  *TriggerOrder    `json:"trigger,omitempty"`
+	Approval *ApprovalRequest `json:"approval,omitempty"`
+}
+
+// GetOrder looks up a swap job, TWAP order, DCA order, swap-in, trigger
+// order or swap held for approval by ID
+func (s *Service) GetOrder(id string) (*Order, error) {
+	if job, ok := s.jobs.get(id); ok {
+		return &Order{Kind: OrderSwap, Swap: &job}, nil
+	}
+	if twap, ok := s.twaps.get(id); ok {
+		return &Order{Kind: OrderTWAP, TWAP: &twap}, nil
+	}
+	if swapIn, ok := s.swapIns.get(id); ok {
+		return &Order{Kind: OrderSwapIn, SwapIn: &swapIn}, nil
+	}
+	if s.dca != nil {
+		if dca, err := s.dca.Get(id); err == nil {
+			return &Order{Kind: OrderDCA, DCA: dca}, nil
+		}
+	}
+	if s.triggers != nil {
+		if trigger, err := s.triggers.Get(id); err == nil {
+			return &Order{Kind: OrderTrigger, Trigger: trigger}, nil
+		}
+	}
+	if s.approvals != nil {
+		if approval, err := s.approvals.Get(id); err == nil {
+			return &Order{Kind: OrderApproval, Approval: approval}, nil
+		}
+	}
+	return nil, fmt.Errorf("order not found: %s", id)
+}
@@ -0,0 +1,130 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Swap outcome polling defaults
+const (
+	defaultOutcomeTimeout      = 30 * time.Second
+	defaultOutcomePollInterval = time.Second
+)
+
+// ErrOutcomePending is returned by a SwapOutcomeSource until the swap has
+// been executed and indexed
+var ErrOutcomePending = errors.New("swap outcome not yet available")
+
+// TxSubmitter is implemented by executors that report the ID of the
+// transaction they broadcast, which is needed to look up its outcome
+type TxSubmitter interface {
+	SubmitDexOperation(ctx context.Context, operationType string, payload string, intents []Intent) (string, error)
+}
+
+// SwapOutcome is what a submitted swap actually did
+type SwapOutcome struct {
+	TxID string
+	Hops []HopQuote // Executed hops in route order; FeeBps and Fee are not set
+}
+
+// SwapOutcomeSource looks up the result of a submitted swap
+type SwapOutcomeSource interface {
+	GetSwapOutcome(ctx context.Context, txID string) (*SwapOutcome, error)
+}
+
+// SetOutcomeSource configures where executed swap results are read from, and
+// how long ExecuteSwap waits for them. A zero timeout uses the default.
+func (s *Service) SetOutcomeSource(source SwapOutcomeSource, timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = defaultOutcomeTimeout
+	}
+	s.outcomeSource = source
+	s.outcomeTimeout = timeout
+	if s.outcomePollInterval == 0 {
+		s.outcomePollInterval = defaultOutcomePollInterval
+	}
+}
+
+// submit executes a DEX operation, returning the transaction ID when the
+// executor reports one
+func (s *Service) submit(ctx context.Context, account string, payload string, intents []Intent) (string, error) {
+	return s.submitInTurn(ctx, account, "execute", payload, intents)
+}
+
+// submitOperation broadcasts a call to any contract method
+func (s *Service) submitOperation(ctx context.Context, operationType string, payload string, intents []Intent) (string, error) {
+	if submitter, ok := s.dexExecutor.(TxSubmitter); ok {
+		return submitter.SubmitDexOperation(ctx, operationType, payload, intents)
+	}
+	return "", s.dexExecutor.ExecuteDexOperationWithIntents(ctx, operationType, payload, intents)
+}
+
+// awaitOutcome polls the outcome source until the swap is found or the
+// outcome timeout passes
+func (s *Service) awaitOutcome(ctx context.Context, txID string) (*SwapOutcome, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.outcomeTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(s.outcomePollInterval)
+	defer ticker.Stop()
+
+	for {
+		outcome, err := s.outcomeSource.GetSwapOutcome(ctx, txID)
+		if err == nil {
+			if len(outcome.Hops) == 0 {
+				return nil, fmt.Errorf("swap %s has no executed hops", txID)
+			}
+			return outcome, nil
+		}
+		if !errors.Is(err, ErrOutcomePending) {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timed out waiting for swap %s: %w", txID, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// applyOutcome replaces a SwapResult's placeholder amounts and route with
+// what the swap actually executed. Hop fees use the quoted pool fee where
+// available, and otherwise the pool's current fee.
+func (s *Service) applyOutcome(result *SwapResult, outcome *SwapOutcome, quote *Quote) {
+	quotedFees := make(map[string]uint64)
+	if quote != nil {
+		for _, hop := range quote.Hops {
+			quotedFees[hop.PoolID] = hop.FeeBps
+		}
+	}
+
+	hops := make([]HopQuote, len(outcome.Hops))
+	route := []string{outcome.Hops[0].AssetIn}
+	pools := make([]string, len(outcome.Hops))
+	for i, hop := range outcome.Hops {
+		feeBps, ok := quotedFees[hop.PoolID]
+		if !ok && s.poolQuerier != nil {
+			if pool, err := s.poolQuerier.GetPoolByID(hop.PoolID); err == nil {
+				feeBps, ok = pool.Fee, true
+			}
+		}
+		if ok && feeBps < 10000 {
+			hop.FeeBps = feeBps
+			hop.Fee = hop.AmountIn - hop.AmountIn*int64(10000-feeBps)/10000
+		}
+		hops[i] = hop
+		route = append(route, hop.AssetOut)
+		pools[i] = hop.PoolID
+	}
+
+	result.TxID = outcome.TxID
+	result.Settled = true
+	result.AmountOut = hops[len(hops)-1].AmountOut
+	result.Fee = hops[0].Fee
+	result.Route = route
+	result.Pools = pools
+	result.HopFees = hops
+}
@@ -0,0 +1,147 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockTxSubmitter is an executor that reports transaction IDs
+type mockTxSubmitter struct {
+	mockDEXExecutor
+	txID string
+}
+
+func (m *mockTxSubmitter) SubmitDexOperation(ctx context.Context, operationType string, payload string, intents []Intent) (string, error) {
+	m.executedOperations = append(m.executedOperations, operationType+":"+payload)
+	return m.txID, nil
+}
+
+// mockOutcomeSource returns ErrOutcomePending for a number of polls
+type mockOutcomeSource struct {
+	pending int
+	outcome *SwapOutcome
+	err     error
+	polls   int
+}
+
+func (m *mockOutcomeSource) GetSwapOutcome(ctx context.Context, txID string) (*SwapOutcome, error) {
+	m.polls++
+	if m.polls <= m.pending {
+		return nil, ErrOutcomePending
+	}
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.outcome, nil
+}
+
+func newOutcomeTestService(source SwapOutcomeSource) (*Service, *mockTxSubmitter) {
+	executor := &mockTxSubmitter{txID: "tx-1"}
+	svc := NewService(VSCConfig{}, executor)
+	svc.SetPoolQuerier(&mockPoolQuerier{pools: []IndexerPoolInfo{
+		{ID: "1", Asset0: "HBD", Asset1: "HIVE", Reserve0: 1000000, Reserve1: 4000000, Fee: 30},
+		{ID: "2", Asset0: "BTC", Asset1: "HBD", Reserve0: 100000000, Reserve1: 50000000, Fee: 8},
+	}})
+	svc.SetOutcomeSource(source, time.Second)
+	svc.outcomePollInterval = time.Millisecond
+	return svc, executor
+}
+
+func TestExecuteSwap_SettledOutcome(t *testing.T) {
+	source := &mockOutcomeSource{pending: 2, outcome: &SwapOutcome{
+		TxID: "tx-1",
+		Hops: []HopQuote{
+			{PoolID: "2", AssetIn: "BTC", AssetOut: "HBD", AmountIn: 100000, AmountOut: 49900},
+			{PoolID: "1", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 49900, AmountOut: 190000},
+		},
+	}}
+	svc, executor := newOutcomeTestService(source)
+
+	result, err := svc.ExecuteSwap(context.Background(), SwapParams{Sender: "test-user", AssetIn: "BTC", AssetOut: "HIVE", AmountIn: 100000, MinAmountOut: 150000})
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	assert.Len(t, executor.executedOperations, 1)
+	assert.Equal(t, 3, source.polls)
+
+	assert.True(t, result.Settled)
+	assert.Equal(t, "tx-1", result.TxID)
+	assert.Equal(t, int64(190000), result.AmountOut)
+	assert.Equal(t, []string{"BTC", "HBD", "HIVE"}, result.Route)
+	assert.Equal(t, []string{"2", "1"}, result.Pools)
+	require.Len(t, result.HopFees, 2)
+	assert.Equal(t, uint64(8), result.HopFees[0].FeeBps)
+	assert.Equal(t, int64(80), result.HopFees[0].Fee)
+	assert.Equal(t, int64(150), result.HopFees[1].Fee)
+	assert.Equal(t, int64(80), result.Fee)
+
+	// The quoted estimate is kept for comparison
+	assert.Greater(t, result.EstimatedAmountOut, int64(0))
+}
+
+func TestExecuteSwap_OutcomeUnavailable(t *testing.T) {
+	source := &mockOutcomeSource{err: fmt.Errorf("indexer returned status 500")}
+	svc, _ := newOutcomeTestService(source)
+
+	result, err := svc.ExecuteSwap(context.Background(), SwapParams{Sender: "test-user", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000, MinAmountOut: 3500})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.False(t, result.Settled)
+	assert.Equal(t, "tx-1", result.TxID)
+	assert.Equal(t, 1, source.polls)
+
+	// Without an outcome the quoted output and pools are reported
+	assert.Equal(t, result.EstimatedAmountOut, result.AmountOut)
+	assert.Greater(t, result.AmountOut, int64(3500))
+	assert.NotEmpty(t, result.Pools)
+}
+
+func TestAwaitOutcome_Timeout(t *testing.T) {
+	source := &mockOutcomeSource{pending: 1 << 30}
+	svc, _ := newOutcomeTestService(source)
+	svc.outcomeTimeout = 20 * time.Millisecond
+
+	_, err := svc.awaitOutcome(context.Background(), "tx-1")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Greater(t, source.polls, 1)
+}
+
+func TestIndexerOutcomeSource(t *testing.T) {
+	indexed := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/transactions", r.URL.Path)
+		assert.Equal(t, "tx-1", r.URL.Query().Get("tx_id"))
+		assert.Equal(t, "swap", r.URL.Query().Get("type"))
+
+		transactions := []map[string]interface{}{}
+		if indexed {
+			// Most recent first, as the indexer lists them
+			transactions = append(transactions,
+				map[string]interface{}{"id": "tx-1", "pool_id": "1", "details": map[string]interface{}{"amount_in": 49900, "amount_out": 190000, "asset_in": "HBD", "asset_out": "HIVE"}},
+				map[string]interface{}{"id": "tx-1", "pool_id": "2", "details": map[string]interface{}{"amount_in": 100000, "amount_out": 49900, "asset_in": "BTC", "asset_out": "HBD"}},
+			)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"transactions": transactions})
+	}))
+	defer ts.Close()
+
+	source := NewIndexerOutcomeSource(ts.URL)
+
+	_, err := source.GetSwapOutcome(context.Background(), "tx-1")
+	assert.ErrorIs(t, err, ErrOutcomePending)
+
+	indexed = true
+	outcome, err := source.GetSwapOutcome(context.Background(), "tx-1")
+	require.NoError(t, err)
+	require.Len(t, outcome.Hops, 2)
+	assert.Equal(t, "2", outcome.Hops[0].PoolID)
+	assert.Equal(t, "BTC", outcome.Hops[0].AssetIn)
+	assert.Equal(t, int64(190000), outcome.Hops[1].AmountOut)
+}
@@ -0,0 +1,153 @@
+package router
+
+import (
+	"sync"
+	"time"
+)
+
+// cachedPools is a cached GetPoolsByAsset result
+type cachedPools struct {
+	pools   []IndexerPoolInfo
+	expires time.Time
+}
+
+// CachingPoolQuerier caches pool lookups by asset so hot pairs can be
+// routed without hitting the indexer on every quote. Entries expire after
+// a TTL, and are dropped early when a pool's reserves are seen to move by
+// more than a threshold.
+type CachingPoolQuerier struct {
+	next         PoolQuerier
+	ttl          time.Duration
+	thresholdBps uint64
+
+	mu      sync.Mutex
+	byAsset map[string]cachedPools
+	now     func() time.Time
+}
+
+// NewCachingPoolQuerier wraps next with a cache. thresholdBps is the reserve
+// change, in basis points, that invalidates cached pools.
+func NewCachingPoolQuerier(next PoolQuerier, ttl time.Duration, thresholdBps uint64) *CachingPoolQuerier {
+	return &CachingPoolQuerier{
+		next:         next,
+		ttl:          ttl,
+		thresholdBps: thresholdBps,
+		byAsset:      make(map[string]cachedPools),
+		now:          time.Now,
+	}
+}
+
+// GetPoolsByAsset returns cached pools for asset, refreshing expired entries
+func (c *CachingPoolQuerier) GetPoolsByAsset(asset string) ([]IndexerPoolInfo, error) {
+	c.mu.Lock()
+	entry, ok := c.byAsset[asset]
+	c.mu.Unlock()
+
+	if ok && c.now().Before(entry.expires) {
+		return copyPools(entry.pools), nil
+	}
+
+	pools, err := c.next.GetPoolsByAsset(asset)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.byAsset[asset] = cachedPools{pools: copyPools(pools), expires: c.now().Add(c.ttl)}
+	c.mu.Unlock()
+
+	return pools, nil
+}
+
+// GetPoolByID always fetches fresh pool state, and uses it to check the
+// cache for reserve movement
+func (c *CachingPoolQuerier) GetPoolByID(poolID string) (*IndexerPoolInfo, error) {
+	pool, err := c.next.GetPoolByID(poolID)
+	if err != nil {
+		return nil, err
+	}
+	c.ObservePool(*pool)
+	return pool, nil
+}
+
+// ObservePool records fresh state for a pool. Cached entries holding the pool
+// are updated in place, or dropped if its reserves moved beyond the threshold.
+func (c *CachingPoolQuerier) ObservePool(pool IndexerPoolInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for asset, entry := range c.byAsset {
+		for i, cached := range entry.pools {
+			if cached.ID != pool.ID {
+				continue
+			}
+			if reservesMoved(cached, pool, c.thresholdBps) {
+				delete(c.byAsset, asset)
+			} else {
+				entry.pools[i] = pool
+			}
+			break
+		}
+	}
+}
+
+// InvalidatePools drops every cached entry holding one of the given pools,
+// here and in the wrapped querier if it caches too
+func (c *CachingPoolQuerier) InvalidatePools(poolIDs ...string) {
+	c.mu.Lock()
+	for asset, entry := range c.byAsset {
+		for _, cached := range entry.pools {
+			if containsPoolID(poolIDs, cached.ID) {
+				delete(c.byAsset, asset)
+				break
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	if invalidator, ok := c.next.(poolInvalidator); ok {
+		invalidator.InvalidatePools(poolIDs...)
+	}
+}
+
+// reservesMoved reports whether either reserve changed by more than
+// thresholdBps relative to its previous value
+func reservesMoved(old, current IndexerPoolInfo, thresholdBps uint64) bool {
+	return changeBps(old.Reserve0, current.Reserve0) > float64(thresholdBps) ||
+		changeBps(old.Reserve1, current.Reserve1) > float64(thresholdBps)
+}
+
+// changeBps returns the relative change from old to current in basis points
+func changeBps(old, current uint64) float64 {
+	if old == 0 {
+		if current == 0 {
+			return 0
+		}
+		return 10000
+	}
+	diff := float64(current) - float64(old)
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff / float64(old) * 10000
+}
+
+// copyPools returns a copy of pools that callers may modify
+func copyPools(pools []IndexerPoolInfo) []IndexerPoolInfo {
+	if pools == nil {
+		return nil
+	}
+	out := make([]IndexerPoolInfo, len(pools))
+	copy(out, pools)
+	return out
+}
+
+// containsPoolID reports whether ids contains id
+func containsPoolID(ids []string, id string) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package router
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCachingTestQuerier() (*CachingPoolQuerier, *mockPoolQuerier, *time.Time) {
+	backend := &mockPoolQuerier{pools: []IndexerPoolInfo{
+		{ID: "1", Asset0: "HBD", Asset1: "HIVE", Reserve0: 1000000, Reserve1: 4000000, Fee: 30},
+		{ID: "2", Asset0: "BTC", Asset1: "HBD", Reserve0: 100000000, Reserve1: 50000000, Fee: 8},
+	}}
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewCachingPoolQuerier(backend, 5*time.Second, 50)
+	cache.now = func() time.Time { return now }
+	return cache, backend, &now
+}
+
+func TestCachingPoolQuerier_TTL(t *testing.T) {
+	cache, backend, now := newCachingTestQuerier()
+
+	pools, err := cache.GetPoolsByAsset("HIVE")
+	require.NoError(t, err)
+	require.Len(t, pools, 1)
+	assert.Equal(t, 1, backend.calls)
+
+	_, err = cache.GetPoolsByAsset("HIVE")
+	require.NoError(t, err)
+	assert.Equal(t, 1, backend.calls)
+
+	*now = now.Add(6 * time.Second)
+	_, err = cache.GetPoolsByAsset("HIVE")
+	require.NoError(t, err)
+	assert.Equal(t, 2, backend.calls)
+}
+
+func TestCachingPoolQuerier_ReserveInvalidation(t *testing.T) {
+	cache, backend, _ := newCachingTestQuerier()
+
+	_, err := cache.GetPoolsByAsset("HIVE")
+	require.NoError(t, err)
+
+	// A 0.1% move is under the 50 bps threshold, so the entry is refreshed in place
+	small := backend.pools[0]
+	small.Reserve0 += 1000
+	cache.ObservePool(small)
+
+	pools, err := cache.GetPoolsByAsset("HIVE")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1001000), pools[0].Reserve0)
+	assert.Equal(t, 1, backend.calls)
+
+	// A 1% move drops the entry
+	large := backend.pools[0]
+	large.Reserve1 -= 40000
+	cache.ObservePool(large)
+
+	_, err = cache.GetPoolsByAsset("HIVE")
+	require.NoError(t, err)
+	assert.Equal(t, 2, backend.calls)
+}
+
+func TestCachingPoolQuerier_GetPoolByIDObserves(t *testing.T) {
+	cache, backend, _ := newCachingTestQuerier()
+
+	_, err := cache.GetPoolsByAsset("HIVE")
+	require.NoError(t, err)
+
+	backend.pools[0].Reserve0 = 2000000
+	pool, err := cache.GetPoolByID("1")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2000000), pool.Reserve0)
+
+	pools, err := cache.GetPoolsByAsset("HIVE")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2000000), pools[0].Reserve0)
+	assert.Equal(t, 3, backend.calls)
+}
+
+func TestCachingPoolQuerier_InvalidatedBySwap(t *testing.T) {
+	cache, backend, _ := newCachingTestQuerier()
+	svc := NewService(VSCConfig{}, &mockDEXExecutor{})
+	svc.SetPoolQuerier(cache)
+
+	params := SwapParams{Sender: "test-user", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 10000}
+
+	_, err := svc.Quote(context.Background(), params)
+	require.NoError(t, err)
+	_, err = svc.Quote(context.Background(), params)
+	require.NoError(t, err)
+	assert.Equal(t, 2, backend.calls) // HBD's pools, then BTC's for two-hop routes
+
+	// Executing through pool 1 drops its cached reserves
+	_, err = svc.ExecuteSwap(context.Background(), params)
+	require.NoError(t, err)
+	_, err = svc.Quote(context.Background(), params)
+	require.NoError(t, err)
+	assert.Equal(t, 3, backend.calls)
+}
@@ -0,0 +1,89 @@
+package router
+
+import (
+	"fmt"
+	"sort"
+)
+
+// PoolLister lists every pool a source knows
+type PoolLister interface {
+	ListPools() ([]IndexerPoolInfo, error)
+}
+
+// PoolDiscrepancy is one field on which the indexer and the contract state
+// read from a VSC node disagree about a pool
+type PoolDiscrepancy struct {
+	PoolID  string `json:"poolId"`
+	Field   string `json:"field"`
+	Indexer string `json:"indexer"`
+	Node    string `json:"node"`
+}
+
+func (d PoolDiscrepancy) String() string {
+	return fmt.Sprintf("pool %s %s: indexer %s, node %s", d.PoolID, d.Field, d.Indexer, d.Node)
+}
+
+// CrossCheckPools compares the indexer's pools with the contract state on a
+// VSC node, which is authoritative. Reserves briefly differ while the indexer
+// catches up with new blocks; discrepancies that persist mean the indexer has
+// missed or misread events.
+func CrossCheckPools(indexer, node PoolLister) ([]PoolDiscrepancy, error) {
+	indexed, err := indexer.ListPools()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexer pools: %w", err)
+	}
+	onChain, err := node.ListPools()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list node pools: %w", err)
+	}
+
+	byID := make(map[string]IndexerPoolInfo, len(indexed))
+	for _, pool := range indexed {
+		byID[pool.ID] = pool
+	}
+
+	var discrepancies []PoolDiscrepancy
+	for _, want := range onChain {
+		got, ok := byID[want.ID]
+		if !ok {
+			discrepancies = append(discrepancies, PoolDiscrepancy{PoolID: want.ID, Field: "pool", Indexer: "missing", Node: "present"})
+			continue
+		}
+		delete(byID, want.ID)
+		discrepancies = append(discrepancies, comparePool(got, want)...)
+	}
+	for id := range byID {
+		discrepancies = append(discrepancies, PoolDiscrepancy{PoolID: id, Field: "pool", Indexer: "present", Node: "missing"})
+	}
+
+	sort.SliceStable(discrepancies, func(i, j int) bool { return discrepancies[i].PoolID < discrepancies[j].PoolID })
+	return discrepancies, nil
+}
+
+// comparePool lists the fields on which two views of one pool differ
+func comparePool(indexed, onChain IndexerPoolInfo) []PoolDiscrepancy {
+	fields := []struct {
+		name          string
+		indexer, node interface{}
+	}{
+		{"asset0", indexed.Asset0, onChain.Asset0},
+		{"asset1", indexed.Asset1, onChain.Asset1},
+		{"reserve0", indexed.Reserve0, onChain.Reserve0},
+		{"reserve1", indexed.Reserve1, onChain.Reserve1},
+		{"fee", indexed.Fee, onChain.Fee},
+		{"total_supply", indexed.TotalSupply, onChain.TotalSupply},
+	}
+
+	var discrepancies []PoolDiscrepancy
+	for _, field := range fields {
+		if field.indexer != field.node {
+			discrepancies = append(discrepancies, PoolDiscrepancy{
+				PoolID:  indexed.ID,
+				Field:   field.name,
+				Indexer: fmt.Sprint(field.indexer),
+				Node:    fmt.Sprint(field.node),
+			})
+		}
+	}
+	return discrepancies
+}
@@ -0,0 +1,59 @@
+package router
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticPoolLister lists a fixed set of pools
+type staticPoolLister struct {
+	pools []IndexerPoolInfo
+	err   error
+}
+
+func (l staticPoolLister) ListPools() ([]IndexerPoolInfo, error) {
+	return l.pools, l.err
+}
+
+func TestCrossCheckPools(t *testing.T) {
+	btcHbd := IndexerPoolInfo{ID: "1", Asset0: "BTC", Asset1: "HBD", Reserve0: 1000, Reserve1: 2000, Fee: 8, TotalSupply: 1414}
+	hbdHive := IndexerPoolInfo{ID: "2", Asset0: "HBD", Asset1: "HIVE", Reserve0: 3000, Reserve1: 4000, Fee: 30, TotalSupply: 3464}
+	hiveEth := IndexerPoolInfo{ID: "3", Asset0: "HIVE", Asset1: "ETH", Reserve0: 5000, Reserve1: 6000, Fee: 30, TotalSupply: 5477}
+
+	t.Run("agreeing sources", func(t *testing.T) {
+		discrepancies, err := CrossCheckPools(
+			staticPoolLister{pools: []IndexerPoolInfo{btcHbd, hbdHive}},
+			staticPoolLister{pools: []IndexerPoolInfo{hbdHive, btcHbd}},
+		)
+		require.NoError(t, err)
+		assert.Empty(t, discrepancies)
+	})
+
+	t.Run("diverging sources", func(t *testing.T) {
+		stale := btcHbd
+		stale.Reserve0 = 900
+
+		discrepancies, err := CrossCheckPools(
+			staticPoolLister{pools: []IndexerPoolInfo{stale, hiveEth}},
+			staticPoolLister{pools: []IndexerPoolInfo{btcHbd, hbdHive}},
+		)
+		require.NoError(t, err)
+		assert.Equal(t, []PoolDiscrepancy{
+			{PoolID: "1", Field: "reserve0", Indexer: "900", Node: "1000"},
+			{PoolID: "2", Field: "pool", Indexer: "missing", Node: "present"},
+			{PoolID: "3", Field: "pool", Indexer: "present", Node: "missing"},
+		}, discrepancies)
+		assert.Equal(t, "pool 1 reserve0: indexer 900, node 1000", discrepancies[0].String())
+	})
+
+	t.Run("unreachable source", func(t *testing.T) {
+		_, err := CrossCheckPools(
+			staticPoolLister{pools: []IndexerPoolInfo{btcHbd}},
+			staticPoolLister{err: errors.New("connection refused")},
+		)
+		assert.ErrorContains(t, err, "node pools")
+	})
+}
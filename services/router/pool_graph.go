@@ -0,0 +1,257 @@
+package router
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// poolGraphRetryDelay is the wait before reconnecting a dropped pool stream
+const poolGraphRetryDelay = 2 * time.Second
+
+// errStreamMoved ends a pool stream connection whose endpoint was changed
+var errStreamMoved = errors.New("pool stream endpoint changed")
+
+// PoolGraph implements PoolQuerier over an in-memory pool topology kept up to
+// date from the indexer's pool stream, so routing never waits on the indexer
+type PoolGraph struct {
+	httpClient *http.Client
+	fallback   PoolQuerier // Answers queries until the first snapshot arrives
+
+	streamMu  sync.Mutex
+	streamURL string             // Empty while streaming is stopped
+	restart   context.CancelFunc // Ends the current connection
+
+	mu      sync.RWMutex
+	pools   map[string]IndexerPoolInfo
+	byAsset map[string]map[string]struct{} // asset -> pool IDs
+	synced  bool
+}
+
+// NewPoolGraph creates a pool graph fed by the indexer at indexerEndpoint.
+// Call Run to start streaming.
+func NewPoolGraph(indexerEndpoint string) *PoolGraph {
+	return &PoolGraph{
+		streamURL:  poolStreamURL(indexerEndpoint),
+		httpClient: &http.Client{}, // No timeout: the stream is long-lived
+		pools:      make(map[string]IndexerPoolInfo),
+		byAsset:    make(map[string]map[string]struct{}),
+	}
+}
+
+// SetFallback configures a querier used while the graph is not synced
+func (g *PoolGraph) SetFallback(querier PoolQuerier) {
+	g.fallback = querier
+}
+
+// SetEndpoint moves the stream to another indexer, reconnecting at once.
+// Queries go to the fallback until the new indexer's snapshot arrives. An
+// empty endpoint stops streaming, leaving every query to the fallback.
+func (g *PoolGraph) SetEndpoint(indexerEndpoint string) {
+	g.streamMu.Lock()
+	defer g.streamMu.Unlock()
+	g.streamURL = poolStreamURL(indexerEndpoint)
+	if g.restart != nil {
+		g.restart()
+	}
+}
+
+// Run streams pool updates until ctx is cancelled, reconnecting on failure.
+// Each connection starts with a full snapshot, so nothing missed while
+// disconnected is lost.
+func (g *PoolGraph) Run(ctx context.Context) {
+	for {
+		err := g.stream(ctx)
+		if err != nil && ctx.Err() == nil && !errors.Is(err, errStreamMoved) {
+			log.Printf("Pool stream disconnected: %v", err)
+		}
+
+		g.mu.Lock()
+		g.synced = false
+		g.mu.Unlock()
+
+		if errors.Is(err, errStreamMoved) {
+			continue // Connect to the new endpoint at once
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(poolGraphRetryDelay):
+		}
+	}
+}
+
+// Synced reports whether the graph holds a current snapshot
+func (g *PoolGraph) Synced() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.synced
+}
+
+// GetPoolByID returns a pool from the graph
+func (g *PoolGraph) GetPoolByID(poolID string) (*IndexerPoolInfo, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if !g.synced {
+		if g.fallback != nil {
+			return g.fallback.GetPoolByID(poolID)
+		}
+		return nil, fmt.Errorf("pool graph not synced")
+	}
+	pool, exists := g.pools[poolID]
+	if !exists {
+		return nil, fmt.Errorf("pool not found: %s", poolID)
+	}
+	return &pool, nil
+}
+
+// GetPoolsByAsset returns the pools in the graph containing asset
+func (g *PoolGraph) GetPoolsByAsset(asset string) ([]IndexerPoolInfo, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if !g.synced {
+		if g.fallback != nil {
+			return g.fallback.GetPoolsByAsset(asset)
+		}
+		return nil, fmt.Errorf("pool graph not synced")
+	}
+	var pools []IndexerPoolInfo
+	for poolID := range g.byAsset[asset] {
+		pools = append(pools, g.pools[poolID])
+	}
+	return pools, nil
+}
+
+// stream consumes one connection to the pool stream, ending with
+// errStreamMoved when the endpoint is changed
+func (g *PoolGraph) stream(ctx context.Context) error {
+	connCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	g.streamMu.Lock()
+	streamURL := g.streamURL
+	g.restart = cancel
+	g.streamMu.Unlock()
+
+	var err error
+	if streamURL == "" {
+		<-connCtx.Done()
+		err = connCtx.Err()
+	} else {
+		err = g.consume(connCtx, streamURL)
+	}
+	if ctx.Err() == nil && connCtx.Err() != nil {
+		return errStreamMoved
+	}
+	return err
+}
+
+// consume reads events from one connection to a pool stream
+func (g *PoolGraph) consume(ctx context.Context, streamURL string) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", streamURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to pool stream: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("indexer returned status %d", resp.StatusCode)
+	}
+
+	var event, data string
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024) // Snapshots carry every pool
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if err := g.handleEvent(event, data); err != nil {
+				return err
+			}
+			event, data = "", ""
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data += strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("pool stream closed")
+}
+
+// poolStreamURL is the pool stream of the indexer at indexerEndpoint, or
+// empty for no indexer
+func poolStreamURL(indexerEndpoint string) string {
+	if indexerEndpoint == "" {
+		return ""
+	}
+	return strings.TrimRight(indexerEndpoint, "/") + "/api/v1/stream/pools"
+}
+
+// handleEvent applies one server-sent event to the graph
+func (g *PoolGraph) handleEvent(event, data string) error {
+	switch event {
+	case "snapshot":
+		var pools []indexerPoolResponse
+		if err := json.Unmarshal([]byte(data), &pools); err != nil {
+			return fmt.Errorf("failed to decode pool snapshot: %w", err)
+		}
+		g.replace(pools)
+	case "pool":
+		var pool indexerPoolResponse
+		if err := json.Unmarshal([]byte(data), &pool); err != nil {
+			return fmt.Errorf("failed to decode pool update: %w", err)
+		}
+		g.apply(pool)
+	}
+	return nil
+}
+
+// replace swaps the whole topology for a snapshot
+func (g *PoolGraph) replace(pools []indexerPoolResponse) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.pools = make(map[string]IndexerPoolInfo, len(pools))
+	g.byAsset = make(map[string]map[string]struct{})
+	for _, pool := range pools {
+		g.applyLocked(pool.toPoolInfo())
+	}
+	g.synced = true
+}
+
+// apply records a single pool's new state
+func (g *PoolGraph) apply(pool indexerPoolResponse) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.applyLocked(pool.toPoolInfo())
+}
+
+// applyLocked indexes a pool by ID and asset; caller must hold g.mu
+func (g *PoolGraph) applyLocked(pool IndexerPoolInfo) {
+	g.pools[pool.ID] = pool
+	for _, asset := range []string{pool.Asset0, pool.Asset1} {
+		if g.byAsset[asset] == nil {
+			g.byAsset[asset] = make(map[string]struct{})
+		}
+		g.byAsset[asset][pool.ID] = struct{}{}
+	}
+}
@@ -0,0 +1,130 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolGraph_Stream(t *testing.T) {
+	update := make(chan string)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/stream/pools", r.URL.Path)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: snapshot\ndata: [{\"id\":\"1\",\"asset0\":\"HBD\",\"asset1\":\"HIVE\",\"reserve0\":1000000,\"reserve1\":4000000,\"fee\":0.3}]\n\n")
+		w.(http.Flusher).Flush()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case data := <-update:
+				fmt.Fprintf(w, ": ping\n\nevent: pool\ndata: %s\n\n", data)
+				w.(http.Flusher).Flush()
+			}
+		}
+	}))
+	defer ts.Close()
+
+	graph := NewPoolGraph(ts.URL + "/")
+	_, err := graph.GetPoolsByAsset("HBD")
+	assert.ErrorContains(t, err, "not synced")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go graph.Run(ctx)
+
+	require.Eventually(t, graph.Synced, 2*time.Second, 10*time.Millisecond)
+
+	pool, err := graph.GetPoolByID("1")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(30), pool.Fee)
+	assert.Equal(t, uint64(1000000), pool.Reserve0)
+
+	// An update moves reserves and a new pool joins the topology
+	update <- `{"id":"1","asset0":"HBD","asset1":"HIVE","reserve0":1010000,"reserve1":3960000,"fee":0.3}`
+	update <- `{"id":"2","asset0":"BTC","asset1":"HBD","reserve0":100000000,"reserve1":50000000,"fee":0.08}`
+
+	require.Eventually(t, func() bool {
+		pools, err := graph.GetPoolsByAsset("HBD")
+		return err == nil && len(pools) == 2
+	}, 2*time.Second, 10*time.Millisecond)
+
+	pool, err = graph.GetPoolByID("1")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1010000), pool.Reserve0)
+
+	// Routing runs entirely off the graph
+	svc := NewService(VSCConfig{}, &mockDEXExecutor{})
+	svc.SetPoolQuerier(graph)
+	quote, err := svc.Quote(ctx, SwapParams{AssetIn: "BTC", AssetOut: "HIVE", AmountIn: 100000})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"BTC", "HBD", "HIVE"}, quote.Route)
+}
+
+func TestPoolGraph_Fallback(t *testing.T) {
+	backend := &mockPoolQuerier{pools: []IndexerPoolInfo{
+		{ID: "1", Asset0: "HBD", Asset1: "HIVE", Reserve0: 1000000, Reserve1: 4000000, Fee: 30},
+	}}
+	graph := NewPoolGraph("http://localhost:0")
+	graph.SetFallback(backend)
+
+	pools, err := graph.GetPoolsByAsset("HIVE")
+	require.NoError(t, err)
+	assert.Len(t, pools, 1)
+	assert.Equal(t, 1, backend.calls)
+
+	// Once synced, the fallback is no longer consulted
+	graph.replace([]indexerPoolResponse{{ID: "1", Asset0: "HBD", Asset1: "HIVE", Reserve0: 5, Reserve1: 5, Fee: 0.3}})
+	pool, err := graph.GetPoolByID("1")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), pool.Reserve0)
+	assert.Equal(t, 1, backend.calls)
+
+	_, err = graph.GetPoolByID("missing")
+	assert.ErrorContains(t, err, "pool not found")
+}
+
+func TestPoolGraph_SetEndpoint(t *testing.T) {
+	streamServer := func(reserve0 int) *httptest.Server {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "event: snapshot\ndata: [{\"id\":\"1\",\"asset0\":\"HBD\",\"asset1\":\"HIVE\",\"reserve0\":%d,\"reserve1\":4000000,\"fee\":0.3}]\n\n", reserve0)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}))
+		t.Cleanup(ts.Close)
+		return ts
+	}
+	first, second := streamServer(1000000), streamServer(2000000)
+
+	graph := NewPoolGraph(first.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go graph.Run(ctx)
+
+	reserve := func() uint64 {
+		pool, err := graph.GetPoolByID("1")
+		if err != nil {
+			return 0
+		}
+		return pool.Reserve0
+	}
+	require.Eventually(t, func() bool { return reserve() == 1000000 }, 2*time.Second, 10*time.Millisecond)
+
+	// The graph reconnects to the new indexer at once
+	graph.SetEndpoint(second.URL)
+	require.Eventually(t, func() bool { return reserve() == 2000000 }, time.Second, 10*time.Millisecond)
+
+	// With no endpoint, the graph stops streaming and defers to its fallback
+	graph.SetEndpoint("")
+	require.Eventually(t, func() bool { return !graph.Synced() }, time.Second, 10*time.Millisecond)
+	_, err := graph.GetPoolByID("1")
+	assert.ErrorContains(t, err, "not synced")
+}
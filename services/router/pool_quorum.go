@@ -0,0 +1,238 @@
+package router
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// defaultQuorumToleranceBps is how far apart two indexers' reserves may be
+// and still agree
+const defaultQuorumToleranceBps = 50
+
+// PoolSource is one of several pool queriers read together, named in errors
+type PoolSource struct {
+	Name    string
+	Querier PoolQuerier
+}
+
+// QuorumConfig sets how closely pool sources must agree
+type QuorumConfig struct {
+	// Quorum is how many sources must agree on a pool; zero means a
+	// majority of the sources
+	Quorum int
+
+	// ToleranceBps is how far apart, in basis points, two sources' reserves
+	// may be and still agree. Reserves move with every swap, so sources a
+	// block apart rarely match exactly. Zero uses 50 bps.
+	ToleranceBps uint64
+}
+
+// QuorumPoolQuerier reads pools from several sources concurrently, such as
+// indexers run by different operators, and only returns pools a quorum of
+// them agree on. One stale or faulty source cannot then produce bad routes:
+// a pool the sources cannot agree on fails the lookup instead.
+type QuorumPoolQuerier struct {
+	sources   []PoolSource
+	quorum    int
+	tolerance uint64
+}
+
+// NewQuorumPoolQuerier reads pools from sources, which must be at least two
+func NewQuorumPoolQuerier(sources []PoolSource, config QuorumConfig) (*QuorumPoolQuerier, error) {
+	if len(sources) < 2 {
+		return nil, fmt.Errorf("a quorum needs at least 2 pool sources")
+	}
+	quorum := config.Quorum
+	if quorum == 0 {
+		quorum = len(sources)/2 + 1
+	}
+	if quorum < 1 || quorum > len(sources) {
+		return nil, fmt.Errorf("quorum must be between 1 and %d", len(sources))
+	}
+	if config.ToleranceBps == 0 {
+		config.ToleranceBps = defaultQuorumToleranceBps
+	}
+	if config.ToleranceBps > 10000 {
+		return nil, fmt.Errorf("quorum tolerance must be at most 10000 bps")
+	}
+	return &QuorumPoolQuerier{sources: sources, quorum: quorum, tolerance: config.ToleranceBps}, nil
+}
+
+// poolView is one source's answer
+type poolView struct {
+	source string
+	pools  []IndexerPoolInfo
+	err    error
+}
+
+// query asks every source at once
+func (q *QuorumPoolQuerier) query(read func(PoolQuerier) ([]IndexerPoolInfo, error)) []poolView {
+	views := make([]poolView, len(q.sources))
+	var wg sync.WaitGroup
+	for i, source := range q.sources {
+		wg.Add(1)
+		go func(i int, source PoolSource) {
+			defer wg.Done()
+			pools, err := read(source.Querier)
+			views[i] = poolView{source: source.Name, pools: pools, err: err}
+		}(i, source)
+	}
+	wg.Wait()
+	return views
+}
+
+// responding returns the views of sources that answered, failing if fewer
+// than a quorum did
+func (q *QuorumPoolQuerier) responding(views []poolView) ([]poolView, error) {
+	var answered []poolView
+	var failures []string
+	for _, view := range views {
+		if view.err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", view.source, view.err))
+			continue
+		}
+		answered = append(answered, view)
+	}
+	if len(answered) < q.quorum {
+		return nil, fmt.Errorf("only %d of %d pool sources answered, %d needed: %s", len(answered), len(views), q.quorum, strings.Join(failures, "; "))
+	}
+	return answered, nil
+}
+
+// GetPoolByID returns the pool as a quorum of sources see it
+func (q *QuorumPoolQuerier) GetPoolByID(poolID string) (*IndexerPoolInfo, error) {
+	views, err := q.responding(q.query(func(querier PoolQuerier) ([]IndexerPoolInfo, error) {
+		pool, err := querier.GetPoolByID(poolID)
+		if err != nil {
+			return nil, err
+		}
+		return []IndexerPoolInfo{*pool}, nil
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pool %s: %w", poolID, err)
+	}
+
+	pool, present, err := q.agree(poolID, views)
+	if err != nil {
+		return nil, err
+	}
+	if !present {
+		return nil, fmt.Errorf("pool not found: %s", poolID)
+	}
+	return pool, nil
+}
+
+// GetPoolsByAsset returns the pools holding asset that a quorum of sources
+// agree on. Pools only a minority of sources know are left out.
+func (q *QuorumPoolQuerier) GetPoolsByAsset(asset string) ([]IndexerPoolInfo, error) {
+	views, err := q.responding(q.query(func(querier PoolQuerier) ([]IndexerPoolInfo, error) {
+		return querier.GetPoolsByAsset(asset)
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s pools: %w", asset, err)
+	}
+
+	seen := make(map[string]bool)
+	var ids []string
+	for _, view := range views {
+		for _, pool := range view.pools {
+			if !seen[pool.ID] {
+				seen[pool.ID] = true
+				ids = append(ids, pool.ID)
+			}
+		}
+	}
+	sort.Strings(ids)
+
+	var pools []IndexerPoolInfo
+	for _, id := range ids {
+		pool, present, err := q.agree(id, views)
+		if err != nil {
+			return nil, err
+		}
+		if present {
+			pools = append(pools, *pool)
+		}
+	}
+	return pools, nil
+}
+
+// agree finds the version of a pool that a quorum of views agree on. The
+// bool is false if a quorum agree the pool does not exist. It fails if
+// neither the pool nor its absence has a quorum.
+func (q *QuorumPoolQuerier) agree(poolID string, views []poolView) (*IndexerPoolInfo, bool, error) {
+	type answer struct {
+		source string
+		pool   *IndexerPoolInfo // Nil if the source does not know the pool
+	}
+	answers := make([]answer, len(views))
+	for i, view := range views {
+		answers[i] = answer{source: view.source}
+		for _, pool := range view.pools {
+			if pool.ID == poolID {
+				p := pool
+				answers[i].pool = &p
+				break
+			}
+		}
+	}
+
+	// The answer most others agree with wins, if enough do
+	best, bestVotes := -1, 0
+	for i, candidate := range answers {
+		votes := 0
+		for _, other := range answers {
+			if q.same(candidate.pool, other.pool) {
+				votes++
+			}
+		}
+		if votes > bestVotes {
+			best, bestVotes = i, votes
+		}
+	}
+	if bestVotes >= q.quorum {
+		return answers[best].pool, answers[best].pool != nil, nil
+	}
+
+	described := make([]string, len(answers))
+	for i, a := range answers {
+		if a.pool == nil {
+			described[i] = a.source + ": missing"
+		} else {
+			described[i] = fmt.Sprintf("%s: %d/%d", a.source, a.pool.Reserve0, a.pool.Reserve1)
+		}
+	}
+	return nil, false, fmt.Errorf("pool sources disagree on pool %s (%s); %d must agree within %d bps", poolID, strings.Join(described, ", "), q.quorum, q.tolerance)
+}
+
+// same reports whether two views of a pool agree: both missing, or with the
+// same assets and fee and reserves within the tolerance
+func (q *QuorumPoolQuerier) same(a, b *IndexerPoolInfo) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Asset0 == b.Asset0 && a.Asset1 == b.Asset1 && a.Fee == b.Fee &&
+		withinBps(a.Reserve0, b.Reserve0, q.tolerance) && withinBps(a.Reserve1, b.Reserve1, q.tolerance)
+}
+
+// withinBps reports whether a and b differ by at most bps of the larger
+func withinBps(a, b, bps uint64) bool {
+	if a < b {
+		a, b = b, a
+	}
+	diff := new(big.Int).Mul(new(big.Int).SetUint64(a-b), big.NewInt(10000))
+	limit := new(big.Int).Mul(new(big.Int).SetUint64(a), new(big.Int).SetUint64(bps))
+	return diff.Cmp(limit) <= 0
+}
+
+// InvalidatePools drops cached state for pools in every source that caches
+func (q *QuorumPoolQuerier) InvalidatePools(poolIDs ...string) {
+	for _, source := range q.sources {
+		if invalidator, ok := source.Querier.(poolInvalidator); ok {
+			invalidator.InvalidatePools(poolIDs...)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package router
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingPoolQuerier is a pool source that cannot be reached
+type failingPoolQuerier struct{}
+
+func (failingPoolQuerier) GetPoolByID(string) (*IndexerPoolInfo, error) {
+	return nil, fmt.Errorf("connection refused")
+}
+
+func (failingPoolQuerier) GetPoolsByAsset(string) ([]IndexerPoolInfo, error) {
+	return nil, fmt.Errorf("connection refused")
+}
+
+func quorumTestPools() []IndexerPoolInfo {
+	return []IndexerPoolInfo{
+		{ID: "hbd-hive", Asset0: "HBD", Asset1: "HIVE", Reserve0: 1000000, Reserve1: 4000000, Fee: 30},
+		{ID: "hbd-btc", Asset0: "HBD", Asset1: "BTC", Reserve0: 5000000, Reserve1: 10000000, Fee: 8},
+	}
+}
+
+func newQuorumTestQuerier(t *testing.T, config QuorumConfig, sources ...PoolQuerier) *QuorumPoolQuerier {
+	named := make([]PoolSource, len(sources))
+	for i, source := range sources {
+		named[i] = PoolSource{Name: fmt.Sprintf("indexer-%d", i+1), Querier: source}
+	}
+	querier, err := NewQuorumPoolQuerier(named, config)
+	require.NoError(t, err)
+	return querier
+}
+
+func TestNewQuorumPoolQuerierValidation(t *testing.T) {
+	one := []PoolSource{{Name: "a", Querier: &mockPoolQuerier{}}}
+	_, err := NewQuorumPoolQuerier(one, QuorumConfig{})
+	assert.ErrorContains(t, err, "at least 2")
+
+	two := append(one, PoolSource{Name: "b", Querier: &mockPoolQuerier{}})
+	_, err = NewQuorumPoolQuerier(two, QuorumConfig{Quorum: 3})
+	assert.ErrorContains(t, err, "between 1 and 2")
+	_, err = NewQuorumPoolQuerier(two, QuorumConfig{ToleranceBps: 10001})
+	assert.Error(t, err)
+
+	querier, err := NewQuorumPoolQuerier(two, QuorumConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, querier.quorum, "a majority of two is both")
+	assert.Equal(t, uint64(defaultQuorumToleranceBps), querier.tolerance)
+}
+
+func TestQuorumOutvotesStaleSource(t *testing.T) {
+	fresh := quorumTestPools()
+	slightlyBehind := quorumTestPools()
+	slightlyBehind[0].Reserve0 += 2000 // 0.2%, within tolerance
+	stale := quorumTestPools()
+	stale[0].Reserve0, stale[0].Reserve1 = 2000000, 2000000
+
+	querier := newQuorumTestQuerier(t, QuorumConfig{},
+		&mockPoolQuerier{pools: fresh}, &mockPoolQuerier{pools: stale}, &mockPoolQuerier{pools: slightlyBehind})
+
+	pools, err := querier.GetPoolsByAsset("HBD")
+	require.NoError(t, err)
+	require.Len(t, pools, 2)
+	assert.Equal(t, "hbd-btc", pools[0].ID)
+	assert.Equal(t, fresh[0], pools[1], "the stale reserves are outvoted")
+
+	pool, err := querier.GetPoolByID("hbd-hive")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4000000), pool.Reserve1)
+}
+
+func TestQuorumFailsOnDisagreement(t *testing.T) {
+	stale := quorumTestPools()
+	stale[0].Reserve0 = 1100000 // 10% off
+
+	querier := newQuorumTestQuerier(t, QuorumConfig{},
+		&mockPoolQuerier{pools: quorumTestPools()}, &mockPoolQuerier{pools: stale})
+	_, err := querier.GetPoolsByAsset("HIVE")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "pool sources disagree on pool hbd-hive")
+	assert.Contains(t, err.Error(), "indexer-2: 1100000/4000000")
+
+	// A wider tolerance accepts them
+	querier = newQuorumTestQuerier(t, QuorumConfig{ToleranceBps: 1000},
+		&mockPoolQuerier{pools: quorumTestPools()}, &mockPoolQuerier{pools: stale})
+	pools, err := querier.GetPoolsByAsset("HIVE")
+	require.NoError(t, err)
+	assert.Len(t, pools, 1)
+}
+
+func TestQuorumPoolPresence(t *testing.T) {
+	withExtra := append(quorumTestPools(), IndexerPoolInfo{ID: "hbd-spk", Asset0: "HBD", Asset1: "SPK", Reserve0: 100, Reserve1: 100, Fee: 30})
+
+	// A pool only one of three sources knows is left out
+	querier := newQuorumTestQuerier(t, QuorumConfig{},
+		&mockPoolQuerier{pools: quorumTestPools()}, &mockPoolQuerier{pools: withExtra}, &mockPoolQuerier{pools: quorumTestPools()})
+	pools, err := querier.GetPoolsByAsset("HBD")
+	require.NoError(t, err)
+	assert.Len(t, pools, 2)
+
+	// With two sources, one knowing a pool the other lacks is a disagreement
+	querier = newQuorumTestQuerier(t, QuorumConfig{},
+		&mockPoolQuerier{pools: quorumTestPools()}, &mockPoolQuerier{pools: withExtra})
+	_, err = querier.GetPoolsByAsset("SPK")
+	assert.ErrorContains(t, err, "indexer-1: missing")
+}
+
+func TestQuorumNeedsEnoughSources(t *testing.T) {
+	querier := newQuorumTestQuerier(t, QuorumConfig{},
+		&mockPoolQuerier{pools: quorumTestPools()}, failingPoolQuerier{}, &mockPoolQuerier{pools: quorumTestPools()})
+
+	// Two of three is still a majority
+	pools, err := querier.GetPoolsByAsset("HBD")
+	require.NoError(t, err)
+	assert.Len(t, pools, 2)
+
+	querier = newQuorumTestQuerier(t, QuorumConfig{Quorum: 3},
+		&mockPoolQuerier{pools: quorumTestPools()}, failingPoolQuerier{}, &mockPoolQuerier{pools: quorumTestPools()})
+	_, err = querier.GetPoolsByAsset("HBD")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only 2 of 3 pool sources answered, 3 needed")
+	assert.Contains(t, err.Error(), "indexer-2: connection refused")
+}
@@ -0,0 +1,241 @@
+package router
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// maxPriceSamples bounds the price history kept per pool
+const maxPriceSamples = 10000
+
+// priceSample is a pool's spot price from the time it was observed until
+// the next sample
+type priceSample struct {
+	at     time.Time
+	price0 float64 // Asset1 per Asset0
+	price1 float64 // Asset0 per Asset1
+}
+
+// trackedPool is the recent price history of one pool
+type trackedPool struct {
+	asset0, asset1     string
+	reserve0, reserve1 uint64 // Last observed
+	samples            []priceSample
+}
+
+// PriceTracker implements PoolQuerier over another querier, recording the
+// spot price of every pool read through it so it can report time-weighted
+// average prices. Run keeps sampling the pools it has seen between reads.
+type PriceTracker struct {
+	next   PoolQuerier
+	window time.Duration
+
+	mu    sync.Mutex
+	pools map[string]*trackedPool
+	now   func() time.Time
+}
+
+// NewPriceTracker wraps next, averaging prices over window
+func NewPriceTracker(next PoolQuerier, window time.Duration) *PriceTracker {
+	return &PriceTracker{
+		next:   next,
+		window: window,
+		pools:  make(map[string]*trackedPool),
+		now:    time.Now,
+	}
+}
+
+// GetPoolByID reads a pool, recording its price
+func (t *PriceTracker) GetPoolByID(poolID string) (*IndexerPoolInfo, error) {
+	pool, err := t.next.GetPoolByID(poolID)
+	if err != nil {
+		return nil, err
+	}
+	t.observe(*pool)
+	return pool, nil
+}
+
+// GetPoolsByAsset reads the pools containing asset, recording their prices
+func (t *PriceTracker) GetPoolsByAsset(asset string) ([]IndexerPoolInfo, error) {
+	pools, err := t.next.GetPoolsByAsset(asset)
+	if err != nil {
+		return nil, err
+	}
+	for _, pool := range pools {
+		t.observe(pool)
+	}
+	return pools, nil
+}
+
+// InvalidatePools passes invalidations on to a caching querier underneath
+func (t *PriceTracker) InvalidatePools(poolIDs ...string) {
+	if invalidator, ok := t.next.(poolInvalidator); ok {
+		invalidator.InvalidatePools(poolIDs...)
+	}
+}
+
+// Run re-reads every pool seen so far each interval until ctx is cancelled,
+// so averages keep accruing while nothing trades
+func (t *PriceTracker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		t.mu.Lock()
+		poolIDs := make([]string, 0, len(t.pools))
+		for poolID := range t.pools {
+			poolIDs = append(poolIDs, poolID)
+		}
+		t.mu.Unlock()
+
+		for _, poolID := range poolIDs {
+			if _, err := t.GetPoolByID(poolID); err != nil {
+				log.Printf("Price sample of pool %s failed: %v", poolID, err)
+			}
+		}
+	}
+}
+
+// observe records a pool's spot price if its reserves moved since the last
+// sample, and drops samples that fell out of the window
+func (t *PriceTracker) observe(pool IndexerPoolInfo) {
+	if pool.Reserve0 == 0 || pool.Reserve1 == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tracked, ok := t.pools[pool.ID]
+	if !ok {
+		tracked = &trackedPool{asset0: pool.Asset0, asset1: pool.Asset1}
+		t.pools[pool.ID] = tracked
+	}
+	if len(tracked.samples) > 0 && tracked.reserve0 == pool.Reserve0 && tracked.reserve1 == pool.Reserve1 {
+		return
+	}
+
+	now := t.now()
+	tracked.reserve0, tracked.reserve1 = pool.Reserve0, pool.Reserve1
+	tracked.samples = append(tracked.samples, priceSample{
+		at:     now,
+		price0: float64(pool.Reserve1) / float64(pool.Reserve0),
+		price1: float64(pool.Reserve0) / float64(pool.Reserve1),
+	})
+
+	// Keep the last sample before the window, whose price still holds at
+	// the window's start
+	start := now.Add(-t.window)
+	drop := 0
+	for drop+1 < len(tracked.samples) && !tracked.samples[drop+1].at.After(start) {
+		drop++
+	}
+	if excess := len(tracked.samples) - drop - maxPriceSamples; excess > 0 {
+		drop += excess
+	}
+	tracked.samples = append(tracked.samples[:0], tracked.samples[drop:]...)
+}
+
+// SpotPrice returns a pool's last observed price of assetIn, in assetOut per
+// unit of assetIn
+func (t *PriceTracker) SpotPrice(poolID string, assetIn string) (float64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tracked, ok := t.pools[poolID]
+	if !ok || len(tracked.samples) == 0 {
+		return 0, false
+	}
+	return tracked.price(tracked.samples[len(tracked.samples)-1], assetIn)
+}
+
+// TWAP returns a pool's time-weighted average price of assetIn over the
+// window, in assetOut per unit of assetIn. It reports false until the
+// pool's history covers at least half the window.
+func (t *PriceTracker) TWAP(poolID string, assetIn string) (float64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tracked, ok := t.pools[poolID]
+	if !ok {
+		return 0, false
+	}
+	return t.twapLocked(tracked, assetIn)
+}
+
+// pairTWAP returns the average price of assetIn in assetOut from the
+// deepest tracked pool between them with enough history
+func (t *PriceTracker) pairTWAP(assetIn, assetOut string) (float64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var best float64
+	var bestDepth uint64
+	found := false
+	for _, tracked := range t.pools {
+		var depth uint64
+		switch {
+		case tracked.asset0 == assetIn && tracked.asset1 == assetOut:
+			depth = tracked.reserve1
+		case tracked.asset1 == assetIn && tracked.asset0 == assetOut:
+			depth = tracked.reserve0
+		default:
+			continue
+		}
+		if price, ok := t.twapLocked(tracked, assetIn); ok && (!found || depth > bestDepth) {
+			best, bestDepth, found = price, depth, true
+		}
+	}
+	return best, found
+}
+
+// twapLocked averages a pool's samples over the window; caller holds t.mu.
+// Each sample's price holds until the next sample, the last one until now.
+func (t *PriceTracker) twapLocked(tracked *trackedPool, assetIn string) (float64, bool) {
+	now := t.now()
+	start := now.Add(-t.window)
+
+	var weighted, covered float64
+	for i, sample := range tracked.samples {
+		from, until := sample.at, now
+		if from.Before(start) {
+			from = start
+		}
+		if i+1 < len(tracked.samples) {
+			until = tracked.samples[i+1].at
+		}
+		if !until.After(from) {
+			continue
+		}
+		price, ok := tracked.price(sample, assetIn)
+		if !ok {
+			return 0, false
+		}
+		seconds := until.Sub(from).Seconds()
+		weighted += price * seconds
+		covered += seconds
+	}
+	if covered == 0 || covered < t.window.Seconds()/2 {
+		return 0, false
+	}
+	return weighted / covered, true
+}
+
+// price orients a sample's price to assetIn
+func (tracked *trackedPool) price(sample priceSample, assetIn string) (float64, bool) {
+	switch assetIn {
+	case tracked.asset0:
+		return sample.price0, true
+	case tracked.asset1:
+		return sample.price1, true
+	}
+	return 0, false
+}
@@ -0,0 +1,170 @@
+package router
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testClock is a settable time source
+type testClock struct {
+	at time.Time
+}
+
+func newTestClock() *testClock {
+	return &testClock{at: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *testClock) now() time.Time          { return c.at }
+func (c *testClock) advance(d time.Duration) { c.at = c.at.Add(d) }
+
+// setReserves changes a mock pool's reserves
+func setReserves(m *mockPoolQuerier, id string, reserve0, reserve1 uint64) {
+	for i := range m.pools {
+		if m.pools[i].ID == id {
+			m.pools[i].Reserve0, m.pools[i].Reserve1 = reserve0, reserve1
+		}
+	}
+}
+
+// countingPoolQuerier counts pool reads, safely across goroutines
+type countingPoolQuerier struct {
+	PoolQuerier
+	reads atomic.Int64
+}
+
+func (c *countingPoolQuerier) GetPoolByID(poolID string) (*IndexerPoolInfo, error) {
+	c.reads.Add(1)
+	return c.PoolQuerier.GetPoolByID(poolID)
+}
+
+func newTestPriceTracker() (*PriceTracker, *mockPoolQuerier, *testClock) {
+	querier := &mockPoolQuerier{pools: []IndexerPoolInfo{
+		{ID: "hbd-hive", Asset0: "HBD", Asset1: "HIVE", Reserve0: 1000000, Reserve1: 4000000, Fee: 30},
+	}}
+	clock := newTestClock()
+	tracker := NewPriceTracker(querier, 30*time.Minute)
+	tracker.now = clock.now
+	return tracker, querier, clock
+}
+
+func TestPriceTrackerTWAP(t *testing.T) {
+	tracker, querier, clock := newTestPriceTracker()
+
+	_, ok := tracker.TWAP("hbd-hive", "HBD")
+	assert.False(t, ok, "unseen pools have no TWAP")
+
+	_, err := tracker.GetPoolsByAsset("HBD")
+	require.NoError(t, err)
+	spot, ok := tracker.SpotPrice("hbd-hive", "HBD")
+	require.True(t, ok)
+	assert.Equal(t, 4.0, spot)
+
+	// Until half the window is covered there is no average
+	clock.advance(10 * time.Minute)
+	_, ok = tracker.TWAP("hbd-hive", "HBD")
+	assert.False(t, ok)
+
+	clock.advance(10 * time.Minute)
+	twap, ok := tracker.TWAP("hbd-hive", "HBD")
+	require.True(t, ok)
+	assert.Equal(t, 4.0, twap)
+
+	// 20 minutes at 4 HIVE per HBD, then 10 minutes at 2
+	setReserves(querier, "hbd-hive", 2000000, 4000000)
+	_, err = tracker.GetPoolByID("hbd-hive")
+	require.NoError(t, err)
+	clock.advance(10 * time.Minute)
+
+	twap, ok = tracker.TWAP("hbd-hive", "HBD")
+	require.True(t, ok)
+	assert.InDelta(t, (4.0*20+2.0*10)/30, twap, 1e-9)
+
+	// Prices are oriented to the input asset
+	inverse, ok := tracker.TWAP("hbd-hive", "HIVE")
+	require.True(t, ok)
+	assert.InDelta(t, (0.25*20+0.5*10)/30, inverse, 1e-9)
+	spot, ok = tracker.SpotPrice("hbd-hive", "HIVE")
+	require.True(t, ok)
+	assert.Equal(t, 0.5, spot)
+
+	// Once the first price leaves the window, only the second remains
+	clock.advance(30 * time.Minute)
+	twap, ok = tracker.TWAP("hbd-hive", "HBD")
+	require.True(t, ok)
+	assert.Equal(t, 2.0, twap)
+
+	_, ok = tracker.TWAP("hbd-hive", "BTC")
+	assert.False(t, ok)
+}
+
+func TestPriceTrackerSamples(t *testing.T) {
+	tracker, querier, clock := newTestPriceTracker()
+
+	// Reads of unchanged reserves add no samples
+	for i := 0; i < 5; i++ {
+		_, err := tracker.GetPoolByID("hbd-hive")
+		require.NoError(t, err)
+		clock.advance(time.Minute)
+	}
+	assert.Len(t, tracker.pools["hbd-hive"].samples, 1)
+
+	// Samples older than the window are dropped, except the one whose price
+	// still held at the window's start
+	for i := uint64(1); i <= 4; i++ {
+		setReserves(querier, "hbd-hive", 1000000, 4000000+i)
+		_, err := tracker.GetPoolByID("hbd-hive")
+		require.NoError(t, err)
+		clock.advance(20 * time.Minute)
+	}
+	samples := tracker.pools["hbd-hive"].samples
+	require.Len(t, samples, 3)
+	assert.Equal(t, 4000002.0/1000000, samples[0].price0)
+	assert.Equal(t, 4000004.0/1000000, samples[2].price0)
+
+	// Empty pools have no price
+	setReserves(querier, "hbd-hive", 0, 4000000)
+	_, err := tracker.GetPoolByID("hbd-hive")
+	require.NoError(t, err)
+	assert.Len(t, tracker.pools["hbd-hive"].samples, 3)
+}
+
+func TestPriceTrackerRun(t *testing.T) {
+	querier := &countingPoolQuerier{PoolQuerier: &mockPoolQuerier{pools: []IndexerPoolInfo{
+		{ID: "hbd-hive", Asset0: "HBD", Asset1: "HIVE", Reserve0: 1000000, Reserve1: 4000000, Fee: 30},
+	}}}
+	tracker := NewPriceTracker(querier, time.Hour)
+	_, err := tracker.GetPoolByID("hbd-hive")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		tracker.Run(ctx, time.Millisecond)
+		close(done)
+	}()
+
+	// Pools seen once keep being sampled
+	require.Eventually(t, func() bool { return querier.reads.Load() > 3 }, time.Second, time.Millisecond)
+	cancel()
+	<-done
+}
+
+func TestPriceTrackerInvalidatesCache(t *testing.T) {
+	querier := &mockPoolQuerier{pools: []IndexerPoolInfo{
+		{ID: "hbd-hive", Asset0: "HBD", Asset1: "HIVE", Reserve0: 1000000, Reserve1: 4000000, Fee: 30},
+	}}
+	cache := NewCachingPoolQuerier(querier, time.Hour, 50)
+	tracker := NewPriceTracker(cache, time.Hour)
+
+	_, err := tracker.GetPoolsByAsset("HBD")
+	require.NoError(t, err)
+	tracker.InvalidatePools("hbd-hive")
+	_, err = tracker.GetPoolsByAsset("HBD")
+	require.NoError(t, err)
+	assert.Equal(t, 2, querier.calls, "invalidation should reach the cache")
+}
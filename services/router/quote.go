@@ -0,0 +1,355 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+// hubAsset is the preferred intermediate asset for multi-hop swaps, matching
+// the dex-router contract's two-hop routing
+const hubAsset = "HBD"
+
+// PoolQuerier provides pool state for routing
+type PoolQuerier interface {
+	GetPoolByID(poolID string) (*IndexerPoolInfo, error)
+	GetPoolsByAsset(asset string) ([]IndexerPoolInfo, error)
+}
+
+// HopQuote describes one pool traversed by a quoted route
+type HopQuote struct {
+	PoolID    string `json:"poolId"`
+	AssetIn   string `json:"assetIn"`
+	AssetOut  string `json:"assetOut"`
+	AmountIn  int64  `json:"amountIn"`
+	AmountOut int64  `json:"amountOut"`
+	FeeBps    uint64 `json:"feeBps"`
+	Fee       int64  `json:"fee"` // Charged in AssetIn
+
+	// Pool reserves the hop was quoted from, so a quote can be checked
+	// against current reserves before it is executed
+	ReserveIn  uint64 `json:"reserveIn"`
+	ReserveOut uint64 `json:"reserveOut"`
+}
+
+// Quote is the expected outcome of a swap, computed from indexed reserves
+type Quote struct {
+	AssetIn     string     `json:"assetIn"`
+	AssetOut    string     `json:"assetOut"`
+	AmountIn    int64      `json:"amountIn"`
+	AmountOut   int64      `json:"amountOut"`
+	Route       []string   `json:"route"` // Assets traversed, e.g. BTC -> HBD -> HIVE
+	Hops        []HopQuote `json:"hops"`
+	PriceImpact float64    `json:"priceImpact"`          // % of output lost to pool depth, excluding fees
+	Legs        []*Quote   `json:"legs,omitempty"`       // Set when the input is split across routes
+	Candidates  []*Quote   `json:"candidates,omitempty"` // Routes compared, best first, when requested
+
+	// Estimated cost of broadcasting the route, and AmountOut less that
+	// cost, when route costs are configured
+	Cost         *RouteCost `json:"cost,omitempty"`
+	NetAmountOut int64      `json:"netAmountOut,omitempty"`
+
+	// How the route was chosen, when quoted under WithRouteTrace
+	Trace *RouteTrace `json:"trace,omitempty"`
+}
+
+// poolInvalidator is implemented by pool queriers that cache pool state
+type poolInvalidator interface {
+	InvalidatePools(poolIDs ...string)
+}
+
+// SetPoolQuerier configures where the router reads pool reserves from
+func (s *Service) SetPoolQuerier(querier PoolQuerier) {
+	s.poolQuerier = querier
+}
+
+// Quote computes the best route and expected output for a swap from indexed
+// reserves. It never submits a transaction. The quote may split the input
+// across several routes in Legs; such a quote is for display only and is
+// refused if passed back as SwapParams.Quote.
+func (s *Service) Quote(ctx context.Context, params SwapParams) (*Quote, error) {
+	if err := s.checkAssets(params.AssetIn, params.AssetOut); err != nil {
+		return nil, err
+	}
+	return s.quoteRoutes(ctx, params, 0, true)
+}
+
+// QuoteCandidates quotes a swap like Quote, and also reports up to k of the
+// single routes it compared, best first, in Quote.Candidates. Passing one of
+// their routes back as SwapParams.Route overrides the router's choice.
+func (s *Service) QuoteCandidates(ctx context.Context, params SwapParams, k int) (*Quote, error) {
+	if err := s.checkAssets(params.AssetIn, params.AssetOut); err != nil {
+		return nil, err
+	}
+	return s.quoteRoutes(ctx, params, k, true)
+}
+
+// quote quotes a swap along the single best route, as it would be executed
+func (s *Service) quote(ctx context.Context, params SwapParams, candidates int) (*Quote, error) {
+	return s.quoteRoutes(ctx, params, candidates, false)
+}
+
+// quoteRoutes quotes a swap, splitting it across routes when split is set
+// and that returns more. A split quote is executed as one route's swap, so
+// only quote-only requests may split.
+func (s *Service) quoteRoutes(ctx context.Context, params SwapParams, candidates int, split bool) (*Quote, error) {
+	if params.AssetIn == params.AssetOut {
+		return nil, fmt.Errorf("cannot swap asset to itself")
+	}
+	if params.AmountIn <= 0 {
+		return nil, fmt.Errorf("amount in must be greater than 0")
+	}
+	if s.poolQuerier == nil {
+		return nil, fmt.Errorf("pool querier not configured")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	defer s.metrics.observeRoute(time.Now())
+
+	trace := routeTraceFrom(ctx)
+	exclude := make(map[string]bool, len(params.ExcludePools))
+	for _, poolID := range params.ExcludePools {
+		exclude[poolID] = true
+	}
+	routes, err := s.candidateRoutes(params.AssetIn, params.AssetOut, exclude, trace)
+	if err != nil {
+		return nil, err
+	}
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("no route found from %s to %s", params.AssetIn, params.AssetOut)
+	}
+
+	var best *Quote
+	var quotes []*Quote
+	var tooShallow bool // A route was skipped for taking too much of a pool
+	quoted := make(map[*Quote][]IndexerPoolInfo)
+	costs := s.routeCosts(params.AssetOut)
+	for _, route := range routes {
+		quote, ok := quoteRoute(params.AssetIn, params.AmountIn, route)
+		if !ok {
+			trace.route(params.AssetIn, route, nil, "a pool has no liquidity to swap through")
+			continue
+		}
+		if len(params.Route) > 0 && !sameRoute(quote.Route, params.Route) {
+			trace.route(params.AssetIn, route, quote, "not the requested route")
+			continue
+		}
+		if !s.routing.withinReserveUsage(quote, route) {
+			tooShallow = true
+			trace.route(params.AssetIn, route, quote, fmt.Sprintf("a hop adds more than %d bps of its pool's input reserve", s.routing.MaxReserveUsageBps))
+			continue
+		}
+		costs.apply(quote)
+		trace.route(params.AssetIn, route, quote, "")
+		quotes = append(quotes, quote)
+		quoted[quote] = route
+		if best == nil || quote.netOut() > best.netOut() {
+			best = quote
+		}
+	}
+	if best == nil {
+		if len(params.Route) > 0 {
+			return nil, fmt.Errorf("route %s is not available", strings.Join(params.Route, " -> "))
+		}
+		if tooShallow {
+			return nil, fmt.Errorf("insufficient liquidity to route %s to %s: the trade would take more than %d bps of a pool's reserves", params.AssetIn, params.AssetOut, s.routing.MaxReserveUsageBps)
+		}
+		return nil, fmt.Errorf("insufficient liquidity to route %s to %s", params.AssetIn, params.AssetOut)
+	}
+
+	var ranked []*Quote
+	if candidates > 0 {
+		sort.SliceStable(quotes, func(i, j int) bool { return quotes[i].netOut() > quotes[j].netOut() })
+		for _, quote := range quotes {
+			if len(ranked) == candidates {
+				break
+			}
+			candidate := *quote
+			ranked = append(ranked, &candidate)
+		}
+	}
+
+	// A chosen route is taken as is, never split
+	if split && len(params.Route) == 0 {
+		single := best
+		best = s.splitQuote(best, quotes, quoted, costs)
+		if best != single {
+			trace.note("split across %d routes for %d more output than the best single route", len(best.Legs), best.netOut()-single.netOut())
+		}
+	}
+	if len(ranked) > 0 || trace != nil {
+		quote := *best
+		quote.Candidates = ranked
+		quote.Trace = trace
+		best = &quote
+	}
+	trace.choose(best)
+	return best, nil
+}
+
+// sameRoute reports whether two routes traverse the same assets in order
+func sameRoute(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// quoteRoute simulates a swap along pools. The bool is false if any pool
+// lacks the liquidity to produce output, or is concentrated and so cannot be
+// priced from its reserves.
+func quoteRoute(assetIn string, amountIn int64, pools []IndexerPoolInfo) (*Quote, bool) {
+	quote := &Quote{
+		AssetIn:  assetIn,
+		AmountIn: amountIn,
+		Route:    []string{assetIn},
+	}
+
+	asset := assetIn
+	amount := amountIn
+	idealOut := new(big.Float).SetInt64(amountIn) // Output at spot prices after fees
+
+	for _, pool := range pools {
+		reserveIn, reserveOut, assetOut := orientPool(pool, asset)
+		if reserveIn == 0 || reserveOut == 0 || pool.concentrated() {
+			return nil, false
+		}
+
+		out, fee := constantProductOut(uint64(amount), reserveIn, reserveOut, pool.Fee)
+		if out == 0 {
+			return nil, false
+		}
+
+		quote.Hops = append(quote.Hops, HopQuote{
+			PoolID:     pool.ID,
+			AssetIn:    asset,
+			AssetOut:   assetOut,
+			AmountIn:   amount,
+			AmountOut:  int64(out),
+			FeeBps:     pool.Fee,
+			Fee:        int64(fee),
+			ReserveIn:  reserveIn,
+			ReserveOut: reserveOut,
+		})
+		quote.Route = append(quote.Route, assetOut)
+
+		spot := new(big.Float).Quo(new(big.Float).SetUint64(reserveOut), new(big.Float).SetUint64(reserveIn))
+		idealOut.Mul(idealOut, spot)
+		idealOut.Mul(idealOut, big.NewFloat(float64(10000-pool.Fee)/10000))
+
+		asset = assetOut
+		amount = int64(out)
+	}
+
+	quote.AssetOut = asset
+	quote.AmountOut = amount
+
+	if ideal, _ := idealOut.Float64(); ideal > 0 {
+		quote.PriceImpact = (ideal - float64(amount)) / ideal * 100
+	}
+	return quote, true
+}
+
+// constantProductOut returns the x*y=k output for amountIn after deducting
+// feeBps from the input, along with the fee charged
+func constantProductOut(amountIn, reserveIn, reserveOut, feeBps uint64) (uint64, uint64) {
+	if feeBps >= 10000 {
+		return 0, amountIn
+	}
+
+	in := new(big.Int).SetUint64(amountIn)
+	inAfterFee := new(big.Int).Mul(in, big.NewInt(int64(10000-feeBps)))
+	inAfterFee.Quo(inAfterFee, big.NewInt(10000))
+	fee := new(big.Int).Sub(in, inAfterFee)
+
+	numerator := new(big.Int).Mul(inAfterFee, new(big.Int).SetUint64(reserveOut))
+	denominator := new(big.Int).Add(new(big.Int).SetUint64(reserveIn), inAfterFee)
+	out := numerator.Quo(numerator, denominator)
+
+	return out.Uint64(), fee.Uint64()
+}
+
+// orientPool returns the pool's reserves with assetIn first, and the asset
+// received in exchange
+func orientPool(pool IndexerPoolInfo, assetIn string) (uint64, uint64, string) {
+	if pool.Asset0 == assetIn {
+		return pool.Reserve0, pool.Reserve1, pool.Asset1
+	}
+	return pool.Reserve1, pool.Reserve0, pool.Asset0
+}
+
+// poolHasAsset reports whether asset is one side of pool
+func poolHasAsset(pool IndexerPoolInfo, asset string) bool {
+	return pool.Asset0 == asset || pool.Asset1 == asset
+}
+
+// invalidateRoute drops cached state for the pools a submitted swap will move
+func (s *Service) invalidateRoute(quote *Quote) {
+	invalidator, ok := s.poolQuerier.(poolInvalidator)
+	if !ok {
+		return
+	}
+	poolIDs := make([]string, len(quote.Hops))
+	for i, hop := range quote.Hops {
+		poolIDs[i] = hop.PoolID
+	}
+	invalidator.InvalidatePools(poolIDs...)
+}
+
+// netOut returns the output routes are compared by: net of the route's
+// cost if it has one
+func (q *Quote) netOut() int64 {
+	if q.Cost != nil {
+		return q.NetAmountOut
+	}
+	return q.AmountOut
+}
+
+// EffectivePrice returns the output received per unit of input, after fees
+func (q *Quote) EffectivePrice() float64 {
+	if q.AmountIn == 0 {
+		return 0
+	}
+	return float64(q.AmountOut) / float64(q.AmountIn)
+}
+
+// pools returns the pools of the quoted route in order. Split quotes are
+// never executed, so they have none.
+func (q *Quote) pools() []string {
+	if len(q.Legs) > 0 || len(q.Hops) == 0 {
+		return nil
+	}
+	pools := make([]string, len(q.Hops))
+	for i, hop := range q.Hops {
+		pools[i] = hop.PoolID
+	}
+	return pools
+}
+
+// MinimumReceived returns the quoted output less a slippage tolerance
+func (q *Quote) MinimumReceived(slippageBps uint64) int64 {
+	if slippageBps >= 10000 {
+		return 0
+	}
+	min := new(big.Int).Mul(big.NewInt(q.AmountOut), big.NewInt(int64(10000-slippageBps)))
+	return min.Quo(min, big.NewInt(10000)).Int64()
+}
+
+// applyTo fills a SwapResult's trade preview from the quote
+func (q *Quote) applyTo(result *SwapResult, slippageBps uint64) {
+	result.EstimatedAmountOut = q.AmountOut
+	result.PriceImpact = q.PriceImpact
+	result.EffectivePrice = q.EffectivePrice()
+	result.MinimumReceived = q.MinimumReceived(slippageBps)
+	result.HopFees = q.Hops
+	result.Route = q.Route
+}
@@ -0,0 +1,90 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	defaultQuoteStreamInterval = time.Second
+	minQuoteStreamInterval     = 250 * time.Millisecond
+)
+
+// QuoteUpdate is sent to a quote subscriber whenever the quote changes
+type QuoteUpdate struct {
+	Quote *Quote    `json:"quote,omitempty"`
+	Error string    `json:"error,omitempty"` // Set instead of Quote while the swap cannot be quoted
+	At    time.Time `json:"at"`
+}
+
+// WatchQuote quotes a swap every interval until ctx is done. The first
+// quote is always sent, and after that an update is only sent when the
+// quote, or the reason it cannot be made, changes. The channel is closed
+// when ctx is done.
+func (s *Service) WatchQuote(ctx context.Context, params SwapParams, interval time.Duration) (<-chan QuoteUpdate, error) {
+	if interval == 0 {
+		interval = defaultQuoteStreamInterval
+	}
+	if interval < minQuoteStreamInterval {
+		return nil, fmt.Errorf("quote interval must be at least %s", minQuoteStreamInterval)
+	}
+	if params.AssetIn == params.AssetOut {
+		return nil, fmt.Errorf("cannot swap asset to itself")
+	}
+	if params.AmountIn <= 0 {
+		return nil, fmt.Errorf("amount in must be greater than 0")
+	}
+	if s.poolQuerier == nil {
+		return nil, fmt.Errorf("pool querier not configured")
+	}
+
+	updates := make(chan QuoteUpdate)
+	go func() {
+		defer close(updates)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		last := ""
+		for {
+			update := QuoteUpdate{At: time.Now().UTC()}
+			quote, err := s.Quote(ctx, params)
+			if err != nil {
+				update.Error = err.Error()
+			} else {
+				update.Quote = quote
+			}
+
+			if key := quoteStreamKey(update); key != last {
+				select {
+				case updates <- update:
+					last = key
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return updates, nil
+}
+
+// quoteStreamKey identifies what a subscriber sees of an update, so that
+// unchanged quotes are not sent again
+func quoteStreamKey(update QuoteUpdate) string {
+	if update.Quote == nil {
+		return "error:" + update.Error
+	}
+	q := update.Quote
+	key := fmt.Sprintf("%d/%d/%s", q.AmountOut, q.NetAmountOut, strings.Join(q.Route, ">"))
+	for _, leg := range q.Legs {
+		key += fmt.Sprintf("|%d:%d/%s", leg.AmountIn, leg.AmountOut, strings.Join(leg.Route, ">"))
+	}
+	return key
+}
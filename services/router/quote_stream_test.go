@@ -0,0 +1,140 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lockedPoolQuerier lets a test change pools while they are being quoted
+type lockedPoolQuerier struct {
+	mu   sync.Mutex
+	pool mockPoolQuerier
+}
+
+func (l *lockedPoolQuerier) GetPoolByID(poolID string) (*IndexerPoolInfo, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.pool.GetPoolByID(poolID)
+}
+
+func (l *lockedPoolQuerier) GetPoolsByAsset(asset string) ([]IndexerPoolInfo, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.pool.GetPoolsByAsset(asset)
+}
+
+func (l *lockedPoolQuerier) setReserves(poolID string, reserve0, reserve1 uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range l.pool.pools {
+		if l.pool.pools[i].ID == poolID {
+			l.pool.pools[i].Reserve0, l.pool.pools[i].Reserve1 = reserve0, reserve1
+		}
+	}
+}
+
+func newQuoteStreamTestService() (*Service, *lockedPoolQuerier) {
+	pools := &lockedPoolQuerier{pool: mockPoolQuerier{pools: []IndexerPoolInfo{
+		{ID: "hbd-hive", Asset0: "HBD", Asset1: "HIVE", Reserve0: 1000000, Reserve1: 4000000, Fee: 30},
+	}}}
+	svc := NewService(VSCConfig{}, &mockDEXExecutor{})
+	svc.SetPoolQuerier(pools)
+	return svc, pools
+}
+
+func nextQuoteUpdate(t *testing.T, updates <-chan QuoteUpdate) QuoteUpdate {
+	t.Helper()
+	select {
+	case update, ok := <-updates:
+		require.True(t, ok, "updates closed")
+		return update
+	case <-time.After(5 * time.Second):
+		t.Fatal("no quote update")
+		return QuoteUpdate{}
+	}
+}
+
+func TestWatchQuoteSendsChanges(t *testing.T) {
+	svc, pools := newQuoteStreamTestService()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	params := SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 10000}
+	updates, err := svc.WatchQuote(ctx, params, minQuoteStreamInterval)
+	require.NoError(t, err)
+
+	first := nextQuoteUpdate(t, updates)
+	require.NotNil(t, first.Quote)
+	assert.Empty(t, first.Error)
+
+	// Unchanged reserves are not sent again
+	select {
+	case update := <-updates:
+		t.Fatalf("unexpected update %+v", update)
+	case <-time.After(3 * minQuoteStreamInterval):
+	}
+
+	pools.setReserves("hbd-hive", 1000000, 3000000)
+	second := nextQuoteUpdate(t, updates)
+	require.NotNil(t, second.Quote)
+	assert.Less(t, second.Quote.AmountOut, first.Quote.AmountOut)
+
+	// A pool that can no longer be quoted is reported, not dropped
+	pools.setReserves("hbd-hive", 0, 0)
+	third := nextQuoteUpdate(t, updates)
+	assert.Nil(t, third.Quote)
+	assert.NotEmpty(t, third.Error)
+
+	cancel()
+	for range updates {
+	}
+}
+
+func TestWatchQuoteValidation(t *testing.T) {
+	svc, _ := newQuoteStreamTestService()
+	ctx := context.Background()
+
+	_, err := svc.WatchQuote(ctx, SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1}, time.Millisecond)
+	assert.ErrorContains(t, err, "at least")
+	_, err = svc.WatchQuote(ctx, SwapParams{AssetIn: "HBD", AssetOut: "HBD", AmountIn: 1}, 0)
+	assert.Error(t, err)
+	_, err = svc.WatchQuote(ctx, SwapParams{AssetIn: "HBD", AssetOut: "HIVE"}, 0)
+	assert.Error(t, err)
+}
+
+func TestQuoteStreamEndpoint(t *testing.T) {
+	svc, pools := newQuoteStreamTestService()
+	server := NewServer(svc, "0")
+
+	w := serveTestRequest(server, "GET", "/api/v1/quote/ws?fromAsset=HBD&toAsset=HIVE&amount=x", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = serveTestRequest(server, "GET", "/api/v1/quote/ws?fromAsset=HBD&toAsset=HIVE&amount=100&interval=1ms", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	ts := httptest.NewServer(server.http.Handler)
+	defer ts.Close()
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/api/v1/quote/ws?fromAsset=HBD&toAsset=HIVE&amount=10000&interval=250ms"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var first, second QuoteUpdate
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	require.NoError(t, conn.ReadJSON(&first))
+	require.NotNil(t, first.Quote)
+	assert.Equal(t, []string{"HBD", "HIVE"}, first.Quote.Route)
+
+	pools.setReserves("hbd-hive", 2000000, 4000000)
+	require.NoError(t, conn.ReadJSON(&second))
+	require.NotNil(t, second.Quote)
+	assert.Less(t, second.Quote.AmountOut, first.Quote.AmountOut)
+}
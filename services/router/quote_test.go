@@ -0,0 +1,269 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockPoolQuerier implements PoolQuerier over a fixed set of pools
+type mockPoolQuerier struct {
+	pools []IndexerPoolInfo
+	calls int
+}
+
+func (m *mockPoolQuerier) GetPoolByID(poolID string) (*IndexerPoolInfo, error) {
+	m.calls++
+	for _, pool := range m.pools {
+		if pool.ID == poolID {
+			p := pool
+			return &p, nil
+		}
+	}
+	return nil, fmt.Errorf("pool not found: %s", poolID)
+}
+
+func (m *mockPoolQuerier) GetPoolsByAsset(asset string) ([]IndexerPoolInfo, error) {
+	m.calls++
+	var matching []IndexerPoolInfo
+	for _, pool := range m.pools {
+		if pool.Asset0 == asset || pool.Asset1 == asset {
+			matching = append(matching, pool)
+		}
+	}
+	return matching, nil
+}
+
+func newQuoteTestService() (*Service, *mockDEXExecutor) {
+	executor := &mockDEXExecutor{}
+	svc := NewService(VSCConfig{}, executor)
+	svc.SetPoolQuerier(&mockPoolQuerier{pools: []IndexerPoolInfo{
+		{ID: "1", Asset0: "HBD", Asset1: "HIVE", Reserve0: 1000000, Reserve1: 4000000, Fee: 30},
+		{ID: "2", Asset0: "BTC", Asset1: "HBD", Reserve0: 100000000, Reserve1: 50000000, Fee: 8},
+	}})
+	return svc, executor
+}
+
+func TestQuote_Direct(t *testing.T) {
+	svc, executor := newQuoteTestService()
+
+	quote, err := svc.Quote(context.Background(), SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 10000})
+	require.NoError(t, err)
+
+	// 9970 after fee: 4000000 * 9970 / 1009970 = 39486
+	assert.Equal(t, int64(39486), quote.AmountOut)
+	assert.Equal(t, []string{"HBD", "HIVE"}, quote.Route)
+	require.Len(t, quote.Hops, 1)
+	assert.Equal(t, "1", quote.Hops[0].PoolID)
+	assert.Equal(t, int64(30), quote.Hops[0].Fee)
+	assert.InDelta(t, 0.987, quote.PriceImpact, 0.01)
+
+	// Quoting never executes anything
+	assert.Empty(t, executor.executedOperations)
+}
+
+func TestQuote_TwoHopViaHBD(t *testing.T) {
+	svc, _ := newQuoteTestService()
+
+	quote, err := svc.Quote(context.Background(), SwapParams{AssetIn: "BTC", AssetOut: "HIVE", AmountIn: 100000})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"BTC", "HBD", "HIVE"}, quote.Route)
+	require.Len(t, quote.Hops, 2)
+	assert.Equal(t, "2", quote.Hops[0].PoolID)
+	assert.Equal(t, "1", quote.Hops[1].PoolID)
+	assert.Equal(t, quote.Hops[0].AmountOut, quote.Hops[1].AmountIn)
+	assert.Equal(t, quote.Hops[1].AmountOut, quote.AmountOut)
+	assert.Greater(t, quote.AmountOut, int64(0))
+}
+
+func TestQuote_PicksBestPool(t *testing.T) {
+	svc := NewService(VSCConfig{}, &mockDEXExecutor{})
+	svc.SetPoolQuerier(&mockPoolQuerier{pools: []IndexerPoolInfo{
+		{ID: "shallow", Asset0: "HBD", Asset1: "HIVE", Reserve0: 10000, Reserve1: 40000, Fee: 30},
+		{ID: "deep", Asset0: "HIVE", Asset1: "HBD", Reserve0: 4000000, Reserve1: 1000000, Fee: 30},
+	}})
+
+	quote, err := svc.Quote(context.Background(), SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 10000})
+	require.NoError(t, err)
+	assert.Equal(t, "deep", quote.Hops[0].PoolID)
+}
+
+func TestQuote_Errors(t *testing.T) {
+	svc, _ := newQuoteTestService()
+	ctx := context.Background()
+
+	_, err := svc.Quote(ctx, SwapParams{AssetIn: "HBD", AssetOut: "HBD", AmountIn: 1})
+	assert.Error(t, err)
+
+	_, err = svc.Quote(ctx, SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 0})
+	assert.Error(t, err)
+
+	_, err = svc.Quote(ctx, SwapParams{AssetIn: "HBD", AssetOut: "ETH", AmountIn: 100})
+	assert.ErrorContains(t, err, "no route found")
+
+	noQuerier := NewService(VSCConfig{}, &mockDEXExecutor{})
+	_, err = noQuerier.Quote(ctx, SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 100})
+	assert.ErrorContains(t, err, "pool querier not configured")
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = svc.Quote(cancelled, SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 100})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestServer_HandleQuote(t *testing.T) {
+	svc, executor := newQuoteTestService()
+	server := NewServer(svc, "8080")
+
+	body := `{"fromAsset":"HBD","toAsset":"HIVE","amount":10000}`
+	req := httptest.NewRequest("POST", "/api/v1/quote", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var quote Quote
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &quote))
+	assert.Equal(t, int64(39486), quote.AmountOut)
+	assert.Empty(t, executor.executedOperations)
+
+	req = httptest.NewRequest("POST", "/api/v1/quote", strings.NewReader(`{"fromAsset":"HBD","toAsset":"ETH","amount":1}`))
+	w = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestQuote_MinimumReceived(t *testing.T) {
+	quote := &Quote{AmountIn: 10000, AmountOut: 39486}
+
+	assert.Equal(t, int64(39288), quote.MinimumReceived(50))
+	assert.Equal(t, int64(39486), quote.MinimumReceived(0))
+	assert.Equal(t, int64(0), quote.MinimumReceived(10000))
+	assert.InDelta(t, 3.9486, quote.EffectivePrice(), 1e-9)
+}
+
+func TestExecuteSwap_TradePreview(t *testing.T) {
+	svc, executor := newQuoteTestService()
+
+	result, err := svc.ExecuteSwap(context.Background(), SwapParams{
+		Sender:      "test-user",
+		AssetIn:     "BTC",
+		AssetOut:    "HIVE",
+		AmountIn:    100000,
+		MaxSlippage: 100,
+	})
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	require.Len(t, executor.executedOperations, 1)
+
+	assert.Equal(t, []string{"BTC", "HBD", "HIVE"}, result.Route)
+	require.Len(t, result.HopFees, 2)
+	assert.Equal(t, int64(80), result.HopFees[0].Fee) // 8 bps of 100000 BTC
+	assert.Greater(t, result.EstimatedAmountOut, int64(0))
+	assert.Equal(t, result.EstimatedAmountOut*9900/10000, result.MinimumReceived)
+	assert.InDelta(t, float64(result.EstimatedAmountOut)/100000, result.EffectivePrice, 1e-9)
+	assert.Greater(t, result.PriceImpact, 0.0)
+
+	// The contract is told the quoted pools, and the input they were quoted for
+	assert.Contains(t, executor.executedOperations[0], `"amount_in":100000`)
+	assert.Contains(t, executor.executedOperations[0], `"route":["2","1"]`)
+
+	// Direct swaps are pinned to their quoted pool too
+	_, err = svc.ExecuteSwap(context.Background(), SwapParams{Sender: "test-user", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000, MaxSlippage: 100})
+	require.NoError(t, err)
+	assert.Contains(t, executor.executedOperations[1], `"amount_in":1000`)
+	assert.Contains(t, executor.executedOperations[1], `"route":["1"]`)
+}
+
+func TestExecuteSwap_NoPreviewWithoutQuerier(t *testing.T) {
+	svc := NewService(VSCConfig{}, &mockDEXExecutor{})
+
+	result, err := svc.ExecuteSwap(context.Background(), SwapParams{Sender: "test-user", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Nil(t, result.Route)
+	assert.Nil(t, result.Pools)
+	assert.Zero(t, result.AmountOut)
+	assert.Zero(t, result.EstimatedAmountOut)
+	assert.Nil(t, result.HopFees)
+}
+
+func TestQuoteCandidates(t *testing.T) {
+	svc := newRoutingTestService(t, DefaultRoutingConfig())
+	params := SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 10000}
+
+	quote, err := svc.QuoteCandidates(context.Background(), params, 5)
+	require.NoError(t, err)
+	require.Len(t, quote.Candidates, 2)
+	assert.Equal(t, quote.Route, quote.Candidates[0].Route, "the winner ranks first")
+	assert.Equal(t, quote.AmountOut, quote.Candidates[0].AmountOut)
+	assert.Equal(t, []string{"HBD", "SPK", "HIVE"}, quote.Candidates[1].Route)
+	assert.Less(t, quote.Candidates[1].AmountOut, quote.Candidates[0].AmountOut)
+	assert.Greater(t, quote.Candidates[1].PriceImpact, 0.0)
+
+	quote, err = svc.QuoteCandidates(context.Background(), params, 1)
+	require.NoError(t, err)
+	assert.Len(t, quote.Candidates, 1)
+
+	// Plain quotes leave the candidates out
+	quote, err = svc.Quote(context.Background(), params)
+	require.NoError(t, err)
+	assert.Empty(t, quote.Candidates)
+}
+
+func TestQuote_RouteOverride(t *testing.T) {
+	svc := newRoutingTestService(t, DefaultRoutingConfig())
+	params := SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 10000, Route: []string{"HBD", "SPK", "HIVE"}}
+
+	quote, err := svc.Quote(context.Background(), params)
+	require.NoError(t, err)
+	assert.Equal(t, params.Route, quote.Route)
+	require.Len(t, quote.Hops, 2)
+	assert.Equal(t, "hbd-spk", quote.Hops[0].PoolID)
+
+	params.Route = []string{"HBD", "BTC", "HIVE"}
+	_, err = svc.Quote(context.Background(), params)
+	assert.ErrorContains(t, err, "route HBD -> BTC -> HIVE is not available")
+}
+
+func TestExecuteSwap_RouteOverride(t *testing.T) {
+	svc := newRoutingTestService(t, DefaultRoutingConfig())
+	executor := svc.dexExecutor.(*mockDEXExecutor)
+	params := SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 10000, Route: []string{"HBD", "SPK", "HIVE"}}
+
+	result, err := svc.ExecuteSwap(context.Background(), params)
+	require.NoError(t, err)
+	require.True(t, result.Success, result.ErrorMessage)
+	assert.Equal(t, params.Route, result.Route)
+	require.Len(t, executor.executedOperations, 1)
+	assert.Contains(t, executor.executedOperations[0], `"metadata":{"route":"HBD,SPK,HIVE"}`)
+
+	// An unavailable route is refused rather than broadcast
+	params.Route = []string{"HBD", "ETH", "HIVE"}
+	result, err = svc.ExecuteSwap(context.Background(), params)
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.ErrorMessage, "invalid route")
+	assert.Len(t, executor.executedOperations, 1)
+}
+
+func TestServer_HandleQuoteCandidates(t *testing.T) {
+	svc := newRoutingTestService(t, DefaultRoutingConfig())
+	server := NewServer(svc, "0")
+
+	body := `{"fromAsset":"HBD","toAsset":"HIVE","amount":10000,"candidates":3}`
+	w := httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/quote", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var quote Quote
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &quote))
+	assert.Len(t, quote.Candidates, 2)
+}
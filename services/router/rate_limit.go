@@ -0,0 +1,157 @@
+package router
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// rateLimitSweepInterval is how often idle callers' allowances are dropped
+const rateLimitSweepInterval = time.Minute
+
+// callKind is a class of call that is rate limited separately
+type callKind string
+
+const (
+	callQuote     callKind = "quote"     // Quotes and quote streams
+	callExecution callKind = "execution" // Anything that may broadcast: swaps, deposits, withdrawals and orders
+)
+
+// RateLimitConfig sets how often each caller may call the router, so that
+// one misbehaving integration cannot starve others of quotes or of the
+// shared executor. Calls count against the referrer owning the caller's API
+// key, or without a registered key the client's address.
+type RateLimitConfig struct {
+	QuotesPerSecond float64 // Zero leaves quotes unlimited
+	QuoteBurst      int     // Quotes allowed at once; zero allows one second's worth
+
+	ExecutionsPerSecond float64 // Zero leaves executions unlimited
+	ExecutionBurst      int     // Executions allowed at once; zero allows one second's worth
+}
+
+// RateLimiter keeps a token bucket per caller and kind of call
+type RateLimiter struct {
+	limits map[callKind]rateLimit
+
+	mu      sync.Mutex
+	buckets map[bucketKey]*tokenBucket
+	swept   time.Time
+	now     func() time.Time
+}
+
+// rateLimit is the refill rate and size of one kind of call's buckets
+type rateLimit struct {
+	perSecond float64
+	burst     float64
+}
+
+// bucketKey identifies a caller's allowance for one kind of call
+type bucketKey struct {
+	kind   callKind
+	caller string
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewRateLimiter creates a rate limiter. Kinds of call with a zero rate are
+// never limited.
+func NewRateLimiter(config RateLimitConfig) (*RateLimiter, error) {
+	if config.QuotesPerSecond < 0 || config.ExecutionsPerSecond < 0 {
+		return nil, fmt.Errorf("rate limits must not be negative")
+	}
+	if config.QuoteBurst < 0 || config.ExecutionBurst < 0 {
+		return nil, fmt.Errorf("rate limit bursts must not be negative")
+	}
+
+	l := &RateLimiter{
+		limits:  make(map[callKind]rateLimit),
+		buckets: make(map[bucketKey]*tokenBucket),
+		now:     time.Now,
+	}
+	l.swept = l.now()
+	for kind, limit := range map[callKind]struct {
+		perSecond float64
+		burst     int
+	}{
+		callQuote:     {config.QuotesPerSecond, config.QuoteBurst},
+		callExecution: {config.ExecutionsPerSecond, config.ExecutionBurst},
+	} {
+		if limit.perSecond == 0 {
+			continue
+		}
+		burst := float64(limit.burst)
+		if burst == 0 {
+			burst = math.Max(1, math.Ceil(limit.perSecond))
+		}
+		l.limits[kind] = rateLimit{perSecond: limit.perSecond, burst: burst}
+	}
+	return l, nil
+}
+
+// allow takes one call of kind from caller's allowance. If none is left it
+// reports false and how long until one is.
+func (l *RateLimiter) allow(kind callKind, caller string) (bool, time.Duration) {
+	limit, ok := l.limits[kind]
+	if !ok {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.swept) >= rateLimitSweepInterval {
+		l.sweep(now)
+	}
+
+	key := bucketKey{kind: kind, caller: caller}
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: limit.burst, updated: now}
+		l.buckets[key] = bucket
+	}
+	bucket.tokens = math.Min(limit.burst, bucket.tokens+now.Sub(bucket.updated).Seconds()*limit.perSecond)
+	bucket.updated = now
+
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / limit.perSecond * float64(time.Second))
+		return false, wait
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// sweep drops buckets that have refilled, as they behave the same as new
+// ones
+func (l *RateLimiter) sweep(now time.Time) {
+	for key, bucket := range l.buckets {
+		limit := l.limits[key.kind]
+		if bucket.tokens+now.Sub(bucket.updated).Seconds()*limit.perSecond >= limit.burst {
+			delete(l.buckets, key)
+		}
+	}
+	l.swept = now
+}
+
+// rateLimitKey identifies who a call counts against: the referrer whose
+// registered API key the call carries, or else the client's address. An
+// unregistered key or a request's sender is never used, as a caller could
+// pick a fresh one for every call to get a full allowance.
+func (s *Service) rateLimitKey(apiKey, address string) string {
+	if apiKey != "" && s.referrals != nil {
+		if ref, ok := s.referrals.forAPIKey(apiKey); ok {
+			return "referrer:" + ref.ID
+		}
+	}
+	return "address:" + address
+}
+
+// SetRateLimiter limits how often each caller may quote and execute over
+// HTTP and gRPC
+func (s *Service) SetRateLimiter(limiter *RateLimiter) {
+	s.rateLimiter = limiter
+}
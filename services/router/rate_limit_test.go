@@ -0,0 +1,155 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/vsc-eco/vsc-dex-mapping/services/router/routerpb"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter, err := NewRateLimiter(RateLimitConfig{QuotesPerSecond: 2, QuoteBurst: 3})
+	require.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+	limiter.now = func() time.Time { return now }
+	limiter.swept = now
+
+	// A burst is allowed, then calls wait for the bucket to refill
+	for i := 0; i < 3; i++ {
+		ok, _ := limiter.allow(callQuote, "key:bot")
+		assert.True(t, ok, "call %d", i)
+	}
+	ok, wait := limiter.allow(callQuote, "key:bot")
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	// Other callers and kinds of call are unaffected
+	ok, _ = limiter.allow(callQuote, "key:wallet")
+	assert.True(t, ok)
+	for i := 0; i < 10; i++ {
+		ok, _ = limiter.allow(callExecution, "key:bot")
+		assert.True(t, ok, "executions are unlimited")
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	ok, _ = limiter.allow(callQuote, "key:bot")
+	assert.True(t, ok)
+	ok, _ = limiter.allow(callQuote, "key:bot")
+	assert.False(t, ok)
+
+	// Idle callers are forgotten once their buckets refill
+	now = now.Add(rateLimitSweepInterval)
+	limiter.allow(callQuote, "key:wallet")
+	assert.Len(t, limiter.buckets, 1)
+
+	_, err = NewRateLimiter(RateLimitConfig{ExecutionsPerSecond: -1})
+	assert.Error(t, err)
+}
+
+// registerTestReferrers gives svc a referral program with the "bot" and
+// "wallet" integrations registered under the keys "bot-key" and "wallet-key"
+func registerTestReferrers(t *testing.T, svc *Service) {
+	program, err := NewReferralProgram("")
+	require.NoError(t, err)
+	for _, id := range []string{"bot", "wallet"} {
+		_, err = program.Register(Referrer{ID: id, APIKey: id + "-key", Beneficiary: id + "-fees", RefBps: 25})
+		require.NoError(t, err)
+	}
+	svc.SetReferralProgram(program)
+}
+
+func TestRateLimitKey(t *testing.T) {
+	svc := NewService(VSCConfig{}, &mockDEXExecutor{})
+	assert.Equal(t, "address:10.0.0.1", svc.rateLimitKey("bot-key", "10.0.0.1"), "no referral program")
+
+	registerTestReferrers(t, svc)
+	assert.Equal(t, "referrer:bot", svc.rateLimitKey("bot-key", "10.0.0.1"))
+	assert.Equal(t, "address:10.0.0.1", svc.rateLimitKey("made-up", "10.0.0.1"))
+	assert.Equal(t, "address:10.0.0.1", svc.rateLimitKey("", "10.0.0.1"))
+}
+
+func TestRateLimitHTTP(t *testing.T) {
+	svc, executor := newQuoteTestService()
+	metrics := NewMetrics()
+	svc.SetMetrics(metrics)
+	limiter, err := NewRateLimiter(RateLimitConfig{QuotesPerSecond: 1, ExecutionsPerSecond: 1})
+	require.NoError(t, err)
+	svc.SetRateLimiter(limiter)
+	registerTestReferrers(t, svc)
+	server := NewServer(svc, "0")
+
+	request := func(path, body, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		w := httptest.NewRecorder()
+		server.http.Handler.ServeHTTP(w, req)
+		return w
+	}
+	const quote = `{"fromAsset": "HBD", "toAsset": "HIVE", "amount": 10000}`
+
+	// Quotes are limited per registered API key, falling back to the
+	// client's address
+	assert.Equal(t, http.StatusOK, request("/api/v1/quote", quote, "bot-key").Code)
+	w := request("/api/v1/quote", quote, "bot-key")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "rate limit exceeded for quote calls")
+	assert.Equal(t, http.StatusOK, request("/api/v1/quote", quote, "wallet-key").Code)
+	assert.Equal(t, http.StatusOK, request("/api/v1/quote", quote, "").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request("/api/v1/quote", quote, "").Code)
+
+	// A made-up key gets no allowance of its own
+	assert.Equal(t, http.StatusTooManyRequests, request("/api/v1/quote", quote, "random-1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request("/api/v1/quote", quote, "random-2").Code)
+
+	// Nor does naming another sender
+	swap := func(sender string) string {
+		return `{"fromAsset": "HBD", "toAsset": "HIVE", "amount": 10000, "sender": "` + sender + `"}`
+	}
+	assert.Equal(t, http.StatusOK, request("/api/v1/swap", swap("alice"), "").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request("/api/v1/swaps", swap("alice"), "").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request("/api/v1/swap", swap("bob"), "").Code)
+	assert.Len(t, executor.executedOperations, 1)
+
+	assert.Len(t, limiter.buckets, 4, "bot, wallet and the client's address for quotes and executions")
+	assert.Equal(t, 4.0, testutil.ToFloat64(metrics.rateLimited.WithLabelValues("quote")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(metrics.rateLimited.WithLabelValues("execution")))
+}
+
+func TestRateLimitGRPC(t *testing.T) {
+	svc, _ := newQuoteTestService()
+	limiter, err := NewRateLimiter(RateLimitConfig{QuotesPerSecond: 1})
+	require.NoError(t, err)
+	svc.SetRateLimiter(limiter)
+	registerTestReferrers(t, svc)
+	client := newGRPCTestClient(t, svc)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "bot-key")
+	req := &routerpb.SwapRequest{FromAsset: "HBD", ToAsset: "HIVE", Amount: 10000}
+	_, err = client.Quote(ctx, req)
+	require.NoError(t, err)
+	_, err = client.Quote(ctx, req)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	_, err = client.Quote(metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "wallet-key"), req)
+	assert.NoError(t, err)
+
+	// Unregistered keys share the client's address
+	_, err = client.Quote(metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "random-1"), req)
+	assert.NoError(t, err)
+	_, err = client.Quote(metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "random-2"), req)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
@@ -0,0 +1,308 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Receipt listing limits
+const (
+	defaultReceiptLimit = 100
+	maxReceiptLimit     = 1000
+)
+
+// ErrReceiptNotFound is returned for unknown receipt IDs
+var ErrReceiptNotFound = errors.New("receipt not found")
+
+// errReceiptsDisabled is returned while no receipt store is configured
+var errReceiptsDisabled = errors.New("receipts are not enabled")
+
+// ReceiptKind is the kind of operation a receipt records
+type ReceiptKind string
+
+// Receipt kinds
+const (
+	ReceiptSwap       ReceiptKind = "swap"
+	ReceiptDeposit    ReceiptKind = "deposit"
+	ReceiptWithdrawal ReceiptKind = "withdrawal"
+	ReceiptBatch      ReceiptKind = "batch"
+)
+
+// Receipt records an operation the router broadcast, whether or not it
+// succeeded: exactly what was sent, and what came of it
+type Receipt struct {
+	ID          string         `json:"id"`
+	Kind        ReceiptKind    `json:"kind"`
+	Account     string         `json:"account"`
+	Operations  []DexOperation `json:"operations"` // Contract calls with their payloads and intents, in broadcast order
+	TxID        string         `json:"txId,omitempty"`
+	Success     bool           `json:"success"`
+	Error       string         `json:"error,omitempty"`
+	Result      *SwapResult    `json:"result,omitempty"`      // Set for swaps, deposits and withdrawals
+	BatchResult *BatchResult   `json:"batchResult,omitempty"` // Set for batches
+	SubmittedAt time.Time      `json:"submittedAt"`
+	CompletedAt time.Time      `json:"completedAt"`
+}
+
+// ReceiptFilter selects receipts to list. Zero fields match every receipt.
+type ReceiptFilter struct {
+	Account string
+	Kind    ReceiptKind
+	Since   time.Time // Submitted at or after
+	Until   time.Time // Submitted before
+	After   string    // Receipt ID to continue listing after
+	Limit   int       // Defaults to 100, at most 1000
+}
+
+// matches reports whether a receipt passes the filter, ignoring paging
+func (f ReceiptFilter) matches(receipt *Receipt) bool {
+	switch {
+	case f.Account != "" && receipt.Account != f.Account:
+		return false
+	case f.Kind != "" && receipt.Kind != f.Kind:
+		return false
+	case !f.Since.IsZero() && receipt.SubmittedAt.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !receipt.SubmittedAt.Before(f.Until):
+		return false
+	}
+	return true
+}
+
+// ReceiptStore keeps a receipt for every operation the router broadcasts.
+// Receipts are appended to a JSON lines file, so they survive restarts.
+type ReceiptStore struct {
+	mu       sync.RWMutex
+	file     *os.File // Nil keeps receipts in memory
+	receipts []*Receipt
+	byID     map[string]int // Index into receipts
+}
+
+// NewReceiptStore creates a receipt store appending to path, loading the
+// receipts already saved there. An empty path keeps receipts in memory.
+func NewReceiptStore(path string) (*ReceiptStore, error) {
+	store := &ReceiptStore{byID: make(map[string]int)}
+	if path == "" {
+		return store, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open receipts: %w", err)
+	}
+
+	// A crash mid-append can leave a partial last line; drop it so new
+	// receipts start on a clean line
+	decoder := json.NewDecoder(file)
+	var end int64
+	for {
+		var receipt Receipt
+		err := decoder.Decode(&receipt)
+		if err == io.EOF {
+			break
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			log.Printf("Dropping partially written receipt at offset %d of %s", end, path)
+			break
+		}
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to decode receipts: %w", err)
+		}
+		store.add(&receipt)
+		end = decoder.InputOffset()
+	}
+	if err := file.Truncate(end); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to truncate receipts: %w", err)
+	}
+	if _, err := file.Seek(end, io.SeekStart); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to seek receipts: %w", err)
+	}
+
+	store.file = file
+	return store, nil
+}
+
+// Close closes the receipts file. Receipts recorded afterwards are kept in
+// memory only.
+func (rs *ReceiptStore) Close() error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if rs.file == nil {
+		return nil
+	}
+	err := rs.file.Close()
+	rs.file = nil
+	return err
+}
+
+// SetReceiptStore configures where receipts of broadcast operations are kept
+func (s *Service) SetReceiptStore(store *ReceiptStore) {
+	s.receipts = store
+}
+
+// GetReceipt returns the receipt with the given ID
+func (s *Service) GetReceipt(id string) (*Receipt, error) {
+	if s.receipts == nil {
+		return nil, errReceiptsDisabled
+	}
+
+	s.receipts.mu.RLock()
+	defer s.receipts.mu.RUnlock()
+
+	i, ok := s.receipts.byID[id]
+	if !ok {
+		return nil, ErrReceiptNotFound
+	}
+	receipt := *s.receipts.receipts[i]
+	return &receipt, nil
+}
+
+// ListReceipts returns the receipts matching a filter, oldest first. To page
+// through them, pass the last receipt's ID as the next filter's After.
+func (s *Service) ListReceipts(filter ReceiptFilter) ([]Receipt, error) {
+	if s.receipts == nil {
+		return nil, errReceiptsDisabled
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultReceiptLimit
+	}
+	if limit > maxReceiptLimit {
+		limit = maxReceiptLimit
+	}
+
+	s.receipts.mu.RLock()
+	defer s.receipts.mu.RUnlock()
+
+	start := 0
+	if filter.After != "" {
+		i, ok := s.receipts.byID[filter.After]
+		if !ok {
+			return nil, ErrReceiptNotFound
+		}
+		start = i + 1
+	}
+
+	receipts := []Receipt{}
+	for _, receipt := range s.receipts.receipts[start:] {
+		if len(receipts) == limit {
+			break
+		}
+		if filter.matches(receipt) {
+			receipts = append(receipts, *receipt)
+		}
+	}
+	return receipts, nil
+}
+
+// add indexes a receipt; the caller holds the lock or owns the store
+func (rs *ReceiptStore) add(receipt *Receipt) {
+	rs.byID[receipt.ID] = len(rs.receipts)
+	rs.receipts = append(rs.receipts, receipt)
+}
+
+// record stores a receipt and appends it to the receipts file. The operation
+// has already been broadcast, so a failed write is logged rather than
+// returned.
+func (rs *ReceiptStore) record(receipt *Receipt) {
+	line, err := json.Marshal(receipt)
+	if err != nil {
+		log.Printf("Failed to encode receipt %s: %v", receipt.ID, err)
+		return
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rs.add(receipt)
+	if rs.file == nil {
+		return
+	}
+	if _, err := rs.file.Write(append(line, '\n')); err != nil {
+		log.Printf("Failed to persist receipt %s for tx %s: %v", receipt.ID, receipt.TxID, err)
+	}
+}
+
+// recordExecution counts a broadcast operation in the metrics and keeps a
+// receipt of it, tagging its result with the receipt's ID. The receipt holds
+// a copy of the result, so callers may keep changing theirs.
+func (s *Service) recordExecution(kind ReceiptKind, account string, operations []DexOperation, submittedAt time.Time, result *SwapResult) {
+	s.metrics.observeExecution(kind, submittedAt, result.Success)
+	if s.receipts == nil {
+		return
+	}
+	receipt, ok := newReceipt(kind, account, operations, submittedAt)
+	if !ok {
+		return
+	}
+	result.ReceiptID = receipt.ID
+
+	receipt.TxID = result.TxID
+	receipt.Success = result.Success
+	receipt.Error = result.ErrorMessage
+	receipt.Result = copySwapResult(result)
+	s.receipts.record(receipt)
+}
+
+// recordBatchExecution counts a broadcast batch in the metrics and keeps a
+// receipt of it
+func (s *Service) recordBatchExecution(account string, operations []DexOperation, submittedAt time.Time, result *BatchResult) {
+	s.metrics.observeExecution(ReceiptBatch, submittedAt, result.Success)
+	if s.receipts == nil {
+		return
+	}
+	receipt, ok := newReceipt(ReceiptBatch, account, operations, submittedAt)
+	if !ok {
+		return
+	}
+	result.ReceiptID = receipt.ID
+	for _, r := range result.Results {
+		r.ReceiptID = receipt.ID
+	}
+
+	batch := *result
+	batch.Results = make([]*SwapResult, len(result.Results))
+	for i, r := range result.Results {
+		batch.Results[i] = copySwapResult(r)
+	}
+	receipt.TxID = result.TxID
+	receipt.Success = result.Success
+	receipt.Error = result.ErrorMessage
+	receipt.BatchResult = &batch
+	s.receipts.record(receipt)
+}
+
+// newReceipt starts a receipt for operations submitted at submittedAt
+func newReceipt(kind ReceiptKind, account string, operations []DexOperation, submittedAt time.Time) (*Receipt, bool) {
+	id, err := newJobID()
+	if err != nil {
+		log.Printf("Failed to create %s receipt for %s: %v", kind, account, err)
+		return nil, false
+	}
+	return &Receipt{
+		ID:          id,
+		Kind:        kind,
+		Account:     account,
+		Operations:  operations,
+		SubmittedAt: submittedAt,
+		CompletedAt: time.Now(),
+	}, true
+}
+
+// copySwapResult returns a copy that shares no slices with result
+func copySwapResult(result *SwapResult) *SwapResult {
+	copied := *result
+	copied.Route = append([]string(nil), result.Route...)
+	copied.HopFees = append([]HopQuote(nil), result.HopFees...)
+	return &copied
+}
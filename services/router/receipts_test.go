@@ -0,0 +1,220 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newReceiptTestService(t *testing.T, executor DEXExecutor, path string) *Service {
+	store, err := NewReceiptStore(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+
+	svc := NewService(VSCConfig{}, executor)
+	svc.SetReceiptStore(store)
+	return svc
+}
+
+func TestSwapReceipt(t *testing.T) {
+	svc := newReceiptTestService(t, &mockTxSubmitter{txID: "swap-tx"}, "")
+
+	before := time.Now()
+	result, err := svc.ExecuteSwap(context.Background(), SwapParams{
+		Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000, MinAmountOut: 900, Route: []string{"HBD", "HIVE"},
+	})
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	require.NotEmpty(t, result.ReceiptID)
+
+	receipt, err := svc.GetReceipt(result.ReceiptID)
+	require.NoError(t, err)
+	assert.Equal(t, ReceiptSwap, receipt.Kind)
+	assert.Equal(t, "alice", receipt.Account)
+	assert.Equal(t, "swap-tx", receipt.TxID)
+	assert.True(t, receipt.Success)
+	assert.Equal(t, result.AmountOut, receipt.Result.AmountOut)
+	assert.False(t, receipt.SubmittedAt.Before(before))
+	assert.False(t, receipt.CompletedAt.Before(receipt.SubmittedAt))
+
+	// The receipt holds exactly what was broadcast
+	require.Len(t, receipt.Operations, 1)
+	assert.Equal(t, "execute", receipt.Operations[0].OperationType)
+	assert.JSONEq(t, svc.dexExecutor.(*mockTxSubmitter).executedOperations[0][len("execute:"):], receipt.Operations[0].Payload)
+	require.Len(t, receipt.Operations[0].Intents, 1)
+	assert.Equal(t, "1000", receipt.Operations[0].Intents[0].Args["limit"])
+
+	// Changing the returned result leaves the receipt alone
+	result.Route[0] = "changed"
+	receipt, err = svc.GetReceipt(result.ReceiptID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"HBD", "HIVE"}, receipt.Result.Route)
+
+	_, err = svc.GetReceipt("missing")
+	assert.ErrorIs(t, err, ErrReceiptNotFound)
+}
+
+func TestReceiptsOnlyForBroadcasts(t *testing.T) {
+	executor := &mockBatchExecutor{err: errors.New("node unavailable")}
+	svc := newReceiptTestService(t, executor, "")
+
+	// Rejected before broadcasting: no receipt
+	result, err := svc.ExecuteSwap(context.Background(), SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HBD", AmountIn: 1000})
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Empty(t, result.ReceiptID)
+
+	// Failed broadcasts are kept with their error
+	batch, err := svc.ExecuteBatch(context.Background(), BatchParams{
+		Sender:     "alice",
+		Operations: []BatchOperation{{Swap: &SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000}}},
+	})
+	require.NoError(t, err)
+	require.False(t, batch.Success)
+
+	receipt, err := svc.GetReceipt(batch.ReceiptID)
+	require.NoError(t, err)
+	assert.Equal(t, ReceiptBatch, receipt.Kind)
+	assert.False(t, receipt.Success)
+	assert.Contains(t, receipt.Error, "node unavailable")
+	assert.Len(t, receipt.Operations, 1)
+
+	// Simulations broadcast nothing either
+	ctx := WithSimulation(context.Background())
+	result, err = svc.ExecuteSwap(ctx, SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000})
+	require.NoError(t, err)
+	assert.Empty(t, result.ReceiptID)
+
+	receipts, err := svc.ListReceipts(ReceiptFilter{})
+	require.NoError(t, err)
+	assert.Len(t, receipts, 1)
+}
+
+func TestListReceipts(t *testing.T) {
+	svc := newReceiptTestService(t, &mockBatchExecutor{}, "")
+	ctx := context.Background()
+
+	for _, sender := range []string{"alice", "bob", "alice"} {
+		_, err := svc.ExecuteSwap(ctx, SwapParams{Sender: sender, AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000})
+		require.NoError(t, err)
+	}
+	_, err := svc.ExecuteDeposit(ctx, DepositParams{Sender: "alice", AssetIn: "HIVE", AssetOut: "HBD", AmountIn: 500})
+	require.NoError(t, err)
+	_, err = svc.ExecuteBatch(ctx, BatchParams{
+		Sender:     "alice",
+		Operations: []BatchOperation{{Swap: &SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000}}},
+	})
+	require.NoError(t, err)
+
+	all, err := svc.ListReceipts(ReceiptFilter{})
+	require.NoError(t, err)
+	require.Len(t, all, 5)
+
+	alice, err := svc.ListReceipts(ReceiptFilter{Account: "alice"})
+	require.NoError(t, err)
+	assert.Len(t, alice, 4)
+
+	swaps, err := svc.ListReceipts(ReceiptFilter{Account: "alice", Kind: ReceiptSwap})
+	require.NoError(t, err)
+	assert.Len(t, swaps, 2)
+
+	// Paging returns every receipt once, oldest first
+	page, err := svc.ListReceipts(ReceiptFilter{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	rest, err := svc.ListReceipts(ReceiptFilter{After: page[1].ID})
+	require.NoError(t, err)
+	assert.Equal(t, all, append(page, rest...))
+
+	future, err := svc.ListReceipts(ReceiptFilter{Since: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	assert.Empty(t, future)
+
+	_, err = svc.ListReceipts(ReceiptFilter{After: "missing"})
+	assert.ErrorIs(t, err, ErrReceiptNotFound)
+}
+
+func TestReceiptStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "receipts.jsonl")
+
+	store, err := NewReceiptStore(path)
+	require.NoError(t, err)
+	svc := NewService(VSCConfig{}, &mockTxSubmitter{txID: "swap-tx"})
+	svc.SetReceiptStore(store)
+	first, err := svc.ExecuteSwap(context.Background(), SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000})
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+
+	// Simulate a crash partway through appending a receipt
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"id":"torn","kind":"sw`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	svc = newReceiptTestService(t, &mockTxSubmitter{txID: "swap-tx-2"}, path)
+	receipt, err := svc.GetReceipt(first.ReceiptID)
+	require.NoError(t, err)
+	assert.Equal(t, "swap-tx", receipt.TxID)
+	_, err = svc.GetReceipt("torn")
+	assert.ErrorIs(t, err, ErrReceiptNotFound)
+
+	// New receipts append after the last complete one
+	second, err := svc.ExecuteSwap(context.Background(), SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 2000})
+	require.NoError(t, err)
+	require.NoError(t, svc.receipts.Close())
+
+	svc = newReceiptTestService(t, &mockTxSubmitter{}, path)
+	receipts, err := svc.ListReceipts(ReceiptFilter{})
+	require.NoError(t, err)
+	require.Len(t, receipts, 2)
+	assert.Equal(t, first.ReceiptID, receipts[0].ID)
+	assert.Equal(t, second.ReceiptID, receipts[1].ID)
+	assert.Equal(t, "swap-tx-2", receipts[1].TxID)
+}
+
+func TestReceiptHandlers(t *testing.T) {
+	svc := newReceiptTestService(t, &mockTxSubmitter{txID: "swap-tx"}, "")
+	server := NewServer(svc, "0")
+
+	result, err := svc.ExecuteSwap(context.Background(), SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000})
+	require.NoError(t, err)
+
+	w := serveTestRequest(server, http.MethodGet, "/api/v1/receipts/"+result.ReceiptID, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var receipt Receipt
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&receipt))
+	assert.Equal(t, "swap-tx", receipt.TxID)
+
+	w = serveTestRequest(server, http.MethodGet, "/api/v1/receipts?account=alice&kind=swap&limit=10", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var listed struct {
+		Receipts []Receipt `json:"receipts"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&listed))
+	require.Len(t, listed.Receipts, 1)
+	assert.Equal(t, result.ReceiptID, listed.Receipts[0].ID)
+
+	w = serveTestRequest(server, http.MethodGet, "/api/v1/receipts?account=bob", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&listed))
+	assert.Empty(t, listed.Receipts)
+
+	w = serveTestRequest(server, http.MethodGet, "/api/v1/receipts?since=yesterday", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = serveTestRequest(server, http.MethodGet, "/api/v1/receipts/missing", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Without a store, receipts are unavailable
+	server = NewServer(NewService(VSCConfig{}, &mockDEXExecutor{}), "0")
+	w = serveTestRequest(server, http.MethodGet, "/api/v1/receipts", "")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
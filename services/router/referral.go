@@ -0,0 +1,296 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxRefBps caps a referrer's share of a swap's output, as the instruction
+// schema does
+const maxRefBps = 10000
+
+// ErrReferrerNotFound is returned for unknown referrer IDs
+var ErrReferrerNotFound = errors.New("referrer not found")
+
+// Referrer is an integration that sends swaps through the router. Swaps made
+// with its API key pay it a referral fee unless they name their own.
+type Referrer struct {
+	ID          string    `json:"id"`
+	APIKey      string    `json:"apiKey"`
+	Beneficiary string    `json:"beneficiary"` // Account the referral fee is paid to
+	RefBps      uint64    `json:"refBps"`      // Share of the swap output, in basis points
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// ReferralStats totals the swaps paying a beneficiary since the router
+// started. Fees are estimated from each swap's output at the time.
+type ReferralStats struct {
+	Beneficiary string           `json:"beneficiary"`
+	Swaps       int              `json:"swaps"`
+	Volume      map[string]int64 `json:"volume"` // Input, per asset
+	Fees        map[string]int64 `json:"fees"`   // Referral fees, per output asset
+}
+
+// ReferralProgram holds the registered referrers and the volume each has
+// referred
+type ReferralProgram struct {
+	path string // File referrers are persisted to; empty keeps them in memory
+
+	mu        sync.Mutex
+	referrers map[string]*Referrer      // By ID
+	stats     map[string]*ReferralStats // By beneficiary
+	now       func() time.Time
+}
+
+// NewReferralProgram creates a referral program persisted to path, loading
+// any referrers already saved there. An empty path keeps referrers in memory.
+func NewReferralProgram(path string) (*ReferralProgram, error) {
+	p := &ReferralProgram{
+		path:  path,
+		stats: make(map[string]*ReferralStats),
+		now:   time.Now,
+	}
+	referrers, err := p.load()
+	if err != nil {
+		return nil, err
+	}
+	p.referrers = referrers
+	return p, nil
+}
+
+// Reload replaces the referrers with those saved in the program's file, so
+// edits made to it by hand take effect. Referral stats are kept. If the file
+// cannot be read the current referrers are kept.
+func (p *ReferralProgram) Reload() error {
+	referrers, err := p.load()
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.referrers = referrers
+	return nil
+}
+
+// load reads the referrers saved in the program's file
+func (p *ReferralProgram) load() (map[string]*Referrer, error) {
+	loaded := make(map[string]*Referrer)
+	if p.path == "" {
+		return loaded, nil
+	}
+
+	data, err := os.ReadFile(p.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return loaded, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read referrers: %w", err)
+	}
+	var referrers []Referrer
+	if err := json.Unmarshal(data, &referrers); err != nil {
+		return nil, fmt.Errorf("failed to decode referrers: %w", err)
+	}
+	for i := range referrers {
+		ref := referrers[i]
+		loaded[ref.ID] = &ref
+	}
+	return loaded, nil
+}
+
+// SetReferralProgram configures the referral defaults applied to swaps
+func (s *Service) SetReferralProgram(program *ReferralProgram) {
+	s.referrals = program
+}
+
+// ApplyReferral fills in the referral fee of the integration owning apiKey,
+// for swaps that set neither a beneficiary nor a fee of their own
+func (s *Service) ApplyReferral(params SwapParams, apiKey string) SwapParams {
+	if s.referrals == nil || apiKey == "" || params.Beneficiary != "" || params.RefBps > 0 {
+		return params
+	}
+	if ref, ok := s.referrals.forAPIKey(apiKey); ok {
+		params.Beneficiary = ref.Beneficiary
+		params.RefBps = ref.RefBps
+	}
+	return params
+}
+
+// Register adds a referrer, or updates the one with the same ID
+func (p *ReferralProgram) Register(ref Referrer) (*Referrer, error) {
+	if ref.ID == "" || ref.APIKey == "" || ref.Beneficiary == "" {
+		return nil, fmt.Errorf("id, API key and beneficiary are required")
+	}
+	if ref.RefBps == 0 || ref.RefBps > maxRefBps {
+		return nil, fmt.Errorf("ref bps must be between 1 and %d", maxRefBps)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for id, other := range p.referrers {
+		if id != ref.ID && other.APIKey == ref.APIKey {
+			return nil, fmt.Errorf("API key is already registered to another referrer")
+		}
+	}
+
+	now := p.now()
+	ref.CreatedAt, ref.UpdatedAt = now, now
+	if existing, ok := p.referrers[ref.ID]; ok {
+		ref.CreatedAt = existing.CreatedAt
+	}
+	previous, existed := p.referrers[ref.ID]
+	p.referrers[ref.ID] = &ref
+
+	if err := p.saveLocked(); err != nil {
+		if existed {
+			p.referrers[ref.ID] = previous
+		} else {
+			delete(p.referrers, ref.ID)
+		}
+		return nil, err
+	}
+	registered := ref
+	return &registered, nil
+}
+
+// Remove deletes a referrer. Swaps with its API key no longer pay it.
+func (p *ReferralProgram) Remove(id string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ref, ok := p.referrers[id]
+	if !ok {
+		return ErrReferrerNotFound
+	}
+	delete(p.referrers, id)
+	if err := p.saveLocked(); err != nil {
+		p.referrers[id] = ref
+		return err
+	}
+	return nil
+}
+
+// Get returns a referrer by ID
+func (p *ReferralProgram) Get(id string) (*Referrer, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ref, ok := p.referrers[id]
+	if !ok {
+		return nil, ErrReferrerNotFound
+	}
+	found := *ref
+	return &found, nil
+}
+
+// List returns every referrer, by ID
+func (p *ReferralProgram) List() []Referrer {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	referrers := make([]Referrer, 0, len(p.referrers))
+	for _, ref := range p.referrers {
+		referrers = append(referrers, *ref)
+	}
+	sort.Slice(referrers, func(i, j int) bool { return referrers[i].ID < referrers[j].ID })
+	return referrers
+}
+
+// Stats returns the swaps that have paid a beneficiary
+func (p *ReferralProgram) Stats(beneficiary string) ReferralStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := ReferralStats{
+		Beneficiary: beneficiary,
+		Volume:      make(map[string]int64),
+		Fees:        make(map[string]int64),
+	}
+	if recorded, ok := p.stats[beneficiary]; ok {
+		stats.Swaps = recorded.Swaps
+		for asset, amount := range recorded.Volume {
+			stats.Volume[asset] = amount
+		}
+		for asset, amount := range recorded.Fees {
+			stats.Fees[asset] = amount
+		}
+	}
+	return stats
+}
+
+// forAPIKey returns the referrer owning an API key
+func (p *ReferralProgram) forAPIKey(apiKey string) (Referrer, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, ref := range p.referrers {
+		if ref.APIKey == apiKey {
+			return *ref, true
+		}
+	}
+	return Referrer{}, false
+}
+
+// record adds a broadcast swap to its beneficiary's totals, if the
+// beneficiary is a registered referrer
+func (p *ReferralProgram) record(params SwapParams, result *SwapResult) {
+	if params.Beneficiary == "" || params.RefBps == 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	registered := false
+	for _, ref := range p.referrers {
+		if ref.Beneficiary == params.Beneficiary {
+			registered = true
+			break
+		}
+	}
+	if !registered {
+		return
+	}
+
+	stats, ok := p.stats[params.Beneficiary]
+	if !ok {
+		stats = &ReferralStats{
+			Beneficiary: params.Beneficiary,
+			Volume:      make(map[string]int64),
+			Fees:        make(map[string]int64),
+		}
+		p.stats[params.Beneficiary] = stats
+	}
+	stats.Swaps++
+	stats.Volume[params.AssetIn] += params.AmountIn
+	stats.Fees[params.AssetOut] += result.AmountOut * int64(params.RefBps) / 10000
+}
+
+// saveLocked writes the referrers to the program's file; caller must hold
+// p.mu
+func (p *ReferralProgram) saveLocked() error {
+	if p.path == "" {
+		return nil
+	}
+
+	referrers := make([]Referrer, 0, len(p.referrers))
+	for _, ref := range p.referrers {
+		referrers = append(referrers, *ref)
+	}
+	sort.Slice(referrers, func(i, j int) bool { return referrers[i].ID < referrers[j].ID })
+
+	data, err := json.MarshalIndent(referrers, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode referrers: %w", err)
+	}
+	if err := writeFileAtomic(p.path, data); err != nil {
+		return fmt.Errorf("failed to write referrers: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReferralProgramRegister(t *testing.T) {
+	program, err := NewReferralProgram("")
+	require.NoError(t, err)
+
+	_, err = program.Register(Referrer{ID: "wallet", APIKey: "key-1", Beneficiary: "wallet-fees", RefBps: 25})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		referrer Referrer
+		errMsg   string
+	}{
+		{"missing beneficiary", Referrer{ID: "a", APIKey: "key-2"}, "required"},
+		{"zero fee", Referrer{ID: "a", APIKey: "key-2", Beneficiary: "b"}, "ref bps"},
+		{"fee above output", Referrer{ID: "a", APIKey: "key-2", Beneficiary: "b", RefBps: 10001}, "ref bps"},
+		{"API key taken", Referrer{ID: "a", APIKey: "key-1", Beneficiary: "b", RefBps: 10}, "already registered"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := program.Register(tt.referrer)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+
+	// Re-registering an ID updates it
+	updated, err := program.Register(Referrer{ID: "wallet", APIKey: "key-1", Beneficiary: "wallet-fees", RefBps: 30})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(30), updated.RefBps)
+	assert.Len(t, program.List(), 1)
+
+	require.NoError(t, program.Remove("wallet"))
+	assert.ErrorIs(t, program.Remove("wallet"), ErrReferrerNotFound)
+}
+
+func TestReferralProgramPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "referrers.json")
+	program, err := NewReferralProgram(path)
+	require.NoError(t, err)
+	_, err = program.Register(Referrer{ID: "wallet", APIKey: "key-1", Beneficiary: "wallet-fees", RefBps: 25})
+	require.NoError(t, err)
+
+	reloaded, err := NewReferralProgram(path)
+	require.NoError(t, err)
+	referrer, err := reloaded.Get("wallet")
+	require.NoError(t, err)
+	assert.Equal(t, "wallet-fees", referrer.Beneficiary)
+	assert.Equal(t, uint64(25), referrer.RefBps)
+}
+
+func TestApplyReferral(t *testing.T) {
+	svc := NewService(VSCConfig{}, &mockDEXExecutor{})
+	program, err := NewReferralProgram("")
+	require.NoError(t, err)
+	svc.SetReferralProgram(program)
+	_, err = program.Register(Referrer{ID: "wallet", APIKey: "key-1", Beneficiary: "wallet-fees", RefBps: 25})
+	require.NoError(t, err)
+
+	params := SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000}
+
+	applied := svc.ApplyReferral(params, "key-1")
+	assert.Equal(t, "wallet-fees", applied.Beneficiary)
+	assert.Equal(t, uint64(25), applied.RefBps)
+
+	// Unknown keys and swaps naming their own referral are left alone
+	assert.Empty(t, svc.ApplyReferral(params, "key-2").Beneficiary)
+	params.Beneficiary = "someone"
+	assert.Zero(t, svc.ApplyReferral(params, "key-1").RefBps)
+}
+
+func TestReferralVolumeTracking(t *testing.T) {
+	svc := NewService(VSCConfig{}, &mockDEXExecutor{})
+	program, err := NewReferralProgram("")
+	require.NoError(t, err)
+	svc.SetReferralProgram(program)
+	_, err = program.Register(Referrer{ID: "wallet", APIKey: "key-1", Beneficiary: "wallet-fees", RefBps: 100})
+	require.NoError(t, err)
+
+	params := svc.ApplyReferral(SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000, MinAmountOut: 900}, "key-1")
+	for i := 0; i < 2; i++ {
+		result, err := svc.ExecuteSwap(context.Background(), params)
+		require.NoError(t, err)
+		require.True(t, result.Success, result.ErrorMessage)
+	}
+
+	// Simulated swaps and swaps without a registered beneficiary are not counted
+	_, err = svc.ExecuteSwap(WithSimulation(context.Background()), params)
+	require.NoError(t, err)
+	_, err = svc.ExecuteSwap(context.Background(), SwapParams{Sender: "bob", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000, Beneficiary: "other", RefBps: 100})
+	require.NoError(t, err)
+
+	stats := program.Stats("wallet-fees")
+	assert.Equal(t, 2, stats.Swaps)
+	assert.Equal(t, int64(2000), stats.Volume["HBD"])
+	assert.Equal(t, int64(18), stats.Fees["HIVE"])
+	assert.Zero(t, program.Stats("other").Swaps)
+}
+
+func TestReferralHandlers(t *testing.T) {
+	executor := &mockDEXExecutor{}
+	svc := NewService(VSCConfig{}, executor)
+	program, err := NewReferralProgram("")
+	require.NoError(t, err)
+	svc.SetReferralProgram(program)
+	server := NewServer(svc, "0")
+
+	adminRequest := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		server.http.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// Disabled until an admin token is set
+	w := adminRequest(http.MethodGet, "/api/v1/referrers", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	server.SetAdminToken("secret")
+	w = serveTestRequest(server, http.MethodGet, "/api/v1/referrers", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = adminRequest(http.MethodPost, "/api/v1/referrers", `{"id": "wallet", "apiKey": "key-1", "beneficiary": "wallet-fees", "refBps": 25}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// Swaps made with the API key pay the referrer
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/swap", strings.NewReader(`{"fromAsset": "HBD", "toAsset": "HIVE", "amount": 1000, "sender": "alice"}`))
+	req.Header.Set("X-API-Key", "key-1")
+	w = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, executor.executedOperations, 1)
+	assert.Contains(t, executor.executedOperations[0], `"beneficiary":"wallet-fees"`)
+	assert.Contains(t, executor.executedOperations[0], `"ref_bps":25`)
+
+	w = adminRequest(http.MethodGet, "/api/v1/referrers/wallet", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Referrer Referrer      `json:"referrer"`
+		Stats    ReferralStats `json:"stats"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "wallet-fees", resp.Referrer.Beneficiary)
+	assert.Equal(t, 1, resp.Stats.Swaps)
+	assert.Equal(t, int64(1000), resp.Stats.Volume["HBD"])
+
+	w = adminRequest(http.MethodDelete, "/api/v1/referrers/wallet", "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = adminRequest(http.MethodGet, "/api/v1/referrers/wallet", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,272 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/vsc-eco/vsc-dex-mapping/schemas"
+)
+
+// ChainWithdrawer is implemented by executors that can bridge an account's
+// asset out of VSC to an address on another chain. It returns the
+// withdrawal's transaction ID.
+type ChainWithdrawer interface {
+	WithdrawToChain(ctx context.Context, account string, asset string, amount int64, chain string, address string) (string, error)
+}
+
+// hiveAssets are the assets that can be withdrawn to a Hive account
+var hiveAssets = map[string]bool{"HIVE": true, "HBD": true}
+
+// checkReturnAddress validates a swap's return address for its chain and
+// returns it normalized. A registered mapping adapter validates addresses on
+// its chain; otherwise BTC and HIVE addresses are checked here. Only the
+// chain's own assets can be sent to it.
+func (s *Service) checkReturnAddress(params SwapParams) (*schemas.ReturnAddress, error) {
+	to := params.ReturnAddress
+	chain := strings.ToUpper(to.Chain)
+
+	if adapter, err := s.mapping(chain); err == nil {
+		address, err := adapter.FormatAddress(to.Address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s return address: %w", chain, err)
+		}
+		if mapped := adapter.GetMappedToken(); params.AssetOut != mapped {
+			return nil, fmt.Errorf("only %s can be sent to a %s address, not %s", mapped, chain, params.AssetOut)
+		}
+		return &schemas.ReturnAddress{Chain: chain, Address: address}, nil
+	}
+
+	switch chain {
+	case "BTC":
+		if err := validateBitcoinAddress(to.Address); err != nil {
+			return nil, fmt.Errorf("invalid BTC return address: %w", err)
+		}
+		if params.AssetOut != "BTC" {
+			return nil, fmt.Errorf("only BTC can be sent to a BTC address, not %s", params.AssetOut)
+		}
+	case "HIVE":
+		if err := validateHiveAccount(strings.TrimPrefix(to.Address, "hive:")); err != nil {
+			return nil, fmt.Errorf("invalid HIVE return address: %w", err)
+		}
+		if !hiveAssets[params.AssetOut] {
+			return nil, fmt.Errorf("only HIVE and HBD can be sent to a HIVE address, not %s", params.AssetOut)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported return address chain: %s", to.Chain)
+	}
+	return &schemas.ReturnAddress{Chain: chain, Address: to.Address}, nil
+}
+
+// withdrawOutput bridges a completed swap's output to its return address.
+// It sends the executed output if known, or else the swap's guaranteed
+// minimum, so the withdrawal never exceeds what was received.
+func (s *Service) withdrawOutput(ctx context.Context, params SwapParams, result *SwapResult) (string, error) {
+	amount := params.MinAmountOut
+	switch {
+	case result.Settled:
+		amount = result.AmountOut
+	case amount == 0:
+		amount = result.MinimumReceived
+	}
+	if amount <= 0 {
+		return "", fmt.Errorf("output to withdraw is unknown; set a minimum amount out")
+	}
+
+	// The output must be in the account before it can leave
+	if !result.Settled {
+		if err := s.awaitInclusion(ctx, result.TxID, s.bridgeDelay); err != nil {
+			return "", fmt.Errorf("swap %s not included: %w", result.TxID, err)
+		}
+	}
+
+	withdrawer := s.dexExecutor.(ChainWithdrawer)
+	var txID string
+	err := s.inTurn(ctx, params.Sender, func() (err error) {
+		txID, err = withdrawer.WithdrawToChain(ctx, params.Sender, params.AssetOut, amount, params.ReturnAddress.Chain, params.ReturnAddress.Address)
+		return err
+	})
+	return txID, err
+}
+
+// validateHiveAccount checks a Hive account name: 3 to 16 characters of
+// dot-separated segments, each starting with a letter, ending with a letter
+// or digit and at least 3 characters long
+func validateHiveAccount(name string) error {
+	if len(name) < 3 || len(name) > 16 {
+		return fmt.Errorf("account name must be 3 to 16 characters")
+	}
+	for _, segment := range strings.Split(name, ".") {
+		if len(segment) < 3 {
+			return fmt.Errorf("each part of an account name must be at least 3 characters")
+		}
+		for i, c := range segment {
+			letter := c >= 'a' && c <= 'z'
+			digit := c >= '0' && c <= '9'
+			switch {
+			case i == 0 && !letter:
+				return fmt.Errorf("account name parts must start with a lowercase letter")
+			case i == len(segment)-1 && !letter && !digit:
+				return fmt.Errorf("account name parts must end with a letter or digit")
+			case !letter && !digit && c != '-':
+				return fmt.Errorf("account names may only contain lowercase letters, digits, dashes and dots")
+			}
+		}
+	}
+	return nil
+}
+
+// validateBitcoinAddress checks a mainnet, testnet or regtest Bitcoin
+// address: base58check P2PKH and P2SH, or bech32 and bech32m segwit
+func validateBitcoinAddress(address string) error {
+	lower := strings.ToLower(address)
+	for _, hrp := range []string{"bc", "tb", "bcrt"} {
+		if strings.HasPrefix(lower, hrp+"1") && strings.LastIndexByte(lower, '1') == len(hrp) {
+			return validateSegwitAddress(address, hrp)
+		}
+	}
+	return validateBase58Address(address)
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// Base58check version bytes of pay-to-pubkey-hash and pay-to-script-hash
+// addresses on mainnet and testnet
+var base58Versions = map[byte]bool{0x00: true, 0x05: true, 0x6f: true, 0xc4: true}
+
+// validateBase58Address checks a base58check P2PKH or P2SH address
+func validateBase58Address(address string) error {
+	n := new(big.Int)
+	for _, c := range address {
+		digit := strings.IndexRune(base58Alphabet, c)
+		if digit < 0 {
+			return fmt.Errorf("invalid character %q", c)
+		}
+		n.Mul(n, big.NewInt(58))
+		n.Add(n, big.NewInt(int64(digit)))
+	}
+
+	// Each leading '1' encodes a leading zero byte
+	zeros := len(address) - len(strings.TrimLeft(address, "1"))
+	decoded := append(make([]byte, zeros), n.Bytes()...)
+	if len(decoded) != 25 {
+		return fmt.Errorf("unrecognized address format")
+	}
+	if !base58Versions[decoded[0]] {
+		return fmt.Errorf("unknown address version %d", decoded[0])
+	}
+
+	first := sha256.Sum256(decoded[:21])
+	checksum := sha256.Sum256(first[:])
+	if !bytes.Equal(checksum[:4], decoded[21:]) {
+		return fmt.Errorf("checksum mismatch")
+	}
+	return nil
+}
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// Checksum constants of bech32 (witness version 0) and bech32m (later
+// versions) addresses
+const (
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+// validateSegwitAddress checks a bech32 or bech32m segwit address with the
+// given human-readable part
+func validateSegwitAddress(address, hrp string) error {
+	if len(address) > 90 {
+		return fmt.Errorf("address too long")
+	}
+	if strings.ToLower(address) != address && strings.ToUpper(address) != address {
+		return fmt.Errorf("mixed-case address")
+	}
+	address = strings.ToLower(address)
+
+	encoded := address[len(hrp)+1:]
+	if len(encoded) < 7 {
+		return fmt.Errorf("address too short")
+	}
+	data := make([]byte, len(encoded))
+	for i, c := range encoded {
+		value := strings.IndexRune(bech32Charset, c)
+		if value < 0 {
+			return fmt.Errorf("invalid character %q", c)
+		}
+		data[i] = byte(value)
+	}
+
+	version := data[0]
+	expected := uint32(bech32Const)
+	if version > 0 {
+		expected = bech32mConst
+	}
+	if version > 16 {
+		return fmt.Errorf("invalid witness version %d", version)
+	}
+	if bech32Polymod(append(bech32ExpandHRP(hrp), data...)) != expected {
+		return fmt.Errorf("checksum mismatch")
+	}
+
+	program, ok := convertBits(data[1:len(data)-6], 5, 8)
+	if !ok || len(program) < 2 || len(program) > 40 {
+		return fmt.Errorf("invalid witness program")
+	}
+	if version == 0 && len(program) != 20 && len(program) != 32 {
+		return fmt.Errorf("invalid witness program length %d for version 0", len(program))
+	}
+	return nil
+}
+
+// bech32Polymod computes the bech32 checksum over values, per BIP 173
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+// bech32ExpandHRP expands the human-readable part for checksumming
+func bech32ExpandHRP(hrp string) []byte {
+	expanded := make([]byte, 0, len(hrp)*2+1)
+	for _, c := range hrp {
+		expanded = append(expanded, byte(c>>5))
+	}
+	expanded = append(expanded, 0)
+	for _, c := range hrp {
+		expanded = append(expanded, byte(c&31))
+	}
+	return expanded
+}
+
+// convertBits regroups data from groups of from bits to groups of to bits,
+// rejecting non-zero padding
+func convertBits(data []byte, from, to uint) ([]byte, bool) {
+	var acc, bits uint
+	var out []byte
+	maxValue := uint(1)<<to - 1
+	for _, value := range data {
+		acc = acc<<from | uint(value)
+		bits += from
+		for bits >= to {
+			bits -= to
+			out = append(out, byte(acc>>bits&maxValue))
+		}
+	}
+	if bits >= from || (acc<<(to-bits))&maxValue != 0 {
+		return nil, false
+	}
+	return out, true
+}
@@ -0,0 +1,170 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vsc-eco/vsc-dex-mapping/schemas"
+)
+
+// mockWithdrawer records withdrawals, failing them while err is set
+type mockWithdrawer struct {
+	mockMinter
+	withdrawals []string
+	err         error
+}
+
+func (m *mockWithdrawer) WithdrawToChain(ctx context.Context, account string, asset string, amount int64, chain string, address string) (string, error) {
+	if m.err != nil {
+		return "", m.err
+	}
+	m.withdrawals = append(m.withdrawals, fmt.Sprintf("%s:%d %s->%s:%s", account, amount, asset, chain, address))
+	return "withdraw-tx", nil
+}
+
+func TestValidateBitcoinAddress(t *testing.T) {
+	valid := []string{
+		"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2",
+		"3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy",
+		"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+		"BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4",
+		"tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7",
+		"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0",
+	}
+	for _, address := range valid {
+		assert.NoError(t, validateBitcoinAddress(address), address)
+	}
+
+	invalid := []string{
+		"",
+		"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN3", // Bad checksum
+		"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5",                                 // Bad checksum
+		"bc1Qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",                                 // Mixed case
+		"bc1zw508d6qejxtdg4y5r3zarvaryvqyzf3du",                                      // Version 2 with a bech32 checksum
+		"0x742d35Cc6634C0532925a3b844Bc454e4438f44e",                                 // Ethereum
+		"bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7k7grplx", // Bech32 checksum on version 1
+	}
+	for _, address := range invalid {
+		assert.Error(t, validateBitcoinAddress(address), address)
+	}
+}
+
+func TestValidateHiveAccount(t *testing.T) {
+	for _, name := range []string{"alice", "bob-123", "dex.router", "abc"} {
+		assert.NoError(t, validateHiveAccount(name), name)
+	}
+	for _, name := range []string{"al", "Alice", "1alice", "alice-", "ab.cde", "averyveryverylongname", "alice_b"} {
+		assert.Error(t, validateHiveAccount(name), name)
+	}
+}
+
+func TestSwapReturnAddressValidation(t *testing.T) {
+	svc := NewService(VSCConfig{}, &mockWithdrawer{})
+
+	tests := []struct {
+		name     string
+		assetOut string
+		to       schemas.ReturnAddress
+		errMsg   string
+	}{
+		{"invalid BTC address", "BTC", schemas.ReturnAddress{Chain: "BTC", Address: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN3"}, "invalid BTC return address"},
+		{"wrong asset for BTC", "HIVE", schemas.ReturnAddress{Chain: "BTC", Address: "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"}, "only BTC"},
+		{"invalid Hive account", "HIVE", schemas.ReturnAddress{Chain: "HIVE", Address: "hive:Al"}, "invalid HIVE return address"},
+		{"wrong asset for Hive", "BTC", schemas.ReturnAddress{Chain: "HIVE", Address: "alice"}, "only HIVE and HBD"},
+		{"unknown chain", "ETH", schemas.ReturnAddress{Chain: "ETH", Address: "0x742d35Cc6634C0532925a3b844Bc454e4438f44e"}, "unsupported return address chain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			to := tt.to
+			result, err := svc.ExecuteSwap(context.Background(), SwapParams{
+				Sender: "alice", AssetIn: "HBD", AssetOut: tt.assetOut, AmountIn: 1000, MinAmountOut: 10, ReturnAddress: &to,
+			})
+			require.NoError(t, err)
+			assert.False(t, result.Success)
+			assert.Contains(t, result.ErrorMessage, tt.errMsg)
+		})
+	}
+
+	// Executors that cannot withdraw refuse the swap before it is broadcast
+	executor := &mockDEXExecutor{}
+	svc = NewService(VSCConfig{}, executor)
+	result, err := svc.ExecuteSwap(context.Background(), SwapParams{
+		Sender: "alice", AssetIn: "HBD", AssetOut: "BTC", AmountIn: 1000, MinAmountOut: 10,
+		ReturnAddress: &schemas.ReturnAddress{Chain: "BTC", Address: "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, result.ErrorMessage, "cannot withdraw")
+	assert.Empty(t, executor.executedOperations)
+}
+
+func TestSwapWithReturnAddress(t *testing.T) {
+	executor := &mockWithdrawer{mockMinter: mockMinter{mockTxSubmitter: mockTxSubmitter{txID: "swap-tx"}}}
+	svc := NewService(VSCConfig{}, executor)
+
+	result, err := svc.ExecuteSwap(context.Background(), SwapParams{
+		Sender: "alice", AssetIn: "HBD", AssetOut: "BTC", AmountIn: 1000000, MinAmountOut: 3000,
+		ReturnAddress: &schemas.ReturnAddress{Chain: "btc", Address: "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},
+	})
+	require.NoError(t, err)
+	require.True(t, result.Success, result.ErrorMessage)
+	assert.Equal(t, "swap-tx", result.TxID)
+	assert.Equal(t, "withdraw-tx", result.WithdrawTxID)
+
+	// The swap's return address is in its payload, and the guaranteed
+	// minimum is withdrawn once the swap is included
+	require.Len(t, executor.executedOperations, 1)
+	assert.Contains(t, executor.executedOperations[0], `"return_address":{"chain":"BTC","address":"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"}`)
+	assert.Equal(t, []string{"swap-tx"}, executor.included)
+	assert.Equal(t, []string{"alice:3000 BTC->BTC:bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"}, executor.withdrawals)
+}
+
+func TestSwapReturnAddressWithdrawalFails(t *testing.T) {
+	executor := &mockWithdrawer{mockMinter: mockMinter{mockTxSubmitter: mockTxSubmitter{txID: "swap-tx"}}, err: errors.New("bridge paused")}
+	svc := NewService(VSCConfig{}, executor)
+
+	result, err := svc.ExecuteSwap(context.Background(), SwapParams{
+		Sender: "alice", AssetIn: "HIVE", AssetOut: "HBD", AmountIn: 1000, MinAmountOut: 200,
+		ReturnAddress: &schemas.ReturnAddress{Chain: "HIVE", Address: "hive:alice"},
+	})
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Equal(t, "swap-tx", result.TxID, "the swap itself went through")
+	assert.Contains(t, result.ErrorMessage, "withdrawal to HIVE failed")
+	assert.Contains(t, result.ErrorMessage, "bridge paused")
+}
+
+func TestSwapReturnAddressRefusedInBatch(t *testing.T) {
+	svc := NewService(VSCConfig{}, &mockBatchExecutor{})
+	result, err := svc.ExecuteBatch(context.Background(), BatchParams{
+		Sender: "alice",
+		Operations: []BatchOperation{{Swap: &SwapParams{
+			AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000,
+			ReturnAddress: &schemas.ReturnAddress{Chain: "HIVE", Address: "alice"},
+		}}},
+	})
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.ErrorMessage, "return addresses are not supported in batches")
+}
+
+func TestHandleSwapReturnAddress(t *testing.T) {
+	executor := &mockWithdrawer{mockMinter: mockMinter{mockTxSubmitter: mockTxSubmitter{txID: "swap-tx"}}}
+	server := NewServer(NewService(VSCConfig{}, executor), "0")
+
+	w := serveTestRequest(server, http.MethodPost, "/api/v1/swap", `{
+		"fromAsset": "HIVE", "toAsset": "HBD", "amount": 1000, "minOut": 200, "sender": "alice",
+		"returnAddress": {"chain": "HIVE", "address": "alice"}
+	}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var result SwapResult
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	assert.True(t, result.Success, result.ErrorMessage)
+	assert.Equal(t, "withdraw-tx", result.WithdrawTxID)
+}
@@ -0,0 +1,245 @@
+package router
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultNotionalWindow is the period an account's notional cap covers
+const defaultNotionalWindow = 24 * time.Hour
+
+// RiskConfig sets the pre-trade checks applied to swaps
+type RiskConfig struct {
+	// MaxDeviationBps is how far, in basis points, a pool's quoted spot
+	// price may stray from its TWAP. Zero disables the check.
+	MaxDeviationBps uint64
+
+	// MaxNotional caps each account's swaps over NotionalWindow, valued in
+	// HBD. Zero disables the cap.
+	MaxNotional int64
+
+	// AccountNotional overrides MaxNotional for individual accounts. Zero
+	// exempts an account.
+	AccountNotional map[string]int64
+
+	// NotionalWindow is the rolling period notional caps cover; zero uses
+	// 24 hours
+	NotionalWindow time.Duration
+
+	// WarnOnly reports failed checks as warnings instead of rejecting swaps
+	WarnOnly bool
+}
+
+// notionalHold is a swap's notional counted against its account's cap
+type notionalHold struct {
+	at     time.Time
+	amount int64
+}
+
+// RiskChecker rejects, or warns on, swaps priced off reserves that stray
+// from their average, and swaps beyond an account's notional cap
+type RiskChecker struct {
+	config  RiskConfig
+	tracker *PriceTracker // Source of TWAPs; nil skips the price check
+
+	mu    sync.Mutex
+	holds map[string][]*notionalHold // Per account, oldest first
+	now   func() time.Time
+}
+
+// riskAssessment is the outcome of a swap's risk checks
+type riskAssessment struct {
+	account  string
+	warnings []string
+	hold     *notionalHold // Set if the swap counts against a cap
+}
+
+// NewRiskChecker creates a risk checker reading TWAPs from tracker. The
+// price check needs a tracker, which should also be the service's pool
+// querier so it sees the reserves swaps are quoted from.
+func NewRiskChecker(tracker *PriceTracker, config RiskConfig) (*RiskChecker, error) {
+	if config.MaxDeviationBps > 0 && tracker == nil {
+		return nil, fmt.Errorf("price deviation check requires a price tracker")
+	}
+	if config.MaxNotional < 0 {
+		return nil, fmt.Errorf("max notional must not be negative")
+	}
+	for account, limit := range config.AccountNotional {
+		if limit < 0 {
+			return nil, fmt.Errorf("notional cap for %s must not be negative", account)
+		}
+	}
+	if config.NotionalWindow <= 0 {
+		config.NotionalWindow = defaultNotionalWindow
+	}
+	return &RiskChecker{
+		config:  config,
+		tracker: tracker,
+		holds:   make(map[string][]*notionalHold),
+		now:     time.Now,
+	}, nil
+}
+
+// SetRiskChecker configures the pre-trade risk checks swaps must pass
+func (s *Service) SetRiskChecker(checker *RiskChecker) {
+	s.risk = checker
+}
+
+// assess runs a swap's risk checks. A failed check rejects the swap with an
+// error unless the checker only warns. With hold set, a swap within its
+// account's cap is counted against it until released.
+func (rc *RiskChecker) assess(params SwapParams, quote *Quote, hold bool) (*riskAssessment, error) {
+	assessment := &riskAssessment{account: params.Sender}
+	var violations []string
+
+	if rc.config.MaxDeviationBps > 0 {
+		if quote == nil {
+			assessment.warnings = append(assessment.warnings, "price not checked against TWAP: no quote available")
+		} else {
+			for _, hop := range uniqueHops(quote.Hops) {
+				warning, violation := rc.checkPrice(hop)
+				if warning != "" {
+					assessment.warnings = append(assessment.warnings, warning)
+				}
+				if violation != "" {
+					violations = append(violations, violation)
+				}
+			}
+		}
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	limit := rc.capFor(params.Sender)
+	if limit > 0 {
+		value, ok := rc.notional(params, quote)
+		used := rc.usedLocked(params.Sender)
+		switch {
+		case !ok:
+			assessment.warnings = append(assessment.warnings, fmt.Sprintf("notional cap not checked: %s could not be valued in %s", params.AssetIn, hubAsset))
+		case used+value > limit:
+			violations = append(violations, fmt.Sprintf("swap of %d %s notional would exceed %s's cap of %d %s per %s (%d used)",
+				value, hubAsset, params.Sender, limit, hubAsset, rc.config.NotionalWindow, used))
+		}
+		if ok && hold && (len(violations) == 0 || rc.config.WarnOnly) {
+			assessment.hold = &notionalHold{at: rc.now(), amount: value}
+			rc.holds[params.Sender] = append(rc.holds[params.Sender], assessment.hold)
+		}
+	}
+
+	if len(violations) > 0 {
+		if !rc.config.WarnOnly {
+			return nil, fmt.Errorf("risk check failed: %s", strings.Join(violations, "; "))
+		}
+		assessment.warnings = append(assessment.warnings, violations...)
+	}
+	for _, warning := range assessment.warnings {
+		log.Printf("Risk warning for %s swap of %d %s: %s", params.Sender, params.AmountIn, params.AssetIn, warning)
+	}
+	return assessment, nil
+}
+
+// release stops counting a swap that was never broadcast against its
+// account's cap
+func (rc *RiskChecker) release(assessment *riskAssessment) {
+	if assessment == nil || assessment.hold == nil {
+		return
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	holds := rc.holds[assessment.account]
+	for i, hold := range holds {
+		if hold == assessment.hold {
+			rc.holds[assessment.account] = append(holds[:i], holds[i+1:]...)
+			break
+		}
+	}
+	assessment.hold = nil
+}
+
+// checkPrice compares a hop's pool price with its TWAP, returning a warning
+// if it cannot, or a violation if the price strays too far
+func (rc *RiskChecker) checkPrice(hop HopQuote) (string, string) {
+	spot, ok := rc.tracker.SpotPrice(hop.PoolID, hop.AssetIn)
+	if !ok {
+		return fmt.Sprintf("price of pool %s not checked against TWAP: pool not tracked", hop.PoolID), ""
+	}
+	twap, ok := rc.tracker.TWAP(hop.PoolID, hop.AssetIn)
+	if !ok {
+		return fmt.Sprintf("price of pool %s not checked against TWAP: not enough price history yet", hop.PoolID), ""
+	}
+
+	deviation := math.Abs(spot-twap) / twap * 10000
+	if deviation > float64(rc.config.MaxDeviationBps) {
+		return "", fmt.Sprintf("pool %s prices %s at %.6g %s, %.0f bps from its TWAP of %.6g (at most %d bps allowed)",
+			hop.PoolID, hop.AssetIn, spot, hop.AssetOut, deviation, twap, rc.config.MaxDeviationBps)
+	}
+	return "", ""
+}
+
+// capFor returns an account's notional cap; zero means none
+func (rc *RiskChecker) capFor(account string) int64 {
+	if limit, ok := rc.config.AccountNotional[account]; ok {
+		return limit
+	}
+	return rc.config.MaxNotional
+}
+
+// usedLocked totals an account's holds within the window, dropping older
+// ones; caller holds rc.mu
+func (rc *RiskChecker) usedLocked(account string) int64 {
+	start := rc.now().Add(-rc.config.NotionalWindow)
+	holds := rc.holds[account]
+	for len(holds) > 0 && !holds[0].at.After(start) {
+		holds = holds[1:]
+	}
+	if len(holds) == 0 {
+		delete(rc.holds, account)
+		return 0
+	}
+	rc.holds[account] = holds
+
+	var used int64
+	for _, hold := range holds {
+		used += hold.amount
+	}
+	return used
+}
+
+// notional values a swap's input in HBD: directly, at the TWAP of a pool
+// pairing it with HBD, or failing that by the quoted output of a swap into
+// HBD
+func (rc *RiskChecker) notional(params SwapParams, quote *Quote) (int64, bool) {
+	if params.AssetIn == hubAsset {
+		return params.AmountIn, true
+	}
+	if rc.tracker != nil {
+		if price, ok := rc.tracker.pairTWAP(params.AssetIn, hubAsset); ok {
+			return int64(float64(params.AmountIn) * price), true
+		}
+	}
+	if quote != nil && params.AssetOut == hubAsset {
+		return quote.AmountOut, true
+	}
+	return 0, false
+}
+
+// uniqueHops returns each pool of a route once
+func uniqueHops(hops []HopQuote) []HopQuote {
+	seen := make(map[string]bool)
+	var unique []HopQuote
+	for _, hop := range hops {
+		if !seen[hop.PoolID] {
+			seen[hop.PoolID] = true
+			unique = append(unique, hop)
+		}
+	}
+	return unique
+}
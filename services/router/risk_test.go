@@ -0,0 +1,149 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRiskTestService returns a service quoting through a price tracker that
+// has seen the HBD/HIVE pool at 4 HIVE per HBD for the last half hour
+func newRiskTestService(t *testing.T, executor DEXExecutor, config RiskConfig) (*Service, *mockPoolQuerier, *testClock) {
+	querier := &mockPoolQuerier{pools: []IndexerPoolInfo{
+		{ID: "hbd-hive", Asset0: "HBD", Asset1: "HIVE", Reserve0: 10000000, Reserve1: 40000000, Fee: 30},
+	}}
+	clock := newTestClock()
+	tracker := NewPriceTracker(querier, 30*time.Minute)
+	tracker.now = clock.now
+	_, err := tracker.GetPoolsByAsset("HBD")
+	require.NoError(t, err)
+	clock.advance(30 * time.Minute)
+
+	checker, err := NewRiskChecker(tracker, config)
+	require.NoError(t, err)
+	checker.now = clock.now
+
+	svc := NewService(VSCConfig{}, executor)
+	svc.SetPoolQuerier(tracker)
+	svc.SetRiskChecker(checker)
+	return svc, querier, clock
+}
+
+func TestRiskCheckerConfig(t *testing.T) {
+	_, err := NewRiskChecker(nil, RiskConfig{MaxDeviationBps: 100})
+	assert.ErrorContains(t, err, "requires a price tracker")
+	_, err = NewRiskChecker(nil, RiskConfig{MaxNotional: -1})
+	assert.Error(t, err)
+	_, err = NewRiskChecker(nil, RiskConfig{AccountNotional: map[string]int64{"alice": -5}})
+	assert.Error(t, err)
+
+	checker, err := NewRiskChecker(nil, RiskConfig{MaxNotional: 1000})
+	require.NoError(t, err)
+	assert.Equal(t, defaultNotionalWindow, checker.config.NotionalWindow)
+}
+
+func TestRiskRejectsManipulatedPrice(t *testing.T) {
+	executor := &mockDEXExecutor{}
+	svc, querier, _ := newRiskTestService(t, executor, RiskConfig{MaxDeviationBps: 500})
+	params := SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000}
+
+	// Reserves in line with their average pass
+	result, err := svc.ExecuteSwap(context.Background(), params)
+	require.NoError(t, err)
+	require.True(t, result.Success, result.ErrorMessage)
+	assert.Empty(t, result.Warnings)
+
+	// Reserves pushed 25% off their average are refused
+	setReserves(querier, "hbd-hive", 10000000, 50000000)
+	result, err = svc.ExecuteSwap(context.Background(), params)
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.ErrorMessage, "risk check failed")
+	assert.Contains(t, result.ErrorMessage, "pool hbd-hive prices HBD at 5 HIVE, 2500 bps from its TWAP of 4")
+	assert.Len(t, executor.executedOperations, 1, "the refused swap should not be broadcast")
+
+	// Batched swaps are checked too
+	batch := NewService(VSCConfig{}, &mockBatchExecutor{})
+	batch.SetPoolQuerier(svc.poolQuerier)
+	batch.SetRiskChecker(svc.risk)
+	batchResult, err := batch.ExecuteBatch(context.Background(), BatchParams{
+		Sender:     "alice",
+		Operations: []BatchOperation{{Swap: &params}},
+	})
+	require.NoError(t, err)
+	assert.False(t, batchResult.Success)
+	assert.Contains(t, batchResult.ErrorMessage, "operation 0: risk check failed")
+}
+
+func TestRiskWarnOnly(t *testing.T) {
+	svc, querier, _ := newRiskTestService(t, &mockDEXExecutor{}, RiskConfig{MaxDeviationBps: 500, WarnOnly: true})
+	setReserves(querier, "hbd-hive", 10000000, 50000000)
+
+	result, err := svc.ExecuteSwap(context.Background(), SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	require.Len(t, result.Warnings, 1)
+	assert.Contains(t, result.Warnings[0], "2500 bps from its TWAP")
+}
+
+func TestRiskWarnsWithoutHistory(t *testing.T) {
+	svc, querier, _ := newRiskTestService(t, &mockDEXExecutor{}, RiskConfig{MaxDeviationBps: 500})
+	querier.pools = append(querier.pools, IndexerPoolInfo{ID: "hbd-btc", Asset0: "HBD", Asset1: "BTC", Reserve0: 50000000, Reserve1: 100000, Fee: 30})
+
+	// A pool seen for the first time has no average to compare against
+	result, err := svc.ExecuteSwap(context.Background(), SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "BTC", AmountIn: 1000})
+	require.NoError(t, err)
+	assert.True(t, result.Success, result.ErrorMessage)
+	require.Len(t, result.Warnings, 1)
+	assert.Contains(t, result.Warnings[0], "not enough price history")
+}
+
+func TestRiskNotionalCap(t *testing.T) {
+	executor := &mockBatchExecutor{}
+	svc, _, clock := newRiskTestService(t, executor, RiskConfig{
+		MaxNotional:     1500,
+		AccountNotional: map[string]int64{"whale": 0, "minnow": 100},
+		NotionalWindow:  time.Hour,
+	})
+	ctx := context.Background()
+	swap := func(sender, assetIn string, amount int64) *SwapResult {
+		assetOut := "HIVE"
+		if assetIn == "HIVE" {
+			assetOut = "HBD"
+		}
+		result, err := svc.ExecuteSwap(ctx, SwapParams{Sender: sender, AssetIn: assetIn, AssetOut: assetOut, AmountIn: amount})
+		require.NoError(t, err)
+		return result
+	}
+
+	assert.True(t, swap("alice", "HBD", 1000).Success)
+
+	// HIVE is valued at the pool's average of 0.25 HBD
+	result := swap("alice", "HIVE", 4000)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.ErrorMessage, "swap of 1000 HBD notional would exceed alice's cap of 1500 HBD per 1h0m0s (1000 used)")
+	assert.True(t, swap("alice", "HIVE", 2000).Success)
+
+	// Caps are per account, and can be overridden
+	assert.True(t, swap("bob", "HBD", 1500).Success)
+	assert.True(t, swap("whale", "HBD", 1000000).Success)
+	assert.False(t, swap("minnow", "HBD", 101).Success)
+
+	// Swaps that never went out do not count
+	executor.err = errors.New("node unavailable")
+	batch, err := svc.ExecuteBatch(ctx, BatchParams{
+		Sender:     "carol",
+		Operations: []BatchOperation{{Swap: &SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1500}}},
+	})
+	require.NoError(t, err)
+	require.False(t, batch.Success)
+	assert.True(t, swap("carol", "HBD", 1500).Success)
+
+	// Usage expires with the window
+	clock.advance(time.Hour)
+	assert.True(t, swap("alice", "HBD", 1500).Success)
+}
@@ -5,13 +5,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vsc-eco/vsc-dex-mapping/schemas"
+	"github.com/vsc-eco/vsc-dex-mapping/services/router/intents"
+	"github.com/vsc-eco/vsc-dex-mapping/services/router/types"
 )
 
-// Intent represents a VSC transaction intent
-type Intent struct {
-	Type string            `json:"type"`
-	Args map[string]string `json:"args"`
-}
+// Intent represents a VSC transaction intent. Build them with the intents
+// package.
+type Intent = intents.Intent
 
 // DEXExecutor interface for executing DEX operations
 type DEXExecutor interface {
@@ -20,10 +26,50 @@ type DEXExecutor interface {
 	ExecuteDexSwap(ctx context.Context, amountOut int64, route []string, fee int64) error
 }
 
-// Service provides DEX routing and transaction composition
+// Service provides DEX routing and transaction composition. Configure it
+// with its Set methods before serving; after that its methods are safe for
+// concurrent use. Each account's broadcasts go out one at a time, in the
+// order they were requested or queued.
 type Service struct {
 	vscConfig   VSCConfig
 	dexExecutor DEXExecutor
+	poolQuerier PoolQuerier
+
+	positionQuerier PositionQuerier
+
+	outcomeSource       SwapOutcomeSource
+	outcomeTimeout      time.Duration
+	outcomePollInterval time.Duration
+
+	jobs     *jobStore
+	twaps    *twapStore
+	dca      *DCAScheduler
+	triggers *TriggerMonitor
+	swapIns  *swapInStore
+
+	mappingsMu  sync.RWMutex
+	mappings    map[string]types.MappingAdapter // Cross-chain adapters by chain
+	bridgeDelay time.Duration
+
+	routing  RoutingConfig
+	simulate bool // Build operations but never broadcast them
+
+	live     atomic.Pointer[liveConfig] // Allowlist and slippage cap; nil for none
+	reloader *ConfigReloader
+
+	commitRevealDelay time.Duration
+
+	accounts    *accountSequencer
+	idempotency *idempotencyStore
+
+	referrals *ReferralProgram
+	volumes   *VolumeTiers
+	receipts  *ReceiptStore
+	risk      *RiskChecker
+	approvals *ApprovalQueue
+	metrics   *Metrics
+
+	rateLimiter *RateLimiter
 }
 
 type VSCConfig struct {
@@ -44,14 +90,41 @@ type SwapParams struct {
 	MiddleOutRatio float64
 	Beneficiary    string
 	RefBps         uint64
+	Deadline       time.Time // Zero means no deadline
+	Route          []string  // Assets to route through, overriding the router's choice
+	CommitReveal   bool      // Commit to a hash of the swap before revealing it
+	IdempotencyKey string    // Retries with the same key return the first swap's result
+	ExcludePools   []string  // Pools never to route through
+
+	// How many other routes to fall back to when a route is refused
+	// before it is broadcast; 0 disables fallback
+	FallbackRoutes int
+
+	// Quote the swap was agreed on. Before it is broadcast the swap is
+	// re-quoted from current reserves and refused if the quoted output has
+	// dropped by more than MaxSlippage, or with Requote, moved to another
+	// route that still returns enough.
+	Quote   *Quote
+	Requote bool
+
+	// Chain address the output is withdrawn to once swapped; nil keeps it
+	// in the sender's VSC account
+	ReturnAddress *schemas.ReturnAddress
+
+	commitSalt string          // Binds the revealed payload to its commitment
+	claim      *idempotentSwap // Idempotency key already claimed by SubmitSwap
+	approved   bool            // Approved through the approval queue
+	tiered     bool            // The sender's fee tier has been applied to RefBps
+	pools      []string        // Pool IDs the contract swaps through, in order
 }
 
 // DepositParams represents a deposit request
 type DepositParams struct {
-	Sender   string
-	AssetIn  string
-	AssetOut string
-	AmountIn int64
+	Sender    string
+	AssetIn   string
+	AssetOut  string
+	AmountIn  int64
+	AmountOut int64 // Of AssetOut, deposited alongside AmountIn
 }
 
 // WithdrawalParams represents a withdrawal request
@@ -66,21 +139,369 @@ type WithdrawalParams struct {
 type SwapResult struct {
 	Success      bool
 	AmountOut    int64
-	Fee          int64
+	Fee          int64 // Charged on the first hop, in AssetIn
 	Route        []string
 	ErrorMessage string
+
+	// Pool IDs the swap was sent through, in order, when the router chose
+	// or was given them
+	Pools []string `json:",omitempty"`
+
+	// Executed swap, populated when an outcome source is configured
+	TxID    string
+	Settled bool // AmountOut, Fee, Route, Pools and HopFees reflect the executed swap
+
+	// Trade preview, populated when a pool querier is configured
+	EstimatedAmountOut int64
+	PriceImpact        float64    // % of output lost to pool depth, excluding fees
+	EffectivePrice     float64    // AssetOut received per unit of AssetIn, after fees
+	MinimumReceived    int64      // EstimatedAmountOut less MaxSlippage
+	HopFees            []HopQuote // Per-pool amounts and fees along the route
+
+	// Withdrawal of the output to SwapParams.ReturnAddress
+	WithdrawTxID string `json:",omitempty"`
+
+	// Receipt of the broadcast, when a receipt store is configured
+	ReceiptID string `json:",omitempty"`
+
+	// Risk checks that could not run, or that failed on a warn-only checker
+	Warnings []string `json:",omitempty"`
+
+	// Approval request the swap is held in until approvers sign off
+	ApprovalID string `json:",omitempty"`
+
+	// Routes that failed before the one that produced this result
+	FailedRoutes []FailedRoute `json:",omitempty"`
+
+	// What would have been broadcast, set instead of TxID when simulating
+	Simulation *Simulation `json:",omitempty"`
 }
 
 // ExecuteSwap executes a swap through the unified DEX router contract
-func (r *Service) ExecuteSwap(params SwapParams) (*SwapResult, error) {
+func (r *Service) ExecuteSwap(ctx context.Context, params SwapParams) (*SwapResult, error) {
+	if err := r.acceptSwap(params); err != nil {
+		return &SwapResult{
+			Success:      false,
+			ErrorMessage: err.Error(),
+		}, nil
+	}
+	return r.executeSwap(ctx, params, nil), nil
+}
+
+// executeSwap runs a swap once per idempotency key: a swap retried with its
+// key waits for and returns the original's result. progress, if non-nil, is
+// called as the swap is broadcast and included.
+func (r *Service) executeSwap(ctx context.Context, params SwapParams, progress func(status JobStatus, txID string)) *SwapResult {
+	if params.IdempotencyKey == "" || r.simulating(ctx) {
+		return r.runSwap(ctx, params, progress)
+	}
+
+	entry, first := params.claim, true
+	if entry == nil {
+		var err error
+		if entry, first, err = r.idempotency.begin(params); err != nil {
+			return &SwapResult{
+				Success:      false,
+				ErrorMessage: err.Error(),
+			}
+		}
+	}
+	if !first {
+		return entry.wait(ctx)
+	}
+
+	result := r.runSwap(ctx, params, progress)
+	r.idempotency.finish(params, entry, result)
+	return result
+}
+
+// runSwap builds, submits and tracks a swap, broadcasting in the sender's
+// turn so an account's swaps go out one at a time and in order
+func (r *Service) runSwap(ctx context.Context, params SwapParams, progress func(status JobStatus, txID string)) *SwapResult {
+	if progress == nil {
+		progress = func(JobStatus, string) {}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return &SwapResult{
+			Success:      false,
+			ErrorMessage: fmt.Sprintf("swap cancelled: %v", err),
+		}
+	}
+
 	// Validate input
 	if params.AssetIn == params.AssetOut {
 		return &SwapResult{
 			Success:      false,
 			ErrorMessage: "cannot swap asset to itself",
+		}
+	}
+
+	if params.ReturnAddress != nil {
+		to, err := r.checkReturnAddress(params)
+		if err != nil {
+			return &SwapResult{
+				Success:      false,
+				ErrorMessage: err.Error(),
+			}
+		}
+		if _, ok := r.dexExecutor.(ChainWithdrawer); !ok && !r.simulating(ctx) {
+			return &SwapResult{
+				Success:      false,
+				ErrorMessage: "executor cannot withdraw to other chains",
+			}
+		}
+		params.ReturnAddress = to
+	}
+
+	if params.CommitReveal {
+		salt, err := newCommitSalt()
+		if err != nil {
+			return &SwapResult{
+				Success:      false,
+				ErrorMessage: fmt.Sprintf("failed to generate commit salt: %v", err),
+			}
+		}
+		params.commitSalt = salt
+	}
+
+	if params.Quote != nil {
+		if err := r.checkQuote(ctx, &params); err != nil {
+			return &SwapResult{
+				Success:      false,
+				ErrorMessage: err.Error(),
+			}
+		}
+	}
+
+	// Estimate the trade from indexed reserves before submitting it
+	var quote *Quote
+	if r.poolQuerier != nil {
+		if q, err := r.quote(ctx, params, 0); err == nil {
+			quote = q
+		} else if len(params.Route) > 0 {
+			// Never send a chosen route that cannot be checked
+			return &SwapResult{
+				Success:      false,
+				ErrorMessage: fmt.Sprintf("invalid route: %v", err),
+			}
+		} else {
+			log.Printf("Swap preview unavailable: %v", err)
+		}
+	}
+
+	// The quoted route is sent as its pools, so the contract swaps through
+	// exactly the quoted pools in one call
+	if len(params.pools) == 0 && quote != nil {
+		params.pools = quote.pools()
+	}
+	params = r.applyFeeTier(params)
+	payload, intents, err := swapOperation(params)
+	if err != nil {
+		return &SwapResult{
+			Success:      false,
+			ErrorMessage: fmt.Sprintf("failed to marshal payload: %v", err),
+		}
+	}
+
+	// Refuse to broadcast once the deadline has passed, and stop a slow
+	// broadcast from running past it
+	submitCtx := ctx
+	if !params.Deadline.IsZero() {
+		if !time.Now().Before(params.Deadline) {
+			return &SwapResult{
+				Success:      false,
+				ErrorMessage: fmt.Sprintf("swap deadline %s has passed", params.Deadline.UTC().Format(time.RFC3339)),
+			}
+		}
+		var cancel context.CancelFunc
+		submitCtx, cancel = context.WithDeadline(ctx, params.Deadline)
+		defer cancel()
+	}
+
+	// Hold large swaps until enough approvers sign off
+	if r.approvals.required(params) && !r.simulating(ctx) {
+		request, err := r.approvals.hold(params)
+		if err != nil {
+			return &SwapResult{
+				Success:      false,
+				ErrorMessage: fmt.Sprintf("failed to hold swap for approval: %v", err),
+			}
+		}
+		result := &SwapResult{
+			Success:      false,
+			ErrorMessage: fmt.Sprintf("swap needs %d approvals before it is broadcast; held as %s", request.Required, request.ID),
+			ApprovalID:   request.ID,
+		}
+		if quote != nil {
+			quote.applyTo(result, params.MaxSlippage)
+		}
+		return result
+	}
+
+	risk := &riskAssessment{}
+	if r.risk != nil {
+		if risk, err = r.risk.assess(params, quote, !r.simulating(ctx)); err != nil {
+			return &SwapResult{
+				Success:      false,
+				ErrorMessage: err.Error(),
+			}
+		}
+	}
+
+	operations := []DexOperation{{OperationType: "execute", Payload: payload, Intents: intents}}
+	if params.CommitReveal {
+		operations = append([]DexOperation{commitOperation(payload)}, operations...)
+	}
+
+	if r.simulating(ctx) {
+		result := unsettledResult(params, quote)
+		result.Success = true
+		result.Simulation = r.simulation(operations...)
+		result.Warnings = risk.warnings
+		return result
+	}
+
+	submittedAt := time.Now()
+	result := r.broadcastSwap(ctx, submitCtx, params, payload, intents, quote, progress)
+	if !result.Success && result.TxID == "" && r.risk != nil {
+		// Nothing was traded, so nothing counts against the cap
+		r.risk.release(risk)
+	}
+	result.Warnings = risk.warnings
+	r.recordExecution(ReceiptSwap, params.Sender, operations, submittedAt, result)
+
+	if !result.Success && result.TxID == "" {
+		if fallback, ok := r.fallbackSwap(ctx, params, quote, result, progress); ok {
+			return fallback
+		}
+	}
+	return result
+}
+
+// unsettledResult is a swap's result until its outcome is known. A quoted
+// swap reports the quoted output and route. Without a quote the router
+// cannot tell what the contract will return or which route it will pick,
+// so it reports the minimum output and only a route it was given.
+func unsettledResult(params SwapParams, quote *Quote) *SwapResult {
+	result := &SwapResult{
+		AmountOut: params.MinAmountOut,
+		Route:     append([]string(nil), params.Route...),
+		Pools:     append([]string(nil), params.pools...),
+	}
+	if quote != nil {
+		quote.applyTo(result, params.MaxSlippage)
+		result.AmountOut = quote.AmountOut
+	}
+	return result
+}
+
+// broadcastSwap submits a built swap, bounded by submitCtx, then tracks its
+// outcome and any withdrawal of its output
+func (r *Service) broadcastSwap(ctx, submitCtx context.Context, params SwapParams, payload string, intents []Intent, quote *Quote, progress func(status JobStatus, txID string)) *SwapResult {
+	// Execute through DEX executor with intents, after committing to the
+	// swap if it should stay hidden until included
+	var txID string
+	var err error
+	if params.CommitReveal {
+		txID, err = r.commitAndReveal(submitCtx, params.Sender, payload, intents)
+	} else {
+		txID, err = r.submit(submitCtx, params.Sender, payload, intents)
+	}
+	if err != nil {
+		return &SwapResult{
+			Success:      false,
+			ErrorMessage: fmt.Sprintf("swap execution failed: %v", err),
+		}
+	}
+
+	progress(JobBroadcast, txID)
+
+	result := unsettledResult(params, quote)
+	result.Success = true
+	result.TxID = txID
+	if quote != nil {
+		r.invalidateRoute(quote)
+	}
+
+	if txID != "" && r.outcomeSource != nil {
+		if outcome, err := r.awaitOutcome(ctx, txID); err == nil {
+			progress(JobIncluded, txID)
+			r.applyOutcome(result, outcome, quote)
+		} else {
+			log.Printf("Swap %s outcome unavailable: %v", txID, err)
+		}
+	}
+	if r.referrals != nil {
+		r.referrals.record(params, result)
+	}
+	r.recordVolume(params, result)
+
+	if params.ReturnAddress != nil {
+		withdrawTxID, err := r.withdrawOutput(ctx, params, result)
+		if err != nil {
+			result.Success = false
+			result.ErrorMessage = fmt.Sprintf("swap succeeded but withdrawal to %s failed, leaving the output in %s's account: %v", params.ReturnAddress.Chain, params.Sender, err)
+			return result
+		}
+		result.WithdrawTxID = withdrawTxID
+	}
+	return result
+}
+
+// ExecuteDeposit executes a liquidity deposit
+func (s *Service) ExecuteDeposit(ctx context.Context, params DepositParams) (*SwapResult, error) {
+	if err := ctx.Err(); err != nil {
+		return &SwapResult{
+			Success:      false,
+			ErrorMessage: fmt.Sprintf("deposit cancelled: %v", err),
+		}, nil
+	}
+
+	if err := s.checkAssets(params.AssetIn, params.AssetOut); err != nil {
+		return &SwapResult{
+			Success:      false,
+			ErrorMessage: err.Error(),
 		}, nil
 	}
 
+	payload, intents, err := depositOperation(params, s.depositPool(params))
+	if err != nil {
+		return &SwapResult{
+			Success:      false,
+			ErrorMessage: fmt.Sprintf("failed to marshal deposit payload: %v", err),
+		}, nil
+	}
+
+	if s.simulating(ctx) {
+		return &SwapResult{
+			Success:    true,
+			Route:      []string{"deposit"},
+			Simulation: s.simulation(DexOperation{OperationType: "execute", Payload: payload, Intents: intents}),
+		}, nil
+	}
+
+	submittedAt := time.Now()
+	result := &SwapResult{
+		Success: true,
+		Route:   []string{"deposit"},
+	}
+	err = s.inTurn(ctx, params.Sender, func() error {
+		return s.dexExecutor.ExecuteDexOperationWithIntents(ctx, "execute", payload, intents)
+	})
+	if err != nil {
+		result = &SwapResult{
+			Success:      false,
+			ErrorMessage: fmt.Sprintf("deposit execution failed: %v", err),
+		}
+	}
+
+	s.recordExecution(ReceiptDeposit, params.Sender, []DexOperation{{OperationType: "execute", Payload: payload, Intents: intents}}, submittedAt, result)
+	return result, nil
+}
+
+// swapOperation builds the contract payload and intents for a swap
+func swapOperation(params SwapParams) (string, []Intent, error) {
 	// Construct JSON payload according to schema
 	payload := map[string]interface{}{
 		"type":           "swap",
@@ -101,47 +522,44 @@ func (r *Service) ExecuteSwap(params SwapParams) (*SwapResult, error) {
 	if params.RefBps > 0 {
 		payload["ref_bps"] = int(params.RefBps)
 	}
+	if !params.Deadline.IsZero() {
+		payload["deadline"] = params.Deadline.Unix()
+	}
+	payload["amount_in"] = params.AmountIn
+	if len(params.pools) > 0 {
+		payload["route"] = params.pools
+	}
+	if params.ReturnAddress != nil {
+		payload["return_address"] = params.ReturnAddress
+	}
+	metadata := make(map[string]string)
+	if len(params.Route) > 0 {
+		metadata["route"] = strings.Join(params.Route, ",")
+	}
+	if params.commitSalt != "" {
+		metadata["commit_salt"] = params.commitSalt
+	}
+	if len(metadata) > 0 {
+		payload["metadata"] = metadata
+	}
 
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		return &SwapResult{
-			Success:      false,
-			ErrorMessage: fmt.Sprintf("failed to marshal payload: %v", err),
-		}, nil
+		return "", nil, err
 	}
 
-	// Create intents for the swap operation
-	// Allow transfer of input asset up to the estimated input amount
-	intents := []Intent{
-		{
-			Type: "transfer.allow",
-			Args: map[string]string{
-				"limit": fmt.Sprintf("%d", params.AmountIn), // Maximum input amount
-				"token": params.AssetIn,
-			},
-		},
-	}
-
-	// Execute through DEX executor with intents
-	err = r.dexExecutor.ExecuteDexOperationWithIntents(context.Background(), "execute", string(payloadBytes), intents)
+	// Allow transfer of the input asset up to the input amount
+	swapIntents, err := intents.Build(intents.TransferAllow{Token: params.AssetIn, Limit: uint64(params.AmountIn)})
 	if err != nil {
-		return &SwapResult{
-			Success:      false,
-			ErrorMessage: fmt.Sprintf("swap execution failed: %v", err),
-		}, nil
+		return "", nil, err
 	}
-
-	// For now, return success - in practice, we'd parse the contract response
-	// The contract would need to return the actual swap result
-	return &SwapResult{
-		Success:   true,
-		AmountOut: params.MinAmountOut, // Placeholder - would come from contract
-		Route:     []string{"direct"},
-	}, nil
+	return string(payloadBytes), swapIntents, nil
 }
 
-// ExecuteDeposit executes a liquidity deposit
-func (s *Service) ExecuteDeposit(params DepositParams) (*SwapResult, error) {
+// depositOperation builds the contract payload and intents for a deposit.
+// The contract takes amounts in pool order, so they are only included when
+// the pool is known.
+func depositOperation(params DepositParams, pool *IndexerPoolInfo) (string, []Intent, error) {
 	// Construct JSON payload for deposit
 	payload := map[string]interface{}{
 		"type":      "deposit",
@@ -149,45 +567,109 @@ func (s *Service) ExecuteDeposit(params DepositParams) (*SwapResult, error) {
 		"asset_in":  params.AssetIn,
 		"asset_out": params.AssetOut,
 		"recipient": params.Sender,
-		// Additional deposit parameters would go here
+	}
+	if pool != nil {
+		amount0, amount1 := params.AmountIn, params.AmountOut
+		if pool.Asset0 != params.AssetIn {
+			amount0, amount1 = amount1, amount0
+		}
+		payload["metadata"] = map[string]string{
+			"amount0": fmt.Sprintf("%d", amount0),
+			"amount1": fmt.Sprintf("%d", amount1),
+		}
 	}
 
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
+		return "", nil, err
+	}
+
+	// Allow transfer of each asset being deposited
+	allowances := []intents.Builder{intents.TransferAllow{Token: params.AssetIn, Limit: uint64(params.AmountIn)}}
+	if params.AmountOut > 0 {
+		allowances = append(allowances, intents.TransferAllow{Token: params.AssetOut, Limit: uint64(params.AmountOut)})
+	}
+	depositIntents, err := intents.Build(allowances...)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(payloadBytes), depositIntents, nil
+}
+
+// depositPool returns the pool a deposit goes into, or nil if it cannot be
+// looked up
+func (s *Service) depositPool(params DepositParams) *IndexerPoolInfo {
+	if s.poolQuerier == nil {
+		return nil
+	}
+	pool, err := s.findPool(params.AssetIn, params.AssetOut)
+	if err != nil {
+		log.Printf("Deposit pool unavailable: %v", err)
+		return nil
+	}
+	return pool
+}
+
+// ExecuteWithdrawal executes a liquidity withdrawal
+func (s *Service) ExecuteWithdrawal(ctx context.Context, params WithdrawalParams) (*SwapResult, error) {
+	if err := ctx.Err(); err != nil {
 		return &SwapResult{
 			Success:      false,
-			ErrorMessage: fmt.Sprintf("failed to marshal deposit payload: %v", err),
+			ErrorMessage: fmt.Sprintf("withdrawal cancelled: %v", err),
 		}, nil
 	}
 
-	// Create intents for the deposit operation
-	// Allow transfer of input asset (the amount being deposited)
-	intents := []Intent{
-		{
-			Type: "transfer.allow",
-			Args: map[string]string{
-				"limit": fmt.Sprintf("%d", params.AmountIn),
-				"token": params.AssetIn,
-			},
-		},
+	// Size the withdrawal from the sender's position so the allowances cover
+	// only what it can return
+	plan, err := s.planWithdrawal(params)
+	if err != nil {
+		return &SwapResult{
+			Success:      false,
+			ErrorMessage: fmt.Sprintf("failed to size withdrawal: %v", err),
+		}, nil
+	}
+	if plan.PoolID == "" {
+		log.Printf("Withdrawal for %s not sized; no pool querier configured", params.Sender)
 	}
 
-	err = s.dexExecutor.ExecuteDexOperationWithIntents(context.Background(), "execute", string(payloadBytes), intents)
+	payload, intents, err := withdrawalOperation(params, plan)
 	if err != nil {
 		return &SwapResult{
 			Success:      false,
-			ErrorMessage: fmt.Sprintf("deposit execution failed: %v", err),
+			ErrorMessage: fmt.Sprintf("failed to marshal withdrawal payload: %v", err),
+		}, nil
+	}
+
+	if s.simulating(ctx) {
+		return &SwapResult{
+			Success:    true,
+			Route:      []string{"withdrawal"},
+			Simulation: s.simulation(DexOperation{OperationType: "execute", Payload: payload, Intents: intents}),
 		}, nil
 	}
 
-	return &SwapResult{
+	submittedAt := time.Now()
+	result := &SwapResult{
 		Success: true,
-		Route:   []string{"deposit"},
-	}, nil
+		Route:   []string{"withdrawal"},
+	}
+	err = s.inTurn(ctx, params.Sender, func() error {
+		return s.dexExecutor.ExecuteDexOperationWithIntents(ctx, "execute", payload, intents)
+	})
+	if err != nil {
+		result = &SwapResult{
+			Success:      false,
+			ErrorMessage: fmt.Sprintf("withdrawal execution failed: %v", err),
+		}
+	}
+
+	s.recordExecution(ReceiptWithdrawal, params.Sender, []DexOperation{{OperationType: "execute", Payload: payload, Intents: intents}}, submittedAt, result)
+	return result, nil
 }
 
-// ExecuteWithdrawal executes a liquidity withdrawal
-func (s *Service) ExecuteWithdrawal(params WithdrawalParams) (*SwapResult, error) {
+// withdrawalOperation builds the contract payload and intents for a sized
+// withdrawal
+func withdrawalOperation(params WithdrawalParams, plan *withdrawalPlan) (string, []Intent, error) {
 	// Construct JSON payload for withdrawal
 	payload := map[string]interface{}{
 		"type":      "withdrawal",
@@ -195,50 +677,26 @@ func (s *Service) ExecuteWithdrawal(params WithdrawalParams) (*SwapResult, error
 		"asset_in":  params.AssetIn,
 		"asset_out": params.AssetOut,
 		"recipient": params.Sender,
-		// Additional withdrawal parameters would go here
+		"metadata": map[string]string{
+			"lp_amount": fmt.Sprintf("%d", plan.LpAmount),
+		},
 	}
 
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		return &SwapResult{
-			Success:      false,
-			ErrorMessage: fmt.Sprintf("failed to marshal withdrawal payload: %v", err),
-		}, nil
+		return "", nil, err
 	}
 
-	// Create intents for the withdrawal operation
-	// For withdrawals, we need to allow the contract to transfer underlying assets back
-	// Since the exact amounts are determined by the contract, we use a broad allowance
-	// In practice, this might need to be calculated based on expected withdrawal amounts
-	intents := []Intent{
-		{
-			Type: "transfer.allow",
-			Args: map[string]string{
-				"limit": "1000000000", // Large limit - would be calculated based on position
-				"token": params.AssetIn,
-			},
-		},
-		{
-			Type: "transfer.allow",
-			Args: map[string]string{
-				"limit": "1000000000", // Large limit - would be calculated based on position
-				"token": params.AssetOut,
-			},
-		},
-	}
-
-	err = s.dexExecutor.ExecuteDexOperationWithIntents(context.Background(), "execute", string(payloadBytes), intents)
+	// Allow the contract to transfer back each asset's share of the pool,
+	// plus a small buffer for reserves moving before execution
+	withdrawalIntents, err := intents.Build(
+		intents.TransferAllow{Token: params.AssetIn, Limit: plan.LimitIn},
+		intents.TransferAllow{Token: params.AssetOut, Limit: plan.LimitOut},
+	)
 	if err != nil {
-		return &SwapResult{
-			Success:      false,
-			ErrorMessage: fmt.Sprintf("withdrawal execution failed: %v", err),
-		}, nil
+		return "", nil, err
 	}
-
-	return &SwapResult{
-		Success: true,
-		Route:   []string{"withdrawal"},
-	}, nil
+	return string(payloadBytes), withdrawalIntents, nil
 }
 
 // NewService creates a new router service
@@ -246,12 +704,21 @@ func NewService(config VSCConfig, dexExecutor DEXExecutor) *Service {
 	return &Service{
 		vscConfig:   config,
 		dexExecutor: dexExecutor,
+		jobs:        newJobStore(),
+		twaps:       newTWAPStore(),
+		swapIns:     newSwapInStore(),
+		routing:     DefaultRoutingConfig(),
+		bridgeDelay: defaultBridgeDelay,
+
+		commitRevealDelay: defaultCommitRevealDelay,
+		accounts:          newAccountSequencer(),
+		idempotency:       newIdempotencyStore(),
 	}
 }
 
 // ComputeRoute finds the optimal route for a swap (external API method)
 func (s *Service) ComputeRoute(ctx context.Context, params SwapParams) (*SwapResult, error) {
-	return s.ExecuteSwap(params)
+	return s.ExecuteSwap(ctx, params)
 }
 
 // ExecuteTransaction composes and submits the swap transaction
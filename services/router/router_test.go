@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -66,11 +67,11 @@ func TestExecuteSwap(t *testing.T) {
 		RefBps:       25, // 0.25% referral
 	}
 
-	result, err := svc.ExecuteSwap(params)
+	result, err := svc.ExecuteSwap(context.Background(), params)
 
 	require.NoError(t, err)
 	assert.True(t, result.Success)
-	assert.Equal(t, []string{"direct"}, result.Route)
+	assert.Nil(t, result.Route, "without a quote the route is the contract's choice")
 
 	// Verify the JSON payload was constructed correctly
 	require.Len(t, mockExecutor.executedOperations, 1)
@@ -110,7 +111,7 @@ func TestExecuteDeposit(t *testing.T) {
 		Sender:   "test-user",
 	}
 
-	result, err := svc.ExecuteDeposit(params)
+	result, err := svc.ExecuteDeposit(context.Background(), params)
 
 	require.NoError(t, err)
 	assert.True(t, result.Success)
@@ -146,7 +147,7 @@ func TestExecuteWithdrawal(t *testing.T) {
 		Sender:   "test-user",
 	}
 
-	result, err := svc.ExecuteWithdrawal(params)
+	result, err := svc.ExecuteWithdrawal(context.Background(), params)
 
 	require.NoError(t, err)
 	assert.True(t, result.Success)
@@ -183,12 +184,105 @@ func TestSwapValidation(t *testing.T) {
 		Sender:       "test-user",
 	}
 
-	result, err := svc.ExecuteSwap(params)
+	result, err := svc.ExecuteSwap(context.Background(), params)
 	require.NoError(t, err)
 	assert.False(t, result.Success)
 	assert.Contains(t, result.ErrorMessage, "cannot swap asset to itself")
 }
 
+func TestSwapDeadline(t *testing.T) {
+	mockExecutor := &mockDEXExecutor{}
+	svc := NewService(VSCConfig{DexRouterContract: "dex-router-contract"}, mockExecutor)
+
+	// An expired deadline is refused without broadcasting
+	params := SwapParams{
+		AssetIn:      "HBD",
+		AssetOut:     "HIVE",
+		AmountIn:     1000000,
+		MinAmountOut: 900000,
+		Sender:       "test-user",
+		Deadline:     time.Now().Add(-time.Minute),
+	}
+
+	result, err := svc.ExecuteSwap(context.Background(), params)
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.ErrorMessage, "deadline")
+	assert.Empty(t, mockExecutor.executedOperations)
+
+	_, err = svc.SubmitSwap(params)
+	assert.ErrorContains(t, err, "deadline")
+
+	// A future deadline is encoded into the instruction
+	params.Deadline = time.Unix(time.Now().Add(time.Minute).Unix(), 0)
+	result, err = svc.ExecuteSwap(context.Background(), params)
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	require.Len(t, mockExecutor.executedOperations, 1)
+
+	var instruction map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(mockExecutor.executedOperations[0], "execute:")), &instruction))
+	assert.Equal(t, float64(params.Deadline.Unix()), instruction["deadline"])
+}
+
+// ctxRecordingExecutor records the context each operation was submitted with
+type ctxRecordingExecutor struct {
+	mockDEXExecutor
+	contexts []context.Context
+}
+
+func (m *ctxRecordingExecutor) ExecuteDexOperationWithIntents(ctx context.Context, operationType string, payload string, intents []Intent) error {
+	m.contexts = append(m.contexts, ctx)
+	return m.mockDEXExecutor.ExecuteDexOperationWithIntents(ctx, operationType, payload, intents)
+}
+
+func TestExecuteRespectsContext(t *testing.T) {
+	executor := &ctxRecordingExecutor{}
+	svc := NewService(VSCConfig{DexRouterContract: "dex-router-contract"}, executor)
+
+	swap := SwapParams{Sender: "test-user", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000}
+	deposit := DepositParams{Sender: "test-user", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000}
+	withdrawal := WithdrawalParams{Sender: "test-user", AssetIn: "HBD", AssetOut: "HIVE", LpAmount: 1000}
+
+	// The caller's context reaches the executor
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	_, err := svc.ExecuteSwap(ctx, swap)
+	require.NoError(t, err)
+	_, err = svc.ExecuteDeposit(ctx, deposit)
+	require.NoError(t, err)
+	_, err = svc.ExecuteWithdrawal(ctx, withdrawal)
+	require.NoError(t, err)
+
+	require.Len(t, executor.contexts, 3)
+	for _, got := range executor.contexts {
+		deadline, ok := got.Deadline()
+		assert.True(t, ok)
+		want, _ := ctx.Deadline()
+		assert.Equal(t, want, deadline)
+	}
+
+	// A cancelled context stops the operation before anything is broadcast
+	cancelled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+
+	result, err := svc.ExecuteSwap(cancelled, swap)
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.ErrorMessage, "swap cancelled")
+
+	result, err = svc.ExecuteDeposit(cancelled, deposit)
+	require.NoError(t, err)
+	assert.Contains(t, result.ErrorMessage, "deposit cancelled")
+
+	result, err = svc.ExecuteWithdrawal(cancelled, withdrawal)
+	require.NoError(t, err)
+	assert.Contains(t, result.ErrorMessage, "withdrawal cancelled")
+
+	assert.Len(t, executor.contexts, 3)
+}
+
 func TestServiceCreation(t *testing.T) {
 	config := VSCConfig{
 		Endpoint:          "http://localhost:4000",
@@ -0,0 +1,1339 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        v5.27.1
+// source: router.proto
+
+// gRPC interface to the router service, for programmatic traders and internal
+// services that want lower overhead than the HTTP API. Amounts are integers in
+// the asset's smallest unit; slippage is in basis points.
+
+package routerpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SwapStatus_Status int32
+
+const (
+	SwapStatus_STATUS_UNSPECIFIED SwapStatus_Status = 0
+	SwapStatus_STATUS_QUEUED      SwapStatus_Status = 1
+	SwapStatus_STATUS_BROADCAST   SwapStatus_Status = 2
+	SwapStatus_STATUS_INCLUDED    SwapStatus_Status = 3
+	SwapStatus_STATUS_CONFIRMED   SwapStatus_Status = 4
+	SwapStatus_STATUS_FAILED      SwapStatus_Status = 5
+)
+
+// Enum value maps for SwapStatus_Status.
+var (
+	SwapStatus_Status_name = map[int32]string{
+		0: "STATUS_UNSPECIFIED",
+		1: "STATUS_QUEUED",
+		2: "STATUS_BROADCAST",
+		3: "STATUS_INCLUDED",
+		4: "STATUS_CONFIRMED",
+		5: "STATUS_FAILED",
+	}
+	SwapStatus_Status_value = map[string]int32{
+		"STATUS_UNSPECIFIED": 0,
+		"STATUS_QUEUED":      1,
+		"STATUS_BROADCAST":   2,
+		"STATUS_INCLUDED":    3,
+		"STATUS_CONFIRMED":   4,
+		"STATUS_FAILED":      5,
+	}
+)
+
+func (x SwapStatus_Status) Enum() *SwapStatus_Status {
+	p := new(SwapStatus_Status)
+	*p = x
+	return p
+}
+
+func (x SwapStatus_Status) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SwapStatus_Status) Descriptor() protoreflect.EnumDescriptor {
+	return file_router_proto_enumTypes[0].Descriptor()
+}
+
+func (SwapStatus_Status) Type() protoreflect.EnumType {
+	return &file_router_proto_enumTypes[0]
+}
+
+func (x SwapStatus_Status) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SwapStatus_Status.Descriptor instead.
+func (SwapStatus_Status) EnumDescriptor() ([]byte, []int) {
+	return file_router_proto_rawDescGZIP(), []int{6, 0}
+}
+
+type SwapRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FromAsset      string         `protobuf:"bytes,1,opt,name=from_asset,json=fromAsset,proto3" json:"from_asset,omitempty"`
+	ToAsset        string         `protobuf:"bytes,2,opt,name=to_asset,json=toAsset,proto3" json:"to_asset,omitempty"`
+	Amount         int64          `protobuf:"varint,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	MinOut         int64          `protobuf:"varint,4,opt,name=min_out,json=minOut,proto3" json:"min_out,omitempty"`
+	SlippageBps    uint64         `protobuf:"varint,5,opt,name=slippage_bps,json=slippageBps,proto3" json:"slippage_bps,omitempty"` // Defaults to 50
+	Sender         string         `protobuf:"bytes,6,opt,name=sender,proto3" json:"sender,omitempty"`
+	Deadline       int64          `protobuf:"varint,7,opt,name=deadline,proto3" json:"deadline,omitempty"`                                    // Unix seconds; zero means no deadline
+	CommitReveal   bool           `protobuf:"varint,8,opt,name=commit_reveal,json=commitReveal,proto3" json:"commit_reveal,omitempty"`        // Commit to a hash of the swap before revealing it
+	IdempotencyKey string         `protobuf:"bytes,9,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`   // Retries with the same key return the first swap's result
+	FallbackRoutes int32          `protobuf:"varint,10,opt,name=fallback_routes,json=fallbackRoutes,proto3" json:"fallback_routes,omitempty"` // Other routes to try if the route is refused before broadcast
+	ExcludePools   []string       `protobuf:"bytes,11,rep,name=exclude_pools,json=excludePools,proto3" json:"exclude_pools,omitempty"`        // Pools never to route through
+	Quote          *QuoteResponse `protobuf:"bytes,12,opt,name=quote,proto3" json:"quote,omitempty"`                                          // Quote the swap was agreed on; refused if reserves have moved beyond slippage
+	Requote        bool           `protobuf:"varint,13,opt,name=requote,proto3" json:"requote,omitempty"`                                     // Move a stale quote to a route that still returns enough
+}
+
+func (x *SwapRequest) Reset() {
+	*x = SwapRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_router_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SwapRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SwapRequest) ProtoMessage() {}
+
+func (x *SwapRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_router_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SwapRequest.ProtoReflect.Descriptor instead.
+func (*SwapRequest) Descriptor() ([]byte, []int) {
+	return file_router_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SwapRequest) GetFromAsset() string {
+	if x != nil {
+		return x.FromAsset
+	}
+	return ""
+}
+
+func (x *SwapRequest) GetToAsset() string {
+	if x != nil {
+		return x.ToAsset
+	}
+	return ""
+}
+
+func (x *SwapRequest) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *SwapRequest) GetMinOut() int64 {
+	if x != nil {
+		return x.MinOut
+	}
+	return 0
+}
+
+func (x *SwapRequest) GetSlippageBps() uint64 {
+	if x != nil {
+		return x.SlippageBps
+	}
+	return 0
+}
+
+func (x *SwapRequest) GetSender() string {
+	if x != nil {
+		return x.Sender
+	}
+	return ""
+}
+
+func (x *SwapRequest) GetDeadline() int64 {
+	if x != nil {
+		return x.Deadline
+	}
+	return 0
+}
+
+func (x *SwapRequest) GetCommitReveal() bool {
+	if x != nil {
+		return x.CommitReveal
+	}
+	return false
+}
+
+func (x *SwapRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *SwapRequest) GetFallbackRoutes() int32 {
+	if x != nil {
+		return x.FallbackRoutes
+	}
+	return 0
+}
+
+func (x *SwapRequest) GetExcludePools() []string {
+	if x != nil {
+		return x.ExcludePools
+	}
+	return nil
+}
+
+func (x *SwapRequest) GetQuote() *QuoteResponse {
+	if x != nil {
+		return x.Quote
+	}
+	return nil
+}
+
+func (x *SwapRequest) GetRequote() bool {
+	if x != nil {
+		return x.Requote
+	}
+	return false
+}
+
+type Hop struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PoolId     string `protobuf:"bytes,1,opt,name=pool_id,json=poolId,proto3" json:"pool_id,omitempty"`
+	AssetIn    string `protobuf:"bytes,2,opt,name=asset_in,json=assetIn,proto3" json:"asset_in,omitempty"`
+	AssetOut   string `protobuf:"bytes,3,opt,name=asset_out,json=assetOut,proto3" json:"asset_out,omitempty"`
+	AmountIn   int64  `protobuf:"varint,4,opt,name=amount_in,json=amountIn,proto3" json:"amount_in,omitempty"`
+	AmountOut  int64  `protobuf:"varint,5,opt,name=amount_out,json=amountOut,proto3" json:"amount_out,omitempty"`
+	FeeBps     uint64 `protobuf:"varint,6,opt,name=fee_bps,json=feeBps,proto3" json:"fee_bps,omitempty"`
+	Fee        int64  `protobuf:"varint,7,opt,name=fee,proto3" json:"fee,omitempty"`                              // Charged in asset_in
+	ReserveIn  uint64 `protobuf:"varint,8,opt,name=reserve_in,json=reserveIn,proto3" json:"reserve_in,omitempty"` // Pool reserves the hop was quoted from
+	ReserveOut uint64 `protobuf:"varint,9,opt,name=reserve_out,json=reserveOut,proto3" json:"reserve_out,omitempty"`
+}
+
+func (x *Hop) Reset() {
+	*x = Hop{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_router_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Hop) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Hop) ProtoMessage() {}
+
+func (x *Hop) ProtoReflect() protoreflect.Message {
+	mi := &file_router_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Hop.ProtoReflect.Descriptor instead.
+func (*Hop) Descriptor() ([]byte, []int) {
+	return file_router_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Hop) GetPoolId() string {
+	if x != nil {
+		return x.PoolId
+	}
+	return ""
+}
+
+func (x *Hop) GetAssetIn() string {
+	if x != nil {
+		return x.AssetIn
+	}
+	return ""
+}
+
+func (x *Hop) GetAssetOut() string {
+	if x != nil {
+		return x.AssetOut
+	}
+	return ""
+}
+
+func (x *Hop) GetAmountIn() int64 {
+	if x != nil {
+		return x.AmountIn
+	}
+	return 0
+}
+
+func (x *Hop) GetAmountOut() int64 {
+	if x != nil {
+		return x.AmountOut
+	}
+	return 0
+}
+
+func (x *Hop) GetFeeBps() uint64 {
+	if x != nil {
+		return x.FeeBps
+	}
+	return 0
+}
+
+func (x *Hop) GetFee() int64 {
+	if x != nil {
+		return x.Fee
+	}
+	return 0
+}
+
+func (x *Hop) GetReserveIn() uint64 {
+	if x != nil {
+		return x.ReserveIn
+	}
+	return 0
+}
+
+func (x *Hop) GetReserveOut() uint64 {
+	if x != nil {
+		return x.ReserveOut
+	}
+	return 0
+}
+
+type QuoteResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	AssetIn         string     `protobuf:"bytes,1,opt,name=asset_in,json=assetIn,proto3" json:"asset_in,omitempty"`
+	AssetOut        string     `protobuf:"bytes,2,opt,name=asset_out,json=assetOut,proto3" json:"asset_out,omitempty"`
+	AmountIn        int64      `protobuf:"varint,3,opt,name=amount_in,json=amountIn,proto3" json:"amount_in,omitempty"`
+	AmountOut       int64      `protobuf:"varint,4,opt,name=amount_out,json=amountOut,proto3" json:"amount_out,omitempty"`
+	Route           []string   `protobuf:"bytes,5,rep,name=route,proto3" json:"route,omitempty"`
+	Hops            []*Hop     `protobuf:"bytes,6,rep,name=hops,proto3" json:"hops,omitempty"`
+	PriceImpact     float64    `protobuf:"fixed64,7,opt,name=price_impact,json=priceImpact,proto3" json:"price_impact,omitempty"`
+	MinimumReceived int64      `protobuf:"varint,8,opt,name=minimum_received,json=minimumReceived,proto3" json:"minimum_received,omitempty"` // amount_out less the requested slippage
+	Cost            *RouteCost `protobuf:"bytes,9,opt,name=cost,proto3" json:"cost,omitempty"`                                               // Set when route costs are configured
+	NetAmountOut    int64      `protobuf:"varint,10,opt,name=net_amount_out,json=netAmountOut,proto3" json:"net_amount_out,omitempty"`       // amount_out less the route's cost
+}
+
+func (x *QuoteResponse) Reset() {
+	*x = QuoteResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_router_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *QuoteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuoteResponse) ProtoMessage() {}
+
+func (x *QuoteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_router_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuoteResponse.ProtoReflect.Descriptor instead.
+func (*QuoteResponse) Descriptor() ([]byte, []int) {
+	return file_router_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *QuoteResponse) GetAssetIn() string {
+	if x != nil {
+		return x.AssetIn
+	}
+	return ""
+}
+
+func (x *QuoteResponse) GetAssetOut() string {
+	if x != nil {
+		return x.AssetOut
+	}
+	return ""
+}
+
+func (x *QuoteResponse) GetAmountIn() int64 {
+	if x != nil {
+		return x.AmountIn
+	}
+	return 0
+}
+
+func (x *QuoteResponse) GetAmountOut() int64 {
+	if x != nil {
+		return x.AmountOut
+	}
+	return 0
+}
+
+func (x *QuoteResponse) GetRoute() []string {
+	if x != nil {
+		return x.Route
+	}
+	return nil
+}
+
+func (x *QuoteResponse) GetHops() []*Hop {
+	if x != nil {
+		return x.Hops
+	}
+	return nil
+}
+
+func (x *QuoteResponse) GetPriceImpact() float64 {
+	if x != nil {
+		return x.PriceImpact
+	}
+	return 0
+}
+
+func (x *QuoteResponse) GetMinimumReceived() int64 {
+	if x != nil {
+		return x.MinimumReceived
+	}
+	return 0
+}
+
+func (x *QuoteResponse) GetCost() *RouteCost {
+	if x != nil {
+		return x.Cost
+	}
+	return nil
+}
+
+func (x *QuoteResponse) GetNetAmountOut() int64 {
+	if x != nil {
+		return x.NetAmountOut
+	}
+	return 0
+}
+
+// RouteCost is the estimated resource credit cost of broadcasting a route
+type RouteCost struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Hbd       int64 `protobuf:"varint,1,opt,name=hbd,proto3" json:"hbd,omitempty"`                              // Valued in HBD
+	AmountOut int64 `protobuf:"varint,2,opt,name=amount_out,json=amountOut,proto3" json:"amount_out,omitempty"` // Valued in the output asset; zero if it cannot be priced
+}
+
+func (x *RouteCost) Reset() {
+	*x = RouteCost{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_router_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RouteCost) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RouteCost) ProtoMessage() {}
+
+func (x *RouteCost) ProtoReflect() protoreflect.Message {
+	mi := &file_router_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RouteCost.ProtoReflect.Descriptor instead.
+func (*RouteCost) Descriptor() ([]byte, []int) {
+	return file_router_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *RouteCost) GetHbd() int64 {
+	if x != nil {
+		return x.Hbd
+	}
+	return 0
+}
+
+func (x *RouteCost) GetAmountOut() int64 {
+	if x != nil {
+		return x.AmountOut
+	}
+	return 0
+}
+
+type ExecutionResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Success            bool     `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	AmountOut          int64    `protobuf:"varint,2,opt,name=amount_out,json=amountOut,proto3" json:"amount_out,omitempty"`
+	Fee                int64    `protobuf:"varint,3,opt,name=fee,proto3" json:"fee,omitempty"`
+	Route              []string `protobuf:"bytes,4,rep,name=route,proto3" json:"route,omitempty"`
+	ErrorMessage       string   `protobuf:"bytes,5,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	TxId               string   `protobuf:"bytes,6,opt,name=tx_id,json=txId,proto3" json:"tx_id,omitempty"`
+	Settled            bool     `protobuf:"varint,7,opt,name=settled,proto3" json:"settled,omitempty"`
+	EstimatedAmountOut int64    `protobuf:"varint,8,opt,name=estimated_amount_out,json=estimatedAmountOut,proto3" json:"estimated_amount_out,omitempty"`
+	PriceImpact        float64  `protobuf:"fixed64,9,opt,name=price_impact,json=priceImpact,proto3" json:"price_impact,omitempty"`
+	EffectivePrice     float64  `protobuf:"fixed64,10,opt,name=effective_price,json=effectivePrice,proto3" json:"effective_price,omitempty"`
+	MinimumReceived    int64    `protobuf:"varint,11,opt,name=minimum_received,json=minimumReceived,proto3" json:"minimum_received,omitempty"`
+	HopFees            []*Hop   `protobuf:"bytes,12,rep,name=hop_fees,json=hopFees,proto3" json:"hop_fees,omitempty"`
+	Warnings           []string `protobuf:"bytes,13,rep,name=warnings,proto3" json:"warnings,omitempty"`                       // Risk checks that could not run, or failed without rejecting the swap
+	ApprovalId         string   `protobuf:"bytes,14,opt,name=approval_id,json=approvalId,proto3" json:"approval_id,omitempty"` // Set when the swap is held until approvers sign off
+}
+
+func (x *ExecutionResult) Reset() {
+	*x = ExecutionResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_router_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExecutionResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecutionResult) ProtoMessage() {}
+
+func (x *ExecutionResult) ProtoReflect() protoreflect.Message {
+	mi := &file_router_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecutionResult.ProtoReflect.Descriptor instead.
+func (*ExecutionResult) Descriptor() ([]byte, []int) {
+	return file_router_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ExecutionResult) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ExecutionResult) GetAmountOut() int64 {
+	if x != nil {
+		return x.AmountOut
+	}
+	return 0
+}
+
+func (x *ExecutionResult) GetFee() int64 {
+	if x != nil {
+		return x.Fee
+	}
+	return 0
+}
+
+func (x *ExecutionResult) GetRoute() []string {
+	if x != nil {
+		return x.Route
+	}
+	return nil
+}
+
+func (x *ExecutionResult) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *ExecutionResult) GetTxId() string {
+	if x != nil {
+		return x.TxId
+	}
+	return ""
+}
+
+func (x *ExecutionResult) GetSettled() bool {
+	if x != nil {
+		return x.Settled
+	}
+	return false
+}
+
+func (x *ExecutionResult) GetEstimatedAmountOut() int64 {
+	if x != nil {
+		return x.EstimatedAmountOut
+	}
+	return 0
+}
+
+func (x *ExecutionResult) GetPriceImpact() float64 {
+	if x != nil {
+		return x.PriceImpact
+	}
+	return 0
+}
+
+func (x *ExecutionResult) GetEffectivePrice() float64 {
+	if x != nil {
+		return x.EffectivePrice
+	}
+	return 0
+}
+
+func (x *ExecutionResult) GetMinimumReceived() int64 {
+	if x != nil {
+		return x.MinimumReceived
+	}
+	return 0
+}
+
+func (x *ExecutionResult) GetHopFees() []*Hop {
+	if x != nil {
+		return x.HopFees
+	}
+	return nil
+}
+
+func (x *ExecutionResult) GetWarnings() []string {
+	if x != nil {
+		return x.Warnings
+	}
+	return nil
+}
+
+func (x *ExecutionResult) GetApprovalId() string {
+	if x != nil {
+		return x.ApprovalId
+	}
+	return ""
+}
+
+type GetSwapStatusRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	JobId string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+}
+
+func (x *GetSwapStatusRequest) Reset() {
+	*x = GetSwapStatusRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_router_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetSwapStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSwapStatusRequest) ProtoMessage() {}
+
+func (x *GetSwapStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_router_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSwapStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetSwapStatusRequest) Descriptor() ([]byte, []int) {
+	return file_router_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetSwapStatusRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+type SwapStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	JobId     string            `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	Status    SwapStatus_Status `protobuf:"varint,2,opt,name=status,proto3,enum=vscdex.router.v1.SwapStatus_Status" json:"status,omitempty"`
+	TxId      string            `protobuf:"bytes,3,opt,name=tx_id,json=txId,proto3" json:"tx_id,omitempty"`
+	Result    *ExecutionResult  `protobuf:"bytes,4,opt,name=result,proto3" json:"result,omitempty"` // Set once the job stops progressing
+	Error     string            `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	CreatedAt int64             `protobuf:"varint,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // Unix milliseconds
+	UpdatedAt int64             `protobuf:"varint,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"` // Unix milliseconds
+}
+
+func (x *SwapStatus) Reset() {
+	*x = SwapStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_router_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SwapStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SwapStatus) ProtoMessage() {}
+
+func (x *SwapStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_router_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SwapStatus.ProtoReflect.Descriptor instead.
+func (*SwapStatus) Descriptor() ([]byte, []int) {
+	return file_router_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SwapStatus) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *SwapStatus) GetStatus() SwapStatus_Status {
+	if x != nil {
+		return x.Status
+	}
+	return SwapStatus_STATUS_UNSPECIFIED
+}
+
+func (x *SwapStatus) GetTxId() string {
+	if x != nil {
+		return x.TxId
+	}
+	return ""
+}
+
+func (x *SwapStatus) GetResult() *ExecutionResult {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *SwapStatus) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *SwapStatus) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *SwapStatus) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+type DepositRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sender     string `protobuf:"bytes,1,opt,name=sender,proto3" json:"sender,omitempty"`
+	FromAsset  string `protobuf:"bytes,2,opt,name=from_asset,json=fromAsset,proto3" json:"from_asset,omitempty"`
+	ToAsset    string `protobuf:"bytes,3,opt,name=to_asset,json=toAsset,proto3" json:"to_asset,omitempty"`
+	Amount     int64  `protobuf:"varint,4,opt,name=amount,proto3" json:"amount,omitempty"`
+	PairAmount int64  `protobuf:"varint,5,opt,name=pair_amount,json=pairAmount,proto3" json:"pair_amount,omitempty"` // Amount of to_asset
+}
+
+func (x *DepositRequest) Reset() {
+	*x = DepositRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_router_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DepositRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DepositRequest) ProtoMessage() {}
+
+func (x *DepositRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_router_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DepositRequest.ProtoReflect.Descriptor instead.
+func (*DepositRequest) Descriptor() ([]byte, []int) {
+	return file_router_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DepositRequest) GetSender() string {
+	if x != nil {
+		return x.Sender
+	}
+	return ""
+}
+
+func (x *DepositRequest) GetFromAsset() string {
+	if x != nil {
+		return x.FromAsset
+	}
+	return ""
+}
+
+func (x *DepositRequest) GetToAsset() string {
+	if x != nil {
+		return x.ToAsset
+	}
+	return ""
+}
+
+func (x *DepositRequest) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *DepositRequest) GetPairAmount() int64 {
+	if x != nil {
+		return x.PairAmount
+	}
+	return 0
+}
+
+type WithdrawRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sender    string `protobuf:"bytes,1,opt,name=sender,proto3" json:"sender,omitempty"`
+	FromAsset string `protobuf:"bytes,2,opt,name=from_asset,json=fromAsset,proto3" json:"from_asset,omitempty"`
+	ToAsset   string `protobuf:"bytes,3,opt,name=to_asset,json=toAsset,proto3" json:"to_asset,omitempty"`
+	LpAmount  int64  `protobuf:"varint,4,opt,name=lp_amount,json=lpAmount,proto3" json:"lp_amount,omitempty"` // Zero withdraws the whole position
+}
+
+func (x *WithdrawRequest) Reset() {
+	*x = WithdrawRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_router_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WithdrawRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WithdrawRequest) ProtoMessage() {}
+
+func (x *WithdrawRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_router_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WithdrawRequest.ProtoReflect.Descriptor instead.
+func (*WithdrawRequest) Descriptor() ([]byte, []int) {
+	return file_router_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *WithdrawRequest) GetSender() string {
+	if x != nil {
+		return x.Sender
+	}
+	return ""
+}
+
+func (x *WithdrawRequest) GetFromAsset() string {
+	if x != nil {
+		return x.FromAsset
+	}
+	return ""
+}
+
+func (x *WithdrawRequest) GetToAsset() string {
+	if x != nil {
+		return x.ToAsset
+	}
+	return ""
+}
+
+func (x *WithdrawRequest) GetLpAmount() int64 {
+	if x != nil {
+		return x.LpAmount
+	}
+	return 0
+}
+
+var File_router_proto protoreflect.FileDescriptor
+
+var file_router_proto_rawDesc = []byte{
+	0x0a, 0x0c, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x10,
+	0x76, 0x73, 0x63, 0x64, 0x65, 0x78, 0x2e, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x72, 0x2e, 0x76, 0x31,
+	0x22, 0xbc, 0x03, 0x0a, 0x0b, 0x53, 0x77, 0x61, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x1d, 0x0a, 0x0a, 0x66, 0x72, 0x6f, 0x6d, 0x5f, 0x61, 0x73, 0x73, 0x65, 0x74, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x66, 0x72, 0x6f, 0x6d, 0x41, 0x73, 0x73, 0x65, 0x74, 0x12,
+	0x19, 0x0a, 0x08, 0x74, 0x6f, 0x5f, 0x61, 0x73, 0x73, 0x65, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x07, 0x74, 0x6f, 0x41, 0x73, 0x73, 0x65, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x6d,
+	0x6f, 0x75, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x61, 0x6d, 0x6f, 0x75,
+	0x6e, 0x74, 0x12, 0x17, 0x0a, 0x07, 0x6d, 0x69, 0x6e, 0x5f, 0x6f, 0x75, 0x74, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x06, 0x6d, 0x69, 0x6e, 0x4f, 0x75, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x73,
+	0x6c, 0x69, 0x70, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x62, 0x70, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x0b, 0x73, 0x6c, 0x69, 0x70, 0x70, 0x61, 0x67, 0x65, 0x42, 0x70, 0x73, 0x12, 0x16,
+	0x0a, 0x06, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x65, 0x61, 0x64, 0x6c, 0x69,
+	0x6e, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x64, 0x65, 0x61, 0x64, 0x6c, 0x69,
+	0x6e, 0x65, 0x12, 0x23, 0x0a, 0x0d, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x5f, 0x72, 0x65, 0x76,
+	0x65, 0x61, 0x6c, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0c, 0x63, 0x6f, 0x6d, 0x6d, 0x69,
+	0x74, 0x52, 0x65, 0x76, 0x65, 0x61, 0x6c, 0x12, 0x27, 0x0a, 0x0f, 0x69, 0x64, 0x65, 0x6d, 0x70,
+	0x6f, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0e, 0x69, 0x64, 0x65, 0x6d, 0x70, 0x6f, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x4b, 0x65, 0x79,
+	0x12, 0x27, 0x0a, 0x0f, 0x66, 0x61, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x5f, 0x72, 0x6f, 0x75,
+	0x74, 0x65, 0x73, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0e, 0x66, 0x61, 0x6c, 0x6c, 0x62,
+	0x61, 0x63, 0x6b, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x65, 0x78, 0x63,
+	0x6c, 0x75, 0x64, 0x65, 0x5f, 0x70, 0x6f, 0x6f, 0x6c, 0x73, 0x18, 0x0b, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x0c, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x50, 0x6f, 0x6f, 0x6c, 0x73, 0x12, 0x35,
+	0x0a, 0x05, 0x71, 0x75, 0x6f, 0x74, 0x65, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e,
+	0x76, 0x73, 0x63, 0x64, 0x65, 0x78, 0x2e, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x72, 0x2e, 0x76, 0x31,
+	0x2e, 0x51, 0x75, 0x6f, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x05,
+	0x71, 0x75, 0x6f, 0x74, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x65, 0x71, 0x75, 0x6f, 0x74, 0x65,
+	0x18, 0x0d, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x72, 0x65, 0x71, 0x75, 0x6f, 0x74, 0x65, 0x22,
+	0xfd, 0x01, 0x0a, 0x03, 0x48, 0x6f, 0x70, 0x12, 0x17, 0x0a, 0x07, 0x70, 0x6f, 0x6f, 0x6c, 0x5f,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x6f, 0x6f, 0x6c, 0x49, 0x64,
+	0x12, 0x19, 0x0a, 0x08, 0x61, 0x73, 0x73, 0x65, 0x74, 0x5f, 0x69, 0x6e, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x07, 0x61, 0x73, 0x73, 0x65, 0x74, 0x49, 0x6e, 0x12, 0x1b, 0x0a, 0x09, 0x61,
+	0x73, 0x73, 0x65, 0x74, 0x5f, 0x6f, 0x75, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x61, 0x73, 0x73, 0x65, 0x74, 0x4f, 0x75, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x61, 0x6d, 0x6f, 0x75,
+	0x6e, 0x74, 0x5f, 0x69, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x61, 0x6d, 0x6f,
+	0x75, 0x6e, 0x74, 0x49, 0x6e, 0x12, 0x1d, 0x0a, 0x0a, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x5f,
+	0x6f, 0x75, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x61, 0x6d, 0x6f, 0x75, 0x6e,
+	0x74, 0x4f, 0x75, 0x74, 0x12, 0x17, 0x0a, 0x07, 0x66, 0x65, 0x65, 0x5f, 0x62, 0x70, 0x73, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x66, 0x65, 0x65, 0x42, 0x70, 0x73, 0x12, 0x10, 0x0a,
+	0x03, 0x66, 0x65, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x66, 0x65, 0x65, 0x12,
+	0x1d, 0x0a, 0x0a, 0x72, 0x65, 0x73, 0x65, 0x72, 0x76, 0x65, 0x5f, 0x69, 0x6e, 0x18, 0x08, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x09, 0x72, 0x65, 0x73, 0x65, 0x72, 0x76, 0x65, 0x49, 0x6e, 0x12, 0x1f,
+	0x0a, 0x0b, 0x72, 0x65, 0x73, 0x65, 0x72, 0x76, 0x65, 0x5f, 0x6f, 0x75, 0x74, 0x18, 0x09, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x0a, 0x72, 0x65, 0x73, 0x65, 0x72, 0x76, 0x65, 0x4f, 0x75, 0x74, 0x22,
+	0xe9, 0x02, 0x0a, 0x0d, 0x51, 0x75, 0x6f, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x19, 0x0a, 0x08, 0x61, 0x73, 0x73, 0x65, 0x74, 0x5f, 0x69, 0x6e, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x73, 0x73, 0x65, 0x74, 0x49, 0x6e, 0x12, 0x1b, 0x0a, 0x09,
+	0x61, 0x73, 0x73, 0x65, 0x74, 0x5f, 0x6f, 0x75, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x08, 0x61, 0x73, 0x73, 0x65, 0x74, 0x4f, 0x75, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x61, 0x6d, 0x6f,
+	0x75, 0x6e, 0x74, 0x5f, 0x69, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x61, 0x6d,
+	0x6f, 0x75, 0x6e, 0x74, 0x49, 0x6e, 0x12, 0x1d, 0x0a, 0x0a, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74,
+	0x5f, 0x6f, 0x75, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x61, 0x6d, 0x6f, 0x75,
+	0x6e, 0x74, 0x4f, 0x75, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x18, 0x05,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x12, 0x29, 0x0a, 0x04, 0x68,
+	0x6f, 0x70, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x76, 0x73, 0x63, 0x64,
+	0x65, 0x78, 0x2e, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x48, 0x6f, 0x70,
+	0x52, 0x04, 0x68, 0x6f, 0x70, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x70, 0x72, 0x69, 0x63, 0x65, 0x5f,
+	0x69, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0b, 0x70, 0x72,
+	0x69, 0x63, 0x65, 0x49, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x12, 0x29, 0x0a, 0x10, 0x6d, 0x69, 0x6e,
+	0x69, 0x6d, 0x75, 0x6d, 0x5f, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x18, 0x08, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x0f, 0x6d, 0x69, 0x6e, 0x69, 0x6d, 0x75, 0x6d, 0x52, 0x65, 0x63, 0x65,
+	0x69, 0x76, 0x65, 0x64, 0x12, 0x2f, 0x0a, 0x04, 0x63, 0x6f, 0x73, 0x74, 0x18, 0x09, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x76, 0x73, 0x63, 0x64, 0x65, 0x78, 0x2e, 0x72, 0x6f, 0x75, 0x74,
+	0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x43, 0x6f, 0x73, 0x74, 0x52,
+	0x04, 0x63, 0x6f, 0x73, 0x74, 0x12, 0x24, 0x0a, 0x0e, 0x6e, 0x65, 0x74, 0x5f, 0x61, 0x6d, 0x6f,
+	0x75, 0x6e, 0x74, 0x5f, 0x6f, 0x75, 0x74, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x6e,
+	0x65, 0x74, 0x41, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x4f, 0x75, 0x74, 0x22, 0x3c, 0x0a, 0x09, 0x52,
+	0x6f, 0x75, 0x74, 0x65, 0x43, 0x6f, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x68, 0x62, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x68, 0x62, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x61, 0x6d,
+	0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x6f, 0x75, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09,
+	0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x4f, 0x75, 0x74, 0x22, 0xde, 0x03, 0x0a, 0x0f, 0x45, 0x78,
+	0x65, 0x63, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x18, 0x0a,
+	0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07,
+	0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x61, 0x6d, 0x6f, 0x75, 0x6e,
+	0x74, 0x5f, 0x6f, 0x75, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x61, 0x6d, 0x6f,
+	0x75, 0x6e, 0x74, 0x4f, 0x75, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x66, 0x65, 0x65, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x03, 0x66, 0x65, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x6f, 0x75, 0x74,
+	0x65, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x12, 0x23,
+	0x0a, 0x0d, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x4d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x12, 0x13, 0x0a, 0x05, 0x74, 0x78, 0x5f, 0x69, 0x64, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x74, 0x78, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x65, 0x74, 0x74,
+	0x6c, 0x65, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x73, 0x65, 0x74, 0x74, 0x6c,
+	0x65, 0x64, 0x12, 0x30, 0x0a, 0x14, 0x65, 0x73, 0x74, 0x69, 0x6d, 0x61, 0x74, 0x65, 0x64, 0x5f,
+	0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x6f, 0x75, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x12, 0x65, 0x73, 0x74, 0x69, 0x6d, 0x61, 0x74, 0x65, 0x64, 0x41, 0x6d, 0x6f, 0x75, 0x6e,
+	0x74, 0x4f, 0x75, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x70, 0x72, 0x69, 0x63, 0x65, 0x5f, 0x69, 0x6d,
+	0x70, 0x61, 0x63, 0x74, 0x18, 0x09, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0b, 0x70, 0x72, 0x69, 0x63,
+	0x65, 0x49, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x12, 0x27, 0x0a, 0x0f, 0x65, 0x66, 0x66, 0x65, 0x63,
+	0x74, 0x69, 0x76, 0x65, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x01,
+	0x52, 0x0e, 0x65, 0x66, 0x66, 0x65, 0x63, 0x74, 0x69, 0x76, 0x65, 0x50, 0x72, 0x69, 0x63, 0x65,
+	0x12, 0x29, 0x0a, 0x10, 0x6d, 0x69, 0x6e, 0x69, 0x6d, 0x75, 0x6d, 0x5f, 0x72, 0x65, 0x63, 0x65,
+	0x69, 0x76, 0x65, 0x64, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0f, 0x6d, 0x69, 0x6e, 0x69,
+	0x6d, 0x75, 0x6d, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x12, 0x30, 0x0a, 0x08, 0x68,
+	0x6f, 0x70, 0x5f, 0x66, 0x65, 0x65, 0x73, 0x18, 0x0c, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e,
+	0x76, 0x73, 0x63, 0x64, 0x65, 0x78, 0x2e, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x72, 0x2e, 0x76, 0x31,
+	0x2e, 0x48, 0x6f, 0x70, 0x52, 0x07, 0x68, 0x6f, 0x70, 0x46, 0x65, 0x65, 0x73, 0x12, 0x1a, 0x0a,
+	0x08, 0x77, 0x61, 0x72, 0x6e, 0x69, 0x6e, 0x67, 0x73, 0x18, 0x0d, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x08, 0x77, 0x61, 0x72, 0x6e, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x61, 0x70, 0x70,
+	0x72, 0x6f, 0x76, 0x61, 0x6c, 0x5f, 0x69, 0x64, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a,
+	0x61, 0x70, 0x70, 0x72, 0x6f, 0x76, 0x61, 0x6c, 0x49, 0x64, 0x22, 0x2d, 0x0a, 0x14, 0x47, 0x65,
+	0x74, 0x53, 0x77, 0x61, 0x70, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x15, 0x0a, 0x06, 0x6a, 0x6f, 0x62, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x64, 0x22, 0x8e, 0x03, 0x0a, 0x0a, 0x53, 0x77,
+	0x61, 0x70, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x15, 0x0a, 0x06, 0x6a, 0x6f, 0x62, 0x5f,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x64, 0x12,
+	0x3b, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x23, 0x2e, 0x76, 0x73, 0x63, 0x64, 0x65, 0x78, 0x2e, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x72, 0x2e,
+	0x76, 0x31, 0x2e, 0x53, 0x77, 0x61, 0x70, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x2e, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x13, 0x0a, 0x05,
+	0x74, 0x78, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x78, 0x49,
+	0x64, 0x12, 0x39, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x21, 0x2e, 0x76, 0x73, 0x63, 0x64, 0x65, 0x78, 0x2e, 0x72, 0x6f, 0x75, 0x74, 0x65,
+	0x72, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65,
+	0x73, 0x75, 0x6c, 0x74, 0x52, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x14, 0x0a, 0x05,
+	0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72,
+	0x6f, 0x72, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x41,
+	0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74,
+	0x22, 0x87, 0x01, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x16, 0x0a, 0x12, 0x53,
+	0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45,
+	0x44, 0x10, 0x00, 0x12, 0x11, 0x0a, 0x0d, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x51, 0x55,
+	0x45, 0x55, 0x45, 0x44, 0x10, 0x01, 0x12, 0x14, 0x0a, 0x10, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53,
+	0x5f, 0x42, 0x52, 0x4f, 0x41, 0x44, 0x43, 0x41, 0x53, 0x54, 0x10, 0x02, 0x12, 0x13, 0x0a, 0x0f,
+	0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x49, 0x4e, 0x43, 0x4c, 0x55, 0x44, 0x45, 0x44, 0x10,
+	0x03, 0x12, 0x14, 0x0a, 0x10, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x43, 0x4f, 0x4e, 0x46,
+	0x49, 0x52, 0x4d, 0x45, 0x44, 0x10, 0x04, 0x12, 0x11, 0x0a, 0x0d, 0x53, 0x54, 0x41, 0x54, 0x55,
+	0x53, 0x5f, 0x46, 0x41, 0x49, 0x4c, 0x45, 0x44, 0x10, 0x05, 0x22, 0x9b, 0x01, 0x0a, 0x0e, 0x44,
+	0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a,
+	0x06, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73,
+	0x65, 0x6e, 0x64, 0x65, 0x72, 0x12, 0x1d, 0x0a, 0x0a, 0x66, 0x72, 0x6f, 0x6d, 0x5f, 0x61, 0x73,
+	0x73, 0x65, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x66, 0x72, 0x6f, 0x6d, 0x41,
+	0x73, 0x73, 0x65, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x74, 0x6f, 0x5f, 0x61, 0x73, 0x73, 0x65, 0x74,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x74, 0x6f, 0x41, 0x73, 0x73, 0x65, 0x74, 0x12,
+	0x16, 0x0a, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x70, 0x61, 0x69, 0x72, 0x5f,
+	0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x70, 0x61,
+	0x69, 0x72, 0x41, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x80, 0x01, 0x0a, 0x0f, 0x57, 0x69, 0x74,
+	0x68, 0x64, 0x72, 0x61, 0x77, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06,
+	0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x65,
+	0x6e, 0x64, 0x65, 0x72, 0x12, 0x1d, 0x0a, 0x0a, 0x66, 0x72, 0x6f, 0x6d, 0x5f, 0x61, 0x73, 0x73,
+	0x65, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x66, 0x72, 0x6f, 0x6d, 0x41, 0x73,
+	0x73, 0x65, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x74, 0x6f, 0x5f, 0x61, 0x73, 0x73, 0x65, 0x74, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x74, 0x6f, 0x41, 0x73, 0x73, 0x65, 0x74, 0x12, 0x1b,
+	0x0a, 0x09, 0x6c, 0x70, 0x5f, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x08, 0x6c, 0x70, 0x41, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x32, 0xb6, 0x04, 0x0a, 0x06,
+	0x52, 0x6f, 0x75, 0x74, 0x65, 0x72, 0x12, 0x47, 0x0a, 0x05, 0x51, 0x75, 0x6f, 0x74, 0x65, 0x12,
+	0x1d, 0x2e, 0x76, 0x73, 0x63, 0x64, 0x65, 0x78, 0x2e, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x72, 0x2e,
+	0x76, 0x31, 0x2e, 0x53, 0x77, 0x61, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f,
+	0x2e, 0x76, 0x73, 0x63, 0x64, 0x65, 0x78, 0x2e, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x72, 0x2e, 0x76,
+	0x31, 0x2e, 0x51, 0x75, 0x6f, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x48, 0x0a, 0x04, 0x53, 0x77, 0x61, 0x70, 0x12, 0x1d, 0x2e, 0x76, 0x73, 0x63, 0x64, 0x65, 0x78,
+	0x2e, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x77, 0x61, 0x70, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e, 0x76, 0x73, 0x63, 0x64, 0x65, 0x78, 0x2e,
+	0x72, 0x6f, 0x75, 0x74, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74,
+	0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x4b, 0x0a, 0x0a, 0x53, 0x75, 0x62,
+	0x6d, 0x69, 0x74, 0x53, 0x77, 0x61, 0x70, 0x12, 0x1d, 0x2e, 0x76, 0x73, 0x63, 0x64, 0x65, 0x78,
+	0x2e, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x77, 0x61, 0x70, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x76, 0x73, 0x63, 0x64, 0x65, 0x78, 0x2e,
+	0x72, 0x6f, 0x75, 0x74, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x77, 0x61, 0x70, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x30, 0x01, 0x12, 0x55, 0x0a, 0x0d, 0x47, 0x65, 0x74, 0x53, 0x77, 0x61,
+	0x70, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x26, 0x2e, 0x76, 0x73, 0x63, 0x64, 0x65, 0x78,
+	0x2e, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x53, 0x77,
+	0x61, 0x70, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x1c, 0x2e, 0x76, 0x73, 0x63, 0x64, 0x65, 0x78, 0x2e, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x72, 0x2e,
+	0x76, 0x31, 0x2e, 0x53, 0x77, 0x61, 0x70, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x53, 0x0a,
+	0x09, 0x57, 0x61, 0x74, 0x63, 0x68, 0x53, 0x77, 0x61, 0x70, 0x12, 0x26, 0x2e, 0x76, 0x73, 0x63,
+	0x64, 0x65, 0x78, 0x2e, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65,
+	0x74, 0x53, 0x77, 0x61, 0x70, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x76, 0x73, 0x63, 0x64, 0x65, 0x78, 0x2e, 0x72, 0x6f, 0x75, 0x74,
+	0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x77, 0x61, 0x70, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x30, 0x01, 0x12, 0x4e, 0x0a, 0x07, 0x44, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x12, 0x20, 0x2e,
+	0x76, 0x73, 0x63, 0x64, 0x65, 0x78, 0x2e, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x72, 0x2e, 0x76, 0x31,
+	0x2e, 0x44, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x21, 0x2e, 0x76, 0x73, 0x63, 0x64, 0x65, 0x78, 0x2e, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x72, 0x2e,
+	0x76, 0x31, 0x2e, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x75,
+	0x6c, 0x74, 0x12, 0x50, 0x0a, 0x08, 0x57, 0x69, 0x74, 0x68, 0x64, 0x72, 0x61, 0x77, 0x12, 0x21,
+	0x2e, 0x76, 0x73, 0x63, 0x64, 0x65, 0x78, 0x2e, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x72, 0x2e, 0x76,
+	0x31, 0x2e, 0x57, 0x69, 0x74, 0x68, 0x64, 0x72, 0x61, 0x77, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x21, 0x2e, 0x76, 0x73, 0x63, 0x64, 0x65, 0x78, 0x2e, 0x72, 0x6f, 0x75, 0x74, 0x65,
+	0x72, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65,
+	0x73, 0x75, 0x6c, 0x74, 0x42, 0x3d, 0x5a, 0x3b, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63,
+	0x6f, 0x6d, 0x2f, 0x76, 0x73, 0x63, 0x2d, 0x65, 0x63, 0x6f, 0x2f, 0x76, 0x73, 0x63, 0x2d, 0x64,
+	0x65, 0x78, 0x2d, 0x6d, 0x61, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x2f, 0x73, 0x65, 0x72, 0x76, 0x69,
+	0x63, 0x65, 0x73, 0x2f, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x72, 0x2f, 0x72, 0x6f, 0x75, 0x74, 0x65,
+	0x72, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_router_proto_rawDescOnce sync.Once
+	file_router_proto_rawDescData = file_router_proto_rawDesc
+)
+
+func file_router_proto_rawDescGZIP() []byte {
+	file_router_proto_rawDescOnce.Do(func() {
+		file_router_proto_rawDescData = protoimpl.X.CompressGZIP(file_router_proto_rawDescData)
+	})
+	return file_router_proto_rawDescData
+}
+
+var file_router_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_router_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_router_proto_goTypes = []any{
+	(SwapStatus_Status)(0),       // 0: vscdex.router.v1.SwapStatus.Status
+	(*SwapRequest)(nil),          // 1: vscdex.router.v1.SwapRequest
+	(*Hop)(nil),                  // 2: vscdex.router.v1.Hop
+	(*QuoteResponse)(nil),        // 3: vscdex.router.v1.QuoteResponse
+	(*RouteCost)(nil),            // 4: vscdex.router.v1.RouteCost
+	(*ExecutionResult)(nil),      // 5: vscdex.router.v1.ExecutionResult
+	(*GetSwapStatusRequest)(nil), // 6: vscdex.router.v1.GetSwapStatusRequest
+	(*SwapStatus)(nil),           // 7: vscdex.router.v1.SwapStatus
+	(*DepositRequest)(nil),       // 8: vscdex.router.v1.DepositRequest
+	(*WithdrawRequest)(nil),      // 9: vscdex.router.v1.WithdrawRequest
+}
+var file_router_proto_depIdxs = []int32{
+	3,  // 0: vscdex.router.v1.SwapRequest.quote:type_name -> vscdex.router.v1.QuoteResponse
+	2,  // 1: vscdex.router.v1.QuoteResponse.hops:type_name -> vscdex.router.v1.Hop
+	4,  // 2: vscdex.router.v1.QuoteResponse.cost:type_name -> vscdex.router.v1.RouteCost
+	2,  // 3: vscdex.router.v1.ExecutionResult.hop_fees:type_name -> vscdex.router.v1.Hop
+	0,  // 4: vscdex.router.v1.SwapStatus.status:type_name -> vscdex.router.v1.SwapStatus.Status
+	5,  // 5: vscdex.router.v1.SwapStatus.result:type_name -> vscdex.router.v1.ExecutionResult
+	1,  // 6: vscdex.router.v1.Router.Quote:input_type -> vscdex.router.v1.SwapRequest
+	1,  // 7: vscdex.router.v1.Router.Swap:input_type -> vscdex.router.v1.SwapRequest
+	1,  // 8: vscdex.router.v1.Router.SubmitSwap:input_type -> vscdex.router.v1.SwapRequest
+	6,  // 9: vscdex.router.v1.Router.GetSwapStatus:input_type -> vscdex.router.v1.GetSwapStatusRequest
+	6,  // 10: vscdex.router.v1.Router.WatchSwap:input_type -> vscdex.router.v1.GetSwapStatusRequest
+	8,  // 11: vscdex.router.v1.Router.Deposit:input_type -> vscdex.router.v1.DepositRequest
+	9,  // 12: vscdex.router.v1.Router.Withdraw:input_type -> vscdex.router.v1.WithdrawRequest
+	3,  // 13: vscdex.router.v1.Router.Quote:output_type -> vscdex.router.v1.QuoteResponse
+	5,  // 14: vscdex.router.v1.Router.Swap:output_type -> vscdex.router.v1.ExecutionResult
+	7,  // 15: vscdex.router.v1.Router.SubmitSwap:output_type -> vscdex.router.v1.SwapStatus
+	7,  // 16: vscdex.router.v1.Router.GetSwapStatus:output_type -> vscdex.router.v1.SwapStatus
+	7,  // 17: vscdex.router.v1.Router.WatchSwap:output_type -> vscdex.router.v1.SwapStatus
+	5,  // 18: vscdex.router.v1.Router.Deposit:output_type -> vscdex.router.v1.ExecutionResult
+	5,  // 19: vscdex.router.v1.Router.Withdraw:output_type -> vscdex.router.v1.ExecutionResult
+	13, // [13:20] is the sub-list for method output_type
+	6,  // [6:13] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_router_proto_init() }
+func file_router_proto_init() {
+	if File_router_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_router_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*SwapRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_router_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*Hop); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_router_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*QuoteResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_router_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*RouteCost); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_router_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*ExecutionResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_router_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*GetSwapStatusRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_router_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*SwapStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_router_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*DepositRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_router_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*WithdrawRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_router_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_router_proto_goTypes,
+		DependencyIndexes: file_router_proto_depIdxs,
+		EnumInfos:         file_router_proto_enumTypes,
+		MessageInfos:      file_router_proto_msgTypes,
+	}.Build()
+	File_router_proto = out.File
+	file_router_proto_rawDesc = nil
+	file_router_proto_goTypes = nil
+	file_router_proto_depIdxs = nil
+}
@@ -0,0 +1,376 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             v5.27.1
+// source: router.proto
+
+// gRPC interface to the router service, for programmatic traders and internal
+// services that want lower overhead than the HTTP API. Amounts are integers in
+// the asset's smallest unit; slippage is in basis points.
+
+package routerpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Router_Quote_FullMethodName         = "/vscdex.router.v1.Router/Quote"
+	Router_Swap_FullMethodName          = "/vscdex.router.v1.Router/Swap"
+	Router_SubmitSwap_FullMethodName    = "/vscdex.router.v1.Router/SubmitSwap"
+	Router_GetSwapStatus_FullMethodName = "/vscdex.router.v1.Router/GetSwapStatus"
+	Router_WatchSwap_FullMethodName     = "/vscdex.router.v1.Router/WatchSwap"
+	Router_Deposit_FullMethodName       = "/vscdex.router.v1.Router/Deposit"
+	Router_Withdraw_FullMethodName      = "/vscdex.router.v1.Router/Withdraw"
+)
+
+// RouterClient is the client API for Router service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RouterClient interface {
+	// Quote computes the best route and expected output without submitting
+	Quote(ctx context.Context, in *SwapRequest, opts ...grpc.CallOption) (*QuoteResponse, error)
+	// Swap executes a swap and waits for its result
+	Swap(ctx context.Context, in *SwapRequest, opts ...grpc.CallOption) (*ExecutionResult, error)
+	// SubmitSwap queues a swap and streams its status until it stops
+	// progressing. The first message carries the job ID.
+	SubmitSwap(ctx context.Context, in *SwapRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SwapStatus], error)
+	// GetSwapStatus returns the current status of a submitted swap
+	GetSwapStatus(ctx context.Context, in *GetSwapStatusRequest, opts ...grpc.CallOption) (*SwapStatus, error)
+	// WatchSwap streams the status of a previously submitted swap
+	WatchSwap(ctx context.Context, in *GetSwapStatusRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SwapStatus], error)
+	// Deposit adds liquidity to a pool
+	Deposit(ctx context.Context, in *DepositRequest, opts ...grpc.CallOption) (*ExecutionResult, error)
+	// Withdraw removes liquidity from a pool
+	Withdraw(ctx context.Context, in *WithdrawRequest, opts ...grpc.CallOption) (*ExecutionResult, error)
+}
+
+type routerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRouterClient(cc grpc.ClientConnInterface) RouterClient {
+	return &routerClient{cc}
+}
+
+func (c *routerClient) Quote(ctx context.Context, in *SwapRequest, opts ...grpc.CallOption) (*QuoteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QuoteResponse)
+	err := c.cc.Invoke(ctx, Router_Quote_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routerClient) Swap(ctx context.Context, in *SwapRequest, opts ...grpc.CallOption) (*ExecutionResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExecutionResult)
+	err := c.cc.Invoke(ctx, Router_Swap_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routerClient) SubmitSwap(ctx context.Context, in *SwapRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SwapStatus], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Router_ServiceDesc.Streams[0], Router_SubmitSwap_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SwapRequest, SwapStatus]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Router_SubmitSwapClient = grpc.ServerStreamingClient[SwapStatus]
+
+func (c *routerClient) GetSwapStatus(ctx context.Context, in *GetSwapStatusRequest, opts ...grpc.CallOption) (*SwapStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SwapStatus)
+	err := c.cc.Invoke(ctx, Router_GetSwapStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routerClient) WatchSwap(ctx context.Context, in *GetSwapStatusRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SwapStatus], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Router_ServiceDesc.Streams[1], Router_WatchSwap_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GetSwapStatusRequest, SwapStatus]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Router_WatchSwapClient = grpc.ServerStreamingClient[SwapStatus]
+
+func (c *routerClient) Deposit(ctx context.Context, in *DepositRequest, opts ...grpc.CallOption) (*ExecutionResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExecutionResult)
+	err := c.cc.Invoke(ctx, Router_Deposit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routerClient) Withdraw(ctx context.Context, in *WithdrawRequest, opts ...grpc.CallOption) (*ExecutionResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExecutionResult)
+	err := c.cc.Invoke(ctx, Router_Withdraw_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RouterServer is the server API for Router service.
+// All implementations must embed UnimplementedRouterServer
+// for forward compatibility.
+type RouterServer interface {
+	// Quote computes the best route and expected output without submitting
+	Quote(context.Context, *SwapRequest) (*QuoteResponse, error)
+	// Swap executes a swap and waits for its result
+	Swap(context.Context, *SwapRequest) (*ExecutionResult, error)
+	// SubmitSwap queues a swap and streams its status until it stops
+	// progressing. The first message carries the job ID.
+	SubmitSwap(*SwapRequest, grpc.ServerStreamingServer[SwapStatus]) error
+	// GetSwapStatus returns the current status of a submitted swap
+	GetSwapStatus(context.Context, *GetSwapStatusRequest) (*SwapStatus, error)
+	// WatchSwap streams the status of a previously submitted swap
+	WatchSwap(*GetSwapStatusRequest, grpc.ServerStreamingServer[SwapStatus]) error
+	// Deposit adds liquidity to a pool
+	Deposit(context.Context, *DepositRequest) (*ExecutionResult, error)
+	// Withdraw removes liquidity from a pool
+	Withdraw(context.Context, *WithdrawRequest) (*ExecutionResult, error)
+	mustEmbedUnimplementedRouterServer()
+}
+
+// UnimplementedRouterServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRouterServer struct{}
+
+func (UnimplementedRouterServer) Quote(context.Context, *SwapRequest) (*QuoteResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Quote not implemented")
+}
+func (UnimplementedRouterServer) Swap(context.Context, *SwapRequest) (*ExecutionResult, error) {
+	return nil, status.Error(codes.Unimplemented, "method Swap not implemented")
+}
+func (UnimplementedRouterServer) SubmitSwap(*SwapRequest, grpc.ServerStreamingServer[SwapStatus]) error {
+	return status.Error(codes.Unimplemented, "method SubmitSwap not implemented")
+}
+func (UnimplementedRouterServer) GetSwapStatus(context.Context, *GetSwapStatusRequest) (*SwapStatus, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetSwapStatus not implemented")
+}
+func (UnimplementedRouterServer) WatchSwap(*GetSwapStatusRequest, grpc.ServerStreamingServer[SwapStatus]) error {
+	return status.Error(codes.Unimplemented, "method WatchSwap not implemented")
+}
+func (UnimplementedRouterServer) Deposit(context.Context, *DepositRequest) (*ExecutionResult, error) {
+	return nil, status.Error(codes.Unimplemented, "method Deposit not implemented")
+}
+func (UnimplementedRouterServer) Withdraw(context.Context, *WithdrawRequest) (*ExecutionResult, error) {
+	return nil, status.Error(codes.Unimplemented, "method Withdraw not implemented")
+}
+func (UnimplementedRouterServer) mustEmbedUnimplementedRouterServer() {}
+func (UnimplementedRouterServer) testEmbeddedByValue()                {}
+
+// UnsafeRouterServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RouterServer will
+// result in compilation errors.
+type UnsafeRouterServer interface {
+	mustEmbedUnimplementedRouterServer()
+}
+
+func RegisterRouterServer(s grpc.ServiceRegistrar, srv RouterServer) {
+	// If the following call panics, it indicates UnimplementedRouterServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Router_ServiceDesc, srv)
+}
+
+func _Router_Quote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SwapRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RouterServer).Quote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Router_Quote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RouterServer).Quote(ctx, req.(*SwapRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Router_Swap_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SwapRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RouterServer).Swap(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Router_Swap_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RouterServer).Swap(ctx, req.(*SwapRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Router_SubmitSwap_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SwapRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RouterServer).SubmitSwap(m, &grpc.GenericServerStream[SwapRequest, SwapStatus]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Router_SubmitSwapServer = grpc.ServerStreamingServer[SwapStatus]
+
+func _Router_GetSwapStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSwapStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RouterServer).GetSwapStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Router_GetSwapStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RouterServer).GetSwapStatus(ctx, req.(*GetSwapStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Router_WatchSwap_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetSwapStatusRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RouterServer).WatchSwap(m, &grpc.GenericServerStream[GetSwapStatusRequest, SwapStatus]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Router_WatchSwapServer = grpc.ServerStreamingServer[SwapStatus]
+
+func _Router_Deposit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DepositRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RouterServer).Deposit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Router_Deposit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RouterServer).Deposit(ctx, req.(*DepositRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Router_Withdraw_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WithdrawRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RouterServer).Withdraw(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Router_Withdraw_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RouterServer).Withdraw(ctx, req.(*WithdrawRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Router_ServiceDesc is the grpc.ServiceDesc for Router service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Router_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "vscdex.router.v1.Router",
+	HandlerType: (*RouterServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Quote",
+			Handler:    _Router_Quote_Handler,
+		},
+		{
+			MethodName: "Swap",
+			Handler:    _Router_Swap_Handler,
+		},
+		{
+			MethodName: "GetSwapStatus",
+			Handler:    _Router_GetSwapStatus_Handler,
+		},
+		{
+			MethodName: "Deposit",
+			Handler:    _Router_Deposit_Handler,
+		},
+		{
+			MethodName: "Withdraw",
+			Handler:    _Router_Withdraw_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubmitSwap",
+			Handler:       _Router_SubmitSwap_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchSwap",
+			Handler:       _Router_WatchSwap_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "router.proto",
+}
@@ -0,0 +1,381 @@
+package router
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+)
+
+// Route search limits
+const (
+	defaultMaxHops            = 2
+	defaultMaxRouteCandidates = 32
+	defaultMaxSplitLegs       = 1
+	defaultMaxReserveUsageBps = 5000
+
+	// maxRoutingHops bounds MaxHops; each extra hop multiplies the paths
+	// searched
+	maxRoutingHops = 4
+
+	// splitParts is how finely a split swap's input is divided between legs
+	splitParts = 20
+)
+
+// RoutingConfig bounds how hard the router searches for a swap's route.
+// Higher limits can find better prices at the cost of quote latency.
+type RoutingConfig struct {
+	MaxHops            int // Pools a route may pass through
+	MaxRouteCandidates int // Routes compared per quote, shortest first
+	MaxSplitLegs       int // Pool-disjoint routes a quote may split a swap across, for display only; 1 disables splitting
+
+	// Liquidity thresholds keep routes out of pools too shallow for the
+	// trade, which would revert or fill at a terrible price
+	MinPoolReserve     uint64 // Pools with less than this on either side are never routed through; 0 disables
+	MaxReserveUsageBps uint64 // Most of a pool's input reserve one hop may add, in basis points; 0 disables
+
+	// Estimated resource credit cost of broadcasting a swap, valued in HBD:
+	// TxCost for the transaction plus HopCost for each pool it passes
+	// through. Routes are compared by their output net of this cost, so a
+	// longer route must pay for its extra hops. Both 0 disables.
+	TxCost  int64
+	HopCost int64
+}
+
+// DefaultRoutingConfig returns the limits a new service routes with: direct
+// and two-hop routes, without splitting, where no hop adds more than half a
+// pool's input reserve
+func DefaultRoutingConfig() RoutingConfig {
+	return RoutingConfig{
+		MaxHops:            defaultMaxHops,
+		MaxRouteCandidates: defaultMaxRouteCandidates,
+		MaxSplitLegs:       defaultMaxSplitLegs,
+		MaxReserveUsageBps: defaultMaxReserveUsageBps,
+	}
+}
+
+// validate checks the limits are usable
+func (c RoutingConfig) validate() error {
+	if c.MaxHops < 1 || c.MaxHops > maxRoutingHops {
+		return fmt.Errorf("max hops must be between 1 and %d", maxRoutingHops)
+	}
+	if c.MaxRouteCandidates < 1 {
+		return fmt.Errorf("max route candidates must be at least 1")
+	}
+	if c.MaxSplitLegs < 1 || c.MaxSplitLegs > splitParts {
+		return fmt.Errorf("max split legs must be between 1 and %d", splitParts)
+	}
+	if c.MaxReserveUsageBps > 10000 {
+		return fmt.Errorf("max reserve usage must be at most 10000 bps")
+	}
+	if c.TxCost < 0 || c.HopCost < 0 {
+		return fmt.Errorf("route costs must not be negative")
+	}
+	return nil
+}
+
+// deepEnough reports whether a pool holds at least MinPoolReserve of both
+// assets
+func (c RoutingConfig) deepEnough(pool IndexerPoolInfo) bool {
+	return pool.Reserve0 >= c.MinPoolReserve && pool.Reserve1 >= c.MinPoolReserve
+}
+
+// withinReserveUsage reports whether no hop of a route quoted over pools adds
+// more than MaxReserveUsageBps of its pool's input reserve
+func (c RoutingConfig) withinReserveUsage(quote *Quote, pools []IndexerPoolInfo) bool {
+	if c.MaxReserveUsageBps == 0 {
+		return true
+	}
+	for i, hop := range quote.Hops {
+		reserveIn, _, _ := orientPool(pools[i], hop.AssetIn)
+		limit := new(big.Int).Mul(new(big.Int).SetUint64(reserveIn), new(big.Int).SetUint64(c.MaxReserveUsageBps))
+		used := new(big.Int).Mul(big.NewInt(hop.AmountIn), big.NewInt(10000))
+		if used.Cmp(limit) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// quoteUsable quotes amountIn along pools, failing if any pool lacks the
+// liquidity or the trade would take too much of its reserves
+func (c RoutingConfig) quoteUsable(assetIn string, amountIn int64, pools []IndexerPoolInfo) (*Quote, bool) {
+	quote, ok := quoteRoute(assetIn, amountIn, pools)
+	if !ok || !c.withinReserveUsage(quote, pools) {
+		return nil, false
+	}
+	return quote, true
+}
+
+// RouteCost is the estimated resource credit cost of broadcasting a route
+type RouteCost struct {
+	HBD       int64 `json:"hbd"`       // Estimated cost, valued in HBD
+	AmountOut int64 `json:"amountOut"` // The same in the output asset; 0 if no HBD pool prices it
+}
+
+// routeCosts estimates the cost of routes to one output asset
+type routeCosts struct {
+	tx, hop int64
+	rate    *big.Rat // Output asset per HBD; nil if unpriced
+}
+
+// routeCosts prices route costs in assetOut at the spot price of its deepest
+// HBD pool. It returns nil if no cost is configured.
+func (s *Service) routeCosts(assetOut string) *routeCosts {
+	if s.routing.TxCost == 0 && s.routing.HopCost == 0 {
+		return nil
+	}
+	costs := &routeCosts{tx: s.routing.TxCost, hop: s.routing.HopCost}
+	if assetOut == hubAsset {
+		costs.rate = big.NewRat(1, 1)
+		return costs
+	}
+
+	pools, err := s.poolQuerier.GetPoolsByAsset(hubAsset)
+	if err != nil {
+		log.Printf("Route costs in %s unpriced: %v", assetOut, err)
+		return costs
+	}
+	var deepest uint64
+	for _, pool := range pools {
+		if !poolHasAsset(pool, assetOut) || pool.concentrated() {
+			continue
+		}
+		reserveHub, reserveOut, _ := orientPool(pool, hubAsset)
+		if reserveHub > deepest && reserveOut > 0 {
+			deepest = reserveHub
+			costs.rate = new(big.Rat).SetFrac(new(big.Int).SetUint64(reserveOut), new(big.Int).SetUint64(reserveHub))
+		}
+	}
+	return costs
+}
+
+// apply sets a quote's cost and its output net of it. A split quote pays
+// for one transaction and every leg's hops.
+func (c *routeCosts) apply(quote *Quote) {
+	if c == nil {
+		return
+	}
+	cost := &RouteCost{HBD: c.tx + c.hop*int64(len(quote.Hops))}
+	if c.rate != nil {
+		value := new(big.Rat).Mul(new(big.Rat).SetInt64(cost.HBD), c.rate)
+		// Round up, so costs are never understated
+		amount := new(big.Int).Quo(value.Num(), value.Denom())
+		if new(big.Rat).SetInt(amount).Cmp(value) < 0 {
+			amount.Add(amount, big.NewInt(1))
+		}
+		cost.AmountOut = amount.Int64()
+	}
+	quote.Cost = cost
+	quote.NetAmountOut = quote.AmountOut - cost.AmountOut
+}
+
+// SetRoutingConfig configures the route search limits
+func (s *Service) SetRoutingConfig(config RoutingConfig) error {
+	if err := config.validate(); err != nil {
+		return err
+	}
+	s.routing = config
+	return nil
+}
+
+// routePath is a partial route being extended during the search
+type routePath struct {
+	pools  []IndexerPoolInfo
+	asset  string          // Asset held after the last pool
+	assets map[string]bool // Assets already visited
+}
+
+// candidateRoutes lists routes from assetIn to assetOut of up to MaxHops
+// pools, shortest first and at most MaxRouteCandidates of them. Routes never
+// revisit an asset. Among routes of equal length, those through the hub asset
+// come first, matching the dex-router contract's routing. Pools in exclude
+// are never used. Every pool looked at is recorded in trace, if it is not nil.
+func (s *Service) candidateRoutes(assetIn, assetOut string, exclude map[string]bool, trace *RouteTrace) ([][]IndexerPoolInfo, error) {
+	poolsByAsset := make(map[string][]IndexerPoolInfo)
+	load := func(asset string) ([]IndexerPoolInfo, error) {
+		if pools, ok := poolsByAsset[asset]; ok {
+			return pools, nil
+		}
+		pools, err := s.poolQuerier.GetPoolsByAsset(asset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load pools for %s: %w", asset, err)
+		}
+		poolsByAsset[asset] = pools
+		return pools, nil
+	}
+
+	var routes [][]IndexerPoolInfo
+	frontier := []routePath{{asset: assetIn, assets: map[string]bool{assetIn: true}}}
+
+	for hops := 1; hops <= s.routing.MaxHops && len(frontier) > 0; hops++ {
+		var next []routePath
+		for _, path := range frontier {
+			pools, err := load(path.asset)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, pool := range pools {
+				_, _, received := orientPool(pool, path.asset)
+				if exclude[pool.ID] {
+					trace.pool(pool, hops, path.asset, received, "excluded")
+					continue
+				}
+				if path.assets[received] {
+					trace.pool(pool, hops, path.asset, received, "route already passed through "+received)
+					continue
+				}
+				if pool.concentrated() {
+					trace.pool(pool, hops, path.asset, received, "concentrated pools are not quoted")
+					continue
+				}
+				if !s.routing.deepEnough(pool) {
+					trace.pool(pool, hops, path.asset, received, fmt.Sprintf("holds less than the minimum reserve of %d", s.routing.MinPoolReserve))
+					continue
+				}
+				if received != assetOut && hops == s.routing.MaxHops {
+					trace.pool(pool, hops, path.asset, received, fmt.Sprintf("reaching %s would take more than %d hops", assetOut, s.routing.MaxHops))
+					continue
+				}
+				trace.pool(pool, hops, path.asset, received, "")
+
+				extended := make([]IndexerPoolInfo, len(path.pools), len(path.pools)+1)
+				copy(extended, path.pools)
+				extended = append(extended, pool)
+
+				if received == assetOut {
+					routes = append(routes, extended)
+					if len(routes) == s.routing.MaxRouteCandidates {
+						trace.note("search stopped at %d candidate routes", s.routing.MaxRouteCandidates)
+						return routes, nil
+					}
+					continue
+				}
+
+				assets := make(map[string]bool, len(path.assets)+1)
+				for asset := range path.assets {
+					assets[asset] = true
+				}
+				assets[received] = true
+				next = append(next, routePath{pools: extended, asset: received, assets: assets})
+			}
+		}
+
+		sort.SliceStable(next, func(i, j int) bool {
+			return next[i].asset == hubAsset && next[j].asset != hubAsset
+		})
+		frontier = next
+	}
+
+	return routes, nil
+}
+
+// splitQuote divides amountIn across up to MaxSplitLegs pool-disjoint routes,
+// starting from the best single-route quote. Each part of the input goes to
+// whichever leg returns the most for it. The best single route is returned
+// unchanged if splitting does not improve on it.
+func (s *Service) splitQuote(best *Quote, quotes []*Quote, routes map[*Quote][]IndexerPoolInfo, costs *routeCosts) *Quote {
+	if s.routing.MaxSplitLegs < 2 || best.AmountIn < splitParts {
+		return best
+	}
+
+	// Take the best routes that share no pool with those already chosen, so
+	// each leg's output does not depend on the others
+	sort.SliceStable(quotes, func(i, j int) bool { return quotes[i].netOut() > quotes[j].netOut() })
+	used := make(map[string]bool)
+	var legs [][]IndexerPoolInfo
+	for _, quote := range quotes {
+		if len(legs) == s.routing.MaxSplitLegs {
+			break
+		}
+		pools := routes[quote]
+		disjoint := true
+		for _, pool := range pools {
+			if used[pool.ID] {
+				disjoint = false
+				break
+			}
+		}
+		if !disjoint {
+			continue
+		}
+		for _, pool := range pools {
+			used[pool.ID] = true
+		}
+		legs = append(legs, pools)
+	}
+	if len(legs) < 2 {
+		return best
+	}
+
+	amounts := make([]int64, len(legs))
+	outputs := make([]int64, len(legs))
+	part := best.AmountIn / splitParts
+	for i := 0; i < splitParts; i++ {
+		size := part
+		if i == splitParts-1 {
+			size = best.AmountIn - part*(splitParts-1)
+		}
+
+		bestLeg, bestGain := -1, int64(0)
+		for leg, pools := range legs {
+			quote, ok := s.routing.quoteUsable(best.AssetIn, amounts[leg]+size, pools)
+			if !ok {
+				continue
+			}
+			if gain := quote.AmountOut - outputs[leg]; bestLeg < 0 || gain > bestGain {
+				bestLeg, bestGain = leg, gain
+			}
+		}
+		if bestLeg < 0 {
+			return best
+		}
+		amounts[bestLeg] += size
+		outputs[bestLeg] += bestGain
+	}
+
+	var split []*Quote
+	for leg, pools := range legs {
+		if amounts[leg] == 0 {
+			continue
+		}
+		quote, ok := s.routing.quoteUsable(best.AssetIn, amounts[leg], pools)
+		if !ok {
+			return best
+		}
+		split = append(split, quote)
+	}
+	if len(split) < 2 {
+		return best
+	}
+
+	combined := combineLegs(split)
+	costs.apply(combined)
+	if combined.netOut() <= best.netOut() {
+		return best
+	}
+	return combined
+}
+
+// combineLegs totals the legs of a split swap into one quote. Route is the
+// largest leg's; Hops lists every leg's pools.
+func combineLegs(legs []*Quote) *Quote {
+	sort.SliceStable(legs, func(i, j int) bool { return legs[i].AmountIn > legs[j].AmountIn })
+
+	combined := &Quote{
+		AssetIn:  legs[0].AssetIn,
+		AssetOut: legs[0].AssetOut,
+		Route:    legs[0].Route,
+		Legs:     legs,
+	}
+	var weightedImpact float64
+	for _, leg := range legs {
+		combined.AmountIn += leg.AmountIn
+		combined.AmountOut += leg.AmountOut
+		combined.Hops = append(combined.Hops, leg.Hops...)
+		weightedImpact += leg.PriceImpact * float64(leg.AmountIn)
+	}
+	combined.PriceImpact = weightedImpact / float64(combined.AmountIn)
+	return combined
+}
@@ -0,0 +1,294 @@
+package router
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRoutingTestService routes over a chain BTC -> HBD -> HIVE -> ETH, with a
+// second, pool-disjoint HBD/HIVE route through SPK
+func newRoutingTestService(t *testing.T, config RoutingConfig) *Service {
+	svc := NewService(VSCConfig{}, &mockDEXExecutor{})
+	svc.SetPoolQuerier(&mockPoolQuerier{pools: []IndexerPoolInfo{
+		{ID: "btc-hbd", Asset0: "BTC", Asset1: "HBD", Reserve0: 100000000, Reserve1: 50000000, Fee: 8},
+		{ID: "hbd-hive", Asset0: "HBD", Asset1: "HIVE", Reserve0: 1000000, Reserve1: 4000000, Fee: 30},
+		{ID: "hive-eth", Asset0: "HIVE", Asset1: "ETH", Reserve0: 4000000, Reserve1: 2000, Fee: 30},
+		{ID: "hbd-spk", Asset0: "HBD", Asset1: "SPK", Reserve0: 1000000, Reserve1: 1000000, Fee: 30},
+		{ID: "spk-hive", Asset0: "SPK", Asset1: "HIVE", Reserve0: 1000000, Reserve1: 4000000, Fee: 30},
+	}})
+	require.NoError(t, svc.SetRoutingConfig(config))
+	return svc
+}
+
+func TestRoutingConfigValidation(t *testing.T) {
+	svc := NewService(VSCConfig{}, &mockDEXExecutor{})
+	assert.Equal(t, DefaultRoutingConfig(), svc.routing)
+
+	tests := []struct {
+		name   string
+		modify func(c *RoutingConfig)
+	}{
+		{"no hops", func(c *RoutingConfig) { c.MaxHops = 0 }},
+		{"too many hops", func(c *RoutingConfig) { c.MaxHops = maxRoutingHops + 1 }},
+		{"no candidates", func(c *RoutingConfig) { c.MaxRouteCandidates = 0 }},
+		{"no legs", func(c *RoutingConfig) { c.MaxSplitLegs = 0 }},
+		{"reserve usage above 100%", func(c *RoutingConfig) { c.MaxReserveUsageBps = 10001 }},
+		{"negative hop cost", func(c *RoutingConfig) { c.HopCost = -1 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultRoutingConfig()
+			tt.modify(&config)
+			assert.Error(t, svc.SetRoutingConfig(config))
+			assert.Equal(t, DefaultRoutingConfig(), svc.routing, "invalid limits are not applied")
+		})
+	}
+}
+
+func TestRoutingMaxHops(t *testing.T) {
+	ctx := context.Background()
+	params := SwapParams{AssetIn: "BTC", AssetOut: "ETH", AmountIn: 100000}
+
+	// BTC -> ETH takes three pools
+	svc := newRoutingTestService(t, DefaultRoutingConfig())
+	_, err := svc.Quote(ctx, params)
+	assert.ErrorContains(t, err, "no route found")
+
+	config := DefaultRoutingConfig()
+	config.MaxHops = 3
+	svc = newRoutingTestService(t, config)
+	quote, err := svc.Quote(ctx, params)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"BTC", "HBD", "HIVE", "ETH"}, quote.Route)
+	assert.Len(t, quote.Hops, 3)
+
+	// One hop allows only direct pools
+	config.MaxHops = 1
+	svc = newRoutingTestService(t, config)
+	_, err = svc.Quote(ctx, SwapParams{AssetIn: "BTC", AssetOut: "HIVE", AmountIn: 100000})
+	assert.ErrorContains(t, err, "no route found")
+}
+
+func TestRoutingCandidatesShortestFirst(t *testing.T) {
+	config := DefaultRoutingConfig()
+	config.MaxRouteCandidates = 1
+	svc := newRoutingTestService(t, config)
+
+	routes, err := svc.candidateRoutes("HBD", "HIVE", nil, nil)
+	require.NoError(t, err)
+	require.Len(t, routes, 1)
+	assert.Equal(t, "hbd-hive", routes[0][0].ID)
+
+	config.MaxRouteCandidates = 10
+	require.NoError(t, svc.SetRoutingConfig(config))
+	routes, err = svc.candidateRoutes("HBD", "HIVE", nil, nil)
+	require.NoError(t, err)
+	require.Len(t, routes, 2)
+	assert.Len(t, routes[1], 2, "the SPK route is longer")
+}
+
+func TestRoutingSplitLegs(t *testing.T) {
+	ctx := context.Background()
+	params := SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 200000}
+
+	single, err := newRoutingTestService(t, DefaultRoutingConfig()).Quote(ctx, params)
+	require.NoError(t, err)
+	assert.Empty(t, single.Legs)
+
+	config := DefaultRoutingConfig()
+	config.MaxSplitLegs = 2
+	split, err := newRoutingTestService(t, config).Quote(ctx, params)
+	require.NoError(t, err)
+
+	// A large swap gets more by spreading across both routes
+	require.Len(t, split.Legs, 2)
+	assert.Greater(t, split.AmountOut, single.AmountOut)
+	assert.Equal(t, params.AmountIn, split.Legs[0].AmountIn+split.Legs[1].AmountIn)
+	assert.Equal(t, split.AmountOut, split.Legs[0].AmountOut+split.Legs[1].AmountOut)
+	assert.Equal(t, []string{"HBD", "HIVE"}, split.Route, "the larger leg goes direct")
+	assert.Len(t, split.Hops, 3)
+
+	// A small swap is not worth splitting
+	small, err := newRoutingTestService(t, config).Quote(ctx, SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 100})
+	require.NoError(t, err)
+	assert.Empty(t, small.Legs)
+}
+
+func TestSplitQuotesAreNotExecuted(t *testing.T) {
+	ctx := context.Background()
+	params := SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 200000, MaxSlippage: 200}
+	config := DefaultRoutingConfig()
+	config.MaxSplitLegs = 2
+
+	t.Run("swap takes the best single route", func(t *testing.T) {
+		svc := newRoutingTestService(t, config)
+		single, err := newRoutingTestService(t, DefaultRoutingConfig()).Quote(ctx, params)
+		require.NoError(t, err)
+
+		result, err := svc.ExecuteSwap(ctx, params)
+		require.NoError(t, err)
+		require.True(t, result.Success, result.ErrorMessage)
+		assert.Equal(t, single.AmountOut, result.EstimatedAmountOut)
+		assert.Equal(t, single.Route, result.Route)
+	})
+
+	split, err := newRoutingTestService(t, config).Quote(ctx, params)
+	require.NoError(t, err)
+	require.Len(t, split.Legs, 2)
+	swap := params
+	swap.Quote = split
+
+	t.Run("swap refused", func(t *testing.T) {
+		svc := newRoutingTestService(t, config)
+		result, err := svc.ExecuteSwap(ctx, swap)
+		require.NoError(t, err)
+		assert.False(t, result.Success)
+		assert.Contains(t, result.ErrorMessage, "splits the swap across 2 routes")
+		assert.Empty(t, svc.dexExecutor.(*mockDEXExecutor).executedOperations)
+	})
+
+	t.Run("job refused", func(t *testing.T) {
+		svc := newRoutingTestService(t, config)
+		_, err := svc.SubmitSwap(swap)
+		assert.ErrorContains(t, err, "splits the swap across 2 routes")
+	})
+
+	t.Run("batch refused", func(t *testing.T) {
+		executor := &mockBatchExecutor{}
+		svc := newRoutingTestService(t, config)
+		svc.dexExecutor = executor
+		batchSwap := swap
+		result, err := svc.ExecuteBatch(ctx, BatchParams{Sender: "alice", Operations: []BatchOperation{{Swap: &batchSwap}}})
+		require.NoError(t, err)
+		assert.False(t, result.Success)
+		assert.Contains(t, result.ErrorMessage, "splits the swap across 2 routes")
+		assert.Empty(t, executor.batches)
+	})
+}
+
+func TestRoutingSkipsDustPools(t *testing.T) {
+	ctx := context.Background()
+	params := SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000}
+
+	// The HBD -> SPK -> HIVE route would otherwise be a candidate
+	config := DefaultRoutingConfig()
+	config.MinPoolReserve = 1000001
+	svc := newRoutingTestService(t, config)
+	svc.poolQuerier.(*mockPoolQuerier).pools = append(svc.poolQuerier.(*mockPoolQuerier).pools,
+		IndexerPoolInfo{ID: "hbd-hive-dust", Asset0: "HBD", Asset1: "HIVE", Reserve0: 10, Reserve1: 1000000000, Fee: 30})
+
+	routes, err := svc.candidateRoutes("HBD", "HIVE", nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, routes, "every pool holds less than the minimum on one side")
+
+	config.MinPoolReserve = 1000
+	require.NoError(t, svc.SetRoutingConfig(config))
+	quote, err := svc.Quote(ctx, params)
+	require.NoError(t, err)
+	for _, hop := range quote.Hops {
+		assert.NotEqual(t, "hbd-hive-dust", hop.PoolID)
+	}
+}
+
+func TestRoutingSkipsConcentratedPools(t *testing.T) {
+	ctx := context.Background()
+	params := SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000}
+
+	// Its reserves would quote far more HIVE than any other pool
+	svc := newRoutingTestService(t, DefaultRoutingConfig())
+	svc.poolQuerier.(*mockPoolQuerier).pools = append(svc.poolQuerier.(*mockPoolQuerier).pools,
+		IndexerPoolInfo{ID: "hbd-hive-cl", Type: poolTypeConcentrated, Asset0: "HBD", Asset1: "HIVE", Reserve0: 1000000, Reserve1: 8000000, Fee: 30})
+
+	quote, err := svc.Quote(ctx, params)
+	require.NoError(t, err)
+	for _, hop := range quote.Hops {
+		assert.NotEqual(t, "hbd-hive-cl", hop.PoolID)
+	}
+
+	// Nor is it quoted when a route names it
+	pool, err := svc.poolQuerier.GetPoolByID("hbd-hive-cl")
+	require.NoError(t, err)
+	_, ok := quoteRoute("HBD", 1000, []IndexerPoolInfo{*pool})
+	assert.False(t, ok)
+}
+
+func TestRoutingMaxReserveUsage(t *testing.T) {
+	ctx := context.Background()
+
+	// 600000 HBD is 60% of both HBD/HIVE routes' first pool
+	svc := newRoutingTestService(t, DefaultRoutingConfig())
+	_, err := svc.Quote(ctx, SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 600000})
+	assert.ErrorContains(t, err, "more than 5000 bps of a pool's reserves")
+
+	quote, err := svc.Quote(ctx, SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 400000})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"HBD", "HIVE"}, quote.Route)
+
+	config := DefaultRoutingConfig()
+	config.MaxReserveUsageBps = 0
+	svc = newRoutingTestService(t, config)
+	_, err = svc.Quote(ctx, SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 600000})
+	assert.NoError(t, err, "the limit can be disabled")
+}
+
+func TestRoutingCosts(t *testing.T) {
+	ctx := context.Background()
+
+	// The route through SPK returns slightly more HBD than the direct pool
+	newService := func(config RoutingConfig) *Service {
+		svc := NewService(VSCConfig{}, &mockDEXExecutor{})
+		svc.SetPoolQuerier(&mockPoolQuerier{pools: []IndexerPoolInfo{
+			{ID: "hive-hbd", Asset0: "HIVE", Asset1: "HBD", Reserve0: 4000000, Reserve1: 1000000, Fee: 30},
+			{ID: "hive-spk", Asset0: "HIVE", Asset1: "SPK", Reserve0: 4000000, Reserve1: 1000000, Fee: 0},
+			{ID: "spk-hbd", Asset0: "SPK", Asset1: "HBD", Reserve0: 1000000, Reserve1: 1010000, Fee: 0},
+		}})
+		require.NoError(t, svc.SetRoutingConfig(config))
+		return svc
+	}
+	params := SwapParams{AssetIn: "HIVE", AssetOut: "HBD", AmountIn: 40000}
+
+	quote, err := newService(DefaultRoutingConfig()).QuoteCandidates(ctx, params, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"HIVE", "SPK", "HBD"}, quote.Route)
+	assert.Nil(t, quote.Cost)
+	require.Len(t, quote.Candidates, 2)
+	assert.Less(t, quote.Candidates[1].AmountOut, quote.AmountOut)
+
+	// Once each hop costs 50 HBD, the direct route nets more
+	config := DefaultRoutingConfig()
+	config.TxCost, config.HopCost = 100, 50
+	quote, err = newService(config).QuoteCandidates(ctx, params, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"HIVE", "HBD"}, quote.Route)
+	require.NotNil(t, quote.Cost)
+	assert.Equal(t, RouteCost{HBD: 150, AmountOut: 150}, *quote.Cost)
+	assert.Equal(t, quote.AmountOut-150, quote.NetAmountOut)
+
+	require.Len(t, quote.Candidates, 2)
+	assert.Equal(t, int64(200), quote.Candidates[1].Cost.HBD)
+	assert.Greater(t, quote.Candidates[1].AmountOut, quote.AmountOut)
+	assert.Less(t, quote.Candidates[1].NetAmountOut, quote.NetAmountOut)
+}
+
+func TestRoutingCostsPricedInOutputAsset(t *testing.T) {
+	config := DefaultRoutingConfig()
+	config.TxCost, config.HopCost = 10, 5
+	svc := newRoutingTestService(t, config)
+
+	// Costs are valued at the HBD/HIVE pool's price of 4 HIVE per HBD
+	quote, err := svc.Quote(context.Background(), SwapParams{AssetIn: "BTC", AssetOut: "HIVE", AmountIn: 100000})
+	require.NoError(t, err)
+	require.Len(t, quote.Hops, 2)
+	assert.Equal(t, RouteCost{HBD: 20, AmountOut: 80}, *quote.Cost)
+	assert.Equal(t, quote.AmountOut-80, quote.NetAmountOut)
+
+	// Without an HBD pool the cost cannot be priced, and routes compare as
+	// before
+	quote, err = svc.Quote(context.Background(), SwapParams{AssetIn: "HIVE", AssetOut: "ETH", AmountIn: 100000})
+	require.NoError(t, err)
+	assert.Equal(t, RouteCost{HBD: 15}, *quote.Cost)
+	assert.Equal(t, quote.AmountOut, quote.NetAmountOut)
+}
@@ -0,0 +1,286 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultIdempotencyTTL is how long a swap's result is kept for retries with
+// the same idempotency key
+const defaultIdempotencyTTL = 24 * time.Hour
+
+// accountSequencer gives each account's broadcasts a turn, first come first
+// served, so an account's transactions are signed and sent one at a time and
+// in the order they were requested. Different accounts broadcast in
+// parallel, up to an optional limit.
+type accountSequencer struct {
+	mu     sync.Mutex
+	queues map[string][]*accountTurn // Waiting turns per account; the head's is ready
+	slots  chan struct{}             // Held by each account broadcasting; nil for no limit
+}
+
+// accountTurn is a place in an account's broadcast queue
+type accountTurn struct {
+	account string
+	ready   chan struct{} // Closed when the turn reaches the head of the queue
+	taken   bool          // Set once a broadcast has waited on it
+}
+
+func newAccountSequencer() *accountSequencer {
+	return &accountSequencer{queues: make(map[string][]*accountTurn)}
+}
+
+// reserve queues a turn for account without waiting for it
+func (q *accountSequencer) reserve(account string) *accountTurn {
+	turn := &accountTurn{account: account, ready: make(chan struct{})}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	waiting := q.queues[account]
+	q.queues[account] = append(waiting, turn)
+	if len(waiting) == 0 {
+		close(turn.ready)
+	}
+	return turn
+}
+
+// acquire waits for account's turn, returning a function that ends it
+func (q *accountSequencer) acquire(ctx context.Context, account string) (func(), error) {
+	return q.wait(ctx, q.reserve(account))
+}
+
+// wait waits for a reserved turn, then for a broadcast slot shared with
+// other accounts, returning a function that ends the turn
+func (q *accountSequencer) wait(ctx context.Context, turn *accountTurn) (func(), error) {
+	select {
+	case <-turn.ready:
+	case <-ctx.Done():
+		q.leave(turn)
+		return nil, ctx.Err()
+	}
+
+	q.mu.Lock()
+	slots := q.slots
+	q.mu.Unlock()
+	if slots == nil {
+		return func() { q.leave(turn) }, nil
+	}
+	select {
+	case slots <- struct{}{}:
+		return func() {
+			<-slots
+			q.leave(turn)
+		}, nil
+	case <-ctx.Done():
+		q.leave(turn)
+		return nil, ctx.Err()
+	}
+}
+
+// take claims a turn reserved in ctx for account, if it has not been used,
+// or reserves a new one
+func (q *accountSequencer) take(ctx context.Context, account string) *accountTurn {
+	if turn, ok := ctx.Value(reservedTurnKey{}).(*accountTurn); ok && turn.account == account {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if !turn.taken {
+			turn.taken = true
+			return turn
+		}
+	}
+	return q.reserve(account)
+}
+
+// setParallelism limits how many accounts broadcast at once; 0 or less
+// removes the limit. Turns already held keep the slot they took.
+func (q *accountSequencer) setParallelism(n int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if n <= 0 {
+		q.slots = nil
+		return
+	}
+	q.slots = make(chan struct{}, n)
+}
+
+// leave removes a turn from its account's queue, passing the turn on if it
+// held it. Leaving a turn that has already left does nothing.
+func (q *accountSequencer) leave(turn *accountTurn) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	waiting := q.queues[turn.account]
+	for i, t := range waiting {
+		if t != turn {
+			continue
+		}
+		waiting = append(waiting[:i], waiting[i+1:]...)
+		if len(waiting) == 0 {
+			delete(q.queues, turn.account)
+			return
+		}
+		q.queues[turn.account] = waiting
+		if i == 0 {
+			close(waiting[0].ready)
+		}
+		return
+	}
+}
+
+type reservedTurnKey struct{}
+
+// withReservedTurn returns a context whose first broadcast for the turn's
+// account uses the turn, so a swap queued ahead of others broadcasts ahead
+// of them however long it takes to prepare
+func withReservedTurn(ctx context.Context, turn *accountTurn) context.Context {
+	return context.WithValue(ctx, reservedTurnKey{}, turn)
+}
+
+// SetAccountParallelism limits how many accounts may broadcast at once. An
+// account's own broadcasts always go out one at a time, in order; 0 lets
+// every account broadcast in parallel.
+func (s *Service) SetAccountParallelism(n int) {
+	s.accounts.setParallelism(n)
+}
+
+// submitInTurn broadcasts an operation once it is account's turn
+func (s *Service) submitInTurn(ctx context.Context, account string, operationType string, payload string, intents []Intent) (string, error) {
+	var txID string
+	err := s.inTurn(ctx, account, func() (err error) {
+		txID, err = s.submitOperation(ctx, operationType, payload, intents)
+		return err
+	})
+	return txID, err
+}
+
+// inTurn runs a broadcast once it is account's turn
+func (s *Service) inTurn(ctx context.Context, account string, broadcast func() error) error {
+	release, err := s.accounts.wait(ctx, s.accounts.take(ctx, account))
+	if err != nil {
+		return err
+	}
+	defer release()
+	return broadcast()
+}
+
+// idempotentSwap is the one execution of a swap behind an idempotency key
+type idempotentSwap struct {
+	fingerprint string
+	jobID       string        // Set if the swap was submitted as a job
+	done        chan struct{} // Closed once result is set
+	result      *SwapResult
+	expires     time.Time
+}
+
+// idempotencyStore remembers swaps by account and idempotency key, so a
+// retried request returns the original result instead of swapping again
+type idempotencyStore struct {
+	mu      sync.Mutex
+	entries map[string]*idempotentSwap
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+func newIdempotencyStore() *idempotencyStore {
+	return &idempotencyStore{
+		entries: make(map[string]*idempotentSwap),
+		ttl:     defaultIdempotencyTTL,
+		now:     time.Now,
+	}
+}
+
+// SetIdempotencyTTL sets how long a swap's result is returned for retries
+// that reuse its idempotency key
+func (s *Service) SetIdempotencyTTL(ttl time.Duration) {
+	s.idempotency.mu.Lock()
+	defer s.idempotency.mu.Unlock()
+	s.idempotency.ttl = ttl
+}
+
+// begin claims an idempotency key for a swap. It reports true if the caller
+// must run the swap, or false with the existing execution to wait on. A key
+// reused for a different swap is an error.
+func (st *idempotencyStore) begin(params SwapParams) (*idempotentSwap, bool, error) {
+	key := params.Sender + "\x00" + params.IdempotencyKey
+	fingerprint := swapFingerprint(params)
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	now := st.now()
+	for k, entry := range st.entries {
+		if entry.result != nil && now.After(entry.expires) {
+			delete(st.entries, k)
+		}
+	}
+
+	if entry, ok := st.entries[key]; ok {
+		if entry.fingerprint != fingerprint {
+			return nil, false, fmt.Errorf("idempotency key %q was already used for a different swap", params.IdempotencyKey)
+		}
+		return entry, false, nil
+	}
+
+	entry := &idempotentSwap{fingerprint: fingerprint, done: make(chan struct{})}
+	st.entries[key] = entry
+	return entry, true, nil
+}
+
+// setJob records the job a claimed swap runs as
+func (st *idempotencyStore) setJob(entry *idempotentSwap, jobID string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	entry.jobID = jobID
+}
+
+// jobOf returns the job a swap runs as, if it was submitted as one
+func (st *idempotencyStore) jobOf(entry *idempotentSwap) string {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return entry.jobID
+}
+
+// finish records a swap's result for later retries. A swap that failed
+// without broadcasting anything is forgotten instead, so it can be retried
+// with the same key.
+func (st *idempotencyStore) finish(params SwapParams, entry *idempotentSwap, result *SwapResult) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	entry.result = result
+	entry.expires = st.now().Add(st.ttl)
+	if !result.Success && result.TxID == "" {
+		delete(st.entries, params.Sender+"\x00"+params.IdempotencyKey)
+	}
+	close(entry.done)
+}
+
+// wait returns the result of a swap already running under the same key
+func (entry *idempotentSwap) wait(ctx context.Context) *SwapResult {
+	select {
+	case <-entry.done:
+		result := *entry.result
+		return &result
+	case <-ctx.Done():
+		return &SwapResult{
+			Success:      false,
+			ErrorMessage: fmt.Sprintf("swap cancelled while waiting for duplicate: %v", ctx.Err()),
+		}
+	}
+}
+
+// swapFingerprint identifies what a swap does, so a key cannot be reused for
+// a different trade. The deadline is left out, as clients retrying a request
+// commonly recompute it.
+func swapFingerprint(params SwapParams) string {
+	var returnAddress string
+	if params.ReturnAddress != nil {
+		returnAddress = params.ReturnAddress.Chain + ":" + params.ReturnAddress.Address
+	}
+	return fmt.Sprintf("%s|%s|%d|%d|%d|%s|%d|%s|%t|%s",
+		params.AssetIn, params.AssetOut, params.AmountIn, params.MinAmountOut, params.MaxSlippage,
+		params.Beneficiary, params.RefBps, strings.Join(params.Route, ","), params.CommitReveal, returnAddress)
+}
@@ -0,0 +1,365 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gatedSubmitter blocks each broadcast until released, counting broadcasts
+// and failing them while err is set
+type gatedSubmitter struct {
+	mockDEXExecutor
+	mu      sync.Mutex
+	release chan struct{}
+	calls   int
+	err     error
+}
+
+func (g *gatedSubmitter) SubmitDexOperation(ctx context.Context, operationType string, payload string, intents []Intent) (string, error) {
+	if g.release != nil {
+		<-g.release
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.calls++
+	if g.err != nil {
+		return "", g.err
+	}
+	return fmt.Sprintf("tx-%d", g.calls), nil
+}
+
+func (g *gatedSubmitter) broadcasts() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.calls
+}
+
+// queued returns how many turns are waiting or held for account
+func (q *accountSequencer) queued(account string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.queues[account])
+}
+
+func TestAccountSequencer_FirstComeFirstServed(t *testing.T) {
+	q := newAccountSequencer()
+	release, err := q.acquire(context.Background(), "alice")
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	for i := 1; i <= 3; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			done, err := q.acquire(context.Background(), "alice")
+			if !assert.NoError(t, err) {
+				return
+			}
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			done()
+		}(i)
+		// Queue each waiter before starting the next
+		require.Eventually(t, func() bool { return q.queued("alice") == i+1 }, time.Second, time.Millisecond)
+	}
+
+	// Other accounts are not held up
+	other, err := q.acquire(context.Background(), "bob")
+	require.NoError(t, err)
+	other()
+
+	release()
+	wg.Wait()
+	assert.Equal(t, []int{1, 2, 3}, order)
+	assert.Zero(t, q.queued("alice"))
+}
+
+func TestAccountSequencer_CancelledWaiterLeavesQueue(t *testing.T) {
+	q := newAccountSequencer()
+	release, err := q.acquire(context.Background(), "alice")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = q.acquire(ctx, "alice")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	release()
+	next, err := q.acquire(context.Background(), "alice")
+	require.NoError(t, err)
+	next()
+	assert.Zero(t, q.queued("alice"))
+}
+
+func TestExecuteSwap_IdempotencyKeyBroadcastsOnce(t *testing.T) {
+	executor := &gatedSubmitter{release: make(chan struct{})}
+	svc := NewService(VSCConfig{}, executor)
+	params := SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000, IdempotencyKey: "order-1"}
+
+	results := make([]*SwapResult, 3)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = svc.ExecuteSwap(context.Background(), params)
+		}(i)
+	}
+	close(executor.release)
+	wg.Wait()
+
+	assert.Equal(t, 1, executor.broadcasts())
+	for _, result := range results {
+		require.True(t, result.Success, result.ErrorMessage)
+		assert.Equal(t, "tx-1", result.TxID)
+	}
+
+	// A later retry gets the same result without broadcasting
+	retry, err := svc.ExecuteSwap(context.Background(), params)
+	require.NoError(t, err)
+	assert.Equal(t, "tx-1", retry.TxID)
+	assert.Equal(t, 1, executor.broadcasts())
+
+	// The key is per account
+	params.Sender = "bob"
+	other, err := svc.ExecuteSwap(context.Background(), params)
+	require.NoError(t, err)
+	assert.Equal(t, "tx-2", other.TxID)
+}
+
+func TestExecuteSwap_IdempotencyKeyReusedForDifferentSwap(t *testing.T) {
+	executor := &gatedSubmitter{}
+	svc := NewService(VSCConfig{}, executor)
+
+	_, err := svc.ExecuteSwap(context.Background(), SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000, IdempotencyKey: "order-1"})
+	require.NoError(t, err)
+
+	result, err := svc.ExecuteSwap(context.Background(), SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 2000, IdempotencyKey: "order-1"})
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.ErrorMessage, "already used for a different swap")
+	assert.Equal(t, 1, executor.broadcasts())
+}
+
+func TestExecuteSwap_FailedBroadcastCanBeRetried(t *testing.T) {
+	executor := &gatedSubmitter{err: errors.New("node unavailable")}
+	svc := NewService(VSCConfig{}, executor)
+	params := SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000, IdempotencyKey: "order-1"}
+
+	result, err := svc.ExecuteSwap(context.Background(), params)
+	require.NoError(t, err)
+	require.False(t, result.Success)
+
+	executor.err = nil
+	result, err = svc.ExecuteSwap(context.Background(), params)
+	require.NoError(t, err)
+	assert.True(t, result.Success, result.ErrorMessage)
+	assert.Equal(t, 2, executor.broadcasts())
+}
+
+func TestExecuteSwap_IdempotencyExpires(t *testing.T) {
+	executor := &gatedSubmitter{}
+	svc := NewService(VSCConfig{}, executor)
+	now := time.Now()
+	svc.idempotency.now = func() time.Time { return now }
+	svc.SetIdempotencyTTL(time.Minute)
+	params := SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000, IdempotencyKey: "order-1"}
+
+	_, err := svc.ExecuteSwap(context.Background(), params)
+	require.NoError(t, err)
+
+	now = now.Add(2 * time.Minute)
+	_, err = svc.ExecuteSwap(context.Background(), params)
+	require.NoError(t, err)
+	assert.Equal(t, 2, executor.broadcasts())
+}
+
+func TestSubmitSwap_IdempotencyKeyReturnsSameJob(t *testing.T) {
+	svc := NewService(VSCConfig{}, &gatedSubmitter{})
+	params := SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000, IdempotencyKey: "order-1"}
+
+	first, err := svc.SubmitSwap(params)
+	require.NoError(t, err)
+	second, err := svc.SubmitSwap(params)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	// A synchronous retry waits for the job's swap rather than repeating it
+	result, err := svc.ExecuteSwap(context.Background(), params)
+	require.NoError(t, err)
+	assert.Equal(t, "tx-1", result.TxID)
+}
+
+func TestServer_IdempotencyKeyHeader(t *testing.T) {
+	executor := &gatedSubmitter{}
+	server := NewServer(NewService(VSCConfig{}, executor), "0")
+	body := `{"fromAsset": "HBD", "toAsset": "HIVE", "amount": 1000, "sender": "alice"}`
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/swap", strings.NewReader(body))
+		req.Header.Set("Idempotency-Key", "order-1")
+		w := httptest.NewRecorder()
+		server.http.Handler.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var result SwapResult
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+		assert.Equal(t, "tx-1", result.TxID)
+	}
+	assert.Equal(t, 1, executor.broadcasts())
+}
+
+// overlapSubmitter records how many broadcasts run at once, overall and per
+// account, holding each one briefly so overlaps show
+type overlapSubmitter struct {
+	mockDEXExecutor
+	mu         sync.Mutex
+	inFlight   map[string]int
+	total      int
+	maxTotal   int
+	overlapped bool // An account had two broadcasts in flight
+	order      map[string][]int64
+}
+
+func newOverlapSubmitter() *overlapSubmitter {
+	return &overlapSubmitter{inFlight: make(map[string]int), order: make(map[string][]int64)}
+}
+
+func (o *overlapSubmitter) SubmitDexOperation(ctx context.Context, operationType string, payload string, intents []Intent) (string, error) {
+	var swap struct {
+		Recipient string `json:"recipient"`
+		MinOut    int64  `json:"min_amount_out"`
+	}
+	if err := json.Unmarshal([]byte(payload), &swap); err != nil {
+		return "", err
+	}
+
+	o.mu.Lock()
+	o.inFlight[swap.Recipient]++
+	o.total++
+	if o.inFlight[swap.Recipient] > 1 {
+		o.overlapped = true
+	}
+	if o.total > o.maxTotal {
+		o.maxTotal = o.total
+	}
+	o.order[swap.Recipient] = append(o.order[swap.Recipient], swap.MinOut)
+	o.mu.Unlock()
+
+	time.Sleep(2 * time.Millisecond)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.inFlight[swap.Recipient]--
+	o.total--
+	return fmt.Sprintf("tx-%s-%d", swap.Recipient, swap.MinOut), nil
+}
+
+func TestAccountSequencer_Parallelism(t *testing.T) {
+	q := newAccountSequencer()
+	q.setParallelism(2)
+
+	alice, err := q.acquire(context.Background(), "alice")
+	require.NoError(t, err)
+	bob, err := q.acquire(context.Background(), "bob")
+	require.NoError(t, err)
+
+	// A third account waits for a slot
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = q.acquire(ctx, "carol")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Zero(t, q.queued("carol"), "a cancelled wait for a slot leaves the queue")
+
+	acquired := make(chan func())
+	go func() {
+		release, err := q.acquire(context.Background(), "carol")
+		assert.NoError(t, err)
+		acquired <- release
+	}()
+	alice()
+	select {
+	case release := <-acquired:
+		release()
+	case <-time.After(time.Second):
+		t.Fatal("carol never got alice's slot")
+	}
+	bob()
+}
+
+func TestExecuteSwap_ConcurrentAccounts(t *testing.T) {
+	executor := newOverlapSubmitter()
+	svc := NewService(VSCConfig{}, executor)
+	svc.SetPoolQuerier(&lockedPoolQuerier{pool: mockPoolQuerier{pools: []IndexerPoolInfo{
+		{ID: "hbd-hive", Asset0: "HBD", Asset1: "HIVE", Reserve0: 1000000000, Reserve1: 4000000000, Fee: 30},
+	}}})
+	svc.SetAccountParallelism(3)
+
+	accounts := []string{"alice", "bob", "carol", "dave", "erin"}
+	const swapsPerAccount = 6
+
+	var wg sync.WaitGroup
+	for _, account := range accounts {
+		wg.Add(1)
+		go func(account string) {
+			defer wg.Done()
+			// Each account submits in order; MinAmountOut numbers its swaps
+			for i := 1; i <= swapsPerAccount; i++ {
+				jobID, err := svc.SubmitSwap(SwapParams{Sender: account, AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000, MinAmountOut: int64(i)})
+				if !assert.NoError(t, err) {
+					return
+				}
+				_ = jobID
+			}
+		}(account)
+		wg.Add(1)
+		go func(account string) {
+			defer wg.Done()
+			// Synchronous swaps from the same accounts run alongside
+			result, err := svc.ExecuteSwap(context.Background(), SwapParams{Sender: account, AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000, MinAmountOut: 100})
+			if assert.NoError(t, err) {
+				assert.True(t, result.Success, result.ErrorMessage)
+			}
+		}(account)
+	}
+	wg.Wait()
+
+	require.Eventually(t, func() bool {
+		executor.mu.Lock()
+		defer executor.mu.Unlock()
+		n := 0
+		for _, swaps := range executor.order {
+			n += len(swaps)
+		}
+		return n == len(accounts)*(swapsPerAccount+1)
+	}, 5*time.Second, 5*time.Millisecond)
+
+	executor.mu.Lock()
+	defer executor.mu.Unlock()
+	assert.False(t, executor.overlapped, "an account broadcast twice at once")
+	assert.LessOrEqual(t, executor.maxTotal, 3)
+	for _, account := range accounts {
+		// Queued swaps go out in the order they were submitted
+		var queued []int64
+		for _, minOut := range executor.order[account] {
+			if minOut != 100 {
+				queued = append(queued, minOut)
+			}
+		}
+		assert.Equal(t, []int64{1, 2, 3, 4, 5, 6}, queued, account)
+	}
+}
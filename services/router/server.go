@@ -2,17 +2,28 @@ package router
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+
+	"github.com/vsc-eco/vsc-dex-mapping/schemas"
 )
 
 // Server provides HTTP API for DEX routing
 type Server struct {
-	router *Service
-	http   *http.Server
+	router     *Service
+	http       *http.Server
+	adminToken string // Bearer token for admin endpoints; empty disables them
 }
 
 // NewServer creates a new HTTP server for the router service
@@ -26,12 +37,87 @@ func NewServer(svc *Service, port string) *Server {
 	// Route computation endpoint
 	r.HandleFunc("/api/v1/route", s.handleComputeRoute).Methods("POST")
 
+	// Synchronous execution endpoints
+	r.HandleFunc("/api/v1/swap", s.handleComputeRoute).Methods("POST")
+	r.HandleFunc("/api/v1/deposit", s.handleExecuteDeposit).Methods("POST")
+	r.HandleFunc("/api/v1/withdraw", s.handleExecuteWithdrawal).Methods("POST")
+
+	// Order lookup across swap jobs, TWAP and DCA orders and swap-ins
+	r.HandleFunc("/api/v1/orders/{id}", s.handleGetOrder).Methods("GET")
+
+	// Quote endpoint (read-only, never executes)
+	r.HandleFunc("/api/v1/quote", s.handleQuote).Methods("POST")
+	r.HandleFunc("/api/v1/quote/ws", s.handleQuoteStream).Methods("GET")
+
+	// Asynchronous swap endpoints
+	r.HandleFunc("/api/v1/swaps", s.handleSubmitSwap).Methods("POST")
+	r.HandleFunc("/api/v1/swaps/{id}", s.handleGetSwapStatus).Methods("GET")
+
+	// TWAP order endpoints
+	r.HandleFunc("/api/v1/twap", s.handleSubmitTWAP).Methods("POST")
+	r.HandleFunc("/api/v1/twap/{id}", s.handleGetTWAP).Methods("GET")
+
+	// Cross-chain swap-in endpoints
+	r.HandleFunc("/api/v1/swapin", s.handleSubmitSwapIn).Methods("POST")
+	r.HandleFunc("/api/v1/swapin/{id}", s.handleGetSwapIn).Methods("GET")
+
+	// Batched swaps and deposits in one transaction
+	r.HandleFunc("/api/v1/batch", s.handleExecuteBatch).Methods("POST")
+
+	// Single-asset deposits and withdrawals
+	r.HandleFunc("/api/v1/zap", s.handleZapDeposit).Methods("POST")
+	r.HandleFunc("/api/v1/withdraw/single", s.handleWithdrawSingleAsset).Methods("POST")
+
+	// Recurring (DCA) order endpoints
+	r.HandleFunc("/api/v1/dca", s.handleCreateDCA).Methods("POST")
+	r.HandleFunc("/api/v1/dca", s.handleListDCA).Methods("GET")
+	r.HandleFunc("/api/v1/dca/{id}", s.handleGetDCA).Methods("GET")
+	r.HandleFunc("/api/v1/dca/{id}", s.handleCancelDCA).Methods("DELETE")
+	r.HandleFunc("/api/v1/dca/{id}/pause", s.handlePauseDCA).Methods("POST")
+	r.HandleFunc("/api/v1/dca/{id}/resume", s.handleResumeDCA).Methods("POST")
+
+	// Stop-loss and take-profit order endpoints
+	r.HandleFunc("/api/v1/triggers", s.handleCreateTrigger).Methods("POST")
+	r.HandleFunc("/api/v1/triggers", s.handleListTriggers).Methods("GET")
+	r.HandleFunc("/api/v1/triggers/ws", s.handleTriggerEvents).Methods("GET")
+	r.HandleFunc("/api/v1/triggers/{id}", s.handleGetTrigger).Methods("GET")
+	r.HandleFunc("/api/v1/triggers/{id}", s.handleCancelTrigger).Methods("DELETE")
+
+	// Large swaps held for N-of-M approval
+	r.HandleFunc("/api/v1/approvals", s.handleListApprovals).Methods("GET")
+	r.HandleFunc("/api/v1/approvals/{id}", s.handleGetApproval).Methods("GET")
+	r.HandleFunc("/api/v1/approvals/{id}/approve", s.handleApprove).Methods("POST")
+	r.HandleFunc("/api/v1/approvals/{id}/reject", s.handleReject).Methods("POST")
+
+	// Referrer management (admin)
+	r.HandleFunc("/api/v1/referrers", s.handleListReferrers).Methods("GET")
+	r.HandleFunc("/api/v1/referrers", s.handleRegisterReferrer).Methods("POST")
+	r.HandleFunc("/api/v1/referrers/{id}", s.handleGetReferrer).Methods("GET")
+	r.HandleFunc("/api/v1/referrers/{id}", s.handleRemoveReferrer).Methods("DELETE")
+
+	// Live configuration (admin)
+	r.HandleFunc("/api/v1/admin/config", s.handleGetConfig).Methods("GET")
+	r.HandleFunc("/api/v1/admin/reload", s.handleReloadConfig).Methods("POST")
+
+	// Trading volume and fee tiers
+	r.HandleFunc("/api/v1/fee-tiers", s.handleListFeeTiers).Methods("GET")
+	r.HandleFunc("/api/v1/accounts/{account}/volume", s.handleGetAccountVolume).Methods("GET")
+
+	// Receipts of broadcast operations
+	r.HandleFunc("/api/v1/receipts", s.handleListReceipts).Methods("GET")
+	r.HandleFunc("/api/v1/receipts/{id}", s.handleGetReceipt).Methods("GET")
+
 	// Instruction-based swap endpoint
 	r.HandleFunc("/api/v1/instruction", s.handleExecuteInstruction).Methods("POST")
 
 	// Health check
 	r.HandleFunc("/health", s.handleHealth).Methods("GET")
 
+	// Prometheus metrics, when enabled
+	if svc.metrics != nil {
+		r.Handle("/metrics", svc.metrics.Handler()).Methods("GET")
+	}
+
 	s.http = &http.Server{
 		Addr:    ":" + port,
 		Handler: r,
@@ -45,6 +131,12 @@ func (s *Server) Start() error {
 	return s.http.ListenAndServe()
 }
 
+// SetAdminToken sets the bearer token admin endpoints require. They are
+// disabled until one is set.
+func (s *Server) SetAdminToken(token string) {
+	s.adminToken = token
+}
+
 // Stop stops the HTTP server
 func (s *Server) Stop(ctx context.Context) error {
 	return s.http.Shutdown(ctx)
@@ -53,12 +145,23 @@ func (s *Server) Stop(ctx context.Context) error {
 // handleComputeRoute handles route computation requests
 func (s *Server) handleComputeRoute(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		FromAsset   string `json:"fromAsset"`
-		ToAsset     string `json:"toAsset"`
-		Amount      int64  `json:"amount"`
-		MinOut      int64  `json:"minOut,omitempty"`
-		SlippageBps uint64 `json:"slippageBps,omitempty"`
-		Sender      string `json:"sender,omitempty"`
+		FromAsset      string                 `json:"fromAsset"`
+		ToAsset        string                 `json:"toAsset"`
+		Amount         int64                  `json:"amount"`
+		MinOut         int64                  `json:"minOut,omitempty"`
+		SlippageBps    uint64                 `json:"slippageBps,omitempty"`
+		Sender         string                 `json:"sender,omitempty"`
+		Deadline       int64                  `json:"deadline,omitempty"`       // Unix seconds
+		Route          []string               `json:"route,omitempty"`          // Assets to route through, from a quote's candidates
+		CommitReveal   bool                   `json:"commitReveal,omitempty"`   // Commit to a hash of the swap before revealing it
+		IdempotencyKey string                 `json:"idempotencyKey,omitempty"` // Or the Idempotency-Key header; retries return the first result
+		Beneficiary    string                 `json:"beneficiary,omitempty"`    // Referral fee recipient; defaults to the X-API-Key referrer's
+		RefBps         uint64                 `json:"refBps,omitempty"`
+		ReturnAddress  *schemas.ReturnAddress `json:"returnAddress,omitempty"`  // Withdraw the output to this chain address
+		FallbackRoutes int                    `json:"fallbackRoutes,omitempty"` // Other routes to try if the route is refused
+		ExcludePools   []string               `json:"excludePools,omitempty"`
+		Quote          *Quote                 `json:"quote,omitempty"`   // Quote the swap was agreed on; refused if it has gone stale
+		Requote        bool                   `json:"requote,omitempty"` // Move a stale quote to a route that still returns enough
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -66,35 +169,59 @@ func (s *Server) handleComputeRoute(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !s.allowCall(w, r, callExecution) {
+		return
+	}
+
 	// Set defaults
 	if req.SlippageBps == 0 {
 		req.SlippageBps = 50 // 0.5% default slippage
 	}
 
 	params := SwapParams{
-		AssetIn:      req.FromAsset,
-		AssetOut:     req.ToAsset,
-		AmountIn:     req.Amount,
-		MinAmountOut: req.MinOut,
-		MaxSlippage:  req.SlippageBps,
-		Sender:       req.Sender,
+		AssetIn:        req.FromAsset,
+		AssetOut:       req.ToAsset,
+		AmountIn:       req.Amount,
+		MinAmountOut:   req.MinOut,
+		MaxSlippage:    req.SlippageBps,
+		Sender:         req.Sender,
+		Deadline:       unixDeadline(req.Deadline),
+		Route:          req.Route,
+		CommitReveal:   req.CommitReveal,
+		IdempotencyKey: idempotencyKey(r, req.IdempotencyKey),
+		Beneficiary:    req.Beneficiary,
+		RefBps:         req.RefBps,
+		ReturnAddress:  req.ReturnAddress,
+		FallbackRoutes: req.FallbackRoutes,
+		ExcludePools:   req.ExcludePools,
+		Quote:          req.Quote,
+		Requote:        req.Requote,
 	}
+	params = s.router.ApplyReferral(params, r.Header.Get("X-API-Key"))
 
-	result, err := s.router.ComputeRoute(r.Context(), params)
+	result, err := s.router.ComputeRoute(requestContext(r), params)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	if result.ApprovalID != "" {
+		// Held for approval; it is broadcast once approvers sign off
+		w.Header().Set("Location", "/api/v1/approvals/"+result.ApprovalID)
+		w.WriteHeader(http.StatusAccepted)
+	}
 	json.NewEncoder(w).Encode(result)
 }
 
-// handleExecuteInstruction handles instruction-based swap requests
-func (s *Server) handleExecuteInstruction(w http.ResponseWriter, r *http.Request) {
+// handleExecuteDeposit adds liquidity to a pool
+func (s *Server) handleExecuteDeposit(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Instruction []byte `json:"instruction"`
-		AmountIn    int64   `json:"amountIn"`
+		Sender     string `json:"sender"`
+		FromAsset  string `json:"fromAsset"`
+		ToAsset    string `json:"toAsset"`
+		Amount     int64  `json:"amount"`
+		PairAmount int64  `json:"pairAmount,omitempty"` // Amount of toAsset
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -102,25 +229,50 @@ func (s *Server) handleExecuteInstruction(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	if len(req.Instruction) == 0 {
-		http.Error(w, "instruction is required", http.StatusBadRequest)
+	if !s.allowCall(w, r, callExecution) {
 		return
 	}
 
-	if req.AmountIn <= 0 {
-		http.Error(w, "amountIn must be greater than 0", http.StatusBadRequest)
+	result, err := s.router.ExecuteDeposit(requestContext(r), DepositParams{
+		Sender:    req.Sender,
+		AssetIn:   req.FromAsset,
+		AssetOut:  req.ToAsset,
+		AmountIn:  req.Amount,
+		AmountOut: req.PairAmount,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Parse and convert instruction to SwapParams
-	params, err := ParseAndConvertInstruction(req.Instruction, req.AmountIn)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to process instruction: %v", err), http.StatusBadRequest)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleExecuteWithdrawal removes liquidity from a pool
+func (s *Server) handleExecuteWithdrawal(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Sender    string `json:"sender"`
+		FromAsset string `json:"fromAsset"`
+		ToAsset   string `json:"toAsset"`
+		LpAmount  int64  `json:"lpAmount,omitempty"` // Zero withdraws the whole position
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !s.allowCall(w, r, callExecution) {
 		return
 	}
 
-	// Execute the swap
-	result, err := s.router.ComputeRoute(r.Context(), *params)
+	result, err := s.router.ExecuteWithdrawal(requestContext(r), WithdrawalParams{
+		Sender:   req.Sender,
+		AssetIn:  req.FromAsset,
+		AssetOut: req.ToAsset,
+		LpAmount: req.LpAmount,
+	})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -130,6 +282,1191 @@ func (s *Server) handleExecuteInstruction(w http.ResponseWriter, r *http.Request
 	json.NewEncoder(w).Encode(result)
 }
 
+// handleGetOrder returns a swap job, TWAP order, DCA order, swap-in or
+// trigger order by ID
+func (s *Server) handleGetOrder(w http.ResponseWriter, r *http.Request) {
+	order, err := s.router.GetOrder(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+// handleQuote returns the expected output and route for a swap without executing it
+func (s *Server) handleQuote(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		FromAsset  string   `json:"fromAsset"`
+		ToAsset    string   `json:"toAsset"`
+		Amount     int64    `json:"amount"`
+		Candidates int      `json:"candidates,omitempty"` // Also report the top routes compared
+		Route      []string `json:"route,omitempty"`
+		Debug      bool     `json:"debug,omitempty"` // Also report how the route was chosen
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !s.allowCall(w, r, callQuote) {
+		return
+	}
+
+	params := SwapParams{
+		AssetIn:  req.FromAsset,
+		AssetOut: req.ToAsset,
+		AmountIn: req.Amount,
+		Route:    req.Route,
+	}
+
+	ctx := r.Context()
+	var trace *RouteTrace
+	if req.Debug {
+		trace = &RouteTrace{}
+		ctx = WithRouteTrace(ctx, trace)
+	}
+
+	quote, err := s.router.QuoteCandidates(ctx, params, req.Candidates)
+	s.router.metrics.observeQuote(err)
+	if err != nil && trace != nil {
+		// The trace shows why no route could be used
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": err.Error(),
+			"trace": trace,
+		})
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(quote)
+}
+
+// handleQuoteStream streams a swap's quote over a WebSocket, sending it again
+// whenever it changes. The swap is given by the fromAsset, toAsset and amount
+// query parameters, with an optional comma-separated route and a re-quote
+// interval such as 500ms.
+func (s *Server) handleQuoteStream(w http.ResponseWriter, r *http.Request) {
+	if !s.allowCall(w, r, callQuote) {
+		return
+	}
+	query := r.URL.Query()
+	amount, err := strconv.ParseInt(query.Get("amount"), 10, 64)
+	if err != nil {
+		http.Error(w, "amount must be an integer", http.StatusBadRequest)
+		return
+	}
+	var interval time.Duration
+	if value := query.Get("interval"); value != "" {
+		if interval, err = time.ParseDuration(value); err != nil {
+			http.Error(w, "interval must be a duration such as 500ms", http.StatusBadRequest)
+			return
+		}
+	}
+	params := SwapParams{
+		AssetIn:  query.Get("fromAsset"),
+		AssetOut: query.Get("toAsset"),
+		AmountIn: amount,
+	}
+	if route := query.Get("route"); route != "" {
+		params.Route = strings.Split(route, ",")
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	updates, err := s.router.WatchQuote(ctx, params, interval)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	conn, err := triggerUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // Upgrade has already responded
+	}
+	defer conn.Close()
+
+	// Reading is only needed to notice the client going away
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for update := range updates {
+		if err := conn.WriteJSON(update); err != nil {
+			return
+		}
+	}
+}
+
+// handleSubmitSwap queues a swap and responds with its job ID without waiting
+func (s *Server) handleSubmitSwap(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		FromAsset      string                 `json:"fromAsset"`
+		ToAsset        string                 `json:"toAsset"`
+		Amount         int64                  `json:"amount"`
+		MinOut         int64                  `json:"minOut,omitempty"`
+		SlippageBps    uint64                 `json:"slippageBps,omitempty"`
+		Sender         string                 `json:"sender,omitempty"`
+		Deadline       int64                  `json:"deadline,omitempty"`       // Unix seconds
+		Route          []string               `json:"route,omitempty"`          // Assets to route through, from a quote's candidates
+		CommitReveal   bool                   `json:"commitReveal,omitempty"`   // Commit to a hash of the swap before revealing it
+		IdempotencyKey string                 `json:"idempotencyKey,omitempty"` // Or the Idempotency-Key header; retries return the first result
+		Beneficiary    string                 `json:"beneficiary,omitempty"`    // Referral fee recipient; defaults to the X-API-Key referrer's
+		RefBps         uint64                 `json:"refBps,omitempty"`
+		ReturnAddress  *schemas.ReturnAddress `json:"returnAddress,omitempty"`  // Withdraw the output to this chain address
+		FallbackRoutes int                    `json:"fallbackRoutes,omitempty"` // Other routes to try if the route is refused
+		ExcludePools   []string               `json:"excludePools,omitempty"`
+		Quote          *Quote                 `json:"quote,omitempty"`   // Quote the swap was agreed on; refused if it has gone stale
+		Requote        bool                   `json:"requote,omitempty"` // Move a stale quote to a route that still returns enough
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !s.allowCall(w, r, callExecution) {
+		return
+	}
+
+	if req.SlippageBps == 0 {
+		req.SlippageBps = 50 // 0.5% default slippage
+	}
+
+	jobID, err := s.router.SubmitSwap(s.router.ApplyReferral(SwapParams{
+		AssetIn:        req.FromAsset,
+		AssetOut:       req.ToAsset,
+		AmountIn:       req.Amount,
+		MinAmountOut:   req.MinOut,
+		MaxSlippage:    req.SlippageBps,
+		Sender:         req.Sender,
+		Deadline:       unixDeadline(req.Deadline),
+		Route:          req.Route,
+		CommitReveal:   req.CommitReveal,
+		IdempotencyKey: idempotencyKey(r, req.IdempotencyKey),
+		Beneficiary:    req.Beneficiary,
+		RefBps:         req.RefBps,
+		ReturnAddress:  req.ReturnAddress,
+		FallbackRoutes: req.FallbackRoutes,
+		ExcludePools:   req.ExcludePools,
+		Quote:          req.Quote,
+		Requote:        req.Requote,
+	}, r.Header.Get("X-API-Key")))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/v1/swaps/"+jobID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{
+		"jobId":  jobID,
+		"status": string(JobQueued),
+	})
+}
+
+// handleGetSwapStatus returns the progress of a submitted swap
+func (s *Server) handleGetSwapStatus(w http.ResponseWriter, r *http.Request) {
+	job, err := s.router.GetSwapStatus(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// handleSubmitTWAP schedules a swap split into slices over a time window
+func (s *Server) handleSubmitTWAP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		FromAsset     string `json:"fromAsset"`
+		ToAsset       string `json:"toAsset"`
+		Amount        int64  `json:"amount"`
+		MinOut        int64  `json:"minOut,omitempty"`
+		SlippageBps   uint64 `json:"slippageBps,omitempty"`
+		Sender        string `json:"sender,omitempty"`
+		Slices        int    `json:"slices"`
+		WindowSeconds int64  `json:"windowSeconds"`
+		PriceBandBps  uint64 `json:"priceBandBps,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !s.allowCall(w, r, callExecution) {
+		return
+	}
+
+	if req.SlippageBps == 0 {
+		req.SlippageBps = 50 // 0.5% default slippage
+	}
+
+	orderID, err := s.router.SubmitTWAP(TWAPParams{
+		Swap: SwapParams{
+			AssetIn:      req.FromAsset,
+			AssetOut:     req.ToAsset,
+			AmountIn:     req.Amount,
+			MinAmountOut: req.MinOut,
+			MaxSlippage:  req.SlippageBps,
+			Sender:       req.Sender,
+		},
+		Slices:       req.Slices,
+		Window:       time.Duration(req.WindowSeconds) * time.Second,
+		PriceBandBps: req.PriceBandBps,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/v1/twap/"+orderID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{
+		"orderId": orderID,
+		"status":  string(TWAPRunning),
+	})
+}
+
+// handleGetTWAP returns a TWAP order with its per-slice results
+func (s *Server) handleGetTWAP(w http.ResponseWriter, r *http.Request) {
+	order, err := s.router.GetTWAP(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+// handleSubmitSwapIn accepts a deposit proof from another chain, then mints
+// and swaps the deposit in the background
+func (s *Server) handleSubmitSwapIn(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Chain       string `json:"chain"`
+		Proof       []byte `json:"proof"` // Base64
+		ToAsset     string `json:"toAsset"`
+		MinOut      int64  `json:"minOut,omitempty"`
+		SlippageBps uint64 `json:"slippageBps,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !s.allowCall(w, r, callExecution) {
+		return
+	}
+
+	if req.SlippageBps == 0 {
+		req.SlippageBps = 50 // 0.5% default slippage
+	}
+
+	swapInID, err := s.router.SubmitSwapIn(r.Context(), SwapInParams{
+		Chain:        req.Chain,
+		Proof:        req.Proof,
+		AssetOut:     req.ToAsset,
+		MinAmountOut: req.MinOut,
+		MaxSlippage:  req.SlippageBps,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/v1/swapin/"+swapInID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{
+		"swapInId": swapInID,
+		"status":   string(SwapInMinting),
+	})
+}
+
+// handleGetSwapIn returns a swap-in's combined mint and swap status
+func (s *Server) handleGetSwapIn(w http.ResponseWriter, r *http.Request) {
+	order, err := s.router.GetSwapIn(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+// writeReceiptError maps receipt lookup errors to statuses
+func writeReceiptError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errReceiptsDisabled):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	case errors.Is(err, ErrReceiptNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleListReceipts lists receipts, filtered by account, kind and
+// submission time, and paged with after and limit
+func (s *Server) handleListReceipts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := ReceiptFilter{
+		Account: query.Get("account"),
+		Kind:    ReceiptKind(query.Get("kind")),
+		After:   query.Get("after"),
+	}
+	for name, t := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if value := query.Get(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("%s must be an RFC 3339 time", name), http.StatusBadRequest)
+				return
+			}
+			*t = parsed
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	receipts, err := s.router.ListReceipts(filter)
+	if err != nil {
+		writeReceiptError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"receipts": receipts,
+	})
+}
+
+// handleGetReceipt returns one receipt
+func (s *Server) handleGetReceipt(w http.ResponseWriter, r *http.Request) {
+	receipt, err := s.router.GetReceipt(mux.Vars(r)["id"])
+	if err != nil {
+		writeReceiptError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(receipt)
+}
+
+// handleExecuteBatch submits several swaps and deposits as one transaction
+func (s *Server) handleExecuteBatch(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Sender     string `json:"sender"`
+		Operations []struct {
+			Type        string `json:"type"` // "swap", "deposit" or "withdrawal"
+			FromAsset   string `json:"fromAsset"`
+			ToAsset     string `json:"toAsset"`
+			Amount      int64  `json:"amount"`
+			PairAmount  int64  `json:"pairAmount,omitempty"` // Deposits only: amount of toAsset
+			LpAmount    int64  `json:"lpAmount,omitempty"`   // Withdrawals only; zero withdraws the whole position
+			MinOut      int64  `json:"minOut,omitempty"`
+			SlippageBps uint64 `json:"slippageBps,omitempty"`
+			Deadline    int64  `json:"deadline,omitempty"` // Unix seconds
+		} `json:"operations"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !s.allowCall(w, r, callExecution) {
+		return
+	}
+
+	params := BatchParams{Sender: req.Sender}
+	for i, op := range req.Operations {
+		switch op.Type {
+		case "swap":
+			if op.SlippageBps == 0 {
+				op.SlippageBps = 50 // 0.5% default slippage
+			}
+			params.Operations = append(params.Operations, BatchOperation{Swap: &SwapParams{
+				AssetIn:      op.FromAsset,
+				AssetOut:     op.ToAsset,
+				AmountIn:     op.Amount,
+				MinAmountOut: op.MinOut,
+				MaxSlippage:  op.SlippageBps,
+				Deadline:     unixDeadline(op.Deadline),
+			}})
+		case "deposit":
+			params.Operations = append(params.Operations, BatchOperation{Deposit: &DepositParams{
+				AssetIn:   op.FromAsset,
+				AssetOut:  op.ToAsset,
+				AmountIn:  op.Amount,
+				AmountOut: op.PairAmount,
+			}})
+		case "withdrawal":
+			params.Operations = append(params.Operations, BatchOperation{Withdrawal: &WithdrawalParams{
+				AssetIn:  op.FromAsset,
+				AssetOut: op.ToAsset,
+				LpAmount: op.LpAmount,
+			}})
+		default:
+			http.Error(w, fmt.Sprintf("operation %d has unsupported type %q", i, op.Type), http.StatusBadRequest)
+			return
+		}
+	}
+
+	result, err := s.router.ExecuteBatch(requestContext(r), params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleZapDeposit deposits into a pool from a single asset
+func (s *Server) handleZapDeposit(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Sender      string `json:"sender"`
+		FromAsset   string `json:"fromAsset"`
+		PairAsset   string `json:"pairAsset"`
+		Amount      int64  `json:"amount"`
+		SlippageBps uint64 `json:"slippageBps,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !s.allowCall(w, r, callExecution) {
+		return
+	}
+
+	if req.SlippageBps == 0 {
+		req.SlippageBps = 50 // 0.5% default slippage
+	}
+
+	result, err := s.router.ExecuteZapDeposit(requestContext(r), ZapDepositParams{
+		Sender:      req.Sender,
+		AssetIn:     req.FromAsset,
+		PairAsset:   req.PairAsset,
+		AmountIn:    req.Amount,
+		MaxSlippage: req.SlippageBps,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleWithdrawSingleAsset withdraws liquidity paid out in one asset
+func (s *Server) handleWithdrawSingleAsset(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Sender      string `json:"sender"`
+		ToAsset     string `json:"toAsset"`
+		PairAsset   string `json:"pairAsset"`
+		LpAmount    int64  `json:"lpAmount,omitempty"` // Zero withdraws the whole position
+		MinOut      int64  `json:"minOut,omitempty"`
+		SlippageBps uint64 `json:"slippageBps,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !s.allowCall(w, r, callExecution) {
+		return
+	}
+
+	if req.SlippageBps == 0 {
+		req.SlippageBps = 50 // 0.5% default slippage
+	}
+
+	result, err := s.router.ExecuteWithdrawToSingleAsset(requestContext(r), SingleAssetWithdrawalParams{
+		Sender:       req.Sender,
+		AssetOut:     req.ToAsset,
+		PairAsset:    req.PairAsset,
+		LpAmount:     req.LpAmount,
+		MinAmountOut: req.MinOut,
+		MaxSlippage:  req.SlippageBps,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// dcaScheduler returns the configured DCA scheduler, responding with 503 if
+// there is none
+func (s *Server) dcaScheduler(w http.ResponseWriter) (*DCAScheduler, bool) {
+	if s.router.dca == nil {
+		http.Error(w, "recurring orders are not enabled", http.StatusServiceUnavailable)
+		return nil, false
+	}
+	return s.router.dca, true
+}
+
+// writeDCAOrder responds with an order, mapping scheduler errors to statuses
+func writeDCAOrder(w http.ResponseWriter, order *DCAOrder, err error, status int) {
+	if errors.Is(err, ErrDCAOrderNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(order)
+}
+
+// handleCreateDCA schedules a recurring swap
+func (s *Server) handleCreateDCA(w http.ResponseWriter, r *http.Request) {
+	scheduler, ok := s.dcaScheduler(w)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		FromAsset       string `json:"fromAsset"`
+		ToAsset         string `json:"toAsset"`
+		Amount          int64  `json:"amount"`
+		MinOut          int64  `json:"minOut,omitempty"`
+		SlippageBps     uint64 `json:"slippageBps,omitempty"`
+		Sender          string `json:"sender"`
+		IntervalSeconds int64  `json:"intervalSeconds"`
+		MaxRuns         int    `json:"maxRuns,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !s.allowCall(w, r, callExecution) {
+		return
+	}
+
+	if req.SlippageBps == 0 {
+		req.SlippageBps = 50 // 0.5% default slippage
+	}
+
+	order, err := scheduler.Create(DCAParams{
+		Swap: s.router.ApplyReferral(SwapParams{
+			AssetIn:      req.FromAsset,
+			AssetOut:     req.ToAsset,
+			AmountIn:     req.Amount,
+			MinAmountOut: req.MinOut,
+			MaxSlippage:  req.SlippageBps,
+			Sender:       req.Sender,
+		}, r.Header.Get("X-API-Key")),
+		Interval: time.Duration(req.IntervalSeconds) * time.Second,
+		MaxRuns:  req.MaxRuns,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/dca/"+order.ID)
+	writeDCAOrder(w, order, nil, http.StatusCreated)
+}
+
+// handleListDCA lists recurring orders, optionally for one owner
+func (s *Server) handleListDCA(w http.ResponseWriter, r *http.Request) {
+	scheduler, ok := s.dcaScheduler(w)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"orders": scheduler.List(r.URL.Query().Get("owner")),
+	})
+}
+
+// handleGetDCA returns a recurring order with its fill history
+func (s *Server) handleGetDCA(w http.ResponseWriter, r *http.Request) {
+	if scheduler, ok := s.dcaScheduler(w); ok {
+		order, err := scheduler.Get(mux.Vars(r)["id"])
+		writeDCAOrder(w, order, err, http.StatusOK)
+	}
+}
+
+// handlePauseDCA pauses a recurring order
+func (s *Server) handlePauseDCA(w http.ResponseWriter, r *http.Request) {
+	if scheduler, ok := s.dcaScheduler(w); ok {
+		order, err := scheduler.Pause(mux.Vars(r)["id"])
+		writeDCAOrder(w, order, err, http.StatusOK)
+	}
+}
+
+// handleResumeDCA resumes a paused recurring order
+func (s *Server) handleResumeDCA(w http.ResponseWriter, r *http.Request) {
+	if scheduler, ok := s.dcaScheduler(w); ok {
+		order, err := scheduler.Resume(mux.Vars(r)["id"])
+		writeDCAOrder(w, order, err, http.StatusOK)
+	}
+}
+
+// handleCancelDCA cancels a recurring order
+func (s *Server) handleCancelDCA(w http.ResponseWriter, r *http.Request) {
+	if scheduler, ok := s.dcaScheduler(w); ok {
+		order, err := scheduler.Cancel(mux.Vars(r)["id"])
+		writeDCAOrder(w, order, err, http.StatusOK)
+	}
+}
+
+// triggerMonitor returns the configured trigger monitor, responding with 503
+// if there is none
+func (s *Server) triggerMonitor(w http.ResponseWriter) (*TriggerMonitor, bool) {
+	if s.router.triggers == nil {
+		http.Error(w, "trigger orders are not enabled", http.StatusServiceUnavailable)
+		return nil, false
+	}
+	return s.router.triggers, true
+}
+
+// writeTriggerOrder responds with a trigger order, mapping monitor errors to
+// statuses
+func writeTriggerOrder(w http.ResponseWriter, order *TriggerOrder, err error, status int) {
+	if errors.Is(err, ErrTriggerOrderNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(order)
+}
+
+// handleCreateTrigger places a stop-loss, take-profit or limit order
+func (s *Server) handleCreateTrigger(w http.ResponseWriter, r *http.Request) {
+	monitor, ok := s.triggerMonitor(w)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		FromAsset    string      `json:"fromAsset"`
+		ToAsset      string      `json:"toAsset"`
+		Amount       int64       `json:"amount"`
+		MinOut       int64       `json:"minOut,omitempty"`
+		SlippageBps  uint64      `json:"slippageBps,omitempty"`
+		Sender       string      `json:"sender"`
+		Kind         TriggerKind `json:"kind"`
+		TriggerPrice float64     `json:"triggerPrice"`
+		MinFill      int64       `json:"minFill,omitempty"` // Smallest part of a limit order filled on its own
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !s.allowCall(w, r, callExecution) {
+		return
+	}
+
+	if req.SlippageBps == 0 {
+		req.SlippageBps = 50 // 0.5% default slippage
+	}
+
+	order, err := monitor.Create(TriggerParams{
+		Swap: s.router.ApplyReferral(SwapParams{
+			AssetIn:      req.FromAsset,
+			AssetOut:     req.ToAsset,
+			AmountIn:     req.Amount,
+			MinAmountOut: req.MinOut,
+			MaxSlippage:  req.SlippageBps,
+			Sender:       req.Sender,
+		}, r.Header.Get("X-API-Key")),
+		Kind:         req.Kind,
+		TriggerPrice: req.TriggerPrice,
+		MinFill:      req.MinFill,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/triggers/"+order.ID)
+	writeTriggerOrder(w, order, nil, http.StatusCreated)
+}
+
+// handleListTriggers lists trigger orders, optionally for one owner
+func (s *Server) handleListTriggers(w http.ResponseWriter, r *http.Request) {
+	monitor, ok := s.triggerMonitor(w)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"orders": monitor.List(r.URL.Query().Get("owner")),
+	})
+}
+
+// handleGetTrigger returns a trigger order
+func (s *Server) handleGetTrigger(w http.ResponseWriter, r *http.Request) {
+	if monitor, ok := s.triggerMonitor(w); ok {
+		order, err := monitor.Get(mux.Vars(r)["id"])
+		writeTriggerOrder(w, order, err, http.StatusOK)
+	}
+}
+
+// handleCancelTrigger cancels a pending trigger order, or the rest of a
+// partially filled one
+func (s *Server) handleCancelTrigger(w http.ResponseWriter, r *http.Request) {
+	if monitor, ok := s.triggerMonitor(w); ok {
+		order, err := monitor.Cancel(mux.Vars(r)["id"])
+		writeTriggerOrder(w, order, err, http.StatusOK)
+	}
+}
+
+// triggerUpgrader accepts WebSocket connections from any origin; the event
+// stream is read-only and carries nothing the order endpoints do not
+var triggerUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// handleTriggerEvents streams trigger order events over a WebSocket, for one
+// owner's orders or every order if no owner is given
+func (s *Server) handleTriggerEvents(w http.ResponseWriter, r *http.Request) {
+	monitor, ok := s.triggerMonitor(w)
+	if !ok {
+		return
+	}
+
+	conn, err := triggerUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // Upgrade has already responded
+	}
+	defer conn.Close()
+
+	events, unsubscribe := monitor.Subscribe(r.URL.Query().Get("owner"))
+	defer unsubscribe()
+
+	// Reading is only needed to notice the client going away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case event, ok := <-events:
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too far behind"))
+				return
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// approvalQueue returns the configured approval queue, responding with 503
+// if there is none
+func (s *Server) approvalQueue(w http.ResponseWriter) (*ApprovalQueue, bool) {
+	if s.router.approvals == nil {
+		http.Error(w, "approvals are not enabled", http.StatusServiceUnavailable)
+		return nil, false
+	}
+	return s.router.approvals, true
+}
+
+// writeApproval responds with an approval request, mapping queue errors to
+// statuses
+func writeApproval(w http.ResponseWriter, request *ApprovalRequest, err error) {
+	if errors.Is(err, ErrUnknownApprover) {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if errors.Is(err, ErrApprovalNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(request)
+}
+
+// approverToken returns the bearer token an approver authenticates with
+func approverToken(r *http.Request) string {
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
+// handleListApprovals lists swaps held for approval, optionally by status
+func (s *Server) handleListApprovals(w http.ResponseWriter, r *http.Request) {
+	queue, ok := s.approvalQueue(w)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"approvals": queue.List(ApprovalStatus(r.URL.Query().Get("status"))),
+	})
+}
+
+// handleGetApproval returns a swap held for approval
+func (s *Server) handleGetApproval(w http.ResponseWriter, r *http.Request) {
+	if queue, ok := s.approvalQueue(w); ok {
+		request, err := queue.Get(mux.Vars(r)["id"])
+		writeApproval(w, request, err)
+	}
+}
+
+// handleApprove records an approver's approval of a held swap
+func (s *Server) handleApprove(w http.ResponseWriter, r *http.Request) {
+	if queue, ok := s.approvalQueue(w); ok {
+		request, err := queue.Approve(mux.Vars(r)["id"], approverToken(r))
+		writeApproval(w, request, err)
+	}
+}
+
+// handleReject refuses a held swap
+func (s *Server) handleReject(w http.ResponseWriter, r *http.Request) {
+	queue, ok := s.approvalQueue(w)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason,omitempty"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	request, err := queue.Reject(mux.Vars(r)["id"], approverToken(r), req.Reason)
+	writeApproval(w, request, err)
+}
+
+// isAdmin reports whether the request carries the admin token, responding
+// with an error if not
+func (s *Server) isAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if s.adminToken == "" {
+		http.Error(w, "admin endpoints are not enabled", http.StatusForbidden)
+		return false
+	}
+	given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(given), []byte(s.adminToken)) != 1 {
+		http.Error(w, "invalid admin token", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// allowCall counts a call against its caller's rate limit, responding with
+// 429 Too Many Requests if the caller is over it
+func (s *Server) allowCall(w http.ResponseWriter, r *http.Request, kind callKind) bool {
+	if s.router.rateLimiter == nil {
+		return true
+	}
+	address, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		address = r.RemoteAddr
+	}
+	ok, wait := s.router.rateLimiter.allow(kind, s.router.rateLimitKey(r.Header.Get("X-API-Key"), address))
+	if ok {
+		return true
+	}
+	s.router.metrics.observeRateLimited(kind)
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	http.Error(w, fmt.Sprintf("rate limit exceeded for %s calls, retry in %s", kind, wait.Round(time.Millisecond)), http.StatusTooManyRequests)
+	return false
+}
+
+// referralProgram returns the configured referral program if the request
+// carries the admin token, responding with an error otherwise
+func (s *Server) referralProgram(w http.ResponseWriter, r *http.Request) (*ReferralProgram, bool) {
+	if !s.isAdmin(w, r) {
+		return nil, false
+	}
+	if s.router.referrals == nil {
+		http.Error(w, "referrals are not enabled", http.StatusServiceUnavailable)
+		return nil, false
+	}
+	return s.router.referrals, true
+}
+
+// handleRegisterReferrer adds or updates a referrer
+func (s *Server) handleRegisterReferrer(w http.ResponseWriter, r *http.Request) {
+	program, ok := s.referralProgram(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ID          string `json:"id"`
+		APIKey      string `json:"apiKey"`
+		Beneficiary string `json:"beneficiary"`
+		RefBps      uint64 `json:"refBps"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	referrer, err := program.Register(Referrer{
+		ID:          req.ID,
+		APIKey:      req.APIKey,
+		Beneficiary: req.Beneficiary,
+		RefBps:      req.RefBps,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(referrer)
+}
+
+// handleListReferrers lists referrers with the volume each has referred
+func (s *Server) handleListReferrers(w http.ResponseWriter, r *http.Request) {
+	program, ok := s.referralProgram(w, r)
+	if !ok {
+		return
+	}
+
+	type referrerStats struct {
+		Referrer
+		Stats ReferralStats `json:"stats"`
+	}
+	referrers := []referrerStats{}
+	for _, referrer := range program.List() {
+		referrers = append(referrers, referrerStats{referrer, program.Stats(referrer.Beneficiary)})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"referrers": referrers,
+	})
+}
+
+// handleGetReferrer returns a referrer with the volume it has referred
+func (s *Server) handleGetReferrer(w http.ResponseWriter, r *http.Request) {
+	program, ok := s.referralProgram(w, r)
+	if !ok {
+		return
+	}
+
+	referrer, err := program.Get(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"referrer": referrer,
+		"stats":    program.Stats(referrer.Beneficiary),
+	})
+}
+
+// handleRemoveReferrer deletes a referrer
+func (s *Server) handleRemoveReferrer(w http.ResponseWriter, r *http.Request) {
+	program, ok := s.referralProgram(w, r)
+	if !ok {
+		return
+	}
+
+	if err := program.Remove(mux.Vars(r)["id"]); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetConfig returns the live configuration in effect
+func (s *Server) handleGetConfig(w http.ResponseWriter, r *http.Request) {
+	if !s.isAdmin(w, r) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.router.Config())
+}
+
+// handleReloadConfig reloads the configuration file and referrers, as
+// SIGHUP does, and returns the configuration now in effect
+func (s *Server) handleReloadConfig(w http.ResponseWriter, r *http.Request) {
+	if !s.isAdmin(w, r) {
+		return
+	}
+	if s.router.reloader == nil {
+		http.Error(w, "config reload is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	config, err := s.router.ReloadConfig()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(config)
+}
+
+// volumeTiers returns the configured volume tracking, responding with 503 if
+// there is none
+func (s *Server) volumeTiers(w http.ResponseWriter) (*VolumeTiers, bool) {
+	if s.router.volumes == nil {
+		http.Error(w, "volume tracking is not enabled", http.StatusServiceUnavailable)
+		return nil, false
+	}
+	return s.router.volumes, true
+}
+
+// handleListFeeTiers lists the fee tier schedule, lowest first
+func (s *Server) handleListFeeTiers(w http.ResponseWriter, r *http.Request) {
+	volumes, ok := s.volumeTiers(w)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(volumes.Tiers())
+}
+
+// handleGetAccountVolume returns an account's cumulative volume and the fee
+// tier it has reached
+func (s *Server) handleGetAccountVolume(w http.ResponseWriter, r *http.Request) {
+	volumes, ok := s.volumeTiers(w)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(volumes.Get(mux.Vars(r)["account"]))
+}
+
+// handleExecuteInstruction handles instruction-based swap requests
+func (s *Server) handleExecuteInstruction(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Instruction []byte `json:"instruction"`
+		AmountIn    int64   `json:"amountIn"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !s.allowCall(w, r, callExecution) {
+		return
+	}
+
+	if len(req.Instruction) == 0 {
+		http.Error(w, "instruction is required", http.StatusBadRequest)
+		return
+	}
+
+	if req.AmountIn <= 0 {
+		http.Error(w, "amountIn must be greater than 0", http.StatusBadRequest)
+		return
+	}
+
+	// Parse and convert instruction to SwapParams
+	params, err := ParseAndConvertInstruction(req.Instruction, req.AmountIn)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to process instruction: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Execute the swap, paying the caller's referrer if the instruction names
+	// no beneficiary of its own
+	result, err := s.router.ComputeRoute(requestContext(r), s.router.ApplyReferral(*params, r.Header.Get("X-API-Key")))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// requestContext returns the request's context, simulated when the request
+// asks for ?simulate=true
+func requestContext(r *http.Request) context.Context {
+	if simulate, _ := strconv.ParseBool(r.URL.Query().Get("simulate")); simulate {
+		return WithSimulation(r.Context())
+	}
+	return r.Context()
+}
+
+// idempotencyKey returns a request's idempotency key, from its body or the
+// Idempotency-Key header
+func idempotencyKey(r *http.Request, fromBody string) string {
+	if fromBody != "" {
+		return fromBody
+	}
+	return r.Header.Get("Idempotency-Key")
+}
+
+// unixDeadline converts a request deadline in Unix seconds; zero means none
+func unixDeadline(secs int64) time.Time {
+	if secs <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(secs, 0)
+}
+
 // handleHealth provides health check endpoint
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
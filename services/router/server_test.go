@@ -0,0 +1,95 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveTestRequest(server *Server, method, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return w
+}
+
+func TestExecutionEndpoints(t *testing.T) {
+	executor := &mockDEXExecutor{}
+	server := NewServer(NewService(VSCConfig{}, executor), "0")
+
+	tests := []struct {
+		path    string
+		body    string
+		opType  string
+		route   string
+		payload string
+	}{
+		{"/api/v1/swap", `{"fromAsset":"HBD","toAsset":"HIVE","amount":1000,"sender":"alice"}`, "swap", "", `"slippage_bps":50`},
+		{"/api/v1/deposit", `{"fromAsset":"HBD","toAsset":"HIVE","amount":1000,"pairAmount":500,"sender":"alice"}`, "deposit", "deposit", `"recipient":"alice"`},
+		{"/api/v1/withdraw", `{"fromAsset":"HBD","toAsset":"HIVE","lpAmount":100,"sender":"alice"}`, "withdrawal", "withdrawal", `"lp_amount":"100"`},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := serveTestRequest(server, http.MethodPost, tt.path, tt.body)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+			var result SwapResult
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+			assert.True(t, result.Success, result.ErrorMessage)
+			if tt.route == "" {
+				assert.Nil(t, result.Route)
+			} else {
+				assert.Equal(t, []string{tt.route}, result.Route)
+			}
+
+			require.Len(t, executor.executedOperations, i+1)
+			assert.Contains(t, executor.executedOperations[i], `"type":"`+tt.opType+`"`)
+			assert.Contains(t, executor.executedOperations[i], tt.payload)
+		})
+	}
+
+	w := serveTestRequest(server, http.MethodPost, "/api/v1/deposit", `not json`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetOrderEndpoint(t *testing.T) {
+	svc := NewService(VSCConfig{}, &mockDEXExecutor{})
+	scheduler, err := NewDCAScheduler(svc, nil)
+	require.NoError(t, err)
+	svc.SetDCAScheduler(scheduler)
+	server := NewServer(svc, "0")
+
+	jobID, err := svc.SubmitSwap(SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000})
+	require.NoError(t, err)
+	dca, err := scheduler.Create(DCAParams{
+		Swap:     SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000},
+		Interval: time.Hour,
+	})
+	require.NoError(t, err)
+
+	w := serveTestRequest(server, http.MethodGet, "/api/v1/orders/"+jobID, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var order Order
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+	assert.Equal(t, OrderSwap, order.Kind)
+	require.NotNil(t, order.Swap)
+	assert.Equal(t, jobID, order.Swap.ID)
+	assert.Nil(t, order.DCA)
+
+	w = serveTestRequest(server, http.MethodGet, "/api/v1/orders/"+dca.ID, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	order = Order{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+	assert.Equal(t, OrderDCA, order.Kind)
+	require.NotNil(t, order.DCA)
+	assert.Equal(t, dca.ID, order.DCA.ID)
+
+	w = serveTestRequest(server, http.MethodGet, "/api/v1/orders/missing", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
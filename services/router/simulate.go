@@ -0,0 +1,53 @@
+package router
+
+import "context"
+
+// simulationKey marks a context whose operations are simulated
+type simulationKey struct{}
+
+// Simulation is exactly what an operation would have broadcast: the calls to
+// the DEX router contract, with their payloads and intents, in order
+type Simulation struct {
+	Contract   string         `json:"contract"`
+	Operations []DexOperation `json:"operations"`
+}
+
+// WithSimulation returns a context under which swaps, deposits, withdrawals
+// and batches run their full pipeline, from route selection to payload and
+// intent construction, but return what they would broadcast in
+// SwapResult.Simulation instead of calling the executor
+func WithSimulation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, simulationKey{}, true)
+}
+
+// SetSimulate puts the whole service in simulation mode, including swaps
+// run in the background for jobs, TWAP and DCA orders
+func (s *Service) SetSimulate(simulate bool) {
+	s.simulate = simulate
+}
+
+// simulating reports whether operations under ctx must not be broadcast
+func (s *Service) simulating(ctx context.Context) bool {
+	simulated, _ := ctx.Value(simulationKey{}).(bool)
+	return s.simulate || simulated
+}
+
+// simulation records operations that were built but not broadcast
+func (s *Service) simulation(operations ...DexOperation) *Simulation {
+	return &Simulation{
+		Contract:   s.vscConfig.DexRouterContract,
+		Operations: operations,
+	}
+}
+
+// merge appends another simulated step's operations, for operations that
+// run as several transactions
+func (sim *Simulation) merge(other *Simulation) *Simulation {
+	if sim == nil {
+		return other
+	}
+	if other != nil {
+		sim.Operations = append(sim.Operations, other.Operations...)
+	}
+	return sim
+}
@@ -0,0 +1,153 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulateSwap(t *testing.T) {
+	svc, executor := newQuoteTestService()
+	params := SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 10000, MaxSlippage: 50}
+
+	simulated, err := svc.ExecuteSwap(WithSimulation(context.Background()), params)
+	require.NoError(t, err)
+	require.True(t, simulated.Success, simulated.ErrorMessage)
+	assert.Empty(t, executor.executedOperations, "simulations never reach the executor")
+	assert.Empty(t, simulated.TxID)
+	assert.Equal(t, int64(39486), simulated.EstimatedAmountOut)
+
+	// The result reports the quoted output and the route it was sent along
+	assert.Equal(t, int64(39486), simulated.AmountOut)
+	assert.Equal(t, []string{"HBD", "HIVE"}, simulated.Route)
+	assert.Equal(t, []string{"1"}, simulated.Pools)
+
+	require.NotNil(t, simulated.Simulation)
+	require.Len(t, simulated.Simulation.Operations, 1)
+	op := simulated.Simulation.Operations[0]
+	assert.Equal(t, "execute", op.OperationType)
+	assert.Equal(t, map[string]string{"HBD": "10000"}, intentLimits(op.Intents))
+
+	// The simulation is exactly what a real swap broadcasts
+	executed, err := svc.ExecuteSwap(context.Background(), params)
+	require.NoError(t, err)
+	require.True(t, executed.Success)
+	require.Len(t, executor.executedOperations, 1)
+	assert.Equal(t, "execute:"+op.Payload, executor.executedOperations[0])
+	assert.Nil(t, executed.Simulation)
+	assert.Equal(t, simulated.AmountOut, executed.AmountOut)
+	assert.Equal(t, simulated.Route, executed.Route)
+	assert.Equal(t, simulated.Pools, executed.Pools)
+}
+
+func TestSimulateWithdrawal(t *testing.T) {
+	svc, executor := newWithdrawalTestService()
+
+	result, err := svc.ExecuteWithdrawal(WithSimulation(context.Background()), WithdrawalParams{
+		Sender:   "alice",
+		AssetIn:  "HIVE",
+		AssetOut: "HBD",
+		LpAmount: 1000,
+	})
+	require.NoError(t, err)
+	require.True(t, result.Success, result.ErrorMessage)
+	assert.Empty(t, executor.intents)
+
+	require.NotNil(t, result.Simulation)
+	assert.Equal(t, "dex-router-contract", result.Simulation.Contract)
+	require.Len(t, result.Simulation.Operations, 1)
+	assert.Equal(t, map[string]string{"HIVE": "50500", "HBD": "101000"}, intentLimits(result.Simulation.Operations[0].Intents))
+}
+
+func TestSimulateBatch(t *testing.T) {
+	executor := &mockBatchExecutor{}
+	svc := NewService(VSCConfig{DexRouterContract: "dex-router-contract"}, executor)
+
+	result, err := svc.ExecuteBatch(WithSimulation(context.Background()), BatchParams{
+		Sender: "alice",
+		Operations: []BatchOperation{
+			{Swap: &SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000}},
+			{Deposit: &DepositParams{AssetIn: "HIVE", AssetOut: "HBD", AmountIn: 500}},
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, result.Success, result.ErrorMessage)
+	assert.Empty(t, executor.batches)
+	assert.Empty(t, result.TxID)
+
+	require.NotNil(t, result.Simulation)
+	assert.Len(t, result.Simulation.Operations, 2)
+	for _, op := range result.Results {
+		assert.Same(t, result.Simulation, op.Simulation, "batched operations share one transaction")
+	}
+}
+
+func TestSimulateZapWithoutBatching(t *testing.T) {
+	svc, executor := newWithdrawalTestService()
+
+	result, err := svc.ExecuteZapDeposit(WithSimulation(context.Background()), ZapDepositParams{
+		Sender:      "alice",
+		AssetIn:     "HIVE",
+		PairAsset:   "HBD",
+		AmountIn:    100000,
+		MaxSlippage: 50,
+	})
+	require.NoError(t, err)
+	require.True(t, result.Success, result.ErrorMessage)
+	assert.Empty(t, executor.intents)
+
+	// Both transactions are reported, in order
+	require.NotNil(t, result.Simulation)
+	require.Len(t, result.Simulation.Operations, 2)
+	assert.Contains(t, result.Simulation.Operations[0].Payload, `"type":"swap"`)
+	assert.Contains(t, result.Simulation.Operations[1].Payload, `"type":"deposit"`)
+}
+
+func TestSimulateServiceMode(t *testing.T) {
+	executor := &mockDEXExecutor{}
+	svc := NewService(VSCConfig{}, executor)
+	svc.SetSimulate(true)
+
+	jobID, err := svc.SubmitSwap(SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000})
+	require.NoError(t, err)
+
+	updates, err := svc.WatchSwap(context.Background(), jobID)
+	require.NoError(t, err)
+	var job SwapJob
+	for job = range updates {
+	}
+	require.NotNil(t, job.Result)
+	assert.True(t, job.Result.Success)
+	assert.NotNil(t, job.Result.Simulation)
+	assert.Empty(t, executor.executedOperations)
+}
+
+func TestServer_SimulateQuery(t *testing.T) {
+	svc, executor := newQuoteTestService()
+	server := NewServer(svc, "0")
+
+	body := `{"fromAsset":"HBD","toAsset":"HIVE","amount":10000,"sender":"alice"}`
+	rec := httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/swap?simulate=true", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Empty(t, executor.executedOperations)
+
+	var result SwapResult
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+	assert.True(t, result.Success)
+	require.NotNil(t, result.Simulation)
+	assert.Len(t, result.Simulation.Operations, 1)
+
+	// Without the flag the swap is broadcast and no simulation is returned
+	rec = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/swap", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, executor.executedOperations, 1)
+	assert.NotContains(t, rec.Body.String(), "Simulation")
+}
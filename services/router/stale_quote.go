@@ -0,0 +1,81 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// checkQuote re-quotes a swap's agreed quote from current reserves before it
+// is broadcast. The swap's MinAmountOut is raised to the quoted output less
+// MaxSlippage, so the contract enforces what the user agreed to. If the
+// quoted route no longer returns that much the swap is refused, unless
+// Requote is set and another route still does, in which case the swap is
+// moved to it.
+func (r *Service) checkQuote(ctx context.Context, params *SwapParams) error {
+	quoted := params.Quote
+	if quoted.AssetIn != params.AssetIn || quoted.AssetOut != params.AssetOut || quoted.AmountIn != params.AmountIn {
+		return fmt.Errorf("quote is for %d %s to %s, not this swap", quoted.AmountIn, quoted.AssetIn, quoted.AssetOut)
+	}
+	if r.poolQuerier == nil {
+		return fmt.Errorf("quote cannot be checked: pool querier not configured")
+	}
+
+	floor := quoted.MinimumReceived(params.MaxSlippage)
+	if params.MinAmountOut < floor {
+		params.MinAmountOut = floor
+	}
+
+	// Re-quote the route that was quoted, unless the swap pins its own
+	check := *params
+	check.Quote = nil
+	if len(check.Route) == 0 && len(quoted.Hops) == len(quoted.Route)-1 {
+		check.Route = quoted.Route
+	}
+	current, err := r.quote(ctx, check, 0)
+	if err == nil && current.AmountOut >= floor {
+		params.Route = check.Route
+		return nil
+	}
+
+	var stale string
+	if err != nil {
+		stale = fmt.Sprintf("quote is stale: the quoted route can no longer be quoted (%v)", err)
+	} else {
+		stale = fmt.Sprintf("quote is stale: the quoted route now returns %d, below the %d allowed by slippage", current.AmountOut, floor)
+		if moved := movedReserves(quoted, current); moved != "" {
+			stale += " (" + moved + ")"
+		}
+	}
+	if !params.Requote || len(params.Route) > 0 {
+		return fmt.Errorf("%s", stale)
+	}
+
+	check.Route = nil
+	best, err := r.quote(ctx, check, 0)
+	if err != nil {
+		return fmt.Errorf("%s; re-quote failed: %v", stale, err)
+	}
+	if best.AmountOut < floor {
+		return fmt.Errorf("%s; the best route now returns %d", stale, best.AmountOut)
+	}
+	params.Route = best.Route
+	return nil
+}
+
+// movedReserves describes how the reserves of a quote's pools have changed
+// in a later quote of the same route
+func movedReserves(quoted, current *Quote) string {
+	now := make(map[string]HopQuote)
+	for _, hop := range current.Hops {
+		now[hop.PoolID] = hop
+	}
+
+	var moved []string
+	for _, hop := range quoted.Hops {
+		if cur, ok := now[hop.PoolID]; ok && (cur.ReserveIn != hop.ReserveIn || cur.ReserveOut != hop.ReserveOut) {
+			moved = append(moved, fmt.Sprintf("pool %s reserves %d/%d -> %d/%d", hop.PoolID, hop.ReserveIn, hop.ReserveOut, cur.ReserveIn, cur.ReserveOut))
+		}
+	}
+	return strings.Join(moved, ", ")
+}
@@ -0,0 +1,109 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setTestReserves changes a pool of a routing test service
+func setTestReserves(svc *Service, poolID string, reserve0, reserve1 uint64) {
+	pools := svc.poolQuerier.(*mockPoolQuerier)
+	for i := range pools.pools {
+		if pools.pools[i].ID == poolID {
+			pools.pools[i].Reserve0, pools.pools[i].Reserve1 = reserve0, reserve1
+		}
+	}
+}
+
+func TestQuotedSwapChecksReserves(t *testing.T) {
+	ctx := context.Background()
+	params := SwapParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 10000, MaxSlippage: 200}
+
+	t.Run("fresh quote", func(t *testing.T) {
+		svc := newRoutingTestService(t, DefaultRoutingConfig())
+		quote, err := svc.Quote(ctx, params)
+		require.NoError(t, err)
+		require.Equal(t, []string{"HBD", "HIVE"}, quote.Route)
+		assert.Equal(t, uint64(1000000), quote.Hops[0].ReserveIn)
+		assert.Equal(t, uint64(4000000), quote.Hops[0].ReserveOut)
+
+		swap := params
+		swap.Quote = quote
+		result, err := svc.ExecuteSwap(ctx, swap)
+		require.NoError(t, err)
+		require.True(t, result.Success, result.ErrorMessage)
+
+		// The quote's floor is enforced by the contract, on the quoted route
+		executor := svc.dexExecutor.(*mockDEXExecutor)
+		require.Len(t, executor.executedOperations, 1)
+		assert.Contains(t, executor.executedOperations[0], fmt.Sprintf(`"min_amount_out":%d`, quote.MinimumReceived(200)))
+		assert.Contains(t, executor.executedOperations[0], `"route":"HBD,HIVE"`)
+	})
+
+	t.Run("stale quote refused", func(t *testing.T) {
+		svc := newRoutingTestService(t, DefaultRoutingConfig())
+		quote, err := svc.Quote(ctx, params)
+		require.NoError(t, err)
+		setTestReserves(svc, "hbd-hive", 1000000, 3000000)
+
+		swap := params
+		swap.Quote = quote
+		result, err := svc.ExecuteSwap(ctx, swap)
+		require.NoError(t, err)
+		assert.False(t, result.Success)
+		assert.Contains(t, result.ErrorMessage, "quote is stale")
+		assert.Contains(t, result.ErrorMessage, "pool hbd-hive reserves 1000000/4000000 -> 1000000/3000000")
+		assert.Empty(t, svc.dexExecutor.(*mockDEXExecutor).executedOperations)
+	})
+
+	t.Run("stale quote re-quoted", func(t *testing.T) {
+		svc := newRoutingTestService(t, DefaultRoutingConfig())
+		quote, err := svc.Quote(ctx, params)
+		require.NoError(t, err)
+		setTestReserves(svc, "hbd-hive", 1000000, 3000000)
+
+		swap := params
+		swap.Quote = quote
+		swap.Requote = true
+		result, err := svc.ExecuteSwap(ctx, swap)
+		require.NoError(t, err)
+		require.True(t, result.Success, result.ErrorMessage)
+		assert.Equal(t, []string{"HBD", "SPK", "HIVE"}, result.Route)
+		assert.GreaterOrEqual(t, result.EstimatedAmountOut, quote.MinimumReceived(200))
+	})
+
+	t.Run("re-quote still below slippage", func(t *testing.T) {
+		svc := newRoutingTestService(t, DefaultRoutingConfig())
+		tight := params
+		tight.MaxSlippage = 50
+		quote, err := svc.Quote(ctx, tight)
+		require.NoError(t, err)
+		setTestReserves(svc, "hbd-hive", 1000000, 3000000)
+
+		tight.Quote = quote
+		tight.Requote = true
+		result, err := svc.ExecuteSwap(ctx, tight)
+		require.NoError(t, err)
+		assert.False(t, result.Success)
+		assert.Contains(t, result.ErrorMessage, "the best route now returns")
+		assert.Empty(t, svc.dexExecutor.(*mockDEXExecutor).executedOperations)
+	})
+
+	t.Run("quote for another swap", func(t *testing.T) {
+		svc := newRoutingTestService(t, DefaultRoutingConfig())
+		quote, err := svc.Quote(ctx, params)
+		require.NoError(t, err)
+
+		swap := params
+		swap.AmountIn = 20000
+		swap.Quote = quote
+		result, err := svc.ExecuteSwap(ctx, swap)
+		require.NoError(t, err)
+		assert.False(t, result.Success)
+		assert.Contains(t, result.ErrorMessage, "not this swap")
+	})
+}
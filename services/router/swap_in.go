@@ -0,0 +1,242 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vsc-eco/vsc-dex-mapping/services/router/types"
+)
+
+// defaultBridgeDelay is how long a cross-chain step waits for the
+// transaction it depends on, such as a swap-in's mint, when the executor
+// cannot report inclusion
+const defaultBridgeDelay = 30 * time.Second
+
+// DepositMinter is implemented by executors that can submit deposit proofs
+// to a chain's mapping contract, which mints the mapped asset to the
+// deposit's recipient. It returns the mint's transaction ID.
+type DepositMinter interface {
+	MintDeposit(ctx context.Context, chain string, proof []byte) (string, error)
+}
+
+// SwapInStatus is the progress of a cross-chain swap-in
+type SwapInStatus string
+
+// Swap-in statuses, in order
+const (
+	SwapInMinting   SwapInStatus = "minting"  // Deposit proof accepted; mint being broadcast
+	SwapInSwapping  SwapInStatus = "swapping" // Mint included; mapped asset being swapped
+	SwapInCompleted SwapInStatus = "completed"
+	SwapInFailed    SwapInStatus = "failed"
+)
+
+// SwapInParams swaps a deposit on another chain into a VSC asset. The
+// deposit's recipient receives the output.
+type SwapInParams struct {
+	Chain        string // Chain the deposit was made on, e.g. BTC
+	Proof        []byte // Deposit proof, in the chain's mapping format
+	AssetOut     string
+	MinAmountOut int64
+	MaxSlippage  uint64
+}
+
+// SwapInOrder is the combined status of a deposit's mint and swap
+type SwapInOrder struct {
+	ID            string       `json:"id"`
+	Status        SwapInStatus `json:"status"`
+	Chain         string       `json:"chain"`
+	DepositTxHash string       `json:"depositTxHash"`
+	Recipient     string       `json:"recipient"`
+	MappedAsset   string       `json:"mappedAsset"`
+	AmountIn      int64        `json:"amountIn"` // Deposited, and minted as MappedAsset
+	AssetOut      string       `json:"assetOut"`
+	MintTxID      string       `json:"mintTxId,omitempty"`
+	Swap          *SwapResult  `json:"swap,omitempty"`
+	Error         string       `json:"error,omitempty"`
+	CreatedAt     time.Time    `json:"createdAt"`
+	UpdatedAt     time.Time    `json:"updatedAt"`
+}
+
+// swapInStore holds swap-ins by ID
+type swapInStore struct {
+	mu     sync.RWMutex
+	orders map[string]*SwapInOrder
+}
+
+func newSwapInStore() *swapInStore {
+	return &swapInStore{orders: make(map[string]*SwapInOrder)}
+}
+
+// update applies fn to a swap-in under the store lock
+func (ss *swapInStore) update(id string, fn func(order *SwapInOrder)) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if order, ok := ss.orders[id]; ok {
+		fn(order)
+		order.UpdatedAt = time.Now()
+	}
+}
+
+// get returns a copy of a swap-in
+func (ss *swapInStore) get(id string) (SwapInOrder, bool) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	order, ok := ss.orders[id]
+	if !ok {
+		return SwapInOrder{}, false
+	}
+	return *order, true
+}
+
+// RegisterMapping enables swap-ins from an adapter's chain, and has it
+// validate return addresses on that chain
+func (s *Service) RegisterMapping(adapter types.MappingAdapter) {
+	s.mappingsMu.Lock()
+	defer s.mappingsMu.Unlock()
+
+	if s.mappings == nil {
+		s.mappings = make(map[string]types.MappingAdapter)
+	}
+	s.mappings[strings.ToUpper(adapter.Chain())] = adapter
+}
+
+// mapping returns the adapter registered for a chain
+func (s *Service) mapping(chain string) (types.MappingAdapter, error) {
+	s.mappingsMu.RLock()
+	defer s.mappingsMu.RUnlock()
+
+	adapter, ok := s.mappings[strings.ToUpper(chain)]
+	if !ok {
+		return nil, types.ErrUnsupportedChain{Chain: chain}
+	}
+	return adapter, nil
+}
+
+// SubmitSwapIn validates a deposit proof and returns a swap-in ID
+// immediately. The deposit is then minted as its chain's mapped asset and
+// swapped into AssetOut for the deposit's recipient; poll GetSwapIn for the
+// combined status.
+func (r *Service) SubmitSwapIn(ctx context.Context, params SwapInParams) (string, error) {
+	adapter, err := r.mapping(params.Chain)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := r.dexExecutor.(DepositMinter); !ok {
+		return "", fmt.Errorf("executor cannot mint deposits")
+	}
+	if r.simulate {
+		return "", fmt.Errorf("swap-ins cannot be simulated")
+	}
+	if len(params.Proof) == 0 {
+		return "", fmt.Errorf("deposit proof is required")
+	}
+
+	mapped := adapter.GetMappedToken()
+	if params.AssetOut == "" || params.AssetOut == mapped {
+		return "", fmt.Errorf("asset out must differ from the mapped asset %s", mapped)
+	}
+	if err := r.acceptSwap(SwapParams{AssetIn: mapped, AssetOut: params.AssetOut, MaxSlippage: params.MaxSlippage}); err != nil {
+		return "", err
+	}
+
+	validation, err := adapter.ValidateDepositProof(ctx, params.Proof)
+	if err != nil {
+		return "", fmt.Errorf("failed to validate deposit proof: %w", err)
+	}
+	if !validation.Valid {
+		return "", fmt.Errorf("invalid deposit proof: %s", validation.Error)
+	}
+	if validation.Recipient == "" || validation.Amount == 0 {
+		return "", fmt.Errorf("deposit proof names no recipient or amount")
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	order := &SwapInOrder{
+		ID:            id,
+		Status:        SwapInMinting,
+		Chain:         adapter.Chain(),
+		DepositTxHash: validation.TxHash,
+		Recipient:     validation.Recipient,
+		MappedAsset:   mapped,
+		AmountIn:      int64(validation.Amount),
+		AssetOut:      params.AssetOut,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	r.swapIns.mu.Lock()
+	r.swapIns.orders[id] = order
+	r.swapIns.mu.Unlock()
+
+	go r.runSwapIn(context.Background(), id, params, *order)
+	return id, nil
+}
+
+// GetSwapIn returns the combined status of a swap-in
+func (r *Service) GetSwapIn(id string) (*SwapInOrder, error) {
+	order, ok := r.swapIns.get(id)
+	if !ok {
+		return nil, fmt.Errorf("swap-in not found: %s", id)
+	}
+	return &order, nil
+}
+
+// runSwapIn mints a validated deposit, waits for the mint to be included and
+// swaps the minted amount
+func (r *Service) runSwapIn(ctx context.Context, id string, params SwapInParams, order SwapInOrder) {
+	minter := r.dexExecutor.(DepositMinter)
+	mintTxID, err := minter.MintDeposit(ctx, order.Chain, params.Proof)
+	if err != nil {
+		r.failSwapIn(id, fmt.Sprintf("mint failed: %v", err))
+		return
+	}
+	r.swapIns.update(id, func(order *SwapInOrder) {
+		order.MintTxID = mintTxID
+	})
+
+	if err := r.awaitInclusion(ctx, mintTxID, r.bridgeDelay); err != nil {
+		r.failSwapIn(id, fmt.Sprintf("mint %s not included: %v", mintTxID, err))
+		return
+	}
+	r.swapIns.update(id, func(order *SwapInOrder) {
+		order.Status = SwapInSwapping
+	})
+
+	result := r.executeSwap(ctx, SwapParams{
+		Sender:       order.Recipient,
+		AmountIn:     order.AmountIn,
+		MinAmountOut: params.MinAmountOut,
+		AssetIn:      order.MappedAsset,
+		AssetOut:     params.AssetOut,
+		MaxSlippage:  params.MaxSlippage,
+	}, nil)
+
+	r.swapIns.update(id, func(order *SwapInOrder) {
+		order.Swap = result
+		if result.Success {
+			order.Status = SwapInCompleted
+		} else {
+			// The mapped asset stays with the recipient
+			order.Status = SwapInFailed
+			order.Error = "swap failed: " + result.ErrorMessage
+		}
+	})
+}
+
+// failSwapIn records a swap-in that stopped before its swap
+func (r *Service) failSwapIn(id string, message string) {
+	r.swapIns.update(id, func(order *SwapInOrder) {
+		order.Status = SwapInFailed
+		order.Error = message
+	})
+}
@@ -0,0 +1,180 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vsc-eco/vsc-dex-mapping/services/router/types"
+)
+
+// mockBTCMapping accepts the proof "valid" as a 50000 sat deposit to alice
+type mockBTCMapping struct{}
+
+func (m *mockBTCMapping) Chain() string          { return "BTC" }
+func (m *mockBTCMapping) GetMappedToken() string { return "BTC" }
+
+func (m *mockBTCMapping) ValidateDepositProof(ctx context.Context, proof []byte) (*types.DepositValidation, error) {
+	if string(proof) != "valid" {
+		return &types.DepositValidation{Valid: false, Error: "unknown header"}, nil
+	}
+	return &types.DepositValidation{Valid: true, Amount: 50000, Recipient: "alice", TxHash: "btc-tx-1"}, nil
+}
+
+func (m *mockBTCMapping) CreateDepositProof(ctx context.Context, txHash string, vout uint32) ([]byte, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockBTCMapping) GetRequiredConfirmations() uint32 { return 6 }
+
+func (m *mockBTCMapping) FormatAddress(address string) (string, error) { return address, nil }
+
+func (m *mockBTCMapping) GetContractAddress() string { return "btc-mapping" }
+
+// mockMinter mints deposits and reports inclusion, failing mints while
+// mintErr is set
+type mockMinter struct {
+	mockTxSubmitter
+	mu       sync.Mutex
+	mints    []string
+	included []string
+	mintErr  error
+}
+
+func (m *mockMinter) MintDeposit(ctx context.Context, chain string, proof []byte) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.mintErr != nil {
+		return "", m.mintErr
+	}
+	m.mints = append(m.mints, chain+":"+string(proof))
+	return "mint-tx", nil
+}
+
+func (m *mockMinter) WaitForInclusion(ctx context.Context, txID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.included = append(m.included, txID)
+	return nil
+}
+
+func newSwapInTestService(executor DEXExecutor) *Service {
+	svc := NewService(VSCConfig{}, executor)
+	svc.RegisterMapping(&mockBTCMapping{})
+	return svc
+}
+
+// awaitSwapIn polls a swap-in until it stops progressing
+func awaitSwapIn(t *testing.T, svc *Service, id string) *SwapInOrder {
+	var order *SwapInOrder
+	require.Eventually(t, func() bool {
+		var err error
+		order, err = svc.GetSwapIn(id)
+		require.NoError(t, err)
+		return order.Status == SwapInCompleted || order.Status == SwapInFailed
+	}, time.Second, time.Millisecond)
+	return order
+}
+
+func TestSwapIn(t *testing.T) {
+	executor := &mockMinter{mockTxSubmitter: mockTxSubmitter{txID: "swap-tx"}}
+	svc := newSwapInTestService(executor)
+
+	id, err := svc.SubmitSwapIn(context.Background(), SwapInParams{Chain: "btc", Proof: []byte("valid"), AssetOut: "HBD", MinAmountOut: 100})
+	require.NoError(t, err)
+
+	order := awaitSwapIn(t, svc, id)
+	assert.Equal(t, SwapInCompleted, order.Status, order.Error)
+	assert.Equal(t, "btc-tx-1", order.DepositTxHash)
+	assert.Equal(t, "mint-tx", order.MintTxID)
+	assert.Equal(t, int64(50000), order.AmountIn)
+	require.NotNil(t, order.Swap)
+	assert.Equal(t, "swap-tx", order.Swap.TxID)
+
+	// The swap starts only once the mint is included, and swaps for the
+	// deposit's recipient
+	assert.Equal(t, []string{"BTC:valid"}, executor.mints)
+	assert.Equal(t, []string{"mint-tx"}, executor.included)
+	require.Len(t, executor.executedOperations, 1)
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(executor.executedOperations[0][len("execute:"):]), &payload))
+	assert.Equal(t, "BTC", payload["asset_in"])
+	assert.Equal(t, "HBD", payload["asset_out"])
+	assert.Equal(t, "alice", payload["recipient"])
+
+	// Swap-ins are found by the order lookup too
+	found, err := svc.GetOrder(id)
+	require.NoError(t, err)
+	assert.Equal(t, OrderSwapIn, found.Kind)
+}
+
+func TestSwapInValidation(t *testing.T) {
+	svc := newSwapInTestService(&mockMinter{})
+
+	tests := []struct {
+		name   string
+		params SwapInParams
+		errMsg string
+	}{
+		{"unsupported chain", SwapInParams{Chain: "ETH", Proof: []byte("valid"), AssetOut: "HBD"}, "unsupported chain"},
+		{"missing proof", SwapInParams{Chain: "BTC", AssetOut: "HBD"}, "proof is required"},
+		{"mapped asset out", SwapInParams{Chain: "BTC", Proof: []byte("valid"), AssetOut: "BTC"}, "must differ"},
+		{"invalid proof", SwapInParams{Chain: "BTC", Proof: []byte("forged"), AssetOut: "HBD"}, "unknown header"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.SubmitSwapIn(context.Background(), tt.params)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+
+	// The executor must be able to mint
+	svc = newSwapInTestService(&mockDEXExecutor{})
+	_, err := svc.SubmitSwapIn(context.Background(), SwapInParams{Chain: "BTC", Proof: []byte("valid"), AssetOut: "HBD"})
+	assert.ErrorContains(t, err, "cannot mint")
+}
+
+func TestSwapInMintFailure(t *testing.T) {
+	executor := &mockMinter{mintErr: errors.New("header not accepted")}
+	svc := newSwapInTestService(executor)
+
+	id, err := svc.SubmitSwapIn(context.Background(), SwapInParams{Chain: "BTC", Proof: []byte("valid"), AssetOut: "HBD"})
+	require.NoError(t, err)
+
+	order := awaitSwapIn(t, svc, id)
+	assert.Equal(t, SwapInFailed, order.Status)
+	assert.Contains(t, order.Error, "mint failed: header not accepted")
+	assert.Nil(t, order.Swap)
+	assert.Empty(t, executor.executedOperations)
+}
+
+func TestSwapInHandlers(t *testing.T) {
+	server := NewServer(newSwapInTestService(&mockMinter{mockTxSubmitter: mockTxSubmitter{txID: "swap-tx"}}), "0")
+
+	// "dmFsaWQ=" is base64 for "valid"
+	w := serveTestRequest(server, http.MethodPost, "/api/v1/swapin", `{"chain": "BTC", "proof": "dmFsaWQ=", "toAsset": "HBD"}`)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	var submitted map[string]string
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&submitted))
+	assert.Equal(t, "/api/v1/swapin/"+submitted["swapInId"], w.Header().Get("Location"))
+
+	awaitSwapIn(t, server.router, submitted["swapInId"])
+	w = serveTestRequest(server, http.MethodGet, "/api/v1/swapin/"+submitted["swapInId"], "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var order SwapInOrder
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&order))
+	assert.Equal(t, SwapInCompleted, order.Status)
+
+	w = serveTestRequest(server, http.MethodPost, "/api/v1/swapin", `{"chain": "BTC", "proof": "Zm9yZ2Vk", "toAsset": "HBD"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = serveTestRequest(server, http.MethodGet, "/api/v1/swapin/missing", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
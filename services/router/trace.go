@@ -0,0 +1,111 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// routeTraceKey marks a context whose quotes record a RouteTrace
+type routeTraceKey struct{}
+
+// RouteTrace records how a quote's route was chosen: every pool the search
+// looked at, every route it priced, and why the others were not used
+type RouteTrace struct {
+	Pools  []PoolDecision  `json:"pools"`
+	Routes []RouteDecision `json:"routes"`
+	Notes  []string        `json:"notes,omitempty"` // Search limits hit and splitting decisions
+}
+
+// PoolDecision is one pool looked at while extending a route
+type PoolDecision struct {
+	PoolID  string `json:"poolId"`
+	Hop     int    `json:"hop"`               // 1 for a route's first pool
+	From    string `json:"from"`              // Asset held on reaching the pool
+	To      string `json:"to"`                // Asset the pool swaps it into
+	Skipped string `json:"skipped,omitempty"` // Why the pool was not used; empty if it was
+}
+
+// RouteDecision is one candidate route and what became of it
+type RouteDecision struct {
+	Route        []string   `json:"route"`
+	Pools        []string   `json:"pools"`
+	Hops         []HopQuote `json:"hops,omitempty"` // Amounts in and out of each pool, if it could be priced
+	AmountOut    int64      `json:"amountOut,omitempty"`
+	NetAmountOut int64      `json:"netAmountOut,omitempty"` // Set when route costs are configured
+	Rejected     string     `json:"rejected,omitempty"`     // Why the route could not be used
+	Chosen       bool       `json:"chosen,omitempty"`
+}
+
+// WithRouteTrace returns a context under which quotes record how they chose
+// their route into trace. Quotes also return it in Quote.Trace; trace is
+// filled in even when a quote fails, to show why no route was usable.
+func WithRouteTrace(ctx context.Context, trace *RouteTrace) context.Context {
+	return context.WithValue(ctx, routeTraceKey{}, trace)
+}
+
+// routeTraceFrom returns the trace quotes under ctx record into, or nil
+func routeTraceFrom(ctx context.Context) *RouteTrace {
+	trace, _ := ctx.Value(routeTraceKey{}).(*RouteTrace)
+	return trace
+}
+
+// pool records a pool the route search looked at; skipped is empty if it
+// was used
+func (t *RouteTrace) pool(pool IndexerPoolInfo, hop int, from, to, skipped string) {
+	if t == nil {
+		return
+	}
+	t.Pools = append(t.Pools, PoolDecision{PoolID: pool.ID, Hop: hop, From: from, To: to, Skipped: skipped})
+}
+
+// route records a priced or rejected route, returning its index
+func (t *RouteTrace) route(assetIn string, pools []IndexerPoolInfo, quote *Quote, rejected string) int {
+	if t == nil {
+		return -1
+	}
+	decision := RouteDecision{Route: []string{assetIn}, Rejected: rejected}
+	asset := assetIn
+	for _, pool := range pools {
+		_, _, asset = orientPool(pool, asset)
+		decision.Route = append(decision.Route, asset)
+		decision.Pools = append(decision.Pools, pool.ID)
+	}
+	if quote != nil {
+		decision.Hops = quote.Hops
+		decision.AmountOut = quote.AmountOut
+		decision.NetAmountOut = quote.NetAmountOut
+	}
+	t.Routes = append(t.Routes, decision)
+	return len(t.Routes) - 1
+}
+
+// choose marks the routes whose pools a quote, or each of its legs, uses
+func (t *RouteTrace) choose(quote *Quote) {
+	if t == nil {
+		return
+	}
+	legs := quote.Legs
+	if len(legs) == 0 {
+		legs = []*Quote{quote}
+	}
+	for _, leg := range legs {
+		pools := make([]string, len(leg.Hops))
+		for i, hop := range leg.Hops {
+			pools[i] = hop.PoolID
+		}
+		for i := range t.Routes {
+			if t.Routes[i].Rejected == "" && strings.Join(t.Routes[i].Pools, ",") == strings.Join(pools, ",") {
+				t.Routes[i].Chosen = true
+			}
+		}
+	}
+}
+
+// note records a decision that is not about one pool or route
+func (t *RouteTrace) note(format string, args ...interface{}) {
+	if t == nil {
+		return
+	}
+	t.Notes = append(t.Notes, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,93 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteTrace(t *testing.T) {
+	svc := newRoutingTestService(t, DefaultRoutingConfig())
+	trace := &RouteTrace{}
+	ctx := WithRouteTrace(context.Background(), trace)
+
+	quote, err := svc.Quote(ctx, SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000})
+	require.NoError(t, err)
+	assert.Same(t, trace, quote.Trace)
+
+	// Both HBD/HIVE routes were priced, and the direct one chosen
+	require.Len(t, trace.Routes, 2)
+	direct, viaSPK := trace.Routes[0], trace.Routes[1]
+	assert.Equal(t, []string{"HBD", "HIVE"}, direct.Route)
+	assert.True(t, direct.Chosen)
+	assert.Equal(t, quote.AmountOut, direct.AmountOut)
+
+	assert.Equal(t, []string{"HBD", "SPK", "HIVE"}, viaSPK.Route)
+	assert.Equal(t, []string{"hbd-spk", "spk-hive"}, viaSPK.Pools)
+	assert.False(t, viaSPK.Chosen)
+	assert.Empty(t, viaSPK.Rejected)
+	require.Len(t, viaSPK.Hops, 2)
+	assert.Equal(t, viaSPK.Hops[0].AmountOut, viaSPK.Hops[1].AmountIn, "each hop's input is the previous hop's output")
+	assert.Less(t, viaSPK.AmountOut, direct.AmountOut)
+
+	// Pools that would loop back are skipped with the reason
+	assert.Contains(t, trace.Pools, PoolDecision{PoolID: "hbd-spk", Hop: 2, From: "SPK", To: "HBD", Skipped: "route already passed through HBD"})
+	assert.Contains(t, trace.Pools, PoolDecision{PoolID: "hbd-hive", Hop: 1, From: "HBD", To: "HIVE"})
+
+	// Without a trace in the context, quotes carry none
+	quote, err = svc.Quote(context.Background(), SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000})
+	require.NoError(t, err)
+	assert.Nil(t, quote.Trace)
+}
+
+func TestRouteTraceExplainsFailures(t *testing.T) {
+	svc := newRoutingTestService(t, DefaultRoutingConfig())
+
+	// Both routes would take 60% of their first pool
+	trace := &RouteTrace{}
+	_, err := svc.Quote(WithRouteTrace(context.Background(), trace), SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 600000})
+	require.Error(t, err)
+	require.Len(t, trace.Routes, 2)
+	for _, route := range trace.Routes {
+		assert.Equal(t, "a hop adds more than 5000 bps of its pool's input reserve", route.Rejected)
+		assert.NotEmpty(t, route.Hops)
+		assert.False(t, route.Chosen)
+	}
+
+	// Too few hops allowed
+	trace = &RouteTrace{}
+	_, err = svc.Quote(WithRouteTrace(context.Background(), trace), SwapParams{AssetIn: "BTC", AssetOut: "ETH", AmountIn: 100000})
+	require.Error(t, err)
+	assert.Empty(t, trace.Routes)
+	assert.Contains(t, trace.Pools, PoolDecision{PoolID: "hbd-hive", Hop: 2, From: "HBD", To: "HIVE", Skipped: "reaching ETH would take more than 2 hops"})
+}
+
+func TestHandleQuoteDebug(t *testing.T) {
+	server := NewServer(newRoutingTestService(t, DefaultRoutingConfig()), "0")
+
+	w := serveTestRequest(server, http.MethodPost, "/api/v1/quote", `{"fromAsset":"HBD","toAsset":"HIVE","amount":1000,"debug":true}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var quote Quote
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &quote))
+	require.NotNil(t, quote.Trace)
+	assert.Len(t, quote.Trace.Routes, 2)
+
+	w = serveTestRequest(server, http.MethodPost, "/api/v1/quote", `{"fromAsset":"HBD","toAsset":"HIVE","amount":1000}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), `"trace"`)
+
+	// A failed quote still explains itself
+	w = serveTestRequest(server, http.MethodPost, "/api/v1/quote", `{"fromAsset":"BTC","toAsset":"ETH","amount":100000,"debug":true}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	var failed struct {
+		Error string     `json:"error"`
+		Trace RouteTrace `json:"trace"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &failed))
+	assert.Contains(t, failed.Error, "no route found")
+	assert.NotEmpty(t, failed.Trace.Pools)
+}
@@ -0,0 +1,547 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Trigger monitor settings
+const (
+	defaultTriggerInterval = 5 * time.Second
+	triggerEventBuffer     = 16 // Events queued per subscriber before it is dropped
+	limitFillSearchSteps   = 24 // Quotes spent sizing a limit order's partial fill
+)
+
+// ErrTriggerOrderNotFound is returned for unknown trigger order IDs
+var ErrTriggerOrderNotFound = errors.New("trigger order not found")
+
+// TriggerKind says which way the price must cross to fire an order
+type TriggerKind string
+
+// Trigger order kinds
+const (
+	TriggerStopLoss   TriggerKind = "stop-loss"   // Fires when the price falls to or below the trigger
+	TriggerTakeProfit TriggerKind = "take-profit" // Fires when the price rises to or above the trigger
+
+	// Fills as much as the pools can absorb at or above the trigger price,
+	// and keeps filling the rest as liquidity returns
+	TriggerLimit TriggerKind = "limit"
+)
+
+// TriggerStatus is the state of a trigger order
+type TriggerStatus string
+
+// Trigger order statuses
+const (
+	TriggerPending   TriggerStatus = "pending"   // Waiting for the price to cross
+	TriggerExecuting TriggerStatus = "executing" // Fired; the swap is being executed
+	TriggerPartial   TriggerStatus = "partially-filled"
+	TriggerFilled    TriggerStatus = "filled"
+	TriggerFailed    TriggerStatus = "failed"
+	TriggerCancelled TriggerStatus = "cancelled"
+)
+
+// TriggerParams configures a trigger order
+type TriggerParams struct {
+	Swap         SwapParams // Executed once the trigger fires; Sender owns the order
+	Kind         TriggerKind
+	TriggerPrice float64 // AssetOut per unit of AssetIn
+
+	// Smallest part of a limit order worth filling on its own, so fills are
+	// not spent on dust; 0 fills any amount. The last part always fills.
+	MinFill int64
+}
+
+// TriggerOrder is a swap held until the indexed price crosses a threshold.
+// Prices are the quoted output per unit of input for the order's full
+// amount, so they account for fees and the order's own price impact.
+type TriggerOrder struct {
+	ID           string        `json:"id"`
+	Owner        string        `json:"owner"`
+	Kind         TriggerKind   `json:"kind"`
+	AssetIn      string        `json:"assetIn"`
+	AssetOut     string        `json:"assetOut"`
+	AmountIn     int64         `json:"amountIn"`
+	MinAmountOut int64         `json:"minAmountOut,omitempty"`
+	MaxSlippage  uint64        `json:"maxSlippage,omitempty"`
+	TriggerPrice float64       `json:"triggerPrice"`
+	MinFill      int64         `json:"minFill,omitempty"`
+	Status       TriggerStatus `json:"status"`
+	LastPrice    float64       `json:"lastPrice,omitempty"` // Last price checked
+	CheckedAt    *time.Time    `json:"checkedAt,omitempty"`
+	TriggeredAt  *time.Time    `json:"triggeredAt,omitempty"`
+	FilledIn     int64         `json:"filledIn,omitempty"`
+	AmountOut    int64         `json:"amountOut,omitempty"` // Total over every fill
+	Fills        []TriggerFill `json:"fills,omitempty"`     // A limit order's fills, oldest first
+	TxID         string        `json:"txId,omitempty"`
+	Error        string        `json:"error,omitempty"`
+	CreatedAt    time.Time     `json:"createdAt"`
+	UpdatedAt    time.Time     `json:"updatedAt"`
+}
+
+// TriggerFill is one swap of a partially fillable order
+type TriggerFill struct {
+	AmountIn  int64     `json:"amountIn"`
+	AmountOut int64     `json:"amountOut"`
+	TxID      string    `json:"txId,omitempty"`
+	FilledAt  time.Time `json:"filledAt"`
+}
+
+// remaining returns how much of the order's input is still to be swapped
+func (o *TriggerOrder) remaining() int64 {
+	return o.AmountIn - o.FilledIn
+}
+
+// open reports whether the order is still waiting to fill
+func (o *TriggerOrder) open() bool {
+	return o.Status == TriggerPending || o.Status == TriggerPartial
+}
+
+// swapParams returns the swap of amountIn the order executes once
+// triggered. The order ID is its idempotency key, so it can never be filled
+// twice. Each fill of a limit order is keyed by its number, and must return
+// at least the limit price and the order's minimum, pro rata.
+func (o *TriggerOrder) swapParams(amountIn int64) SwapParams {
+	params := SwapParams{
+		Sender:         o.Owner,
+		AmountIn:       amountIn,
+		AssetIn:        o.AssetIn,
+		AssetOut:       o.AssetOut,
+		MinAmountOut:   o.MinAmountOut,
+		MaxSlippage:    o.MaxSlippage,
+		IdempotencyKey: "trigger:" + o.ID,
+	}
+	if o.Kind == TriggerLimit {
+		params.IdempotencyKey = fmt.Sprintf("trigger:%s:%d", o.ID, len(o.Fills)+1)
+		share := new(big.Int).Mul(big.NewInt(o.MinAmountOut), big.NewInt(amountIn))
+		params.MinAmountOut = share.Quo(share, big.NewInt(o.AmountIn)).Int64()
+		if atLimit := int64(math.Floor(float64(amountIn) * o.TriggerPrice)); atLimit > params.MinAmountOut {
+			params.MinAmountOut = atLimit
+		}
+	}
+	return params
+}
+
+// minFill returns the smallest fill of a limit order worth swapping
+func (o *TriggerOrder) minFill() int64 {
+	if o.MinFill <= 0 {
+		return 1
+	}
+	if remaining := o.remaining(); o.MinFill > remaining {
+		return remaining
+	}
+	return o.MinFill
+}
+
+// crossed reports whether price fires the order
+func (o *TriggerOrder) crossed(price float64) bool {
+	if o.Kind == TriggerStopLoss {
+		return price <= o.TriggerPrice
+	}
+	return price >= o.TriggerPrice
+}
+
+// TriggerEvent is sent to subscribers when a trigger order fires and when
+// its swap completes
+type TriggerEvent struct {
+	Type  TriggerStatus `json:"type"` // executing, partially-filled, filled or failed
+	Order TriggerOrder  `json:"order"`
+}
+
+// triggerSubscriber receives the events of one owner's orders, or of every
+// order if owner is empty
+type triggerSubscriber struct {
+	owner  string
+	events chan TriggerEvent
+}
+
+// TriggerMonitor watches indexed prices and executes stop-loss, take-profit
+// and limit orders when their trigger price is crossed
+type TriggerMonitor struct {
+	router *Service
+	store  OrderStore // Nil keeps orders in memory only
+
+	mu          sync.Mutex
+	orders      map[string]*TriggerOrder
+	subscribers map[*triggerSubscriber]struct{}
+	now         func() time.Time
+}
+
+// NewTriggerMonitor creates a monitor, restoring orders from store. Orders
+// that were executing when the router stopped are marked failed, since
+// whether their swap went out is unknown; their receipts tell. Call Run to
+// start watching prices.
+func NewTriggerMonitor(svc *Service, store OrderStore) (*TriggerMonitor, error) {
+	m := &TriggerMonitor{
+		router:      svc,
+		store:       store,
+		orders:      make(map[string]*TriggerOrder),
+		subscribers: make(map[*triggerSubscriber]struct{}),
+		now:         time.Now,
+	}
+
+	if store != nil {
+		stored, err := store.LoadOrders(OrderTrigger)
+		if err != nil {
+			return nil, err
+		}
+		for _, data := range stored {
+			order := &TriggerOrder{}
+			if err := json.Unmarshal(data, order); err != nil {
+				return nil, fmt.Errorf("failed to decode trigger order: %w", err)
+			}
+			if order.Status == TriggerExecuting {
+				order.Status = TriggerFailed
+				order.Error = "router stopped while the order was executing; check its receipt"
+				if err := m.saveLocked(order); err != nil {
+					return nil, err
+				}
+			}
+			m.orders[order.ID] = order
+		}
+	}
+	return m, nil
+}
+
+// SetTriggerMonitor configures the monitor that serves stop-loss,
+// take-profit and limit orders
+func (s *Service) SetTriggerMonitor(monitor *TriggerMonitor) {
+	s.triggers = monitor
+}
+
+// Run checks pending orders against current prices every interval until
+// ctx is cancelled
+func (m *TriggerMonitor) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultTriggerInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.checkPending(ctx)
+		}
+	}
+}
+
+// Create places a trigger order
+func (m *TriggerMonitor) Create(params TriggerParams) (*TriggerOrder, error) {
+	if params.Swap.AssetIn == params.Swap.AssetOut {
+		return nil, fmt.Errorf("cannot swap asset to itself")
+	}
+	if params.Swap.AmountIn <= 0 {
+		return nil, fmt.Errorf("amount in must be greater than 0")
+	}
+	if params.Swap.Sender == "" {
+		return nil, fmt.Errorf("sender is required")
+	}
+	if params.Kind != TriggerStopLoss && params.Kind != TriggerTakeProfit && params.Kind != TriggerLimit {
+		return nil, fmt.Errorf("trigger kind must be %s, %s or %s", TriggerStopLoss, TriggerTakeProfit, TriggerLimit)
+	}
+	if !(params.TriggerPrice > 0) {
+		return nil, fmt.Errorf("trigger price must be greater than 0")
+	}
+	if params.MinFill != 0 && params.Kind != TriggerLimit {
+		return nil, fmt.Errorf("only limit orders fill partially")
+	}
+	if params.MinFill < 0 || params.MinFill > params.Swap.AmountIn {
+		return nil, fmt.Errorf("minimum fill must be between 0 and the amount in")
+	}
+	if err := m.router.acceptSwap(params.Swap); err != nil {
+		return nil, err
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	order := &TriggerOrder{
+		ID:           id,
+		Owner:        params.Swap.Sender,
+		Kind:         params.Kind,
+		AssetIn:      params.Swap.AssetIn,
+		AssetOut:     params.Swap.AssetOut,
+		AmountIn:     params.Swap.AmountIn,
+		MinAmountOut: params.Swap.MinAmountOut,
+		MaxSlippage:  params.Swap.MaxSlippage,
+		TriggerPrice: params.TriggerPrice,
+		MinFill:      params.MinFill,
+		Status:       TriggerPending,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	m.orders[id] = order
+
+	if err := m.saveLocked(order); err != nil {
+		delete(m.orders, id)
+		return nil, err
+	}
+
+	copied := *order
+	return &copied, nil
+}
+
+// Get returns a trigger order
+func (m *TriggerMonitor) Get(id string) (*TriggerOrder, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	order, ok := m.orders[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTriggerOrderNotFound, id)
+	}
+	copied := *order
+	return &copied, nil
+}
+
+// List returns the orders owned by owner, or every order if owner is empty,
+// oldest first
+func (m *TriggerMonitor) List(owner string) []TriggerOrder {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	orders := []TriggerOrder{}
+	for _, order := range m.orders {
+		if owner == "" || order.Owner == owner {
+			orders = append(orders, *order)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		if orders[i].CreatedAt.Equal(orders[j].CreatedAt) {
+			return orders[i].ID < orders[j].ID
+		}
+		return orders[i].CreatedAt.Before(orders[j].CreatedAt)
+	})
+	return orders
+}
+
+// Cancel withdraws a pending order, or what is left of a partially filled
+// one
+func (m *TriggerMonitor) Cancel(id string) (*TriggerOrder, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	order, ok := m.orders[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTriggerOrderNotFound, id)
+	}
+	if !order.open() {
+		return nil, fmt.Errorf("trigger order %s is %s", id, order.Status)
+	}
+
+	order.Status = TriggerCancelled
+	order.UpdatedAt = m.now()
+	if err := m.saveLocked(order); err != nil {
+		return nil, err
+	}
+
+	copied := *order
+	return &copied, nil
+}
+
+// Subscribe returns a channel of events for owner's orders, or every order
+// if owner is empty, and a function that ends the subscription. A
+// subscriber that falls behind is dropped and its channel closed.
+func (m *TriggerMonitor) Subscribe(owner string) (<-chan TriggerEvent, func()) {
+	sub := &triggerSubscriber{owner: owner, events: make(chan TriggerEvent, triggerEventBuffer)}
+
+	m.mu.Lock()
+	m.subscribers[sub] = struct{}{}
+	m.mu.Unlock()
+
+	return sub.events, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.unsubscribeLocked(sub)
+	}
+}
+
+// unsubscribeLocked removes a subscriber and closes its channel; caller
+// must hold m.mu
+func (m *TriggerMonitor) unsubscribeLocked(sub *triggerSubscriber) {
+	if _, ok := m.subscribers[sub]; ok {
+		delete(m.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// publishLocked sends an order's event to its subscribers; caller must
+// hold m.mu
+func (m *TriggerMonitor) publishLocked(order *TriggerOrder) {
+	event := TriggerEvent{Type: order.Status, Order: *order}
+	for sub := range m.subscribers {
+		if sub.owner != "" && sub.owner != order.Owner {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			log.Printf("Dropping trigger order subscriber for %q: too far behind", sub.owner)
+			m.unsubscribeLocked(sub)
+		}
+	}
+}
+
+// checkPending quotes every open order and executes those whose price
+// crossed their trigger. A limit order whose remaining amount is priced
+// beyond its limit fills whatever part of it is not.
+func (m *TriggerMonitor) checkPending(ctx context.Context) {
+	m.mu.Lock()
+	var pending []TriggerOrder
+	for _, order := range m.orders {
+		if order.open() {
+			pending = append(pending, *order)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, order := range pending {
+		if ctx.Err() != nil {
+			return
+		}
+		quote, err := m.router.quote(ctx, SwapParams{AssetIn: order.AssetIn, AssetOut: order.AssetOut, AmountIn: order.remaining()}, 0)
+		if err != nil {
+			log.Printf("Price check of trigger order %s failed: %v", order.ID, err)
+			continue
+		}
+
+		price := quote.EffectivePrice()
+		var amountIn int64
+		switch {
+		case order.crossed(price):
+			amountIn = order.remaining()
+		case order.Kind == TriggerLimit:
+			amountIn = m.fillable(ctx, order)
+		}
+		if m.fire(order.ID, price, amountIn) {
+			m.execute(ctx, order.ID, amountIn)
+		}
+	}
+}
+
+// fillable returns the largest part of a limit order's remaining input that
+// quotes at or above its limit price, or 0 if no part worth filling does
+func (m *TriggerMonitor) fillable(ctx context.Context, order TriggerOrder) int64 {
+	lo, hi := int64(0), order.remaining() // lo fills at the limit; hi does not
+	for i := 0; i < limitFillSearchSteps && hi-lo > 1; i++ {
+		mid := lo + (hi-lo)/2
+		quote, err := m.router.quote(ctx, SwapParams{AssetIn: order.AssetIn, AssetOut: order.AssetOut, AmountIn: mid}, 0)
+		if err == nil && order.crossed(quote.EffectivePrice()) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	if lo < order.minFill() {
+		return 0
+	}
+	return lo
+}
+
+// fire records a checked price and, if amountIn is set and the order is
+// still open, marks the order executing. It reports whether the order fired.
+func (m *TriggerMonitor) fire(id string, price float64, amountIn int64) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	order, ok := m.orders[id]
+	if !ok || !order.open() {
+		return false // Cancelled while being checked
+	}
+
+	now := m.now()
+	order.LastPrice = price
+	order.CheckedAt = &now
+	if amountIn <= 0 {
+		return false
+	}
+
+	previous, triggeredAt := order.Status, order.TriggeredAt
+	order.Status = TriggerExecuting
+	if order.TriggeredAt == nil {
+		order.TriggeredAt = &now
+	}
+	order.UpdatedAt = now
+	if err := m.saveLocked(order); err != nil {
+		// Executing an order whose state cannot be persisted could fill it
+		// twice across a restart
+		log.Printf("Not executing trigger order %s: %v", id, err)
+		order.Status = previous
+		order.TriggeredAt = triggeredAt
+		return false
+	}
+	if amountIn < order.remaining() {
+		log.Printf("Trigger order %s filling %d of %d at its %.6g limit", id, amountIn, order.remaining(), order.TriggerPrice)
+	} else {
+		log.Printf("Trigger order %s fired: %s price %.6g crossed %.6g", id, order.Kind, price, order.TriggerPrice)
+	}
+	m.publishLocked(order)
+	return true
+}
+
+// execute swaps amountIn of a fired order and records the outcome
+func (m *TriggerMonitor) execute(ctx context.Context, id string, amountIn int64) {
+	m.mu.Lock()
+	params := m.orders[id].swapParams(amountIn)
+	m.mu.Unlock()
+
+	result := m.router.executeSwap(ctx, params, nil)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	order := m.orders[id]
+	order.TxID = result.TxID
+	order.Error = result.ErrorMessage
+	order.UpdatedAt = now
+	switch {
+	case !result.Success:
+		order.Status = TriggerFailed
+	default:
+		order.FilledIn += amountIn
+		order.AmountOut += result.AmountOut
+		if order.Kind == TriggerLimit {
+			order.Fills = append(order.Fills, TriggerFill{
+				AmountIn:  amountIn,
+				AmountOut: result.AmountOut,
+				TxID:      result.TxID,
+				FilledAt:  now,
+			})
+		}
+		order.Status = TriggerFilled
+		if order.remaining() > 0 {
+			order.Status = TriggerPartial
+		}
+	}
+
+	if err := m.saveLocked(order); err != nil {
+		log.Printf("Failed to persist trigger order %s: %v", id, err)
+	}
+	m.publishLocked(order)
+}
+
+// saveLocked persists an order; caller must hold m.mu
+func (m *TriggerMonitor) saveLocked(order *TriggerOrder) error {
+	if m.store == nil {
+		return nil
+	}
+	return saveOrder(m.store, OrderTrigger, order.ID, order)
+}
@@ -0,0 +1,376 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTriggerTestMonitor returns a monitor backed by a file store in a temp
+// directory, quoting from an HBD/HIVE pool at about 4 HIVE per HBD
+func newTriggerTestMonitor(t *testing.T) (*TriggerMonitor, *mockDEXExecutor, *mockPoolQuerier, string) {
+	executor := &mockDEXExecutor{}
+	svc := NewService(VSCConfig{DexRouterContract: "dex-router-contract"}, executor)
+	querier := &mockPoolQuerier{pools: []IndexerPoolInfo{
+		{ID: "hbd-hive", Asset0: "HBD", Asset1: "HIVE", Reserve0: 10000000, Reserve1: 40000000, Fee: 30},
+	}}
+	svc.SetPoolQuerier(querier)
+
+	dir := t.TempDir()
+	store, err := NewFileOrderStore(dir)
+	require.NoError(t, err)
+	monitor, err := NewTriggerMonitor(svc, store)
+	require.NoError(t, err)
+	svc.SetTriggerMonitor(monitor)
+	return monitor, executor, querier, dir
+}
+
+func triggerTestParams(kind TriggerKind, price float64) TriggerParams {
+	return TriggerParams{
+		Swap: SwapParams{
+			Sender:      "alice",
+			AssetIn:     "HBD",
+			AssetOut:    "HIVE",
+			AmountIn:    1000,
+			MaxSlippage: 50,
+		},
+		Kind:         kind,
+		TriggerPrice: price,
+	}
+}
+
+func TestTriggerCreateValidation(t *testing.T) {
+	monitor, _, _, _ := newTriggerTestMonitor(t)
+
+	tests := []struct {
+		name   string
+		modify func(p *TriggerParams)
+		errMsg string
+	}{
+		{"same asset", func(p *TriggerParams) { p.Swap.AssetOut = "HBD" }, "cannot swap asset to itself"},
+		{"zero amount", func(p *TriggerParams) { p.Swap.AmountIn = 0 }, "amount in must be greater than 0"},
+		{"no sender", func(p *TriggerParams) { p.Swap.Sender = "" }, "sender is required"},
+		{"unknown kind", func(p *TriggerParams) { p.Kind = "trailing" }, "trigger kind must be"},
+		{"zero price", func(p *TriggerParams) { p.TriggerPrice = 0 }, "trigger price must be greater than 0"},
+		{"partial stop-loss", func(p *TriggerParams) { p.MinFill = 100 }, "only limit orders fill partially"},
+		{"minimum fill above amount", func(p *TriggerParams) { p.Kind, p.MinFill = TriggerLimit, 1001 }, "minimum fill must be between"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := triggerTestParams(TriggerStopLoss, 3.5)
+			tt.modify(&params)
+			_, err := monitor.Create(params)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+
+	assert.Empty(t, monitor.List(""))
+}
+
+func TestTriggerStopLossAndTakeProfit(t *testing.T) {
+	monitor, executor, querier, _ := newTriggerTestMonitor(t)
+	ctx := context.Background()
+
+	stop, err := monitor.Create(triggerTestParams(TriggerStopLoss, 3.5))
+	require.NoError(t, err)
+	take, err := monitor.Create(triggerTestParams(TriggerTakeProfit, 4.5))
+	require.NoError(t, err)
+
+	// Neither fires at the current price
+	monitor.checkPending(ctx)
+	assert.Empty(t, executor.executedOperations)
+	order, err := monitor.Get(stop.ID)
+	require.NoError(t, err)
+	assert.Equal(t, TriggerPending, order.Status)
+	assert.InDelta(t, 3.98, order.LastPrice, 0.01)
+	assert.NotNil(t, order.CheckedAt)
+
+	// A falling price fires the stop-loss only
+	setReserves(querier, "hbd-hive", 10000000, 30000000)
+	monitor.checkPending(ctx)
+	require.Len(t, executor.executedOperations, 1)
+	order, err = monitor.Get(stop.ID)
+	require.NoError(t, err)
+	assert.Equal(t, TriggerFilled, order.Status)
+	assert.NotNil(t, order.TriggeredAt)
+	order, err = monitor.Get(take.ID)
+	require.NoError(t, err)
+	assert.Equal(t, TriggerPending, order.Status)
+
+	// A rising price fires the take-profit, and a filled order never fires again
+	setReserves(querier, "hbd-hive", 10000000, 50000000)
+	monitor.checkPending(ctx)
+	monitor.checkPending(ctx)
+	require.Len(t, executor.executedOperations, 2)
+	order, err = monitor.Get(take.ID)
+	require.NoError(t, err)
+	assert.Equal(t, TriggerFilled, order.Status)
+}
+
+func TestTriggerLimitPartialFills(t *testing.T) {
+	monitor, executor, querier, _ := newTriggerTestMonitor(t)
+	ctx := context.Background()
+
+	params := triggerTestParams(TriggerLimit, 3.8)
+	params.Swap.AmountIn = 2000000
+	created, err := monitor.Create(params)
+	require.NoError(t, err)
+
+	// The whole order would price at about 3.3, but roughly the first 496000
+	// still fills at the limit
+	monitor.checkPending(ctx)
+	require.Len(t, executor.executedOperations, 1)
+	order, err := monitor.Get(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, TriggerPartial, order.Status)
+	require.Len(t, order.Fills, 1)
+	assert.InDelta(t, 496000, order.FilledIn, 1000)
+	assert.Equal(t, order.Fills[0].AmountIn, order.FilledIn)
+	assert.Contains(t, executor.executedOperations[0], fmt.Sprintf(`"min_amount_out":%d`, int64(math.Floor(float64(order.FilledIn)*3.8))))
+	firstFill := order.FilledIn
+
+	// Nothing fills while the price is below the limit
+	setReserves(querier, "hbd-hive", 10000000, 37000000)
+	monitor.checkPending(ctx)
+	require.Len(t, executor.executedOperations, 1)
+	order, err = monitor.Get(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, TriggerPartial, order.Status)
+	assert.Equal(t, firstFill, order.FilledIn)
+
+	// Returning liquidity fills more, and then the rest
+	setReserves(querier, "hbd-hive", 20000000, 80000000)
+	monitor.checkPending(ctx)
+	require.Len(t, executor.executedOperations, 2)
+	order, err = monitor.Get(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, TriggerPartial, order.Status)
+	require.Len(t, order.Fills, 2)
+
+	setReserves(querier, "hbd-hive", 100000000, 400000000)
+	monitor.checkPending(ctx)
+	monitor.checkPending(ctx)
+	require.Len(t, executor.executedOperations, 3)
+	order, err = monitor.Get(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, TriggerFilled, order.Status)
+	require.Len(t, order.Fills, 3)
+	assert.Equal(t, int64(2000000), order.FilledIn)
+	var total int64
+	for _, fill := range order.Fills {
+		total += fill.AmountIn
+	}
+	assert.Equal(t, order.AmountIn, total)
+	assert.NotEqual(t, executor.executedOperations[1], executor.executedOperations[2])
+}
+
+func TestTriggerLimitMinimumFill(t *testing.T) {
+	monitor, executor, _, _ := newTriggerTestMonitor(t)
+
+	params := triggerTestParams(TriggerLimit, 3.8)
+	params.Swap.AmountIn = 2000000
+	params.MinFill = 600000
+	created, err := monitor.Create(params)
+	require.NoError(t, err)
+
+	// The 496000 that would fill is below the minimum
+	monitor.checkPending(context.Background())
+	assert.Empty(t, executor.executedOperations)
+	order, err := monitor.Get(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, TriggerPending, order.Status)
+
+	// What is left of a partially filled order can be cancelled
+	params.MinFill = 0
+	partial, err := monitor.Create(params)
+	require.NoError(t, err)
+	monitor.checkPending(context.Background())
+	cancelled, err := monitor.Cancel(partial.ID)
+	require.NoError(t, err)
+	assert.Equal(t, TriggerCancelled, cancelled.Status)
+	assert.NotZero(t, cancelled.FilledIn)
+}
+
+func TestTriggerFailedSwap(t *testing.T) {
+	monitor, executor, _, _ := newTriggerTestMonitor(t)
+
+	checker, err := NewRiskChecker(nil, RiskConfig{MaxNotional: 500})
+	require.NoError(t, err)
+	monitor.router.SetRiskChecker(checker)
+
+	created, err := monitor.Create(triggerTestParams(TriggerTakeProfit, 3))
+	require.NoError(t, err)
+
+	monitor.checkPending(context.Background())
+	order, err := monitor.Get(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, TriggerFailed, order.Status)
+	assert.Contains(t, order.Error, "risk check failed")
+	assert.Empty(t, executor.executedOperations)
+}
+
+func TestTriggerCancel(t *testing.T) {
+	monitor, executor, querier, _ := newTriggerTestMonitor(t)
+
+	created, err := monitor.Create(triggerTestParams(TriggerStopLoss, 3.5))
+	require.NoError(t, err)
+	order, err := monitor.Cancel(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, TriggerCancelled, order.Status)
+
+	_, err = monitor.Cancel(created.ID)
+	assert.ErrorContains(t, err, "is cancelled")
+	_, err = monitor.Cancel("missing")
+	assert.ErrorIs(t, err, ErrTriggerOrderNotFound)
+
+	setReserves(querier, "hbd-hive", 10000000, 30000000)
+	monitor.checkPending(context.Background())
+	assert.Empty(t, executor.executedOperations)
+}
+
+func TestTriggerPersistence(t *testing.T) {
+	monitor, _, _, dir := newTriggerTestMonitor(t)
+
+	pending, err := monitor.Create(triggerTestParams(TriggerStopLoss, 3.5))
+	require.NoError(t, err)
+	executing, err := monitor.Create(triggerTestParams(TriggerTakeProfit, 3.5))
+	require.NoError(t, err)
+
+	// Fire the second order without executing it, as if the router stopped
+	// mid-swap
+	require.True(t, monitor.fire(executing.ID, 4, executing.AmountIn))
+
+	store, err := NewFileOrderStore(dir)
+	require.NoError(t, err)
+	restored, err := NewTriggerMonitor(monitor.router, store)
+	require.NoError(t, err)
+	require.Len(t, restored.List("alice"), 2)
+
+	order, err := restored.Get(pending.ID)
+	require.NoError(t, err)
+	assert.Equal(t, TriggerPending, order.Status)
+	assert.Equal(t, 3.5, order.TriggerPrice)
+
+	order, err = restored.Get(executing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, TriggerFailed, order.Status)
+	assert.Contains(t, order.Error, "router stopped")
+
+	// The interruption is persisted too
+	store, err = NewFileOrderStore(dir)
+	require.NoError(t, err)
+	stored, err := store.LoadOrders(OrderTrigger)
+	require.NoError(t, err)
+	require.Len(t, stored, 2)
+	for _, data := range stored {
+		assert.NotContains(t, string(data), `"status":"executing"`)
+	}
+}
+
+func TestTriggerEndpoints(t *testing.T) {
+	disabled := NewServer(NewService(VSCConfig{}, &mockDEXExecutor{}), "0")
+	w := serveTestRequest(disabled, "GET", "/api/v1/triggers", "")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	monitor, _, _, _ := newTriggerTestMonitor(t)
+	server := NewServer(monitor.router, "0")
+
+	w = serveTestRequest(server, "POST", "/api/v1/triggers", `{"fromAsset":"HBD","toAsset":"HIVE","amount":1000,"sender":"alice","kind":"stop-loss","triggerPrice":3.5}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	location := w.Header().Get("Location")
+	require.True(t, strings.HasPrefix(location, "/api/v1/triggers/"))
+	id := strings.TrimPrefix(location, "/api/v1/triggers/")
+
+	w = serveTestRequest(server, "POST", "/api/v1/triggers", `{"fromAsset":"HBD","toAsset":"HIVE","amount":1000,"sender":"alice","kind":"stop-loss"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serveTestRequest(server, "GET", "/api/v1/triggers?owner=alice", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), id)
+
+	w = serveTestRequest(server, "GET", "/api/v1/orders/"+id, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"kind":"trigger"`)
+
+	w = serveTestRequest(server, "DELETE", "/api/v1/triggers/"+id, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"cancelled"`)
+	w = serveTestRequest(server, "DELETE", "/api/v1/triggers/"+id, "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = serveTestRequest(server, "GET", "/api/v1/triggers/missing", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestTriggerWebSocketNotifications(t *testing.T) {
+	monitor, _, querier, _ := newTriggerTestMonitor(t)
+	httpServer := httptest.NewServer(NewServer(monitor.router, "0").http.Handler)
+	defer httpServer.Close()
+
+	url := "ws" + strings.TrimPrefix(httpServer.URL, "http") + "/api/v1/triggers/ws?owner=alice"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Eventually(t, func() bool {
+		monitor.mu.Lock()
+		defer monitor.mu.Unlock()
+		return len(monitor.subscribers) == 1
+	}, time.Second, time.Millisecond)
+
+	// Other owners' orders are not sent
+	other := triggerTestParams(TriggerStopLoss, 3.5)
+	other.Swap.Sender = "bob"
+	_, err = monitor.Create(other)
+	require.NoError(t, err)
+	order, err := monitor.Create(triggerTestParams(TriggerStopLoss, 3.5))
+	require.NoError(t, err)
+
+	setReserves(querier, "hbd-hive", 10000000, 30000000)
+	monitor.checkPending(context.Background())
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for _, want := range []TriggerStatus{TriggerExecuting, TriggerFilled} {
+		var event TriggerEvent
+		require.NoError(t, conn.ReadJSON(&event))
+		assert.Equal(t, want, event.Type)
+		assert.Equal(t, order.ID, event.Order.ID)
+	}
+
+	// Closing the connection ends the subscription
+	conn.Close()
+	require.Eventually(t, func() bool {
+		monitor.mu.Lock()
+		defer monitor.mu.Unlock()
+		return len(monitor.subscribers) == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestTriggerSlowSubscriberDropped(t *testing.T) {
+	monitor, _, _, _ := newTriggerTestMonitor(t)
+	events, unsubscribe := monitor.Subscribe("")
+	defer unsubscribe()
+
+	order, err := monitor.Create(triggerTestParams(TriggerStopLoss, 3.5))
+	require.NoError(t, err)
+	monitor.mu.Lock()
+	for i := 0; i <= triggerEventBuffer; i++ {
+		monitor.publishLocked(monitor.orders[order.ID])
+	}
+	monitor.mu.Unlock()
+
+	received := 0
+	for range events {
+		received++
+	}
+	assert.Equal(t, triggerEventBuffer, received, "the channel should close once the buffer overflows")
+}
@@ -0,0 +1,377 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+// TWAPStatus is the state of a TWAP order
+type TWAPStatus string
+
+// TWAP order statuses
+const (
+	TWAPRunning   TWAPStatus = "running"
+	TWAPCompleted TWAPStatus = "completed"
+	TWAPAborted   TWAPStatus = "aborted" // Price left the band; remaining slices were skipped
+	TWAPFailed    TWAPStatus = "failed"  // A slice failed to execute
+)
+
+// TWAPParams splits a swap into equal slices spread evenly over a window
+type TWAPParams struct {
+	Swap         SwapParams    // The full order; AmountIn and MinAmountOut are divided between slices
+	Slices       int           // Number of slices, at least 2
+	Window       time.Duration // Time between the first and last slice
+	PriceBandBps uint64        // Abort if a slice's quoted price moves this far from the first; zero disables
+}
+
+// TWAPSlice is one scheduled part of a TWAP order
+type TWAPSlice struct {
+	Index       int         `json:"index"`
+	AmountIn    int64       `json:"amountIn"`
+	ScheduledAt time.Time   `json:"scheduledAt"`
+	StartedAt   *time.Time  `json:"startedAt,omitempty"`
+	ExecutedAt  *time.Time  `json:"executedAt,omitempty"`
+	QuotedPrice float64     `json:"quotedPrice,omitempty"`
+	Result      *SwapResult `json:"result,omitempty"`
+}
+
+// TWAPOrder is the progress of a TWAP order
+type TWAPOrder struct {
+	ID             string      `json:"id"`
+	Status         TWAPStatus  `json:"status"`
+	AssetIn        string      `json:"assetIn"`
+	AssetOut       string      `json:"assetOut"`
+	AmountIn       int64       `json:"amountIn"`
+	FilledIn       int64       `json:"filledIn"`
+	FilledOut      int64       `json:"filledOut"`
+	ReferencePrice float64     `json:"referencePrice,omitempty"` // First slice's quoted price
+	Slices         []TWAPSlice `json:"slices"`
+	Error          string      `json:"error,omitempty"`
+	CreatedAt      time.Time   `json:"createdAt"`
+	UpdatedAt      time.Time   `json:"updatedAt"`
+}
+
+// storedTWAPOrder is a TWAP order as persisted: its progress, and the
+// parameters needed to resume it
+type storedTWAPOrder struct {
+	ID     string     `json:"id"`
+	Order  TWAPOrder  `json:"order"`
+	Params TWAPParams `json:"params"`
+}
+
+// twapStore holds TWAP orders by ID
+type twapStore struct {
+	mu     sync.RWMutex
+	orders map[string]*TWAPOrder
+	params map[string]TWAPParams // Persisted alongside each order
+	store  OrderStore            // Nil keeps orders in memory only
+}
+
+func newTWAPStore() *twapStore {
+	return &twapStore{
+		orders: make(map[string]*TWAPOrder),
+		params: make(map[string]TWAPParams),
+	}
+}
+
+// add stores a new order
+func (ts *twapStore) add(order *TWAPOrder, params TWAPParams) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	ts.orders[order.ID] = order
+	ts.params[order.ID] = params
+	if err := ts.saveLocked(order.ID); err != nil {
+		delete(ts.orders, order.ID)
+		delete(ts.params, order.ID)
+		return err
+	}
+	return nil
+}
+
+// update applies fn to an order under the store lock
+func (ts *twapStore) update(id string, fn func(order *TWAPOrder)) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if order, ok := ts.orders[id]; ok {
+		fn(order)
+		order.UpdatedAt = time.Now()
+		if err := ts.saveLocked(id); err != nil {
+			log.Printf("Failed to persist TWAP order %s: %v", id, err)
+		}
+	}
+}
+
+// saveLocked persists an order; caller must hold ts.mu
+func (ts *twapStore) saveLocked(id string) error {
+	if ts.store == nil {
+		return nil
+	}
+	return saveOrder(ts.store, OrderTWAP, id, storedTWAPOrder{
+		ID:     id,
+		Order:  *ts.orders[id],
+		Params: ts.params[id],
+	})
+}
+
+// get returns a copy of an order
+func (ts *twapStore) get(id string) (TWAPOrder, bool) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+
+	order, ok := ts.orders[id]
+	if !ok {
+		return TWAPOrder{}, false
+	}
+	copied := *order
+	copied.Slices = append([]TWAPSlice(nil), order.Slices...)
+	return copied, true
+}
+
+// validate checks a TWAP order can be scheduled
+func (p TWAPParams) validate() error {
+	if p.Swap.AssetIn == p.Swap.AssetOut {
+		return fmt.Errorf("cannot swap asset to itself")
+	}
+	if p.Slices < 2 {
+		return fmt.Errorf("a TWAP order needs at least 2 slices")
+	}
+	if p.Window <= 0 {
+		return fmt.Errorf("window must be greater than 0")
+	}
+	if p.Swap.AmountIn < int64(p.Slices) {
+		return fmt.Errorf("amount in is too small for %d slices", p.Slices)
+	}
+	if p.PriceBandBps >= 10000 {
+		return fmt.Errorf("price band must be below 10000 bps")
+	}
+	return nil
+}
+
+// schedule divides the order into slices. The last slice takes any
+// remainder so the slices add up to the full amount.
+func (p TWAPParams) schedule(start time.Time) []TWAPSlice {
+	slices := make([]TWAPSlice, p.Slices)
+	amount := p.Swap.AmountIn / int64(p.Slices)
+	interval := p.Window / time.Duration(p.Slices-1)
+
+	for i := range slices {
+		slices[i] = TWAPSlice{
+			Index:       i,
+			AmountIn:    amount,
+			ScheduledAt: start.Add(time.Duration(i) * interval),
+		}
+	}
+	slices[len(slices)-1].AmountIn += p.Swap.AmountIn - amount*int64(p.Slices)
+	return slices
+}
+
+// SubmitTWAP schedules a TWAP order and returns its ID immediately. The first
+// slice executes at once; poll GetTWAP for per-slice results.
+func (r *Service) SubmitTWAP(params TWAPParams) (string, error) {
+	if err := params.validate(); err != nil {
+		return "", err
+	}
+	if err := r.acceptSwap(params.Swap); err != nil {
+		return "", err
+	}
+	if params.PriceBandBps > 0 && r.poolQuerier == nil {
+		return "", fmt.Errorf("a price band requires a pool querier")
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	order := &TWAPOrder{
+		ID:        id,
+		Status:    TWAPRunning,
+		AssetIn:   params.Swap.AssetIn,
+		AssetOut:  params.Swap.AssetOut,
+		AmountIn:  params.Swap.AmountIn,
+		Slices:    params.schedule(now),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := r.twaps.add(order, params); err != nil {
+		return "", err
+	}
+
+	go r.runTWAP(context.Background(), id, params)
+	return id, nil
+}
+
+// RestoreTWAPOrders persists TWAP orders to store from now on, and resumes
+// the running orders it holds. Slices the router missed while it was down
+// are not rushed: the next slice runs at once and the rest keep their
+// spacing. An order whose slice was executing when the router stopped is
+// marked failed, since whether the slice went out is unknown.
+func (r *Service) RestoreTWAPOrders(store OrderStore) error {
+	stored, err := store.LoadOrders(OrderTWAP)
+	if err != nil {
+		return err
+	}
+
+	r.twaps.mu.Lock()
+	r.twaps.store = store
+	var resume []storedTWAPOrder
+	for _, data := range stored {
+		var record storedTWAPOrder
+		if err := json.Unmarshal(data, &record); err != nil {
+			r.twaps.mu.Unlock()
+			return fmt.Errorf("failed to decode TWAP order: %w", err)
+		}
+		order := record.Order
+		r.twaps.orders[order.ID] = &order
+		r.twaps.params[order.ID] = record.Params
+		if order.Status != TWAPRunning {
+			continue
+		}
+		if recoverTWAP(&order, time.Now()) {
+			resume = append(resume, record)
+		}
+		if err := r.twaps.saveLocked(order.ID); err != nil {
+			r.twaps.mu.Unlock()
+			return err
+		}
+	}
+	r.twaps.mu.Unlock()
+
+	for _, record := range resume {
+		log.Printf("Resuming TWAP order %s", record.ID)
+		go r.runTWAP(context.Background(), record.ID, record.Params)
+	}
+	return nil
+}
+
+// recoverTWAP prepares a running order restored after a restart, reporting
+// whether it has slices left to run. Finished and interrupted orders get
+// their final status instead.
+func recoverTWAP(order *TWAPOrder, now time.Time) bool {
+	next := -1
+	for i, slice := range order.Slices {
+		if slice.ExecutedAt == nil {
+			next = i
+			break
+		}
+	}
+
+	switch {
+	case next < 0:
+		// Every slice ran, but the router stopped before recording the end
+		order.Status = TWAPCompleted
+		for i, slice := range order.Slices {
+			if slice.Result == nil || !slice.Result.Success {
+				order.Status = TWAPFailed
+				order.Error = fmt.Sprintf("slice %d failed", i)
+				break
+			}
+		}
+		return false
+	case order.Slices[next].StartedAt != nil:
+		order.Status = TWAPFailed
+		order.Error = fmt.Sprintf("router stopped while slice %d was executing; check its receipt", next)
+		return false
+	}
+
+	if late := now.Sub(order.Slices[next].ScheduledAt); late > 0 {
+		for i := next; i < len(order.Slices); i++ {
+			order.Slices[i].ScheduledAt = order.Slices[i].ScheduledAt.Add(late)
+		}
+	}
+	order.UpdatedAt = now
+	return true
+}
+
+// GetTWAP returns the current state of a TWAP order
+func (r *Service) GetTWAP(id string) (*TWAPOrder, error) {
+	order, ok := r.twaps.get(id)
+	if !ok {
+		return nil, fmt.Errorf("TWAP order not found: %s", id)
+	}
+	return &order, nil
+}
+
+// runTWAP executes an order's slices on schedule
+func (r *Service) runTWAP(ctx context.Context, id string, params TWAPParams) {
+	order, _ := r.twaps.get(id)
+	reference := order.ReferencePrice
+
+	for i, slice := range order.Slices {
+		if slice.ExecutedAt != nil {
+			continue // Ran before a restart
+		}
+		if wait := time.Until(slice.ScheduledAt); wait > 0 {
+			select {
+			case <-ctx.Done():
+				r.finishTWAP(id, TWAPFailed, ctx.Err().Error())
+				return
+			case <-time.After(wait):
+			}
+		}
+
+		sliceParams := params.Swap
+		sliceParams.AmountIn = slice.AmountIn
+		sliceParams.MinAmountOut = params.Swap.MinAmountOut * slice.AmountIn / params.Swap.AmountIn
+
+		// Check the price band against the first slice's quote
+		var price float64
+		if params.PriceBandBps > 0 {
+			quote, err := r.quote(ctx, sliceParams, 0)
+			if err != nil {
+				r.finishTWAP(id, TWAPFailed, fmt.Sprintf("slice %d quote failed: %v", i, err))
+				return
+			}
+			price = quote.EffectivePrice()
+			if reference == 0 {
+				reference = price
+			} else if moveBps := math.Abs(price-reference) / reference * 10000; moveBps > float64(params.PriceBandBps) {
+				r.finishTWAP(id, TWAPAborted, fmt.Sprintf("price moved %.0f bps from %g to %g before slice %d", moveBps, reference, price, i))
+				return
+			}
+		}
+
+		startedAt := time.Now()
+		r.twaps.update(id, func(order *TWAPOrder) {
+			order.Slices[i].StartedAt = &startedAt
+		})
+
+		result := r.executeSwap(ctx, sliceParams, nil)
+		executedAt := time.Now()
+
+		r.twaps.update(id, func(order *TWAPOrder) {
+			order.ReferencePrice = reference
+			order.Slices[i].ExecutedAt = &executedAt
+			order.Slices[i].QuotedPrice = price
+			order.Slices[i].Result = result
+			if result.Success {
+				order.FilledIn += slice.AmountIn
+				order.FilledOut += result.AmountOut
+			}
+		})
+
+		if !result.Success {
+			r.finishTWAP(id, TWAPFailed, fmt.Sprintf("slice %d failed: %s", i, result.ErrorMessage))
+			return
+		}
+	}
+
+	r.finishTWAP(id, TWAPCompleted, "")
+}
+
+// finishTWAP records an order's final status
+func (r *Service) finishTWAP(id string, status TWAPStatus, message string) {
+	r.twaps.update(id, func(order *TWAPOrder) {
+		order.Status = status
+		order.Error = message
+	})
+}
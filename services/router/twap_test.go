@@ -0,0 +1,230 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// drainingPoolQuerier serves one HBD/HIVE pool whose HIVE reserve shrinks by
+// 10% on every lookup, so each quote sees a worse price
+type drainingPoolQuerier struct {
+	mu   sync.Mutex
+	pool IndexerPoolInfo
+}
+
+func (d *drainingPoolQuerier) GetPoolByID(poolID string) (*IndexerPoolInfo, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	pool := d.pool
+	return &pool, nil
+}
+
+func (d *drainingPoolQuerier) GetPoolsByAsset(asset string) ([]IndexerPoolInfo, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	pool := d.pool
+	d.pool.Reserve1 = d.pool.Reserve1 * 9 / 10
+	return []IndexerPoolInfo{pool}, nil
+}
+
+func waitForTWAP(t *testing.T, svc *Service, id string) *TWAPOrder {
+	var order *TWAPOrder
+	require.Eventually(t, func() bool {
+		var err error
+		order, err = svc.GetTWAP(id)
+		require.NoError(t, err)
+		return order.Status != TWAPRunning
+	}, 2*time.Second, time.Millisecond)
+	return order
+}
+
+func TestTWAPParams_Schedule(t *testing.T) {
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	params := TWAPParams{Swap: SwapParams{AmountIn: 1000}, Slices: 3, Window: time.Hour}
+
+	slices := params.schedule(start)
+	require.Len(t, slices, 3)
+	assert.Equal(t, int64(333), slices[0].AmountIn)
+	assert.Equal(t, int64(333), slices[1].AmountIn)
+	assert.Equal(t, int64(334), slices[2].AmountIn)
+	assert.Equal(t, start, slices[0].ScheduledAt)
+	assert.Equal(t, start.Add(30*time.Minute), slices[1].ScheduledAt)
+	assert.Equal(t, start.Add(time.Hour), slices[2].ScheduledAt)
+}
+
+func TestSubmitTWAP_Validation(t *testing.T) {
+	svc := NewService(VSCConfig{}, &mockDEXExecutor{})
+	valid := TWAPParams{Swap: SwapParams{AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000}, Slices: 4, Window: time.Minute}
+
+	tests := []struct {
+		name   string
+		modify func(p *TWAPParams)
+	}{
+		{"same asset", func(p *TWAPParams) { p.Swap.AssetOut = "HBD" }},
+		{"one slice", func(p *TWAPParams) { p.Slices = 1 }},
+		{"no window", func(p *TWAPParams) { p.Window = 0 }},
+		{"amount too small", func(p *TWAPParams) { p.Swap.AmountIn = 3 }},
+		{"band without querier", func(p *TWAPParams) { p.PriceBandBps = 100 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := valid
+			tt.modify(&params)
+			_, err := svc.SubmitTWAP(params)
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := svc.GetTWAP("missing")
+	assert.Error(t, err)
+}
+
+func TestSubmitTWAP_Completes(t *testing.T) {
+	executor := &mockDEXExecutor{}
+	svc := NewService(VSCConfig{}, executor)
+
+	id, err := svc.SubmitTWAP(TWAPParams{
+		Swap:   SwapParams{Sender: "whale", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 1000, MinAmountOut: 3000},
+		Slices: 3,
+		Window: 20 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	order := waitForTWAP(t, svc, id)
+	assert.Equal(t, TWAPCompleted, order.Status)
+	assert.Equal(t, int64(1000), order.FilledIn)
+	assert.Len(t, executor.executedOperations, 3)
+	for _, slice := range order.Slices {
+		require.NotNil(t, slice.Result)
+		assert.True(t, slice.Result.Success)
+		assert.NotNil(t, slice.ExecutedAt)
+	}
+	// MinAmountOut is split in proportion to each slice
+	assert.Equal(t, int64(999), order.Slices[0].Result.AmountOut)
+}
+
+func TestSubmitTWAP_AbortsOutsidePriceBand(t *testing.T) {
+	executor := &mockDEXExecutor{}
+	svc := NewService(VSCConfig{}, executor)
+	svc.SetPoolQuerier(&drainingPoolQuerier{pool: IndexerPoolInfo{ID: "1", Asset0: "HBD", Asset1: "HIVE", Reserve0: 1000000, Reserve1: 4000000, Fee: 30}})
+
+	id, err := svc.SubmitTWAP(TWAPParams{
+		Swap:         SwapParams{Sender: "whale", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 3000},
+		Slices:       3,
+		Window:       20 * time.Millisecond,
+		PriceBandBps: 500,
+	})
+	require.NoError(t, err)
+
+	order := waitForTWAP(t, svc, id)
+	assert.Equal(t, TWAPAborted, order.Status)
+	assert.Contains(t, order.Error, "price moved")
+	assert.Greater(t, order.ReferencePrice, 0.0)
+	assert.Equal(t, int64(1000), order.FilledIn)
+	assert.NotNil(t, order.Slices[0].Result)
+	assert.Nil(t, order.Slices[2].Result)
+}
+
+func TestServer_TWAP(t *testing.T) {
+	svc := NewService(VSCConfig{}, &mockDEXExecutor{})
+	server := NewServer(svc, "0")
+
+	req := httptest.NewRequest("POST", "/api/v1/twap", strings.NewReader(`{"fromAsset":"HBD","toAsset":"HIVE","amount":1000,"slices":2,"windowSeconds":1}`))
+	w := httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"running"`)
+
+	location := w.Header().Get("Location")
+	require.True(t, strings.HasPrefix(location, "/api/v1/twap/"))
+
+	req = httptest.NewRequest("GET", location, nil)
+	w = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"slices":[`)
+
+	req = httptest.NewRequest("POST", "/api/v1/twap", strings.NewReader(`{"fromAsset":"HBD","toAsset":"HIVE","amount":1000,"slices":1,"windowSeconds":1}`))
+	w = httptest.NewRecorder()
+	server.http.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestRestoreTWAPOrders(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileOrderStore(dir)
+	require.NoError(t, err)
+
+	params := TWAPParams{
+		Swap:   SwapParams{Sender: "whale", AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 900, MinAmountOut: 2700},
+		Slices: 3,
+		Window: 20 * time.Millisecond,
+	}
+	twoHoursAgo := time.Now().Add(-2 * time.Hour)
+	stored := func(id string, slices []TWAPSlice) {
+		record := storedTWAPOrder{
+			ID:     id,
+			Order:  TWAPOrder{ID: id, Status: TWAPRunning, AssetIn: "HBD", AssetOut: "HIVE", AmountIn: 900, Slices: slices},
+			Params: params,
+		}
+		require.NoError(t, saveOrder(store, OrderTWAP, id, record))
+	}
+	executed := TWAPSlice{AmountIn: 300, ScheduledAt: twoHoursAgo, StartedAt: &twoHoursAgo, ExecutedAt: &twoHoursAgo, Result: &SwapResult{Success: true, AmountOut: 900}}
+	pending := func(index int, at time.Time) TWAPSlice {
+		return TWAPSlice{Index: index, AmountIn: 300, ScheduledAt: at}
+	}
+
+	// One slice ran before the restart; two were missed while it was down
+	stored("resumed", []TWAPSlice{executed, pending(1, twoHoursAgo.Add(10*time.Millisecond)), pending(2, twoHoursAgo.Add(20*time.Millisecond))})
+	// The router stopped while a slice was executing
+	interrupted := pending(0, twoHoursAgo)
+	interrupted.StartedAt = &twoHoursAgo
+	stored("interrupted", []TWAPSlice{interrupted, pending(1, twoHoursAgo), pending(2, twoHoursAgo)})
+	// Every slice ran, but the order was never marked finished
+	stored("finished", []TWAPSlice{executed, executed, executed})
+
+	executor := &mockDEXExecutor{}
+	svc := NewService(VSCConfig{}, executor)
+	require.NoError(t, svc.RestoreTWAPOrders(store))
+
+	order := waitForTWAP(t, svc, "resumed")
+	assert.Equal(t, TWAPCompleted, order.Status, order.Error)
+	assert.Len(t, executor.executedOperations, 2, "only the missed slices should run")
+	assert.Equal(t, int64(600), order.FilledIn)
+	// Missed slices are not rushed: they keep their spacing
+	gap := order.Slices[2].ScheduledAt.Sub(order.Slices[1].ScheduledAt)
+	assert.Equal(t, 10*time.Millisecond, gap)
+	assert.WithinDuration(t, time.Now(), order.Slices[1].ScheduledAt, time.Minute)
+
+	order, err = svc.GetTWAP("interrupted")
+	require.NoError(t, err)
+	assert.Equal(t, TWAPFailed, order.Status)
+	assert.Contains(t, order.Error, "slice 0 was executing")
+
+	order, err = svc.GetTWAP("finished")
+	require.NoError(t, err)
+	assert.Equal(t, TWAPCompleted, order.Status)
+
+	// New orders are persisted too, and progress survives another restart
+	id, err := svc.SubmitTWAP(params)
+	require.NoError(t, err)
+	waitForTWAP(t, svc, id)
+
+	reopened, err := NewFileOrderStore(dir)
+	require.NoError(t, err)
+	restarted := NewService(VSCConfig{}, &mockDEXExecutor{})
+	require.NoError(t, restarted.RestoreTWAPOrders(reopened))
+	for _, id := range []string{"resumed", id} {
+		order, err := restarted.GetTWAP(id)
+		require.NoError(t, err)
+		assert.Equal(t, TWAPCompleted, order.Status)
+	}
+}
@@ -0,0 +1,252 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DEX router contract state keys, as laid out by contracts/dex-router
+const (
+	stateNextPoolID = "next_pool_id"
+	statePoolPrefix = "pool/"
+)
+
+// poolStateFields are the per-pool state keys a pool is read from
+var poolStateFields = []string{"asset0", "asset1", "reserve0", "reserve1", "fee", "total_lp", "tick_spacing"}
+
+// VSCPoolQuerier implements PoolQuerier and PositionQuerier by reading the
+// DEX router contract's state from a VSC node's GraphQL API. It needs no
+// indexer, and is always as current as the node, at the cost of reading every
+// pool's state on each asset lookup.
+type VSCPoolQuerier struct {
+	endpoint   string
+	contractID string
+	httpClient *http.Client
+}
+
+// NewVSCPoolQuerier creates a pool querier reading the given DEX router
+// contract's state from a VSC node
+func NewVSCPoolQuerier(vscEndpoint, contractID string) *VSCPoolQuerier {
+	return &VSCPoolQuerier{
+		endpoint:   strings.TrimRight(vscEndpoint, "/"),
+		contractID: contractID,
+		httpClient: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+	}
+}
+
+// GetPoolByID retrieves a pool by its ID in the contract
+func (q *VSCPoolQuerier) GetPoolByID(poolID string) (*IndexerPoolInfo, error) {
+	state, err := q.getState(context.Background(), poolStateKeys(poolID))
+	if err != nil {
+		return nil, err
+	}
+
+	pool, ok, err := poolFromState(poolID, state)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("pool not found: %s", poolID)
+	}
+	return &pool, nil
+}
+
+// GetPoolsByAsset retrieves all pools containing the specified asset
+func (q *VSCPoolQuerier) GetPoolsByAsset(asset string) ([]IndexerPoolInfo, error) {
+	pools, err := q.ListPools()
+	if err != nil {
+		return nil, err
+	}
+
+	var matchingPools []IndexerPoolInfo
+	for _, pool := range pools {
+		if pool.Asset0 == asset || pool.Asset1 == asset {
+			matchingPools = append(matchingPools, pool)
+		}
+	}
+	return matchingPools, nil
+}
+
+// ListPools returns every pool in the contract. Pool IDs are assigned
+// sequentially from 1, so this reads the next ID and then every pool's state
+// in a single query.
+func (q *VSCPoolQuerier) ListPools() ([]IndexerPoolInfo, error) {
+	ctx := context.Background()
+
+	state, err := q.getState(ctx, []string{stateNextPoolID})
+	if err != nil {
+		return nil, err
+	}
+	nextPoolID, err := stateUint(state, stateNextPoolID)
+	if err != nil {
+		return nil, err
+	}
+	if nextPoolID <= 1 {
+		return nil, nil
+	}
+
+	var keys []string
+	for id := uint64(1); id < nextPoolID; id++ {
+		keys = append(keys, poolStateKeys(strconv.FormatUint(id, 10))...)
+	}
+	state, err = q.getState(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	var pools []IndexerPoolInfo
+	for id := uint64(1); id < nextPoolID; id++ {
+		pool, ok, err := poolFromState(strconv.FormatUint(id, 10), state)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			pools = append(pools, pool)
+		}
+	}
+	return pools, nil
+}
+
+// GetLiquidityPosition returns the LP tokens account holds in a pool, or zero
+// if it has no position
+func (q *VSCPoolQuerier) GetLiquidityPosition(poolID, account string) (uint64, error) {
+	key := poolStateKey(poolID, "lp/"+account)
+	state, err := q.getState(context.Background(), []string{key})
+	if err != nil {
+		return 0, err
+	}
+	return stateUint(state, key)
+}
+
+// getState reads contract state keys, returning each key's raw value. Keys
+// that were never set are missing or null.
+func (q *VSCPoolQuerier) getState(ctx context.Context, keys []string) (map[string]json.RawMessage, error) {
+	query := `query GetStateByKeys($contractId: String!, $keys: [String!]!) {
+		getStateByKeys(contractId: $contractId, keys: $keys)
+	}`
+
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"query": query,
+		"variables": map[string]interface{}{
+			"contractId": q.contractID,
+			"keys":       keys,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal GraphQL request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", q.endpoint+"/api/v1/graphql", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := q.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query VSC node: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("VSC node returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Data struct {
+			GetStateByKeys map[string]json.RawMessage `json:"getStateByKeys"`
+		} `json:"data"`
+		Errors []map[string]interface{} `json:"errors,omitempty"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode GraphQL response: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		return nil, fmt.Errorf("GraphQL errors: %v", result.Errors)
+	}
+	return result.Data.GetStateByKeys, nil
+}
+
+// poolStateKey is the contract state key of one of a pool's fields
+func poolStateKey(poolID, field string) string {
+	return statePoolPrefix + poolID + "/" + field
+}
+
+// poolStateKeys lists every state key a pool is read from
+func poolStateKeys(poolID string) []string {
+	keys := make([]string, 0, len(poolStateFields))
+	for _, field := range poolStateFields {
+		keys = append(keys, poolStateKey(poolID, field))
+	}
+	return keys
+}
+
+// poolFromState builds a pool from contract state, reporting false if the
+// pool does not exist
+func poolFromState(poolID string, state map[string]json.RawMessage) (IndexerPoolInfo, bool, error) {
+	pool := IndexerPoolInfo{
+		ID:     poolID,
+		Asset0: stateString(state, poolStateKey(poolID, "asset0")),
+		Asset1: stateString(state, poolStateKey(poolID, "asset1")),
+	}
+	if pool.Asset0 == "" {
+		return pool, false, nil
+	}
+
+	var err error
+	if pool.Reserve0, err = stateUint(state, poolStateKey(poolID, "reserve0")); err != nil {
+		return pool, false, err
+	}
+	if pool.Reserve1, err = stateUint(state, poolStateKey(poolID, "reserve1")); err != nil {
+		return pool, false, err
+	}
+	if pool.Fee, err = stateUint(state, poolStateKey(poolID, "fee")); err != nil {
+		return pool, false, err
+	}
+	if pool.TotalSupply, err = stateUint(state, poolStateKey(poolID, "total_lp")); err != nil {
+		return pool, false, err
+	}
+	tickSpacing, err := stateUint(state, poolStateKey(poolID, "tick_spacing"))
+	if err != nil {
+		return pool, false, err
+	}
+	if tickSpacing > 0 {
+		pool.Type = poolTypeConcentrated
+	}
+	return pool, true, nil
+}
+
+// stateString returns a state value as a string, or "" if it is unset. The
+// contract stores every value as a string, but numbers are accepted too.
+func stateString(state map[string]json.RawMessage, key string) string {
+	raw, ok := state[key]
+	if !ok || string(raw) == "null" {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return string(raw)
+}
+
+// stateUint returns a numeric state value, or zero if it is unset
+func stateUint(state map[string]json.RawMessage, key string) (uint64, error) {
+	s := stateString(state, key)
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid contract state %s: %q", key, s)
+	}
+	return v, nil
+}
@@ -0,0 +1,144 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// contractStateServer serves getStateByKeys from a fixed state map, as a VSC
+// node would for the DEX router contract
+func contractStateServer(t *testing.T, state map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/graphql", r.URL.Path)
+
+		var req struct {
+			Query     string `json:"query"`
+			Variables struct {
+				ContractID string   `json:"contractId"`
+				Keys       []string `json:"keys"`
+			} `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Contains(t, req.Query, "getStateByKeys")
+		assert.Equal(t, "dex-router-contract", req.Variables.ContractID)
+
+		values := make(map[string]interface{}, len(req.Variables.Keys))
+		for _, key := range req.Variables.Keys {
+			if v, ok := state[key]; ok {
+				values[key] = v
+			} else {
+				values[key] = nil
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"getStateByKeys": values},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// testContractState holds two pools, BTC/HBD and HBD/HIVE
+var testContractState = map[string]string{
+	"next_pool_id":    "3",
+	"pool/1/asset0":   "BTC",
+	"pool/1/asset1":   "HBD",
+	"pool/1/reserve0": "1000",
+	"pool/1/reserve1": "2000",
+	"pool/1/fee":      "8",
+	"pool/1/total_lp": "1414",
+	"pool/1/lp/alice": "500",
+	"pool/2/asset0":   "HBD",
+	"pool/2/asset1":   "HIVE",
+	"pool/2/reserve0": "3000",
+	"pool/2/reserve1": "4000",
+	"pool/2/fee":      "30",
+	"pool/2/total_lp": "3464",
+}
+
+func TestVSCPoolQuerier_GetPoolByID(t *testing.T) {
+	server := contractStateServer(t, testContractState)
+	querier := NewVSCPoolQuerier(server.URL, "dex-router-contract")
+
+	pool, err := querier.GetPoolByID("1")
+	require.NoError(t, err)
+	assert.Equal(t, IndexerPoolInfo{
+		ID:          "1",
+		Asset0:      "BTC",
+		Asset1:      "HBD",
+		Reserve0:    1000,
+		Reserve1:    2000,
+		Fee:         8,
+		TotalSupply: 1414,
+	}, *pool)
+
+	_, err = querier.GetPoolByID("9")
+	assert.Error(t, err)
+}
+
+func TestVSCPoolQuerier_GetPoolsByAsset(t *testing.T) {
+	server := contractStateServer(t, testContractState)
+	querier := NewVSCPoolQuerier(server.URL, "dex-router-contract")
+
+	pools, err := querier.GetPoolsByAsset("HBD")
+	require.NoError(t, err)
+	assert.Len(t, pools, 2)
+
+	pools, err = querier.GetPoolsByAsset("HIVE")
+	require.NoError(t, err)
+	require.Len(t, pools, 1)
+	assert.Equal(t, "2", pools[0].ID)
+	assert.Equal(t, uint64(30), pools[0].Fee)
+}
+
+func TestVSCPoolQuerier_NoPools(t *testing.T) {
+	server := contractStateServer(t, map[string]string{})
+	querier := NewVSCPoolQuerier(server.URL, "dex-router-contract")
+
+	pools, err := querier.ListPools()
+	require.NoError(t, err)
+	assert.Empty(t, pools)
+}
+
+func TestVSCPoolQuerier_GetLiquidityPosition(t *testing.T) {
+	server := contractStateServer(t, testContractState)
+	querier := NewVSCPoolQuerier(server.URL, "dex-router-contract")
+
+	amount, err := querier.GetLiquidityPosition("1", "alice")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(500), amount)
+
+	amount, err = querier.GetLiquidityPosition("1", "bob")
+	require.NoError(t, err)
+	assert.Zero(t, amount)
+}
+
+func TestVSCPoolQuerier_GraphQLErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"errors":[{"message":"contract not found"}]}`))
+	}))
+	defer server.Close()
+
+	querier := NewVSCPoolQuerier(server.URL, "dex-router-contract")
+	_, err := querier.GetPoolsByAsset("BTC")
+	assert.ErrorContains(t, err, "contract not found")
+}
+
+func TestVSCPoolQuerier_InvalidState(t *testing.T) {
+	state := map[string]string{
+		"next_pool_id":    "2",
+		"pool/1/asset0":   "BTC",
+		"pool/1/asset1":   "HBD",
+		"pool/1/reserve0": "lots",
+	}
+	server := contractStateServer(t, state)
+	querier := NewVSCPoolQuerier(server.URL, "dex-router-contract")
+
+	_, err := querier.GetPoolByID("1")
+	assert.ErrorContains(t, err, "pool/1/reserve0")
+}
@@ -0,0 +1,296 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+)
+
+// Withdrawal allowance sizing
+const (
+	// withdrawalBufferBps is added to the expected withdrawal amounts so the
+	// allowance tolerates reserves moving between sizing and execution
+	withdrawalBufferBps = 100
+
+	// unsizedWithdrawalLimit is the allowance used when no pool querier is
+	// configured and the withdrawal cannot be sized
+	unsizedWithdrawalLimit = 1000000000
+)
+
+// PositionQuerier provides liquidity positions for sizing withdrawals
+type PositionQuerier interface {
+	GetLiquidityPosition(poolID, account string) (uint64, error)
+}
+
+// SetPositionQuerier configures where the router reads LP positions from
+func (s *Service) SetPositionQuerier(querier PositionQuerier) {
+	s.positionQuerier = querier
+}
+
+// withdrawalPlan is the LP amount a withdrawal burns and the amounts of each
+// asset it is expected to return
+type withdrawalPlan struct {
+	PoolID    string // Empty if the withdrawal could not be sized
+	LpAmount  uint64
+	AmountIn  uint64 // Of WithdrawalParams.AssetIn
+	AmountOut uint64 // Of WithdrawalParams.AssetOut
+	LimitIn   uint64
+	LimitOut  uint64
+}
+
+// planWithdrawal sizes a withdrawal from the pool's reserves and the sender's
+// position. A zero LpAmount withdraws the whole position.
+func (s *Service) planWithdrawal(params WithdrawalParams) (*withdrawalPlan, error) {
+	if params.LpAmount < 0 {
+		return nil, fmt.Errorf("LP amount must not be negative")
+	}
+	if s.poolQuerier == nil {
+		if params.LpAmount == 0 {
+			return nil, fmt.Errorf("LP amount required when positions cannot be queried")
+		}
+		return &withdrawalPlan{
+			LpAmount: uint64(params.LpAmount),
+			LimitIn:  unsizedWithdrawalLimit,
+			LimitOut: unsizedWithdrawalLimit,
+		}, nil
+	}
+
+	pool, err := s.findPool(params.AssetIn, params.AssetOut)
+	if err != nil {
+		return nil, err
+	}
+
+	lpAmount := uint64(params.LpAmount)
+	if s.positionQuerier != nil {
+		position, err := s.positionQuerier.GetLiquidityPosition(pool.ID, params.Sender)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load liquidity position: %w", err)
+		}
+		if position == 0 {
+			return nil, fmt.Errorf("%s has no liquidity in pool %s", params.Sender, pool.ID)
+		}
+		if lpAmount == 0 {
+			lpAmount = position
+		}
+		if lpAmount > position {
+			return nil, fmt.Errorf("LP amount %d exceeds position of %d", lpAmount, position)
+		}
+	}
+	if lpAmount == 0 {
+		return nil, fmt.Errorf("LP amount required when positions cannot be queried")
+	}
+	if pool.TotalSupply == 0 {
+		return nil, fmt.Errorf("pool %s has no LP supply", pool.ID)
+	}
+	if lpAmount > pool.TotalSupply {
+		return nil, fmt.Errorf("LP amount %d exceeds pool supply of %d", lpAmount, pool.TotalSupply)
+	}
+
+	reserveIn, reserveOut, _ := orientPool(*pool, params.AssetIn)
+	plan := &withdrawalPlan{
+		PoolID:    pool.ID,
+		LpAmount:  lpAmount,
+		AmountIn:  proRata(reserveIn, lpAmount, pool.TotalSupply),
+		AmountOut: proRata(reserveOut, lpAmount, pool.TotalSupply),
+	}
+	plan.LimitIn = withBuffer(plan.AmountIn, withdrawalBufferBps)
+	plan.LimitOut = withBuffer(plan.AmountOut, withdrawalBufferBps)
+	return plan, nil
+}
+
+// SingleAssetWithdrawalParams represents a withdrawal paid out in one asset
+type SingleAssetWithdrawalParams struct {
+	Sender       string
+	AssetOut     string // Asset the sender receives
+	PairAsset    string // The pool's other asset, swapped into AssetOut
+	LpAmount     int64  // Zero withdraws the whole position
+	MinAmountOut int64  // Across both steps; zero derives it from MaxSlippage
+	MaxSlippage  uint64
+}
+
+// singleAssetPlan splits a single-asset withdrawal into its two legs
+type singleAssetPlan struct {
+	Withdrawal     WithdrawalParams
+	WithdrawalPlan *withdrawalPlan
+	Swap           SwapParams
+	Expected       int64 // Total AssetOut expected across both legs
+	Minimum        int64 // Total AssetOut the sender is protected to
+}
+
+// ExecuteWithdrawToSingleAsset removes liquidity and swaps the PairAsset leg
+// into AssetOut, so the sender receives one asset. The slippage limit covers
+// the total received: the swap leg's minimum is whatever the withdrawn
+// AssetOut leaves to reach it. Both legs go out in one transaction when the
+// executor supports batching.
+func (s *Service) ExecuteWithdrawToSingleAsset(ctx context.Context, params SingleAssetWithdrawalParams) (*SwapResult, error) {
+	if err := ctx.Err(); err != nil {
+		return &SwapResult{
+			Success:      false,
+			ErrorMessage: fmt.Sprintf("withdrawal cancelled: %v", err),
+		}, nil
+	}
+
+	plan, err := s.planSingleAssetWithdrawal(params)
+	if err != nil {
+		return &SwapResult{
+			Success:      false,
+			ErrorMessage: fmt.Sprintf("failed to plan withdrawal: %v", err),
+		}, nil
+	}
+	if err := s.acceptSwap(plan.Swap); err != nil {
+		return &SwapResult{
+			Success:      false,
+			ErrorMessage: err.Error(),
+		}, nil
+	}
+
+	var result *SwapResult
+	if _, ok := s.dexExecutor.(BatchExecutor); ok {
+		batch, err := s.ExecuteBatch(ctx, BatchParams{
+			Sender: params.Sender,
+			Operations: []BatchOperation{
+				{Withdrawal: &plan.Withdrawal},
+				{Swap: &plan.Swap},
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !batch.Success {
+			return &SwapResult{
+				Success:      false,
+				ErrorMessage: fmt.Sprintf("withdrawal execution failed: %s", batch.ErrorMessage),
+			}, nil
+		}
+		result = batch.Results[1]
+	} else {
+		// Without batching, swap only once the withdrawal has gone through
+		withdrawal, err := s.ExecuteWithdrawal(ctx, plan.Withdrawal)
+		if err != nil {
+			return nil, err
+		}
+		if !withdrawal.Success {
+			return withdrawal, nil
+		}
+		result = s.executeSwap(ctx, plan.Swap, nil)
+		if !result.Success {
+			result.ErrorMessage = fmt.Sprintf("withdrawal succeeded but swapping %s to %s failed: %s", params.PairAsset, params.AssetOut, result.ErrorMessage)
+			return result, nil
+		}
+		result.Simulation = withdrawal.Simulation.merge(result.Simulation)
+	}
+
+	// Report totals across both legs. Until the swap settles its output is
+	// the plan's, which prices it against the reserves the withdrawal leaves.
+	if result.Settled {
+		result.AmountOut += int64(plan.WithdrawalPlan.AmountOut)
+	} else {
+		result.AmountOut = plan.Expected
+	}
+	result.EstimatedAmountOut = plan.Expected
+	result.MinimumReceived = plan.Minimum
+	result.Route = append([]string{"withdrawal"}, result.Route...)
+	return result, nil
+}
+
+// planSingleAssetWithdrawal sizes the withdrawal from the sender's position
+// and the swap leg from the pool's reserves once the withdrawal has left
+func (s *Service) planSingleAssetWithdrawal(params SingleAssetWithdrawalParams) (*singleAssetPlan, error) {
+	if params.AssetOut == params.PairAsset {
+		return nil, fmt.Errorf("asset out and pair asset must differ")
+	}
+	if params.MinAmountOut < 0 {
+		return nil, fmt.Errorf("minimum amount out must not be negative")
+	}
+	if s.poolQuerier == nil {
+		return nil, fmt.Errorf("pool querier not configured")
+	}
+
+	// AssetIn is the leg that gets swapped
+	withdrawal := WithdrawalParams{
+		Sender:   params.Sender,
+		AssetIn:  params.PairAsset,
+		AssetOut: params.AssetOut,
+		LpAmount: params.LpAmount,
+	}
+	wplan, err := s.planWithdrawal(withdrawal)
+	if err != nil {
+		return nil, err
+	}
+	if wplan.AmountIn == 0 {
+		return nil, fmt.Errorf("withdrawal returns no %s to swap", params.PairAsset)
+	}
+	withdrawal.LpAmount = int64(wplan.LpAmount)
+	pool, err := s.poolQuerier.GetPoolByID(wplan.PoolID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pool %s: %w", wplan.PoolID, err)
+	}
+
+	// The swap trades against the reserves the withdrawal leaves behind
+	reserveIn, reserveOut, _ := orientPool(*pool, params.PairAsset)
+	if reserveIn <= wplan.AmountIn || reserveOut <= wplan.AmountOut {
+		return nil, fmt.Errorf("withdrawal would empty pool %s", pool.ID)
+	}
+	swapOut, _ := constantProductOut(wplan.AmountIn, reserveIn-wplan.AmountIn, reserveOut-wplan.AmountOut, pool.Fee)
+
+	expected := int64(wplan.AmountOut + swapOut)
+	minimum := params.MinAmountOut
+	if minimum == 0 {
+		minimum = (&Quote{AmountOut: expected}).MinimumReceived(params.MaxSlippage)
+	}
+	if minimum > expected {
+		return nil, fmt.Errorf("minimum amount out %d exceeds the expected %d", minimum, expected)
+	}
+
+	// The swap must make up whatever the withdrawn AssetOut falls short of
+	// the minimum; never let it execute unprotected
+	swapMin := minimum - int64(wplan.AmountOut)
+	if swapMin < 1 {
+		swapMin = 1
+	}
+
+	return &singleAssetPlan{
+		Withdrawal:     withdrawal,
+		WithdrawalPlan: wplan,
+		Swap: SwapParams{
+			Sender:       params.Sender,
+			AssetIn:      params.PairAsset,
+			AssetOut:     params.AssetOut,
+			AmountIn:     int64(wplan.AmountIn),
+			MinAmountOut: swapMin,
+			MaxSlippage:  params.MaxSlippage,
+		},
+		Expected: expected,
+		Minimum:  minimum,
+	}, nil
+}
+
+// findPool returns the constant product pool trading assetA against assetB.
+// Concentrated pools are skipped: they issue no LP shares and their
+// reserves are not a price to plan against.
+func (s *Service) findPool(assetA, assetB string) (*IndexerPoolInfo, error) {
+	pools, err := s.poolQuerier.GetPoolsByAsset(assetA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pools for %s: %w", assetA, err)
+	}
+	for _, pool := range pools {
+		if !pool.concentrated() && poolHasAsset(pool, assetB) {
+			return &pool, nil
+		}
+	}
+	return nil, fmt.Errorf("no pool found for %s/%s", assetA, assetB)
+}
+
+// proRata returns reserve * share / total, rounded down as the contract does
+func proRata(reserve, share, total uint64) uint64 {
+	amount := new(big.Int).Mul(new(big.Int).SetUint64(reserve), new(big.Int).SetUint64(share))
+	return amount.Quo(amount, new(big.Int).SetUint64(total)).Uint64()
+}
+
+// withBuffer adds bufferBps to amount, rounding up
+func withBuffer(amount, bufferBps uint64) uint64 {
+	buffer := new(big.Int).Mul(new(big.Int).SetUint64(amount), new(big.Int).SetUint64(bufferBps))
+	buffer.Add(buffer, big.NewInt(9999))
+	buffer.Quo(buffer, big.NewInt(10000))
+	return amount + buffer.Uint64()
+}
@@ -0,0 +1,221 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// intentRecordingExecutor records the intents submitted with each operation
+type intentRecordingExecutor struct {
+	mockDEXExecutor
+	intents [][]Intent
+}
+
+func (m *intentRecordingExecutor) ExecuteDexOperationWithIntents(ctx context.Context, operationType string, payload string, intents []Intent) error {
+	m.intents = append(m.intents, intents)
+	return m.mockDEXExecutor.ExecuteDexOperationWithIntents(ctx, operationType, payload, intents)
+}
+
+// mockPositionQuerier serves fixed LP positions keyed by pool ID and account
+type mockPositionQuerier map[string]uint64
+
+func (m mockPositionQuerier) GetLiquidityPosition(poolID, account string) (uint64, error) {
+	return m[poolID+"/"+account], nil
+}
+
+func newWithdrawalTestService() (*Service, *intentRecordingExecutor) {
+	executor := &intentRecordingExecutor{}
+	svc := NewService(VSCConfig{DexRouterContract: "dex-router-contract"}, executor)
+	svc.SetPoolQuerier(&mockPoolQuerier{pools: []IndexerPoolInfo{
+		{ID: "pool-1", Asset0: "HBD", Asset1: "HIVE", Reserve0: 1000000, Reserve1: 500000, Fee: 8, TotalSupply: 10000},
+	}})
+	svc.SetPositionQuerier(mockPositionQuerier{"pool-1/alice": 2500})
+	return svc, executor
+}
+
+func intentLimits(intents []Intent) map[string]string {
+	limits := make(map[string]string)
+	for _, intent := range intents {
+		limits[intent.Args["token"]] = intent.Args["limit"]
+	}
+	return limits
+}
+
+func TestExecuteWithdrawalSizesAllowances(t *testing.T) {
+	svc, executor := newWithdrawalTestService()
+
+	// Withdrawing 1000 of 10000 LP returns 10% of each reserve
+	result, err := svc.ExecuteWithdrawal(context.Background(), WithdrawalParams{
+		Sender:   "alice",
+		AssetIn:  "HIVE",
+		AssetOut: "HBD",
+		LpAmount: 1000,
+	})
+	require.NoError(t, err)
+	require.True(t, result.Success, result.ErrorMessage)
+
+	require.Len(t, executor.intents, 1)
+	assert.Equal(t, map[string]string{
+		"HIVE": "50500",  // 50000 plus 1%
+		"HBD":  "101000", // 100000 plus 1%
+	}, intentLimits(executor.intents[0]))
+
+	var instruction map[string]interface{}
+	payload := strings.TrimPrefix(executor.executedOperations[0], "execute:")
+	require.NoError(t, json.Unmarshal([]byte(payload), &instruction))
+	assert.Equal(t, map[string]interface{}{"lp_amount": "1000"}, instruction["metadata"])
+}
+
+func TestExecuteWithdrawalWholePosition(t *testing.T) {
+	svc, executor := newWithdrawalTestService()
+
+	result, err := svc.ExecuteWithdrawal(context.Background(), WithdrawalParams{
+		Sender:   "alice",
+		AssetIn:  "HBD",
+		AssetOut: "HIVE",
+	})
+	require.NoError(t, err)
+	require.True(t, result.Success, result.ErrorMessage)
+
+	assert.Equal(t, map[string]string{
+		"HBD":  "252500",
+		"HIVE": "126250",
+	}, intentLimits(executor.intents[0]))
+	assert.Contains(t, executor.executedOperations[0], `"lp_amount":"2500"`)
+}
+
+func TestExecuteWithdrawalRejectsUnbackedAmounts(t *testing.T) {
+	tests := []struct {
+		name   string
+		params WithdrawalParams
+		errMsg string
+	}{
+		{"exceeds position", WithdrawalParams{Sender: "alice", AssetIn: "HBD", AssetOut: "HIVE", LpAmount: 3000}, "exceeds position of 2500"},
+		{"no position", WithdrawalParams{Sender: "bob", AssetIn: "HBD", AssetOut: "HIVE", LpAmount: 100}, "bob has no liquidity in pool pool-1"},
+		{"unknown pool", WithdrawalParams{Sender: "alice", AssetIn: "HBD", AssetOut: "BTC", LpAmount: 100}, "no pool found for HBD/BTC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, executor := newWithdrawalTestService()
+
+			result, err := svc.ExecuteWithdrawal(context.Background(), tt.params)
+			require.NoError(t, err)
+			assert.False(t, result.Success)
+			assert.Contains(t, result.ErrorMessage, tt.errMsg)
+			assert.Empty(t, executor.executedOperations, "nothing should be broadcast")
+		})
+	}
+}
+
+func TestWithBuffer(t *testing.T) {
+	for _, tt := range []struct{ amount, want uint64 }{
+		{0, 0},
+		{1, 2}, // Rounds up so tiny amounts still get headroom
+		{100, 101},
+		{100000, 101000},
+	} {
+		assert.Equal(t, tt.want, withBuffer(tt.amount, 100), fmt.Sprint(tt.amount))
+	}
+}
+
+func TestFindPoolSkipsConcentratedPools(t *testing.T) {
+	svc := NewService(VSCConfig{DexRouterContract: "dex-router-contract"}, &mockDEXExecutor{})
+	svc.SetPoolQuerier(&mockPoolQuerier{pools: []IndexerPoolInfo{
+		{ID: "pool-cl", Type: poolTypeConcentrated, Asset0: "HBD", Asset1: "HIVE", Reserve0: 1000000, Reserve1: 8000000, Fee: 30},
+		{ID: "pool-1", Asset0: "HBD", Asset1: "HIVE", Reserve0: 1000000, Reserve1: 500000, Fee: 8, TotalSupply: 10000},
+	}})
+
+	pool, err := svc.findPool("HIVE", "HBD")
+	require.NoError(t, err)
+	assert.Equal(t, "pool-1", pool.ID)
+
+	svc.SetPoolQuerier(&mockPoolQuerier{pools: []IndexerPoolInfo{
+		{ID: "pool-cl", Type: poolTypeConcentrated, Asset0: "HBD", Asset1: "HIVE", Reserve0: 1000000, Reserve1: 8000000, Fee: 30},
+	}})
+	_, err = svc.findPool("HIVE", "HBD")
+	assert.ErrorContains(t, err, "no pool found for HIVE/HBD")
+}
+
+func TestPlanSingleAssetWithdrawal(t *testing.T) {
+	svc, _ := newWithdrawalTestService()
+
+	plan, err := svc.planSingleAssetWithdrawal(SingleAssetWithdrawalParams{
+		Sender:      "alice",
+		AssetOut:    "HBD",
+		PairAsset:   "HIVE",
+		MaxSlippage: 50,
+	})
+	require.NoError(t, err)
+
+	// alice's 2500 of 10000 LP returns 250000 HBD and 125000 HIVE; the HIVE
+	// is swapped against the reserves left behind (750000 HBD / 375000 HIVE)
+	assert.Equal(t, int64(2500), plan.Withdrawal.LpAmount)
+	assert.Equal(t, uint64(250000), plan.WithdrawalPlan.AmountOut)
+	assert.Equal(t, int64(125000), plan.Swap.AmountIn)
+	assert.Equal(t, int64(250000+187387), plan.Expected)
+	assert.Equal(t, int64(435200), plan.Minimum)
+
+	// The swap leg only has to make up the rest of the combined minimum
+	assert.Equal(t, int64(435200-250000), plan.Swap.MinAmountOut)
+
+	_, err = svc.planSingleAssetWithdrawal(SingleAssetWithdrawalParams{
+		Sender:       "alice",
+		AssetOut:     "HBD",
+		PairAsset:    "HIVE",
+		MinAmountOut: 500000,
+	})
+	assert.ErrorContains(t, err, "exceeds the expected 437387")
+}
+
+func TestExecuteWithdrawToSingleAsset(t *testing.T) {
+	svc, executor := newWithdrawalTestService()
+
+	result, err := svc.ExecuteWithdrawToSingleAsset(context.Background(), SingleAssetWithdrawalParams{
+		Sender:      "alice",
+		AssetOut:    "HBD",
+		PairAsset:   "HIVE",
+		LpAmount:    1000,
+		MaxSlippage: 50,
+	})
+	require.NoError(t, err)
+	require.True(t, result.Success, result.ErrorMessage)
+	assert.Equal(t, "withdrawal", result.Route[0])
+	assert.Equal(t, result.EstimatedAmountOut, result.AmountOut, "unsettled swaps report the expected output")
+
+	// The withdrawal goes out first, then the swap of the HIVE leg
+	require.Len(t, executor.executedOperations, 2)
+	assert.Contains(t, executor.executedOperations[0], `"type":"withdrawal"`)
+	assert.Contains(t, executor.executedOperations[0], `"lp_amount":"1000"`)
+	assert.Contains(t, executor.executedOperations[1], `"type":"swap"`)
+	assert.Contains(t, executor.executedOperations[1], `"asset_in":"HIVE"`)
+}
+
+func TestExecuteWithdrawToSingleAssetBatched(t *testing.T) {
+	executor := &mockBatchExecutor{}
+	svc := NewService(VSCConfig{}, executor)
+	svc.SetPoolQuerier(&mockPoolQuerier{pools: []IndexerPoolInfo{
+		{ID: "pool-1", Asset0: "HBD", Asset1: "HIVE", Reserve0: 1000000, Reserve1: 500000, Fee: 8, TotalSupply: 10000},
+	}})
+	svc.SetPositionQuerier(mockPositionQuerier{"pool-1/alice": 2500})
+
+	result, err := svc.ExecuteWithdrawToSingleAsset(context.Background(), SingleAssetWithdrawalParams{
+		Sender:    "alice",
+		AssetOut:  "HIVE",
+		PairAsset: "HBD",
+	})
+	require.NoError(t, err)
+	require.True(t, result.Success, result.ErrorMessage)
+	assert.Equal(t, "batch-tx-1", result.TxID)
+
+	require.Len(t, executor.batches, 1)
+	require.Len(t, executor.batches[0], 2)
+	assert.Contains(t, executor.batches[0][0].Payload, `"lp_amount":"2500"`)
+	assert.Contains(t, executor.batches[0][1].Payload, `"asset_in":"HBD"`)
+}
@@ -0,0 +1,152 @@
+package router
+
+import (
+	"context"
+	"fmt"
+)
+
+// ZapDepositParams represents a deposit funded entirely from one asset
+type ZapDepositParams struct {
+	Sender      string
+	AssetIn     string // Asset the sender holds
+	PairAsset   string // The pool's other asset
+	AmountIn    int64
+	MaxSlippage uint64 // Tolerance on the swap leg, in basis points
+}
+
+// zapPlan splits a zap into its swap and deposit legs
+type zapPlan struct {
+	Swap    SwapParams
+	Deposit DepositParams
+}
+
+// ExecuteZapDeposit swaps half of a single asset into the pool's other asset
+// and deposits both sides, so the sender does not need to hold both assets.
+// Both legs go out in one transaction when the executor supports batching.
+// Whatever the deposit cannot use at the post-swap pool ratio stays with the
+// sender.
+func (s *Service) ExecuteZapDeposit(ctx context.Context, params ZapDepositParams) (*SwapResult, error) {
+	if err := ctx.Err(); err != nil {
+		return &SwapResult{
+			Success:      false,
+			ErrorMessage: fmt.Sprintf("zap cancelled: %v", err),
+		}, nil
+	}
+
+	plan, err := s.planZap(params)
+	if err != nil {
+		return &SwapResult{
+			Success:      false,
+			ErrorMessage: fmt.Sprintf("failed to plan zap: %v", err),
+		}, nil
+	}
+	if err := s.acceptSwap(plan.Swap); err != nil {
+		return &SwapResult{
+			Success:      false,
+			ErrorMessage: err.Error(),
+		}, nil
+	}
+
+	if _, ok := s.dexExecutor.(BatchExecutor); ok {
+		batch, err := s.ExecuteBatch(ctx, BatchParams{
+			Sender: params.Sender,
+			Operations: []BatchOperation{
+				{Swap: &plan.Swap},
+				{Deposit: &plan.Deposit},
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !batch.Success {
+			return &SwapResult{
+				Success:      false,
+				ErrorMessage: fmt.Sprintf("zap execution failed: %s", batch.ErrorMessage),
+			}, nil
+		}
+		result := batch.Results[0]
+		result.Route = append(result.Route, "deposit")
+		return result, nil
+	}
+
+	// Without batching, deposit only once the swap leg has gone through
+	result := s.executeSwap(ctx, plan.Swap, nil)
+	if !result.Success {
+		result.ErrorMessage = fmt.Sprintf("zap swap failed: %s", result.ErrorMessage)
+		return result, nil
+	}
+	deposit, err := s.ExecuteDeposit(ctx, plan.Deposit)
+	if err != nil {
+		return nil, err
+	}
+	if !deposit.Success {
+		result.Success = false
+		result.ErrorMessage = fmt.Sprintf("zap deposit failed after swap %s: %s", result.TxID, deposit.ErrorMessage)
+		return result, nil
+	}
+	result.Route = append(result.Route, "deposit")
+	result.Simulation = result.Simulation.merge(deposit.Simulation)
+	return result, nil
+}
+
+// planZap sizes the swap and deposit legs of a zap from the pool's reserves
+func (s *Service) planZap(params ZapDepositParams) (*zapPlan, error) {
+	if params.AssetIn == params.PairAsset {
+		return nil, fmt.Errorf("cannot zap asset into a pool with itself")
+	}
+	if params.AmountIn < 2 {
+		return nil, fmt.Errorf("amount in must be at least 2")
+	}
+	if s.poolQuerier == nil {
+		return nil, fmt.Errorf("pool querier not configured")
+	}
+
+	pool, err := s.findPool(params.AssetIn, params.PairAsset)
+	if err != nil {
+		return nil, err
+	}
+	reserveIn, reserveOut, _ := orientPool(*pool, params.AssetIn)
+	if reserveIn == 0 || reserveOut == 0 {
+		return nil, fmt.Errorf("pool %s has no liquidity", pool.ID)
+	}
+
+	swapAmount := uint64(params.AmountIn / 2)
+	keep := uint64(params.AmountIn) - swapAmount
+
+	out, fee := constantProductOut(swapAmount, reserveIn, reserveOut, pool.Fee)
+	minOut := (&Quote{AmountOut: int64(out)}).MinimumReceived(params.MaxSlippage)
+	if minOut <= 0 {
+		return nil, fmt.Errorf("swapping %d %s returns nothing", swapAmount, params.AssetIn)
+	}
+
+	// Deposit at the pool's ratio after the swap. The contract mints LP for
+	// the smaller side and keeps any excess, so the sides must match.
+	postIn := reserveIn + swapAmount - fee // Fees are held outside the reserves
+	postOut := reserveOut - out
+	depositIn, depositOut := keep, proRata(postOut, keep, postIn)
+	if depositOut > uint64(minOut) {
+		depositOut = uint64(minOut)
+		depositIn = proRata(postIn, depositOut, postOut)
+	}
+	if depositIn == 0 || depositOut == 0 {
+		return nil, fmt.Errorf("amount in is too small to deposit")
+	}
+
+	return &zapPlan{
+		Swap: SwapParams{
+			Sender:       params.Sender,
+			AssetIn:      params.AssetIn,
+			AssetOut:     params.PairAsset,
+			AmountIn:     int64(swapAmount),
+			MinAmountOut: minOut,
+			MaxSlippage:  params.MaxSlippage,
+		},
+		Deposit: DepositParams{
+			Sender:    params.Sender,
+			AssetIn:   params.AssetIn,
+			AssetOut:  params.PairAsset,
+			AmountIn:  int64(depositIn),
+			AmountOut: int64(depositOut),
+		},
+	}, nil
+}
@@ -0,0 +1,99 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func zapTestPools() *mockPoolQuerier {
+	return &mockPoolQuerier{pools: []IndexerPoolInfo{
+		{ID: "pool-1", Asset0: "HBD", Asset1: "HIVE", Reserve0: 1000000, Reserve1: 500000, Fee: 8, TotalSupply: 700000},
+	}}
+}
+
+func TestPlanZap(t *testing.T) {
+	svc := NewService(VSCConfig{}, &mockDEXExecutor{})
+	svc.SetPoolQuerier(zapTestPools())
+
+	plan, err := svc.planZap(ZapDepositParams{Sender: "alice", AssetIn: "HIVE", PairAsset: "HBD", AmountIn: 100001, MaxSlippage: 50})
+	require.NoError(t, err)
+
+	// Half is swapped, rounding down
+	assert.Equal(t, int64(50000), plan.Swap.AmountIn)
+	assert.Equal(t, "HBD", plan.Swap.AssetOut)
+
+	out, fee := constantProductOut(50000, 500000, 1000000, 8)
+	assert.Equal(t, (&Quote{AmountOut: int64(out)}).MinimumReceived(50), plan.Swap.MinAmountOut)
+
+	// The deposit never uses more than was kept or is guaranteed by the swap,
+	// and matches the pool's post-swap ratio
+	assert.LessOrEqual(t, plan.Deposit.AmountIn, int64(50001))
+	assert.LessOrEqual(t, plan.Deposit.AmountOut, plan.Swap.MinAmountOut)
+	postIn, postOut := float64(500000+50000-fee), float64(1000000-out)
+	assert.InDelta(t, postOut/postIn, float64(plan.Deposit.AmountOut)/float64(plan.Deposit.AmountIn), 0.001)
+}
+
+func TestPlanZapValidation(t *testing.T) {
+	svc := NewService(VSCConfig{}, &mockDEXExecutor{})
+
+	_, err := svc.planZap(ZapDepositParams{AssetIn: "HIVE", PairAsset: "HBD", AmountIn: 1000})
+	assert.EqualError(t, err, "pool querier not configured")
+
+	svc.SetPoolQuerier(zapTestPools())
+	_, err = svc.planZap(ZapDepositParams{AssetIn: "HIVE", PairAsset: "HIVE", AmountIn: 1000})
+	assert.Error(t, err)
+	_, err = svc.planZap(ZapDepositParams{AssetIn: "HIVE", PairAsset: "HBD", AmountIn: 1})
+	assert.Error(t, err)
+	_, err = svc.planZap(ZapDepositParams{AssetIn: "HIVE", PairAsset: "BTC", AmountIn: 1000})
+	assert.EqualError(t, err, "no pool found for HIVE/BTC")
+}
+
+func TestExecuteZapDepositBatched(t *testing.T) {
+	executor := &mockBatchExecutor{}
+	svc := NewService(VSCConfig{}, executor)
+	svc.SetPoolQuerier(zapTestPools())
+
+	result, err := svc.ExecuteZapDeposit(context.Background(), ZapDepositParams{Sender: "alice", AssetIn: "HIVE", PairAsset: "HBD", AmountIn: 100000, MaxSlippage: 50})
+	require.NoError(t, err)
+	require.True(t, result.Success, result.ErrorMessage)
+	assert.Equal(t, "batch-tx-1", result.TxID)
+	assert.Equal(t, []string{"HIVE", "HBD", "deposit"}, result.Route)
+
+	require.Len(t, executor.batches, 1)
+	ops := executor.batches[0]
+	require.Len(t, ops, 2)
+	assert.Contains(t, ops[0].Payload, `"type":"swap"`)
+
+	// Deposit amounts are given in pool order: HBD is asset0
+	var deposit struct {
+		Type     string            `json:"type"`
+		Metadata map[string]string `json:"metadata"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(ops[1].Payload), &deposit))
+	assert.Equal(t, "deposit", deposit.Type)
+	assert.Equal(t, intentLimits(ops[1].Intents)["HBD"], deposit.Metadata["amount0"])
+	assert.Equal(t, intentLimits(ops[1].Intents)["HIVE"], deposit.Metadata["amount1"])
+
+	hiveIn, _ := strconv.ParseInt(deposit.Metadata["amount1"], 10, 64)
+	assert.LessOrEqual(t, hiveIn, int64(50000))
+}
+
+func TestExecuteZapDepositSequential(t *testing.T) {
+	executor := &mockDEXExecutor{}
+	svc := NewService(VSCConfig{}, executor)
+	svc.SetPoolQuerier(zapTestPools())
+
+	result, err := svc.ExecuteZapDeposit(context.Background(), ZapDepositParams{Sender: "alice", AssetIn: "HIVE", PairAsset: "HBD", AmountIn: 100000})
+	require.NoError(t, err)
+	require.True(t, result.Success, result.ErrorMessage)
+
+	require.Len(t, executor.executedOperations, 2)
+	assert.True(t, strings.Contains(executor.executedOperations[0], `"type":"swap"`))
+	assert.True(t, strings.Contains(executor.executedOperations[1], `"type":"deposit"`))
+}